@@ -11,6 +11,7 @@ import (
 	"github.com/julianshen/rubichan/internal/agent"
 	"github.com/julianshen/rubichan/internal/folderaccess"
 	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/skills/mcpbackend"
 	"github.com/julianshen/rubichan/internal/tools"
 	"github.com/julianshen/rubichan/internal/tools/xcode"
 )
@@ -93,10 +94,11 @@ func runACP() error {
 		return err
 	}
 
-	p, err := provider.NewProviderWithDebug(cfg, debugMode)
+	p, err := newLLMProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("creating provider: %w", err)
 	}
+	meter := agent.NewUsageMeter()
 
 	// Detected once and used twice: tool registration reads it, and the agent
 	// needs it too. Interactive, headless and shell all pass it through
@@ -129,7 +131,7 @@ func runACP() error {
 
 	// --skills was read for the apple-dev check above and otherwise ignored, so
 	// a client launching with --skills got none of them.
-	rt, skillCloser, err := createSkillRuntime(ctx, registry, p, cfg, "acp", cwd, cfgDir)
+	rt, skillCloser, err := createSkillRuntime(ctx, registry, meter.Wrap(p, cfg.Provider.Model), cfg, "acp", cwd, cfgDir)
 	if err != nil {
 		return fmt.Errorf("creating skill runtime: %w", err)
 	}
@@ -162,11 +164,37 @@ func runACP() error {
 		agent.WithCapabilities(modelCaps),
 		agent.WithApprovalChecker(composite),
 		agent.WithSkillRuntime(rt),
+		agent.WithMentionResolver(mcpbackend.MentionResolver(rt)),
 		agent.WithToolMiddlewares(pipeline.Middlewares),
+		agent.WithUsageMeter(meter),
+		agent.WithCustomCommands(loadCustomCommands(cfg, cwd, cfgDir)),
 	)
+	if err := registerACPAgentTools(registry, toolsCfg, a); err != nil {
+		return err
+	}
 
 	// Signal-cancellable, not a timeout: an ACP connection lives as long as the
 	// client keeps it open, and --timeout governs a single headless run, so it
 	// would cut a live editor session off mid-conversation.
 	return agent.ServeACP(ctx, a, os.Stdin, os.Stdout)
 }
+
+// registerACPAgentTools registers the tools that need the constructed agent.
+// The todo tool is the one that matters to a client: its changes reach the
+// editor as plan session/updates, so leaving it out left ACP without a plan.
+//
+// ask_user is deliberately not registered. Every answer it can give comes
+// from a UIRequestHandler, and ACP has no method to carry one: the agent
+// does not implement session/request_permission, which could at most offer
+// fixed choices, and ACP defines nothing for a free-text question. With no
+// handler the tool can only report that nobody is available, and offering
+// the model a question it can never have answered would just cost a turn.
+// The model asks in its reply instead, which the client does show.
+func registerACPAgentTools(registry *tools.Registry, toolsCfg ToolsConfig, a *agent.Agent) error {
+	if toolsCfg.ShouldEnable(tools.TodoName) {
+		if err := registry.Register(tools.NewTodoTool(a.TodoAccess())); err != nil {
+			return fmt.Errorf("registering todo tool: %w", err)
+		}
+	}
+	return nil
+}
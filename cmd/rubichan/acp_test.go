@@ -1,8 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/acp"
+	"github.com/julianshen/rubichan/internal/agent"
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestACPRequiresExplicitToolConsent pins the safety posture of the ACP mode.
@@ -66,3 +82,140 @@ func TestACPApproveCwdIsNotToolConsent(t *testing.T) {
 		t.Errorf("the refusal must say why --approve-cwd is not enough, got: %v", err)
 	}
 }
+
+// TestACPTodoCallReachesTheClientAsAPlan drives a todo tool call through a
+// served ACP connection wired the way runACP wires it, and checks the client
+// is told the plan. Registering the tool is the part runACP once skipped.
+func TestACPTodoCallReachesTheClientAsAPlan(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agent.MaxTurns = 5
+	p := &scriptedACPProvider{responses: [][]provider.StreamEvent{
+		{
+			{Type: "tool_use", ToolUse: &provider.ToolUseBlock{ID: "tool_1", Name: tools.TodoName}},
+			{Type: "text_delta", Text: `{"action":"set","items":[{"content":"write test","status":"in_progress"},{"content":"fix"}]}`},
+			{Type: "stop"},
+		},
+		{{Type: "text_delta", Text: "planned"}, {Type: "stop"}},
+	}}
+	cwd := t.TempDir()
+	registry := tools.NewRegistry()
+	a := agent.New(p, registry, func(context.Context, string, json.RawMessage) (bool, error) { return true, nil }, cfg,
+		agent.WithWorkingDir(cwd))
+	require.NoError(t, registerACPAgentTools(registry, ToolsConfig{}, a))
+	_, hasAskUser := registry.Get(tools.AskUserName)
+	assert.False(t, hasAskUser, "ACP cannot carry a question to the user, so ask_user is not offered")
+
+	serverR, clientW := io.Pipe()
+	clientR, serverW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = agent.ServeACP(ctx, a, serverR, serverW)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		_ = clientW.Close()
+		_ = serverW.Close()
+		<-done
+	})
+
+	enc := json.NewEncoder(clientW)
+	lines := make(chan []byte)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(clientR)
+		for sc.Scan() {
+			lines <- append([]byte(nil), sc.Bytes()...)
+		}
+	}()
+	type message struct {
+		ID     *int             `json:"id"`
+		Method string           `json:"method"`
+		Params json.RawMessage  `json:"params"`
+		Result *json.RawMessage `json:"result"`
+		Error  *acp.RPCError    `json:"error"`
+	}
+	// call sends a request and returns its result along with every
+	// notification that arrived before it.
+	call := func(id int, method string, params any) (json.RawMessage, []message) {
+		t.Helper()
+		raw, err := json.Marshal(params)
+		require.NoError(t, err)
+		go func() { _ = enc.Encode(acp.Request{JSONRPC: "2.0", ID: id, Method: method, Params: raw}) }()
+		var notes []message
+		for {
+			select {
+			case line, ok := <-lines:
+				require.True(t, ok, "the connection closed before answering %s", method)
+				var msg message
+				require.NoError(t, json.Unmarshal(line, &msg))
+				if msg.Method != "" {
+					notes = append(notes, msg)
+					continue
+				}
+				require.Nil(t, msg.Error, "%s failed: %v", method, msg.Error)
+				require.NotNil(t, msg.Result)
+				return *msg.Result, notes
+			case <-time.After(5 * time.Second):
+				t.Fatalf("no answer to %s within 5s", method)
+			}
+		}
+	}
+
+	call(1, "initialize", map[string]any{"protocolVersion": 1, "clientCapabilities": map[string]any{}})
+	raw, _ := call(2, "session/new", map[string]any{"cwd": cwd, "mcpServers": []any{}})
+	var created acp.NewSessionResult
+	require.NoError(t, json.Unmarshal(raw, &created))
+
+	raw, notes := call(3, "session/prompt", map[string]any{
+		"sessionId": created.SessionID,
+		"prompt":    []any{map[string]any{"type": "text", "text": "plan it"}},
+	})
+	var stop acp.PromptResult
+	require.NoError(t, json.Unmarshal(raw, &stop))
+	assert.Equal(t, acp.StopEndTurn, stop.StopReason)
+
+	var plans [][]acp.PlanEntry
+	for _, n := range notes {
+		var params struct {
+			Update struct {
+				SessionUpdate string          `json:"sessionUpdate"`
+				Entries       []acp.PlanEntry `json:"entries"`
+			} `json:"update"`
+		}
+		require.NoError(t, json.Unmarshal(n.Params, &params))
+		if params.Update.SessionUpdate == "plan" {
+			plans = append(plans, params.Update.Entries)
+		}
+	}
+	require.Len(t, plans, 1, "the todo call reaches the client as one plan update")
+	assert.Equal(t, []acp.PlanEntry{
+		{Content: "write test", Priority: acp.PlanPriorityMedium, Status: acp.PlanEntryStatus(agentsdk.PlanEntryInProgress)},
+		{Content: "fix", Priority: acp.PlanPriorityMedium, Status: acp.PlanEntryStatus(agentsdk.PlanEntryPending)},
+	}, plans[0])
+}
+
+// scriptedACPProvider answers each call with the next scripted response.
+type scriptedACPProvider struct {
+	mu        sync.Mutex
+	responses [][]provider.StreamEvent
+}
+
+func (p *scriptedACPProvider) Stream(context.Context, provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.responses) == 0 {
+		return nil, fmt.Errorf("scriptedACPProvider: no more responses")
+	}
+	events := p.responses[0]
+	p.responses = p.responses[1:]
+	ch := make(chan provider.StreamEvent, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (p *scriptedACPProvider) Name() string { return "scripted" }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/pkg/provider/cassette"
+)
+
+// newLLMProvider creates the session's LLM provider. With --record the
+// configured provider is wrapped so every call lands in a cassette; with
+// --replay the cassette answers instead and no provider (or API key) is
+// needed at all.
+func newLLMProvider(cfg *config.Config) (provider.LLMProvider, error) {
+	if recordFlag != "" && replayFlag != "" {
+		return nil, fmt.Errorf("--record and --replay cannot be used together")
+	}
+	if replayFlag != "" {
+		return cassette.Load(replayFlag, cassetteOptions()...)
+	}
+	p, err := provider.NewProviderWithDebug(cfg, debugMode)
+	if err != nil {
+		return nil, err
+	}
+	if recordFlag != "" {
+		return cassette.NewRecorder(p, recordFlag, cassetteOptions()...)
+	}
+	return p, nil
+}
+
+// cassetteOptions replaces the working and home directories with
+// placeholders, so a session recorded in one checkout replays in another.
+func cassetteOptions() []cassette.Option {
+	var opts []cassette.Option
+	if cwd, err := os.Getwd(); err == nil {
+		opts = append(opts, cassette.WithReplacement(cwd, "<workdir>"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		opts = append(opts, cassette.WithReplacement(home, "<home>"))
+	}
+	return opts
+}
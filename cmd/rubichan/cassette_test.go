@@ -0,0 +1,50 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/pkg/provider/cassette"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withCassetteFlags(t *testing.T, record, replay string) {
+	t.Helper()
+	oldRecord, oldReplay := recordFlag, replayFlag
+	recordFlag, replayFlag = record, replay
+	t.Cleanup(func() { recordFlag, replayFlag = oldRecord, oldReplay })
+}
+
+func TestNewLLMProviderRejectsRecordAndReplay(t *testing.T) {
+	withCassetteFlags(t, "a.json", "b.json")
+	_, err := newLLMProvider(config.DefaultConfig())
+	assert.ErrorContains(t, err, "cannot be used together")
+}
+
+func TestNewLLMProviderReplayNeedsNoCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, (&cassette.Cassette{Version: cassette.Version}).Write(path))
+	withCassetteFlags(t, "", path)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "anthropic"
+	p, err := newLLMProvider(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &cassette.Replayer{}, p)
+}
+
+func TestNewLLMProviderRecordWrapsProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	withCassetteFlags(t, path, "")
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "anthropic"
+	p, err := newLLMProvider(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &cassette.Recorder{}, p)
+	assert.FileExists(t, path)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/julianshen/rubichan/internal/commands"
+	"github.com/julianshen/rubichan/internal/config"
+)
+
+// loadCustomCommands reads the markdown slash commands in .agent/commands
+// and <cfgDir>/commands. Files that fail to parse are logged and skipped.
+// Project commands may inline shell output only when project hooks are
+// trusted, since both run code checked in by someone else.
+func loadCustomCommands(cfg *config.Config, cwd, cfgDir string) []*commands.CustomCommand {
+	cmds, err := commands.LoadCustomCommands(commands.CustomCommandOptions{
+		ProjectDir:   cwd,
+		UserDir:      filepath.Join(cfgDir, "commands"),
+		TrustProject: cfg.Hooks.TrustProjectHooks,
+	})
+	if err != nil {
+		log.Printf("warning: loading custom commands: %v", err)
+	}
+	return cmds
+}
+
+// registerCustomCommands adds custom commands to a registry that already
+// holds the built-ins, so a file reusing a built-in name is reported and
+// skipped rather than shadowing the built-in.
+func registerCustomCommands(reg *commands.Registry, cmds []*commands.CustomCommand) {
+	for _, cmd := range cmds {
+		if err := reg.Register(cmd); err != nil {
+			log.Printf("warning: skipping custom command %s: %v", cmd.Path(), err)
+		}
+	}
+}
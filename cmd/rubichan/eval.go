@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/eval"
+	"github.com/julianshen/rubichan/internal/integrations"
+)
+
+func evalCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Run benchmark suites and compare the scores",
+	}
+	cmd.AddCommand(evalRunCmd(), evalCompareCmd())
+	return cmd
+}
+
+func evalRunCmd() *cobra.Command {
+	var (
+		parallel      int
+		label         string
+		outDir        string
+		judgeModel    string
+		keepWorktrees bool
+	)
+	cmd := &cobra.Command{
+		Use:   "run <suite.yaml>",
+		Short: "Run an eval suite and write a scored report",
+		Long: `Run every task of an eval suite and write a scored report.
+
+Each task starts from its repo fixture in a fresh git worktree, runs its
+prompt as a headless, auto-approved session with the configured model, and
+is scored by its graders: shell commands, file assertions, regexes over the
+answer, or an LLM judge. Plain directories are copied to a throwaway git
+repository first. The report is written as JSON and markdown to --out, and
+the markdown compares the run with earlier reports of the same suite.
+
+The global --model, --provider, --config, --api-base and --api-key flags
+select the configuration under test.
+
+Examples:
+  rubichan eval run evals/core.yaml
+  rubichan eval run evals/core.yaml --model claude-sonnet-4-5 --parallel 4
+  rubichan eval run evals/core.yaml --label "new system prompt" --keep-worktrees`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			suite, err := eval.LoadSuite(args[0])
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			self, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("locating rubichan binary: %w", err)
+			}
+
+			r := &eval.Runner{
+				Executor: &eval.CommandExecutor{
+					Path: self,
+					Args: evalForwardedFlags(cfg),
+					Env:  evalForwardedEnv(cfg),
+				},
+				Parallel:      parallel,
+				KeepWorktrees: keepWorktrees,
+				Label:         label,
+				Model:         cfg.Provider.Model,
+				Provider:      cfg.Provider.Default,
+				Progress:      func(t eval.TaskResult) { printEvalProgress(os.Stderr, t) },
+			}
+			if r.Label == "" {
+				r.Label = cfg.Provider.Default + "/" + cfg.Provider.Model
+			}
+			if suiteUsesJudge(suite) {
+				p, err := newLLMProvider(cfg)
+				if err != nil {
+					return fmt.Errorf("creating judge provider: %w", err)
+				}
+				model := judgeModel
+				if model == "" {
+					model = cfg.Provider.Model
+				}
+				r.Judge = integrations.NewLLMCompleter(p, model)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			fmt.Fprintf(os.Stderr, "Running %d task(s) of %q with %s\n", len(suite.Tasks), suite.Name, r.Label)
+			rep, err := r.Run(ctx, suite)
+			if err != nil {
+				return err
+			}
+
+			history, err := eval.LoadHistory(outDir, suite.Name)
+			if err != nil {
+				return err
+			}
+			jsonPath, mdPath, err := rep.Write(outDir, history)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Score %.0f%% (%d/%d tasks passed)\n", rep.Score*100, rep.Passed, rep.Total)
+			fmt.Printf("Report: %s\n        %s\n", mdPath, jsonPath)
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&parallel, "parallel", 1, "number of tasks to run at once")
+	cmd.Flags().StringVar(&label, "label", "", "name for this run in reports (default provider/model)")
+	cmd.Flags().StringVar(&outDir, "out", ".rubichan/evals", "directory reports are written to and compared against")
+	cmd.Flags().StringVar(&judgeModel, "judge-model", "", "model for llm graders (default the model under test)")
+	cmd.Flags().BoolVar(&keepWorktrees, "keep-worktrees", false, "keep each task's worktree for inspection")
+	return cmd
+}
+
+func evalCompareCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "compare <report.json>...",
+		Short: "Compare eval reports side by side",
+		Long: `Print a markdown table comparing eval reports, oldest first, with the
+overall score of each run and every task's result per run.
+
+Examples:
+  rubichan eval compare .rubichan/evals/core-*.json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			var reports []*eval.Report
+			for _, path := range args {
+				r, err := eval.LoadReport(path)
+				if err != nil {
+					return err
+				}
+				reports = append(reports, r)
+			}
+			fmt.Print(eval.Compare(reports))
+			return nil
+		},
+	}
+}
+
+// evalForwardedFlags passes the configuration under test on to each task's
+// headless run. The model is resolved here so every task uses the same one.
+func evalForwardedFlags(cfg *config.Config) []string {
+	var args []string
+	add := func(flag, value string) {
+		if value != "" {
+			args = append(args, "--"+flag, value)
+		}
+	}
+	if configPath != "" {
+		// Tasks run in their worktrees, so a relative path would not resolve.
+		if abs, err := filepath.Abs(configPath); err == nil {
+			add("config", abs)
+		}
+	}
+	add("provider", cfg.Provider.Default)
+	add("model", cfg.Provider.Model)
+	add("api-base", apiBaseFlag)
+	return args
+}
+
+// evalForwardedEnv passes --api-key on to each task's headless run through
+// the provider's API key environment variable, which keeps the key off the
+// child's argv where any local user could read it.
+func evalForwardedEnv(cfg *config.Config) []string {
+	if apiKeyFlag == "" {
+		return nil
+	}
+	name := apiKeyEnvVar(cfg.Provider.Default)
+	if name == "" {
+		return nil
+	}
+	return []string{name + "=" + apiKeyFlag}
+}
+
+// apiKeyEnvVar returns the environment variable the config loader reads the
+// named provider's API key from, or "" for providers without one.
+func apiKeyEnvVar(provider string) string {
+	switch provider {
+	case "anthropic":
+		return "ANTHROPIC_API_KEY"
+	case "zai":
+		return "Z_AI_API_KEY"
+	case "ollama":
+		return ""
+	}
+	return config.OpenAICompatibleEnvVar(provider)
+}
+
+func suiteUsesJudge(s *eval.Suite) bool {
+	for _, t := range s.Tasks {
+		for _, g := range t.Graders {
+			if g.Type == eval.GraderLLM {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func printEvalProgress(w io.Writer, t eval.TaskResult) {
+	status := "pass"
+	if !t.Passed {
+		status = "FAIL"
+	}
+	line := fmt.Sprintf("  %s %-30s %3.0f%%", status, t.ID, t.Score*100)
+	if t.Error != "" {
+		line += "  " + strings.SplitN(t.Error, "\n", 2)[0]
+	}
+	fmt.Fprintln(w, line)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/eval"
+)
+
+func TestEvalCmdFlags(t *testing.T) {
+	cmd := evalCmd()
+	run, _, err := cmd.Find([]string{"run"})
+	require.NoError(t, err)
+	for _, name := range []string{"parallel", "label", "out", "judge-model", "keep-worktrees"} {
+		assert.NotNil(t, run.Flags().Lookup(name), "missing flag %q", name)
+	}
+	assert.Equal(t, ".rubichan/evals", run.Flags().Lookup("out").DefValue)
+
+	compare, _, err := cmd.Find([]string{"compare"})
+	require.NoError(t, err)
+	assert.Equal(t, "compare", compare.Name())
+}
+
+func TestEvalForwardedFlags(t *testing.T) {
+	oldConfig, oldBase, oldKey := configPath, apiBaseFlag, apiKeyFlag
+	t.Cleanup(func() { configPath, apiBaseFlag, apiKeyFlag = oldConfig, oldBase, oldKey })
+	configPath, apiBaseFlag, apiKeyFlag = "conf.toml", "", "sk-secret"
+
+	cfg := &config.Config{}
+	cfg.Provider.Default = "anthropic"
+	cfg.Provider.Model = "claude-x"
+	abs, err := filepath.Abs("conf.toml")
+	require.NoError(t, err)
+	args := evalForwardedFlags(cfg)
+	assert.Equal(t, []string{"--config", abs, "--provider", "anthropic", "--model", "claude-x"}, args)
+	for _, arg := range args {
+		assert.NotContains(t, arg, "sk-secret", "the API key must not be on argv")
+	}
+	assert.Equal(t, []string{"ANTHROPIC_API_KEY=sk-secret"}, evalForwardedEnv(cfg))
+}
+
+func TestEvalForwardedEnv(t *testing.T) {
+	oldKey := apiKeyFlag
+	t.Cleanup(func() { apiKeyFlag = oldKey })
+
+	cfg := &config.Config{}
+	apiKeyFlag = ""
+	cfg.Provider.Default = "anthropic"
+	assert.Nil(t, evalForwardedEnv(cfg), "no --api-key leaves the environment alone")
+
+	apiKeyFlag = "k"
+	for provider, want := range map[string][]string{
+		"zai":        {"Z_AI_API_KEY=k"},
+		"openrouter": {"OPENROUTER_API_KEY=k"},
+		"my-server":  {"MY_SERVER_API_KEY=k"},
+		"ollama":     nil,
+	} {
+		cfg.Provider.Default = provider
+		assert.Equal(t, want, evalForwardedEnv(cfg), provider)
+	}
+}
+
+func TestSuiteUsesJudge(t *testing.T) {
+	s := &eval.Suite{Tasks: []eval.Task{{Graders: []eval.GraderSpec{{Type: eval.GraderRegex}}}}}
+	assert.False(t, suiteUsesJudge(s))
+	s.Tasks[0].Graders = append(s.Tasks[0].Graders, eval.GraderSpec{Type: eval.GraderLLM})
+	assert.True(t, suiteUsesJudge(s))
+}
+
+func TestPrintEvalProgress(t *testing.T) {
+	var buf bytes.Buffer
+	printEvalProgress(&buf, eval.TaskResult{ID: "fix", Score: 0.5, Error: "timed out after 1m\nmore"})
+	assert.Contains(t, buf.String(), "FAIL fix")
+	assert.Contains(t, buf.String(), " 50%")
+	assert.Contains(t, buf.String(), "timed out after 1m")
+	assert.NotContains(t, buf.String(), "more")
+}
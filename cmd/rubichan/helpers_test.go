@@ -165,6 +165,12 @@ func TestApplyHeadlessBootstrapProbePromptDisabled(t *testing.T) {
 	assert.Equal(t, prompt, got)
 }
 
+func TestApplyStructuredOutputPrompt(t *testing.T) {
+	got := applyStructuredOutputPrompt("Classify this issue\n")
+	assert.True(t, strings.HasPrefix(got, "Classify this issue\n\nAnswer format requirement:"))
+	assert.Contains(t, got, "structured_output tool exactly once")
+}
+
 func TestValidateHeadlessBootstrapProbe(t *testing.T) {
 	ok := &output.RunResult{
 		ToolCalls: []output.ToolCallLog{
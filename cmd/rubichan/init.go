@@ -27,7 +27,7 @@ func initCmd() *cobra.Command {
 		Use:   "init",
 		Short: "Initialize a project with AGENT.md and .agent/ structure",
 		Long: `Scans the codebase, generates an AGENT.md with project-specific rules,
-and creates the .agent/ directory structure (skills/, hooks/).
+and creates the .agent/ directory structure (skills/, hooks/, commands/).
 
 Uses detected build systems, test frameworks, and linter configs to populate
 AGENT.md sections. Sections that cannot be auto-detected use TODO placeholders.`,
@@ -45,7 +45,7 @@ AGENT.md sections. Sections that cannot be auto-detected use TODO placeholders.`
 				return runSetupHooks(cmd.Context(), cmd.OutOrStdout(), dir, trustHooks)
 			}
 
-			for _, sub := range []string{".agent/skills", ".agent/hooks"} {
+			for _, sub := range []string{".agent/skills", ".agent/hooks", ".agent/commands"} {
 				if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
 					return fmt.Errorf("creating %s: %w", sub, err)
 				}
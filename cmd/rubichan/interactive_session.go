@@ -0,0 +1,590 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/julianshen/rubichan/internal/agent"
+	"github.com/julianshen/rubichan/internal/checkpoint"
+	"github.com/julianshen/rubichan/internal/cmux"
+	"github.com/julianshen/rubichan/internal/commands"
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/diag"
+	"github.com/julianshen/rubichan/internal/folderaccess"
+	"github.com/julianshen/rubichan/internal/hooks"
+	"github.com/julianshen/rubichan/internal/integrations"
+	"github.com/julianshen/rubichan/internal/knowledgegraph"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/session"
+	"github.com/julianshen/rubichan/internal/skills/mcpbackend"
+	"github.com/julianshen/rubichan/internal/store"
+	"github.com/julianshen/rubichan/internal/subagents"
+	"github.com/julianshen/rubichan/internal/terminal"
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/internal/tools/xcode"
+	"github.com/julianshen/rubichan/internal/tui"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// interactiveEnv is what the sessions of one interactive run share: the
+// run's context, config, provider and conversation store. Each TUI tab is
+// a session built from it.
+type interactiveEnv struct {
+	ctx                context.Context
+	cfg                *config.Config
+	cfgDir             string
+	cfgPath            string
+	provider           provider.LLMProvider
+	store              *store.Store
+	caps               *terminal.Caps
+	cmux               cmux.Caller
+	structuredEventLog *diag.EventLogger
+
+	// plain runs the line-oriented host instead of the TUI.
+	plain bool
+	// appleSkillRequested records whether --skills named apple-dev before
+	// runInteractive removed it from the list.
+	appleSkillRequested bool
+	// checkFolder is set until the first session has confirmed folder
+	// access.
+	checkFolder bool
+	// tabs registers /tab in each session's commands when set.
+	tabs commands.TabController
+	// out receives warnings and notices from session setup and teardown.
+	out io.Writer
+}
+
+// interactiveSession is one agent session: its TUI model (or plain host),
+// the agent, and the cleanups that release what the session set up.
+type interactiveSession struct {
+	model     *tui.Model
+	plainHost *plainInteractiveHost
+	agent     *agent.Agent
+	cleanups  []func()
+}
+
+// close runs the session's cleanups, most recent first.
+func (sess *interactiveSession) close() {
+	for i := len(sess.cleanups) - 1; i >= 0; i-- {
+		sess.cleanups[i]()
+	}
+	sess.cleanups = nil
+}
+
+// newSession sets up a session for spec: its working directory (a git
+// worktree when spec.Worktree is set), tools, skills, agent and model.
+// spec.SessionID resumes a stored conversation.
+func (env *interactiveEnv) newSession(spec tui.TabSpec) (sess *interactiveSession, err error) {
+	sess = &interactiveSession{}
+	defer func() {
+		if err != nil {
+			sess.close()
+		}
+	}()
+	runCtx := env.ctx
+	cfg := env.cfg
+	cfgDir, cfgPath := env.cfgDir, env.cfgPath
+	p := env.provider
+	s := env.store
+	caps, cmuxClient := env.caps, env.cmux
+	structuredEventLog := env.structuredEventLog
+
+	// Provider calls made outside the agent loop (summaries, skill and wiki
+	// completions, subagents) are billed to the agent through the meter so
+	// the usage ledger and spending caps see all of the session's spend.
+	meter := agent.NewUsageMeter()
+
+	// Set up effective working directory (creates worktree if --worktree is set).
+	cwd, wtMgr, wtCleanup, err := setupWorktree(cfg, spec.Worktree, env.out)
+	if err != nil {
+		return nil, fmt.Errorf("worktree setup: %w", err)
+	}
+	sess.cleanups = append(sess.cleanups, wtCleanup)
+	if err := interactiveExitError(runCtx); err != nil {
+		return nil, err
+	}
+
+	// Detect and load bootstrap context if available
+	bootstrapPath := filepath.Join(cwd, ".knowledge", ".bootstrap.json")
+	var bootstrapContext *knowledgegraph.BootstrapMetadata
+	if _, err := os.Stat(bootstrapPath); err == nil {
+		ctx, err := agent.LoadBootstrapContext(bootstrapPath)
+		if err == nil {
+			bootstrapContext = ctx
+			// Remove marker so we don't re-inject on next run
+			markerPath := filepath.Join(cwd, ".knowledge", ".bootstrap-agent-start")
+			os.Remove(markerPath)
+		}
+	}
+
+	// Create tool registry
+	registry := tools.NewRegistry()
+	diffTracker := tools.NewDiffTracker()
+	allowed := parseToolsFlag(toolsFlag)
+	modelCaps := provider.DetectCapabilities(cfg.Provider.Default, cfg.Provider.Model)
+	toolsCfg := ToolsConfig{
+		ModelCapabilities: modelCaps,
+		ProjectContext: ProjectContext{
+			AppleProjectDetected: xcode.DiscoverProject(cwd).Type != "none",
+			AppleSkillRequested:  env.appleSkillRequested,
+		},
+		CLIOverrides: allowed,
+	}
+
+	coreResult, err := registerCoreTools(cwd, registry, cfg, toolsCfg, diffTracker, 120*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	sess.cleanups = append(sess.cleanups, coreResult.cleanups...)
+
+	// Auto-activate apple-dev Xcode tools if Apple project detected.
+	var opts []agent.AgentOption
+	opts = append(opts, agent.WithDiffTracker(diffTracker))
+	opts = appendWorkingDirOption(opts, cwd)
+	opts = append(opts, agent.WithCapabilities(modelCaps))
+
+	// Inject bootstrap context into system prompt if available
+	if bootstrapContext != nil {
+		opts = append(opts, agent.WithBootstrapContext(bootstrapContext))
+	}
+	if err := wireAppleDev(cwd, registry, toolsCfg); err != nil {
+		return nil, err
+	}
+
+	// Wire wiki skill (generate_wiki tool).
+	llmCompleter := integrations.NewLLMCompleter(meter.Wrap(p, cfg.Provider.Model), cfg.Provider.Model)
+	if err := wireWiki(cwd, registry, llmCompleter, toolsCfg); err != nil {
+		return nil, err
+	}
+
+	subagentWiring, err := subagents.Wire(subagents.Options{
+		Config:          cfg,
+		Registry:        registry,
+		EnableTask:      toolsCfg.ShouldEnable("task"),
+		EnableListTasks: toolsCfg.ShouldEnable("list_tasks"),
+		WorktreeManager: wtMgr,
+		GitRoot:         gitRepoRoot,
+		Logf:            log.Printf,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("wiring subagents: %w", err)
+	}
+	agentDefReg := subagentWiring.AgentDefs
+	wakeManager := subagentWiring.WakeManager
+	spawner := subagentWiring.Spawner
+
+	// Build the approval function. When --auto-approve is set, skip the TUI
+	// prompt entirely. Otherwise, defer to the TUI model's interactive prompt.
+	// The model is created first (with nil agent) so we can extract its
+	// approval function before constructing the agent.
+	var approvalFunc agent.ApprovalFunc
+	if autoApprove {
+		approvalFunc = func(_ context.Context, _ string, _ json.RawMessage) (bool, error) {
+			return true, nil
+		}
+	}
+
+	// Wire conversation persistence.
+	if env.checkFolder {
+		// Only the first session can ask: later tabs open while the TUI owns
+		// the terminal, in this folder or one of its worktrees.
+		if err := folderaccess.EnsureApprovedInteractive(s, cwd, os.Stdin, os.Stderr, autoApprove, approveCwd); err != nil {
+			return nil, err
+		}
+		env.checkFolder = false
+	}
+	opts = append(opts, agent.WithStore(s))
+	if spec.SessionID != "" {
+		opts = append(opts, agent.WithResumeSession(spec.SessionID))
+	}
+
+	opts = appendPersonaOptions(opts, cwd)
+	opts = appendKnowledgeGraphOption(runCtx, opts, cwd)
+	opts = append(opts, agent.WithMode("interactive"))
+
+	// Create skill runtime with built-in prompt skills and any explicit --skills.
+	rt, storeCloser, err := createSkillRuntime(runCtx, registry, meter.Wrap(p, cfg.Provider.Model), cfg, "interactive", cwd, cfgDir)
+	if err != nil {
+		return nil, fmt.Errorf("creating skill runtime: %w", err)
+	}
+	if storeCloser != nil {
+		sess.cleanups = append(sess.cleanups, func() { storeCloser.Close() })
+	}
+	if rt != nil {
+		emitSkillDiscoveryWarnings(env.out, rt)
+		opts = append(opts, agent.WithSkillRuntime(rt), agent.WithMentionResolver(mcpbackend.MentionResolver(rt)))
+	}
+
+	// Build user hooks from config, .agent/hooks.toml, and AGENT.md.
+	userHookConfigs := loadProjectHooks(cfg, s, cwd)
+	if len(userHookConfigs) > 0 {
+		opts = append(opts, agent.WithUserHooks(hooks.NewUserHookRunner(userHookConfigs, cwd)))
+	}
+
+	// Wire cross-session memory and summarizer.
+	summaryModel := cfg.Provider.SummaryModel
+	if summaryModel == "" {
+		summaryModel = cfg.Provider.Model
+	}
+	summarizer := agent.NewLLMSummarizer(meter.Wrap(p, summaryModel), summaryModel)
+	opts = append(opts, agent.WithSummarizer(summarizer))
+	opts = append(opts, agent.WithMemoryStore(&storeMemoryAdapter{store: s}))
+
+	// Create command registry and register built-in slash commands.
+	// Built-in registration failures indicate a programming bug (duplicate names).
+	cmdRegistry := commands.NewRegistry()
+	for _, cmd := range []commands.SlashCommand{
+		commands.NewQuitCommand(),
+		commands.NewExitCommand(),
+		commands.NewConfigCommand(),
+		commands.NewAboutCommand(),
+		commands.NewInitKnowledgeGraphCommand(),
+		commands.NewHelpCommand(cmdRegistry),
+		commands.NewInitCommand(cwd),
+	} {
+		if err := cmdRegistry.Register(cmd); err != nil {
+			return nil, fmt.Errorf("register built-in command %q: %w", cmd.Name(), err)
+		}
+	}
+
+	// Wire command registry into skill runtime so skill-contributed commands
+	// are registered on activation and unregistered on deactivation.
+	if rt != nil {
+		rt.SetCommandRegistry(cmdRegistry)
+		rt.SetAgentDefRegistrar(&agentDefRegistrarAdapter{reg: agentDefReg})
+	}
+
+	var plainHost *plainInteractiveHost
+	if env.plain {
+		plainHost = newPlainInteractiveHost(os.Stdin, os.Stdout, cfg.Provider.Model, cfg.Agent.MaxTurns, cmdRegistry)
+		plainHost.SetDebug(debugMode)
+		if rt != nil {
+			plainHost.SetSkillRuntime(rt)
+		}
+	}
+
+	// Create checkpoint manager for undo/rewind support.
+	cpSessionID := uuid.New().String()
+	cpMgr, err := checkpoint.New(cwd, cpSessionID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("create checkpoint manager: %w", err)
+	}
+	sess.cleanups = append(sess.cleanups, func() {
+		// The agent records checkpoints as each turn ends; save once more
+		// to catch an /undo made since. The manager's own copies go away
+		// with Cleanup.
+		if sess.agent != nil {
+			if err := sess.agent.SaveCheckpoints(); err != nil {
+				log.Printf("checkpoint save: %v", err)
+			}
+		}
+		if err := cpMgr.Cleanup(); err != nil {
+			log.Printf("checkpoint cleanup: %v", err)
+		}
+	})
+	// Whole-tree snapshots let /rewind undo shell and generator changes too;
+	// outside a git work tree, or past the size limits, rewind falls back to
+	// file-tool checkpoints only.
+	if cfg.Checkpoint.IsSnapshotsEnabled() {
+		limits := checkpoint.SnapshotLimits{MaxFiles: cfg.Checkpoint.MaxFiles(), MaxBytes: cfg.Checkpoint.MaxBytes()}
+		if err := cpMgr.EnableSnapshots(context.Background(), limits); err != nil {
+			log.Printf("checkpoint: %v", err)
+		}
+	}
+
+	// Create TUI model first (with nil agent) so we can extract the
+	// interactive approval function before constructing the agent.
+	model := tui.NewModel(nil, "rubichan", cfg.Provider.Model, cfg.Agent.MaxTurns, cfgPath, cfg, cmdRegistry)
+	model.SetTermCaps(caps)
+	model.SetCmuxClient(cmuxClient)
+	model.SetCheckpointManager(cpMgr) // TUI-only: enables /undo overlay; plainHost uses /rewind directly
+	model.SetSessionStore(s)
+	model.SetDebug(debugMode)
+	if rt != nil {
+		model.SetSkillSummaryProvider(rt)
+	}
+	sink := diag.BuildEventSink(structuredEventLog, debugMode)
+	model.SetEventSink(sink)
+	if plainHost != nil {
+		plainHost.SetEventSink(sink)
+	}
+	if plainTUI {
+		model.SetPlainMode(true)
+	}
+
+	// Set git branch in status bar if available.
+	if branch, err := detectGitBranch(cwd); err == nil && branch != "" {
+		model.SetGitBranch(branch)
+		if plainHost != nil {
+			plainHost.SetGitBranch(branch)
+		}
+	}
+
+	// Register commands that need model callbacks (these need the model instance).
+	if err := cmdRegistry.Register(commands.NewClearCommand(func() {
+		if model.GetAgent() != nil {
+			model.GetAgent().ClearConversation()
+		}
+		model.ClearContent()
+	})); err != nil {
+		return nil, fmt.Errorf("register built-in command %q: %w", "clear", err)
+	}
+	if err := cmdRegistry.Register(commands.NewRalphLoopCommand(model.StartRalphLoop)); err != nil {
+		return nil, fmt.Errorf("register built-in command %q: %w", "ralph-loop", err)
+	}
+	if err := cmdRegistry.Register(commands.NewCancelRalphCommand(model.CancelRalphLoop)); err != nil {
+		return nil, fmt.Errorf("register built-in command %q: %w", "cancel-ralph", err)
+	}
+	if err := cmdRegistry.Register(commands.NewModelCommand(func(name string) {
+		if model.GetAgent() != nil {
+			model.GetAgent().SetModel(name)
+		}
+		model.SwitchModel(name)
+		if plainHost != nil {
+			plainHost.SetModel(name)
+		}
+	})); err != nil {
+		return nil, fmt.Errorf("register built-in command %q: %w", "model", err)
+	}
+	if err := cmdRegistry.Register(commands.NewDebugVerificationSnapshotCommand(func() string {
+		if plainHost != nil {
+			return plainHost.DebugVerificationSnapshot()
+		}
+		return model.DebugVerificationSnapshot()
+	})); err != nil {
+		return nil, fmt.Errorf("register built-in command %q: %w", "debug-verification-snapshot", err)
+	}
+	if rt != nil {
+		if err := cmdRegistry.Register(commands.NewSkillCommand(&skillListerAdapter{rt: rt})); err != nil {
+			return nil, fmt.Errorf("register built-in command %q: %w", "skill", err)
+		}
+		if err := cmdRegistry.Register(commands.NewSkillLogCommand(&skillListerAdapter{rt: rt})); err != nil {
+			return nil, fmt.Errorf("register built-in command %q: %w", "skill-log", err)
+		}
+	}
+	if env.tabs != nil {
+		if err := cmdRegistry.Register(commands.NewTabCommand(env.tabs)); err != nil {
+			return nil, fmt.Errorf("register built-in command %q: %w", "tab", err)
+		}
+	}
+
+	// Register remaining commands that need post-model dependencies.
+	// NewUndoOverlayCommand handles /undo via the TUI overlay (shows checkpoint
+	// list). NewUndoCommand is not registered here to avoid a name collision —
+	// the overlay supersedes the direct command in interactive mode.
+	for _, cmd := range []commands.SlashCommand{
+		commands.NewResumeCommand(),
+		commands.NewSearchCommand(),
+		commands.NewUndoOverlayCommand(),
+		commands.NewRewindCommand(cpMgr),
+		commands.NewCheckpointCommand(cpMgr),
+		commands.NewContextCommand(func() agentsdk.ContextBudget {
+			if model.GetAgent() != nil {
+				return model.GetAgent().ContextBudget()
+			}
+			return agentsdk.ContextBudget{}
+		}),
+		commands.NewCompactCommand(func(ctx context.Context) (agentsdk.CompactResult, error) {
+			if model.GetAgent() != nil {
+				return model.GetAgent().ForceCompact(ctx)
+			}
+			return agentsdk.CompactResult{}, nil
+		}),
+		commands.NewSessionsCommand(func() ([]store.Session, error) {
+			if model.GetAgent() != nil {
+				return model.GetAgent().ListSessions(20)
+			}
+			return nil, nil
+		}),
+		commands.NewForkCommand(func(ctx context.Context) (string, error) {
+			if model.GetAgent() != nil {
+				return model.GetAgent().ForkSession(ctx)
+			}
+			return "", fmt.Errorf("no agent")
+		}),
+	} {
+		if err := cmdRegistry.Register(cmd); err != nil {
+			return nil, fmt.Errorf("register built-in command %q: %w", cmd.Name(), err)
+		}
+	}
+	registerCustomCommands(cmdRegistry, loadCustomCommands(cfg, cwd, cfgDir))
+
+	// Build tool execution slot middlewares first so the rule engine can
+	// feed the approval system. The agent composes the full pipeline.
+	pc := buildPipeline(registry, cfg, cwd, rt)
+	opts = append(opts, agent.WithToolMiddlewares(pc.Middlewares))
+
+	if !autoApprove {
+		if plainHost != nil {
+			approvalFunc = plainHost.MakeApprovalFunc()
+		} else {
+			// UIRequestHandler takes priority in the shared agentsdk.ApprovalFlow.
+			// Keep approvalFunc wired as a fallback for non-UI handler paths.
+			approvalFunc = model.MakeApprovalFunc()
+			opts = append(opts, agent.WithUIRequestHandler(model.MakeUIRequestHandler()))
+		}
+
+		// Build the approval checker: compose session cache, pipeline rule
+		// engine, and config-based trust rules. Session cache (TUI "always"
+		// decisions) is checked first, then the pipeline's rule engine
+		// (category-based allow rules), then config trust rules.
+		var checkers []agent.ApprovalChecker
+
+		// Hierarchical permission policies (org → project → user).
+		if hc := buildHierarchicalChecker(cfg, cfgPath, cwd); hc != nil {
+			checkers = append(checkers, hc)
+		}
+
+		if plainHost != nil {
+			checkers = append(checkers, plainHost)
+		} else {
+			checkers = append(checkers, model) // session cache
+		}
+		checkers = append(checkers, &ruleEngineChecker{
+			classifier: pc.Classifier,
+			engine:     pc.RuleEngine,
+		})
+		if len(cfg.Agent.TrustRules) > 0 {
+			regexRules, globRules := splitTrustRules(cfg.Agent.TrustRules)
+			if err := agent.ValidateTrustRules(regexRules, globRules); err != nil {
+				return nil, fmt.Errorf("invalid trust rules in config: %w", err)
+			}
+			checkers = append(checkers, agent.NewTrustRuleChecker(regexRules, globRules))
+		}
+		composite := agent.NewCompositeApprovalChecker(checkers...)
+		opts = append(opts, agent.WithApprovalChecker(composite))
+		spawner.ApprovalChecker = composite
+	} else {
+		// Auto-approve mode: still respect hierarchical deny policies.
+		var autoCheckers []agent.ApprovalChecker
+		if hc := buildHierarchicalChecker(cfg, cfgPath, cwd); hc != nil {
+			autoCheckers = append(autoCheckers, hc)
+		}
+		autoCheckers = append(autoCheckers, agent.AlwaysAutoApprove{})
+		composite := agent.NewCompositeApprovalChecker(autoCheckers...)
+		opts = append(opts, agent.WithApprovalChecker(composite))
+		spawner.ApprovalChecker = composite
+	}
+
+	// Attach the wake manager for background subagent notifications.
+	opts = append(opts, agent.WithWakeManager(wakeManager))
+
+	// Create shared rate limiter for throttling LLM API requests.
+	var rateLimiter *agent.SharedRateLimiter
+	if cfg.Agent.MaxRequestsPerMinute > 0 {
+		rateLimiter = agent.NewSharedRateLimiter(cfg.Agent.MaxRequestsPerMinute)
+	}
+	if rateLimiter != nil {
+		opts = append(opts, agent.WithRateLimiter(rateLimiter))
+	}
+
+	opts = append(opts, agent.WithCheckpointManager(cpMgr))
+
+	// Create agent with the approval function.
+	opts = append(opts, agent.WithUsageMeter(meter))
+	a := agent.New(p, registry, approvalFunc, cfg, opts...)
+
+	// Wire spawner dependencies that need the agent and provider.
+	spawner.Provider = p
+	spawner.ParentTools = registry
+	spawner.ParentSkillRuntime = rt
+	spawner.RateLimiter = rateLimiter
+	spawner.UsageMeter = meter
+
+	// Register notes tool backed by agent's scratchpad.
+	if toolsCfg.ShouldEnable("notes") {
+		if err := registry.Register(tools.NewNotesTool(a.ScratchpadAccess())); err != nil {
+			return nil, fmt.Errorf("registering notes tool: %w", err)
+		}
+	}
+
+	// Register todo tool backed by agent's todo list.
+	if toolsCfg.ShouldEnable(tools.TodoName) {
+		if err := registry.Register(tools.NewTodoTool(a.TodoAccess())); err != nil {
+			return nil, fmt.Errorf("registering todo tool: %w", err)
+		}
+	}
+
+	// Register task_complete tool for explicit loop termination.
+	if toolsCfg.ShouldEnable("task_complete") {
+		if err := registry.Register(tools.NewCompletionSignalTool()); err != nil {
+			return nil, fmt.Errorf("registering task_complete tool: %w", err)
+		}
+	}
+
+	// Register ask_user, answered by whichever UI hosts the session.
+	if toolsCfg.ShouldEnable(tools.AskUserName) {
+		askHandler := model.MakeUIRequestHandler()
+		if plainHost != nil {
+			askHandler = plainHost.MakeAskHandler()
+		}
+		if err := registry.Register(newAskUserTool(cfg, askHandler, sink, a)); err != nil {
+			return nil, fmt.Errorf("registering ask_user tool: %w", err)
+		}
+	}
+
+	// Register cmux tools when running inside cmux terminal.
+	if cmuxClient != nil {
+		cmuxTools := []tools.Tool{
+			tools.NewCmuxBrowserNavigate(cmuxClient),
+			tools.NewCmuxBrowserSnapshot(cmuxClient),
+			tools.NewCmuxBrowserClick(cmuxClient),
+			tools.NewCmuxBrowserType(cmuxClient),
+			tools.NewCmuxBrowserWait(cmuxClient),
+			tools.NewCmuxSplit(cmuxClient),
+			tools.NewCmuxSend(cmuxClient),
+			tools.NewCmuxOrchestrate(cmuxClient),
+		}
+		for _, t := range cmuxTools {
+			if toolsCfg.ShouldEnable(t.Name()) {
+				if err := registry.Register(t); err != nil {
+					log.Printf("warning: registering cmux tool %q: %v", t.Name(), err)
+				}
+			}
+		}
+	}
+
+	// Wire the agent into the TUI model now that both exist.
+	model.SetAgent(a)
+	model.SetWikiConfig(tui.WikiCommandConfig{
+		WorkDir: cwd,
+		LLM:     llmCompleter,
+	})
+	sess.model, sess.plainHost, sess.agent = model, plainHost, a
+	if plainHost != nil {
+		plainHost.SetAgent(a)
+		return sess, nil
+	}
+
+	// Index project files for @ mention autocomplete (background).
+	fileSrc := tui.NewFileCompletionSource(cwd)
+	model.SetFileCompletionSource(fileSrc)
+	go indexProjectFiles(cwd, fileSrc)
+
+	return sess, nil
+}
+
+// newAskUserTool creates the ask_user tool answered by handler, recording
+// every answer as a user_answer event on sink.
+func newAskUserTool(cfg *config.Config, handler agent.UIRequestHandler, sink session.EventSink, a *agent.Agent) *tools.AskUserTool {
+	return tools.NewAskUserTool(tools.AskUserConfig{
+		Handler: handler,
+		Timeout: cfg.AskUser.TimeoutDuration(),
+		Default: cfg.AskUser.Default,
+		OnAnswer: func(ans tools.AskUserAnswer) {
+			if sink == nil {
+				return
+			}
+			evt := session.NewUserAnswerEvent(ans.RequestID, ans.Question, ans.Kind, ans.Answers, ans.Defaulted)
+			sink.Emit(evt.WithActor(session.PrimaryActor()).WithSessionID(a.SessionID()))
+		},
+	})
+}
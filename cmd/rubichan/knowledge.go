@@ -14,8 +14,8 @@ import (
 	"github.com/julianshen/rubichan/internal/integrations"
 	"github.com/julianshen/rubichan/internal/knowledgegraph"
 	"github.com/julianshen/rubichan/internal/provider"
-	"github.com/julianshen/rubichan/internal/provider/ollama"
 	kg "github.com/julianshen/rubichan/pkg/knowledgegraph"
+	"github.com/julianshen/rubichan/pkg/provider/ollama"
 )
 
 // knowledgeCmd returns the top-level "knowledge" command with subcommands for
@@ -24,7 +24,6 @@ import (
 	"github.com/sourcegraph/conc"
 
 	"github.com/julianshen/rubichan/internal/agent"
-	"github.com/julianshen/rubichan/internal/checkpoint"
 	"github.com/julianshen/rubichan/internal/cmux"
 	"github.com/julianshen/rubichan/internal/commands"
 	"github.com/julianshen/rubichan/internal/config"
@@ -32,6 +31,7 @@ import (
 	"github.com/julianshen/rubichan/internal/folderaccess"
 	"github.com/julianshen/rubichan/internal/hooks"
 	"github.com/julianshen/rubichan/internal/integrations"
+	"github.com/julianshen/rubichan/internal/jsonschema"
 	"github.com/julianshen/rubichan/internal/knowledgegraph"
 	"github.com/julianshen/rubichan/internal/modelcheck"
 	"github.com/julianshen/rubichan/internal/output"
@@ -50,6 +50,7 @@ import (
 	"github.com/julianshen/rubichan/internal/skills"
 	"github.com/julianshen/rubichan/internal/skills/builtin"
 	"github.com/julianshen/rubichan/internal/skills/builtin/appledev"
+	"github.com/julianshen/rubichan/internal/skills/mcpbackend"
 	"github.com/julianshen/rubichan/internal/store"
 	"github.com/julianshen/rubichan/internal/subagents"
 	"github.com/julianshen/rubichan/internal/terminal"
@@ -57,21 +58,22 @@ import (
 	"github.com/julianshen/rubichan/internal/tools"
 	"github.com/julianshen/rubichan/internal/tools/browser"
 	dbtools "github.com/julianshen/rubichan/internal/tools/db"
-	gittools "github.com/julianshen/rubichan/internal/tools/git"
 	httptool "github.com/julianshen/rubichan/internal/tools/http"
 	"github.com/julianshen/rubichan/internal/tools/lsp"
-	toolsandbox "github.com/julianshen/rubichan/internal/tools/sandbox"
 	"github.com/julianshen/rubichan/internal/tools/xcode"
 	"github.com/julianshen/rubichan/internal/tui"
 	"github.com/julianshen/rubichan/internal/wiki"
 	"github.com/julianshen/rubichan/internal/worktree"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider/ollama"
+	gittools "github.com/julianshen/rubichan/pkg/tools/git"
+	toolsandbox "github.com/julianshen/rubichan/pkg/tools/sandbox"
 
 	"golang.org/x/term"
 
 	// Register providers via init() side effects.
 	_ "github.com/julianshen/rubichan/internal/provider/anthropic"
-	"github.com/julianshen/rubichan/internal/provider/ollama"
+	_ "github.com/julianshen/rubichan/internal/provider/ollama"
 	_ "github.com/julianshen/rubichan/internal/provider/openai"
 	_ "github.com/julianshen/rubichan/internal/provider/zai"
 )
@@ -96,6 +98,8 @@ var (
 	testFlag         bool
 	approveCwd       bool
 	eventLogPath     string
+	recordFlag       string
+	replayFlag       string
 
 	headless     bool
 	acpFlag      bool
@@ -103,6 +107,9 @@ var (
 	fileFlag     string
 	modeFlag     string
 	outputFlag   string
+	inputFormat  string
+	jsonSchema   string
+	answersFlag  string
 	diffFlag     string
 	maxTurnsFlag int
 	timeoutFlag  time.Duration
@@ -125,6 +132,7 @@ var (
 	wikiOutFlag         string
 	wikiFormatFlag      string
 	wikiConcurrencyFlag int
+	wikiSinceFlag       string
 
 	newProviderWithDebug = provider.NewProviderWithDebug
 )
@@ -245,9 +253,15 @@ func handleInteractiveProgramError(err error, runCtx context.Context, phase stri
 // that auto-removes the worktree if it has no changes. The returned manager
 // is non-nil only when a worktree is active.
 func setupWorkingDir(cfg *config.Config) (cwd string, mgr *worktree.Manager, cleanup func(), err error) {
+	return setupWorktree(cfg, worktreeFlag, os.Stderr)
+}
+
+// setupWorktree is setupWorkingDir for a named worktree; an empty name means
+// the current directory. Cleanup notices are written to out.
+func setupWorktree(cfg *config.Config, name string, out io.Writer) (cwd string, mgr *worktree.Manager, cleanup func(), err error) {
 	cleanup = func() {} // no-op default
 
-	if worktreeFlag == "" {
+	if name == "" {
 		cwd, err = os.Getwd()
 		if err != nil {
 			return "", nil, nil, fmt.Errorf("getting working directory: %w", err)
@@ -267,24 +281,24 @@ func setupWorkingDir(cfg *config.Config) (cwd string, mgr *worktree.Manager, cle
 	}
 	mgr = worktree.NewManager(root, wtCfg)
 
-	wt, createErr := mgr.Create(context.Background(), worktreeFlag)
+	wt, createErr := mgr.Create(context.Background(), name)
 	if createErr != nil {
 		return "", nil, nil, fmt.Errorf("creating worktree: %w", createErr)
 	}
 
 	wtDir := wt.Dir()
 	cleanup = func() {
-		hasChanges, err := mgr.HasChanges(context.Background(), worktreeFlag)
+		hasChanges, err := mgr.HasChanges(context.Background(), name)
 		if err != nil {
 			// Treat status-check failure as dirty — preserve worktree.
-			fmt.Fprintf(os.Stderr, "Warning: cannot check worktree %q status: %v (preserving)\n", worktreeFlag, err)
+			fmt.Fprintf(out, "Warning: cannot check worktree %q status: %v (preserving)\n", name, err)
 			return
 		}
 		if hasChanges {
-			fmt.Fprintf(os.Stderr, "Worktree %q preserved at %s (has uncommitted changes)\n", worktreeFlag, wtDir)
+			fmt.Fprintf(out, "Worktree %q preserved at %s (has uncommitted changes)\n", name, wtDir)
 		} else if cfg.Worktree.AutoCleanup {
-			if err := mgr.Remove(context.Background(), worktreeFlag); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to clean up worktree %q: %v\n", worktreeFlag, err)
+			if err := mgr.Remove(context.Background(), name); err != nil {
+				fmt.Fprintf(out, "Warning: failed to clean up worktree %q: %v\n", name, err)
 			}
 		}
 	}
@@ -347,7 +361,7 @@ func main() {
 				if err != nil {
 					return err
 				}
-				return runWikiHeadless(cfg, cwd, wikiOutFlag, wikiFormatFlag, wikiConcurrencyFlag)
+				return runWikiHeadless(cfg, cwd, wikiOutFlag, wikiFormatFlag, wikiConcurrencyFlag, wikiSinceFlag)
 			}
 			if acpFlag {
 				return runACP()
@@ -383,15 +397,20 @@ func main() {
 	rootCmd.PersistentFlags().BoolVar(&testFlag, "test", false, "test configured provider/model capabilities and connectivity")
 	rootCmd.PersistentFlags().BoolVar(&approveCwd, "approve-cwd", false, "approve access to the current working directory in headless mode without enabling full auto-approval")
 	rootCmd.PersistentFlags().StringVar(&eventLogPath, "event-log", "", "write structured interactive session events to the given JSONL file")
+	rootCmd.PersistentFlags().StringVar(&recordFlag, "record", "", "record every LLM request and response to the given cassette file")
+	rootCmd.PersistentFlags().StringVar(&replayFlag, "replay", "", "answer LLM requests from the given cassette file instead of calling the provider")
 	rootCmd.PersistentFlags().BoolVar(&headless, "headless", false, "run in non-interactive headless mode")
 	rootCmd.PersistentFlags().BoolVar(&acpFlag, "acp", false, "serve the Agent Client Protocol over stdin/stdout for an editor client")
 	rootCmd.PersistentFlags().StringVar(&promptFlag, "prompt", "", "prompt text for headless mode")
 	rootCmd.PersistentFlags().StringVar(&fileFlag, "file", "", "read prompt from file for headless mode")
 	rootCmd.PersistentFlags().StringVar(&modeFlag, "mode", "", "headless mode (e.g. code-review)")
-	rootCmd.PersistentFlags().StringVar(&outputFlag, "output", "markdown", "output format: json, markdown")
+	rootCmd.PersistentFlags().StringVar(&outputFlag, "output", "markdown", "output format: json, markdown, stream-json")
+	rootCmd.PersistentFlags().StringVar(&inputFormat, "input-format", "text", "headless input format: text, stream-json (follow-up messages and approvals on stdin; needs --output stream-json)")
+	rootCmd.PersistentFlags().StringVar(&jsonSchema, "json-schema", "", "JSON Schema file the final answer of a headless run must match (returned as structured_output)")
+	rootCmd.PersistentFlags().StringVar(&answersFlag, "answers", "", "JSON file answering the questions the agent asks in headless mode (question → answer)")
 	rootCmd.PersistentFlags().StringVar(&diffFlag, "diff", "", "git diff range for code-review mode")
 	rootCmd.PersistentFlags().IntVar(&maxTurnsFlag, "max-turns", 0, "override max agent turns")
-	rootCmd.PersistentFlags().DurationVar(&timeoutFlag, "timeout", 120*time.Second, "headless execution timeout")
+	rootCmd.PersistentFlags().DurationVar(&timeoutFlag, "timeout", 120*time.Second, "headless execution timeout (per turn with --input-format stream-json)")
 	rootCmd.PersistentFlags().StringVar(&toolsFlag, "tools", "", "comma-separated tool whitelist (empty = all)")
 	rootCmd.PersistentFlags().StringVar(&skillsFlag, "skills", "", "comma-separated list of skill names to activate")
 	rootCmd.PersistentFlags().BoolVar(&approveSkillsFlag, "approve-skills", false, "auto-approve skill permissions")
@@ -405,8 +424,9 @@ func main() {
 	rootCmd.PersistentFlags().BoolVar(&annotationsFlag, "annotations", false, "emit GitHub Actions workflow annotations")
 	rootCmd.PersistentFlags().BoolVar(&wikiFlag, "wiki", false, "run wiki generation (implies --headless, --approve-cwd)")
 	rootCmd.PersistentFlags().StringVar(&wikiOutFlag, "wiki-out", "docs/wiki", "output directory for wiki files")
-	rootCmd.PersistentFlags().StringVar(&wikiFormatFlag, "wiki-format", "raw-md", "wiki output format: raw-md, hugo, docusaurus")
+	rootCmd.PersistentFlags().StringVar(&wikiFormatFlag, "wiki-format", "raw-md", "wiki output format: raw-md, hugo, docusaurus, html")
 	rootCmd.PersistentFlags().IntVar(&wikiConcurrencyFlag, "wiki-concurrency", 5, "max parallel LLM calls for wiki generation")
+	rootCmd.PersistentFlags().StringVar(&wikiSinceFlag, "wiki-since", "", "update the wiki incrementally, re-analyzing only code changed since this git ref")
 
 	versionCmd := &cobra.Command{
 		Use:   "version",
@@ -419,14 +439,20 @@ func main() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(replayCmd())
 	rootCmd.AddCommand(skillCmd())
-	// wiki is now a built-in skill (generate_wiki tool), not a CLI subcommand.
+	// wiki generation is a built-in skill (generate_wiki tool); the wiki
+	// subcommand only previews generated output.
+	rootCmd.AddCommand(wikiCmd())
 	rootCmd.AddCommand(ollamaCmd())
 	rootCmd.AddCommand(knowledgeCmd())
 	rootCmd.AddCommand(initKnowledgeGraphCmd())
 	rootCmd.AddCommand(worktreeCmd())
 	rootCmd.AddCommand(sessionCmd())
+	rootCmd.AddCommand(usageCmd())
+	rootCmd.AddCommand(evalCmd())
 	rootCmd.AddCommand(initCmd())
 	rootCmd.AddCommand(serveCmd())
+	rootCmd.AddCommand(mcpCmd())
+	rootCmd.AddCommand(securityCmd())
 	rootCmd.AddCommand(shellCmd())
 
 	if err := rootCmd.Execute(); err != nil {
@@ -628,14 +654,19 @@ func saveMemoriesBestEffort(parentCtx context.Context, a *agent.Agent, out io.Wr
 
 // newDefaultSecurityEngine creates a security engine pre-configured with all
 // built-in static scanners and, when an LLM provider is given, the phase-2
-// LLM analyzers. This lives in main.go to avoid an import cycle between
-// security/ and security/scanner/ (and security/analyzer/).
-func newDefaultSecurityEngine(cfg security.EngineConfig, llm provider.LLMProvider) *security.Engine {
+// LLM analyzers. deps is the dependency scanner to use; nil means one with
+// neither an offline OSV database nor online lookups. This lives in main.go
+// to avoid an import cycle between security/ and security/scanner/ (and
+// security/analyzer/).
+func newDefaultSecurityEngine(cfg security.EngineConfig, llm provider.LLMProvider, deps *scanner.DepScanner) *security.Engine {
+	if deps == nil {
+		deps = scanner.NewDepScanner(nil)
+	}
 	e := security.NewEngine(cfg)
 	e.AddScanner(scanner.NewSecretScanner())
 	e.AddScanner(scanner.NewSASTScanner())
 	e.AddScanner(scanner.NewConfigScanner())
-	e.AddScanner(scanner.NewDepScanner(nil))
+	e.AddScanner(deps)
 	e.AddScanner(scanner.NewLicenseScanner())
 	e.AddScanner(scanner.NewAppleScanner())
 
@@ -889,16 +920,22 @@ func applyAPIBaseFlag(cfg *config.Config) {
 		}
 	}
 
-	// No existing entry — create one. Default API key to "none" so local
-	// servers that need no auth work without an env-var lookup failure.
-	key := apiKey
+	// No existing entry — create one. Without --api-key, use the provider's
+	// API key environment variable when it is set (rubichan eval passes keys
+	// to its runs that way) and otherwise default to "none" so local servers
+	// that need no auth work without an env-var lookup failure.
+	source, key := "config", apiKey
 	if key == "" {
-		key = "none"
+		if os.Getenv(config.OpenAICompatibleEnvVar(name)) != "" {
+			source = "env"
+		} else {
+			key = "none"
+		}
 	}
 	cfg.Provider.OpenAI = append(cfg.Provider.OpenAI, config.OpenAICompatibleConfig{
 		Name:         name,
 		BaseURL:      apiBaseFlag,
-		APIKeySource: "config",
+		APIKeySource: source,
 		APIKey:       key,
 	})
 }
@@ -1019,9 +1056,12 @@ func runInteractive() error {
 	if err := interactiveExitError(runCtx); err != nil {
 		return err
 	}
+	if err := tui.ApplyUIConfig(cfg.UI, cfgPath); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v; using the default theme and keys\n", err)
+	}
 
 	// Create provider
-	p, err := provider.NewProviderWithDebug(cfg, debugMode)
+	p, err := newLLMProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("creating provider: %w", err)
 	}
@@ -1029,110 +1069,12 @@ func runInteractive() error {
 		return err
 	}
 
-	// Set up effective working directory (creates worktree if --worktree is set).
-	cwd, wtMgr, wtCleanup, err := setupWorkingDir(cfg)
-	if err != nil {
-		return fmt.Errorf("worktree setup: %w", err)
-	}
-	defer wtCleanup()
-	if err := interactiveExitError(runCtx); err != nil {
-		return err
-	}
-
-	// Detect and load bootstrap context if available
-	bootstrapPath := filepath.Join(cwd, ".knowledge", ".bootstrap.json")
-	var bootstrapContext *knowledgegraph.BootstrapMetadata
-	if _, err := os.Stat(bootstrapPath); err == nil {
-		ctx, err := agent.LoadBootstrapContext(bootstrapPath)
-		if err == nil {
-			bootstrapContext = ctx
-			// Remove marker so we don't re-inject on next run
-			markerPath := filepath.Join(cwd, ".knowledge", ".bootstrap-agent-start")
-			os.Remove(markerPath)
-		}
-	}
-
-	// Create tool registry
-	registry := tools.NewRegistry()
-	diffTracker := tools.NewDiffTracker()
-	allowed := parseToolsFlag(toolsFlag)
-	modelCaps := provider.DetectCapabilities(cfg.Provider.Default, cfg.Provider.Model)
-	toolsCfg := ToolsConfig{
-		ModelCapabilities: modelCaps,
-		ProjectContext: ProjectContext{
-			AppleProjectDetected: xcode.DiscoverProject(cwd).Type != "none",
-			AppleSkillRequested:  containsSkill("apple-dev", skillsFlag),
-		},
-		CLIOverrides: allowed,
-	}
-
-	coreResult, err := registerCoreTools(cwd, registry, cfg, toolsCfg, diffTracker, 120*time.Second)
-	if err != nil {
-		return err
-	}
-	for _, cleanup := range coreResult.cleanups {
-		defer cleanup()
-	}
-
-	// Auto-activate apple-dev Xcode tools if Apple project detected.
-	var opts []agent.AgentOption
-	opts = append(opts, agent.WithDiffTracker(diffTracker))
-	opts = appendWorkingDirOption(opts, cwd)
-	opts = append(opts, agent.WithCapabilities(modelCaps))
-
-	// Inject bootstrap context into system prompt if available
-	if bootstrapContext != nil {
-		opts = append(opts, agent.WithBootstrapContext(bootstrapContext))
-	}
-	if err := wireAppleDev(cwd, registry, toolsCfg); err != nil {
-		return err
-	}
-	// Prevent createSkillRuntime from trying to discover apple-dev again.
-	skillsFlag = removeSkill("apple-dev", skillsFlag)
-
-	// Wire wiki skill (generate_wiki tool).
-	llmCompleter := integrations.NewLLMCompleter(p, cfg.Provider.Model)
-	if err := wireWiki(cwd, registry, llmCompleter, toolsCfg); err != nil {
-		return err
-	}
-
-	subagentWiring, err := subagents.Wire(subagents.Options{
-		Config:          cfg,
-		Registry:        registry,
-		EnableTask:      toolsCfg.ShouldEnable("task"),
-		EnableListTasks: toolsCfg.ShouldEnable("list_tasks"),
-		WorktreeManager: wtMgr,
-		GitRoot:         gitRepoRoot,
-		Logf:            log.Printf,
-	})
-	if err != nil {
-		return fmt.Errorf("wiring subagents: %w", err)
-	}
-	agentDefReg := subagentWiring.AgentDefs
-	wakeManager := subagentWiring.WakeManager
-	spawner := subagentWiring.Spawner
-
-	// Build the approval function. When --auto-approve is set, skip the TUI
-	// prompt entirely. Otherwise, defer to the TUI model's interactive prompt.
-	// The model is created first (with nil agent) so we can extract its
-	// approval function before constructing the agent.
-	var approvalFunc agent.ApprovalFunc
-	if autoApprove {
-		approvalFunc = func(_ context.Context, _ string, _ json.RawMessage) (bool, error) {
-			return true, nil
-		}
-	}
-
-	// Wire conversation persistence.
+	// The conversation store is shared by every session of the run.
 	s, err := openStore(cfgDir)
 	if err != nil {
 		return fmt.Errorf("opening store: %w", err)
 	}
 	defer s.Close()
-	if err := folderaccess.EnsureApprovedInteractive(s, cwd, os.Stdin, os.Stderr, autoApprove, approveCwd); err != nil {
-		return err
-	}
-	opts = append(opts, agent.WithStore(s))
 	if forkFlag {
 		if resumeFlag == "" {
 			return fmt.Errorf("--fork requires --resume <session-id>")
@@ -1144,333 +1086,90 @@ func runInteractive() error {
 		log.Printf("Forked session %s → %s", resumeFlag, newID)
 		resumeFlag = newID
 	}
-	if resumeFlag != "" {
-		opts = append(opts, agent.WithResumeSession(resumeFlag))
-	}
-
-	opts = appendPersonaOptions(opts, cwd)
-	opts = appendKnowledgeGraphOption(runCtx, opts, cwd)
-	opts = append(opts, agent.WithMode("interactive"))
-
-	// Create skill runtime with built-in prompt skills and any explicit --skills.
-	rt, storeCloser, err := createSkillRuntime(runCtx, registry, p, cfg, "interactive", cwd, cfgDir)
-	if err != nil {
-		return fmt.Errorf("creating skill runtime: %w", err)
-	}
-	if storeCloser != nil {
-		defer storeCloser.Close()
-	}
-	if rt != nil {
-		emitSkillDiscoveryWarnings(os.Stderr, rt)
-		opts = append(opts, agent.WithSkillRuntime(rt))
-	}
-
-	// Build user hooks from config, .agent/hooks.toml, and AGENT.md.
-	userHookConfigs := loadProjectHooks(cfg, s, cwd)
-	if len(userHookConfigs) > 0 {
-		opts = append(opts, agent.WithUserHooks(hooks.NewUserHookRunner(userHookConfigs, cwd)))
-	}
 
-	// Wire cross-session memory and summarizer.
-	summaryModel := cfg.Provider.SummaryModel
-	if summaryModel == "" {
-		summaryModel = cfg.Provider.Model
-	}
-	summarizer := agent.NewLLMSummarizer(p, summaryModel)
-	opts = append(opts, agent.WithSummarizer(summarizer))
-	opts = append(opts, agent.WithMemoryStore(&storeMemoryAdapter{store: s}))
-
-	// Create command registry and register built-in slash commands.
-	// Built-in registration failures indicate a programming bug (duplicate names).
-	cmdRegistry := commands.NewRegistry()
-	for _, cmd := range []commands.SlashCommand{
-		commands.NewQuitCommand(),
-		commands.NewExitCommand(),
-		commands.NewConfigCommand(),
-		commands.NewAboutCommand(),
-		commands.NewInitKnowledgeGraphCommand(),
-		commands.NewHelpCommand(cmdRegistry),
-		commands.NewInitCommand(cwd),
-	} {
-		if err := cmdRegistry.Register(cmd); err != nil {
-			return fmt.Errorf("register built-in command %q: %w", cmd.Name(), err)
-		}
-	}
-
-	// Wire command registry into skill runtime so skill-contributed commands
-	// are registered on activation and unregistered on deactivation.
-	if rt != nil {
-		rt.SetCommandRegistry(cmdRegistry)
-		rt.SetAgentDefRegistrar(&agentDefRegistrarAdapter{reg: agentDefReg})
-	}
+	env := &interactiveEnv{
+		ctx:                 runCtx,
+		cfg:                 cfg,
+		cfgDir:              cfgDir,
+		cfgPath:             cfgPath,
+		provider:            p,
+		store:               s,
+		caps:                caps,
+		cmux:                cmuxClient,
+		structuredEventLog:  structuredEventLog,
+		plain:               plainInteractive,
+		appleSkillRequested: containsSkill("apple-dev", skillsFlag),
+		checkFolder:         true,
+		out:                 os.Stderr,
+	}
+	// Sessions wire apple-dev's tools themselves when they detect an Apple
+	// project; keep createSkillRuntime from discovering it again.
+	skillsFlag = removeSkill("apple-dev", skillsFlag)
 
-	var plainHost *plainInteractiveHost
 	if plainInteractive {
-		plainHost = newPlainInteractiveHost(os.Stdin, os.Stdout, cfg.Provider.Model, cfg.Agent.MaxTurns, cmdRegistry)
-		plainHost.SetDebug(debugMode)
-		if rt != nil {
-			plainHost.SetSkillRuntime(rt)
+		sess, err := env.newSession(tui.TabSpec{SessionID: resumeFlag, Worktree: worktreeFlag})
+		if err != nil {
+			return err
 		}
-	}
-
-	// Create checkpoint manager for undo/rewind support.
-	cpSessionID := uuid.New().String()
-	cpMgr, err := checkpoint.New(cwd, cpSessionID, 0)
-	if err != nil {
-		return fmt.Errorf("create checkpoint manager: %w", err)
-	}
-	defer func() {
-		if err := cpMgr.Cleanup(); err != nil {
-			log.Printf("checkpoint cleanup: %v", err)
+		defer sess.close()
+		err = sess.plainHost.Run(runCtx)
+		saveMemoriesBestEffort(runCtx, sess.agent, os.Stderr)
+		if err != nil {
+			return err
 		}
-	}()
-
-	// Create TUI model first (with nil agent) so we can extract the
-	// interactive approval function before constructing the agent.
-	model := tui.NewModel(nil, "rubichan", cfg.Provider.Model, cfg.Agent.MaxTurns, cfgPath, cfg, cmdRegistry)
-	model.SetTermCaps(caps)
-	model.SetCmuxClient(cmuxClient)
-	model.SetCheckpointManager(cpMgr) // TUI-only: enables /undo overlay; plainHost uses /rewind directly
-	model.SetSessionStore(s)
-	model.SetDebug(debugMode)
-	if rt != nil {
-		model.SetSkillSummaryProvider(rt)
-	}
-	sink := diag.BuildEventSink(structuredEventLog, debugMode)
-	model.SetEventSink(sink)
-	if plainHost != nil {
-		plainHost.SetEventSink(sink)
+		return interactiveExitError(runCtx)
 	}
+
 	if plainTUI {
 		noAltScreen = true
 		noMouse = true
-		model.SetPlainMode(true)
-	}
-
-	// Set git branch in status bar if available.
-	if branch, err := detectGitBranch(cwd); err == nil && branch != "" {
-		model.SetGitBranch(branch)
-		if plainHost != nil {
-			plainHost.SetGitBranch(branch)
-		}
-	}
-
-	// Register commands that need model callbacks (these need the model instance).
-	if err := cmdRegistry.Register(commands.NewClearCommand(func() {
-		if model.GetAgent() != nil {
-			model.GetAgent().ClearConversation()
-		}
-		model.ClearContent()
-	})); err != nil {
-		return fmt.Errorf("register built-in command %q: %w", "clear", err)
-	}
-	if err := cmdRegistry.Register(commands.NewRalphLoopCommand(model.StartRalphLoop)); err != nil {
-		return fmt.Errorf("register built-in command %q: %w", "ralph-loop", err)
-	}
-	if err := cmdRegistry.Register(commands.NewCancelRalphCommand(model.CancelRalphLoop)); err != nil {
-		return fmt.Errorf("register built-in command %q: %w", "cancel-ralph", err)
-	}
-	if err := cmdRegistry.Register(commands.NewModelCommand(func(name string) {
-		if model.GetAgent() != nil {
-			model.GetAgent().SetModel(name)
-		}
-		model.SwitchModel(name)
-		if plainHost != nil {
-			plainHost.SetModel(name)
-		}
-	})); err != nil {
-		return fmt.Errorf("register built-in command %q: %w", "model", err)
 	}
-	if err := cmdRegistry.Register(commands.NewDebugVerificationSnapshotCommand(func() string {
-		if plainHost != nil {
-			return plainHost.DebugVerificationSnapshot()
-		}
-		return model.DebugVerificationSnapshot()
-	})); err != nil {
-		return fmt.Errorf("register built-in command %q: %w", "debug-verification-snapshot", err)
+	project, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
 	}
-	if rt != nil {
-		if err := cmdRegistry.Register(commands.NewSkillCommand(&skillListerAdapter{rt: rt})); err != nil {
-			return fmt.Errorf("register built-in command %q: %w", "skill", err)
-		}
-		if err := cmdRegistry.Register(commands.NewSkillLogCommand(&skillListerAdapter{rt: rt})); err != nil {
-			return fmt.Errorf("register built-in command %q: %w", "skill-log", err)
+	tabs := tui.NewTabs(func(spec tui.TabSpec) (*tui.TabSession, error) {
+		sess, err := env.newSession(spec)
+		if err != nil {
+			return nil, err
 		}
-	}
+		return tabSession(runCtx, sess, env.out), nil
+	}, s, project)
+	env.tabs = tabs
 
-	// Register remaining commands that need post-model dependencies.
-	// NewUndoOverlayCommand handles /undo via the TUI overlay (shows checkpoint
-	// list). NewUndoCommand is not registered here to avoid a name collision —
-	// the overlay supersedes the direct command in interactive mode.
-	for _, cmd := range []commands.SlashCommand{
-		commands.NewResumeCommand(),
-		commands.NewUndoOverlayCommand(),
-		commands.NewRewindCommand(cpMgr),
-		commands.NewContextCommand(func() agentsdk.ContextBudget {
-			if model.GetAgent() != nil {
-				return model.GetAgent().ContextBudget()
-			}
-			return agentsdk.ContextBudget{}
-		}),
-		commands.NewCompactCommand(func(ctx context.Context) (agentsdk.CompactResult, error) {
-			if model.GetAgent() != nil {
-				return model.GetAgent().ForceCompact(ctx)
-			}
-			return agentsdk.CompactResult{}, nil
-		}),
-		commands.NewSessionsCommand(func() ([]store.Session, error) {
-			if model.GetAgent() != nil {
-				return model.GetAgent().ListSessions(20)
-			}
-			return nil, nil
-		}),
-		commands.NewForkCommand(func(ctx context.Context) (string, error) {
-			if model.GetAgent() != nil {
-				return model.GetAgent().ForkSession(ctx)
+	specs, active := initialTabs(s, project)
+	shown := 0
+	for i, spec := range specs {
+		sess, err := env.newSession(spec)
+		if err != nil {
+			if exitErr := interactiveExitError(runCtx); exitErr != nil {
+				return exitErr
 			}
-			return "", fmt.Errorf("no agent")
-		}),
-	} {
-		if err := cmdRegistry.Register(cmd); err != nil {
-			return fmt.Errorf("register built-in command %q: %w", cmd.Name(), err)
-		}
-	}
-
-	// Build tool execution slot middlewares first so the rule engine can
-	// feed the approval system. The agent composes the full pipeline.
-	pc := buildPipeline(registry, cfg, cwd, rt)
-	opts = append(opts, agent.WithToolMiddlewares(pc.Middlewares))
-
-	if !autoApprove {
-		if plainHost != nil {
-			approvalFunc = plainHost.MakeApprovalFunc()
-		} else {
-			// UIRequestHandler takes priority in the shared agentsdk.ApprovalFlow.
-			// Keep approvalFunc wired as a fallback for non-UI handler paths.
-			approvalFunc = model.MakeApprovalFunc()
-			opts = append(opts, agent.WithUIRequestHandler(model.MakeUIRequestHandler()))
-		}
-
-		// Build the approval checker: compose session cache, pipeline rule
-		// engine, and config-based trust rules. Session cache (TUI "always"
-		// decisions) is checked first, then the pipeline's rule engine
-		// (category-based allow rules), then config trust rules.
-		var checkers []agent.ApprovalChecker
-
-		// Hierarchical permission policies (org → project → user).
-		if hc := buildHierarchicalChecker(cfg, cfgPath, cwd); hc != nil {
-			checkers = append(checkers, hc)
-		}
-
-		if plainHost != nil {
-			checkers = append(checkers, plainHost)
-		} else {
-			checkers = append(checkers, model) // session cache
-		}
-		checkers = append(checkers, &ruleEngineChecker{
-			classifier: pc.Classifier,
-			engine:     pc.RuleEngine,
-		})
-		if len(cfg.Agent.TrustRules) > 0 {
-			regexRules, globRules := splitTrustRules(cfg.Agent.TrustRules)
-			if err := agent.ValidateTrustRules(regexRules, globRules); err != nil {
-				return fmt.Errorf("invalid trust rules in config: %w", err)
+			if len(specs) == 1 {
+				return err
 			}
-			checkers = append(checkers, agent.NewTrustRuleChecker(regexRules, globRules))
-		}
-		composite := agent.NewCompositeApprovalChecker(checkers...)
-		opts = append(opts, agent.WithApprovalChecker(composite))
-		spawner.ApprovalChecker = composite
-	} else {
-		// Auto-approve mode: still respect hierarchical deny policies.
-		var autoCheckers []agent.ApprovalChecker
-		if hc := buildHierarchicalChecker(cfg, cfgPath, cwd); hc != nil {
-			autoCheckers = append(autoCheckers, hc)
-		}
-		autoCheckers = append(autoCheckers, agent.AlwaysAutoApprove{})
-		composite := agent.NewCompositeApprovalChecker(autoCheckers...)
-		opts = append(opts, agent.WithApprovalChecker(composite))
-		spawner.ApprovalChecker = composite
-	}
-
-	// Attach the wake manager for background subagent notifications.
-	opts = append(opts, agent.WithWakeManager(wakeManager))
-
-	// Create shared rate limiter for throttling LLM API requests.
-	var rateLimiter *agent.SharedRateLimiter
-	if cfg.Agent.MaxRequestsPerMinute > 0 {
-		rateLimiter = agent.NewSharedRateLimiter(cfg.Agent.MaxRequestsPerMinute)
-	}
-	if rateLimiter != nil {
-		opts = append(opts, agent.WithRateLimiter(rateLimiter))
-	}
-
-	opts = append(opts, agent.WithCheckpointManager(cpMgr))
-
-	// Create agent with the approval function.
-	a := agent.New(p, registry, approvalFunc, cfg, opts...)
-
-	// Wire spawner dependencies that need the agent and provider.
-	spawner.Provider = p
-	spawner.ParentTools = registry
-	spawner.ParentSkillRuntime = rt
-	spawner.RateLimiter = rateLimiter
-
-	// Register notes tool backed by agent's scratchpad.
-	if toolsCfg.ShouldEnable("notes") {
-		if err := registry.Register(tools.NewNotesTool(a.ScratchpadAccess())); err != nil {
-			return fmt.Errorf("registering notes tool: %w", err)
+			fmt.Fprintf(os.Stderr, "warning: not restoring tab %q: %v\n", spec.Name, err)
+			continue
 		}
-	}
-
-	// Register task_complete tool for explicit loop termination.
-	if toolsCfg.ShouldEnable("task_complete") {
-		if err := registry.Register(tools.NewCompletionSignalTool()); err != nil {
-			return fmt.Errorf("registering task_complete tool: %w", err)
+		if i == active {
+			shown = len(tabs.Sessions())
 		}
+		tabs.Add(spec, tabSession(runCtx, sess, os.Stderr))
 	}
-
-	// Register cmux tools when running inside cmux terminal.
-	if cmuxClient != nil {
-		cmuxTools := []tools.Tool{
-			tools.NewCmuxBrowserNavigate(cmuxClient),
-			tools.NewCmuxBrowserSnapshot(cmuxClient),
-			tools.NewCmuxBrowserClick(cmuxClient),
-			tools.NewCmuxBrowserType(cmuxClient),
-			tools.NewCmuxBrowserWait(cmuxClient),
-			tools.NewCmuxSplit(cmuxClient),
-			tools.NewCmuxSend(cmuxClient),
-			tools.NewCmuxOrchestrate(cmuxClient),
-		}
-		for _, t := range cmuxTools {
-			if toolsCfg.ShouldEnable(t.Name()) {
-				if err := registry.Register(t); err != nil {
-					log.Printf("warning: registering cmux tool %q: %v", t.Name(), err)
-				}
-			}
-		}
+	if len(tabs.Sessions()) == 0 {
+		return fmt.Errorf("no tab could be restored")
 	}
-
-	// Wire the agent into the TUI model now that both exist.
-	model.SetAgent(a)
-	model.SetWikiConfig(tui.WikiCommandConfig{
-		WorkDir: cwd,
-		LLM:     llmCompleter,
-	})
-	if plainHost != nil {
-		plainHost.SetAgent(a)
-		err := plainHost.Run(runCtx)
-		saveMemoriesBestEffort(runCtx, a, os.Stderr)
-		if err != nil {
-			return err
+	tabs.Select(shown)
+	// Closing the remaining tabs saves their memories on shutdown.
+	defer func() {
+		for _, ts := range tabs.Sessions() {
+			ts.Close()
 		}
-		return interactiveExitError(runCtx)
-	}
+	}()
 
-	// Index project files for @ mention autocomplete (background).
-	fileSrc := tui.NewFileCompletionSource(cwd)
-	model.SetFileCompletionSource(fileSrc)
-	go indexProjectFiles(cwd, fileSrc)
+	// Sessions opened from now on report through the log: the TUI owns the
+	// terminal.
+	env.out = log.Writer()
 
 	programOpts := []tea.ProgramOption{
 		tea.WithContext(runCtx),
@@ -1482,26 +1181,77 @@ func runInteractive() error {
 		programOpts = append(programOpts, tea.WithAltScreen())
 	}
 	// TODO: enable Kitty keyboard (caps.KittyKeyboard) when bubbletea adds tea.WithKittyKeyboard().
-	prog := tea.NewProgram(model, programOpts...)
+	prog := tea.NewProgram(tabs, programOpts...)
 	if _, err := prog.Run(); err != nil {
 		if err := handleInteractiveProgramError(err, runCtx, "running TUI"); err != nil {
 			return err
 		}
 	}
-	if err := interactiveExitError(runCtx); err != nil {
-		return err
+	return interactiveExitError(runCtx)
+}
+
+// tabSession adapts an interactive session to a TUI tab. Closing the tab
+// saves the agent's memories and releases the session.
+func tabSession(ctx context.Context, sess *interactiveSession, out io.Writer) *tui.TabSession {
+	return &tui.TabSession{
+		Model: sess.model,
+		Close: func() {
+			saveMemoriesBestEffort(ctx, sess.agent, out)
+			sess.close()
+		},
 	}
+}
 
-	// Save memories on graceful shutdown.
-	saveMemoriesBestEffort(runCtx, a, os.Stderr)
-	if err := interactiveExitError(runCtx); err != nil {
-		return err
+// initialTabs returns the tabs to start with and the index of the one to
+// show. --resume and --worktree start a single tab for that session;
+// otherwise the tabs left open in project are restored when there were
+// several, and a single fresh tab is opened when not.
+func initialTabs(s *store.Store, project string) ([]tui.TabSpec, int) {
+	if resumeFlag == "" && worktreeFlag == "" {
+		saved, err := s.LoadTabs(project)
+		if err != nil {
+			log.Printf("loading tabs: %v", err)
+		}
+		if len(saved) > 1 {
+			specs := make([]tui.TabSpec, len(saved))
+			active := 0
+			for i, st := range saved {
+				specs[i] = tui.TabSpec{Name: st.Name, SessionID: st.SessionID, Worktree: st.Worktree}
+				if st.Active {
+					active = i
+				}
+			}
+			return specs, active
+		}
 	}
+	return []tui.TabSpec{{SessionID: resumeFlag, Worktree: worktreeFlag}}, 0
+}
 
-	return nil
+// checkHeadlessInputFormat validates --input-format against the other
+// headless flags. Stream-json input needs stream-json output: approval
+// requests reach the caller only as ui_request events, so with any other
+// output a turn needing approval would wait until --timeout.
+func checkHeadlessInputFormat(input, output, mode string) error {
+	switch input {
+	case "text":
+		return nil
+	case "stream-json":
+		if mode == "code-review" {
+			return fmt.Errorf("--input-format stream-json is not supported in code-review mode")
+		}
+		if output != "stream-json" {
+			return fmt.Errorf("--input-format stream-json requires --output stream-json, which carries the approval requests stdin answers")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported --input-format %q: use text or stream-json", input)
+	}
 }
 
 func runHeadless() error {
+	if err := checkHeadlessInputFormat(inputFormat, outputFlag, modeFlag); err != nil {
+		return err
+	}
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
@@ -1515,8 +1265,36 @@ func runHeadless() error {
 		cfg.Agent.MaxTurns = maxTurnsFlag
 	}
 
-	// Single top-level timeout governs the entire headless execution.
-	ctx, cancel := context.WithTimeout(context.Background(), timeoutFlag)
+	// With stream-json input, stdin stays open for follow-up messages and
+	// approval responses instead of being read as a single prompt.
+	var streamInput *runner.StreamInput
+	if inputFormat == "stream-json" {
+		streamInput = runner.NewStreamInput(os.Stdin)
+	}
+
+	var answerSchema *jsonschema.Schema
+	if jsonSchema != "" {
+		data, err := os.ReadFile(jsonSchema)
+		if err != nil {
+			return fmt.Errorf("reading --json-schema: %w", err)
+		}
+		if answerSchema, err = jsonschema.Compile(data); err != nil {
+			return fmt.Errorf("loading --json-schema %s: %w", jsonSchema, err)
+		}
+	}
+
+	// A single timeout governs a one-shot headless execution. A stream-json
+	// session lives as long as stdin stays open, so there the timeout bounds
+	// each turn instead (see SetTurnTimeout) and idling on stdin is fine.
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if streamInput != nil {
+		ctx, cancel = context.WithCancel(context.Background())
+	} else {
+		ctx, cancel = context.WithTimeout(context.Background(), timeoutFlag)
+	}
 	defer cancel()
 
 	// Set up effective working directory (creates worktree if --worktree is set).
@@ -1539,6 +1317,21 @@ func runHeadless() error {
 			return fmt.Errorf("extracting diff: %w", err)
 		}
 		promptText = pipeline.BuildReviewPrompt(diff)
+	} else if streamInput != nil && strings.TrimSpace(promptFlag) == "" && fileFlag == "" {
+		var err error
+		promptText, err = streamInput.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("no input provided: stream-json input closed before the first user message")
+		}
+		if err != nil {
+			return err
+		}
+	} else if streamInput != nil {
+		var err error
+		promptText, err = runner.ResolveInput(promptFlag, fileFlag, nil)
+		if err != nil {
+			return err
+		}
 	} else {
 		var stdinReader io.Reader
 		if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice == 0 {
@@ -1552,10 +1345,12 @@ func runHeadless() error {
 		}
 	}
 	// Create provider
-	p, err := provider.NewProviderWithDebug(cfg, debugMode)
+	p, err := newLLMProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("creating provider: %w", err)
 	}
+	// Bills side calls (summaries, completions, analyzers, subagents) to the agent.
+	meter := agent.NewUsageMeter()
 
 	registry := tools.NewRegistry()
 	allowed := parseToolsFlag(toolsFlag)
@@ -1590,7 +1385,7 @@ func runHeadless() error {
 	skillsFlag = removeSkill("apple-dev", skillsFlag)
 
 	// Wire wiki skill (generate_wiki tool).
-	headlessLLM := integrations.NewLLMCompleter(p, cfg.Provider.Model)
+	headlessLLM := integrations.NewLLMCompleter(meter.Wrap(p, cfg.Provider.Model), cfg.Provider.Model)
 	if err := wireWiki(cwd, registry, headlessLLM, headlessToolsCfg); err != nil {
 		return err
 	}
@@ -1646,7 +1441,7 @@ func runHeadless() error {
 		headlessMode = "headless"
 	}
 	opts = append(opts, agent.WithMode(headlessMode))
-	rt, storeCloser, err := createSkillRuntime(ctx, registry, p, cfg, headlessMode, cwd, cfgDir)
+	rt, storeCloser, err := createSkillRuntime(ctx, registry, meter.Wrap(p, cfg.Provider.Model), cfg, headlessMode, cwd, cfgDir)
 	if err != nil {
 		return fmt.Errorf("creating skill runtime: %w", err)
 	}
@@ -1658,7 +1453,7 @@ func runHeadless() error {
 		// Apply the same tool admission policy to skill-contributed tools
 		// that is used for built-in tools, ensuring a unified policy path.
 		rt.SetToolAdmissionFunc(headlessToolsCfg.ShouldEnable)
-		opts = append(opts, agent.WithSkillRuntime(rt))
+		opts = append(opts, agent.WithSkillRuntime(rt), agent.WithMentionResolver(mcpbackend.MentionResolver(rt)))
 	}
 
 	// Build user hooks from config, .agent/hooks.toml, and AGENT.md.
@@ -1672,7 +1467,7 @@ func runHeadless() error {
 	if headlessSummaryModel == "" {
 		headlessSummaryModel = cfg.Provider.Model
 	}
-	headlessSummarizer := agent.NewLLMSummarizer(p, headlessSummaryModel)
+	headlessSummarizer := agent.NewLLMSummarizer(meter.Wrap(p, headlessSummaryModel), headlessSummaryModel)
 	opts = append(opts, agent.WithSummarizer(headlessSummarizer))
 	opts = append(opts, agent.WithMemoryStore(&storeMemoryAdapter{store: s}))
 
@@ -1693,16 +1488,23 @@ func runHeadless() error {
 
 	// Headless auto-approves all tools (restricted via --tools allowlist),
 	// but still respects hierarchical deny policies so org-level restrictions
-	// apply in CI/CD.
+	// apply in CI/CD. With stream-json input, calls the policies leave open
+	// are sent out as ui_request events and answered on stdin instead,
+	// unless --auto-approve is set. Subagents keep auto-approval: the task
+	// call that spawns them is the approval point.
 	{
 		var headlessCheckers []agent.ApprovalChecker
 		if hc := buildHierarchicalChecker(cfg, configPath, cwd); hc != nil {
 			headlessCheckers = append(headlessCheckers, hc)
 		}
-		headlessCheckers = append(headlessCheckers, agent.AlwaysAutoApprove{})
-		composite := agent.NewCompositeApprovalChecker(headlessCheckers...)
-		opts = append(opts, agent.WithApprovalChecker(composite))
-		headlessSpawner.ApprovalChecker = composite
+		autoComposite := agent.NewCompositeApprovalChecker(append(headlessCheckers, agent.AlwaysAutoApprove{})...)
+		if streamInput != nil && !autoApprove {
+			opts = append(opts, agent.WithApprovalChecker(agent.NewCompositeApprovalChecker(headlessCheckers...)))
+			opts = append(opts, agent.WithUIRequestHandler(streamInput))
+		} else {
+			opts = append(opts, agent.WithApprovalChecker(autoComposite))
+		}
+		headlessSpawner.ApprovalChecker = autoComposite
 	}
 	opts = append(opts, agent.WithParallelPolicy(agent.AllowAllParallel{}))
 	opts = append(opts, agent.WithWakeManager(headlessWakeManager))
@@ -1718,6 +1520,7 @@ func runHeadless() error {
 		opts = append(opts, agent.WithRateLimiter(headlessRateLimiter))
 	}
 
+	opts = append(opts, agent.WithUsageMeter(meter))
 	a := agent.New(p, registry, approvalFunc, cfg, opts...)
 
 	// Wire spawner dependencies that need the agent and provider.
@@ -1725,6 +1528,7 @@ func runHeadless() error {
 	headlessSpawner.ParentTools = registry
 	headlessSpawner.ParentSkillRuntime = rt
 	headlessSpawner.RateLimiter = headlessRateLimiter
+	headlessSpawner.UsageMeter = meter
 
 	// Register notes tool backed by agent's scratchpad.
 	if headlessToolsCfg.ShouldEnable("notes") {
@@ -1733,6 +1537,13 @@ func runHeadless() error {
 		}
 	}
 
+	// Register todo tool backed by agent's todo list.
+	if headlessToolsCfg.ShouldEnable(tools.TodoName) {
+		if err := registry.Register(tools.NewTodoTool(a.TodoAccess())); err != nil {
+			return fmt.Errorf("registering todo tool: %w", err)
+		}
+	}
+
 	// Register task_complete tool for explicit loop termination.
 	if headlessToolsCfg.ShouldEnable("task_complete") {
 		if err := registry.Register(tools.NewCompletionSignalTool()); err != nil {
@@ -1740,6 +1551,33 @@ func runHeadless() error {
 		}
 	}
 
+	// Register ask_user. Without an --answers file there is nobody to ask,
+	// and the tool tells the model so.
+	headlessSink := diag.BuildEventSink(structuredEventLog, debugMode)
+	if headlessToolsCfg.ShouldEnable(tools.AskUserName) {
+		var askHandler agent.UIRequestHandler
+		if answersFlag != "" {
+			answers, err := runner.LoadAnswers(answersFlag)
+			if err != nil {
+				return err
+			}
+			askHandler = answers
+		}
+		if err := registry.Register(newAskUserTool(cfg, askHandler, headlessSink, a)); err != nil {
+			return fmt.Errorf("registering ask_user tool: %w", err)
+		}
+	}
+
+	// Register structured_output to collect a --json-schema answer. It
+	// bypasses --tools: without it the run cannot succeed.
+	var structuredOutput *tools.StructuredOutputTool
+	if answerSchema != nil {
+		structuredOutput = tools.NewStructuredOutputTool(answerSchema)
+		if err := registry.Register(structuredOutput); err != nil {
+			return fmt.Errorf("registering structured_output tool: %w", err)
+		}
+	}
+
 	// Run headless
 	mode := modeFlag
 	if mode == "" {
@@ -1749,10 +1587,25 @@ func runHeadless() error {
 	// Run LLM review and security scan concurrently for code-review mode.
 	hr := runner.NewHeadlessRunner(a.Turn)
 	hr.SetModelName(cfg.Provider.Model)
-	if sink := diag.BuildEventSink(structuredEventLog, debugMode); len(sink) > 0 {
-		hr.SetEventSink(sink)
+	if streamInput != nil {
+		hr.SetTurnTimeout(timeoutFlag)
+	}
+	if len(headlessSink) > 0 {
+		hr.SetEventSink(headlessSink)
+	}
+	if outputFlag == "stream-json" {
+		stream := output.NewStreamJSONWriter(os.Stdout)
+		hr.SetTurnEventHandler(func(evt agent.TurnEvent) {
+			if err := stream.WriteEvent(evt); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: writing stream-json event: %v\n", err)
+			}
+		})
 	}
 	promptText = applyHeadlessBootstrapProbePrompt(promptText, headlessToolsCfg.ShouldEnable("shell"))
+	if structuredOutput != nil {
+		hr.SetStructuredOutput(structuredOutput, structuredOutputRetries)
+		promptText = applyStructuredOutputPrompt(promptText)
+	}
 	var result *output.RunResult
 	var secReport *security.Report
 
@@ -1769,9 +1622,11 @@ func runHeadless() error {
 		// the config defaults to false so users opt-in to the cost.
 		var llmForSec provider.LLMProvider
 		if cfg.Security.EnableLLMAnalysis {
-			llmForSec = p
+			llmForSec = meter.Wrap(p, cfg.Provider.Model)
 		}
-		engine := newDefaultSecurityEngine(engineCfg, llmForSec)
+		deps, closeDeps := newDepScanner(cfg, cfgDir)
+		defer closeDeps()
+		engine := newDefaultSecurityEngine(engineCfg, llmForSec, deps)
 
 		// Load .security.yaml for custom rules.
 		projectCfg, projectCfgErr := security.LoadProjectConfig(cwd)
@@ -1781,6 +1636,14 @@ func runHeadless() error {
 		if projectCfg != nil && len(projectCfg.Rules) > 0 {
 			engine.AddScanner(scanner.NewCustomRuleScanner(projectCfg.Rules))
 		}
+		if projectCfg != nil && len(projectCfg.SASTRules) > 0 {
+			sast, err := scanner.NewSASTScannerFromRules(projectCfg.SASTRules)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: loading .security.yaml sast_rules: %v\n", err)
+			} else {
+				engine.AddScanner(sast)
+			}
+		}
 
 		// Add skill-provided security scanners so security-rule skills
 		// participate in the same engine used by review workflows.
@@ -1871,6 +1734,8 @@ func runHeadless() error {
 	switch outputFlag {
 	case "json":
 		formatter = output.NewJSONFormatter()
+	case "stream-json":
+		formatter = output.NewStreamJSONFormatter()
 	default:
 		if term.IsTerminal(int(os.Stdout.Fd())) {
 			width := 80
@@ -1890,6 +1755,25 @@ func runHeadless() error {
 
 	fmt.Print(string(out))
 
+	// Keep serving follow-up messages until stdin closes; the last turn's
+	// result decides the exit status.
+	if streamInput != nil {
+		last, err := hr.RunFollowUps(ctx, streamInput, mode, func(r *output.RunResult) error {
+			out, err := formatter.Format(r)
+			if err != nil {
+				return fmt.Errorf("formatting output: %w", err)
+			}
+			fmt.Print(string(out))
+			return nil
+		})
+		if last != nil {
+			result = last
+		}
+		if err != nil {
+			return err
+		}
+	}
+
 	// Post results to PR if requested.
 	if postToPRFlag {
 		if err := postResultsToPR(ctx, result, secReport); err != nil {
@@ -1950,6 +1834,18 @@ Headless bootstrap requirement:
 	return prefix + "\n\nUser task:\n" + strings.TrimSpace(prompt)
 }
 
+// structuredOutputRetries is how many extra turns a --json-schema run gets
+// to correct an answer that failed validation.
+const structuredOutputRetries = 2
+
+func applyStructuredOutputPrompt(prompt string) string {
+	return strings.TrimSpace(prompt) + "\n\n" + strings.TrimSpace(`
+Answer format requirement:
+- When the task is done, submit your final answer by calling the structured_output tool exactly once.
+- The tool's input schema is the required answer format; do not put the answer in a text reply.
+`)
+}
+
 func validateHeadlessBootstrapProbe(result *output.RunResult, shellEnabled bool) error {
 	if !shellEnabled {
 		return nil
@@ -2497,12 +2393,12 @@ func postResultsToPR(ctx context.Context, result *output.RunResult, secReport *s
 // runWikiHeadless runs the wiki generation pipeline directly without an agent loop.
 // It creates an LLM provider, a parser, and delegates to wiki.Run, emitting
 // progress updates to stderr.
-func runWikiHeadless(cfg *config.Config, cwd, outDir, format string, concurrency int) error {
+func runWikiHeadless(cfg *config.Config, cwd, outDir, format string, concurrency int, since string) error {
 	caps := terminal.Detect()
 	cmuxClient, closeCmux := dialCmux(caps)
 	defer closeCmux()
 
-	p, err := provider.NewProviderWithDebug(cfg, debugMode)
+	p, err := newLLMProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("creating provider: %w", err)
 	}
@@ -2515,6 +2411,7 @@ func runWikiHeadless(cfg *config.Config, cwd, outDir, format string, concurrency
 		OutputDir:   outDir,
 		Format:      format,
 		Concurrency: concurrency,
+		Since:       since,
 		ProgressFunc: func(stage string, current, total int) {
 			if total > 0 {
 				fmt.Fprintf(os.Stderr, "[%s] %d/%d\n", stage, current, total)
@@ -2569,6 +2466,19 @@ func runWikiHeadless(cfg *config.Config, cwd, outDir, format string, concurrency
 		fmt.Fprintf(os.Stdout, "Wiki generated: %d documents, %d diagrams — output: %s (format: %s, %.1fs)\n",
 			result.Documents, result.Diagrams, result.OutputDir, result.Format,
 			float64(result.DurationMs)/1000)
+		if result.Since != "" {
+			fmt.Fprintf(os.Stdout, "Since %s: %d files changed, %d chunks analyzed, %d reused from the cache\n",
+				result.Since, result.ChangedFiles, result.AnalyzedChunks, result.CachedChunks)
+		}
+		if len(result.Artifacts) > 0 {
+			fmt.Fprintf(os.Stdout, "API specs: %s\n", strings.Join(result.Artifacts, ", "))
+		}
+		if len(result.BreakingAPIChanges) > 0 {
+			fmt.Fprintf(os.Stdout, "Breaking API changes since the last run:\n")
+			for _, change := range result.BreakingAPIChanges {
+				fmt.Fprintf(os.Stdout, "  - %s\n", change)
+			}
+		}
 	}
 	return nil
 }
@@ -170,7 +170,7 @@ func TestNoColorFlagDefined(t *testing.T) {
 }
 
 func TestNewDefaultSecurityEngine(t *testing.T) {
-	engine := newDefaultSecurityEngine(security.EngineConfig{Concurrency: 4}, nil)
+	engine := newDefaultSecurityEngine(security.EngineConfig{Concurrency: 4}, nil, nil)
 	require.NotNil(t, engine)
 }
 
@@ -321,6 +321,24 @@ func TestApplyAPIBaseFlag_DefaultsKeyToNone(t *testing.T) {
 	assert.Equal(t, "none", cfg.Provider.OpenAI[0].APIKey)
 }
 
+func TestApplyAPIBaseFlag_UsesProviderEnvKey(t *testing.T) {
+	saveFlags(t)
+	apiBaseFlag = "http://localhost:1234/v1"
+	apiKeyFlag = ""
+	t.Setenv("LOCAL_API_KEY", "env-key")
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "local"
+
+	applyAPIBaseFlag(cfg)
+
+	require.Len(t, cfg.Provider.OpenAI, 1)
+	assert.Equal(t, "env", cfg.Provider.OpenAI[0].APIKeySource)
+	key, err := config.ResolveOpenAICompatibleAPIKey(cfg.Provider.OpenAI[0])
+	require.NoError(t, err)
+	assert.Equal(t, "env-key", key)
+}
+
 func TestApplyAPIBaseFlag_OverridesExistingEntry(t *testing.T) {
 	saveFlags(t)
 	apiBaseFlag = "http://new-url:5678/v1"
@@ -455,7 +473,7 @@ func TestRunWikiHeadlessInvalidProviderReturnsError(t *testing.T) {
 	cfg := &config.Config{}
 	cfg.Provider.Default = "nonexistent-provider-xyz"
 
-	err := runWikiHeadless(cfg, t.TempDir(), "docs/wiki", "raw-md", 1)
+	err := runWikiHeadless(cfg, t.TempDir(), "docs/wiki", "raw-md", 1, "")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "creating provider")
 }
@@ -464,7 +482,7 @@ func TestWikiHeadlessEndToEnd_NoProvider(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Provider.Default = ""
 	cfg.Provider.Model = ""
-	err := runWikiHeadless(cfg, t.TempDir(), filepath.Join(t.TempDir(), "wiki-out"), "raw-md", 1)
+	err := runWikiHeadless(cfg, t.TempDir(), filepath.Join(t.TempDir(), "wiki-out"), "raw-md", 1, "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "provider")
 }
@@ -498,3 +516,14 @@ func TestWikiHelpOutput(t *testing.T) {
 	assert.Contains(t, output, "--wiki-format")
 	assert.Contains(t, output, "--wiki-concurrency")
 }
+
+func TestCheckHeadlessInputFormat(t *testing.T) {
+	for _, output := range []string{"markdown", "json", "stream-json"} {
+		assert.NoError(t, checkHeadlessInputFormat("text", output, "generic"))
+	}
+	assert.NoError(t, checkHeadlessInputFormat("stream-json", "stream-json", "generic"))
+	assert.ErrorContains(t, checkHeadlessInputFormat("stream-json", "json", "generic"), "requires --output stream-json")
+	assert.ErrorContains(t, checkHeadlessInputFormat("stream-json", "markdown", "generic"), "requires --output stream-json")
+	assert.ErrorContains(t, checkHeadlessInputFormat("stream-json", "stream-json", "code-review"), "code-review")
+	assert.ErrorContains(t, checkHeadlessInputFormat("yaml", "json", "generic"), "unsupported --input-format")
+}
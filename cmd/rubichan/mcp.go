@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/julianshen/rubichan/internal/agent"
+	"github.com/julianshen/rubichan/internal/commands"
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/folderaccess"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/skills"
+	"github.com/julianshen/rubichan/internal/toolexec"
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/internal/tools/mcp"
+	"github.com/julianshen/rubichan/internal/tools/xcode"
+	kg "github.com/julianshen/rubichan/pkg/knowledgegraph"
+)
+
+var (
+	mcpServeHTTP    string
+	mcpServeToken   string
+	mcpAllowOrigins []string
+)
+
+func mcpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Model Context Protocol integration",
+	}
+	cmd.AddCommand(mcpServeCmd())
+	return cmd
+}
+
+func mcpServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve rubichan's tools, knowledge and commands over MCP",
+		Long: `Run rubichan as a Model Context Protocol server.
+
+Exposed:
+  tools      the tool registry, including tools of skills enabled with --skills
+  resources  knowledge graph entities (knowledge://<id>) and search
+             (knowledge://search?q=<text>)
+  prompts    custom slash commands from .agent/commands
+
+Tool calls pass through the same rule engine, hooks and shell sandbox as the
+agent. There is nobody to ask for approval, so a call the agent would prompt
+for is refused unless a trust rule allows it or --auto-approve is given.
+
+By default the server speaks JSON-RPC on stdin/stdout. --http serves the
+streamable HTTP transport on the given address instead. Browsers may only
+call it from loopback origins or those given with --allow-origin, and a
+--token is required unless the address is a loopback one.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runMCPServe()
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.Flags().StringVar(&mcpServeHTTP, "http", "", "serve streamable HTTP on this address (host:port) instead of stdio")
+	cmd.Flags().StringVar(&mcpServeToken, "token", "", "bearer token HTTP clients must send (required unless --http is a loopback address)")
+	cmd.Flags().StringSliceVar(&mcpAllowOrigins, "allow-origin", nil, "browser origin allowed to call the HTTP transport besides loopback ones (repeatable)")
+	return cmd
+}
+
+func runMCPServe() error {
+	if mcpServeHTTP != "" {
+		if err := checkMCPHTTPAuth(mcpServeHTTP, mcpServeToken); err != nil {
+			return err
+		}
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+	cfgDir, err := configDir()
+	if err != nil {
+		return fmt.Errorf("resolving config directory: %w", err)
+	}
+	st, err := openStore(cfgDir)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer st.Close()
+	if err := folderaccess.EnsureApprovedNonInteractive(st, cwd, autoApprove, approveCwd); err != nil {
+		return err
+	}
+
+	// Skills may call back into a model, so the provider is still needed
+	// even though the server itself never runs a turn.
+	p, err := newLLMProvider(cfg)
+	if err != nil {
+		return fmt.Errorf("creating provider: %w", err)
+	}
+	modelCaps := provider.DetectCapabilities(cfg.Provider.Default, cfg.Provider.Model)
+
+	registry := tools.NewRegistry()
+	toolsCfg := ToolsConfig{
+		ModelCapabilities: modelCaps,
+		ProjectContext: ProjectContext{
+			AppleProjectDetected: xcode.DiscoverProject(cwd).Type != "none",
+			AppleSkillRequested:  containsSkill("apple-dev", skillsFlag),
+		},
+		CLIOverrides: parseToolsFlag(toolsFlag),
+	}
+	coreTools, err := registerCoreTools(cwd, registry, cfg, toolsCfg, tools.NewDiffTracker(), timeoutFlag)
+	if err != nil {
+		return fmt.Errorf("registering tools: %w", err)
+	}
+	for _, cleanup := range coreTools.cleanups {
+		defer cleanup()
+	}
+	if err := wireAppleDev(cwd, registry, toolsCfg); err != nil {
+		return fmt.Errorf("wiring apple dev tools: %w", err)
+	}
+	rt, skillCloser, err := createSkillRuntime(ctx, registry, p, cfg, "mcp", cwd, cfgDir)
+	if err != nil {
+		return fmt.Errorf("creating skill runtime: %w", err)
+	}
+	if skillCloser != nil {
+		defer skillCloser.Close()
+	}
+
+	pc := buildPipeline(registry, cfg, cwd, rt)
+	checker, err := mcpApprovalChecker(cfg, cwd, pc, autoApprove)
+	if err != nil {
+		return err
+	}
+
+	serverCfg := mcp.ServerConfig{
+		Tools: &mcpToolServer{
+			registry: registry,
+			pipeline: mcpToolPipeline(registry, pc.Middlewares, rt),
+			checker:  checker,
+		},
+		Prompts:        &mcpPromptServer{commands: loadCustomCommands(cfg, cwd, cfgDir)},
+		AllowedOrigins: mcpAllowOrigins,
+	}
+	// The knowledge graph is optional, as it is for the agent.
+	if g, err := openGraph(ctx, cwd); err == nil {
+		defer g.Close()
+		serverCfg.Resources = &mcpKnowledgeServer{graph: g}
+	}
+	server := mcp.NewServer(serverCfg)
+
+	if mcpServeHTTP == "" {
+		// stdout is the protocol; diagnostics go to stderr via log.
+		return server.ServeStdio(ctx, os.Stdin, os.Stdout)
+	}
+	return serveMCPHTTP(ctx, server, mcpServeHTTP, mcpServeToken)
+}
+
+// checkMCPHTTPAuth refuses to serve without a token on an address other
+// machines can reach.
+func checkMCPHTTPAuth(addr, token string) error {
+	if token != "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid --http address %q: %w", addr, err)
+	}
+	if !mcp.IsLoopbackHost(host) {
+		return fmt.Errorf("refusing to serve MCP on %s without --token; bind to a loopback address such as 127.0.0.1 or set a token", addr)
+	}
+	return nil
+}
+
+func serveMCPHTTP(ctx context.Context, server *mcp.Server, addr, token string) error {
+	if err := checkMCPHTTPAuth(addr, token); err != nil {
+		return err
+	}
+	var handler http.Handler = server.HTTPHandler()
+	if token != "" {
+		handler = requireBearer(token, handler)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", handler)
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", addr, err)
+	}
+	log.Printf("rubichan mcp: serving streamable HTTP on http://%s/mcp", ln.Addr())
+	if token == "" {
+		log.Print("rubichan mcp: WARNING: no auth token set — every local process can connect")
+	}
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+func requireBearer(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// mcpApprovalChecker builds the approval posture for served tool calls: the
+// interactive chain without its session cache, or hierarchical policy plus
+// blanket approval under --auto-approve.
+func mcpApprovalChecker(cfg *config.Config, cwd string, pc pipelineComponents, auto bool) (agent.ApprovalChecker, error) {
+	var checkers []agent.ApprovalChecker
+	if hc := buildHierarchicalChecker(cfg, configPath, cwd); hc != nil {
+		checkers = append(checkers, hc)
+	}
+	if auto {
+		checkers = append(checkers, agent.AlwaysAutoApprove{})
+		return agent.NewCompositeApprovalChecker(checkers...), nil
+	}
+	checkers = append(checkers, &ruleEngineChecker{classifier: pc.Classifier, engine: pc.RuleEngine})
+	if len(cfg.Agent.TrustRules) > 0 {
+		regexRules, globRules := splitTrustRules(cfg.Agent.TrustRules)
+		if err := agent.ValidateTrustRules(regexRules, globRules); err != nil {
+			return nil, fmt.Errorf("invalid trust rules in config: %w", err)
+		}
+		checkers = append(checkers, agent.NewTrustRuleChecker(regexRules, globRules))
+	}
+	return agent.NewCompositeApprovalChecker(checkers...), nil
+}
+
+// mcpToolPipeline composes the agent's tool pipeline minus the stages that
+// need a conversation (checkpoints for /undo, verdict evaluation, result
+// offloading).
+func mcpToolPipeline(registry *tools.Registry, mws agent.ToolMiddlewares, rt *skills.Runtime) *toolexec.Pipeline {
+	hookAdapter := &toolexec.SkillHookAdapter{Runtime: rt}
+	var chain []toolexec.Middleware
+	chain = append(chain, toolexec.CanonicalizeToolNameMiddleware(registry))
+	chain = append(chain, mws.BeforeHooks...)
+	chain = append(chain, toolexec.HookMiddleware(hookAdapter))
+	chain = append(chain, mws.AfterHooks...)
+	chain = append(chain, toolexec.PostHookMiddleware(hookAdapter))
+	return toolexec.NewPipeline(toolexec.RegistryExecutor(registry), chain...)
+}
+
+// mcpToolServer exposes the tool registry to MCP clients.
+type mcpToolServer struct {
+	registry *tools.Registry
+	pipeline *toolexec.Pipeline
+	checker  agent.ApprovalChecker
+	nextID   atomic.Int64
+}
+
+func (s *mcpToolServer) ListTools(context.Context) []mcp.MCPTool {
+	defs := s.registry.All()
+	out := make([]mcp.MCPTool, 0, len(defs))
+	for _, d := range defs {
+		out = append(out, mcp.MCPTool{Name: d.Name, Description: d.Description, InputSchema: d.InputSchema})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (s *mcpToolServer) CallTool(ctx context.Context, name string, args json.RawMessage) (*mcp.ToolResult, error) {
+	if _, ok := s.registry.Get(name); !ok {
+		return nil, fmt.Errorf("unknown tool %q", name)
+	}
+	switch s.checker.CheckApproval(name, args) {
+	case agent.AutoDenied:
+		return mcpErrorResult(fmt.Sprintf("tool %q is denied by policy", name)), nil
+	case agent.ApprovalRequired:
+		return mcpErrorResult(fmt.Sprintf("tool %q needs approval, which this server cannot ask for; "+
+			"add a trust rule for it or restart with --auto-approve", name)), nil
+	}
+
+	res := s.pipeline.Execute(ctx, toolexec.ToolCall{
+		ID:    fmt.Sprintf("mcp-%d", s.nextID.Add(1)),
+		Name:  name,
+		Input: args,
+	})
+	out := &mcp.ToolResult{IsError: res.IsError}
+	if res.Content != "" || len(res.Media) == 0 {
+		out.Content = append(out.Content, mcp.ContentBlock{Type: "text", Text: res.Content})
+	}
+	for _, m := range res.Media {
+		if m.Source != nil && m.Source.Data != "" && strings.HasPrefix(m.Source.MediaType, "image/") {
+			out.Content = append(out.Content, mcp.ContentBlock{Type: "image", Data: m.Source.Data, MimeType: m.Source.MediaType})
+		}
+	}
+	return out, nil
+}
+
+func mcpErrorResult(msg string) *mcp.ToolResult {
+	return &mcp.ToolResult{IsError: true, Content: []mcp.ContentBlock{{Type: "text", Text: msg}}}
+}
+
+// mcpPromptServer exposes custom slash commands as MCP prompts. Each takes
+// a single free-form "arguments" argument, split on whitespace the way the
+// slash command line is.
+type mcpPromptServer struct {
+	commands []*commands.CustomCommand
+}
+
+func (s *mcpPromptServer) ListPrompts(context.Context) []mcp.Prompt {
+	out := make([]mcp.Prompt, 0, len(s.commands))
+	for _, c := range s.commands {
+		desc := "arguments passed to the command"
+		if hint := c.ArgumentHint(); hint != "" {
+			desc = hint
+		}
+		out = append(out, mcp.Prompt{
+			Name:        c.Name(),
+			Description: c.Description(),
+			Arguments:   []mcp.PromptArgument{{Name: "arguments", Description: desc}},
+		})
+	}
+	return out
+}
+
+func (s *mcpPromptServer) GetPrompt(ctx context.Context, name string, args map[string]string) (*mcp.PromptResult, error) {
+	for _, c := range s.commands {
+		if c.Name() != name {
+			continue
+		}
+		text, err := c.Expand(ctx, strings.Fields(args["arguments"]))
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.PromptResult{
+			Description: c.Description(),
+			Messages:    []mcp.PromptMessage{{Role: "user", Content: mcp.ContentBlock{Type: "text", Text: text}}},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown prompt %q", name)
+}
+
+// knowledgeSearchLimit caps the entities a knowledge://search read returns.
+const knowledgeSearchLimit = 10
+
+// mcpKnowledgeServer exposes the knowledge graph as MCP resources: every
+// entity under knowledge://<id>, and ranked search under
+// knowledge://search?q=<text>.
+type mcpKnowledgeServer struct {
+	graph kg.Graph
+}
+
+func (s *mcpKnowledgeServer) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	entities, err := s.graph.List(ctx, kg.ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]mcp.Resource, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, mcp.Resource{
+			URI:         "knowledge://" + e.ID,
+			Name:        e.Title,
+			Description: string(e.Kind),
+			MimeType:    "text/markdown",
+		})
+	}
+	return out, nil
+}
+
+func (s *mcpKnowledgeServer) ListResourceTemplates(context.Context) ([]mcp.ResourceTemplate, error) {
+	return []mcp.ResourceTemplate{{
+		URITemplate: "knowledge://search{?q}",
+		Name:        "Knowledge search",
+		Description: "Knowledge graph entities ranked by relevance to q",
+		MimeType:    "text/markdown",
+	}}, nil
+}
+
+func (s *mcpKnowledgeServer) ReadResource(ctx context.Context, uri string) ([]mcp.ResourceContents, error) {
+	ref, ok := strings.CutPrefix(uri, "knowledge://")
+	if !ok || ref == "" {
+		return nil, fmt.Errorf("unsupported resource %q", uri)
+	}
+	if query, ok := strings.CutPrefix(ref, "search?"); ok {
+		params, err := url.ParseQuery(query)
+		if err != nil || params.Get("q") == "" {
+			return nil, errors.New("knowledge://search needs a q parameter")
+		}
+		results, err := s.graph.Query(ctx, kg.QueryRequest{Text: params.Get("q"), Limit: knowledgeSearchLimit})
+		if err != nil {
+			return nil, err
+		}
+		out := make([]mcp.ResourceContents, 0, len(results))
+		for _, r := range results {
+			out = append(out, knowledgeContents(r.Entity))
+		}
+		return out, nil
+	}
+	e, err := s.graph.Get(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{knowledgeContents(e)}, nil
+}
+
+func knowledgeContents(e *kg.Entity) mcp.ResourceContents {
+	return mcp.ResourceContents{
+		URI:      "knowledge://" + e.ID,
+		MimeType: "text/markdown",
+		Text:     fmt.Sprintf("# %s\n\n%s", e.Title, e.Body),
+	}
+}
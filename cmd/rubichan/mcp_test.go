@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/agent"
+	"github.com/julianshen/rubichan/internal/commands"
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mcpEchoTool struct{}
+
+func (mcpEchoTool) Name() string                 { return "echo" }
+func (mcpEchoTool) Description() string          { return "Echo the input" }
+func (mcpEchoTool) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (mcpEchoTool) Execute(_ context.Context, input json.RawMessage) (tools.ToolResult, error) {
+	return tools.ToolResult{Content: string(input)}, nil
+}
+
+type mcpFixedChecker agent.ApprovalResult
+
+func (c mcpFixedChecker) CheckApproval(string, json.RawMessage) agent.ApprovalResult {
+	return agent.ApprovalResult(c)
+}
+
+func newMCPToolServer(t *testing.T, checker agent.ApprovalChecker) *mcpToolServer {
+	t.Helper()
+	registry := tools.NewRegistry()
+	require.NoError(t, registry.Register(mcpEchoTool{}))
+	cfg := config.DefaultConfig()
+	pc := buildPipeline(registry, cfg, t.TempDir(), nil)
+	return &mcpToolServer{
+		registry: registry,
+		pipeline: mcpToolPipeline(registry, pc.Middlewares, nil),
+		checker:  checker,
+	}
+}
+
+func TestMCPToolServerCallsThroughPipeline(t *testing.T) {
+	s := newMCPToolServer(t, agent.AlwaysAutoApprove{})
+	list := s.ListTools(context.Background())
+	require.Len(t, list, 1)
+	assert.Equal(t, "echo", list[0].Name)
+
+	res, err := s.CallTool(context.Background(), "echo", json.RawMessage(`{"x":1}`))
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+	require.Len(t, res.Content, 1)
+	assert.Equal(t, `{"x":1}`, res.Content[0].Text)
+
+	_, err = s.CallTool(context.Background(), "missing", json.RawMessage(`{}`))
+	assert.Error(t, err)
+}
+
+func TestMCPToolServerRefusesUnapprovedCalls(t *testing.T) {
+	res, err := newMCPToolServer(t, mcpFixedChecker(agent.ApprovalRequired)).CallTool(context.Background(), "echo", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].Text, "--auto-approve")
+
+	res, err = newMCPToolServer(t, mcpFixedChecker(agent.AutoDenied)).CallTool(context.Background(), "echo", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].Text, "denied by policy")
+}
+
+func TestMCPPromptServerExpandsCommands(t *testing.T) {
+	project := t.TempDir()
+	dir := filepath.Join(project, ".agent", "commands")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fix.md"), []byte("---\ndescription: Fix an issue\nargument-hint: <issue>\n---\nFix issue $1.\n"), 0o644))
+	cmds, err := commands.LoadCustomCommands(commands.CustomCommandOptions{ProjectDir: project})
+	require.NoError(t, err)
+
+	s := &mcpPromptServer{commands: cmds}
+	prompts := s.ListPrompts(context.Background())
+	require.Len(t, prompts, 1)
+	assert.Equal(t, "fix", prompts[0].Name)
+	assert.Equal(t, "<issue>", prompts[0].Arguments[0].Description)
+
+	got, err := s.GetPrompt(context.Background(), "fix", map[string]string{"arguments": "42"})
+	require.NoError(t, err)
+	require.Len(t, got.Messages, 1)
+	assert.Equal(t, "Fix issue 42.", got.Messages[0].Content.Text)
+
+	_, err = s.GetPrompt(context.Background(), "nope", nil)
+	assert.Error(t, err)
+}
+
+func TestCheckMCPHTTPAuth(t *testing.T) {
+	assert.NoError(t, checkMCPHTTPAuth("127.0.0.1:8080", ""))
+	assert.NoError(t, checkMCPHTTPAuth("localhost:8080", ""))
+	assert.NoError(t, checkMCPHTTPAuth("[::1]:8080", ""))
+	assert.NoError(t, checkMCPHTTPAuth("0.0.0.0:8080", "secret"))
+	assert.ErrorContains(t, checkMCPHTTPAuth(":8080", ""), "without --token")
+	assert.ErrorContains(t, checkMCPHTTPAuth("0.0.0.0:8080", ""), "without --token")
+	assert.ErrorContains(t, checkMCPHTTPAuth("192.168.1.5:8080", ""), "without --token")
+	assert.Error(t, checkMCPHTTPAuth("8080", ""))
+}
@@ -8,7 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/julianshen/rubichan/internal/provider/ollama"
+	"github.com/julianshen/rubichan/pkg/provider/ollama"
 )
 
 var defaultOllamaBaseURL = ollama.DefaultBaseURL
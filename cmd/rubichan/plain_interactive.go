@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/julianshen/rubichan/internal/persona"
 	"github.com/julianshen/rubichan/internal/session"
 	"github.com/julianshen/rubichan/internal/skills"
+	"github.com/julianshen/rubichan/internal/tools"
 )
 
 type plainInteractiveHost struct {
@@ -165,6 +167,92 @@ func (h *plainInteractiveHost) CheckApproval(tool string, input json.RawMessage)
 	return agent.ApprovalRequired
 }
 
+// MakeAskHandler returns the UI request handler the ask_user tool uses to
+// ask its questions on the terminal. Options are chosen by number or name;
+// an empty answer takes the default, or declines without one.
+func (h *plainInteractiveHost) MakeAskHandler() agent.UIRequestHandler {
+	return agent.UIRequestFunc(func(ctx context.Context, req agent.UIRequest) (agent.UIResponse, error) {
+		kind := req.Metadata["question_kind"]
+		def := req.Metadata["default"]
+		options := make([]string, 0, len(req.Actions))
+		for _, a := range req.Actions {
+			options = append(options, a.ID)
+		}
+
+		fmt.Fprintf(h.out, "\nQuestion: %s\n", req.Message)
+		for i, opt := range options {
+			fmt.Fprintf(h.out, "  %d) %s\n", i+1, opt)
+		}
+		prompt := "Answer"
+		if kind == tools.AskMulti {
+			prompt = "Choices (comma-separated)"
+		}
+		if def != "" {
+			prompt += fmt.Sprintf(" [%s]", def)
+		}
+
+		for {
+			fmt.Fprintf(h.out, "%s: ", prompt)
+			line, err := h.readLineCtx(ctx)
+			if err != nil {
+				return agent.UIResponse{}, err
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				line = def
+			}
+			resp, ok := plainAskResponse(req.ID, kind, options, line)
+			if ok {
+				return resp, nil
+			}
+			fmt.Fprintln(h.out, "Please pick from the listed options.")
+		}
+	})
+}
+
+// plainAskResponse turns a typed answer into the ask_user response; ok is
+// false when a choice is not one of the options. An empty answer declines.
+func plainAskResponse(requestID, kind string, options []string, line string) (agent.UIResponse, bool) {
+	resp := agent.UIResponse{RequestID: requestID}
+	if line == "" {
+		resp.ActionID = tools.AskCancelAction
+		return resp, true
+	}
+	pick := func(s string) (string, bool) {
+		s = strings.TrimSpace(s)
+		if n, err := strconv.Atoi(s); err == nil && n >= 1 && n <= len(options) {
+			return options[n-1], true
+		}
+		for _, opt := range options {
+			if strings.EqualFold(opt, s) {
+				return opt, true
+			}
+		}
+		return "", false
+	}
+	switch kind {
+	case tools.AskSingle:
+		opt, ok := pick(line)
+		resp.ActionID = opt
+		return resp, ok
+	case tools.AskMulti:
+		answers := []string{}
+		for _, part := range strings.Split(line, ",") {
+			opt, ok := pick(part)
+			if !ok {
+				return resp, false
+			}
+			answers = append(answers, opt)
+		}
+		resp.ActionID = "submit"
+		resp.Values, _ = json.Marshal(tools.AskMultiValues{Answers: answers})
+	default:
+		resp.ActionID = "submit"
+		resp.Values, _ = json.Marshal(tools.AskTextValues{Answer: line})
+	}
+	return resp, true
+}
+
 func (h *plainInteractiveHost) Run(ctx context.Context) error {
 	h.printSessionHeader()
 	for {
@@ -300,6 +388,13 @@ func (h *plainInteractiveHost) handleCommandParts(ctx context.Context, line stri
 	h.emitSessionEvent(session.NewCommandResultEvent(line, result.Output, activated, deactivated))
 	logPlainSlashCommand(line, result.Output, activated, deactivated)
 
+	if result.Prompt != "" {
+		return false, h.runTurnWithOptions(ctx, result.Prompt, agent.TurnOptions{
+			Model:        result.Model,
+			AllowedTools: result.AllowedTools,
+		})
+	}
+
 	switch result.Action {
 	case commands.ActionNone:
 		// Ordinary command. Its Output, if any, was printed above.
@@ -311,6 +406,8 @@ func (h *plainInteractiveHost) handleCommandParts(ctx context.Context, line stri
 		_, _ = fmt.Fprintln(h.out, "Wiki overlay is not available in plain interactive mode.")
 	case commands.ActionResume:
 		_, _ = fmt.Fprintln(h.out, "Resume overlay is not available in plain interactive mode. Restart with --resume <session-id>.")
+	case commands.ActionOpenSearch:
+		_, _ = fmt.Fprintf(h.out, "Search overlay is not available in plain interactive mode. Run: rubichan session search %s\n", result.Query)
 	default:
 		// Every remaining Action opens a TUI overlay, and each of those
 		// commands returns an empty Output — so a missing case here is not a
@@ -324,6 +421,10 @@ func (h *plainInteractiveHost) handleCommandParts(ctx context.Context, line stri
 }
 
 func (h *plainInteractiveHost) runTurn(ctx context.Context, text string) error {
+	return h.runTurnWithOptions(ctx, text, agent.TurnOptions{})
+}
+
+func (h *plainInteractiveHost) runTurnWithOptions(ctx context.Context, text string, opts agent.TurnOptions) error {
 	if h.agent == nil {
 		_, _ = fmt.Fprintln(h.out, persona.ErrorMessage("no agent configured"))
 		return nil
@@ -331,7 +432,7 @@ func (h *plainInteractiveHost) runTurn(ctx context.Context, text string) error {
 	turnCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	ch, err := h.agent.Turn(turnCtx, text)
+	ch, err := h.agent.TurnWithOptions(turnCtx, text, opts)
 	if err != nil {
 		return err
 	}
@@ -374,6 +475,13 @@ func (h *plainInteractiveHost) runTurn(ctx context.Context, text string) error {
 			if evt.ToolProgress != nil {
 				_, _ = fmt.Fprintf(h.out, "[tool-progress:%s] %s\n", evt.ToolProgress.Name, strings.TrimSpace(evt.ToolProgress.Content))
 			}
+		case "plan_update":
+			h.sessionState.ApplyEvent(evt)
+			h.emitSessionEvent(session.NewPlanUpdatedEvent("todo", h.sessionState.Plan()))
+			_, _ = fmt.Fprintln(h.out, "\n[plan]")
+			for _, item := range evt.Plan {
+				_, _ = fmt.Fprintf(h.out, "  [%s] %s\n", item.Status, item.Content)
+			}
 		case "subagent_done":
 			if evt.SubagentResult != nil {
 				h.emitSessionEvent(session.NewSubagentDoneEvent(evt.SubagentResult.Name, evt.Text, evt.SubagentResult.Output))
@@ -11,6 +11,7 @@ import (
 	"github.com/julianshen/rubichan/internal/session"
 	"github.com/julianshen/rubichan/internal/skills"
 	"github.com/julianshen/rubichan/internal/testutil"
+	"github.com/julianshen/rubichan/internal/tools"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -292,3 +293,42 @@ func TestPlainInteractiveOrdinaryCommandStaysQuiet(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotContains(t, out.String(), "not available in plain interactive mode")
 }
+
+func TestPlainAskHandlerAnswersQuestions(t *testing.T) {
+	var events []session.Event
+	in := bytes.NewBufferString("3\n2\n1, darwin\n\n")
+	out := &bytes.Buffer{}
+	host := newPlainInteractiveHost(in, out, "gpt-test", 20, commands.NewRegistry())
+	tool := tools.NewAskUserTool(tools.AskUserConfig{
+		Handler: host.MakeAskHandler(),
+		OnAnswer: func(ans tools.AskUserAnswer) {
+			events = append(events, session.NewUserAnswerEvent(ans.RequestID, ans.Question, ans.Kind, ans.Answers, ans.Defaulted))
+		},
+	})
+	ask := func(input string) string {
+		t.Helper()
+		res, err := tool.Execute(context.Background(), json.RawMessage(input))
+		require.NoError(t, err)
+		require.False(t, res.IsError, res.Content)
+		return res.Content
+	}
+
+	// 3 is not an option, so the question is asked again.
+	assert.Equal(t, "The user answered: postgres",
+		ask(`{"question":"Which database?","options":["sqlite","postgres"]}`))
+	assert.Contains(t, out.String(), "Please pick from the listed options.")
+	assert.Equal(t, "The user selected: linux, darwin",
+		ask(`{"question":"Platforms?","kind":"multi","options":["linux","darwin"]}`))
+	assert.Equal(t, "The user answered: none",
+		ask(`{"question":"Anything else?","default":"none"}`))
+
+	require.Len(t, events, 3)
+	assert.Equal(t, "Which database?", events[0].Answer.Question)
+	assert.Equal(t, []string{"linux", "darwin"}, events[1].Answer.Answers)
+}
+
+func TestPlainAskResponseEmptyDeclines(t *testing.T) {
+	resp, ok := plainAskResponse("q1", tools.AskText, nil, "")
+	require.True(t, ok)
+	assert.Equal(t, tools.AskCancelAction, resp.ActionID)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/security/scanner"
+)
+
+// osvUpdateTimeout bounds a full `security db update`; the npm and PyPI
+// exports are hundreds of megabytes.
+const osvUpdateTimeout = 30 * time.Minute
+
+func securityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "security",
+		Short: "Manage the security scanner",
+	}
+	cmd.AddCommand(securityDBCmd())
+	return cmd
+}
+
+func securityDBCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the offline OSV vulnerability database",
+		Long: `The dependency scanner matches lockfiles against a local index of OSV
+advisories, so scans need no network access. Build it with "update", or with
+"import" from export zips fetched elsewhere (for air-gapped machines).
+
+The index lives at [security] osv_database, by default osv.db in the config
+directory. With [security] osv_online = true, ecosystems missing from the
+index are looked up on api.osv.dev instead.`,
+	}
+	cmd.AddCommand(securityDBUpdateCmd(), securityDBImportCmd())
+	return cmd
+}
+
+func securityDBUpdateCmd() *cobra.Command {
+	var (
+		ecosystems []string
+		url        string
+	)
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Download OSV advisories for every supported ecosystem",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if len(ecosystems) == 0 {
+				ecosystems = scanner.NewDepScanner(nil).Ecosystems()
+			}
+			db, path, err := openOSVDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), osvUpdateTimeout)
+			defer cancel()
+			stats, err := db.Update(ctx, http.DefaultClient, url, ecosystems)
+			if err != nil {
+				return err
+			}
+			printOSVImport(cmd.OutOrStdout(), path, stats)
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&ecosystems, "ecosystem", nil, "OSV ecosystems to update (default: all the scanner supports)")
+	cmd.Flags().StringVar(&url, "url", scanner.DefaultOSVExportURL, "base URL of the OSV export mirror")
+	return cmd
+}
+
+func securityDBImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <all.zip>...",
+		Short: "Import OSV export zips downloaded elsewhere",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, path, err := openOSVDatabase()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			var total scanner.OSVImportStats
+			for _, zipPath := range args {
+				stats, err := db.ImportFile(cmd.Context(), zipPath)
+				if err != nil {
+					return fmt.Errorf("importing %s: %w", zipPath, err)
+				}
+				total.Advisories += stats.Advisories
+				total.Skipped += stats.Skipped
+				total.Ecosystems = append(total.Ecosystems, stats.Ecosystems...)
+			}
+			printOSVImport(cmd.OutOrStdout(), path, total)
+			return nil
+		},
+	}
+}
+
+func printOSVImport(w io.Writer, path string, stats scanner.OSVImportStats) {
+	fmt.Fprintf(w, "Imported %d advisories (%s) into %s", stats.Advisories, strings.Join(stats.Ecosystems, ", "), path)
+	if stats.Skipped > 0 {
+		fmt.Fprintf(w, "; skipped %d withdrawn or unreadable", stats.Skipped)
+	}
+	fmt.Fprintln(w)
+}
+
+// osvDatabasePath resolves where the offline OSV index lives.
+func osvDatabasePath(cfg *config.Config, cfgDir string) string {
+	if cfg.Security.OSVDatabase != "" {
+		return cfg.Security.OSVDatabase
+	}
+	return filepath.Join(cfgDir, "osv.db")
+}
+
+func openOSVDatabase() (*scanner.OSVDB, string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	cfgDir, err := configDir()
+	if err != nil {
+		return nil, "", err
+	}
+	path := osvDatabasePath(cfg, cfgDir)
+	db, err := scanner.OpenOSVDB(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return db, path, nil
+}
+
+// newDepScanner builds the dependency scanner the security engine uses:
+// matched against the offline OSV index when one has been built, and
+// against the OSV API only when [security] osv_online allows it. The
+// returned close function releases the index.
+func newDepScanner(cfg *config.Config, cfgDir string) (*scanner.DepScanner, func()) {
+	var client *http.Client
+	if cfg.Security.OSVOnline {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	deps := scanner.NewDepScanner(client)
+
+	path := osvDatabasePath(cfg, cfgDir)
+	if _, err := os.Stat(path); err != nil {
+		return deps, func() {}
+	}
+	db, err := scanner.OpenOSVDB(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: opening OSV database: %v\n", err)
+		return deps, func() {}
+	}
+	deps.SetDatabase(db)
+	return deps, func() { db.Close() }
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/config"
+)
+
+func TestSecurityDBImport(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "osv.db")
+	cfgFile := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("[security]\nosv_database = \""+dbPath+"\"\n"), 0o644))
+
+	oldConfigPath := configPath
+	defer func() { configPath = oldConfigPath }()
+	configPath = cfgFile
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("GO-2022-1059.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`{"id":"GO-2022-1059","affected":[{"package":{"name":"golang.org/x/text","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"0"},{"fixed":"0.3.8"}]}]}]}`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	zipPath := filepath.Join(dir, "all.zip")
+	require.NoError(t, os.WriteFile(zipPath, buf.Bytes(), 0o644))
+
+	cmd := securityCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"db", "import", zipPath})
+	require.NoError(t, cmd.ExecuteContext(context.Background()))
+	assert.Contains(t, out.String(), "Imported 1 advisories (Go) into "+dbPath)
+
+	cfg := config.DefaultConfig()
+	cfg.Security.OSVDatabase = dbPath
+	deps, closeDeps := newDepScanner(cfg, dir)
+	defer closeDeps()
+	_, ok := deps.VulnDBAge()
+	assert.False(t, ok, "age is only known after a scan")
+}
+
+func TestNewDepScannerWithoutDatabase(t *testing.T) {
+	cfg := config.DefaultConfig()
+	deps, closeDeps := newDepScanner(cfg, t.TempDir())
+	defer closeDeps()
+	require.NotNil(t, deps)
+	assert.Equal(t, filepath.Join("/cfg", "osv.db"), osvDatabasePath(cfg, "/cfg"))
+}
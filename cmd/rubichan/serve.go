@@ -13,7 +13,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/julianshen/rubichan/internal/config"
-	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/tools"
 	ws "github.com/julianshen/rubichan/internal/transport/ws"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
 )
@@ -66,17 +66,13 @@ func runServe() error {
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
-	llm, err := provider.NewProviderWithDebug(cfg, debugMode)
+	llm, err := newLLMProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("create provider: %w", err)
 	}
 
 	agentFactory := func(sessionID string, opts ws.SessionCreatePayload) (*agentsdk.Agent, error) {
-		agentOpts := []agentsdk.Option{}
-		if opts.SystemPrompt != "" {
-			agentOpts = append(agentOpts, agentsdk.WithSystemPrompt(opts.SystemPrompt))
-		}
-		return agentsdk.NewAgent(llm, agentOpts...), nil
+		return newServeAgent(llm, opts)
 	}
 
 	srv := ws.NewServer(ws.ServerConfig{
@@ -114,3 +110,19 @@ func runServe() error {
 		return srv.Shutdown(shutdownCtx)
 	}
 }
+
+// newServeAgent builds the agent behind one WebSocket session. It carries the
+// todo tool and its list, so a client receives the model's plan as
+// plan_update events.
+func newServeAgent(llm agentsdk.LLMProvider, opts ws.SessionCreatePayload) (*agentsdk.Agent, error) {
+	todos := agentsdk.NewTodoList()
+	registry := tools.NewRegistry()
+	if err := registry.Register(tools.NewTodoTool(todos)); err != nil {
+		return nil, fmt.Errorf("registering todo tool: %w", err)
+	}
+	agentOpts := []agentsdk.Option{agentsdk.WithTools(registry), agentsdk.WithTodoList(todos)}
+	if opts.SystemPrompt != "" {
+		agentOpts = append(agentOpts, agentsdk.WithSystemPrompt(opts.SystemPrompt))
+	}
+	return agentsdk.NewAgent(llm, agentOpts...), nil
+}
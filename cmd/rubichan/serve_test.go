@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/tools"
+	ws "github.com/julianshen/rubichan/internal/transport/ws"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServeAgentPublishesThePlan checks a WebSocket session's agent has the
+// todo tool and reports each change to the list as a plan_update event,
+// which the hub forwards to the client.
+func TestServeAgentPublishesThePlan(t *testing.T) {
+	p := &scriptedACPProvider{responses: [][]provider.StreamEvent{
+		{
+			{Type: "tool_use", ToolUse: &provider.ToolUseBlock{ID: "tool_1", Name: tools.TodoName}},
+			{Type: "text_delta", Text: `{"action":"set","items":[{"content":"write test","status":"in_progress"}]}`},
+			{Type: "stop"},
+		},
+		{{Type: "text_delta", Text: "planned"}, {Type: "stop"}},
+	}}
+	a, err := newServeAgent(p, ws.SessionCreatePayload{})
+	require.NoError(t, err)
+
+	events, err := a.Turn(context.Background(), "plan it")
+	require.NoError(t, err)
+	var plans [][]agentsdk.PlanEntry
+	for ev := range events {
+		require.NotEqual(t, "error", ev.Type, "unexpected error: %v", ev.Error)
+		if ev.Type == "plan_update" {
+			plans = append(plans, ev.Plan)
+		}
+	}
+	assert.Equal(t, [][]agentsdk.PlanEntry{
+		{{Content: "write test", Status: agentsdk.PlanEntryInProgress}},
+	}, plans)
+}
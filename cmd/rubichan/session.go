@@ -1,14 +1,18 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 
+	"github.com/julianshen/rubichan/internal/session"
 	"github.com/julianshen/rubichan/internal/store"
 )
 
@@ -20,6 +24,9 @@ func sessionCmd() *cobra.Command {
 	cmd.AddCommand(sessionListCmd())
 	cmd.AddCommand(sessionForkCmd())
 	cmd.AddCommand(sessionDeleteCmd())
+	cmd.AddCommand(sessionSearchCmd())
+	cmd.AddCommand(sessionExportCmd())
+	cmd.AddCommand(sessionImportCmd())
 	return cmd
 }
 
@@ -69,7 +76,8 @@ func sessionListCmd() *cobra.Command {
 }
 
 func sessionForkCmd() *cobra.Command {
-	return &cobra.Command{
+	at := -1
+	cmd := &cobra.Command{
 		Use:   "fork <session-id>",
 		Short: "Fork a session",
 		Args:  cobra.ExactArgs(1),
@@ -82,13 +90,20 @@ func sessionForkCmd() *cobra.Command {
 
 			sourceID := args[0]
 			newID := uuid.New().String()
-			if err := s.ForkSession(sourceID, newID); err != nil {
+			if at >= 0 {
+				err = s.ForkSessionAtTurn(sourceID, newID, at)
+			} else {
+				err = s.ForkSession(sourceID, newID)
+			}
+			if err != nil {
 				return err
 			}
 			fmt.Printf("Forked session %s → %s\nResume with: rubichan --resume %s\n", truncateID(sourceID), truncateID(newID), newID)
 			return nil
 		},
 	}
+	cmd.Flags().IntVar(&at, "at", -1, "fork from the turn containing this message (see session search)")
+	return cmd
 }
 
 func sessionDeleteCmd() *cobra.Command {
@@ -112,6 +127,260 @@ func sessionDeleteCmd() *cobra.Command {
 	}
 }
 
+func sessionSearchCmd() *cobra.Command {
+	var dir, since, until, tool, model string
+	var all bool
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search the messages of stored sessions",
+		Long: `Search the text, tool calls and tool results of stored sessions.
+
+Every word of the query must match; end a word with * to match a prefix.
+Results are limited to sessions run in the current directory unless --all
+or --dir is given.
+
+Examples:
+  rubichan session search flaky migration test --since 7d
+  rubichan session search "deadlock" --tool shell --all`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			q, err := buildSearchQuery(strings.Join(args, " "), dir, all, since, until, time.Now())
+			if err != nil {
+				return err
+			}
+			q.Tool, q.Model, q.Limit = tool, model, limit
+
+			s, err := openSessionStore()
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			hits, err := s.SearchMessages(q)
+			if err != nil {
+				return err
+			}
+			renderSearchHits(os.Stdout, hits)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "", "search sessions run in this directory or below it (default: current directory)")
+	cmd.Flags().BoolVar(&all, "all", false, "search sessions from all directories")
+	cmd.Flags().StringVar(&since, "since", "", "only messages since a duration ago (7d, 12h) or a date (2026-01-02)")
+	cmd.Flags().StringVar(&until, "until", "", "only messages before a duration ago or a date")
+	cmd.Flags().StringVar(&tool, "tool", "", "only messages calling this tool or carrying its result")
+	cmd.Flags().StringVar(&model, "model", "", "only sessions run with this model")
+	cmd.Flags().IntVar(&limit, "limit", 20, "maximum number of results")
+	return cmd
+}
+
+// buildSearchQuery turns the search command's flags into a store query.
+func buildSearchQuery(text, dir string, all bool, since, until string, now time.Time) (store.SearchQuery, error) {
+	q := store.SearchQuery{Text: text}
+	switch {
+	case dir != "":
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return q, err
+		}
+		q.WorkingDir = abs
+	case !all:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return q, err
+		}
+		q.WorkingDir = cwd
+	}
+	if since != "" {
+		t, err := parseUsageSince(since, now)
+		if err != nil {
+			return q, err
+		}
+		q.Since = t
+	}
+	if until != "" {
+		t, err := parseUsageSince(until, now)
+		if err != nil {
+			return q, fmt.Errorf("invalid --until %q: want a date (2026-01-02) or duration (7d, 12h)", until)
+		}
+		q.Until = t
+	}
+	return q, nil
+}
+
+// renderSearchHits prints each hit with its session, turn and snippet.
+func renderSearchHits(out io.Writer, hits []store.SearchHit) {
+	if len(hits) == 0 {
+		fmt.Fprintln(out, "No matching messages.")
+		return
+	}
+	for _, h := range hits {
+		title := h.SessionTitle
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Fprintf(out, "%s  %s  %s  (%s)\n", h.SessionID, h.CreatedAt.Local().Format("2006-01-02 15:04"), title, h.Model)
+		detail := fmt.Sprintf("message %d · %s", h.Seq, h.Role)
+		if len(h.Tools) > 0 {
+			detail += " · " + strings.Join(h.Tools, ", ")
+		}
+		fmt.Fprintf(out, "    %s · %s\n", detail, h.WorkingDir)
+		fmt.Fprintf(out, "    %s\n\n", strings.Join(strings.Fields(h.Snippet), " "))
+	}
+	fmt.Fprintln(out, "Resume with: rubichan --resume <session-id>; fork at a message with: rubichan session fork <session-id> --at <message>")
+}
+
+func sessionExportCmd() *cobra.Command {
+	var format, output string
+	cmd := &cobra.Command{
+		Use:   "export <session-id>",
+		Short: "Export a session to Markdown, HTML or a JSON bundle",
+		Long: `Export a stored session.
+
+Formats:
+  markdown  a readable transcript
+  html      a self-contained page with collapsible tool calls and diffs
+  json      a versioned bundle of the session's messages, compaction
+            snapshot, tool-result blobs and checkpoints, for
+            "rubichan session import" on another machine
+
+The format defaults to the extension of --output (.md, .html, .json),
+or markdown when writing to stdout.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			f, err := exportFormat(format, output)
+			if err != nil {
+				return err
+			}
+
+			s, err := openSessionStore()
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			b, err := s.ExportSession(args[0])
+			if err != nil {
+				return err
+			}
+			if output == "" || output == "-" {
+				return writeSessionExport(os.Stdout, b, f)
+			}
+			out, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("create %s: %w", output, err)
+			}
+			if err := writeSessionExport(out, b, f); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Exported session %s to %s\n", truncateID(b.Session.ID), output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "", "markdown, html or json")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to this file instead of stdout")
+	return cmd
+}
+
+// exportFormat picks the export format from the --format flag, falling
+// back to the extension of the output path.
+func exportFormat(format, output string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(output)) {
+		case ".html", ".htm":
+			format = "html"
+		case ".json":
+			format = "json"
+		default:
+			format = "markdown"
+		}
+	}
+	switch format {
+	case "markdown", "md":
+		return "markdown", nil
+	case "html", "json":
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown export format %q: want markdown, html or json", format)
+}
+
+// writeSessionExport renders a session bundle in the given format.
+func writeSessionExport(out io.Writer, b *store.Bundle, format string) error {
+	switch format {
+	case "html":
+		return session.RenderHTML(out, b)
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(b)
+	default:
+		return session.RenderMarkdown(out, b)
+	}
+}
+
+func sessionImportCmd() *cobra.Command {
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "import <bundle.json>",
+		Short: "Import a session exported with session export --format json",
+		Long: `Import a session bundle written by "rubichan session export --format json".
+
+The session keeps its ID and can then be resumed with --resume or forked
+with "rubichan session fork". Use --dir when the project lives in a
+different directory on this machine. Pass - to read the bundle from stdin.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			b, err := readSessionBundle(args[0])
+			if err != nil {
+				return err
+			}
+			if dir != "" {
+				if dir, err = filepath.Abs(dir); err != nil {
+					return err
+				}
+			}
+
+			s, err := openSessionStore()
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			if err := s.ImportSession(b, dir); err != nil {
+				return err
+			}
+			fmt.Printf("Imported session %s (%d messages)\nResume with: rubichan --resume %s\n",
+				truncateID(b.Session.ID), len(b.Messages), b.Session.ID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "", "working directory to record for the session (default: the exported one)")
+	return cmd
+}
+
+// readSessionBundle decodes a JSON session bundle from path, or stdin for "-".
+func readSessionBundle(path string) (*store.Bundle, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var b store.Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("read session bundle: %w", err)
+	}
+	return &b, nil
+}
+
 func openSessionStore() (*store.Store, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
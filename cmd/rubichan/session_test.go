@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+func TestBuildSearchQuery(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	q, err := buildSearchQuery("flaky test", "", false, "7d", "2026-03-09", now)
+	require.NoError(t, err)
+	assert.Equal(t, "flaky test", q.Text)
+	assert.Equal(t, cwd, q.WorkingDir, "defaults to the current directory")
+	assert.Equal(t, now.AddDate(0, 0, -7), q.Since)
+	assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), q.Until)
+
+	q, err = buildSearchQuery("x", "", true, "", "", now)
+	require.NoError(t, err)
+	assert.Empty(t, q.WorkingDir)
+	assert.True(t, q.Since.IsZero())
+
+	q, err = buildSearchQuery("x", "/work/api", false, "", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, "/work/api", q.WorkingDir)
+
+	_, err = buildSearchQuery("x", "", true, "", "soon", now)
+	assert.ErrorContains(t, err, "--until")
+}
+
+func TestRenderSearchHits(t *testing.T) {
+	var out bytes.Buffer
+	renderSearchHits(&out, nil)
+	assert.Contains(t, out.String(), "No matching messages")
+
+	out.Reset()
+	renderSearchHits(&out, []store.SearchHit{{
+		SessionID:  "0123456789",
+		Model:      "claude",
+		WorkingDir: "/work/api",
+		Seq:        4,
+		Role:       "user",
+		Tools:      []string{"shell"},
+		Snippet:    "the «flaky»\nmigration",
+	}})
+	text := out.String()
+	assert.Contains(t, text, "0123456789")
+	assert.Contains(t, text, "(untitled)")
+	assert.Contains(t, text, "message 4 · user · shell · /work/api")
+	assert.Contains(t, text, "the «flaky» migration")
+}
+
+func TestSessionCmdHasSearch(t *testing.T) {
+	var subNames []string
+	for _, sub := range sessionCmd().Commands() {
+		subNames = append(subNames, sub.Name())
+	}
+	assert.Contains(t, subNames, "search")
+
+	cmd := sessionSearchCmd()
+	cmd.SetArgs([]string{})
+	assert.Error(t, cmd.Execute(), "a query is required")
+}
+
+func TestExportFormat(t *testing.T) {
+	for _, tc := range []struct{ format, output, want string }{
+		{"", "", "markdown"},
+		{"", "out.html", "html"},
+		{"", "bundle.JSON", "json"},
+		{"", "notes.txt", "markdown"},
+		{"md", "out.json", "markdown"},
+		{"json", "", "json"},
+	} {
+		got, err := exportFormat(tc.format, tc.output)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "format %q output %q", tc.format, tc.output)
+	}
+	_, err := exportFormat("pdf", "")
+	assert.ErrorContains(t, err, "unknown export format")
+}
+
+func TestSessionExportJSONRoundTrip(t *testing.T) {
+	b := &store.Bundle{
+		Version:  store.BundleVersion,
+		Session:  store.BundleSession{ID: "s1", Model: "m"},
+		Messages: []store.BundleMessage{{Seq: 0, Role: "user"}},
+	}
+	var out bytes.Buffer
+	require.NoError(t, writeSessionExport(&out, b, "json"))
+
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0o644))
+	got, err := readSessionBundle(path)
+	require.NoError(t, err)
+	assert.Equal(t, "s1", got.Session.ID)
+	assert.Len(t, got.Messages, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+	_, err = readSessionBundle(path)
+	assert.ErrorContains(t, err, "read session bundle")
+
+	out.Reset()
+	require.NoError(t, writeSessionExport(&out, b, "html"))
+	assert.Contains(t, out.String(), "<!DOCTYPE html>")
+}
+
+func TestSessionCmdHasExportAndImport(t *testing.T) {
+	var subNames []string
+	for _, sub := range sessionCmd().Commands() {
+		subNames = append(subNames, sub.Name())
+	}
+	assert.Contains(t, subNames, "export")
+	assert.Contains(t, subNames, "import")
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/julianshen/rubichan/internal/commands"
 	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/internal/shell"
+	"github.com/julianshen/rubichan/internal/skills/mcpbackend"
 	"github.com/julianshen/rubichan/internal/tools"
 )
 
@@ -53,10 +54,11 @@ func runShell() error {
 	defer wtCleanup()
 
 	// Create provider.
-	p, err := provider.NewProviderWithDebug(cfg, debugMode)
+	p, err := newLLMProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("creating provider: %w", err)
 	}
+	meter := agent.NewUsageMeter()
 
 	// Create tool registry.
 	registry := tools.NewRegistry()
@@ -114,7 +116,7 @@ func runShell() error {
 	// Create skill runtime.
 	ctx := context.Background()
 	opts = appendKnowledgeGraphOption(ctx, opts, cwd)
-	rt, storeCloser, err := createSkillRuntime(ctx, registry, p, cfg, "shell", cwd, cfgDir)
+	rt, storeCloser, err := createSkillRuntime(ctx, registry, meter.Wrap(p, cfg.Provider.Model), cfg, "shell", cwd, cfgDir)
 	if err != nil {
 		return fmt.Errorf("creating skill runtime: %w", err)
 	}
@@ -122,7 +124,7 @@ func runShell() error {
 		defer storeCloser.Close()
 	}
 	if rt != nil {
-		opts = append(opts, agent.WithSkillRuntime(rt))
+		opts = append(opts, agent.WithSkillRuntime(rt), agent.WithMentionResolver(mcpbackend.MentionResolver(rt)))
 	}
 
 	// Create command registry and register built-in slash commands.
@@ -143,6 +145,7 @@ func runShell() error {
 	}
 
 	// Create agent.
+	opts = append(opts, agent.WithUsageMeter(meter))
 	a := agent.New(p, registry, approvalFunc, cfg, opts...)
 
 	// Register model command (needs the agent instance).
@@ -151,6 +154,7 @@ func runShell() error {
 	})); err != nil {
 		return fmt.Errorf("register built-in command %q: %w", "model", err)
 	}
+	registerCustomCommands(cmdRegistry, loadCustomCommands(cfg, cwd, cfgDir))
 
 	// Scan PATH for known executables.
 	executables := shell.ScanPATH()
@@ -187,7 +191,7 @@ func runShell() error {
 		HomeDir:          homeDir,
 		AgentTurn:        makeAgentTurnFunc(a),
 		ShellExec:        makeShellExecFunc(registry),
-		SlashCommandFn:   makeSlashCommandFunc(cmdRegistry),
+		SlashCommandFn:   makeSlashCommandFunc(cmdRegistry, a),
 		Executables:      executables,
 		Stdin:            os.Stdin,
 		Stdout:           os.Stdout,
@@ -314,64 +318,84 @@ func makeShellExecFunc(registry *tools.Registry) shell.ShellExecFunc {
 // makeAgentTurnFunc wraps agent.Turn into a shell.AgentTurnFunc.
 func makeAgentTurnFunc(a *agent.Agent) shell.AgentTurnFunc {
 	return func(ctx context.Context, userMessage string) (<-chan shell.TurnEvent, error) {
-		agentEvents, err := a.Turn(ctx, userMessage)
-		if err != nil {
-			return nil, err
-		}
+		return shellTurn(ctx, a, userMessage, agent.TurnOptions{})
+	}
+}
+
+// shellTurn runs an agent turn and converts its events to shell.TurnEvent.
+func shellTurn(ctx context.Context, a *agent.Agent, userMessage string, opts agent.TurnOptions) (<-chan shell.TurnEvent, error) {
+	agentEvents, err := a.TurnWithOptions(ctx, userMessage, opts)
+	if err != nil {
+		return nil, err
+	}
 
-		ch := make(chan shell.TurnEvent, 16)
-		go func() {
-			defer close(ch)
-			for event := range agentEvents {
-				switch event.Type {
-				case "text_delta":
-					ch <- shell.TurnEvent{Type: "text_delta", Text: event.Text}
-				case "tool_call":
-					toolName := ""
-					if event.ToolCall != nil {
-						toolName = event.ToolCall.Name
-					}
-					ch <- shell.TurnEvent{Type: "tool_call", ToolName: toolName}
-				case "tool_result":
-					text := ""
-					if event.ToolResult != nil {
-						text = event.ToolResult.Content
-					}
-					ch <- shell.TurnEvent{Type: "tool_result", Text: text}
-				case "done":
-					ch <- shell.TurnEvent{Type: "done"}
-				case "error":
-					errText := ""
-					if event.Error != nil {
-						errText = event.Error.Error()
-					}
-					ch <- shell.TurnEvent{Type: "error", Text: errText}
+	ch := make(chan shell.TurnEvent, 16)
+	go func() {
+		defer close(ch)
+		for event := range agentEvents {
+			switch event.Type {
+			case "text_delta":
+				ch <- shell.TurnEvent{Type: "text_delta", Text: event.Text}
+			case "tool_call":
+				toolName := ""
+				if event.ToolCall != nil {
+					toolName = event.ToolCall.Name
 				}
+				ch <- shell.TurnEvent{Type: "tool_call", ToolName: toolName}
+			case "tool_result":
+				text := ""
+				if event.ToolResult != nil {
+					text = event.ToolResult.Content
+				}
+				ch <- shell.TurnEvent{Type: "tool_result", Text: text}
+			case "done":
+				ch <- shell.TurnEvent{Type: "done"}
+			case "error":
+				errText := ""
+				if event.Error != nil {
+					errText = event.Error.Error()
+				}
+				ch <- shell.TurnEvent{Type: "error", Text: errText}
 			}
-		}()
+		}
+	}()
 
-		return ch, nil
-	}
+	return ch, nil
 }
 
 // makeSlashCommandFunc wraps the commands.Registry into a shell.SlashCommandFunc.
-func makeSlashCommandFunc(registry *commands.Registry) shell.SlashCommandFunc {
-	return func(ctx context.Context, name string, args []string) (string, bool, error) {
+// Commands that expand to a prompt run it as a turn on a; a may be nil when
+// no agent is available.
+func makeSlashCommandFunc(registry *commands.Registry, a *agent.Agent) shell.SlashCommandFunc {
+	return func(ctx context.Context, name string, args []string) (shell.SlashResult, error) {
 		cmd, ok := registry.Get(name)
 		if !ok {
-			return fmt.Sprintf("unknown command: /%s", name), false, nil
+			return shell.SlashResult{Output: fmt.Sprintf("unknown command: /%s", name)}, nil
 		}
 
 		result, err := cmd.Execute(ctx, args)
 		if err != nil {
-			return "", false, err
+			return shell.SlashResult{}, err
+		}
+
+		if result.Prompt != "" {
+			if a == nil {
+				return shell.SlashResult{}, fmt.Errorf("agent not available")
+			}
+			opts := agent.TurnOptions{Model: result.Model, AllowedTools: result.AllowedTools}
+			return shell.SlashResult{
+				Output: result.Output,
+				Turn: func(ctx context.Context) (<-chan shell.TurnEvent, error) {
+					return shellTurn(ctx, a, result.Prompt, opts)
+				},
+			}, nil
 		}
 
 		switch result.Action {
 		case commands.ActionNone:
-			return result.Output, false, nil
+			return shell.SlashResult{Output: result.Output}, nil
 		case commands.ActionQuit:
-			return result.Output, true, nil
+			return shell.SlashResult{Output: result.Output, Quit: true}, nil
 		default:
 			// Every remaining action opens a TUI overlay, and those commands
 			// return an empty Output — so testing only for ActionQuit and
@@ -382,7 +406,7 @@ func makeSlashCommandFunc(registry *commands.Registry) shell.SlashCommandFunc {
 			if result.Output != "" {
 				msg = result.Output + "\n" + msg
 			}
-			return msg, false, nil
+			return shell.SlashResult{Output: msg}, nil
 		}
 	}
 }
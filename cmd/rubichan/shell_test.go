@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,24 +50,24 @@ func TestMakeSlashCommandFunc(t *testing.T) {
 	require.NoError(t, registry.Register(commands.NewQuitCommand()))
 	require.NoError(t, registry.Register(commands.NewHelpCommand(registry)))
 
-	fn := makeSlashCommandFunc(registry)
+	fn := makeSlashCommandFunc(registry, nil)
 
 	// Unknown command
-	output, quit, err := fn(context.Background(), "nonexistent", nil)
+	res, err := fn(context.Background(), "nonexistent", nil)
 	require.NoError(t, err)
-	assert.False(t, quit)
-	assert.Contains(t, output, "unknown command")
+	assert.False(t, res.Quit)
+	assert.Contains(t, res.Output, "unknown command")
 
 	// Quit command
-	_, quit, err = fn(context.Background(), "quit", nil)
+	res, err = fn(context.Background(), "quit", nil)
 	require.NoError(t, err)
-	assert.True(t, quit)
+	assert.True(t, res.Quit)
 
 	// Help command
-	output, quit, err = fn(context.Background(), "help", nil)
+	res, err = fn(context.Background(), "help", nil)
 	require.NoError(t, err)
-	assert.False(t, quit)
-	assert.NotEmpty(t, output)
+	assert.False(t, res.Quit)
+	assert.NotEmpty(t, res.Output)
 }
 
 func TestErrExitIsExported(t *testing.T) {
@@ -87,13 +89,13 @@ func TestMakeSlashCommandFuncReportsOverlayActions(t *testing.T) {
 	registry := commands.NewRegistry()
 	require.NoError(t, registry.Register(commands.NewModelCommand(func(string) {})))
 
-	fn := makeSlashCommandFunc(registry)
+	fn := makeSlashCommandFunc(registry, nil)
 
-	output, quit, err := fn(context.Background(), "model", nil)
+	res, err := fn(context.Background(), "model", nil)
 	require.NoError(t, err)
-	assert.False(t, quit, "an unsupported command must not end the shell")
-	assert.NotEmpty(t, output, "a command that does nothing must at least say so")
-	assert.Contains(t, output, "not available in shell mode")
+	assert.False(t, res.Quit, "an unsupported command must not end the shell")
+	assert.NotEmpty(t, res.Output, "a command that does nothing must at least say so")
+	assert.Contains(t, res.Output, "not available in shell mode")
 }
 
 // TestMakeSlashCommandFuncStaysQuietForOrdinaryCommands guards the other side:
@@ -104,9 +106,28 @@ func TestMakeSlashCommandFuncStaysQuietForOrdinaryCommands(t *testing.T) {
 	registry := commands.NewRegistry()
 	require.NoError(t, registry.Register(commands.NewHelpCommand(registry)))
 
-	fn := makeSlashCommandFunc(registry)
+	fn := makeSlashCommandFunc(registry, nil)
 
-	output, _, err := fn(context.Background(), "help", nil)
+	res, err := fn(context.Background(), "help", nil)
 	require.NoError(t, err)
-	assert.NotContains(t, output, "not available in shell mode")
+	assert.NotContains(t, res.Output, "not available in shell mode")
+}
+
+// TestMakeSlashCommandFuncPromptNeedsAgent checks that a command expanding to
+// a prompt reports the missing agent instead of printing nothing.
+func TestMakeSlashCommandFuncPromptNeedsAgent(t *testing.T) {
+	t.Parallel()
+
+	project := t.TempDir()
+	dir := filepath.Join(project, ".agent", "commands")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "triage.md"), []byte("Triage $1\n"), 0o644))
+	custom, err := commands.LoadCustomCommands(commands.CustomCommandOptions{ProjectDir: project})
+	require.NoError(t, err)
+	registry := commands.NewRegistry()
+	require.NoError(t, registry.Register(custom[0]))
+
+	_, err = makeSlashCommandFunc(registry, nil)(context.Background(), "triage", []string{"#12"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "agent not available")
 }
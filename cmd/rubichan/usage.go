@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+func usageCmd() *cobra.Command {
+	var by, since, month, project, format string
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report recorded token usage and cost",
+		Long: `Report token usage and cost recorded for every provider call.
+
+Costs are priced when the call is made, using the built-in price table plus
+any [pricing] entries in config.toml. Days are UTC.
+
+Examples:
+  rubichan usage                            # daily totals for the last 30 days
+  rubichan usage --by model --since 7d
+  rubichan usage --by project --month 2026-03 --format csv`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			q, err := buildUsageQuery(by, since, month, project, time.Now())
+			if err != nil {
+				return err
+			}
+			s, err := openSessionStore()
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			rows, err := s.UsageReport(q)
+			if err != nil {
+				return err
+			}
+			return renderUsage(os.Stdout, q.GroupBy, rows, format)
+		},
+	}
+	cmd.Flags().StringVar(&by, "by", "day", "group by: day, model, project or session")
+	cmd.Flags().StringVar(&since, "since", "30d", "report usage since a duration ago (7d, 12h) or a date (2026-01-02)")
+	cmd.Flags().StringVar(&month, "month", "", "report a calendar month (2026-03); overrides --since")
+	cmd.Flags().StringVar(&project, "project", "", "limit to one project directory (\".\" for the current one)")
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json or csv")
+	return cmd
+}
+
+// buildUsageQuery turns the usage command's flags into a store query.
+func buildUsageQuery(by, since, month, project string, now time.Time) (store.UsageQuery, error) {
+	q := store.UsageQuery{GroupBy: store.UsageGroup(strings.ToLower(strings.TrimSpace(by)))}
+	switch q.GroupBy {
+	case store.UsageByDay, store.UsageByModel, store.UsageByProject, store.UsageBySession:
+	default:
+		return q, fmt.Errorf("unsupported --by %q (want day, model, project or session)", by)
+	}
+
+	if month != "" {
+		start, err := time.ParseInLocation("2006-01", month, time.UTC)
+		if err != nil {
+			return q, fmt.Errorf("invalid --month %q: want YYYY-MM", month)
+		}
+		q.Since, q.Until = start, start.AddDate(0, 1, 0)
+	} else if since != "" {
+		start, err := parseUsageSince(since, now)
+		if err != nil {
+			return q, err
+		}
+		q.Since = start
+	}
+
+	if project != "" {
+		q.Project = project
+		if project == "." {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return q, err
+			}
+			q.Project = cwd
+		}
+	}
+	return q, nil
+}
+
+// parseUsageSince accepts a date (2006-01-02), a day count (30d) or any
+// time.ParseDuration value, and returns the start of the range.
+func parseUsageSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.UTC); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since %q: want a date (2026-01-02) or duration (7d, 12h)", s)
+	}
+	return now.Add(-d), nil
+}
+
+// usageRowJSON is the json/csv shape of one report row.
+type usageRowJSON struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens"`
+	ReasoningTokens  int     `json:"reasoning_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+func renderUsage(out io.Writer, group store.UsageGroup, rows []store.UsageSummary, format string) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "text":
+		return renderUsageText(out, group, rows)
+	case "json":
+		payload := make([]usageRowJSON, len(rows))
+		for i, r := range rows {
+			payload[i] = usageRowJSON(r)
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]any{"group_by": group, "rows": payload})
+	case "csv":
+		w := csv.NewWriter(out)
+		_ = w.Write([]string{string(group), "calls", "input_tokens", "output_tokens", "cache_read_tokens", "cache_write_tokens", "reasoning_tokens", "cost_usd"})
+		for _, r := range rows {
+			_ = w.Write([]string{
+				r.Key,
+				strconv.Itoa(r.Calls),
+				strconv.Itoa(r.InputTokens),
+				strconv.Itoa(r.OutputTokens),
+				strconv.Itoa(r.CacheReadTokens),
+				strconv.Itoa(r.CacheWriteTokens),
+				strconv.Itoa(r.ReasoningTokens),
+				strconv.FormatFloat(r.CostUSD, 'f', 4, 64),
+			})
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		return fmt.Errorf("unsupported usage format %q", format)
+	}
+}
+
+func renderUsageText(out io.Writer, group store.UsageGroup, rows []store.UsageSummary) error {
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(out, "No usage recorded in this range.")
+		return err
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\tCALLS\tINPUT\tOUTPUT\tCACHE READ\tCACHE WRITE\tCOST\t\n", strings.ToUpper(string(group)))
+	var total store.UsageSummary
+	for _, r := range rows {
+		key := r.Key
+		if key == "" {
+			key = "(unknown)"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t$%.2f\t\n", key, r.Calls, r.InputTokens, r.OutputTokens, r.CacheReadTokens, r.CacheWriteTokens, r.CostUSD)
+		total.Calls += r.Calls
+		total.InputTokens += r.InputTokens
+		total.OutputTokens += r.OutputTokens
+		total.CacheReadTokens += r.CacheReadTokens
+		total.CacheWriteTokens += r.CacheWriteTokens
+		total.CostUSD += r.CostUSD
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%d\t%d\t%d\t$%.2f\t\n", total.Calls, total.InputTokens, total.OutputTokens, total.CacheReadTokens, total.CacheWriteTokens, total.CostUSD)
+	return tw.Flush()
+}
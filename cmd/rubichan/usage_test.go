@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+func TestUsageCmdFlags(t *testing.T) {
+	cmd := usageCmd()
+	assert.Equal(t, "usage", cmd.Use)
+	for _, name := range []string{"by", "since", "month", "project", "format"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing flag %q", name)
+	}
+	assert.Equal(t, "day", cmd.Flags().Lookup("by").DefValue)
+	assert.Equal(t, "30d", cmd.Flags().Lookup("since").DefValue)
+}
+
+func TestParseUsageSince(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseUsageSince("7d", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC), got)
+
+	got, err = parseUsageSince("12h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-12*time.Hour), got)
+
+	got, err = parseUsageSince("2026-02-01", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), got)
+
+	_, err = parseUsageSince("soon", now)
+	assert.Error(t, err)
+	_, err = parseUsageSince("-3h", now)
+	assert.Error(t, err)
+}
+
+func TestBuildUsageQuery(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	q, err := buildUsageQuery("Model", "30d", "", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, store.UsageByModel, q.GroupBy)
+	assert.Equal(t, now.AddDate(0, 0, -30), q.Since)
+	assert.True(t, q.Until.IsZero())
+
+	q, err = buildUsageQuery("project", "30d", "2026-02", "/repo", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), q.Since, "--month overrides --since")
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), q.Until)
+	assert.Equal(t, "/repo", q.Project)
+
+	q, err = buildUsageQuery("day", "", "", ".", now)
+	require.NoError(t, err)
+	assert.NotEqual(t, ".", q.Project)
+	assert.True(t, q.Since.IsZero())
+
+	_, err = buildUsageQuery("week", "", "", "", now)
+	assert.ErrorContains(t, err, "unsupported --by")
+	_, err = buildUsageQuery("day", "", "March", "", now)
+	assert.ErrorContains(t, err, "invalid --month")
+}
+
+var usageRows = []store.UsageSummary{
+	{Key: "claude-sonnet-4-5", Calls: 3, InputTokens: 1000, OutputTokens: 200, CacheReadTokens: 5000, CostUSD: 1.25},
+	{Key: "gpt-4o", Calls: 1, InputTokens: 100, OutputTokens: 20, ReasoningTokens: 4, CostUSD: 0.5},
+}
+
+func TestRenderUsageText(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, renderUsage(&buf, store.UsageByModel, usageRows, "text"))
+	out := buf.String()
+	assert.Contains(t, out, "MODEL")
+	assert.Contains(t, out, "claude-sonnet-4-5")
+	assert.Contains(t, out, "$1.25")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[3], "TOTAL")
+	assert.Contains(t, lines[3], "$1.75")
+}
+
+func TestRenderUsageTextEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, renderUsage(&buf, store.UsageByDay, nil, "text"))
+	assert.Contains(t, buf.String(), "No usage recorded")
+}
+
+func TestRenderUsageJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, renderUsage(&buf, store.UsageByModel, usageRows, "json"))
+
+	var got struct {
+		GroupBy string         `json:"group_by"`
+		Rows    []usageRowJSON `json:"rows"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "model", got.GroupBy)
+	require.Len(t, got.Rows, 2)
+	assert.Equal(t, 4, got.Rows[1].ReasoningTokens)
+	assert.InDelta(t, 1.25, got.Rows[0].CostUSD, 1e-9)
+}
+
+func TestRenderUsageCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, renderUsage(&buf, store.UsageByProject, usageRows, "csv"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "project,calls,"))
+	assert.Equal(t, "gpt-4o,1,100,20,0,0,4,0.5000", lines[2])
+}
+
+func TestRenderUsageUnknownFormat(t *testing.T) {
+	err := renderUsage(&bytes.Buffer{}, store.UsageByDay, usageRows, "xml")
+	assert.ErrorContains(t, err, "unsupported usage format")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/julianshen/rubichan/internal/wiki"
+)
+
+// wikiCmd groups commands for working with a generated wiki. Generation
+// itself runs through --wiki or the generate_wiki tool.
+func wikiCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wiki",
+		Short: "Work with a generated project wiki",
+	}
+	cmd.AddCommand(wikiServeCmd())
+	return cmd
+}
+
+func wikiServeCmd() *cobra.Command {
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "serve [dir]",
+		Short: "Preview a wiki generated in the html format",
+		Long: `Serve a wiki generated with --wiki-format html over HTTP for local preview.
+
+The site is plain static files, so publishing it only needs a copy of the
+output directory; this command is a convenience for reviewing it.
+
+Examples:
+  rubichan --wiki --wiki-format html && rubichan wiki serve
+  rubichan wiki serve site/wiki --addr 127.0.0.1:9000`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "docs/wiki"
+			if len(args) == 1 {
+				dir = args[0]
+			}
+			handler, err := wiki.PreviewHandler(dir)
+			if err != nil {
+				return err
+			}
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("listen %s: %w", addr, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Serving %s at http://%s/ (Ctrl+C to stop)\n", dir, ln.Addr())
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return serveWiki(ctx, ln, handler)
+		},
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8000", "listen address (host:port)")
+	return cmd
+}
+
+// serveWiki serves handler on ln until ctx is done.
+func serveWiki(ctx context.Context, ln net.Listener, handler http.Handler) error {
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
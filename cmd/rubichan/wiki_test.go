@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/wiki"
+)
+
+func TestWikiServeCmdFlags(t *testing.T) {
+	cmd := wikiCmd()
+	serve, _, err := cmd.Find([]string{"serve"})
+	require.NoError(t, err)
+	assert.Equal(t, "serve [dir]", serve.Use)
+	assert.Equal(t, "127.0.0.1:8000", serve.Flags().Lookup("addr").DefValue)
+}
+
+func TestWikiServeRequiresHTMLSite(t *testing.T) {
+	cmd := wikiCmd()
+	cmd.SetArgs([]string{"serve", t.TempDir()})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, "no HTML wiki")
+}
+
+func TestServeWikiStopsOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, wiki.Render([]wiki.Document{{Path: "_index.md", Title: "Home", Content: "# Home\n"}},
+		wiki.RendererConfig{Format: "html", OutputDir: dir}))
+	handler, err := wiki.PreviewHandler(dir)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveWiki(ctx, ln, handler) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "Home")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
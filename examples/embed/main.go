@@ -21,8 +21,10 @@
 // internal/ dependency.
 //
 // The example is self-contained — it uses a tiny canned provider so it runs
-// with no API key. A real embedder would supply its own LLMProvider
-// implementation (Anthropic, OpenAI, Ollama, …) instead.
+// with no API key. With ANTHROPIC_API_KEY set it talks to the real Anthropic
+// API through pkg/provider/anthropic instead; pkg/provider also ships the
+// OpenAI-compatible, Ollama and Z.ai providers, and pkg/tools the core file,
+// search and shell tools.
 package main
 
 import (
@@ -34,10 +36,14 @@ import (
 	"time"
 
 	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider/anthropic"
+	"github.com/julianshen/rubichan/pkg/tools"
 )
 
+const anthropicModel = "claude-sonnet-4-5"
+
 func main() {
-	if err := run(os.Stdout); err != nil {
+	if err := run(os.Stdout, providerFromEnv()); err != nil {
 		fmt.Fprintln(os.Stderr, "embed example failed:", err)
 		os.Exit(1)
 	}
@@ -47,15 +53,26 @@ func main() {
 // given, so both main and the integration test observe the same wiring.
 type embedder struct {
 	agent     *agentsdk.Agent
-	provider  *scriptedProvider
+	provider  *scriptedProvider // nil when a real provider was supplied
 	strategy  *deploymentWindowStrategy
 	auditor   *sessionAuditor
 	toolCalls *callCounter
 }
 
+// providerFromEnv returns the public Anthropic provider when
+// ANTHROPIC_API_KEY is set, or nil to fall back to the canned provider.
+func providerFromEnv() agentsdk.LLMProvider {
+	key := os.Getenv("ANTHROPIC_API_KEY")
+	if key == "" {
+		return nil
+	}
+	return anthropic.New("https://api.anthropic.com", key)
+}
+
 // compose wires the core with three modules. This is the whole point of the
-// example: agentsdk.NewAgent plus a few options, no bespoke struct.
-func compose() (embedder, error) {
+// example: agentsdk.NewAgent plus a few options, no bespoke struct. A nil llm
+// selects the canned provider.
+func compose(llm agentsdk.LLMProvider) (embedder, error) {
 	// A tool the demo turn will call, so the middleware and the
 	// background-task join both have something to observe.
 	registry := agentsdk.NewRegistry()
@@ -65,6 +82,11 @@ func compose() (embedder, error) {
 		// model's call instead of the real cause.
 		return embedder{}, fmt.Errorf("register greet tool: %w", err)
 	}
+	// A core tool from pkg/tools, so a real model can look around the
+	// working directory.
+	if err := registry.Register(tools.NewSearchTool(".")); err != nil {
+		return embedder{}, fmt.Errorf("register search tool: %w", err)
+	}
 
 	// Module 1 — a ContextStrategy that injects a section into every system
 	// prompt. A real one might pull runbook links, on-call info, or
@@ -84,9 +106,14 @@ func compose() (embedder, error) {
 		}
 	}
 
-	provider := cannedProvider()
+	var canned *scriptedProvider
+	if llm == nil {
+		canned = cannedProvider()
+		llm = canned
+	}
 	agentCore := agentsdk.NewAgent(
-		provider,
+		llm,
+		agentsdk.WithModel(anthropicModel),
 		agentsdk.WithTools(registry),
 		agentsdk.WithApproval(autoApprove),
 		agentsdk.WithSystemPrompt("You are a release assistant."),
@@ -97,7 +124,7 @@ func compose() (embedder, error) {
 
 	return embedder{
 		agent:     agentCore,
-		provider:  provider,
+		provider:  canned,
 		strategy:  deployWindow,
 		auditor:   auditor,
 		toolCalls: toolCalls,
@@ -106,8 +133,8 @@ func compose() (embedder, error) {
 
 // run composes the embedder, drives one turn, and reports what the modules
 // observed.
-func run(out interface{ Write([]byte) (int, error) }) error {
-	e, err := compose()
+func run(out interface{ Write([]byte) (int, error) }, llm agentsdk.LLMProvider) error {
+	e, err := compose(llm)
 	if err != nil {
 		return err
 	}
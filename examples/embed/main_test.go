@@ -14,7 +14,7 @@ import (
 // proves the three modules an embedder opts into actually compose and fire
 // during a real turn against the core loop.
 func TestEmbedderComposesThreeSeams(t *testing.T) {
-	e, err := compose()
+	e, err := compose(nil)
 	require.NoError(t, err)
 
 	assistant, err := e.driveTurn(context.Background(), "greet the release team")
@@ -55,7 +55,7 @@ func TestEmbedderComposesThreeSeams(t *testing.T) {
 // runnable entrypoint executes end to end without error.
 func TestRunPrintsModuleObservations(t *testing.T) {
 	var buf strings.Builder
-	require.NoError(t, run(&buf))
+	require.NoError(t, run(&buf, nil))
 	out := buf.String()
 	assert.Contains(t, out, "assistant said: Release team greeted.")
 	assert.Contains(t, out, "tool middleware saw 1 call")
@@ -0,0 +1,58 @@
+package acp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Prompt is one prompt template the agent offers, in the shape MCP's
+// prompts/list uses. Here they are the user's custom slash commands, which a
+// client can surface as its own slash commands and invoke by sending
+// "/name args" as a session/prompt.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes an argument a prompt accepts.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// ListPromptsResult answers prompts/list.
+type ListPromptsResult struct {
+	// Prompts is never omitted, for the same reason InitializeResult's
+	// AuthMethods is not: an absent list reads as "the agent forgot".
+	Prompts []Prompt `json:"prompts"`
+}
+
+// PromptsConfig supplies what prompts/list serves.
+type PromptsConfig struct {
+	// Handshake gates the method on initialize, as it does the session
+	// methods. Nil disables the check.
+	Handshake *Handshake
+
+	// List returns the prompts to advertise. It is called per request, so
+	// the answer reflects the commands as they are now rather than as they
+	// were when the connection opened.
+	List func() []Prompt
+}
+
+// RegisterPrompts wires prompts/list into a registry.
+func RegisterPrompts(registry *CapabilityRegistry, cfg PromptsConfig) {
+	registry.RegisterMethod(MethodListPrompts, func(json.RawMessage) (json.RawMessage, error) {
+		if !cfg.Handshake.IsComplete() {
+			return nil, fmt.Errorf("prompts/list: %w: initialize must complete first", ErrInvalidParams)
+		}
+		result := ListPromptsResult{Prompts: []Prompt{}}
+		if cfg.List != nil {
+			if prompts := cfg.List(); prompts != nil {
+				result.Prompts = prompts
+			}
+		}
+		return json.Marshal(result)
+	})
+}
@@ -0,0 +1,58 @@
+package acp_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/acp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPromptsServesCurrentList(t *testing.T) {
+	t.Parallel()
+
+	prompts := []acp.Prompt{{Name: "triage", Description: "Triage an issue"}}
+	registry := acp.NewCapabilityRegistry()
+	acp.RegisterPrompts(registry, acp.PromptsConfig{List: func() []acp.Prompt { return prompts }})
+
+	raw, err := registry.Call(acp.MethodListPrompts, nil)
+	require.NoError(t, err)
+	var got acp.ListPromptsResult
+	require.NoError(t, json.Unmarshal(raw, &got))
+	assert.Equal(t, prompts, got.Prompts)
+
+	prompts = append(prompts, acp.Prompt{Name: "release-notes"})
+	raw, err = registry.Call(acp.MethodListPrompts, nil)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, &got))
+	assert.Len(t, got.Prompts, 2)
+}
+
+// TestListPromptsNeverOmitsTheList pins that an agent with no prompts says
+// so with an empty list rather than a missing field.
+func TestListPromptsNeverOmitsTheList(t *testing.T) {
+	t.Parallel()
+
+	registry := acp.NewCapabilityRegistry()
+	acp.RegisterPrompts(registry, acp.PromptsConfig{})
+
+	raw, err := registry.Call(acp.MethodListPrompts, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"prompts":[]}`, string(raw))
+}
+
+func TestListPromptsRequiresHandshake(t *testing.T) {
+	t.Parallel()
+
+	handshake := acp.NewHandshake()
+	registry := acp.NewCapabilityRegistry()
+	acp.RegisterPrompts(registry, acp.PromptsConfig{Handshake: handshake})
+
+	_, err := registry.Call(acp.MethodListPrompts, nil)
+	require.ErrorIs(t, err, acp.ErrInvalidParams)
+
+	handshake.MarkComplete()
+	_, err = registry.Call(acp.MethodListPrompts, nil)
+	require.NoError(t, err)
+}
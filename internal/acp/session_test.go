@@ -273,6 +273,34 @@ func TestSessionUpdateShapes(t *testing.T) {
 		assert.Equal(t, "call-1", update["toolCallId"])
 		assert.Equal(t, "failed", update["status"])
 	})
+
+	t.Run("plan carries every entry", func(t *testing.T) {
+		t.Parallel()
+
+		got := decodeUpdate(t, acp.NewSessionNotification("sess-1",
+			acp.Plan([]acp.PlanEntry{{
+				Content:  "Add tests",
+				Priority: acp.PlanPriorityMedium,
+				Status:   acp.PlanEntryInProgress,
+			}})))
+		update := requireObject(t, got["update"])
+		assert.Equal(t, "plan", update["sessionUpdate"])
+		entries, ok := update["entries"].([]any)
+		require.True(t, ok, "expected a JSON array, got %T", update["entries"])
+		require.Len(t, entries, 1)
+		entry := requireObject(t, entries[0])
+		assert.Equal(t, "Add tests", entry["content"])
+		assert.Equal(t, "medium", entry["priority"])
+		assert.Equal(t, "in_progress", entry["status"])
+	})
+
+	t.Run("an empty plan is an empty list", func(t *testing.T) {
+		t.Parallel()
+
+		got := decodeUpdate(t, acp.NewSessionNotification("sess-1", acp.Plan(nil)))
+		update := requireObject(t, got["update"])
+		assert.Equal(t, []any{}, update["entries"])
+	})
 }
 
 // requireObject asserts a field decoded to a JSON object before indexing it. A
@@ -79,3 +79,44 @@ func ToolCallUpdate(id string, status ToolCallStatus) any {
 		Status:        status,
 	}
 }
+
+// PlanEntryPriority is how much an entry matters to the task.
+type PlanEntryPriority string
+
+const (
+	PlanPriorityHigh   PlanEntryPriority = "high"
+	PlanPriorityMedium PlanEntryPriority = "medium"
+	PlanPriorityLow    PlanEntryPriority = "low"
+)
+
+// PlanEntryStatus is where an entry stands.
+type PlanEntryStatus string
+
+const (
+	PlanEntryPending    PlanEntryStatus = "pending"
+	PlanEntryInProgress PlanEntryStatus = "in_progress"
+	PlanEntryCompleted  PlanEntryStatus = "completed"
+)
+
+// PlanEntry is one step of the agent's plan.
+type PlanEntry struct {
+	Content  string            `json:"content"`
+	Priority PlanEntryPriority `json:"priority"`
+	Status   PlanEntryStatus   `json:"status"`
+}
+
+// plan carries the agent's whole plan.
+type plan struct {
+	SessionUpdate string      `json:"sessionUpdate"`
+	Entries       []PlanEntry `json:"entries"`
+}
+
+// Plan reports the agent's plan. Every update carries the complete list and
+// replaces the last one, so a client never merges entries; an empty list is
+// sent as [] rather than null for the same reason.
+func Plan(entries []PlanEntry) any {
+	if entries == nil {
+		entries = []PlanEntry{}
+	}
+	return plan{SessionUpdate: "plan", Entries: entries}
+}
@@ -1,7 +1,11 @@
 package agent
 
 import (
+	"context"
+	"strings"
+
 	"github.com/julianshen/rubichan/internal/acp"
+	"github.com/julianshen/rubichan/internal/commands"
 )
 
 // agentVersion is reported to peers in the initialize handshake.
@@ -73,6 +77,49 @@ func (a *Agent) registerACPCapabilities(registry *acp.CapabilityRegistry, handsh
 		},
 	})
 
+	acp.RegisterPrompts(registry, acp.PromptsConfig{
+		Handshake: handshake,
+		List:      a.acpPrompts,
+	})
+}
+
+// acpPrompts lists the agent's custom commands as ACP prompts.
+func (a *Agent) acpPrompts() []acp.Prompt {
+	prompts := make([]acp.Prompt, 0, len(a.customCommands))
+	for _, cmd := range a.customCommands {
+		p := acp.Prompt{Name: cmd.Name(), Description: cmd.Description()}
+		if hint := cmd.ArgumentHint(); hint != "" {
+			p.Arguments = []acp.PromptArgument{{Name: "arguments", Description: hint}}
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts
+}
+
+// acpTurn runs an ACP prompt as a turn. A prompt of the form "/name args"
+// naming a custom command runs the command's expansion instead, with its
+// model and tool overrides, so the prompts advertised in prompts/list can be
+// invoked the way an editor sends its own slash commands.
+func (a *Agent) acpTurn(ctx context.Context, text string) (<-chan TurnEvent, error) {
+	if strings.HasPrefix(text, "/") {
+		if parts, err := commands.ParseLine(text); err == nil && len(parts) > 0 {
+			name := strings.TrimPrefix(parts[0], "/")
+			for _, cmd := range a.customCommands {
+				if cmd.Name() != name {
+					continue
+				}
+				result, err := cmd.Execute(ctx, parts[1:])
+				if err != nil {
+					return nil, err
+				}
+				return a.TurnWithOptions(ctx, result.Prompt, TurnOptions{
+					Model:        result.Model,
+					AllowedTools: result.AllowedTools,
+				})
+			}
+		}
+	}
+	return a.Turn(ctx, text)
 }
 
 // setClientCapabilities records what the peer offered during the handshake.
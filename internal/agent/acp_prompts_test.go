@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/acp"
+	"github.com/julianshen/rubichan/internal/commands"
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACPRunsCustomCommands(t *testing.T) {
+	project := t.TempDir()
+	dir := filepath.Join(project, ".agent", "commands")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "triage.md"),
+		[]byte("---\ndescription: Triage an issue\nargument-hint: <issue>\nmodel: small-model\n---\nTriage issue $1.\n"), 0o644))
+	custom, err := commands.LoadCustomCommands(commands.CustomCommandOptions{ProjectDir: project})
+	require.NoError(t, err)
+
+	var reqs []provider.CompletionRequest
+	p := &requestCapturingProvider{
+		events:    []provider.StreamEvent{{Type: "text_delta", Text: "ok"}, {Type: "stop"}},
+		onRequest: func(req provider.CompletionRequest) { reqs = append(reqs, req) },
+	}
+	a := New(p, tools.NewRegistry(), autoApprove, config.DefaultConfig(), WithCustomCommands(custom))
+
+	assert.Equal(t, []acp.Prompt{{
+		Name:        "triage",
+		Description: "Triage an issue",
+		Arguments:   []acp.PromptArgument{{Name: "arguments", Description: "<issue>"}},
+	}}, a.acpPrompts())
+
+	ch, err := a.acpTurn(context.Background(), "/triage #42")
+	require.NoError(t, err)
+	for range ch {
+	}
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "small-model", reqs[0].Model)
+	assert.Equal(t, "Triage issue #42.", reqs[0].Messages[0].Content[0].Text)
+
+	// Anything else is an ordinary prompt.
+	ch, err = a.acpTurn(context.Background(), "/unknown thing")
+	require.NoError(t, err)
+	for range ch {
+	}
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "/unknown thing", reqs[1].Messages[len(reqs[1].Messages)-1].Content[0].Text)
+}
@@ -45,11 +45,12 @@ var allExitReasons = []agentsdk.TurnExitReason{
 	agentsdk.ExitDiminishingReturns,
 	agentsdk.ExitStopHookPrevented,
 	agentsdk.ExitBudgetExceeded,
+	agentsdk.ExitCostBudgetExceeded,
 }
 
 // stopReasonFor translates the agent's exit reason into the one ACP defines.
 //
-// The map is lossy — ACP has five stop reasons and the agent has seventeen exit
+// The map is lossy — ACP has five stop reasons and the agent has eighteen exit
 // reasons — but it is lossy deliberately. Reasons that mean "the turn ended"
 // collapse onto end_turn; reasons that mean "the turn broke" become errors, so
 // a client is told the agent failed rather than that the model finished.
@@ -67,7 +68,7 @@ func stopReasonFor(r agentsdk.TurnExitReason) (acp.StopReason, error) {
 	case agentsdk.ExitCancelled:
 		return acp.StopCancelled, nil
 
-	case agentsdk.ExitMaxOutputTokens, agentsdk.ExitBudgetExceeded:
+	case agentsdk.ExitMaxOutputTokens, agentsdk.ExitBudgetExceeded, agentsdk.ExitCostBudgetExceeded:
 		return acp.StopMaxTokens, nil
 
 	// Everything below is a failed turn. ExitUnknown is included on purpose:
@@ -172,6 +173,8 @@ func acpPromptFunc(n Notifier, turn turnFunc) acp.PromptFunc {
 					}
 					notify(acp.ToolCallUpdate(ev.ToolResult.ID, status))
 				}
+			case "plan_update":
+				notify(acp.Plan(acpPlanEntries(ev.Plan)))
 			case "done":
 				exit, ended = ev.ExitReason, true
 			}
@@ -191,6 +194,20 @@ func acpPromptFunc(n Notifier, turn turnFunc) acp.PromptFunc {
 	}
 }
 
+// acpPlanEntries translates the todo list into ACP plan entries. The todo
+// list has no notion of priority, so every entry is medium.
+func acpPlanEntries(items []agentsdk.PlanEntry) []acp.PlanEntry {
+	entries := make([]acp.PlanEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, acp.PlanEntry{
+			Content:  item.Content,
+			Priority: acp.PlanPriorityMedium,
+			Status:   acp.PlanEntryStatus(item.Status),
+		})
+	}
+	return entries
+}
+
 // ServeACP serves the Agent Client Protocol over r/w until the stream ends or
 // ctx is cancelled.
 //
@@ -225,7 +242,7 @@ func ServeACP(ctx context.Context, a *Agent, r io.Reader, w io.Writer) error {
 		Capabilities: acpAgentCapabilities(),
 		WorkingDir:   a.WorkingDir(),
 		Handshake:    handshake,
-		Turn:         a.Turn,
+		Turn:         a.acpTurn,
 	})
 }
 
@@ -32,6 +32,7 @@ func TestStopReasonForCoversEveryExitReason(t *testing.T) {
 		agentsdk.ExitCancelled:          acp.StopCancelled,
 		agentsdk.ExitMaxOutputTokens:    acp.StopMaxTokens,
 		agentsdk.ExitBudgetExceeded:     acp.StopMaxTokens,
+		agentsdk.ExitCostBudgetExceeded: acp.StopMaxTokens,
 	}
 
 	// Turns that broke. These must surface as errors: reporting one as a stop
@@ -121,6 +122,10 @@ func TestACPPromptStreamsTheTurn(t *testing.T) {
 			Type:       "tool_result",
 			ToolResult: &agentsdk.ToolResultEvent{ID: "c1", Name: "read_file"},
 		}
+		ch <- agentsdk.TurnEvent{
+			Type: "plan_update",
+			Plan: []agentsdk.PlanEntry{{Content: "Read the file", Status: agentsdk.PlanEntryCompleted}},
+		}
 		ch <- agentsdk.TurnEvent{Type: "done", ExitReason: agentsdk.ExitCompleted}
 		close(ch)
 		return ch, nil
@@ -137,7 +142,12 @@ func TestACPPromptStreamsTheTurn(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, acp.StopEndTurn, stop)
 
-	require.Len(t, notifier.sent, 4, "two text chunks, one tool call, one tool update")
+	require.Len(t, notifier.sent, 5, "two text chunks, one tool call, one tool update, one plan")
+	assert.Equal(t, acp.Plan([]acp.PlanEntry{{
+		Content:  "Read the file",
+		Priority: acp.PlanPriorityMedium,
+		Status:   acp.PlanEntryCompleted,
+	}}), notifier.sent[4].Update)
 	for _, n := range notifier.sent {
 		assert.Equal(t, "sess-1", n.SessionID)
 	}
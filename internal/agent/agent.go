@@ -21,11 +21,13 @@ import (
 	"github.com/julianshen/rubichan/internal/acp"
 	"github.com/julianshen/rubichan/internal/agent/errorclass"
 	"github.com/julianshen/rubichan/internal/checkpoint"
+	"github.com/julianshen/rubichan/internal/commands"
 	"github.com/julianshen/rubichan/internal/config"
 	"github.com/julianshen/rubichan/internal/evaluator"
 	"github.com/julianshen/rubichan/internal/hooks"
 	"github.com/julianshen/rubichan/internal/knowledgegraph"
 	"github.com/julianshen/rubichan/internal/persona"
+	"github.com/julianshen/rubichan/internal/pricing"
 	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/internal/skills"
 	"github.com/julianshen/rubichan/internal/store"
@@ -95,7 +97,7 @@ func (a *Agent) buildPipeline(t *tools.Registry) *toolexec.Pipeline {
 	// Checkpoint middleware captures file state before write/patch operations.
 	if a.checkpointMgr != nil {
 		middlewares = append(middlewares, toolexec.CheckpointMiddleware(a.checkpointMgr, func() int {
-			return int(a.turnNumber.Load())
+			return int(a.checkpointTurn.Load())
 		}))
 	}
 
@@ -167,6 +169,16 @@ func WithStore(st *store.Store) AgentOption {
 	}
 }
 
+// WithUsageMeter attaches a UsageMeter. The first agent built with a meter
+// owns it and records the calls of every provider the meter wraps; agents
+// built with it afterwards (subagents) bill their own calls and spending-cap
+// checks to that owner.
+func WithUsageMeter(m *UsageMeter) AgentOption {
+	return func(a *Agent) {
+		a.usageMeter = m
+	}
+}
+
 // WithResumeSession configures the agent to resume an existing session
 // instead of creating a new one.
 func WithResumeSession(sessionID string) AgentOption {
@@ -348,7 +360,10 @@ func (a *Agent) Undo(ctx context.Context) (string, error) {
 }
 
 // RewindToTurn restores all files modified since the given turn number.
-// Returns the list of restored file paths or an error.
+// Turns are numbered across the whole session, as reported in
+// Checkpoint.Turn. With working-tree snapshots enabled this includes files
+// changed outside the file tool. Returns the list of restored file paths or
+// an error.
 func (a *Agent) RewindToTurn(ctx context.Context, turn int) ([]string, error) {
 	if a.checkpointMgr == nil {
 		return nil, fmt.Errorf("checkpoint manager not configured")
@@ -392,6 +407,27 @@ func WithToolMiddlewares(set ToolMiddlewares) AgentOption {
 	}
 }
 
+// WithCustomCommands gives the agent the user's markdown slash commands so
+// ACP can advertise them in prompts/list and run them when a client sends
+// "/name args" as a prompt. The interactive hosts register the same
+// commands in their command registry instead.
+func WithCustomCommands(cmds []*commands.CustomCommand) AgentOption {
+	return func(a *Agent) {
+		a.customCommands = cmds
+	}
+}
+
+// MentionResolver expands references in a user message, such as
+// @mcp:<server>:<uri>, into content blocks sent after the message text.
+type MentionResolver func(ctx context.Context, text string) []provider.ContentBlock
+
+// WithMentionResolver sets the resolver run on every user message.
+func WithMentionResolver(r MentionResolver) AgentOption {
+	return func(a *Agent) {
+		a.mentionResolver = r
+	}
+}
+
 // WithAgentMD injects project-level AGENT.md content into the system prompt.
 func WithAgentMD(content string) AgentOption {
 	return func(a *Agent) {
@@ -507,7 +543,10 @@ type Agent struct {
 	promptBuilder       *PromptBuilder
 	deferral            *tools.DeferralManager
 	diffTracker         *tools.DiffTracker
-	turnMu              sync.Mutex // serializes Turn() calls to prevent DiffTracker race
+	turnMu              sync.Mutex  // serializes Turn() calls to prevent DiffTracker race
+	turnOpts            TurnOptions // options of the turn in flight; guarded by turnMu
+	customCommands      []*commands.CustomCommand
+	mentionResolver     MentionResolver
 	wakeManager         *WakeManager
 	pipeline            *toolexec.Pipeline
 	workingDir          string // override working directory (empty = os.Getwd)
@@ -518,14 +557,25 @@ type Agent struct {
 	toolMiddlewares     ToolMiddlewares
 	userHookRunner      *hooks.UserHookRunner
 	turnNumber          atomic.Int32
+	checkpointTurn      atomic.Int32 // never reset; keys file checkpoints and tree snapshots
 	generation          atomic.Int64
 	fallbackModel       string
+	providerSwitches    int // failover switches already reported; touched only by the loop goroutine
+	pricing             *pricing.Table
+	budget              config.BudgetConfig
+	usageMu             sync.Mutex
+	usageMeter          *UsageMeter
+	usageParent         *Agent  // agent billed for this one's usage; nil when it bills itself
+	turnCostUSD         float64 // guarded by usageMu; reset at the start of each runLoop
+	sessionCostUSD      float64 // guarded by usageMu; consulted only when there is no store
 	rateLimiter         *SharedRateLimiter
 	capabilities        provider.ModelCapabilities
+	providerName        string // provider capabilities are detected for on a per-turn model override
 	configuredMaxTokens int
 	resultBudget        int
 	fileCache           *tools.FileReadCache
 	progress            *ProgressTracker
+	todos               *TodoList
 	latches             *sessionLatches // one-way ratchets for session-stable capability values
 	agentDef            *agentsdk.AgentDefinition
 	agentRegistry       *AgentRegistry
@@ -554,10 +604,14 @@ func New(p provider.LLMProvider, t *tools.Registry, approve ApprovalFunc, cfg *c
 		context:             newContextManagerFromConfig(cfg),
 		approve:             approve,
 		model:               cfg.Provider.Model,
+		providerName:        cfg.Provider.Default,
 		maxTurns:            cfg.Agent.MaxTurns,
 		configuredMaxTokens: cfg.Agent.MaxOutputTokens,
+		pricing:             pricing.NewTable(cfg.Pricing),
+		budget:              cfg.Budget,
 		scratchpad:          NewScratchpad(),
 		progress:            NewProgressTracker(),
+		todos:               NewTodoList(),
 		capabilities:        agentsdk.DefaultCapabilities(),
 		latches:             newSessionLatches(),
 		agentDef:            &agentsdk.AgentDefinition{Name: "general-purpose", Tools: []string{"*"}},
@@ -566,6 +620,9 @@ func New(p provider.LLMProvider, t *tools.Registry, approve ApprovalFunc, cfg *c
 	for _, opt := range opts {
 		opt(a)
 	}
+	if a.usageMeter != nil {
+		a.usageParent = a.usageMeter.bind(a)
+	}
 	// Built-in prompt contributors go ahead of user-registered strategies
 	// so the dynamic section order is fixed regardless of option order.
 	a.contextStrategies = append(a.builtinContextStrategies(), a.contextStrategies...)
@@ -632,6 +689,12 @@ func New(p provider.LLMProvider, t *tools.Registry, approve ApprovalFunc, cfg *c
 				if err := a.loadSessionHistory(a.conversation, sess.ID); err != nil {
 					a.logger.Warn("failed to load session history: %v", err)
 				}
+				if todos, err := a.store.GetTodos(sess.ID); err != nil {
+					a.logger.Warn("failed to load todos: %v", err)
+				} else {
+					a.todos.load(todos)
+				}
+				a.loadCheckpoints(sess.ID)
 			}
 		}
 
@@ -907,6 +970,22 @@ func (a *Agent) ForkSession(ctx context.Context) (string, error) {
 	return newID, nil
 }
 
+// ForkSessionAtTurn forks a stored session from the turn containing its
+// message seq and switches the agent to the fork. It returns the fork's ID.
+func (a *Agent) ForkSessionAtTurn(ctx context.Context, sessionID string, seq int) (string, error) {
+	if a.store == nil {
+		return "", fmt.Errorf("fork session: store not configured")
+	}
+	newID := uuid.New().String()
+	if err := a.store.ForkSessionAtTurn(sessionID, newID, seq); err != nil {
+		return "", fmt.Errorf("fork session: %w", err)
+	}
+	if err := a.ResumeSession(ctx, newID); err != nil {
+		return "", err
+	}
+	return newID, nil
+}
+
 // ListSessions returns recent sessions from the store filtered by working directory.
 func (a *Agent) ListSessions(limit int) ([]store.Session, error) {
 	if a.store == nil {
@@ -951,8 +1030,14 @@ func (a *Agent) ResumeSession(ctx context.Context, sessionID string) error {
 		return fmt.Errorf("resume session: %w", err)
 	}
 
+	todos, err := a.store.GetTodos(sess.ID)
+	if err != nil {
+		return fmt.Errorf("resume session: %w", err)
+	}
+
 	a.sessionID = sess.ID
 	a.conversation = conv
+	a.todos.load(todos)
 	return nil
 }
 
@@ -986,10 +1071,8 @@ func (a *Agent) loadSessionHistory(conv *Conversation, sessionID string) error {
 }
 
 // persistToolResult saves a tool result message to the store.
-func (a *Agent) persistToolResult(toolUseID, content string, isError bool) {
-	a.persistMessage("user", []provider.ContentBlock{
-		{Type: "tool_result", ToolUseID: toolUseID, Text: content, IsError: isError},
-	})
+func (a *Agent) persistToolResult(toolUseID, content string, isError bool, media []provider.ContentBlock) {
+	a.persistMessage("user", provider.NewToolResultMessage(toolUseID, content, isError, media...).Content)
 }
 
 // persistMessage saves a message to the store. Errors are logged but non-fatal.
@@ -1081,11 +1164,32 @@ func (a *Agent) Generation() int64 {
 	return a.generation.Load()
 }
 
+// TurnOptions adjusts a single turn. The zero value is a plain Turn.
+type TurnOptions struct {
+	// Attachments are image or document blocks (see
+	// agentsdk.NewMediaFileBlock) sent after the user message text.
+	Attachments []provider.ContentBlock
+	// Model, when set, replaces the agent's model for this turn only, and
+	// the model's capabilities (vision, reasoning effort, tool use) are
+	// detected afresh for it.
+	Model string
+	// AllowedTools, when non-empty, limits the tools offered to the model
+	// and accepted from it during this turn. A shell entry may carry a
+	// qualifier limiting the commands it allows, as in "shell(git:*)".
+	AllowedTools []string
+}
+
 // Turn initiates a new agent turn with the given user message. It returns a
 // channel of TurnEvent that streams events as the agent processes the turn.
 // Concurrent calls are serialized to prevent DiffTracker race conditions.
 func (a *Agent) Turn(ctx context.Context, userMessage string) (<-chan TurnEvent, error) {
+	return a.TurnWithOptions(ctx, userMessage, TurnOptions{})
+}
+
+// TurnWithOptions is Turn with per-turn options.
+func (a *Agent) TurnWithOptions(ctx context.Context, userMessage string, opts TurnOptions) (<-chan TurnEvent, error) {
 	a.turnMu.Lock()
+	a.turnOpts = opts
 
 	// Check for token budget directives in the user message.
 	// Supports: "+500k do this", "do this +500k", "use 2M tokens".
@@ -1100,9 +1204,14 @@ func (a *Agent) Turn(ctx context.Context, userMessage string) (<-chan TurnEvent,
 		})
 	}
 
-	a.conversation.AddUser(userMessage)
-	a.persistMessage("user", []provider.ContentBlock{{Type: "text", Text: userMessage}})
+	userBlocks := append([]provider.ContentBlock{{Type: "text", Text: userMessage}}, opts.Attachments...)
+	if a.mentionResolver != nil {
+		userBlocks = append(userBlocks, a.mentionResolver(ctx, userMessage)...)
+	}
+	a.conversation.AddUserBlocks(userBlocks)
+	a.persistMessage("user", userBlocks)
 	if err := a.context.Compact(ctx, a.conversation); err != nil {
+		a.turnOpts = TurnOptions{}
 		a.turnMu.Unlock()
 		if errors.Is(err, ErrCompactionExhausted) {
 			return nil, fmt.Errorf("compaction exhausted before turn start: %w", err)
@@ -1141,9 +1250,17 @@ func (a *Agent) Turn(ctx context.Context, userMessage string) (<-chan TurnEvent,
 			if handle := a.summaryHandle.Swap(nil); handle != nil {
 				handle.Stop()
 			}
+			a.turnOpts = TurnOptions{}
 			a.turnMu.Unlock()
 		}()
 		defer close(ch)
+		// Record the turn's checkpoints before the caller sees the channel
+		// close, so they survive a crash or an exit that skips cleanup.
+		defer func() {
+			if err := a.SaveCheckpoints(); err != nil {
+				a.logger.Warn("failed to save checkpoints: %v", err)
+			}
+		}()
 		defer func() {
 			if r := recover(); r != nil {
 				stack := debug.Stack()
@@ -1170,6 +1287,26 @@ func (a *Agent) Turn(ctx context.Context, userMessage string) (<-chan TurnEvent,
 	return ch, nil
 }
 
+// turnModel returns the model the turn in flight calls: the turn's override
+// when it set one, otherwise the agent's model. Only the loop goroutine,
+// which holds turnMu, may call it.
+func (a *Agent) turnModel() string {
+	if a.turnOpts.Model != "" {
+		return a.turnOpts.Model
+	}
+	return a.model
+}
+
+// turnCapabilities returns the capabilities of the model the turn in flight
+// calls: detected for the turn's model override when it set one, otherwise
+// the agent's. Only the loop goroutine, which holds turnMu, may call it.
+func (a *Agent) turnCapabilities() provider.ModelCapabilities {
+	if a.turnOpts.Model == "" || a.turnOpts.Model == a.model {
+		return a.capabilities
+	}
+	return provider.DetectCapabilities(a.providerName, a.turnOpts.Model)
+}
+
 // DiffTracker returns the agent's diff tracker, or nil if none is attached.
 func (a *Agent) DiffTracker() *tools.DiffTracker {
 	return a.diffTracker
@@ -1237,7 +1374,7 @@ func (a *Agent) buildSystemPromptWithFragments(ctx context.Context, lastUserMess
 	}
 
 	// Context strategies contribute the dynamic sections: the built-ins
-	// (scratchpad, progress, knowledge, memories — prepended at
+	// (scratchpad, todo list, progress, knowledge, memories — prepended at
 	// construction in canonical order) followed by registered strategies,
 	// before skill fragments.
 	a.contributeStrategySections(ctx, pb, agentsdk.PromptContext{
@@ -1294,6 +1431,7 @@ func (a *Agent) makeDoneEvent(inputTokens, outputTokens int, reason agentsdk.Tur
 		Type:          "done",
 		InputTokens:   inputTokens,
 		OutputTokens:  outputTokens,
+		CostUSD:       a.turnCost(),
 		ContextBudget: &budget,
 		ExitReason:    reason,
 	}
@@ -1434,7 +1572,15 @@ func (a *Agent) runLoop(ctx context.Context, ch chan<- TurnEvent, turnCount int,
 	defer a.endBackgroundSession()
 
 	var totalInputTokens, totalOutputTokens int
+	a.usageMu.Lock()
+	a.turnCostUSD = 0
+	a.usageMu.Unlock()
 	ls := newLoopState(a.maxTurns, turnCount, a.configuredMaxTokens)
+	// Hosts learn the todo list carried over from earlier turns, or from a
+	// resumed session, before the model changes it.
+	if items := a.todos.Items(); len(items) > 0 {
+		a.emit(ctx, ch, TurnEvent{Type: "plan_update", Plan: items})
+	}
 	if a.skillRuntime != nil {
 		triggerCtx := a.buildSkillTriggerContext(lastUserMessage)
 		if err := a.skillRuntime.EvaluateAndActivate(triggerCtx); err != nil {
@@ -1447,8 +1593,18 @@ func (a *Agent) runLoop(ctx context.Context, ch chan<- TurnEvent, turnCount int,
 		if ls.turnCount > turnCount && ls.lastContinueReason != ContinueNextTurn {
 			a.logger.Warn("loop continue: reason=%s turn=%d/%d", ls.lastContinueReason, ls.turnCount, ls.maxTurns)
 		}
-		// Track turn number for checkpoint middleware.
 		a.turnNumber.Store(int32(ls.turnCount))
+		// ls.turnCount restarts with every user message, so checkpoints and
+		// tree snapshots are keyed on a session-wide counter instead; both
+		// must agree on turn boundaries for rewind to restore consistently.
+		cpTurn := int(a.checkpointTurn.Add(1))
+		// Snapshot the whole working tree at the turn boundary so rewind
+		// also covers files changed by shell commands and generators.
+		if a.checkpointMgr != nil {
+			if err := a.checkpointMgr.SnapshotTurn(ctx, cpTurn); err != nil {
+				a.logger.Warn("checkpoint snapshot: %v", err)
+			}
+		}
 
 		if ctx.Err() != nil {
 			a.emit(ctx, ch, TurnEvent{Type: "error", Error: ctx.Err()})
@@ -1456,6 +1612,15 @@ func (a *Agent) runLoop(ctx context.Context, ch chan<- TurnEvent, turnCount int,
 			return
 		}
 
+		// Spending caps are hard stops: checked before every provider call,
+		// so the call that crosses a cap completes and the next one is refused.
+		if err := a.checkCostBudget(time.Now()); err != nil {
+			a.logger.Warn("cost budget stop: %v", err)
+			a.emit(ctx, ch, TurnEvent{Type: "error", Error: err})
+			a.emit(ctx, ch, a.makeDoneEvent(totalInputTokens, totalOutputTokens, agentsdk.ExitCostBudgetExceeded))
+			return
+		}
+
 		if err := a.context.Compact(ctx, a.conversation); err != nil {
 			if errors.Is(err, ErrCompactionExhausted) {
 				a.emit(ctx, ch, TurnEvent{Type: "error", Error: err})
@@ -1473,18 +1638,25 @@ func (a *Agent) runLoop(ctx context.Context, ch chan<- TurnEvent, turnCount int,
 		allToolDefs := tools.SelectForContext(a.tools, a.conversation.Messages())
 		// Apply agent definition tool filter before deferral.
 		allToolDefs = FilterTools(allToolDefs, a.agentDef, nil)
+		allToolDefs = filterAllowedTools(allToolDefs, a.turnOpts.AllowedTools)
 		budget := a.context.Budget()
 		activeTools, _ := a.deferral.SelectForContext(allToolDefs, budget.EffectiveWindow())
 
-		if a.capabilities.MaxToolCount > 0 {
-			activeTools = tools.ApplyMaxToolCount(activeTools, a.capabilities.MaxToolCount)
+		caps := a.turnCapabilities()
+		if caps.MaxToolCount > 0 {
+			activeTools = tools.ApplyMaxToolCount(activeTools, caps.MaxToolCount)
 		}
 
 		// Append tool discovery hint for models that benefit from explicit guidance.
 		// The latch freezes this decision at the first turn so a mid-session
 		// capability change cannot alter the system prompt's dynamic section,
-		// which would invalidate the provider's session prompt cache.
-		needsToolHint := a.latches.latchToolHint(a.capabilities.NeedsToolDiscoveryHint)
+		// which would invalidate the provider's session prompt cache. A turn
+		// that overrides the model misses that cache anyway, so it follows
+		// its own model and leaves the latch alone.
+		needsToolHint := caps.NeedsToolDiscoveryHint
+		if a.turnOpts.Model == "" {
+			needsToolHint = a.latches.latchToolHint(caps.NeedsToolDiscoveryHint)
+		}
 		if needsToolHint {
 			toolHint := a.deferral.ToolSummary(activeTools)
 			systemPrompt = systemPrompt + "\n\n" + toolHint
@@ -1492,7 +1664,7 @@ func (a *Agent) runLoop(ctx context.Context, ch chan<- TurnEvent, turnCount int,
 
 		// Branch on native tool use capability: models without native support
 		// receive tool definitions rendered as text in the system prompt instead.
-		useNativeTools := a.capabilities.SupportsNativeToolUse
+		useNativeTools := caps.SupportsNativeToolUse
 		var reqTools []provider.ToolDef
 		if useNativeTools {
 			reqTools = activeTools
@@ -1504,7 +1676,7 @@ func (a *Agent) runLoop(ctx context.Context, ch chan<- TurnEvent, turnCount int,
 
 		// Snapshot cache-key state before model call for break detection.
 		if a.cacheBreakDetector != nil {
-			a.cacheBreakDetector.Snapshot(ls.turnCount, systemPrompt, activeTools, a.model, cacheBreakpoints)
+			a.cacheBreakDetector.Snapshot(ls.turnCount, systemPrompt, activeTools, a.turnModel(), cacheBreakpoints)
 		}
 
 		// Measure component-level token usage before the LLM call.
@@ -1536,7 +1708,7 @@ func (a *Agent) runLoop(ctx context.Context, ch chan<- TurnEvent, turnCount int,
 		}
 
 		req := provider.CompletionRequest{
-			Model:            a.model,
+			Model:            a.turnModel(),
 			System:           systemPrompt,
 			Messages:         normalizeMessages(a.conversation.Messages()),
 			Tools:            reqTools,
@@ -1545,8 +1717,15 @@ func (a *Agent) runLoop(ctx context.Context, ch chan<- TurnEvent, turnCount int,
 		}
 		// Latch ReasoningEffort so a mid-session capability change cannot
 		// alter the value passed to the provider. The latch is set on the
-		// first non-empty value; empty means "use provider default."
-		req.Capabilities.ReasoningEffort = a.latches.latchReasoningEffort(a.capabilities.ReasoningEffort)
+		// first non-empty value; empty means "use provider default." A
+		// model override sends its own model's effort instead.
+		req.Capabilities.ReasoningEffort = caps.ReasoningEffort
+		if a.turnOpts.Model == "" {
+			req.Capabilities.ReasoningEffort = a.latches.latchReasoningEffort(caps.ReasoningEffort)
+		}
+		// Vision gates whether transformers send image/document bytes or a
+		// text placeholder; it is read per request, not latched.
+		req.Capabilities.SupportsVision = caps.SupportsVision
 
 		stream, callOutcome := a.streamWithRecovery(ctx, ch, ls, req, totalInputTokens, totalOutputTokens)
 		if callOutcome == stepRetryTurn {
@@ -1557,6 +1736,7 @@ func (a *Agent) runLoop(ctx context.Context, ch chan<- TurnEvent, turnCount int,
 		}
 
 		cs := a.consumeProviderStream(ctx, ch, ls, stream, &totalInputTokens, &totalOutputTokens)
+		a.recordUsage(cs.model, cs.usage)
 
 		asm, asmOutcome := a.assembleAssistantTurn(ctx, ch, ls, cs.acc, cs.execStream, cs.thinkingBuf, cs.stopReason, useNativeTools, totalInputTokens, totalOutputTokens)
 		if asmOutcome == stepRetryTurn {
@@ -1712,6 +1892,7 @@ type toolExecResult struct {
 	toolUseID string
 	content   string
 	isError   bool
+	media     []provider.ContentBlock
 }
 
 type plannedToolCall struct {
@@ -1868,6 +2049,7 @@ func (a *Agent) executeTools(ctx context.Context, ch chan<- TurnEvent, pendingTo
 				Content:        res.content,
 				DisplayContent: res.event.ToolResult.DisplayContent,
 				IsError:        res.isError,
+				Media:          res.media,
 			}
 		}
 
@@ -1881,6 +2063,7 @@ func (a *Agent) executeTools(ctx context.Context, ch chan<- TurnEvent, pendingTo
 				toolUseID: it.tc.ID,
 				content:   br.Content,
 				isError:   br.IsError,
+				media:     br.Media,
 				event:     makeToolResultEvent(it.tc.ID, it.tc.Name, br.Content, br.DisplayContent, br.IsError),
 			}
 		}
@@ -1912,6 +2095,7 @@ func (a *Agent) executeTools(ctx context.Context, ch chan<- TurnEvent, pendingTo
 
 	// Snapshot after all tool results so a resume picks up from here.
 	a.saveSnapshotIfNeeded()
+	a.publishTodos(ctx, ch)
 
 	// Every loop above tests ctx.Err() before a call, never after, so
 	// cancellation during the *last* tool would otherwise be reported as a
@@ -1974,8 +2158,8 @@ func (a *Agent) commitToolResults(ctx context.Context, ch chan<- TurnEvent, pend
 		if r.toolUseID == "" {
 			continue
 		}
-		a.conversation.AddToolResult(r.toolUseID, r.content, r.isError)
-		a.persistToolResult(r.toolUseID, r.content, r.isError)
+		a.conversation.AddToolResultMedia(r.toolUseID, r.content, r.isError, r.media)
+		a.persistToolResult(r.toolUseID, r.content, r.isError, r.media)
 		a.emit(ctx, ch, r.event)
 
 		// Record progress for compaction-resistant tracking.
@@ -1993,8 +2177,8 @@ func (a *Agent) executePlannedToolsSequential(ctx context.Context, ch chan<- Tur
 		// and cached result directly without re-executing.
 		if r, ok := streamedResults[planned.tc.ID]; ok {
 			a.emit(ctx, ch, makeToolCallEvent(planned.tc))
-			a.conversation.AddToolResult(r.toolUseID, r.content, r.isError)
-			a.persistToolResult(r.toolUseID, r.content, r.isError)
+			a.conversation.AddToolResultMedia(r.toolUseID, r.content, r.isError, r.media)
+			a.persistToolResult(r.toolUseID, r.content, r.isError, r.media)
 			a.emit(ctx, ch, r.event)
 			a.recordToolProgress(planned.tc, r)
 			continue
@@ -2002,8 +2186,8 @@ func (a *Agent) executePlannedToolsSequential(ctx context.Context, ch chan<- Tur
 
 		a.emit(ctx, ch, makeToolCallEvent(planned.tc))
 		r := a.executeSingleToolWithApproval(ctx, ch, planned.tc, planned.approvalResult)
-		a.conversation.AddToolResult(r.toolUseID, r.content, r.isError)
-		a.persistToolResult(r.toolUseID, r.content, r.isError)
+		a.conversation.AddToolResultMedia(r.toolUseID, r.content, r.isError, r.media)
+		a.persistToolResult(r.toolUseID, r.content, r.isError, r.media)
 		a.emit(ctx, ch, r.event)
 
 		// Record progress for compaction-resistant tracking.
@@ -2012,6 +2196,7 @@ func (a *Agent) executePlannedToolsSequential(ctx context.Context, ch chan<- Tur
 
 	// Snapshot after all tool results so a resume picks up from here.
 	a.saveSnapshotIfNeeded()
+	a.publishTodos(ctx, ch)
 
 	// The loop tests ctx.Err() before each call, never after the last one,
 	// so a cancellation landing during the final tool would look like a
@@ -2041,7 +2226,21 @@ func (a *Agent) executeSingleToolWithApproval(ctx context.Context, ch chan<- Tur
 	if !out.Approved {
 		return a.approvalToolErrorResult(tc, out.Message, out.Err)
 	}
-	return a.executeSingleTool(ctx, ch, tc)
+	// The user may have changed the call, e.g. rejected some hunks of an
+	// edit: run what they approved and tell the model what differs.
+	if out.Input != nil {
+		tc.Input = out.Input
+	}
+	res := a.executeSingleTool(ctx, ch, tc)
+	if out.Note != "" {
+		res.content = out.Annotate(res.content)
+		if res.event.ToolResult != nil {
+			tr := *res.event.ToolResult
+			tr.Content = out.Annotate(tr.Content)
+			res.event.ToolResult = &tr
+		}
+	}
+	return res
 }
 
 // executeSingleTool delegates tool execution to the pipeline.
@@ -2069,6 +2268,16 @@ func (a *Agent) executeSingleTool(ctx context.Context, ch chan<- TurnEvent, tc p
 	// fired every hook a second time with a json.RawMessage payload that
 	// no filter or template consumer could read.
 
+	if !toolAllowed(tc.Name, tc.Input, a.turnOpts.AllowedTools) {
+		msg := fmt.Sprintf("tool %q is not allowed in this turn", tc.Name)
+		return toolExecResult{
+			toolUseID: tc.ID,
+			content:   msg,
+			isError:   true,
+			event:     makeToolResultEvent(tc.ID, tc.Name, msg, "", true),
+		}
+	}
+
 	emit := agentsdk.MakeToolProgressEmitter(tc.ID, tc.Name, func(ev TurnEvent) { a.emit(ctx, ch, ev) })
 	result := a.pipeline.Execute(toolexec.WithToolEventEmitter(ctx, emit), toolexec.ToolCall{
 		ID: tc.ID, Name: tc.Name, Input: tc.Input,
@@ -2097,6 +2306,7 @@ func (a *Agent) executeSingleTool(ctx context.Context, ch chan<- TurnEvent, tc p
 		toolUseID: tc.ID,
 		content:   result.Content,
 		isError:   result.IsError,
+		media:     result.Media,
 		event:     makeToolResultEvent(tc.ID, tc.Name, result.Content, result.DisplayContent, result.IsError),
 	}
 }
@@ -2161,7 +2371,10 @@ func (a *Agent) summarizeForSummary(ctx context.Context, messages []provider.Mes
 		return "", err
 	}
 	var result strings.Builder
+	var usage pricing.Usage
+	defer func() { a.recordUsage(req.Model, usage) }()
 	for event := range stream {
+		usage = usage.Add(usageFromEvent(event))
 		if event.Error != nil {
 			return "", fmt.Errorf("summary stream error: %w", event.Error)
 		}
@@ -4114,3 +4114,185 @@ func TestRunLoop_MaxTokensRecovery_PersistsTruncatedText(t *testing.T) {
 	assert.Contains(t, joined, "response_3", "recovery attempt 2 must persist truncated text")
 	assert.Contains(t, joined, "response_4", "final completed response persisted")
 }
+
+// screenshotTool returns a result with an attached image, like
+// browser_screenshot.
+type screenshotTool struct{}
+
+func (screenshotTool) Name() string                 { return "screenshot" }
+func (screenshotTool) Description() string          { return "stub screenshot tool" }
+func (screenshotTool) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (screenshotTool) Execute(context.Context, json.RawMessage) (tools.ToolResult, error) {
+	return tools.ToolResult{
+		Content: "saved screenshot",
+		Media:   []agentsdk.ContentBlock{agentsdk.NewImageBlock("image/png", []byte("png-bytes"))},
+	}, nil
+}
+
+func TestToolResultMediaReachesConversation(t *testing.T) {
+	mp := &dynamicMockProvider{responses: [][]provider.StreamEvent{
+		{
+			{Type: "tool_use", ToolUse: &provider.ToolUseBlock{ID: "tc-1", Name: "screenshot"}},
+			{Type: "text_delta", Text: "{}"},
+			{Type: "stop"},
+		},
+		{{Type: "text_delta", Text: "done"}, {Type: "stop"}},
+	}}
+	reg := tools.NewRegistry()
+	require.NoError(t, reg.Register(screenshotTool{}))
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+	a := New(mp, reg, autoApprove, config.DefaultConfig(), WithStore(s))
+
+	attachment := agentsdk.NewImageBlock("image/png", []byte("pasted"))
+	ch, err := a.TurnWithOptions(context.Background(), "look", TurnOptions{
+		Attachments: []provider.ContentBlock{attachment},
+	})
+	require.NoError(t, err)
+	for range ch {
+	}
+
+	findResult := func(msgs []provider.Message) provider.ContentBlock {
+		for _, m := range msgs {
+			for _, b := range m.Content {
+				if b.Type == "tool_result" {
+					return b
+				}
+			}
+		}
+		t.Fatal("no tool_result in conversation")
+		return provider.ContentBlock{}
+	}
+
+	msgs := a.conversation.Messages()
+	assert.Equal(t, []provider.ContentBlock{{Type: "text", Text: "look"}, attachment}, msgs[0].Content)
+	result := findResult(msgs)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, agentsdk.BlockTypeImage, result.Content[0].Type)
+
+	stored, err := s.GetMessages(a.SessionID())
+	require.NoError(t, err)
+	persisted := make([]provider.Message, len(stored))
+	for i, m := range stored {
+		persisted[i] = provider.Message{Role: m.Role, Content: m.Content}
+	}
+	assert.Len(t, persisted[0].Content, 2)
+	assert.Len(t, findResult(persisted).Content, 1)
+}
+
+func TestMentionResolverAddsBlocks(t *testing.T) {
+	mp := &dynamicMockProvider{responses: [][]provider.StreamEvent{
+		{{Type: "text_delta", Text: "ok"}, {Type: "stop"}},
+	}}
+	var seen string
+	a := New(mp, tools.NewRegistry(), autoApprove, config.DefaultConfig(),
+		WithMentionResolver(func(_ context.Context, text string) []provider.ContentBlock {
+			seen = text
+			return []provider.ContentBlock{{Type: "text", Text: "resource body"}}
+		}))
+
+	ch, err := a.Turn(context.Background(), "summarize @mcp:docs:file:///readme")
+	require.NoError(t, err)
+	for range ch {
+	}
+
+	assert.Equal(t, "summarize @mcp:docs:file:///readme", seen)
+	assert.Equal(t, []provider.ContentBlock{
+		{Type: "text", Text: "summarize @mcp:docs:file:///readme"},
+		{Type: "text", Text: "resource body"},
+	}, a.conversation.Messages()[0].Content)
+}
+
+// turnRequestProvider records each request and replays dynamicMockProvider's
+// scripted responses.
+type turnRequestProvider struct {
+	dynamicMockProvider
+	reqs []provider.CompletionRequest
+}
+
+func (p *turnRequestProvider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	p.reqs = append(p.reqs, req)
+	return p.dynamicMockProvider.Stream(ctx, req)
+}
+
+func TestTurnOptionsOverrideModelAndTools(t *testing.T) {
+	mp := &turnRequestProvider{dynamicMockProvider: dynamicMockProvider{responses: [][]provider.StreamEvent{
+		{
+			{Type: "tool_use", ToolUse: &provider.ToolUseBlock{ID: "tc-1", Name: "shell"}},
+			{Type: "text_delta", Text: "{}"},
+			{Type: "stop"},
+		},
+		{{Type: "text_delta", Text: "done"}, {Type: "stop"}},
+		{{Type: "text_delta", Text: "again"}, {Type: "stop"}},
+	}}}
+	reg := tools.NewRegistry()
+	require.NoError(t, reg.Register(&testTool{name: "file"}))
+	require.NoError(t, reg.Register(&testTool{name: "shell"}))
+	cfg := config.DefaultConfig()
+	a := New(mp, reg, autoApprove, cfg,
+		WithCapabilities(provider.ModelCapabilities{SupportsNativeToolUse: true}))
+
+	ch, err := a.TurnWithOptions(context.Background(), "run", TurnOptions{
+		Model:        "override-model",
+		AllowedTools: []string{"file(read:*)"},
+	})
+	require.NoError(t, err)
+	var blockedResult *agentsdk.ToolResultEvent
+	for ev := range ch {
+		if ev.Type == "tool_result" && ev.ToolResult != nil {
+			blockedResult = ev.ToolResult
+		}
+	}
+	require.Len(t, mp.reqs, 2)
+	for _, req := range mp.reqs {
+		assert.Equal(t, "override-model", req.Model)
+		assert.Contains(t, toolNames(req.Tools), "file")
+		assert.NotContains(t, toolNames(req.Tools), "shell")
+	}
+	require.NotNil(t, blockedResult)
+	assert.True(t, blockedResult.IsError)
+	assert.Contains(t, blockedResult.Content, "not allowed")
+
+	// The overrides last for one turn only.
+	ch, err = a.Turn(context.Background(), "plain")
+	require.NoError(t, err)
+	for range ch {
+	}
+	require.Len(t, mp.reqs, 3)
+	assert.Equal(t, cfg.Provider.Model, mp.reqs[2].Model)
+	assert.Contains(t, toolNames(mp.reqs[2].Tools), "shell")
+}
+
+func TestTurnOptionsModelOverrideUsesItsCapabilities(t *testing.T) {
+	mp := &turnRequestProvider{dynamicMockProvider: dynamicMockProvider{responses: [][]provider.StreamEvent{
+		{{Type: "text_delta", Text: "one"}, {Type: "stop"}},
+		{{Type: "text_delta", Text: "two"}, {Type: "stop"}},
+		{{Type: "text_delta", Text: "three"}, {Type: "stop"}},
+	}}}
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "anthropic"
+	cfg.Provider.Model = "claude-opus-4-5"
+	a := New(mp, tools.NewRegistry(), autoApprove, cfg,
+		WithCapabilities(provider.DetectCapabilities("anthropic", cfg.Provider.Model)))
+
+	run := func(opts TurnOptions) {
+		ch, err := a.TurnWithOptions(context.Background(), "hi", opts)
+		require.NoError(t, err)
+		for range ch {
+		}
+	}
+	run(TurnOptions{})
+	run(TurnOptions{Model: "claude-2.1"})
+	run(TurnOptions{})
+	require.Len(t, mp.reqs, 3)
+
+	assert.Equal(t, "high", mp.reqs[0].Capabilities.ReasoningEffort)
+	assert.True(t, mp.reqs[0].Capabilities.SupportsVision)
+
+	override := mp.reqs[1].Capabilities
+	assert.Empty(t, override.ReasoningEffort, "the override model does not think")
+	assert.False(t, override.SupportsVision, "the override model has no vision")
+
+	assert.Equal(t, mp.reqs[0].Capabilities, mp.reqs[2].Capabilities, "the session model's capabilities come back")
+}
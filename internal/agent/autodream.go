@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/julianshen/rubichan/internal/pricing"
 	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
 )
@@ -172,7 +173,10 @@ func (d *autoDreamBackgroundTask) EndSession(ctx context.Context) {
 			return "", err
 		}
 		var result strings.Builder
+		var usage pricing.Usage
+		defer func() { a.recordUsage(req.Model, usage) }()
 		for event := range stream {
+			usage = usage.Add(usageFromEvent(event))
 			if event.Error != nil {
 				return "", fmt.Errorf("dream stream error: %w", event.Error)
 			}
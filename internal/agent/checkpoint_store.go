@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/julianshen/rubichan/internal/checkpoint"
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+// SaveCheckpoints records the checkpoint manager's stack with the session,
+// so it can be exported with it and undone after a resume. Paths inside the
+// working directory are stored relative to it. It does nothing without a
+// store or a checkpoint manager.
+func (a *Agent) SaveCheckpoints() error {
+	if a.store == nil || a.sessionID == "" || a.checkpointMgr == nil {
+		return nil
+	}
+	root := a.checkpointMgr.RootDir()
+	cps := a.checkpointMgr.List()
+	stored := make([]store.FileCheckpoint, 0, len(cps))
+	for _, cp := range cps {
+		data, err := a.checkpointMgr.Data(cp)
+		if err != nil {
+			return err
+		}
+		path := cp.FilePath
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			path = filepath.ToSlash(rel)
+		}
+		stored = append(stored, store.FileCheckpoint{
+			ID:        cp.ID,
+			FilePath:  path,
+			Turn:      cp.Turn,
+			Operation: cp.Operation,
+			Existed:   data != nil,
+			FileMode:  uint32(cp.FileMode),
+			Data:      data,
+			CreatedAt: cp.Timestamp,
+		})
+	}
+	return a.store.SaveCheckpoints(a.sessionID, stored)
+}
+
+// loadCheckpoints hands the checkpoints recorded for a resumed session to
+// the checkpoint manager and continues turn numbering after them, so /undo
+// and /rewind reach changes made before the resume.
+func (a *Agent) loadCheckpoints(sessionID string) {
+	if a.checkpointMgr == nil {
+		return
+	}
+	stored, err := a.store.GetCheckpoints(sessionID)
+	if err != nil {
+		a.logger.Warn("failed to load checkpoints: %v", err)
+		return
+	}
+	cps := make([]checkpoint.Checkpoint, 0, len(stored))
+	lastTurn := 0
+	for _, sc := range stored {
+		data := sc.Data
+		if sc.Existed && data == nil {
+			data = []byte{} // an empty file, not a creation
+		}
+		cps = append(cps, checkpoint.Checkpoint{
+			ID:           sc.ID,
+			FilePath:     filepath.FromSlash(sc.FilePath),
+			Turn:         sc.Turn,
+			Timestamp:    sc.CreatedAt,
+			Operation:    sc.Operation,
+			OriginalData: data,
+			FileMode:     os.FileMode(sc.FileMode),
+		})
+		lastTurn = max(lastTurn, sc.Turn)
+	}
+	if err := a.checkpointMgr.Load(cps); err != nil {
+		a.logger.Warn("failed to load checkpoints: %v", err)
+		return
+	}
+	a.checkpointTurn.Store(int32(lastTurn))
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/checkpoint"
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/store"
+	"github.com/julianshen/rubichan/internal/tools"
+)
+
+func TestSaveCheckpointsStoresRelativePaths(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main"), 0o644))
+	mgr, err := checkpoint.New(root, "save-checkpoints", 0)
+	require.NoError(t, err)
+	defer func() { _ = mgr.Cleanup() }()
+	_, err = mgr.Capture(context.Background(), "main.go", 1, "write")
+	require.NoError(t, err)
+	_, err = mgr.Capture(context.Background(), "new.go", 2, "write")
+	require.NoError(t, err)
+
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+	cfg := &config.Config{Agent: config.AgentConfig{MaxTurns: 1, ContextBudget: 100000}}
+	a := New(&mockProvider{}, tools.NewRegistry(), autoApprove, cfg, WithStore(s), WithCheckpointManager(mgr))
+	require.NoError(t, a.SaveCheckpoints())
+
+	cps, err := s.GetCheckpoints(a.SessionID())
+	require.NoError(t, err)
+	require.Len(t, cps, 2)
+	assert.Equal(t, "main.go", cps[0].FilePath, "paths are stored relative to the working dir")
+	assert.True(t, cps[0].Existed)
+	assert.Equal(t, []byte("package main"), cps[0].Data)
+	assert.Equal(t, "new.go", cps[1].FilePath)
+	assert.False(t, cps[1].Existed)
+}
+
+func TestResumedSessionUndoesImportedCheckpoints(t *testing.T) {
+	src, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer src.Close()
+	require.NoError(t, src.CreateSession(store.Session{ID: "s1", Model: "m", WorkingDir: "/elsewhere"}))
+	require.NoError(t, src.SaveCheckpoints("s1", []store.FileCheckpoint{
+		{ID: "c1", FilePath: "main.go", Turn: 1, Operation: "write", Existed: true, FileMode: 0o644, Data: []byte("v1")},
+		{ID: "c2", FilePath: "new.go", Turn: 2, Operation: "write"},
+	}))
+	b, err := src.ExportSession("s1")
+	require.NoError(t, err)
+
+	// The project on the importing machine holds the session's changes.
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("v2"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "new.go"), []byte("created"), 0o644))
+	dst, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer dst.Close()
+	require.NoError(t, dst.ImportSession(b, root))
+
+	mgr, err := checkpoint.New(root, "resume-checkpoints", 0)
+	require.NoError(t, err)
+	defer func() { _ = mgr.Cleanup() }()
+	cfg := &config.Config{Agent: config.AgentConfig{MaxTurns: 1, ContextBudget: 100000}}
+	mp := &mockProvider{events: []provider.StreamEvent{
+		{Type: "text_delta", Text: "ok"},
+		{Type: "stop"},
+	}}
+	a := New(mp, tools.NewRegistry(), autoApprove, cfg,
+		WithStore(dst), WithResumeSession("s1"), WithCheckpointManager(mgr))
+	require.Len(t, a.Checkpoints(), 2)
+	assert.Equal(t, int32(2), a.checkpointTurn.Load(), "turn numbering continues after the stored checkpoints")
+
+	path, err := a.Undo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "new.go", filepath.Base(path))
+	assert.NoFileExists(t, filepath.Join(root, "new.go"))
+
+	// The next turn's end records the undo, so it sticks across resumes.
+	ch, err := a.Turn(context.Background(), "hi")
+	require.NoError(t, err)
+	drainTurn(ch)
+	stored, err := dst.GetCheckpoints("s1")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "c1", stored[0].ID)
+
+	_, err = a.Undo(context.Background())
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(root, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+}
+
+func TestResumeRefusesUnsafeCheckpoints(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	big := make([]byte, 2*1024*1024) // spilled to disk on load
+	malicious := store.FileCheckpoint{ID: "../../../escape", FilePath: "main.go", Turn: 1, Operation: "write", Existed: true, Data: big}
+
+	src, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer src.Close()
+	require.NoError(t, src.CreateSession(store.Session{ID: "s1", Model: "m", WorkingDir: "/elsewhere"}))
+	require.NoError(t, src.SaveCheckpoints("s1", []store.FileCheckpoint{malicious}))
+	b, err := src.ExportSession("s1")
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	dst, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer dst.Close()
+	assert.ErrorContains(t, dst.ImportSession(b, root), "invalid id", "the bundle is refused at import")
+
+	// A store that already holds such a checkpoint still resumes safely:
+	// the checkpoint manager refuses the ID instead of spilling through it.
+	require.NoError(t, src.CreateSession(store.Session{ID: "s2", Model: "m", WorkingDir: root}))
+	require.NoError(t, src.SaveCheckpoints("s2", []store.FileCheckpoint{malicious}))
+	mgr, err := checkpoint.New(root, "resume-unsafe", 0)
+	require.NoError(t, err)
+	defer func() { _ = mgr.Cleanup() }()
+	cfg := &config.Config{Agent: config.AgentConfig{MaxTurns: 1, ContextBudget: 100000}}
+	a := New(&mockProvider{}, tools.NewRegistry(), autoApprove, cfg,
+		WithStore(src), WithResumeSession("s2"), WithCheckpointManager(mgr))
+	assert.Empty(t, a.Checkpoints())
+	assert.NoFileExists(t, filepath.Join(tmp, "escape.bak"))
+}
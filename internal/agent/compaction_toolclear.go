@@ -68,6 +68,13 @@ func (s *toolResultClearingStrategy) Compact(_ context.Context, messages []provi
 				newContent[j].Text = fmt.Sprintf("[Tool result cleared — was %d bytes]", len(block.Text))
 				modified = true
 			}
+			// Attached screenshots and documents cost far more than their
+			// text; old ones are always dropped.
+			if block.Type == "tool_result" && len(block.Content) > 0 {
+				newContent[j].Text += fmt.Sprintf("\n[%d attachment(s) cleared]", len(block.Content))
+				newContent[j].Content = nil
+				modified = true
+			}
 		}
 
 		if modified {
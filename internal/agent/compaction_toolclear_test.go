@@ -53,6 +53,25 @@ func TestToolResultClearingSmallResultsPreserved(t *testing.T) {
 	assert.Equal(t, smallContent, result[0].Content[0].Text)
 }
 
+func TestToolResultClearingDropsOldAttachments(t *testing.T) {
+	s := &toolResultClearingStrategy{threshold: 100}
+
+	shot := provider.NewToolResultMessage("t1", "saved", false, provider.ContentBlock{Type: "image"})
+	messages := []provider.Message{
+		shot,
+		{Role: "assistant", Content: []provider.ContentBlock{{Type: "text", Text: "ok"}}},
+		{Role: "user", Content: []provider.ContentBlock{{Type: "text", Text: "next"}}},
+		{Role: "assistant", Content: []provider.ContentBlock{{Type: "text", Text: "response"}}},
+	}
+
+	result, err := s.Compact(context.Background(), messages, 100000)
+	require.NoError(t, err)
+
+	assert.Empty(t, result[0].Content[0].Content)
+	assert.Equal(t, "saved\n[1 attachment(s) cleared]", result[0].Content[0].Text)
+	assert.Len(t, messages[0].Content[0].Content, 1, "input must not be modified")
+}
+
 func TestToolResultClearingRecentResultsUntouched(t *testing.T) {
 	s := &toolResultClearingStrategy{threshold: 100}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/julianshen/rubichan/internal/pricing"
 	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/internal/text"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
@@ -19,6 +20,8 @@ type consumedStream struct {
 	execStream  *streamingToolExecutor
 	thinkingBuf string
 	stopReason  string
+	usage       pricing.Usage // this call's token usage, for the cost ledger
+	model       string        // model that served the call (from message_start), or the requested one
 }
 
 // consumeProviderStream drains one provider stream into an accumulator:
@@ -37,6 +40,8 @@ func (a *Agent) consumeProviderStream(ctx context.Context, ch chan<- TurnEvent,
 	ls.resetPerTurn()
 	var thinkingBuf string
 	var stopReason string
+	var usage pricing.Usage
+	model := a.turnModel()
 
 	// Streaming dispatch: concurrency-safe tools run in the
 	// background as their tool_use blocks finalize during the stream,
@@ -104,6 +109,10 @@ func (a *Agent) consumeProviderStream(ctx context.Context, ch chan<- TurnEvent,
 		// Accumulate token usage from every stream event.
 		*totalInputTokens += event.InputTokens
 		*totalOutputTokens += event.OutputTokens
+		usage = usage.Add(usageFromEvent(event))
+		if event.Type == agentsdk.EventMessageStart && event.Model != "" {
+			model = event.Model
+		}
 
 		// Detect prompt cache breaks on message_start.
 		if event.Type == agentsdk.EventMessageStart && a.cacheBreakDetector != nil {
@@ -156,5 +165,5 @@ func (a *Agent) consumeProviderStream(ctx context.Context, ch chan<- TurnEvent,
 			}
 		}
 	}
-	return consumedStream{acc: acc, execStream: execStream, thinkingBuf: thinkingBuf, stopReason: stopReason}
+	return consumedStream{acc: acc, execStream: execStream, thinkingBuf: thinkingBuf, stopReason: stopReason, usage: usage, model: model}
 }
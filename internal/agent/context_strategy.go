@@ -10,8 +10,8 @@ import (
 
 // WithContextStrategies registers strategies that contribute system-prompt
 // sections at prompt-build time. Contributed sections render after the
-// built-in dynamic sections (scratchpad, progress, knowledge, memories)
-// and before skill prompt fragments. Nil strategies are ignored. See
+// built-in dynamic sections (scratchpad, todo list, progress, knowledge,
+// memories) and before skill prompt fragments. Nil strategies are ignored. See
 // agentsdk.ContextStrategy.
 func WithContextStrategies(strategies ...agentsdk.ContextStrategy) AgentOption {
 	return func(a *Agent) {
@@ -91,6 +91,7 @@ func (a *Agent) contributeStrategySections(ctx context.Context, pb *PromptBuilde
 func (a *Agent) builtinContextStrategies() []agentsdk.ContextStrategy {
 	return []agentsdk.ContextStrategy{
 		scratchpadStrategy{agent: a},
+		todoStrategy{agent: a},
 		progressStrategy{agent: a},
 		knowledgeStrategy{agent: a},
 		memoriesStrategy{agent: a},
@@ -76,6 +76,14 @@ func (c *Conversation) AddUser(text string) {
 	c.messages = append(c.messages, provider.NewUserMessage(text))
 }
 
+// AddUserBlocks appends a user message made of the given content blocks,
+// e.g. text followed by pasted images.
+func (c *Conversation) AddUserBlocks(blocks []provider.ContentBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, provider.Message{Role: "user", Content: blocks})
+}
+
 // AddAssistant appends an assistant message with the given content blocks.
 func (c *Conversation) AddAssistant(blocks []provider.ContentBlock) {
 	c.mu.Lock()
@@ -88,9 +96,15 @@ func (c *Conversation) AddAssistant(blocks []provider.ContentBlock) {
 
 // AddToolResult appends a tool result message to the conversation.
 func (c *Conversation) AddToolResult(toolUseID, content string, isError bool) {
+	c.AddToolResultMedia(toolUseID, content, isError, nil)
+}
+
+// AddToolResultMedia appends a tool result message with image or document
+// blocks attached.
+func (c *Conversation) AddToolResultMedia(toolUseID, content string, isError bool, media []provider.ContentBlock) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.messages = append(c.messages, provider.NewToolResultMessage(toolUseID, content, isError))
+	c.messages = append(c.messages, provider.NewToolResultMessage(toolUseID, content, isError, media...))
 }
 
 // AddSystem appends a system message to the conversation.
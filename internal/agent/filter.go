@@ -1,6 +1,11 @@
 package agent
 
 import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/julianshen/rubichan/internal/permissions"
 	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
 )
@@ -63,3 +68,81 @@ func filterByNames(all []provider.ToolDef, allow []string, deny map[string]bool)
 	}
 	return out
 }
+
+// filterAllowedTools keeps only the tools a turn's allow list names. An
+// empty list allows every tool.
+func filterAllowedTools(all []provider.ToolDef, allowed []string) []provider.ToolDef {
+	if len(allowed) == 0 {
+		return all
+	}
+	var out []provider.ToolDef
+	for _, t := range all {
+		if toolNamed(t.Name, allowed) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// splitAllowEntry splits an allow-list entry such as "shell(git:*)" into
+// the tool name and its parenthesised qualifier.
+func splitAllowEntry(entry string) (name, qualifier string, qualified bool) {
+	entry = strings.TrimSpace(entry)
+	i := strings.IndexByte(entry, '(')
+	if i < 0 || !strings.HasSuffix(entry, ")") {
+		return entry, "", false
+	}
+	return strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1 : len(entry)-1]), true
+}
+
+// toolNamed reports whether any entry of a turn's allow list names the tool,
+// with or without a qualifier.
+func toolNamed(name string, allowed []string) bool {
+	for _, entry := range allowed {
+		if tool, _, _ := splitAllowEntry(entry); tool == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toolAllowed reports whether a turn's allow list permits calling name with
+// input. A bare entry allows every call of the tool. A qualified shell entry
+// allows only matching commands: "shell(git:*)" allows commands whose every
+// sub-command starts with git, and "shell(go test)" allows exactly that
+// command. Qualified entries for other tools allow no calls; command files
+// reject them when they are loaded.
+func toolAllowed(name string, input json.RawMessage, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	var prefixes, exact []string
+	for _, entry := range allowed {
+		tool, qualifier, qualified := splitAllowEntry(entry)
+		if tool != name {
+			continue
+		}
+		if !qualified {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(qualifier, ":*"); ok {
+			prefixes = append(prefixes, prefix)
+		} else {
+			exact = append(exact, qualifier)
+		}
+	}
+	if name != "shell" || len(prefixes)+len(exact) == 0 {
+		return false
+	}
+	var parsed struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(input, &parsed); err != nil {
+		return false
+	}
+	command := strings.TrimSpace(parsed.Command)
+	if slices.Contains(exact, command) {
+		return true
+	}
+	return permissions.ShellCommandAllowed(command, prefixes)
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/julianshen/rubichan/internal/provider"
@@ -83,3 +84,28 @@ func toolNamesFromDefs(tools []provider.ToolDef) []string {
 	}
 	return names
 }
+
+func TestToolAllowedEnforcesShellQualifiers(t *testing.T) {
+	cmd := func(c string) json.RawMessage {
+		data, _ := json.Marshal(map[string]string{"command": c})
+		return data
+	}
+	allowed := []string{"read_file", "shell(git:*)", "shell(go test ./...)"}
+
+	require.True(t, toolAllowed("read_file", nil, allowed))
+	require.True(t, toolAllowed("shell", cmd("git status"), allowed))
+	require.True(t, toolAllowed("shell", cmd("git log | git shortlog"), allowed))
+	require.True(t, toolAllowed("shell", cmd("go test ./..."), allowed))
+
+	require.False(t, toolAllowed("shell", cmd("rm -rf /"), allowed))
+	require.False(t, toolAllowed("shell", cmd("git status && rm -rf /"), allowed))
+	require.False(t, toolAllowed("shell", cmd("git log $(rm -rf /)"), allowed))
+	require.False(t, toolAllowed("shell", cmd("gitk"), allowed), "prefixes match whole words")
+	require.False(t, toolAllowed("shell", cmd("go test ./... -exec rm"), allowed))
+	require.False(t, toolAllowed("shell", json.RawMessage(`{`), allowed))
+	require.False(t, toolAllowed("write_file", nil, allowed))
+
+	require.True(t, toolAllowed("shell", cmd("rm -rf /"), []string{"shell(git:*)", "shell"}), "a bare entry allows every call")
+	require.False(t, toolAllowed("file", nil, []string{"file(read:*)"}), "qualifiers on other tools are not enforceable")
+	require.Equal(t, []provider.ToolDef{{Name: "shell"}}, filterAllowedTools([]provider.ToolDef{{Name: "file"}, {Name: "shell"}}, allowed))
+}
@@ -2,7 +2,7 @@ package agent
 
 import (
 	"github.com/julianshen/rubichan/internal/provider"
-	"github.com/julianshen/rubichan/internal/provider/normalize"
+	"github.com/julianshen/rubichan/pkg/provider/normalize"
 )
 
 // normalizeMessages cleans up conversation messages before sending to the LLM.
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -442,3 +443,86 @@ func TestBeforeHookFailureIsLogged(t *testing.T) {
 	assert.Contains(t, joined, "HookOnBeforeToolCall failed",
 		"hook failure must leave an operator-facing warning in the log")
 }
+
+// writingFileTool is a file tool stub that actually writes, so rewind has
+// something to undo.
+type writingFileTool struct{}
+
+func (writingFileTool) Name() string                 { return "file" }
+func (writingFileTool) Description() string          { return "stub writing file tool" }
+func (writingFileTool) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (writingFileTool) Execute(_ context.Context, input json.RawMessage) (tools.ToolResult, error) {
+	var in struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(input, &in); err != nil {
+		return tools.ToolResult{}, err
+	}
+	return tools.ToolResult{Content: "ok"}, os.WriteFile(in.Path, []byte(in.Content), 0o644)
+}
+
+// TestRewindAcrossUserTurns pins checkpoint and snapshot turn numbering
+// across Turn() calls. The loop's own counter restarts with every user
+// message; keying captures and tree snapshots on it made their cutoffs
+// disagree, so rewind restored one tree while discarding per-file
+// checkpoints it had never applied.
+func TestRewindAcrossUserTurns(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	out, err := exec.Command("git", "init", "--quiet", dir).CombinedOutput()
+	require.NoError(t, err, string(out))
+	target := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(target, []byte("v0"), 0o644))
+
+	mgr, err := checkpoint.New(dir, "sess-multi-turn", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = mgr.Cleanup() })
+	require.NoError(t, mgr.EnableSnapshots(context.Background(), checkpoint.SnapshotLimits{}))
+
+	write := func(id, content string) []provider.StreamEvent {
+		input, _ := json.Marshal(map[string]string{"operation": "write", "path": target, "content": content})
+		return []provider.StreamEvent{
+			{Type: "tool_use", ToolUse: &provider.ToolUseBlock{ID: id, Name: "file"}},
+			{Type: "text_delta", Text: string(input)},
+			{Type: "stop"},
+		}
+	}
+	finish := []provider.StreamEvent{{Type: "text_delta", Text: "done"}, {Type: "stop"}}
+	mp := &dynamicMockProvider{responses: [][]provider.StreamEvent{
+		write("tc-1", "v1"), finish,
+		write("tc-2", "v2"), finish,
+	}}
+
+	reg := tools.NewRegistry()
+	require.NoError(t, reg.Register(writingFileTool{}))
+	a := New(mp, reg, autoApprove, config.DefaultConfig(), WithCheckpointManager(mgr))
+
+	for _, msg := range []string{"first", "second"} {
+		ch, err := a.Turn(context.Background(), msg)
+		require.NoError(t, err)
+		for range ch {
+		}
+	}
+
+	cps := mgr.List()
+	require.Len(t, cps, 2)
+	require.Greater(t, cps[1].Turn, cps[0].Turn, "turn numbers must keep increasing across user messages")
+
+	// Undo only the second message's edit, as the TUI undo overlay does.
+	_, err = mgr.RewindToTurn(context.Background(), cps[1].Turn-1)
+	require.NoError(t, err)
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+	assert.Len(t, mgr.List(), 1, "the first message's checkpoint survives")
+
+	_, err = mgr.RewindToTurn(context.Background(), cps[0].Turn-1)
+	require.NoError(t, err)
+	data, err = os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "v0", string(data))
+	assert.Empty(t, mgr.List())
+}
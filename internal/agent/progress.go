@@ -147,11 +147,15 @@ func classifyToolAction(toolName string, input json.RawMessage) (action, detail
 	case "notes":
 		op := jsonStr(parsed["action"])
 		return "notes " + op, jsonStr(parsed["tag"])
+	case "todo":
+		return "todo " + jsonStr(parsed["action"]), truncateResult(jsonStr(parsed["content"]), 60)
 	case "task":
 		desc := jsonStr(parsed["description"])
 		return "spawned task", truncateResult(desc, 60)
 	case "task_complete":
 		return "completed task", jsonStr(parsed["summary"])
+	case "ask_user":
+		return "asked the user", truncateResult(jsonStr(parsed["question"]), 60)
 	default:
 		return toolName, ""
 	}
@@ -7,8 +7,8 @@ import (
 
 	"github.com/julianshen/rubichan/internal/agent/errorclass"
 	"github.com/julianshen/rubichan/internal/provider"
-	"github.com/julianshen/rubichan/internal/provider/normalize"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider/normalize"
 )
 
 // loopStepOutcome tells runLoop how to proceed after one of its extracted
@@ -138,6 +138,7 @@ func (a *Agent) streamWithRecovery(ctx context.Context, ch chan<- TurnEvent, ls
 			}, onRetry)
 			if fallbackErr == nil {
 				ls.lastContinueReason = ContinueModelFallback
+				a.noteActiveProvider(ctx, ch)
 				return stream, stepProceed
 			}
 			a.logger.Warn("fallback model also failed: %v", fallbackErr)
@@ -151,5 +152,26 @@ func (a *Agent) streamWithRecovery(ctx context.Context, ch chan<- TurnEvent, ls
 		return nil, stepEnded
 	}
 
+	a.noteActiveProvider(ctx, ch)
 	return stream, stepProceed
 }
+
+// noteActiveProvider emits a provider_switch event when a failover chain
+// served this call from a different provider than the previous one.
+func (a *Agent) noteActiveProvider(ctx context.Context, ch chan<- TurnEvent) {
+	reporter, ok := a.provider.(provider.ActiveProviderReporter)
+	if !ok {
+		return
+	}
+	active := reporter.ActiveProvider()
+	if active.Switches == a.providerSwitches {
+		return
+	}
+	a.providerSwitches = active.Switches
+	a.logger.Warn("provider failover: %s -> %s (%s)", active.Previous, active.Label(), active.Reason)
+	a.emit(ctx, ch, TurnEvent{
+		Type:           "provider_switch",
+		Model:          active.Model,
+		ProviderSwitch: &ProviderSwitchEvent{From: active.Previous, To: active.Label(), Reason: active.Reason},
+	})
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// downProvider always fails with a provider-side error that TurnRetry does
+// not retry, so the failover chain moves on immediately.
+type downProvider struct{}
+
+func (downProvider) Stream(context.Context, provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	return nil, &provider.ProviderError{Kind: provider.ErrAuthFailed, Provider: "anthropic", Message: "key revoked"}
+}
+
+type okProvider struct{}
+
+func (okProvider) Stream(context.Context, provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	ch := make(chan provider.StreamEvent, 2)
+	ch <- provider.StreamEvent{Type: "text_delta", Text: "from backup"}
+	ch <- provider.StreamEvent{Type: "stop", StopReason: "end_turn", InputTokens: 1, OutputTokens: 1}
+	close(ch)
+	return ch, nil
+}
+
+func TestStreamWithRecovery_EmitsProviderSwitch(t *testing.T) {
+	chain := provider.NewFailoverProvider([]provider.ChainEntry{
+		{Name: "anthropic", Model: "claude-sonnet-4-5", Provider: downProvider{}},
+		{Name: "ollama", Model: "llama3", Provider: okProvider{}},
+	}, 1, time.Minute)
+	a := New(chain, tools.NewRegistry(), autoApprove, config.DefaultConfig())
+
+	var switches []*ProviderSwitchEvent
+	for i := 0; i < 2; i++ {
+		ch, err := a.Turn(context.Background(), "hello")
+		require.NoError(t, err)
+		for evt := range ch {
+			if evt.Type == "provider_switch" {
+				require.NotNil(t, evt.ProviderSwitch)
+				assert.Equal(t, "llama3", evt.Model)
+				switches = append(switches, evt.ProviderSwitch)
+			}
+		}
+	}
+
+	require.Len(t, switches, 1, "the switch is reported once, not on every call")
+	assert.Equal(t, "anthropic/claude-sonnet-4-5", switches[0].From)
+	assert.Equal(t, "ollama/llama3", switches[0].To)
+	assert.Contains(t, switches[0].Reason, "key revoked")
+}
+
+func TestStreamWithRecovery_NoSwitchEventWithoutChain(t *testing.T) {
+	a := New(okProvider{}, tools.NewRegistry(), autoApprove, config.DefaultConfig())
+	ch, err := a.Turn(context.Background(), "hello")
+	require.NoError(t, err)
+	for evt := range ch {
+		assert.NotEqual(t, "provider_switch", evt.Type)
+	}
+}
@@ -23,6 +23,12 @@ type ToolResultEvent = agentsdk.ToolResultEvent
 // ToolProgressEvent contains a streaming progress chunk from a tool execution.
 type ToolProgressEvent = agentsdk.ToolProgressEvent
 
+// ProviderSwitchEvent reports a failover chain moving to another provider.
+type ProviderSwitchEvent = agentsdk.ProviderSwitchEvent
+
+// PlanEntry is one item of the model's todo list in a plan_update event.
+type PlanEntry = agentsdk.PlanEntry
+
 // UIRequestKind identifies generalized UI interaction categories.
 type UIRequestKind = agentsdk.UIRequestKind
 
@@ -72,6 +78,9 @@ type AllowAllParallel = agentsdk.AllowAllParallel
 // ApprovalResult represents the approval decision for a tool call.
 type ApprovalResult = agentsdk.ApprovalResult
 
+// ApprovalEdit carries a user's changes to an approved tool call.
+type ApprovalEdit = agentsdk.ApprovalEdit
+
 // Re-export approval result constants.
 const (
 	ApprovalRequired  = agentsdk.ApprovalRequired
@@ -51,6 +51,7 @@ type DefaultSubagentSpawner struct {
 	AgentDefs          *AgentDefRegistry
 	WorktreeProvider   WorktreeProvider   // Optional; required for isolation: "worktree"
 	RateLimiter        *SharedRateLimiter // Optional; shared rate limiter propagated to children
+	UsageMeter         *UsageMeter        // Optional; bills children's provider calls to the meter's owner
 	Logger             Logger             // Optional; defaults to log.Printf-based logger
 }
 
@@ -145,6 +146,9 @@ func (s *DefaultSubagentSpawner) Spawn(ctx context.Context, cfg SubagentConfig,
 	if s.RateLimiter != nil {
 		opts = append(opts, WithRateLimiter(s.RateLimiter))
 	}
+	if s.UsageMeter != nil {
+		opts = append(opts, WithUsageMeter(s.UsageMeter))
+	}
 	if cfg.SystemPrompt != "" {
 		opts = append(opts, WithExtraSystemPrompt("Subagent Instructions", cfg.SystemPrompt))
 	}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// TodoAccess defines the interface for the todo tool to read and replace
+// the agent's todo list. This breaks the import cycle between tools/ and
+// agent/.
+type TodoAccess interface {
+	Items() []PlanEntry
+	Set(items []PlanEntry)
+}
+
+// TodoList is the plan the model keeps with the todo tool. It is rendered
+// into the system prompt every turn, so it survives compaction, persisted
+// with the session and published to hosts as plan_update events.
+type TodoList struct {
+	mu      sync.Mutex
+	items   []PlanEntry
+	changed bool
+}
+
+// NewTodoList creates an empty TodoList.
+func NewTodoList() *TodoList {
+	return &TodoList{}
+}
+
+// Items returns a copy of the list.
+func (t *TodoList) Items() []PlanEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]PlanEntry(nil), t.items...)
+}
+
+// Set replaces the list.
+func (t *TodoList) Set(items []PlanEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.items = append([]PlanEntry(nil), items...)
+	t.changed = true
+}
+
+// load replaces the list with one restored from the store, without
+// marking it changed.
+func (t *TodoList) load(items []PlanEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.items = append([]PlanEntry(nil), items...)
+	t.changed = false
+}
+
+// takeChanged reports whether the list changed since the last call.
+func (t *TodoList) takeChanged() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	changed := t.changed
+	t.changed = false
+	return changed
+}
+
+// Render formats the list for system prompt injection. Returns "" if the
+// list is empty.
+func (t *TodoList) Render() string {
+	items := t.Items()
+	if len(items) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Your todo list for this task. Keep it current with the todo tool as you work.\n\n")
+	for i, item := range items {
+		fmt.Fprintf(&sb, "%d. [%s] %s\n", i+1, item.Status, item.Content)
+	}
+	return sb.String()
+}
+
+// TodoAccess returns the agent's todo list for external use (e.g., by the
+// todo tool).
+func (a *Agent) TodoAccess() TodoAccess {
+	return a.todos
+}
+
+// publishTodos persists the todo list and emits a plan_update event when
+// the todo tool has changed it since the last call.
+func (a *Agent) publishTodos(ctx context.Context, ch chan<- TurnEvent) {
+	if a.todos == nil || !a.todos.takeChanged() {
+		return
+	}
+	items := a.todos.Items()
+	if a.store != nil && a.sessionID != "" {
+		if err := a.store.SaveTodos(a.sessionID, items); err != nil {
+			a.logger.Warn("failed to save todos: %v", err)
+		}
+	}
+	a.emit(ctx, ch, TurnEvent{Type: "plan_update", Plan: items})
+}
+
+// todoStrategy contributes the model-managed todo list.
+type todoStrategy struct{ agent *Agent }
+
+func (s todoStrategy) ContributePromptSections(context.Context, agentsdk.PromptContext) []agentsdk.PromptSection {
+	if s.agent.todos == nil {
+		return nil
+	}
+	return []agentsdk.PromptSection{{
+		Title:   "Todo List",
+		Content: s.agent.todos.Render(),
+		Reason:  "the model updates its todo list as it works",
+	}}
+}
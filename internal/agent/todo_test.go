@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/store"
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+func TestTodoListRenderAndChanges(t *testing.T) {
+	l := NewTodoList()
+	assert.Empty(t, l.Render())
+	assert.False(t, l.takeChanged())
+
+	l.Set([]agentsdk.PlanEntry{{Content: "fix bug", Status: agentsdk.PlanEntryInProgress}})
+	assert.True(t, l.takeChanged())
+	assert.False(t, l.takeChanged())
+	assert.Contains(t, l.Render(), "1. [in_progress] fix bug")
+
+	l.load(nil)
+	assert.False(t, l.takeChanged())
+	assert.Empty(t, l.Items())
+}
+
+func TestTodoToolUpdatesPlanAndPersists(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	mp := &dynamicMockProvider{responses: [][]provider.StreamEvent{
+		{
+			{Type: "tool_use", ToolUse: &provider.ToolUseBlock{ID: "tool_1", Name: "todo"}},
+			{Type: "text_delta", Text: `{"action":"set","items":[{"content":"write test","status":"in_progress"},{"content":"fix"}]}`},
+			{Type: "stop"},
+		},
+		{{Type: "text_delta", Text: "planned"}, {Type: "stop"}},
+		{{Type: "text_delta", Text: "again"}, {Type: "stop"}},
+	}}
+	cfg := config.DefaultConfig()
+	cfg.Agent.MaxTurns = 5
+	reg := tools.NewRegistry()
+	a := New(mp, reg, autoApprove, cfg, WithStore(s))
+	require.NoError(t, reg.Register(tools.NewTodoTool(a.TodoAccess())))
+
+	plans := func(msg string) [][]agentsdk.PlanEntry {
+		ch, err := a.Turn(context.Background(), msg)
+		require.NoError(t, err)
+		var out [][]agentsdk.PlanEntry
+		for ev := range ch {
+			if ev.Type == "plan_update" {
+				out = append(out, ev.Plan)
+			}
+		}
+		return out
+	}
+
+	want := []agentsdk.PlanEntry{
+		{Content: "write test", Status: agentsdk.PlanEntryInProgress},
+		{Content: "fix", Status: agentsdk.PlanEntryPending},
+	}
+	assert.Equal(t, [][]agentsdk.PlanEntry{want}, plans("plan it"))
+	stored, err := s.GetTodos(a.SessionID())
+	require.NoError(t, err)
+	assert.Equal(t, want, stored)
+
+	// The next turn opens with the list, which the prompt also carries.
+	assert.Equal(t, [][]agentsdk.PlanEntry{want}, plans("go on"))
+
+	resumed := New(&mockProvider{}, tools.NewRegistry(), autoApprove, cfg, WithStore(s), WithResumeSession(a.SessionID()))
+	assert.Equal(t, want, resumed.TodoAccess().Items())
+}
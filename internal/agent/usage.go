@@ -0,0 +1,217 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/pricing"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+// usageFromEvent extracts the token usage a single stream event reports.
+// Providers spread usage across message_start, message_delta and stop
+// events, so callers sum this over the whole stream.
+func usageFromEvent(ev provider.StreamEvent) pricing.Usage {
+	return pricing.Usage{
+		Input:      ev.InputTokens,
+		Output:     ev.OutputTokens,
+		CacheRead:  ev.CacheReadTokens,
+		CacheWrite: ev.CacheCreationTokens,
+		Reasoning:  ev.ReasoningTokens,
+	}
+}
+
+// recordUsage prices one provider call, adds it to the running turn and
+// session totals, and appends it to the store's usage ledger. Ledger write
+// failures are logged, not returned: losing a row must not fail the turn.
+// Safe for concurrent use; background calls (auto-dream, activity summaries)
+// record alongside the main loop. A subagent counts the call toward its own
+// turn and bills it to the agent that owns its usage meter.
+func (a *Agent) recordUsage(model string, u pricing.Usage) {
+	if u.IsZero() {
+		return
+	}
+	cost := a.pricing.Cost(model, u)
+	if a.usageParent != nil {
+		a.usageMu.Lock()
+		a.turnCostUSD += cost
+		a.usageMu.Unlock()
+		a.usageParent.recordCost(model, u, cost)
+		return
+	}
+	a.recordCost(model, u, cost)
+}
+
+// recordCost adds an already-priced call to the turn and session totals and
+// the ledger.
+func (a *Agent) recordCost(model string, u pricing.Usage, cost float64) {
+	a.usageMu.Lock()
+	a.turnCostUSD += cost
+	a.sessionCostUSD += cost
+	a.usageMu.Unlock()
+
+	if a.store == nil || a.sessionID == "" {
+		return
+	}
+	err := a.store.RecordUsage(store.UsageRecord{
+		SessionID:        a.sessionID,
+		Project:          a.workingDir,
+		Model:            model,
+		InputTokens:      u.Input,
+		OutputTokens:     u.Output,
+		CacheReadTokens:  u.CacheRead,
+		CacheWriteTokens: u.CacheWrite,
+		ReasoningTokens:  u.Reasoning,
+		CostUSD:          cost,
+	})
+	if err != nil {
+		a.logger.Warn("usage ledger: %v", err)
+	}
+}
+
+// turnCost returns the priced cost recorded since the current turn began.
+func (a *Agent) turnCost() float64 {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	return a.turnCostUSD
+}
+
+// sessionCost returns the session's total spend. With a store the ledger is
+// authoritative, so resumed sessions count what earlier processes spent.
+func (a *Agent) sessionCost() float64 {
+	if a.store != nil && a.sessionID != "" {
+		cost, err := a.store.SessionCost(a.sessionID)
+		if err == nil {
+			return cost
+		}
+		a.logger.Warn("usage ledger: %v", err)
+	}
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	return a.sessionCostUSD
+}
+
+// checkCostBudget returns an error naming the first configured spending cap
+// that recorded usage has reached, or nil when every cap has headroom. The
+// project cap covers the working directory's spend in the current calendar
+// month and needs a store; without one only the session cap applies.
+func (a *Agent) checkCostBudget(now time.Time) error {
+	if a.usageParent != nil {
+		return a.usageParent.checkCostBudget(now)
+	}
+	if limit := a.budget.SessionUSD; limit > 0 {
+		if spent := a.sessionCost(); spent >= limit {
+			return fmt.Errorf("session budget of $%.2f reached ($%.2f spent)", limit, spent)
+		}
+	}
+	if limit := a.budget.ProjectMonthlyUSD; limit > 0 && a.store != nil && a.workingDir != "" {
+		// The ledger and `rubichan usage --month` both work in UTC.
+		now = now.UTC()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		spent, err := a.store.ProjectCost(a.workingDir, monthStart)
+		if err != nil {
+			a.logger.Warn("usage ledger: %v", err)
+			return nil
+		}
+		if spent >= limit {
+			return fmt.Errorf("monthly project budget of $%.2f reached ($%.2f spent this month)", limit, spent)
+		}
+	}
+	return nil
+}
+
+// UsageMeter bills provider calls made outside an agent's own loop — compaction
+// summaries, skill and wiki completions, security analyzers, subagents — to
+// that agent's usage ledger and spending caps. Create it before the agent so
+// the providers handed to those consumers can be wrapped, then pass it to New
+// with WithUsageMeter. The first agent built with the meter owns it; later
+// agents built with it (subagents) bill their calls to the owner. Calls made
+// before the owner exists are not recorded.
+type UsageMeter struct {
+	mu    sync.Mutex
+	owner *Agent
+}
+
+// NewUsageMeter creates an unbound UsageMeter.
+func NewUsageMeter() *UsageMeter {
+	return &UsageMeter{}
+}
+
+// bind makes a the meter's owner if it has none and returns the agent a
+// should bill instead of itself, or nil when a is the owner.
+func (m *UsageMeter) bind(a *Agent) *Agent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owner == nil {
+		m.owner = a
+	}
+	if m.owner == a {
+		return nil
+	}
+	return m.owner
+}
+
+func (m *UsageMeter) record(model string, u pricing.Usage) {
+	m.mu.Lock()
+	owner := m.owner
+	m.mu.Unlock()
+	if owner != nil {
+		owner.recordUsage(model, u)
+	}
+}
+
+// Wrap returns a provider that forwards to p and records the usage of every
+// stream it serves. model names the call in the ledger when a request leaves
+// Model empty and the provider does not report one. Wrapping a nil provider
+// returns nil.
+func (m *UsageMeter) Wrap(p provider.LLMProvider, model string) provider.LLMProvider {
+	if m == nil || p == nil {
+		return p
+	}
+	return &meteredProvider{inner: p, model: model, meter: m}
+}
+
+// meteredProvider is the provider returned by UsageMeter.Wrap.
+type meteredProvider struct {
+	inner provider.LLMProvider
+	model string
+	meter *UsageMeter
+}
+
+func (p *meteredProvider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	in, err := p.inner.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	model := req.Model
+	if model == "" {
+		model = p.model
+	}
+	out := make(chan provider.StreamEvent, cap(in))
+	go func() {
+		defer close(out)
+		var usage pricing.Usage
+		forward := true
+		for ev := range in {
+			usage = usage.Add(usageFromEvent(ev))
+			if ev.Model != "" {
+				model = ev.Model
+			}
+			if !forward {
+				continue
+			}
+			// A consumer that stops reading after cancellation must not
+			// strand the rest of the stream: keep draining for usage.
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				forward = false
+			}
+		}
+		p.meter.record(model, usage)
+	}()
+	return out, nil
+}
@@ -0,0 +1,250 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/store"
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pricedCallEvents is one gpt-4o call that uses 1M input and 100k output
+// tokens: $2.50 + $1.00 at built-in prices.
+func pricedCallEvents() []provider.StreamEvent {
+	return []provider.StreamEvent{
+		{Type: "message_start", Model: "gpt-4o"},
+		{Type: "text_delta", Text: "done"},
+		{Type: "stop", StopReason: "end_turn", InputTokens: 1_000_000, OutputTokens: 100_000},
+	}
+}
+
+// pricedProvider replays pricedCallEvents and counts calls.
+type pricedProvider struct {
+	calls int
+}
+
+func (c *pricedProvider) Stream(context.Context, provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	c.calls++
+	events := pricedCallEvents()
+	ch := make(chan provider.StreamEvent, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	return ch, nil
+}
+
+func runTurn(t *testing.T, a *Agent) (done TurnEvent, errs []error) {
+	t.Helper()
+	ch, err := a.Turn(context.Background(), "hello")
+	require.NoError(t, err)
+	for evt := range ch {
+		switch evt.Type {
+		case "done":
+			done = evt
+		case "error":
+			errs = append(errs, evt.Error)
+		}
+	}
+	return done, errs
+}
+
+func TestRecordUsageWritesLedgerAndDoneCost(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	wd := t.TempDir()
+	prov := &pricedProvider{}
+	a := New(prov, tools.NewRegistry(), autoApprove, config.DefaultConfig(), WithStore(s), WithWorkingDir(wd))
+
+	done, _ := runTurn(t, a)
+	assert.Equal(t, agentsdk.ExitCompleted, done.ExitReason)
+	assert.InDelta(t, 3.50, done.CostUSD, 1e-9)
+
+	rows, err := s.UsageReport(store.UsageQuery{GroupBy: store.UsageByModel})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "gpt-4o", rows[0].Key, "model comes from message_start")
+	assert.Equal(t, 1, rows[0].Calls)
+	assert.Equal(t, 1_000_000, rows[0].InputTokens)
+	assert.InDelta(t, 3.50, rows[0].CostUSD, 1e-9)
+
+	cost, err := s.SessionCost(a.SessionID())
+	require.NoError(t, err)
+	assert.InDelta(t, 3.50, cost, 1e-9)
+}
+
+func TestDoneCostIsPerTurn(t *testing.T) {
+	a := New(&pricedProvider{}, tools.NewRegistry(), autoApprove, config.DefaultConfig())
+
+	first, _ := runTurn(t, a)
+	second, _ := runTurn(t, a)
+	assert.InDelta(t, 3.50, first.CostUSD, 1e-9)
+	assert.InDelta(t, 3.50, second.CostUSD, 1e-9)
+}
+
+func TestConfigPricingOverridesBuiltins(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Pricing = map[string]config.ModelPricingConfig{"gpt-4o": {Input: 1.0, Output: 10.0}}
+	a := New(&pricedProvider{}, tools.NewRegistry(), autoApprove, cfg)
+
+	done, _ := runTurn(t, a)
+	assert.InDelta(t, 2.0, done.CostUSD, 1e-9)
+}
+
+func TestSessionBudgetStopsLoop(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Budget.SessionUSD = 3.0
+	prov := &pricedProvider{}
+	a := New(prov, tools.NewRegistry(), autoApprove, cfg, WithStore(s), WithWorkingDir(t.TempDir()))
+
+	// The call that crosses the cap is allowed to finish...
+	done, _ := runTurn(t, a)
+	assert.Equal(t, agentsdk.ExitCompleted, done.ExitReason)
+
+	// ...and the next provider call is refused.
+	done, errs := runTurn(t, a)
+	assert.Equal(t, agentsdk.ExitCostBudgetExceeded, done.ExitReason)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "session budget of $3.00 reached")
+	assert.Equal(t, 1, prov.calls)
+}
+
+func TestSessionBudgetWithoutStore(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Budget.SessionUSD = 3.0
+	prov := &pricedProvider{}
+	a := New(prov, tools.NewRegistry(), autoApprove, cfg)
+
+	runTurn(t, a)
+	done, _ := runTurn(t, a)
+	assert.Equal(t, agentsdk.ExitCostBudgetExceeded, done.ExitReason)
+	assert.Equal(t, 1, prov.calls)
+}
+
+func TestProjectMonthlyBudgetStopsLoop(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	wd := t.TempDir()
+	// Spend from an earlier session in the same project this month.
+	require.NoError(t, s.RecordUsage(store.UsageRecord{SessionID: "earlier", Project: wd, Model: "gpt-4o", CostUSD: 150}))
+	// Spend from last month does not count.
+	lastMonth := time.Now().AddDate(0, -1, 0)
+	require.NoError(t, s.RecordUsage(store.UsageRecord{SessionID: "old", Project: wd, Model: "gpt-4o", CostUSD: 500, CreatedAt: lastMonth}))
+
+	cfg := config.DefaultConfig()
+	cfg.Budget.ProjectMonthlyUSD = 100
+	prov := &pricedProvider{}
+	a := New(prov, tools.NewRegistry(), autoApprove, cfg, WithStore(s), WithWorkingDir(wd))
+
+	done, errs := runTurn(t, a)
+	assert.Equal(t, agentsdk.ExitCostBudgetExceeded, done.ExitReason)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "monthly project budget of $100.00 reached ($150.00 spent this month)")
+	assert.Zero(t, prov.calls)
+}
+
+func TestProjectMonthlyBudgetIgnoresOtherProjects(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.RecordUsage(store.UsageRecord{SessionID: "x", Project: t.TempDir(), Model: "gpt-4o", CostUSD: 500}))
+
+	cfg := config.DefaultConfig()
+	cfg.Budget.ProjectMonthlyUSD = 100
+	prov := &pricedProvider{}
+	a := New(prov, tools.NewRegistry(), autoApprove, cfg, WithStore(s), WithWorkingDir(t.TempDir()))
+
+	done, _ := runTurn(t, a)
+	assert.Equal(t, agentsdk.ExitCompleted, done.ExitReason)
+	assert.Equal(t, 1, prov.calls)
+}
+
+func TestProjectMonthlyBudgetUsesUTCMonth(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	wd := t.TempDir()
+	require.NoError(t, s.RecordUsage(store.UsageRecord{
+		SessionID: "earlier", Project: wd, Model: "gpt-4o", CostUSD: 150,
+		CreatedAt: time.Date(2026, 2, 15, 12, 0, 0, 0, time.UTC),
+	}))
+
+	cfg := config.DefaultConfig()
+	cfg.Budget.ProjectMonthlyUSD = 100
+	a := New(&pricedProvider{}, tools.NewRegistry(), autoApprove, cfg, WithStore(s), WithWorkingDir(wd))
+
+	// Already March locally, still February in UTC: February's spend counts,
+	// matching `rubichan usage --month 2026-02`.
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.FixedZone("UTC+5", 5*3600))
+	err = a.checkCostBudget(now)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "$150.00 spent this month")
+}
+
+func TestUsageMeterBillsWrappedCallsToOwner(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	meter := NewUsageMeter()
+	a := New(&mockProvider{}, tools.NewRegistry(), autoApprove, config.DefaultConfig(),
+		WithStore(s), WithWorkingDir(t.TempDir()), WithUsageMeter(meter))
+
+	summarizer := NewLLMSummarizer(meter.Wrap(&pricedProvider{}, "gpt-4o"), "gpt-4o")
+	_, err = summarizer.Summarize(context.Background(), []provider.Message{provider.NewUserMessage("hi")})
+	require.NoError(t, err)
+
+	cost, err := s.SessionCost(a.SessionID())
+	require.NoError(t, err)
+	assert.InDelta(t, 3.50, cost, 1e-9)
+}
+
+func TestUsageMeterWrapNil(t *testing.T) {
+	var meter *UsageMeter
+	p := &pricedProvider{}
+	assert.Same(t, p, meter.Wrap(p, "gpt-4o"))
+	assert.Nil(t, NewUsageMeter().Wrap(nil, "gpt-4o"))
+}
+
+func TestSubagentUsageBilledToParent(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Budget.SessionUSD = 3.0
+	meter := NewUsageMeter()
+	parent := New(&mockProvider{}, tools.NewRegistry(), autoApprove, cfg,
+		WithStore(s), WithWorkingDir(t.TempDir()), WithUsageMeter(meter))
+
+	childProv := &pricedProvider{}
+	child := New(childProv, tools.NewRegistry(), autoApprove, cfg, WithUsageMeter(meter))
+
+	done, _ := runTurn(t, child)
+	assert.InDelta(t, 3.50, done.CostUSD, 1e-9, "the child still reports its own turn cost")
+
+	cost, err := s.SessionCost(parent.SessionID())
+	require.NoError(t, err)
+	assert.InDelta(t, 3.50, cost, 1e-9, "the child's spend lands in the parent's ledger")
+
+	// The parent's session cap now stops the child too.
+	done, _ = runTurn(t, child)
+	assert.Equal(t, agentsdk.ExitCostBudgetExceeded, done.ExitReason)
+	assert.Equal(t, 1, childProv.calls)
+}
@@ -20,6 +20,27 @@ var ErrNoCheckpoints = errors.New("no checkpoints to undo")
 const defaultMemBudget = 100 * 1024 * 1024 // 100MB
 const spillThreshold = 1024 * 1024         // 1MB
 
+// maxIDLen bounds checkpoint IDs; Capture's UUIDs are 36 characters.
+const maxIDLen = 64
+
+// ValidID reports whether id is a plain checkpoint ID: letters, digits, "-"
+// and "_" only, like the UUIDs Capture assigns. Spill files are named after
+// IDs, so IDs from elsewhere (a resumed or imported session) must pass it
+// before they reach the filesystem.
+func ValidID(id string) bool {
+	if id == "" || len(id) > maxIDLen {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Checkpoint represents a snapshot of a file before modification.
 type Checkpoint struct {
 	ID           string
@@ -41,11 +62,15 @@ func (c Checkpoint) IsSpilled() bool { return c.spilled }
 // Manager manages a stack of file checkpoints with memory budget and disk spillover.
 type Manager struct {
 	mu        sync.Mutex
+	snapMu    sync.Mutex // serializes shadow-store git operations; acquired before mu
 	stack     []Checkpoint
 	rootDir   string
 	memUsed   int64
 	memBudget int64
 	spillDir  string
+	shadow    *shadowRepo    // nil unless EnableSnapshots succeeded
+	limits    SnapshotLimits // checked before every snapshot
+	snapshots []TreeSnapshot // whole-tree snapshots, oldest first
 }
 
 // New creates a Manager with the given root directory and session ID.
@@ -86,6 +111,19 @@ func (m *Manager) List() []Checkpoint {
 	return cp
 }
 
+// Data returns the file content captured by cp, reading it back from disk
+// when it was spilled. It returns nil for a creation checkpoint.
+func (m *Manager) Data(cp Checkpoint) ([]byte, error) {
+	if !cp.spilled {
+		return cp.OriginalData, nil
+	}
+	data, err := os.ReadFile(cp.spillPath)
+	if err != nil {
+		return nil, fmt.Errorf("read spill file: %w", err)
+	}
+	return data, nil
+}
+
 // Capture snapshots a file before modification.
 func (m *Manager) Capture(ctx context.Context, filePath string, turn int, operation string) (string, error) {
 	absPath, err := m.resolvePath(filePath)
@@ -120,40 +158,94 @@ func (m *Manager) Capture(ctx context.Context, filePath string, turn int, operat
 		Size:         size,
 	}
 
+	m.mu.Lock()
+	needsManifest, err := m.pushLocked(cp)
+	m.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	if needsManifest {
+		m.writeManifest() // best-effort manifest update; ignore error
+	}
+
+	return id, nil
+}
+
+// Load pushes checkpoints captured by an earlier run, oldest first, so a
+// resumed session can undo them. Relative paths resolve against the root
+// directory; if any path is outside it, or any ID is not a ValidID, nothing
+// is loaded.
+func (m *Manager) Load(cps []Checkpoint) error {
+	resolved := make([]Checkpoint, len(cps))
+	for i, cp := range cps {
+		if !ValidID(cp.ID) {
+			return fmt.Errorf("checkpoint: invalid id %q", cp.ID)
+		}
+		absPath, err := m.resolvePath(cp.FilePath)
+		if err != nil {
+			return fmt.Errorf("checkpoint resolve path: %w", err)
+		}
+		cp.FilePath = absPath
+		cp.Size = int64(len(cp.OriginalData))
+		cp.spilled, cp.spillPath = false, ""
+		resolved[i] = cp
+	}
+
 	needsManifest := false
+	for _, cp := range resolved {
+		m.mu.Lock()
+		spilled, err := m.pushLocked(cp)
+		m.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		needsManifest = needsManifest || spilled
+	}
+	if needsManifest {
+		m.writeManifest() // best-effort manifest update; ignore error
+	}
+	return nil
+}
 
-	m.mu.Lock()
+// pushLocked adds cp to the top of the stack, spilling it or older
+// checkpoints to disk to stay within the memory budget. It reports whether
+// anything was spilled. The caller must hold m.mu.
+func (m *Manager) pushLocked(cp Checkpoint) (bool, error) {
+	spilled := false
 	// Spill large files directly to disk
-	if size > spillThreshold {
-		spillPath := filepath.Join(m.spillDir, id+".bak")
-		if err := os.WriteFile(spillPath, data, 0644); err != nil {
-			m.mu.Unlock()
-			return "", fmt.Errorf("checkpoint spill: %w", err)
+	if cp.Size > spillThreshold {
+		spillPath, err := m.spillPath(cp.ID)
+		if err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(spillPath, cp.OriginalData, 0644); err != nil {
+			return false, fmt.Errorf("checkpoint spill: %w", err)
 		}
 		cp.spilled = true
 		cp.spillPath = spillPath
 		cp.OriginalData = nil // don't hold in memory
-		needsManifest = true
+		spilled = true
 	} else {
 		// Check budget and evict if needed. Break if eviction fails
 		// (e.g., disk full) to avoid an infinite loop.
-		for m.memUsed+size > m.memBudget && len(m.stack) > 0 {
+		for m.memUsed+cp.Size > m.memBudget && len(m.stack) > 0 {
 			if !m.evictOldest() {
 				break // can't evict — accept over-budget rather than loop forever
 			}
-			needsManifest = true
+			spilled = true
 		}
-		m.memUsed += size
+		m.memUsed += cp.Size
 	}
 
 	m.stack = append(m.stack, cp)
-	m.mu.Unlock()
-
-	if needsManifest {
-		m.writeManifest() // best-effort manifest update; ignore error
-	}
+	return spilled, nil
+}
 
-	return id, nil
+// RootDir returns the directory checkpointed paths must stay inside, with
+// symlinks resolved.
+func (m *Manager) RootDir() string {
+	return m.rootDir
 }
 
 // resolvePath resolves a relative path to absolute under rootDir with symlink
@@ -267,8 +359,13 @@ func (m *Manager) restore(cp Checkpoint) error {
 // RewindToTurn reverts all checkpoints with turn > the given turn number,
 // in reverse order (newest first). Each checkpoint is restored individually;
 // intermediate checkpoints for the same file are applied in sequence, not skipped.
+//
+// When whole-tree snapshots are enabled and one was taken after turn, the
+// working tree is instead restored to that snapshot exactly — including files
+// created or deleted outside the file tool — and the superseded per-file
+// checkpoints are discarded.
 func (m *Manager) RewindToTurn(ctx context.Context, turn int) ([]string, error) {
-	paths, rewindErr := m.rewindLocked(turn)
+	paths, rewindErr := m.rewindLocked(ctx, turn)
 	// Persist manifest after rewind (writeManifest re-acquires m.mu internally).
 	// Do this even on rewind error — the stack was truncated to match filesystem reality.
 	if err := m.writeManifest(); err != nil {
@@ -279,7 +376,9 @@ func (m *Manager) RewindToTurn(ctx context.Context, turn int) ([]string, error)
 
 // rewindLocked performs the rewind under m.mu. Extracted to guarantee a single
 // unlock point (via defer) and avoid fragile manual unlock in multiple paths.
-func (m *Manager) rewindLocked(turn int) ([]string, error) {
+func (m *Manager) rewindLocked(ctx context.Context, turn int) ([]string, error) {
+	m.snapMu.Lock()
+	defer m.snapMu.Unlock()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -291,6 +390,14 @@ func (m *Manager) rewindLocked(turn int) ([]string, error) {
 		}
 	}
 
+	if paths, ok, err := m.rewindTreeLocked(ctx, turn); ok {
+		if err != nil {
+			return nil, err
+		}
+		m.dropLocked(cutoff)
+		return paths, nil
+	}
+
 	// Pop everything after cutoff in reverse
 	var paths []string
 	seen := make(map[string]bool)
@@ -316,12 +423,31 @@ func (m *Manager) rewindLocked(turn int) ([]string, error) {
 	return paths, nil
 }
 
-// Cleanup removes the spill directory and all checkpoint data.
+// dropLocked discards checkpoints after index cutoff without restoring them,
+// used when a tree snapshot has already put the files back. The caller must
+// hold m.mu.
+func (m *Manager) dropLocked(cutoff int) {
+	for _, cp := range m.stack[cutoff+1:] {
+		if cp.spilled {
+			os.Remove(cp.spillPath) // best-effort; the data is no longer needed
+		} else {
+			m.memUsed -= cp.Size
+		}
+	}
+	m.stack = m.stack[:cutoff+1]
+}
+
+// Cleanup removes the spill directory and all checkpoint data, including
+// the shadow snapshot store.
 func (m *Manager) Cleanup() error {
+	m.snapMu.Lock()
+	defer m.snapMu.Unlock()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.stack = nil
 	m.memUsed = 0
+	m.shadow = nil
+	m.snapshots = nil
 	return os.RemoveAll(m.spillDir)
 }
 
@@ -335,7 +461,10 @@ func (m *Manager) evictOldest() bool {
 			// to reclaim). Skip to the next candidate.
 			continue
 		}
-		spillPath := filepath.Join(m.spillDir, cp.ID+".bak")
+		spillPath, err := m.spillPath(cp.ID)
+		if err != nil {
+			continue
+		}
 		if err := os.WriteFile(spillPath, cp.OriginalData, 0644); err != nil {
 			continue
 		}
@@ -347,3 +476,12 @@ func (m *Manager) evictOldest() bool {
 	}
 	return false
 }
+
+// spillPath returns the file checkpoint id spills to, refusing IDs that
+// could name a path outside the spill directory.
+func (m *Manager) spillPath(id string) (string, error) {
+	if !ValidID(id) {
+		return "", fmt.Errorf("checkpoint spill: invalid id %q", id)
+	}
+	return filepath.Join(m.spillDir, id+".bak"), nil
+}
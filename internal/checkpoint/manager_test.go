@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/julianshen/rubichan/internal/checkpoint"
@@ -182,6 +183,68 @@ func TestCaptureSpillsLargeFile(t *testing.T) {
 	require.Len(t, cps, 1)
 	assert.True(t, cps[0].IsSpilled(), "file >1MB should be spilled to disk")
 	assert.Nil(t, cps[0].OriginalData, "spilled checkpoint should not hold data in memory")
+
+	got, err := mgr.Data(cps[0])
+	require.NoError(t, err)
+	assert.Equal(t, data, got, "Data reads a spilled checkpoint back from disk")
+}
+
+func TestDataInMemoryAndCreation(t *testing.T) {
+	rootDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "a.go"), []byte("package a"), 0644))
+
+	mgr, err := checkpoint.New(rootDir, "data-mem", 0)
+	require.NoError(t, err)
+	defer func() { _ = mgr.Cleanup() }()
+
+	_, err = mgr.Capture(context.Background(), "a.go", 1, "write")
+	require.NoError(t, err)
+	_, err = mgr.Capture(context.Background(), "new.go", 1, "write")
+	require.NoError(t, err)
+
+	cps := mgr.List()
+	require.Len(t, cps, 2)
+	got, err := mgr.Data(cps[0])
+	require.NoError(t, err)
+	assert.Equal(t, []byte("package a"), got)
+	got, err = mgr.Data(cps[1])
+	require.NoError(t, err)
+	assert.Nil(t, got, "creation checkpoints have no data")
+}
+
+func TestLoadRestoresEarlierCheckpoints(t *testing.T) {
+	rootDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "a.go"), []byte("changed"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "new.go"), []byte("created"), 0644))
+
+	mgr, err := checkpoint.New(rootDir, "load", 0)
+	require.NoError(t, err)
+	defer func() { _ = mgr.Cleanup() }()
+
+	err = mgr.Load([]checkpoint.Checkpoint{
+		{ID: "c1", FilePath: "a.go", Turn: 1, OriginalData: []byte("original"), FileMode: 0644},
+		{ID: "outside", FilePath: "../escape.go", Turn: 1},
+	})
+	require.Error(t, err)
+	assert.Empty(t, mgr.List(), "nothing loads when a path escapes the root")
+
+	require.NoError(t, mgr.Load([]checkpoint.Checkpoint{
+		{ID: "c1", FilePath: "a.go", Turn: 1, OriginalData: []byte("original"), FileMode: 0644},
+		{ID: "c2", FilePath: "new.go", Turn: 2},
+	}))
+	cps := mgr.List()
+	require.Len(t, cps, 2)
+	assert.Equal(t, "c1", cps[0].ID)
+	assert.True(t, filepath.IsAbs(cps[0].FilePath))
+
+	_, err = mgr.Undo(context.Background())
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(rootDir, "new.go"))
+	_, err = mgr.Undo(context.Background())
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(rootDir, "a.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data))
 }
 
 func TestCaptureBudgetEviction(t *testing.T) {
@@ -424,3 +487,31 @@ func TestRewindToTurnUpdatesManifest(t *testing.T) {
 	require.NoError(t, json.Unmarshal(manifestData, &mf))
 	assert.Empty(t, mf.Checkpoints, "manifest should have zero checkpoints after rewind to turn 0")
 }
+
+func TestLoadRejectsUnsafeIDs(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	rootDir := t.TempDir()
+
+	mgr, err := checkpoint.New(rootDir, "load-ids", 0)
+	require.NoError(t, err)
+	defer func() { _ = mgr.Cleanup() }()
+
+	// Large enough to be spilled to <TMPDIR>/aiagent/checkpoints/load-ids/<id>.bak.
+	big := make([]byte, 2*1024*1024)
+	err = mgr.Load([]checkpoint.Checkpoint{
+		{ID: "../../../escape", FilePath: "a.go", Turn: 1, OriginalData: big},
+	})
+	assert.ErrorContains(t, err, "invalid id")
+	assert.Empty(t, mgr.List())
+	assert.NoFileExists(t, filepath.Join(tmp, "escape.bak"))
+}
+
+func TestValidID(t *testing.T) {
+	for _, id := range []string{"c1", "2f1c9a4e-5b7d-4c3a-9e8f-0a1b2c3d4e5f", "snap_01"} {
+		assert.True(t, checkpoint.ValidID(id), id)
+	}
+	for _, id := range []string{"", "..", "../x", "a/b", `a\b`, "a.b", strings.Repeat("a", 65)} {
+		assert.False(t, checkpoint.ValidID(id), id)
+	}
+}
@@ -120,6 +120,10 @@ func RecoverSession(baseDir, sessionID string) ([]string, error) {
 			}
 		}
 
+		if !ValidID(entry.ID) {
+			restoreErrors = append(restoreErrors, fmt.Sprintf("invalid checkpoint id %q for %s", entry.ID, entry.FilePath))
+			continue
+		}
 		spillPath := filepath.Join(baseDir, sessionID, entry.ID+".bak")
 		content, err := os.ReadFile(spillPath)
 		if err != nil {
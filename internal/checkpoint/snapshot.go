@@ -0,0 +1,358 @@
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrSnapshotsDisabled is returned by tree-snapshot operations when
+// EnableSnapshots has not been called (or failed).
+var ErrSnapshotsDisabled = errors.New("working-tree snapshots are not enabled")
+
+// ErrSnapshotTooLarge is returned when the working tree exceeds the
+// configured SnapshotLimits. Snapshots are switched off when it occurs.
+var ErrSnapshotTooLarge = errors.New("working tree exceeds snapshot limits")
+
+// SnapshotLimits bounds the working trees whole-tree snapshots will copy
+// into the shadow store. Zero fields are unlimited.
+type SnapshotLimits struct {
+	MaxFiles int
+	MaxBytes int64
+}
+
+// TreeSnapshot records the state of the whole working tree at a turn
+// boundary, before the turn's tools ran. Tree is a git tree object ID in the
+// session's shadow object store.
+type TreeSnapshot struct {
+	Turn      int
+	Tree      string
+	Timestamp time.Time
+}
+
+// shadowRepo is a bare git object store under the session's spill directory
+// whose work tree is the project root. It never touches the project's own
+// .git: objects, index and config all live in gitDir. Snapshots are plain
+// tree objects (no commits or refs), so `git add -A` honours the project's
+// .gitignore files exactly as the project's own git would.
+type shadowRepo struct {
+	gitDir       string
+	workDir      string
+	excludesFile string // the project's info/exclude, honoured alongside .gitignore
+}
+
+func newShadowRepo(ctx context.Context, gitDir, workDir, excludesFile string) (*shadowRepo, error) {
+	r := &shadowRepo{gitDir: gitDir, workDir: workDir, excludesFile: excludesFile}
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err == nil {
+		return r, nil
+	}
+	// init refuses --work-tree together with --bare, so it runs unbound.
+	bare := &shadowRepo{gitDir: gitDir}
+	if _, err := bare.git(ctx, "init", "--quiet", "--bare", gitDir); err != nil {
+		return nil, err
+	}
+	// Snapshot trees are unreferenced objects; keep gc from pruning them.
+	if _, err := bare.git(ctx, "config", "gc.auto", "0"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// git runs a git command against the shadow store and returns its stdout.
+func (r *shadowRepo) git(ctx context.Context, args ...string) (string, error) {
+	full := []string{"--git-dir=" + r.gitDir}
+	if r.workDir != "" {
+		full = append(full, "--work-tree="+r.workDir)
+	}
+	full = append(full, "-c", "core.autocrlf=false", "-c", "core.safecrlf=false")
+	if r.excludesFile != "" {
+		full = append(full, "-c", "core.excludesFile="+r.excludesFile)
+	}
+	full = append(full, args...)
+	return runGit(ctx, r.workDir, full...)
+}
+
+// runGit runs git in dir and returns its stdout. GIT_* variables inherited
+// from the environment are dropped so a caller running inside a git hook
+// cannot redirect the command to another repository.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "GIT_") {
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// projectExcludesFile verifies that root lies inside a git work tree and
+// returns the path of that repository's info/exclude file. Snapshots are
+// limited to git work trees so that .gitignore keeps build output,
+// dependencies and other bulk out of the shadow store.
+func projectExcludesFile(ctx context.Context, root string) (string, error) {
+	out, err := runGit(ctx, root, "rev-parse", "--is-inside-work-tree", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("%s is not a git work tree", root)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || lines[0] != "true" {
+		return "", fmt.Errorf("%s is not a git work tree", root)
+	}
+	common := lines[1]
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(root, common)
+	}
+	return filepath.Join(common, "info", "exclude"), nil
+}
+
+// writeTree stages the entire work tree into the shadow index and returns
+// the resulting tree ID. Afterwards the shadow index matches that tree,
+// which restoreTree relies on.
+func (r *shadowRepo) writeTree(ctx context.Context) (string, error) {
+	if _, err := r.git(ctx, "add", "--all", "."); err != nil {
+		return "", err
+	}
+	out, err := r.git(ctx, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// checkLimits reports ErrSnapshotTooLarge when the files the next snapshot
+// would stage (everything tracked by the shadow index plus untracked,
+// non-ignored files) exceed limits. Listing and stat-ing is much cheaper
+// than hashing the tree into the object store.
+func (r *shadowRepo) checkLimits(ctx context.Context, limits SnapshotLimits) error {
+	if limits.MaxFiles <= 0 && limits.MaxBytes <= 0 {
+		return nil
+	}
+	out, err := r.git(ctx, "ls-files", "--cached", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return err
+	}
+	var files int
+	var size int64
+	for _, p := range strings.Split(out, "\x00") {
+		if p == "" {
+			continue
+		}
+		files++
+		if limits.MaxFiles > 0 && files > limits.MaxFiles {
+			return fmt.Errorf("%w: more than %d files", ErrSnapshotTooLarge, limits.MaxFiles)
+		}
+		if info, err := os.Lstat(filepath.Join(r.workDir, filepath.FromSlash(p))); err == nil {
+			size += info.Size()
+		}
+		if limits.MaxBytes > 0 && size > limits.MaxBytes {
+			return fmt.Errorf("%w: more than %d bytes", ErrSnapshotTooLarge, limits.MaxBytes)
+		}
+	}
+	return nil
+}
+
+// changedPaths lists paths (relative to the work tree) that differ between
+// two trees, including additions and deletions.
+func (r *shadowRepo) changedPaths(ctx context.Context, from, to string) ([]string, error) {
+	out, err := r.git(ctx, "diff-tree", "-r", "--no-renames", "--name-only", "-z", from, to)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// restoreTree makes the work tree match target exactly: changed files are
+// rewritten, files absent from target are deleted and files missing from the
+// work tree are recreated. Ignored files are left alone. It returns the
+// paths that changed.
+func (r *shadowRepo) restoreTree(ctx context.Context, target string) ([]string, error) {
+	current, err := r.writeTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := r.changedPaths(ctx, current, target)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	// The index now equals current, so a reset read-tree with -u removes
+	// entries missing from target and checks out everything that differs.
+	if _, err := r.git(ctx, "read-tree", "--reset", "-u", target); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// diff returns a stat summary followed by the unified patch between two trees.
+func (r *shadowRepo) diff(ctx context.Context, from, to string) (string, error) {
+	return r.git(ctx, "diff", "--no-color", "--no-ext-diff", "--stat", "--patch", from, to)
+}
+
+// EnableSnapshots turns on whole-tree snapshots backed by a shadow git object
+// store in the session's spill directory. Once enabled, SnapshotTurn records
+// the tree at each turn boundary and RewindToTurn restores exact tree state,
+// covering changes made by shell commands, generators and formatters that
+// never pass through Capture.
+//
+// Snapshots honour the project's .gitignore and info/exclude, so they are
+// only enabled inside a git work tree, and only while the tree stays within
+// limits. On error the manager keeps working with per-file checkpoints only.
+func (m *Manager) EnableSnapshots(ctx context.Context, limits SnapshotLimits) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("checkpoint snapshots: git not found: %w", err)
+	}
+	excludes, err := projectExcludesFile(ctx, m.rootDir)
+	if err != nil {
+		return fmt.Errorf("checkpoint snapshots: %w", err)
+	}
+	repo, err := newShadowRepo(ctx, filepath.Join(m.spillDir, "shadow.git"), m.rootDir, excludes)
+	if err != nil {
+		return fmt.Errorf("checkpoint snapshots: %w", err)
+	}
+	if err := repo.checkLimits(ctx, limits); err != nil {
+		return fmt.Errorf("checkpoint snapshots: %w", err)
+	}
+	m.snapMu.Lock()
+	defer m.snapMu.Unlock()
+	m.mu.Lock()
+	m.shadow = repo
+	m.limits = limits
+	m.mu.Unlock()
+	return nil
+}
+
+// SnapshotsEnabled reports whether whole-tree snapshots are active.
+func (m *Manager) SnapshotsEnabled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shadow != nil
+}
+
+// SnapshotTurn records the working tree as it stands at the start of turn.
+// A retried turn whose tree has not changed since its own snapshot is not
+// recorded twice. It is a no-op when snapshots are disabled. If the tree has
+// grown past the configured limits, snapshots are switched off and an error
+// wrapping ErrSnapshotTooLarge is returned.
+//
+// The tree is staged without holding the manager's main lock, so Capture,
+// Undo and List are not blocked behind git.
+func (m *Manager) SnapshotTurn(ctx context.Context, turn int) error {
+	m.snapMu.Lock()
+	defer m.snapMu.Unlock()
+	m.mu.Lock()
+	repo, limits := m.shadow, m.limits
+	m.mu.Unlock()
+	if repo == nil {
+		return nil
+	}
+	if err := repo.checkLimits(ctx, limits); err != nil {
+		if errors.Is(err, ErrSnapshotTooLarge) {
+			m.mu.Lock()
+			m.shadow = nil
+			m.snapshots = nil
+			m.mu.Unlock()
+		}
+		return fmt.Errorf("snapshot turn %d: %w", turn, err)
+	}
+	tree, err := repo.writeTree(ctx)
+	if err != nil {
+		return fmt.Errorf("snapshot turn %d: %w", turn, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n := len(m.snapshots); n > 0 && m.snapshots[n-1].Turn == turn && m.snapshots[n-1].Tree == tree {
+		return nil
+	}
+	m.snapshots = append(m.snapshots, TreeSnapshot{Turn: turn, Tree: tree, Timestamp: time.Now()})
+	return nil
+}
+
+// Snapshots returns a copy of the recorded tree snapshots (oldest first).
+func (m *Manager) Snapshots() []TreeSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]TreeSnapshot, len(m.snapshots))
+	copy(out, m.snapshots)
+	return out
+}
+
+// DiffTurn returns what changed in the working tree during the most recent
+// turn numbered turn: the diff from that turn's snapshot to the next one, or
+// to the current tree if it is the latest turn.
+func (m *Manager) DiffTurn(ctx context.Context, turn int) (string, error) {
+	m.snapMu.Lock()
+	defer m.snapMu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shadow == nil {
+		return "", ErrSnapshotsDisabled
+	}
+	idx := -1
+	for i, s := range m.snapshots {
+		if s.Turn == turn {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return "", fmt.Errorf("no snapshot for turn %d", turn)
+	}
+	var to string
+	if idx+1 < len(m.snapshots) {
+		to = m.snapshots[idx+1].Tree
+	} else {
+		var err error
+		if to, err = m.shadow.writeTree(ctx); err != nil {
+			return "", fmt.Errorf("diff turn %d: %w", turn, err)
+		}
+	}
+	return m.shadow.diff(ctx, m.snapshots[idx].Tree, to)
+}
+
+// rewindTreeLocked restores the tree recorded at the start of the first turn
+// after turn and drops the snapshots it supersedes. ok is false when there is
+// no such snapshot, in which case the caller falls back to per-file restore.
+// The caller must hold m.snapMu and m.mu.
+func (m *Manager) rewindTreeLocked(ctx context.Context, turn int) (paths []string, ok bool, err error) {
+	if m.shadow == nil {
+		return nil, false, nil
+	}
+	cutoff := -1
+	for i, s := range m.snapshots {
+		if s.Turn <= turn {
+			cutoff = i
+		}
+	}
+	if cutoff+1 >= len(m.snapshots) {
+		return nil, false, nil
+	}
+	rel, err := m.shadow.restoreTree(ctx, m.snapshots[cutoff+1].Tree)
+	if err != nil {
+		return nil, true, fmt.Errorf("rewind restore tree: %w", err)
+	}
+	m.snapshots = m.snapshots[:cutoff+1]
+	for _, p := range rel {
+		paths = append(paths, filepath.Join(m.rootDir, filepath.FromSlash(p)))
+	}
+	return paths, true, nil
+}
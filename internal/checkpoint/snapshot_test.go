@@ -0,0 +1,222 @@
+package checkpoint_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSnapshotManager(t *testing.T, sessionID string) (*checkpoint.Manager, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	rootDir := t.TempDir()
+	gitInit(t, rootDir)
+	mgr, err := checkpoint.New(rootDir, sessionID, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = mgr.Cleanup() })
+	require.NoError(t, mgr.EnableSnapshots(context.Background(), checkpoint.SnapshotLimits{}))
+	return mgr, rootDir
+}
+
+func gitInit(t *testing.T, dir string) {
+	t.Helper()
+	cmd := exec.Command("git", "init", "--quiet", dir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestRewindToTurnRestoresShellChanges(t *testing.T) {
+	ctx := context.Background()
+	mgr, rootDir := newSnapshotManager(t, "snap-rewind")
+
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "keep.go"), []byte("v1"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "doomed.go"), []byte("doomed"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 1))
+
+	// Turn 1 edits files without going through Capture, as a shell command would.
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "keep.go"), []byte("v2"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(rootDir, "doomed.go")))
+	require.NoError(t, os.MkdirAll(filepath.Join(rootDir, "gen"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "gen", "new.go"), []byte("generated"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 2))
+
+	paths, err := mgr.RewindToTurn(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, paths, 3)
+
+	data, err := os.ReadFile(filepath.Join(rootDir, "keep.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+	data, err = os.ReadFile(filepath.Join(rootDir, "doomed.go"))
+	require.NoError(t, err, "deleted file should be recreated")
+	assert.Equal(t, "doomed", string(data))
+	_, err = os.Stat(filepath.Join(rootDir, "gen", "new.go"))
+	assert.True(t, os.IsNotExist(err), "file created during the turn should be removed")
+
+	assert.Empty(t, mgr.Snapshots(), "superseded snapshots are dropped")
+}
+
+func TestRewindToTurnKeepsEarlierTurns(t *testing.T) {
+	ctx := context.Background()
+	mgr, rootDir := newSnapshotManager(t, "snap-partial")
+	file := filepath.Join(rootDir, "a.txt")
+
+	require.NoError(t, os.WriteFile(file, []byte("start"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 1))
+	require.NoError(t, os.WriteFile(file, []byte("after turn 1"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 2))
+	require.NoError(t, os.WriteFile(file, []byte("after turn 2"), 0644))
+
+	// No snapshot after turn 2 yet: fall back to per-file checkpoints (none).
+	paths, err := mgr.RewindToTurn(ctx, 2)
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+
+	paths, err = mgr.RewindToTurn(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, paths, 1)
+	data, _ := os.ReadFile(file)
+	assert.Equal(t, "after turn 1", string(data))
+	assert.Len(t, mgr.Snapshots(), 1)
+}
+
+func TestRewindToTurnRespectsGitignore(t *testing.T) {
+	ctx := context.Background()
+	mgr, rootDir := newSnapshotManager(t, "snap-ignore")
+
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, ".gitignore"), []byte("build/\n"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 1))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(rootDir, "build"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "build", "out.bin"), []byte("artifact"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 2))
+
+	paths, err := mgr.RewindToTurn(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+	_, err = os.Stat(filepath.Join(rootDir, "build", "out.bin"))
+	assert.NoError(t, err, "ignored files are not snapshotted or removed")
+}
+
+func TestSnapshotsHonourInfoExclude(t *testing.T) {
+	ctx := context.Background()
+	mgr, rootDir := newSnapshotManager(t, "snap-exclude")
+
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, ".git", "info", "exclude"), []byte("*.local\n"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 1))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "notes.local"), []byte("private"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 2))
+
+	paths, err := mgr.RewindToTurn(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+	_, err = os.Stat(filepath.Join(rootDir, "notes.local"))
+	assert.NoError(t, err, "files excluded via info/exclude are left alone")
+}
+
+func TestEnableSnapshotsRequiresGitWorkTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	mgr, err := checkpoint.New(t.TempDir(), "snap-nogit", 0)
+	require.NoError(t, err)
+	defer func() { _ = mgr.Cleanup() }()
+
+	err = mgr.EnableSnapshots(context.Background(), checkpoint.SnapshotLimits{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a git work tree")
+	assert.False(t, mgr.SnapshotsEnabled())
+}
+
+func TestSnapshotLimits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+	rootDir := t.TempDir()
+	gitInit(t, rootDir)
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "a.txt"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "b.txt"), []byte("b"), 0644))
+
+	mgr, err := checkpoint.New(rootDir, "snap-limits", 0)
+	require.NoError(t, err)
+	defer func() { _ = mgr.Cleanup() }()
+
+	err = mgr.EnableSnapshots(ctx, checkpoint.SnapshotLimits{MaxFiles: 1})
+	assert.ErrorIs(t, err, checkpoint.ErrSnapshotTooLarge)
+	assert.False(t, mgr.SnapshotsEnabled())
+
+	require.NoError(t, mgr.EnableSnapshots(ctx, checkpoint.SnapshotLimits{MaxFiles: 2, MaxBytes: 1024}))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 1))
+
+	// The tree grows past the limit mid-session: snapshots switch off.
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "c.txt"), []byte("c"), 0644))
+	err = mgr.SnapshotTurn(ctx, 2)
+	assert.ErrorIs(t, err, checkpoint.ErrSnapshotTooLarge)
+	assert.False(t, mgr.SnapshotsEnabled())
+	assert.Empty(t, mgr.Snapshots())
+}
+
+func TestRewindToTurnDropsSupersededFileCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	mgr, rootDir := newSnapshotManager(t, "snap-drop")
+	file := filepath.Join(rootDir, "a.go")
+
+	require.NoError(t, os.WriteFile(file, []byte("orig"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 1))
+	_, err := mgr.Capture(ctx, "a.go", 1, "write")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, []byte("edited"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 2))
+
+	_, err = mgr.RewindToTurn(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, mgr.List())
+	data, _ := os.ReadFile(file)
+	assert.Equal(t, "orig", string(data))
+}
+
+func TestDiffTurn(t *testing.T) {
+	ctx := context.Background()
+	mgr, rootDir := newSnapshotManager(t, "snap-diff")
+
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "a.go"), []byte("one\n"), 0644))
+	require.NoError(t, mgr.SnapshotTurn(ctx, 1))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "a.go"), []byte("two\n"), 0644))
+
+	// Latest turn diffs against the live tree.
+	diff, err := mgr.DiffTurn(ctx, 1)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-one")
+	assert.Contains(t, diff, "+two")
+
+	require.NoError(t, mgr.SnapshotTurn(ctx, 2))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "b.go"), []byte("new\n"), 0644))
+
+	diff, err = mgr.DiffTurn(ctx, 1)
+	require.NoError(t, err)
+	assert.NotContains(t, diff, "b.go", "turn 1 diff ends at turn 2's snapshot")
+
+	_, err = mgr.DiffTurn(ctx, 7)
+	assert.Error(t, err)
+}
+
+func TestSnapshotsDisabled(t *testing.T) {
+	mgr, err := checkpoint.New(t.TempDir(), "snap-disabled", 0)
+	require.NoError(t, err)
+	defer func() { _ = mgr.Cleanup() }()
+
+	assert.False(t, mgr.SnapshotsEnabled())
+	require.NoError(t, mgr.SnapshotTurn(context.Background(), 1))
+	assert.Empty(t, mgr.Snapshots())
+	_, err = mgr.DiffTurn(context.Background(), 1)
+	assert.ErrorIs(t, err, checkpoint.ErrSnapshotsDisabled)
+}
@@ -313,3 +313,33 @@ func (c *resumeCommand) Complete(_ context.Context, _ []string) []Candidate {
 func (c *resumeCommand) Execute(_ context.Context, _ []string) (Result, error) {
 	return Result{Action: ActionResume}, nil
 }
+
+// --- search ---
+
+type searchCommand struct{}
+
+// NewSearchCommand creates a command that requests the host to search the
+// messages of every stored session.
+func NewSearchCommand() SlashCommand {
+	return &searchCommand{}
+}
+
+func (c *searchCommand) Name() string        { return "search" }
+func (c *searchCommand) Description() string { return "Search past sessions" }
+func (c *searchCommand) Arguments() []ArgumentDef {
+	return []ArgumentDef{
+		{Name: "query", Description: "words to find in messages, tool calls and tool results", Required: true},
+	}
+}
+
+func (c *searchCommand) Complete(_ context.Context, _ []string) []Candidate {
+	return nil
+}
+
+func (c *searchCommand) Execute(_ context.Context, args []string) (Result, error) {
+	query := strings.TrimSpace(strings.Join(args, " "))
+	if query == "" {
+		return Result{}, fmt.Errorf("search query is required: /search <words>")
+	}
+	return Result{Action: ActionOpenSearch, Query: query}, nil
+}
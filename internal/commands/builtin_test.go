@@ -259,6 +259,19 @@ func TestResumeCommandExecute(t *testing.T) {
 	assert.Equal(t, ActionResume, result.Action)
 }
 
+func TestSearchCommandExecute(t *testing.T) {
+	cmd := NewSearchCommand()
+	assert.Equal(t, "search", cmd.Name())
+
+	result, err := cmd.Execute(context.Background(), []string{"flaky", "migration"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionOpenSearch, result.Action)
+	assert.Equal(t, "flaky migration", result.Query)
+
+	_, err = cmd.Execute(context.Background(), nil)
+	assert.Error(t, err)
+}
+
 func TestRalphLoopCommandExecute(t *testing.T) {
 	var got RalphLoopConfig
 	cmd := NewRalphLoopCommand(func(cfg RalphLoopConfig) error {
@@ -80,3 +80,87 @@ func (c *rewindCommand) Execute(ctx context.Context, args []string) (Result, err
 	}
 	return Result{Output: fmt.Sprintf("Reverted %d file(s):\n  - %s", len(paths), strings.Join(paths, "\n  - "))}, nil
 }
+
+// --- checkpoint ---
+
+type checkpointCommand struct {
+	mgr *checkpoint.Manager
+}
+
+// NewCheckpointCommand creates a command that inspects working-tree
+// snapshots: /checkpoint list shows the recorded turns and
+// /checkpoint diff N shows what changed during turn N.
+func NewCheckpointCommand(mgr *checkpoint.Manager) SlashCommand {
+	return &checkpointCommand{mgr: mgr}
+}
+
+func (c *checkpointCommand) Name() string        { return "checkpoint" }
+func (c *checkpointCommand) Description() string { return "Inspect working-tree snapshots" }
+func (c *checkpointCommand) Arguments() []ArgumentDef {
+	return []ArgumentDef{{Name: "subcommand", Description: "list | diff <turn>", Required: false}}
+}
+
+func (c *checkpointCommand) Complete(_ context.Context, args []string) []Candidate {
+	if len(args) == 0 {
+		return []Candidate{
+			{Value: "list", Description: "List turn snapshots"},
+			{Value: "diff", Description: "Show changes made during a turn"},
+		}
+	}
+	return nil
+}
+
+func (c *checkpointCommand) Execute(ctx context.Context, args []string) (Result, error) {
+	if c.mgr == nil {
+		return Result{Output: "Checkpoints not available."}, nil
+	}
+	sub := "list"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	switch sub {
+	case "list":
+		return c.executeList()
+	case "diff":
+		if len(args) < 2 {
+			return Result{}, fmt.Errorf("turn number is required: /checkpoint diff N")
+		}
+		turn, err := strconv.Atoi(args[1])
+		if err != nil {
+			return Result{}, fmt.Errorf("invalid turn number: %s", args[1])
+		}
+		return c.executeDiff(ctx, turn)
+	default:
+		return Result{}, fmt.Errorf("unknown subcommand %q: use list or diff", sub)
+	}
+}
+
+func (c *checkpointCommand) executeList() (Result, error) {
+	if !c.mgr.SnapshotsEnabled() {
+		return Result{Output: "Working-tree snapshots are disabled (turned off in config, no git work tree, or the tree is too large); only file-tool edits are checkpointed."}, nil
+	}
+	snaps := c.mgr.Snapshots()
+	if len(snaps) == 0 {
+		return Result{Output: "No snapshots yet."}, nil
+	}
+	var b strings.Builder
+	b.WriteString("Turn snapshots (oldest first):\n")
+	for _, s := range snaps {
+		fmt.Fprintf(&b, "  turn %d  %s  %s\n", s.Turn, s.Timestamp.Format("15:04:05"), s.Tree[:min(len(s.Tree), 12)])
+	}
+	return Result{Output: strings.TrimRight(b.String(), "\n")}, nil
+}
+
+func (c *checkpointCommand) executeDiff(ctx context.Context, turn int) (Result, error) {
+	diff, err := c.mgr.DiffTurn(ctx, turn)
+	if err != nil {
+		if errors.Is(err, checkpoint.ErrSnapshotsDisabled) {
+			return Result{Output: "Working-tree snapshots are disabled; only file-tool edits are checkpointed."}, nil
+		}
+		return Result{}, err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return Result{Output: fmt.Sprintf("No changes during turn %d.", turn)}, nil
+	}
+	return Result{Output: strings.TrimRight(diff, "\n")}, nil
+}
@@ -3,6 +3,7 @@ package commands_test
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
@@ -147,3 +148,52 @@ func TestRewindCommandNothingToRewind(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, result.Output, "No checkpoints")
 }
+
+func TestCheckpointCommandDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+	rootDir := t.TempDir()
+	out, err := exec.Command("git", "init", "--quiet", rootDir).CombinedOutput()
+	require.NoError(t, err, string(out))
+	mgr, _ := checkpoint.New(rootDir, "cmd-checkpoint-diff", 0)
+	defer func() { _ = mgr.Cleanup() }()
+	require.NoError(t, mgr.EnableSnapshots(ctx, checkpoint.SnapshotLimits{}))
+
+	require.NoError(t, mgr.SnapshotTurn(ctx, 1))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "gen.go"), []byte("package gen\n"), 0644))
+
+	cmd := commands.NewCheckpointCommand(mgr)
+	assert.Equal(t, "checkpoint", cmd.Name())
+
+	result, err := cmd.Execute(ctx, []string{"diff", "1"})
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "gen.go")
+	assert.Contains(t, result.Output, "+package gen")
+
+	result, err = cmd.Execute(ctx, []string{"list"})
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "turn 1")
+}
+
+func TestCheckpointCommandErrors(t *testing.T) {
+	mgr, _ := checkpoint.New(t.TempDir(), "cmd-checkpoint-errors", 0)
+	defer func() { _ = mgr.Cleanup() }()
+	cmd := commands.NewCheckpointCommand(mgr)
+
+	_, err := cmd.Execute(context.Background(), []string{"diff"})
+	assert.Error(t, err)
+	_, err = cmd.Execute(context.Background(), []string{"diff", "x"})
+	assert.Error(t, err)
+	_, err = cmd.Execute(context.Background(), []string{"bogus"})
+	assert.Error(t, err)
+
+	result, err := cmd.Execute(context.Background(), []string{"diff", "1"})
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "disabled")
+
+	result, err = commands.NewCheckpointCommand(nil).Execute(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "not available")
+}
@@ -30,6 +30,9 @@ const (
 	ActionResume
 	// ActionOpenModelPicker requests the host to open the model picker overlay.
 	ActionOpenModelPicker
+	// ActionOpenSearch requests the host to search stored sessions for
+	// Result.Query and show the matches.
+	ActionOpenSearch
 )
 
 // Candidate represents a completion suggestion.
@@ -50,6 +53,14 @@ type ArgumentDef struct {
 type Result struct {
 	Output string
 	Action Action
+	// Prompt, when non-empty, is sent to the agent as the next user turn.
+	Prompt string
+	// Model overrides the agent's model for the Prompt turn.
+	Model string
+	// AllowedTools limits the tools available to the Prompt turn.
+	AllowedTools []string
+	// Query is the search text for ActionOpenSearch.
+	Query string
 }
 
 // SlashCommand defines the interface for a user-invokable slash command.
@@ -0,0 +1,398 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// customShellTimeout bounds each !`command` a custom command inlines.
+	customShellTimeout = 30 * time.Second
+	// maxCustomInclude caps the size of a file inlined with @path.
+	maxCustomInclude = 256 * 1024
+)
+
+// customNameRe is the shape of a custom command name. Subdirectories become
+// colon-separated namespaces, so .agent/commands/git/pr.md is /git:pr.
+var customNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*(:[a-z0-9][a-z0-9_-]*)*$`)
+
+// customTokenRe finds everything a command body expands: !`command`,
+// @path (at the start of a word), $ARGUMENTS and $1..$9.
+var customTokenRe = regexp.MustCompile("!`([^`\n]+)`|(^|\\s)@([^\\s`]+)|\\$ARGUMENTS|\\$([1-9])")
+
+// CustomCommandOptions locates custom command definitions.
+type CustomCommandOptions struct {
+	// ProjectDir is the project root. Commands are read from
+	// <ProjectDir>/.agent/commands, and @path and !`command` resolve
+	// against it.
+	ProjectDir string
+	// UserDir holds the user's own commands, typically
+	// ~/.config/rubichan/commands. A project command shadows a user
+	// command of the same name.
+	UserDir string
+	// TrustProject allows project commands to run !`command` lines. A
+	// checked-in command file is code from whoever last edited the
+	// repository, so it gets the same gate as project hooks.
+	TrustProject bool
+}
+
+// customFrontmatter is the YAML header of a command file.
+type customFrontmatter struct {
+	Description  string     `yaml:"description"`
+	ArgumentHint string     `yaml:"argument-hint"`
+	AllowedTools stringList `yaml:"allowed-tools"`
+	Model        string     `yaml:"model"`
+}
+
+// stringList accepts either a YAML sequence or a comma-separated scalar.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var items []string
+		if err := node.Decode(&items); err != nil {
+			return err
+		}
+		*l = items
+	case yaml.ScalarNode:
+		var items []string
+		for _, item := range strings.Split(node.Value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*l = items
+	default:
+		return fmt.Errorf("expected a list or a comma-separated string")
+	}
+	return nil
+}
+
+// CustomCommand is a slash command defined by a markdown file. Executing it
+// expands the file body into a prompt the host sends to the agent.
+type CustomCommand struct {
+	name         string
+	description  string
+	argumentHint string
+	allowedTools []string
+	model        string
+	body         string
+	path         string
+	scope        string
+	workDir      string
+	allowShell   bool
+}
+
+// LoadCustomCommands reads the user and project command directories.
+// Missing directories are not an error. A file that cannot be parsed is
+// skipped and reported in the returned error, which may accompany a
+// non-empty result. Commands are sorted by name.
+func LoadCustomCommands(opts CustomCommandOptions) ([]*CustomCommand, error) {
+	byName := make(map[string]*CustomCommand)
+	var errs []error
+	load := func(dir, scope string, allowShell bool) {
+		if dir == "" {
+			return
+		}
+		cmds, err := loadCustomDir(dir, scope, opts.ProjectDir, allowShell)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, cmd := range cmds {
+			byName[cmd.name] = cmd
+		}
+	}
+	load(opts.UserDir, "user", true)
+	if opts.ProjectDir != "" {
+		load(filepath.Join(opts.ProjectDir, ".agent", "commands"), "project", opts.TrustProject)
+	}
+
+	out := make([]*CustomCommand, 0, len(byName))
+	for _, cmd := range byName {
+		out = append(out, cmd)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out, errors.Join(errs...)
+}
+
+func loadCustomDir(dir, scope, workDir string, allowShell bool) ([]*CustomCommand, error) {
+	var cmds []*CustomCommand
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := strings.ReplaceAll(strings.TrimSuffix(filepath.ToSlash(rel), ".md"), "/", ":")
+		cmd, err := parseCustomCommand(name, path)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		cmd.scope = scope
+		cmd.workDir = workDir
+		cmd.allowShell = allowShell
+		cmds = append(cmds, cmd)
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("reading %s: %w", dir, err))
+	}
+	return cmds, errors.Join(errs...)
+}
+
+func parseCustomCommand(name, path string) (*CustomCommand, error) {
+	if !customNameRe.MatchString(name) {
+		return nil, fmt.Errorf("%s: invalid command name %q", path, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	body, header := splitFrontmatter(strings.ReplaceAll(string(data), "\r\n", "\n"))
+	var fm customFrontmatter
+	if header != "" {
+		if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+			return nil, fmt.Errorf("%s: frontmatter: %w", path, err)
+		}
+	}
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, fmt.Errorf("%s: command body is empty", path)
+	}
+	if err := checkAllowedTools(fm.AllowedTools); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	description := strings.TrimSpace(fm.Description)
+	if description == "" {
+		description = firstLine(body)
+	}
+	return &CustomCommand{
+		name:         name,
+		description:  description,
+		argumentHint: strings.TrimSpace(fm.ArgumentHint),
+		allowedTools: fm.AllowedTools,
+		model:        strings.TrimSpace(fm.Model),
+		body:         body,
+		path:         path,
+	}, nil
+}
+
+// checkAllowedTools rejects allowed-tools qualifiers the agent cannot
+// enforce, so a command never gets more than it asked for: only shell
+// entries take one, as in "shell(git:*)" or "shell(go test)".
+func checkAllowedTools(tools []string) error {
+	for _, entry := range tools {
+		i := strings.IndexByte(entry, '(')
+		if i < 0 {
+			continue
+		}
+		name := strings.TrimSpace(entry[:i])
+		qualifier, closed := strings.CutSuffix(entry[i+1:], ")")
+		if !closed || name != "shell" || strings.TrimSpace(qualifier) == "" {
+			return fmt.Errorf("allowed-tools: unsupported entry %q: only shell takes a qualifier, as in shell(git:*)", entry)
+		}
+	}
+	return nil
+}
+
+// splitFrontmatter separates a "---" delimited YAML header from the body.
+func splitFrontmatter(content string) (body, frontmatter string) {
+	if !strings.HasPrefix(content, "---\n") {
+		return content, ""
+	}
+	rest := content[4:]
+	if idx := strings.Index(rest, "\n---\n"); idx >= 0 {
+		return rest[idx+5:], rest[:idx]
+	}
+	if strings.HasSuffix(rest, "\n---") {
+		return "", rest[:len(rest)-4]
+	}
+	return content, ""
+}
+
+// firstLine returns the first non-blank line of s, stripped of markdown
+// heading markers and shortened for display.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "# "))
+		if line == "" {
+			continue
+		}
+		if len(line) > 80 {
+			line = line[:77] + "..."
+		}
+		return line
+	}
+	return ""
+}
+
+func (c *CustomCommand) Name() string        { return c.name }
+func (c *CustomCommand) Description() string { return c.description }
+
+// Scope reports where the command was defined: "project" or "user".
+func (c *CustomCommand) Scope() string { return c.scope }
+
+// Path returns the file the command was loaded from.
+func (c *CustomCommand) Path() string { return c.path }
+
+// ArgumentHint returns the frontmatter's argument-hint, if any.
+func (c *CustomCommand) ArgumentHint() string { return c.argumentHint }
+
+func (c *CustomCommand) Arguments() []ArgumentDef {
+	if c.argumentHint == "" {
+		return nil
+	}
+	return []ArgumentDef{{Name: c.argumentHint, Description: "arguments passed to the command"}}
+}
+
+func (c *CustomCommand) Complete(_ context.Context, _ []string) []Candidate {
+	return nil
+}
+
+// Execute expands the command body and returns it as the prompt for the
+// next turn, along with the frontmatter's model and tool overrides.
+func (c *CustomCommand) Execute(ctx context.Context, args []string) (Result, error) {
+	prompt, err := c.Expand(ctx, args)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Prompt: prompt, Model: c.model, AllowedTools: c.allowedTools}, nil
+}
+
+// Expand renders the command body for args: $ARGUMENTS becomes the whole
+// argument list and $1..$9 the individual arguments, @path inlines a file
+// under the project root, and !`command` inlines the command's output.
+// Expansion is a single pass over the body, so text that arrives through an
+// argument, a file or a command's output is never expanded in turn. When
+// the body references no arguments, any given are appended to it.
+func (c *CustomCommand) Expand(ctx context.Context, args []string) (string, error) {
+	var b strings.Builder
+	usedArgs := false
+	last := 0
+	for _, m := range customTokenRe.FindAllStringSubmatchIndex(c.body, -1) {
+		b.WriteString(c.body[last:m[0]])
+		last = m[1]
+		token := c.body[m[0]:m[1]]
+		switch {
+		case m[2] >= 0: // !`command`
+			out, err := c.runShell(ctx, c.body[m[2]:m[3]])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(out)
+		case m[6] >= 0: // @path
+			b.WriteString(c.body[m[4]:m[5]])
+			// Punctuation ending a sentence ("see @README.md.") is not part
+			// of the path.
+			ref := strings.TrimRight(c.body[m[6]:m[7]], ".,;:!?)")
+			trailing := c.body[m[6]+len(ref) : m[7]]
+			included, err := c.includeFile(ref)
+			if err != nil {
+				return "", err
+			}
+			if included == "" {
+				b.WriteString("@" + ref)
+			} else {
+				b.WriteString(included)
+			}
+			b.WriteString(trailing)
+		case m[8] >= 0: // $1..$9
+			usedArgs = true
+			n, _ := strconv.Atoi(c.body[m[8]:m[9]])
+			if n <= len(args) {
+				b.WriteString(args[n-1])
+			}
+		case token == "$ARGUMENTS":
+			usedArgs = true
+			b.WriteString(strings.Join(args, " "))
+		}
+	}
+	b.WriteString(c.body[last:])
+	if !usedArgs && len(args) > 0 {
+		b.WriteString("\n\n" + strings.Join(args, " "))
+	}
+	return b.String(), nil
+}
+
+// includeFile renders the file ref names for inlining. It returns "" when
+// ref names no file, so an @mention that is not a path is left alone. The
+// file must stay under the project root once symlinks are resolved.
+func (c *CustomCommand) includeFile(ref string) (string, error) {
+	if ref == "" || c.workDir == "" {
+		return "", nil
+	}
+	root, err := filepath.Abs(c.workDir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(root, filepath.FromSlash(ref))
+	if filepath.IsAbs(ref) {
+		path = filepath.Clean(ref)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("/%s: @%s is outside the project", c.name, ref)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", nil
+	}
+	if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+		root = realRoot
+	}
+	if realRel, err := filepath.Rel(root, resolved); err != nil || realRel == ".." || strings.HasPrefix(realRel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("/%s: @%s is outside the project", c.name, ref)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil || info.IsDir() {
+		return "", nil
+	}
+	if info.Size() > maxCustomInclude {
+		return "", fmt.Errorf("/%s: @%s is larger than %d bytes", c.name, ref, maxCustomInclude)
+	}
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", fmt.Errorf("/%s: reading @%s: %w", c.name, ref, err)
+	}
+	return fmt.Sprintf("%s:\n```\n%s\n```", filepath.ToSlash(rel), strings.TrimRight(string(data), "\n")), nil
+}
+
+// runShell runs a !`command` line in the project root and returns its
+// trimmed combined output.
+func (c *CustomCommand) runShell(ctx context.Context, command string) (string, error) {
+	if !c.allowShell {
+		return "", fmt.Errorf("/%s runs shell commands, which project commands may only do when hooks.trust_project_hooks is set", c.name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, customShellTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = c.workDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("/%s: running %q: %w\n%s", c.name, command, err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,185 @@
+package commands_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/commands"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCommand(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestLoadCustomCommands(t *testing.T) {
+	project := t.TempDir()
+	user := t.TempDir()
+	cmdDir := filepath.Join(project, ".agent", "commands")
+
+	writeCommand(t, cmdDir, "release-notes.md", `---
+description: Draft release notes
+argument-hint: <version>
+allowed-tools: read_file, shell(git:*)
+model: claude-haiku-4-5
+---
+Write release notes for $1.
+`)
+	writeCommand(t, cmdDir, "git/pr.md", "# Open a pull request\nDo it.\n")
+	writeCommand(t, cmdDir, "notes.txt", "ignored")
+	writeCommand(t, user, "triage.md", "Triage from user dir\n")
+	writeCommand(t, user, "release-notes.md", "shadowed by the project\n")
+
+	cmds, err := commands.LoadCustomCommands(commands.CustomCommandOptions{ProjectDir: project, UserDir: user})
+	require.NoError(t, err)
+	require.Len(t, cmds, 3)
+
+	assert.Equal(t, "git:pr", cmds[0].Name())
+	assert.Equal(t, "Open a pull request", cmds[0].Description())
+
+	rn := cmds[1]
+	assert.Equal(t, "release-notes", rn.Name())
+	assert.Equal(t, "project", rn.Scope())
+	assert.Equal(t, "Draft release notes", rn.Description())
+	require.Len(t, rn.Arguments(), 1)
+	assert.Equal(t, "<version>", rn.Arguments()[0].Name)
+
+	result, err := rn.Execute(context.Background(), []string{"v1.2.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "Write release notes for v1.2.0.", result.Prompt)
+	assert.Equal(t, "claude-haiku-4-5", result.Model)
+	assert.Equal(t, []string{"read_file", "shell(git:*)"}, result.AllowedTools)
+
+	assert.Equal(t, "triage", cmds[2].Name())
+	assert.Equal(t, "user", cmds[2].Scope())
+}
+
+func TestLoadCustomCommandsMissingDirs(t *testing.T) {
+	cmds, err := commands.LoadCustomCommands(commands.CustomCommandOptions{
+		ProjectDir: t.TempDir(),
+		UserDir:    filepath.Join(t.TempDir(), "nope"),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, cmds)
+}
+
+func TestLoadCustomCommandsReportsBadFiles(t *testing.T) {
+	project := t.TempDir()
+	cmdDir := filepath.Join(project, ".agent", "commands")
+	writeCommand(t, cmdDir, "good.md", "fine\n")
+	writeCommand(t, cmdDir, "Bad Name.md", "body\n")
+	writeCommand(t, cmdDir, "empty.md", "---\ndescription: x\n---\n")
+	writeCommand(t, cmdDir, "broken.md", "---\ndescription: [\n---\nbody\n")
+	writeCommand(t, cmdDir, "wide.md", "---\nallowed-tools: file(read:*)\n---\nbody\n")
+
+	cmds, err := commands.LoadCustomCommands(commands.CustomCommandOptions{ProjectDir: project})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid command name")
+	assert.Contains(t, err.Error(), "body is empty")
+	assert.Contains(t, err.Error(), "frontmatter")
+	assert.Contains(t, err.Error(), `unsupported entry "file(read:*)"`)
+	require.Len(t, cmds, 1)
+	assert.Equal(t, "good", cmds[0].Name())
+}
+
+func loadOne(t *testing.T, opts commands.CustomCommandOptions, body string) *commands.CustomCommand {
+	t.Helper()
+	writeCommand(t, filepath.Join(opts.ProjectDir, ".agent", "commands"), "cmd.md", body)
+	cmds, err := commands.LoadCustomCommands(opts)
+	require.NoError(t, err)
+	require.Len(t, cmds, 1)
+	return cmds[0]
+}
+
+func TestCustomCommandArguments(t *testing.T) {
+	cmd := loadOne(t, commands.CustomCommandOptions{ProjectDir: t.TempDir()}, "All: $ARGUMENTS; first: $1; third: $3.")
+	out, err := cmd.Expand(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "All: a b; first: a; third: .", out)
+
+	plain := loadOne(t, commands.CustomCommandOptions{ProjectDir: t.TempDir()}, "Summarize the diff.")
+	out, err = plain.Expand(context.Background(), []string{"focus", "tests"})
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize the diff.\n\nfocus tests", out)
+}
+
+func TestCustomCommandIncludesFiles(t *testing.T) {
+	project := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(project, "CHANGELOG.md"), []byte("## v1\n"), 0o644))
+	cmd := loadOne(t, commands.CustomCommandOptions{ProjectDir: project}, "See @CHANGELOG.md, and ping @alice.")
+
+	out, err := cmd.Expand(context.Background(), []string{"$1 @CHANGELOG.md"})
+	require.NoError(t, err)
+	assert.Contains(t, out, "CHANGELOG.md:\n```\n## v1\n```")
+	assert.Contains(t, out, "ping @alice.")
+	// Arguments are appended verbatim, never expanded.
+	assert.Contains(t, out, "\n\n$1 @CHANGELOG.md")
+
+	escape := loadOne(t, commands.CustomCommandOptions{ProjectDir: t.TempDir()}, "Read @../secret.txt")
+	_, err = escape.Expand(context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside the project")
+}
+
+func TestCustomCommandIncludeKeepsTrailingPunctuation(t *testing.T) {
+	project := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(project, "README.md"), []byte("hello\n"), 0o644))
+	cmd := loadOne(t, commands.CustomCommandOptions{ProjectDir: project}, "see @README.md.")
+
+	out, err := cmd.Expand(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "see README.md:\n```\nhello\n```.", out)
+}
+
+func TestCustomCommandIncludeRejectsSymlinkEscape(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "id_rsa")
+	require.NoError(t, os.WriteFile(outside, []byte("PRIVATE KEY\n"), 0o600))
+	project := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(project, "key.txt")))
+	require.NoError(t, os.Symlink(filepath.Dir(outside), filepath.Join(project, "home")))
+
+	for _, ref := range []string{"key.txt", "home/id_rsa"} {
+		cmd := loadOne(t, commands.CustomCommandOptions{ProjectDir: project}, "Read @"+ref)
+		out, err := cmd.Expand(context.Background(), nil)
+		require.Error(t, err, ref)
+		assert.Contains(t, err.Error(), "outside the project")
+		assert.NotContains(t, out, "PRIVATE KEY")
+	}
+
+	// Symlinks that stay inside the project still work.
+	require.NoError(t, os.WriteFile(filepath.Join(project, "notes.md"), []byte("inside\n"), 0o644))
+	require.NoError(t, os.Symlink(filepath.Join(project, "notes.md"), filepath.Join(project, "link.md")))
+	cmd := loadOne(t, commands.CustomCommandOptions{ProjectDir: project}, "Read @link.md")
+	out, err := cmd.Expand(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Contains(t, out, "inside")
+}
+
+func TestCustomCommandShell(t *testing.T) {
+	body := "Branch: !`echo main`"
+
+	untrusted := loadOne(t, commands.CustomCommandOptions{ProjectDir: t.TempDir()}, body)
+	_, err := untrusted.Execute(context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "trust_project_hooks")
+
+	trusted := loadOne(t, commands.CustomCommandOptions{ProjectDir: t.TempDir(), TrustProject: true}, body)
+	result, err := trusted.Execute(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Branch: main", result.Prompt)
+
+	user := t.TempDir()
+	writeCommand(t, user, "who.md", body)
+	cmds, err := commands.LoadCustomCommands(commands.CustomCommandOptions{ProjectDir: t.TempDir(), UserDir: user})
+	require.NoError(t, err)
+	require.Len(t, cmds, 1)
+	result, err = cmds[0].Execute(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Branch: main", result.Prompt)
+}
@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TabInfo describes one open TUI tab for the /tab command.
+type TabInfo struct {
+	Name     string
+	Worktree string
+	Active   bool
+	Status   string // "working", "awaiting approval", "unread" or ""
+}
+
+// TabController manages the TUI's tabs without importing the tui package.
+type TabController interface {
+	ListTabs() []TabInfo
+	// OpenTab opens a new tab. A non-empty worktree runs it in that git
+	// worktree, created if needed.
+	OpenTab(name, worktree string) error
+	CloseTab() error
+	RenameTab(name string) error
+	SwitchTab(name string) error
+}
+
+type tabCommand struct {
+	tabs TabController
+}
+
+// NewTabCommand creates a command to open, close, rename, switch and list
+// the TUI's session tabs.
+func NewTabCommand(tabs TabController) SlashCommand {
+	return &tabCommand{tabs: tabs}
+}
+
+func (c *tabCommand) Name() string        { return "tab" }
+func (c *tabCommand) Description() string { return "Manage session tabs" }
+
+func (c *tabCommand) Arguments() []ArgumentDef {
+	return []ArgumentDef{
+		{
+			Name:        "subcommand",
+			Description: "list | new [name] [--worktree [branch]] | close | rename <name> | switch <name>",
+			Required:    false,
+		},
+	}
+}
+
+func (c *tabCommand) Complete(_ context.Context, args []string) []Candidate {
+	if len(args) == 0 {
+		return []Candidate{
+			{Value: "list", Description: "List open tabs"},
+			{Value: "new", Description: "Open a session in a new tab"},
+			{Value: "close", Description: "Close the current tab"},
+			{Value: "rename", Description: "Rename the current tab"},
+			{Value: "switch", Description: "Switch to a tab"},
+		}
+	}
+	if args[0] == "switch" {
+		var candidates []Candidate
+		for _, t := range c.tabs.ListTabs() {
+			if !t.Active {
+				candidates = append(candidates, Candidate{Value: t.Name, Description: t.Status})
+			}
+		}
+		return candidates
+	}
+	return nil
+}
+
+func (c *tabCommand) Execute(_ context.Context, args []string) (Result, error) {
+	sub := "list"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+
+	switch sub {
+	case "list":
+		return c.executeList(), nil
+	case "new":
+		name, worktree, err := parseTabNewArgs(args[1:])
+		if err != nil {
+			return Result{}, err
+		}
+		if err := c.tabs.OpenTab(name, worktree); err != nil {
+			return Result{}, err
+		}
+		return Result{Output: "Opening new tab..."}, nil
+	case "close":
+		return Result{}, c.tabs.CloseTab()
+	case "rename":
+		if len(args) < 2 {
+			return Result{}, fmt.Errorf("tab name is required: /tab rename <name>")
+		}
+		return Result{}, c.tabs.RenameTab(strings.Join(args[1:], " "))
+	case "switch":
+		if len(args) < 2 {
+			return Result{}, fmt.Errorf("tab name is required: /tab switch <name>")
+		}
+		return Result{}, c.tabs.SwitchTab(strings.Join(args[1:], " "))
+	default:
+		return Result{}, fmt.Errorf("unknown subcommand %q: use list, new, close, rename, or switch", sub)
+	}
+}
+
+// parseTabNewArgs parses "[name] [--worktree [branch]]". A bare
+// --worktree names the worktree after the tab.
+func parseTabNewArgs(args []string) (name, worktree string, err error) {
+	useWorktree := false
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == "--worktree":
+			useWorktree = true
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") && name != "" {
+				worktree = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(a, "--"):
+			return "", "", fmt.Errorf("unknown flag %q: /tab new [name] [--worktree [branch]]", a)
+		case name == "":
+			name = a
+		default:
+			return "", "", fmt.Errorf("unexpected argument %q: /tab new [name] [--worktree [branch]]", a)
+		}
+	}
+	if useWorktree && worktree == "" {
+		if name == "" {
+			return "", "", fmt.Errorf("--worktree needs a tab name or branch: /tab new <name> --worktree")
+		}
+		worktree = name
+	}
+	return name, worktree, nil
+}
+
+func (c *tabCommand) executeList() Result {
+	var b strings.Builder
+	b.WriteString("Tabs:\n")
+	for i, t := range c.tabs.ListTabs() {
+		marker := " "
+		if t.Active {
+			marker = "*"
+		}
+		fmt.Fprintf(&b, " %s%d %s", marker, i+1, t.Name)
+		if t.Worktree != "" {
+			fmt.Fprintf(&b, " (worktree %s)", t.Worktree)
+		}
+		if t.Status != "" {
+			fmt.Fprintf(&b, " — %s", t.Status)
+		}
+		b.WriteString("\n")
+	}
+	return Result{Output: b.String()}
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- mock TabController ---
+
+type mockTabController struct {
+	tabs         []TabInfo
+	openName     string
+	openWorktree string
+	closed       bool
+	renamed      string
+	switched     string
+	err          error
+}
+
+func (m *mockTabController) ListTabs() []TabInfo { return m.tabs }
+
+func (m *mockTabController) OpenTab(name, worktree string) error {
+	m.openName, m.openWorktree = name, worktree
+	return m.err
+}
+
+func (m *mockTabController) CloseTab() error {
+	m.closed = true
+	return m.err
+}
+
+func (m *mockTabController) RenameTab(name string) error {
+	m.renamed = name
+	return m.err
+}
+
+func (m *mockTabController) SwitchTab(name string) error {
+	m.switched = name
+	return m.err
+}
+
+// --- Tab Command Tests ---
+
+func TestTabCommandName(t *testing.T) {
+	cmd := NewTabCommand(&mockTabController{})
+	assert.Equal(t, "tab", cmd.Name())
+	assert.NotEmpty(t, cmd.Description())
+}
+
+func TestTabCommandListIsDefault(t *testing.T) {
+	ctrl := &mockTabController{tabs: []TabInfo{
+		{Name: "main", Active: true},
+		{Name: "fix", Worktree: "fix", Status: "awaiting approval"},
+	}}
+	cmd := NewTabCommand(ctrl)
+
+	result, err := cmd.Execute(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "*1 main")
+	assert.Contains(t, result.Output, " 2 fix (worktree fix) — awaiting approval")
+}
+
+func TestTabCommandNew(t *testing.T) {
+	tests := []struct {
+		args         []string
+		wantName     string
+		wantWorktree string
+	}{
+		{args: []string{"new"}},
+		{args: []string{"new", "docs"}, wantName: "docs"},
+		{args: []string{"new", "fix", "--worktree"}, wantName: "fix", wantWorktree: "fix"},
+		{args: []string{"new", "fix", "--worktree", "bugfix"}, wantName: "fix", wantWorktree: "bugfix"},
+	}
+	for _, tt := range tests {
+		ctrl := &mockTabController{}
+		_, err := NewTabCommand(ctrl).Execute(context.Background(), tt.args)
+		require.NoError(t, err, tt.args)
+		assert.Equal(t, tt.wantName, ctrl.openName, tt.args)
+		assert.Equal(t, tt.wantWorktree, ctrl.openWorktree, tt.args)
+	}
+}
+
+func TestTabCommandNewErrors(t *testing.T) {
+	cmd := NewTabCommand(&mockTabController{})
+	for _, args := range [][]string{
+		{"new", "--worktree"},
+		{"new", "a", "b"},
+		{"new", "--bogus"},
+	} {
+		_, err := cmd.Execute(context.Background(), args)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestTabCommandCloseRenameSwitch(t *testing.T) {
+	ctrl := &mockTabController{}
+	cmd := NewTabCommand(ctrl)
+
+	_, err := cmd.Execute(context.Background(), []string{"close"})
+	require.NoError(t, err)
+	assert.True(t, ctrl.closed)
+
+	_, err = cmd.Execute(context.Background(), []string{"rename", "api", "work"})
+	require.NoError(t, err)
+	assert.Equal(t, "api work", ctrl.renamed)
+
+	_, err = cmd.Execute(context.Background(), []string{"switch", "main"})
+	require.NoError(t, err)
+	assert.Equal(t, "main", ctrl.switched)
+
+	_, err = cmd.Execute(context.Background(), []string{"rename"})
+	assert.Error(t, err)
+	_, err = cmd.Execute(context.Background(), []string{"bogus"})
+	assert.Error(t, err)
+}
+
+func TestTabCommandPropagatesErrors(t *testing.T) {
+	cmd := NewTabCommand(&mockTabController{err: fmt.Errorf("this is the last tab")})
+	_, err := cmd.Execute(context.Background(), []string{"close"})
+	assert.EqualError(t, err, "this is the last tab")
+}
+
+func TestTabCommandCompleteSwitchOffersOtherTabs(t *testing.T) {
+	cmd := NewTabCommand(&mockTabController{tabs: []TabInfo{
+		{Name: "main", Active: true},
+		{Name: "docs", Status: "unread"},
+	}})
+	assert.Len(t, cmd.Complete(context.Background(), nil), 5)
+
+	candidates := cmd.Complete(context.Background(), []string{"switch"})
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "docs", candidates[0].Value)
+}
@@ -4,9 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/julianshen/rubichan/pkg/tools/sandbox"
 )
 
 // Config represents the top-level application configuration.
@@ -22,115 +23,178 @@ type Config struct {
 	Hooks       HooksConfig       `toml:"hooks"`
 	LSP         LSPConfig         `toml:"lsp"`
 	Sandbox     SandboxConfig     `toml:"sandbox"`
+	// Pricing overrides or extends the built-in per-model price table,
+	// keyed by model ID (or model ID prefix).
+	Pricing    map[string]ModelPricingConfig `toml:"pricing"`
+	Budget     BudgetConfig                  `toml:"budget"`
+	Checkpoint CheckpointConfig              `toml:"checkpoint"`
+	UI         UIConfig                      `toml:"ui"`
+	AskUser    AskUserConfig                 `toml:"ask_user"`
 }
 
-// LSPConfig holds settings for language server protocol integration.
-type LSPConfig struct {
-	Enabled     *bool `toml:"enabled"`      // nil = default true
-	AutoInstall *bool `toml:"auto_install"` // nil = default true
+// DefaultAskUserTimeout is how long the ask_user tool waits for an answer
+// when no timeout is configured.
+const DefaultAskUserTimeout = 5 * time.Minute
+
+// AskUserConfig holds settings for the ask_user tool.
+type AskUserConfig struct {
+	Timeout string `toml:"timeout"` // how long to wait for an answer, e.g. "2m" (default 5m)
+	Default string `toml:"default"` // answer used when a question times out without a default of its own
 }
 
-// IsEnabled returns whether LSP is enabled (default true).
-func (c LSPConfig) IsEnabled() bool {
-	if c.Enabled == nil {
-		return true
+// TimeoutDuration returns the configured answer timeout.
+func (c AskUserConfig) TimeoutDuration() time.Duration {
+	if d, err := time.ParseDuration(c.Timeout); err == nil && d > 0 {
+		return d
 	}
-	return *c.Enabled
+	return DefaultAskUserTimeout
 }
 
-// IsAutoInstall returns whether auto-install is enabled (default true).
-func (c LSPConfig) IsAutoInstall() bool {
-	if c.AutoInstall == nil {
-		return true
+// Validate checks that the timeout parses as a positive duration.
+func (c AskUserConfig) Validate() error {
+	if c.Timeout != "" {
+		if d, err := time.ParseDuration(c.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", c.Timeout)
+		}
 	}
-	return *c.AutoInstall
+	return nil
 }
 
-// SandboxConfig holds settings for command sandboxing.
-type SandboxConfig struct {
-	Enabled                  *bool                   `toml:"enabled"`
-	AllowUnsandboxedCommands *bool                   `toml:"allow_unsandboxed_commands"`
-	ExcludedCommands         []string                `toml:"excluded_commands"`
-	Network                  SandboxNetworkConfig    `toml:"network"`
-	Filesystem               SandboxFilesystemConfig `toml:"filesystem"`
-}
+// UIConfig holds settings for the interactive TUI.
+type UIConfig struct {
+	// Theme is a built-in theme name (rubichan, dark, light,
+	// high-contrast, colorblind), the name of a theme file in the themes
+	// directory next to config.toml, or a path to a theme file. Empty
+	// selects rubichan.
+	Theme string `toml:"theme"`
+	// ViMode starts the input area in vi-style normal/insert editing.
+	ViMode bool `toml:"vi_mode"`
+	// Keys remaps key bindings: action name to the keys that trigger it,
+	// e.g. submit = ["enter"]. Unlisted actions keep their defaults.
+	Keys map[string][]string `toml:"keys"`
+}
+
+// Default working-tree snapshot limits. A project above either limit keeps
+// per-file checkpoints only.
+const (
+	DefaultSnapshotMaxFiles = 20000
+	DefaultSnapshotMaxMB    = 200
+)
 
-// IsEnabled returns whether sandboxing is enabled (default false).
-func (c SandboxConfig) IsEnabled() bool {
-	if c.Enabled == nil {
-		return false
-	}
-	return *c.Enabled
+// CheckpointConfig holds settings for undo/rewind checkpoints.
+type CheckpointConfig struct {
+	Snapshots        *bool `toml:"snapshots"`          // whole-tree snapshots at turn boundaries; nil = default true
+	SnapshotMaxFiles int   `toml:"snapshot_max_files"` // 0 = DefaultSnapshotMaxFiles
+	SnapshotMaxMB    int   `toml:"snapshot_max_mb"`    // 0 = DefaultSnapshotMaxMB
 }
 
-// IsAllowUnsandboxedCommands returns whether unsandboxed commands are allowed (default true).
-func (c SandboxConfig) IsAllowUnsandboxedCommands() bool {
-	if c.AllowUnsandboxedCommands == nil {
+// IsSnapshotsEnabled returns whether whole-tree snapshots are enabled (default true).
+func (c CheckpointConfig) IsSnapshotsEnabled() bool {
+	if c.Snapshots == nil {
 		return true
 	}
-	return *c.AllowUnsandboxedCommands
+	return *c.Snapshots
 }
 
-// Validate checks that SandboxConfig fields are well-formed.
-func (c SandboxConfig) Validate() error {
-	for i, cmd := range c.ExcludedCommands {
-		if strings.Contains(cmd, "/") || strings.Contains(cmd, " ") {
-			return fmt.Errorf("excluded_commands[%d]: must not contain '/' or spaces", i)
-		}
+// MaxFiles returns the snapshot file-count limit.
+func (c CheckpointConfig) MaxFiles() int {
+	if c.SnapshotMaxFiles <= 0 {
+		return DefaultSnapshotMaxFiles
 	}
-	for i, domain := range c.Network.AllowedDomains {
-		if strings.Contains(domain, "://") || hasPort(domain) {
-			return fmt.Errorf("allowed_domains[%d]: must not contain scheme (://) or port (:port)", i)
-		}
+	return c.SnapshotMaxFiles
+}
+
+// MaxBytes returns the snapshot total-size limit in bytes.
+func (c CheckpointConfig) MaxBytes() int64 {
+	mb := c.SnapshotMaxMB
+	if mb <= 0 {
+		mb = DefaultSnapshotMaxMB
 	}
-	for i, p := range c.Filesystem.AllowWrite {
-		if !isValidFSPath(p) {
-			return fmt.Errorf("allow_write[%d]: must start with '/', '~/', or './'", i)
-		}
+	return int64(mb) * 1024 * 1024
+}
+
+// Validate checks that no limit is negative.
+func (c CheckpointConfig) Validate() error {
+	if c.SnapshotMaxFiles < 0 {
+		return fmt.Errorf("snapshot_max_files must not be negative")
 	}
-	for i, p := range c.Filesystem.DenyRead {
-		if !isValidFSPath(p) {
-			return fmt.Errorf("deny_read[%d]: must start with '/', '~/', or './'", i)
-		}
+	if c.SnapshotMaxMB < 0 {
+		return fmt.Errorf("snapshot_max_mb must not be negative")
 	}
 	return nil
 }
 
-// hasPort checks if a domain string ends with a port suffix like ":443".
-func hasPort(s string) bool {
-	idx := strings.LastIndex(s, ":")
-	if idx < 0 {
-		return false
+// ModelPricingConfig is the price of one model in US dollars per million
+// tokens. Zero cache and reasoning prices fall back to the input and output
+// prices respectively.
+type ModelPricingConfig struct {
+	Input      float64 `toml:"input"`
+	Output     float64 `toml:"output"`
+	CacheRead  float64 `toml:"cache_read"`
+	CacheWrite float64 `toml:"cache_write"`
+	Reasoning  float64 `toml:"reasoning"`
+}
+
+// BudgetConfig holds hard spending caps in US dollars. A zero cap disables
+// that check. When a cap is reached the agent stops before the next
+// provider call.
+type BudgetConfig struct {
+	SessionUSD        float64 `toml:"session_usd"`         // spend within one session
+	ProjectMonthlyUSD float64 `toml:"project_monthly_usd"` // spend for the working directory in the current calendar month
+}
+
+// Validate checks that no cap is negative.
+func (c BudgetConfig) Validate() error {
+	if c.SessionUSD < 0 {
+		return fmt.Errorf("session_usd must not be negative")
 	}
-	// Everything after the last colon must be digits (port number).
-	port := s[idx+1:]
-	if len(port) == 0 {
-		return false
+	if c.ProjectMonthlyUSD < 0 {
+		return fmt.Errorf("project_monthly_usd must not be negative")
 	}
-	for _, ch := range port {
-		if ch < '0' || ch > '9' {
-			return false
+	return nil
+}
+
+// validatePricing checks that every configured price is non-negative.
+func validatePricing(pricing map[string]ModelPricingConfig) error {
+	for model, p := range pricing {
+		if p.Input < 0 || p.Output < 0 || p.CacheRead < 0 || p.CacheWrite < 0 || p.Reasoning < 0 {
+			return fmt.Errorf("model %q: prices must not be negative", model)
 		}
 	}
-	return true
+	return nil
+}
+
+// LSPConfig holds settings for language server protocol integration.
+type LSPConfig struct {
+	Enabled     *bool `toml:"enabled"`      // nil = default true
+	AutoInstall *bool `toml:"auto_install"` // nil = default true
+}
+
+// IsEnabled returns whether LSP is enabled (default true).
+func (c LSPConfig) IsEnabled() bool {
+	if c.Enabled == nil {
+		return true
+	}
+	return *c.Enabled
 }
 
-// isValidFSPath checks that a filesystem path starts with "/", "~/", or "./".
-func isValidFSPath(p string) bool {
-	return strings.HasPrefix(p, "/") || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, "./")
+// IsAutoInstall returns whether auto-install is enabled (default true).
+func (c LSPConfig) IsAutoInstall() bool {
+	if c.AutoInstall == nil {
+		return true
+	}
+	return *c.AutoInstall
 }
 
+// SandboxConfig holds settings for command sandboxing. The canonical
+// definition lives in pkg/tools/sandbox so the public shell tool can take it.
+type SandboxConfig = sandbox.Config
+
 // SandboxNetworkConfig holds network sandbox settings.
-type SandboxNetworkConfig struct {
-	AllowedDomains []string `toml:"allowed_domains"`
-	ProxyPort      int      `toml:"proxy_port"`
-}
+type SandboxNetworkConfig = sandbox.NetworkConfig
 
 // SandboxFilesystemConfig holds filesystem sandbox settings.
-type SandboxFilesystemConfig struct {
-	AllowWrite []string `toml:"allow_write"`
-	DenyRead   []string `toml:"deny_read"`
-}
+type SandboxFilesystemConfig = sandbox.FilesystemConfig
 
 // HooksConfig holds settings for user-configured shell hooks.
 type HooksConfig struct {
@@ -196,6 +260,12 @@ type SecurityConfig struct {
 	EnableLLMAnalysis bool     `toml:"enable_llm_analysis"`
 	MaxLLMCalls       int      `toml:"max_llm_calls"`
 	ExcludePatterns   []string `toml:"exclude_patterns"`
+	// OSVDatabase is the offline OSV index built by `rubichan security db
+	// update`; empty means osv.db in the config directory.
+	OSVDatabase string `toml:"osv_database"`
+	// OSVOnline lets the dependency scanner query api.osv.dev for
+	// ecosystems the offline index does not hold.
+	OSVOnline bool `toml:"osv_online"`
 }
 
 // ProviderConfig holds settings for AI provider selection and configuration.
@@ -207,6 +277,44 @@ type ProviderConfig struct {
 	OpenAI       []OpenAICompatibleConfig `toml:"openai_compatible"`
 	Ollama       OllamaProviderConfig     `toml:"ollama"`
 	Zai          ZaiProviderConfig        `toml:"zai"`
+	Failover     FailoverConfig           `toml:"failover"`
+}
+
+// FailoverConfig declares an ordered chain of provider+model pairs tried
+// after the primary (Default + Model) fails with a provider-side error.
+// An empty Chain disables failover.
+type FailoverConfig struct {
+	Chain            []FailoverEntryConfig `toml:"chain"`
+	FailureThreshold int                   `toml:"failure_threshold"` // consecutive failures that open a provider's circuit (default 3)
+	Cooldown         string                `toml:"cooldown"`          // how long an open circuit skips the provider, e.g. "60s" (default 60s)
+}
+
+// FailoverEntryConfig is one secondary link in the failover chain.
+type FailoverEntryConfig struct {
+	Provider string `toml:"provider"` // provider ID, e.g. "openrouter" or "ollama"
+	Model    string `toml:"model"`
+}
+
+// Validate checks that every chain entry names a provider and model and
+// that the cooldown parses as a duration.
+func (c FailoverConfig) Validate() error {
+	for i, e := range c.Chain {
+		if e.Provider == "" {
+			return fmt.Errorf("failover chain entry %d: provider is required", i)
+		}
+		if e.Model == "" {
+			return fmt.Errorf("failover chain entry %d (%s): model is required", i, e.Provider)
+		}
+	}
+	if c.FailureThreshold < 0 {
+		return fmt.Errorf("failover: failure_threshold must not be negative")
+	}
+	if c.Cooldown != "" {
+		if d, err := time.ParseDuration(c.Cooldown); err != nil || d < 0 {
+			return fmt.Errorf("failover: invalid cooldown %q", c.Cooldown)
+		}
+	}
+	return nil
 }
 
 // AnthropicProviderConfig holds Anthropic-specific provider settings.
@@ -222,6 +330,10 @@ type OpenAICompatibleConfig struct {
 	APIKeySource string            `toml:"api_key_source"`
 	APIKey       string            `toml:"api_key"`
 	ExtraHeaders map[string]string `toml:"extra_headers"`
+	// StreamUsage asks the server for a final usage chunk so calls can be
+	// priced (nil = default true). Set false for servers that reject
+	// stream_options.
+	StreamUsage *bool `toml:"stream_usage"`
 }
 
 // OllamaProviderConfig holds Ollama-specific provider settings.
@@ -319,11 +431,12 @@ type MCPConfig struct {
 
 // MCPServerConfig describes a single MCP server connection.
 type MCPServerConfig struct {
-	Name      string   `toml:"name"`
-	Transport string   `toml:"transport"` // "stdio" or "sse"
-	Command   string   `toml:"command"`   // for stdio transport
-	Args      []string `toml:"args"`      // for stdio transport
-	URL       string   `toml:"url"`       // for sse transport
+	Name      string            `toml:"name"`
+	Transport string            `toml:"transport"` // "stdio", "http" (streamable HTTP) or "sse"
+	Command   string            `toml:"command"`   // for stdio transport
+	Args      []string          `toml:"args"`      // for stdio transport
+	URL       string            `toml:"url"`       // for http and sse transports
+	Headers   map[string]string `toml:"headers"`   // sent with every http request, e.g. Authorization
 }
 
 // Validate checks that the MCPServerConfig fields are consistent.
@@ -336,14 +449,14 @@ func (c MCPServerConfig) Validate() error {
 		if c.Command == "" {
 			return fmt.Errorf("mcp server %q: command is required for stdio transport", c.Name)
 		}
-	case "sse":
+	case "http", "sse":
 		if c.URL == "" {
-			return fmt.Errorf("mcp server %q: url is required for sse transport", c.Name)
+			return fmt.Errorf("mcp server %q: url is required for %s transport", c.Name, c.Transport)
 		}
 	case "":
-		return fmt.Errorf("mcp server %q: transport is required (stdio or sse)", c.Name)
+		return fmt.Errorf("mcp server %q: transport is required (stdio, http or sse)", c.Name)
 	default:
-		return fmt.Errorf("mcp server %q: unknown transport %q (must be stdio or sse)", c.Name, c.Transport)
+		return fmt.Errorf("mcp server %q: unknown transport %q (must be stdio, http or sse)", c.Name, c.Transport)
 	}
 	return nil
 }
@@ -376,6 +489,26 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("sandbox config: %w", err)
 	}
 
+	if err := cfg.Provider.Failover.Validate(); err != nil {
+		return nil, fmt.Errorf("provider config: %w", err)
+	}
+
+	if err := validatePricing(cfg.Pricing); err != nil {
+		return nil, fmt.Errorf("pricing config: %w", err)
+	}
+
+	if err := cfg.Budget.Validate(); err != nil {
+		return nil, fmt.Errorf("budget config: %w", err)
+	}
+
+	if err := cfg.Checkpoint.Validate(); err != nil {
+		return nil, fmt.Errorf("checkpoint config: %w", err)
+	}
+
+	if err := cfg.AskUser.Validate(); err != nil {
+		return nil, fmt.Errorf("ask_user config: %w", err)
+	}
+
 	return cfg, nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -90,6 +91,162 @@ extra_headers = { HTTP-Referer = "https://github.com/user/rubichan" }
 	assert.Equal(t, "https://github.com/user/rubichan", cfg.Provider.OpenAI[1].ExtraHeaders["HTTP-Referer"])
 }
 
+func TestLoadFailoverChain(t *testing.T) {
+	t.Parallel()
+
+	tomlContent := `
+[provider]
+default = "anthropic"
+model = "claude-sonnet-4-5"
+
+[provider.failover]
+failure_threshold = 2
+cooldown = "30s"
+
+[[provider.failover.chain]]
+provider = "openrouter"
+model = "anthropic/claude-sonnet-4.5"
+
+[[provider.failover.chain]]
+provider = "ollama"
+model = "qwen2.5-coder:32b"
+`
+	tmpFile := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tmpFile, []byte(tomlContent), 0644))
+
+	cfg, err := Load(tmpFile)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.Provider.Failover.FailureThreshold)
+	assert.Equal(t, "30s", cfg.Provider.Failover.Cooldown)
+	assert.Equal(t, []FailoverEntryConfig{
+		{Provider: "openrouter", Model: "anthropic/claude-sonnet-4.5"},
+		{Provider: "ollama", Model: "qwen2.5-coder:32b"},
+	}, cfg.Provider.Failover.Chain)
+}
+
+func TestFailoverConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     FailoverConfig
+		wantErr string
+	}{
+		{name: "empty", cfg: FailoverConfig{}},
+		{name: "valid", cfg: FailoverConfig{Chain: []FailoverEntryConfig{{Provider: "ollama", Model: "llama3"}}, Cooldown: "1m"}},
+		{name: "missing provider", cfg: FailoverConfig{Chain: []FailoverEntryConfig{{Model: "llama3"}}}, wantErr: "provider is required"},
+		{name: "missing model", cfg: FailoverConfig{Chain: []FailoverEntryConfig{{Provider: "ollama"}}}, wantErr: "model is required"},
+		{name: "bad cooldown", cfg: FailoverConfig{Cooldown: "soon"}, wantErr: "invalid cooldown"},
+		{name: "negative threshold", cfg: FailoverConfig{FailureThreshold: -1}, wantErr: "failure_threshold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoadPricingAndBudget(t *testing.T) {
+	t.Parallel()
+
+	tomlContent := `
+[pricing."claude-sonnet-4-5"]
+input = 3.0
+output = 15.0
+cache_read = 0.3
+cache_write = 3.75
+
+[pricing."o3"]
+input = 2.0
+output = 8.0
+reasoning = 8.0
+
+[budget]
+session_usd = 5.0
+project_monthly_usd = 200.0
+`
+	tmpFile := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tmpFile, []byte(tomlContent), 0644))
+
+	cfg, err := Load(tmpFile)
+	require.NoError(t, err)
+	assert.Equal(t, ModelPricingConfig{Input: 3.0, Output: 15.0, CacheRead: 0.3, CacheWrite: 3.75}, cfg.Pricing["claude-sonnet-4-5"])
+	assert.Equal(t, 8.0, cfg.Pricing["o3"].Reasoning)
+	assert.Equal(t, 5.0, cfg.Budget.SessionUSD)
+	assert.Equal(t, 200.0, cfg.Budget.ProjectMonthlyUSD)
+}
+
+func TestLoadRejectsNegativePricingAndBudget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"negative price", "[pricing.\"gpt-4o\"]\ninput = -1.0\n", "pricing config"},
+		{"negative session cap", "[budget]\nsession_usd = -1.0\n", "session_usd"},
+		{"negative project cap", "[budget]\nproject_monthly_usd = -0.5\n", "project_monthly_usd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := filepath.Join(t.TempDir(), "config.toml")
+			require.NoError(t, os.WriteFile(tmpFile, []byte(tt.toml), 0644))
+			_, err := Load(tmpFile)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCheckpointConfig(t *testing.T) {
+	t.Parallel()
+
+	var zero CheckpointConfig
+	assert.True(t, zero.IsSnapshotsEnabled())
+	assert.Equal(t, DefaultSnapshotMaxFiles, zero.MaxFiles())
+	assert.Equal(t, int64(DefaultSnapshotMaxMB)*1024*1024, zero.MaxBytes())
+
+	tmpFile := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tmpFile, []byte("[checkpoint]\nsnapshots = false\nsnapshot_max_files = 500\nsnapshot_max_mb = 10\n"), 0644))
+	cfg, err := Load(tmpFile)
+	require.NoError(t, err)
+	assert.False(t, cfg.Checkpoint.IsSnapshotsEnabled())
+	assert.Equal(t, 500, cfg.Checkpoint.MaxFiles())
+	assert.Equal(t, int64(10*1024*1024), cfg.Checkpoint.MaxBytes())
+
+	require.NoError(t, os.WriteFile(tmpFile, []byte("[checkpoint]\nsnapshot_max_files = -1\n"), 0644))
+	_, err = Load(tmpFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "snapshot_max_files")
+}
+
+func TestAskUserConfig(t *testing.T) {
+	t.Parallel()
+
+	var zero AskUserConfig
+	assert.Equal(t, DefaultAskUserTimeout, zero.TimeoutDuration())
+
+	tmpFile := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tmpFile, []byte("[ask_user]\ntimeout = \"90s\"\ndefault = \"proceed\"\n"), 0644))
+	cfg, err := Load(tmpFile)
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, cfg.AskUser.TimeoutDuration())
+	assert.Equal(t, "proceed", cfg.AskUser.Default)
+
+	require.NoError(t, os.WriteFile(tmpFile, []byte("[ask_user]\ntimeout = \"soon\"\n"), 0644))
+	_, err = Load(tmpFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ask_user")
+}
+
 func TestLoadMissingFileReturnsDefaults(t *testing.T) {
 	t.Parallel()
 
@@ -265,6 +422,15 @@ func TestMCPServerConfigValidate(t *testing.T) {
 			name: "valid sse",
 			cfg:  MCPServerConfig{Name: "test", Transport: "sse", URL: "http://localhost:3001/sse"},
 		},
+		{
+			name:    "http missing url",
+			cfg:     MCPServerConfig{Name: "test", Transport: "http"},
+			wantErr: "url is required for http transport",
+		},
+		{
+			name: "valid http",
+			cfg:  MCPServerConfig{Name: "test", Transport: "http", URL: "https://example.com/mcp", Headers: map[string]string{"Authorization": "Bearer t"}},
+		},
 	}
 
 	for _, tt := range tests {
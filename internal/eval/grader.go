@@ -0,0 +1,232 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultCommandTimeout bounds a command grader that sets no timeout of
+// its own.
+const defaultCommandTimeout = 5 * time.Minute
+
+// maxDetail caps how much grader output is kept in a report.
+const maxDetail = 2000
+
+// Completer is the LLM used by llm graders.
+type Completer interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// Grade is the outcome of one grader.
+type Grade struct {
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	Weight float64 `json:"weight"`
+	Pass   bool    `json:"pass"`
+	Score  float64 `json:"score"` // 0 to 1
+	Detail string  `json:"detail,omitempty"`
+}
+
+// gradeInput is what graders look at once the agent has finished.
+type gradeInput struct {
+	Task   Task
+	Dir    string // task directory inside the worktree
+	Answer string
+	Diff   string
+	Judge  Completer
+}
+
+// grade runs one grader. Grader failures — a command that cannot start, a
+// judge that does not answer — count as a failed grade rather than an
+// error, so one broken grader does not abort the suite.
+func grade(ctx context.Context, spec GraderSpec, in gradeInput) Grade {
+	g := Grade{Name: spec.Name, Type: spec.Type, Weight: spec.Weight}
+	var err error
+	switch spec.Type {
+	case GraderCommand:
+		g.Pass, g.Detail, err = gradeCommand(ctx, spec, in.Dir)
+	case GraderFile:
+		g.Pass, g.Detail, err = gradeFile(spec, in.Dir)
+	case GraderRegex:
+		g.Pass, g.Detail, err = gradeRegex(spec, in.Answer)
+	case GraderLLM:
+		g.Pass, g.Score, g.Detail, err = gradeLLM(ctx, spec, in)
+	default:
+		err = fmt.Errorf("unknown grader type %q", spec.Type)
+	}
+	if err != nil {
+		return Grade{Name: g.Name, Type: g.Type, Weight: g.Weight, Detail: "error: " + err.Error()}
+	}
+	if spec.Type != GraderLLM && g.Pass {
+		g.Score = 1
+	}
+	return g
+}
+
+func gradeCommand(ctx context.Context, spec GraderSpec, dir string) (bool, string, error) {
+	timeout := time.Duration(spec.Timeout)
+	if timeout == 0 {
+		timeout = defaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", spec.Run)
+	cmd.Dir = dir
+	// Children of the shell can hold its output open after it is killed.
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return true, "", nil
+	}
+	detail := tail(string(out), maxDetail)
+	if ctx.Err() == context.DeadlineExceeded {
+		return false, fmt.Sprintf("timed out after %s\n%s", timeout, detail), nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return false, fmt.Sprintf("exit status %d\n%s", exitErr.ExitCode(), detail), nil
+	}
+	return false, "", err
+}
+
+func gradeFile(spec GraderSpec, dir string) (bool, string, error) {
+	path := filepath.Join(dir, filepath.Clean(spec.Path))
+	if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+		return false, "", fmt.Errorf("path %q escapes the task directory", spec.Path)
+	}
+	wantExists := spec.Exists == nil || *spec.Exists
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if wantExists {
+			return false, spec.Path + " does not exist", nil
+		}
+		return true, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	if !wantExists {
+		return false, spec.Path + " exists", nil
+	}
+	if spec.Contains != "" && !bytes.Contains(data, []byte(spec.Contains)) {
+		return false, fmt.Sprintf("%s does not contain %q", spec.Path, spec.Contains), nil
+	}
+	if spec.Matches != "" {
+		re, err := regexp.Compile(spec.Matches)
+		if err != nil {
+			return false, "", err
+		}
+		if !re.Match(data) {
+			return false, fmt.Sprintf("%s does not match %s", spec.Path, spec.Matches), nil
+		}
+	}
+	return true, "", nil
+}
+
+func gradeRegex(spec GraderSpec, answer string) (bool, string, error) {
+	re, err := regexp.Compile(spec.Pattern)
+	if err != nil {
+		return false, "", err
+	}
+	if re.MatchString(answer) {
+		return true, "", nil
+	}
+	return false, "answer does not match " + spec.Pattern, nil
+}
+
+const judgePrompt = `You are grading the work of a coding agent.
+
+## Task given to the agent
+%s
+
+## Rubric
+%s
+
+## Agent's final answer
+%s
+
+## Changes the agent made (git diff)
+%s
+
+Grade the work against the rubric only. Reply with a single JSON object and
+nothing else:
+{"pass": true or false, "score": a number from 0 to 1, "reason": "one or two sentences"}`
+
+// maxJudgeDiff keeps the judge prompt within a reasonable context size.
+const maxJudgeDiff = 40000
+
+func gradeLLM(ctx context.Context, spec GraderSpec, in gradeInput) (bool, float64, string, error) {
+	if in.Judge == nil {
+		return false, 0, "", fmt.Errorf("no judge model configured")
+	}
+	diff := in.Diff
+	if diff == "" {
+		diff = "(no changes)"
+	} else if len(diff) > maxJudgeDiff {
+		diff = diff[:maxJudgeDiff] + "\n... (diff truncated)"
+	}
+	prompt := fmt.Sprintf(judgePrompt, in.Task.Prompt, spec.Rubric, in.Answer, diff)
+	resp, err := in.Judge.Complete(ctx, prompt)
+	if err != nil {
+		return false, 0, "", fmt.Errorf("judge: %w", err)
+	}
+	v, err := parseVerdict(resp)
+	if err != nil {
+		return false, 0, "", err
+	}
+	return v.Pass, v.Score, v.Reason, nil
+}
+
+// verdict is the judge's answer.
+type verdict struct {
+	Pass   bool
+	Score  float64
+	Reason string
+}
+
+// parseVerdict extracts the judge's JSON verdict, tolerating surrounding
+// prose and code fences. A missing score follows pass.
+func parseVerdict(resp string) (verdict, error) {
+	start := strings.Index(resp, "{")
+	end := strings.LastIndex(resp, "}")
+	if start < 0 || end < start {
+		return verdict{}, fmt.Errorf("judge returned no JSON verdict: %s", tail(resp, 200))
+	}
+	var v struct {
+		Pass   bool     `json:"pass"`
+		Score  *float64 `json:"score"`
+		Reason string   `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(resp[start:end+1]), &v); err != nil {
+		return verdict{}, fmt.Errorf("parsing judge verdict: %w", err)
+	}
+	out := verdict{Pass: v.Pass, Reason: v.Reason}
+	switch {
+	case v.Score != nil:
+		out.Score = min(max(*v.Score, 0), 1)
+	case v.Pass:
+		out.Score = 1
+	}
+	return out, nil
+}
+
+// tail returns at most the last n bytes of s, where test runners put the
+// failures.
+func tail(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
@@ -0,0 +1,123 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubJudge struct {
+	reply  string
+	err    error
+	prompt string
+}
+
+func (j *stubJudge) Complete(_ context.Context, prompt string) (string, error) {
+	j.prompt = prompt
+	return j.reply, j.err
+}
+
+func spec(t *testing.T, g GraderSpec) GraderSpec {
+	t.Helper()
+	require.NoError(t, g.normalize())
+	return g
+}
+
+func TestCommandGrader(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	g := grade(ctx, spec(t, GraderSpec{Type: GraderCommand, Run: "test -f marker"}), gradeInput{Dir: dir})
+	assert.False(t, g.Pass)
+	assert.Contains(t, g.Detail, "exit status 1")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "marker"), nil, 0o644))
+	g = grade(ctx, spec(t, GraderSpec{Type: GraderCommand, Run: "test -f marker"}), gradeInput{Dir: dir})
+	assert.True(t, g.Pass)
+	assert.Equal(t, 1.0, g.Score)
+}
+
+func TestCommandGraderTimeout(t *testing.T) {
+	g := grade(context.Background(),
+		spec(t, GraderSpec{Type: GraderCommand, Run: "sleep 5", Timeout: Duration(50 * time.Millisecond)}),
+		gradeInput{Dir: t.TempDir()})
+	assert.False(t, g.Pass)
+	assert.Contains(t, g.Detail, "timed out")
+}
+
+func TestFileGrader(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "calc.go"), []byte("func Add(a, b int) int { return a + b }\n"), 0o644))
+	no := false
+	cases := []struct {
+		name string
+		g    GraderSpec
+		pass bool
+	}{
+		{"exists", GraderSpec{Path: "calc.go"}, true},
+		{"missing", GraderSpec{Path: "nope.go"}, false},
+		{"contains", GraderSpec{Path: "calc.go", Contains: "a + b"}, true},
+		{"does not contain", GraderSpec{Path: "calc.go", Contains: "a - b"}, false},
+		{"matches", GraderSpec{Path: "calc.go", Matches: `return\s+a\s*\+\s*b`}, true},
+		{"must not exist", GraderSpec{Path: "nope.go", Exists: &no}, true},
+		{"exists but should not", GraderSpec{Path: "calc.go", Exists: &no}, false},
+		{"escapes", GraderSpec{Path: "../secret"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.g.Type = GraderFile
+			g := grade(context.Background(), spec(t, tc.g), gradeInput{Dir: dir})
+			assert.Equal(t, tc.pass, g.Pass, g.Detail)
+		})
+	}
+}
+
+func TestRegexGrader(t *testing.T) {
+	s := spec(t, GraderSpec{Type: GraderRegex, Pattern: `(?i)off.by.one`})
+	assert.True(t, grade(context.Background(), s, gradeInput{Answer: "It was an Off-by-one error."}).Pass)
+	assert.False(t, grade(context.Background(), s, gradeInput{Answer: "Fixed it."}).Pass)
+}
+
+func TestLLMGrader(t *testing.T) {
+	s := spec(t, GraderSpec{Type: GraderLLM, Rubric: "Names the bug."})
+	in := gradeInput{Task: Task{Prompt: "Fix add."}, Answer: "Changed - to +.", Diff: "-a - b\n+a + b"}
+
+	judge := &stubJudge{reply: "Sure.\n```json\n{\"pass\": true, \"score\": 0.8, \"reason\": \"Clear.\"}\n```"}
+	in.Judge = judge
+	g := grade(context.Background(), s, in)
+	assert.True(t, g.Pass)
+	assert.Equal(t, 0.8, g.Score)
+	assert.Equal(t, "Clear.", g.Detail)
+	assert.Contains(t, judge.prompt, "Names the bug.")
+	assert.Contains(t, judge.prompt, "+a + b")
+
+	in.Judge = &stubJudge{reply: `{"pass": true}`}
+	assert.Equal(t, 1.0, grade(context.Background(), s, in).Score, "a missing score follows pass")
+
+	in.Judge = &stubJudge{reply: "I cannot decide."}
+	g = grade(context.Background(), s, in)
+	assert.False(t, g.Pass)
+	assert.Contains(t, g.Detail, "no JSON verdict")
+
+	in.Judge = &stubJudge{err: errors.New("rate limited")}
+	assert.Contains(t, grade(context.Background(), s, in).Detail, "rate limited")
+
+	in.Judge = nil
+	assert.Contains(t, grade(context.Background(), s, in).Detail, "no judge model")
+}
+
+func TestScoreIsWeighted(t *testing.T) {
+	got, passed := score([]Grade{{Pass: true, Score: 1, Weight: 3}, {Pass: false, Score: 0, Weight: 1}})
+	assert.Equal(t, 0.75, got)
+	assert.False(t, passed)
+
+	got, passed = score([]Grade{{Pass: true, Score: 1, Weight: 1}, {Pass: true, Score: 0.5, Weight: 1}})
+	assert.Equal(t, 0.75, got)
+	assert.True(t, passed)
+}
@@ -0,0 +1,253 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the scored result of one suite run.
+type Report struct {
+	Suite      string       `json:"suite"`
+	Label      string       `json:"label,omitempty"`
+	Model      string       `json:"model,omitempty"`
+	Provider   string       `json:"provider,omitempty"`
+	StartedAt  time.Time    `json:"started_at"`
+	DurationMs int64        `json:"duration_ms"`
+	Score      float64      `json:"score"` // mean task score, 0 to 1
+	Passed     int          `json:"passed"`
+	Total      int          `json:"total"`
+	Tasks      []TaskResult `json:"tasks"`
+}
+
+func (r *Report) tally() {
+	r.Total = len(r.Tasks)
+	r.Passed = 0
+	var sum float64
+	for _, t := range r.Tasks {
+		sum += t.Score
+		if t.Passed {
+			r.Passed++
+		}
+	}
+	r.Score = 0
+	if r.Total > 0 {
+		r.Score = sum / float64(r.Total)
+	}
+}
+
+// name identifies a report in comparison tables.
+func (r *Report) name() string {
+	ts := r.StartedAt.Local().Format("2006-01-02 15:04")
+	if r.Label != "" {
+		return r.Label + " (" + ts + ")"
+	}
+	return ts
+}
+
+// fileStem is the base name report files are written under.
+func (r *Report) fileStem() string {
+	return r.Suite + "-" + r.StartedAt.UTC().Format("20060102-150405")
+}
+
+// LoadReport reads a JSON report.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading report: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing report %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// LoadHistory returns the reports of suite found in dir, oldest first.
+// Unreadable files are skipped.
+func LoadHistory(dir, suite string) ([]*Report, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var out []*Report
+	for _, p := range paths {
+		r, err := LoadReport(p)
+		if err != nil || r.Suite != suite {
+			continue
+		}
+		out = append(out, r)
+	}
+	sortReports(out)
+	return out, nil
+}
+
+func sortReports(rs []*Report) {
+	sort.SliceStable(rs, func(i, j int) bool { return rs[i].StartedAt.Before(rs[j].StartedAt) })
+}
+
+// Write saves r to dir as JSON and markdown and returns both paths. The
+// markdown compares r with the earlier runs in history.
+func (r *Report) Write(dir string, history []*Report) (jsonPath, mdPath string, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("creating report directory: %w", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", "", fmt.Errorf("encoding report: %w", err)
+	}
+	jsonPath = filepath.Join(dir, r.fileStem()+".json")
+	if err := os.WriteFile(jsonPath, append(data, '\n'), 0o644); err != nil {
+		return "", "", fmt.Errorf("writing report: %w", err)
+	}
+	mdPath = filepath.Join(dir, r.fileStem()+".md")
+	if err := os.WriteFile(mdPath, []byte(r.Markdown(history)), 0o644); err != nil {
+		return "", "", fmt.Errorf("writing report: %w", err)
+	}
+	return jsonPath, mdPath, nil
+}
+
+// Markdown renders r, followed by a comparison with history when there is
+// any.
+func (r *Report) Markdown(history []*Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Eval: %s\n\n", r.Suite)
+	if r.Label != "" {
+		fmt.Fprintf(&b, "- Label: %s\n", r.Label)
+	}
+	if r.Provider != "" || r.Model != "" {
+		fmt.Fprintf(&b, "- Model: %s\n", strings.Trim(r.Provider+"/"+r.Model, "/"))
+	}
+	fmt.Fprintf(&b, "- Started: %s\n", r.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Duration: %s\n", formatMs(r.DurationMs))
+	fmt.Fprintf(&b, "- Score: **%s** (%d/%d tasks passed)\n\n", pct(r.Score), r.Passed, r.Total)
+
+	b.WriteString("| Task | Result | Score | Turns | Tool calls | Duration |\n")
+	b.WriteString("|------|--------|-------|-------|------------|----------|\n")
+	for _, t := range r.Tasks {
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %d | %s |\n",
+			t.ID, result(t), pct(t.Score), t.Turns, t.ToolCalls, formatMs(t.DurationMs))
+	}
+
+	b.WriteString("\n## Details\n")
+	for _, t := range r.Tasks {
+		fmt.Fprintf(&b, "\n### %s\n\n", t.ID)
+		if t.Error != "" {
+			fmt.Fprintf(&b, "Error: %s\n\n", oneLine(t.Error))
+		}
+		for _, g := range t.Grades {
+			mark := "pass"
+			if !g.Pass {
+				mark = "FAIL"
+			}
+			fmt.Fprintf(&b, "- %s `%s` %s", mark, g.Type, g.Name)
+			if g.Type == GraderLLM {
+				fmt.Fprintf(&b, " (%s)", pct(g.Score))
+			}
+			if g.Detail != "" {
+				fmt.Fprintf(&b, ": %s", oneLine(g.Detail))
+			}
+			b.WriteString("\n")
+		}
+		if t.Worktree != "" {
+			fmt.Fprintf(&b, "\nWorktree: `%s`\n", t.Worktree)
+		}
+	}
+
+	var earlier []*Report
+	for _, h := range history {
+		if h.StartedAt.Before(r.StartedAt) {
+			earlier = append(earlier, h)
+		}
+	}
+	if len(earlier) > 0 {
+		const keep = 5
+		if len(earlier) > keep {
+			earlier = earlier[len(earlier)-keep:]
+		}
+		b.WriteString("\n## Compared with earlier runs\n\n")
+		b.WriteString(Compare(append(earlier, r)))
+	}
+	return b.String()
+}
+
+// Compare renders a markdown table of several runs of the same suite,
+// oldest first: an overall row per run, then each task's score per run.
+func Compare(reports []*Report) string {
+	rs := append([]*Report(nil), reports...)
+	sortReports(rs)
+
+	var b strings.Builder
+	b.WriteString("| # | Run | Suite | Score | Passed | Duration |\n")
+	b.WriteString("|---|-----|-------|-------|--------|----------|\n")
+	for i, r := range rs {
+		fmt.Fprintf(&b, "| %d | %s | %s | %s | %d/%d | %s |\n",
+			i+1, r.name(), r.Suite, pct(r.Score), r.Passed, r.Total, formatMs(r.DurationMs))
+	}
+
+	// Tasks in first-seen order, so renamed or added tasks still show.
+	var ids []string
+	seen := make(map[string]bool)
+	for _, r := range rs {
+		for _, t := range r.Tasks {
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				ids = append(ids, t.ID)
+			}
+		}
+	}
+	b.WriteString("\n| Task |")
+	for i := range rs {
+		fmt.Fprintf(&b, " #%d |", i+1)
+	}
+	b.WriteString("\n|------|")
+	b.WriteString(strings.Repeat("----|", len(rs)))
+	b.WriteString("\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "| %s |", id)
+		for _, r := range rs {
+			cell := "-"
+			for _, t := range r.Tasks {
+				if t.ID == id {
+					cell = result(t) + " " + pct(t.Score)
+					break
+				}
+			}
+			fmt.Fprintf(&b, " %s |", cell)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func result(t TaskResult) string {
+	switch {
+	case t.Passed:
+		return "pass"
+	case t.Error != "" && len(t.Grades) == 0:
+		return "error"
+	default:
+		return "FAIL"
+	}
+}
+
+func pct(f float64) string {
+	return fmt.Sprintf("%.0f%%", f*100)
+}
+
+func formatMs(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(time.Second).String()
+}
+
+// oneLine flattens s so it fits a markdown list item.
+func oneLine(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 300 {
+		s = s[:300] + "..."
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package eval
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleReport(label string, started time.Time, fixScore float64) *Report {
+	r := &Report{
+		Suite:     "core",
+		Label:     label,
+		Model:     "m",
+		Provider:  "p",
+		StartedAt: started,
+		Tasks: []TaskResult{
+			{ID: "fix", Passed: fixScore == 1, Score: fixScore, Grades: []Grade{
+				{Name: "go test ./...", Type: GraderCommand, Weight: 1, Pass: fixScore == 1, Score: fixScore, Detail: "--- FAIL: TestAdd\n    want 3"},
+			}},
+			{ID: "broken", Error: "fixture: no such file"},
+		},
+	}
+	r.tally()
+	return r
+}
+
+func TestReportWriteAndHistory(t *testing.T) {
+	dir := t.TempDir()
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	old := sampleReport("baseline", t0, 0)
+	_, _, err := old.Write(dir, nil)
+	require.NoError(t, err)
+
+	other := sampleReport("x", t0.Add(time.Minute), 1)
+	other.Suite = "other"
+	_, _, err = other.Write(dir, nil)
+	require.NoError(t, err)
+
+	history, err := LoadHistory(dir, "core")
+	require.NoError(t, err)
+	require.Len(t, history, 1, "only reports of the same suite")
+
+	cur := sampleReport("candidate", t0.Add(time.Hour), 1)
+	jsonPath, mdPath, err := cur.Write(dir, history)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "core-20260301-110000.json"), jsonPath)
+
+	loaded, err := LoadReport(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, cur.Score, loaded.Score)
+	assert.Equal(t, 1, loaded.Passed)
+	assert.Equal(t, 2, loaded.Total)
+
+	md := cur.Markdown(history)
+	assert.Contains(t, md, "# Eval: core")
+	assert.Contains(t, md, "Score: **50%** (1/2 tasks passed)")
+	assert.Contains(t, md, "| broken | error |")
+	assert.Contains(t, md, "Error: fixture: no such file")
+	assert.Contains(t, md, "## Compared with earlier runs")
+	assert.FileExists(t, mdPath)
+}
+
+func TestCompareOrdersRunsAndAlignsTasks(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	newer := sampleReport("b", t0.Add(time.Hour), 1)
+	newer.Tasks = append(newer.Tasks, TaskResult{ID: "added", Passed: true, Score: 1})
+	newer.tally()
+	older := sampleReport("a", t0, 0)
+
+	out := Compare([]*Report{newer, older})
+	lines := strings.Split(out, "\n")
+	assert.Contains(t, lines[2], "| 1 | a (")
+	assert.Contains(t, lines[3], "| 2 | b (")
+	assert.Contains(t, out, "| fix | FAIL 0% | pass 100% |")
+	assert.Contains(t, out, "| added | - | pass 100% |")
+}
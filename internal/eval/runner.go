@@ -0,0 +1,402 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/conc/pool"
+
+	"github.com/julianshen/rubichan/internal/output"
+	"github.com/julianshen/rubichan/internal/worktree"
+)
+
+// maxAnswer caps how much of the agent's answer is kept in a report.
+const maxAnswer = 4000
+
+// Transcript is what an Executor reports about one agent run.
+type Transcript struct {
+	Answer    string
+	Turns     int
+	ToolCalls int
+	Error     string // the run finished but the agent reported an error
+}
+
+// Executor runs the agent on a task inside dir.
+type Executor interface {
+	Execute(ctx context.Context, task Task, dir string) (Transcript, error)
+}
+
+// CommandExecutor runs each task as a headless rubichan process.
+type CommandExecutor struct {
+	Path string   // rubichan binary
+	Args []string // extra flags for every run, e.g. --model
+	Env  []string // extra KEY=value environment for every run, e.g. the API key
+}
+
+// Execute implements Executor.
+func (e *CommandExecutor) Execute(ctx context.Context, task Task, dir string) (Transcript, error) {
+	args := []string{
+		"--headless",
+		"--prompt", task.Prompt,
+		"--max-turns", strconv.Itoa(task.MaxTurns),
+		"--timeout", time.Duration(task.Timeout).String(),
+		"--output", "json",
+		"--auto-approve",
+	}
+	args = append(args, e.Args...)
+	cmd := exec.CommandContext(ctx, e.Path, args...)
+	cmd.Dir = dir
+	if len(e.Env) > 0 {
+		cmd.Env = append(os.Environ(), e.Env...)
+	}
+	cmd.WaitDelay = 5 * time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return Transcript{}, ctx.Err()
+	}
+
+	var res output.RunResult
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		if runErr != nil {
+			return Transcript{}, fmt.Errorf("%w: %s", runErr, tail(stderr.String(), 500))
+		}
+		return Transcript{}, fmt.Errorf("parsing headless output: %w", err)
+	}
+	return Transcript{
+		Answer:    res.Response,
+		Turns:     res.TurnCount,
+		ToolCalls: len(res.ToolCalls),
+		Error:     res.Error,
+	}, nil
+}
+
+// TaskResult is the outcome of one task.
+type TaskResult struct {
+	ID         string  `json:"id"`
+	Passed     bool    `json:"passed"`
+	Score      float64 `json:"score"`
+	Grades     []Grade `json:"grades,omitempty"`
+	Answer     string  `json:"answer,omitempty"`
+	Turns      int     `json:"turns"`
+	ToolCalls  int     `json:"tool_calls"`
+	DurationMs int64   `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+	Worktree   string  `json:"worktree,omitempty"` // set when worktrees are kept
+}
+
+// Runner runs a suite, each task in its own git worktree.
+type Runner struct {
+	Executor      Executor
+	Judge         Completer // for llm graders; may be nil if the suite has none
+	Parallel      int       // tasks run at once (default 1)
+	KeepWorktrees bool      // leave worktrees behind for inspection
+
+	// Label, Model and Provider identify the configuration in the report.
+	Label    string
+	Model    string
+	Provider string
+
+	// Progress, if set, is called as each task finishes.
+	Progress func(TaskResult)
+}
+
+// Run runs every task of s and returns the scored report. Task failures
+// are recorded in the report; the error is only for problems that stop
+// the whole run, such as the context being cancelled.
+func (r *Runner) Run(ctx context.Context, s *Suite) (*Report, error) {
+	if r.Executor == nil {
+		return nil, fmt.Errorf("eval runner has no executor")
+	}
+	started := time.Now()
+	runID := started.Format("20060102-150405")
+
+	fixtures := &fixtureSet{keep: r.KeepWorktrees}
+	defer fixtures.cleanup()
+
+	parallel := r.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]TaskResult, len(s.Tasks))
+	var progressMu sync.Mutex
+	p := pool.New().WithMaxGoroutines(parallel)
+	for i, task := range s.Tasks {
+		p.Go(func() {
+			res := r.runTask(ctx, task, runID, fixtures)
+			results[i] = res
+			if r.Progress != nil {
+				progressMu.Lock()
+				r.Progress(res)
+				progressMu.Unlock()
+			}
+		})
+	}
+	p.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rep := &Report{
+		Suite:      s.Name,
+		Label:      r.Label,
+		Model:      r.Model,
+		Provider:   r.Provider,
+		StartedAt:  started.UTC(),
+		DurationMs: time.Since(started).Milliseconds(),
+		Tasks:      results,
+	}
+	rep.tally()
+	return rep, nil
+}
+
+func (r *Runner) runTask(ctx context.Context, task Task, runID string, fixtures *fixtureSet) TaskResult {
+	res := TaskResult{ID: task.ID}
+	start := time.Now()
+	defer func() { res.DurationMs = time.Since(start).Milliseconds() }()
+
+	fx, err := fixtures.get(ctx, task.Repo)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	mgr := worktree.NewManager(fx.root, worktree.Config{BaseBranch: task.Ref})
+	wt, err := mgr.Create(ctx, "eval-"+task.ID+"-"+runID)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if r.KeepWorktrees {
+		res.Worktree = wt.Dir()
+	} else {
+		// Remove with a fresh context so cleanup still happens after a
+		// timeout or cancellation.
+		defer mgr.Remove(context.Background(), wt.Name) //nolint:errcheck
+	}
+	dir := filepath.Join(wt.Dir(), fx.subdir)
+
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(task.Timeout))
+	tr, err := r.Executor.Execute(runCtx, task, dir)
+	cancel()
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			res.Error = fmt.Sprintf("timed out after %s", time.Duration(task.Timeout))
+		} else {
+			res.Error = err.Error()
+		}
+		if ctx.Err() != nil {
+			return res
+		}
+	}
+	res.Answer = tail(tr.Answer, maxAnswer)
+	res.Turns = tr.Turns
+	res.ToolCalls = tr.ToolCalls
+	if res.Error == "" {
+		res.Error = tr.Error
+	}
+
+	// Graders still look at a run that failed or timed out: partial work
+	// is worth seeing in the report, it just cannot pass or score.
+	in := gradeInput{Task: task, Dir: dir, Answer: tr.Answer, Judge: r.Judge}
+	if needsDiff(task) {
+		in.Diff = worktreeDiff(ctx, dir)
+	}
+	for _, spec := range task.Graders {
+		res.Grades = append(res.Grades, grade(ctx, spec, in))
+	}
+	res.Score, res.Passed = score(res.Grades)
+	if res.Error != "" {
+		res.Score, res.Passed = 0, false
+	}
+	return res
+}
+
+// score is the weighted average of the grade scores; a task passes only
+// when every grader passes.
+func score(grades []Grade) (float64, bool) {
+	var total, weight float64
+	passed := len(grades) > 0
+	for _, g := range grades {
+		total += g.Score * g.Weight
+		weight += g.Weight
+		passed = passed && g.Pass
+	}
+	if weight == 0 {
+		return 0, passed
+	}
+	return total / weight, passed
+}
+
+func needsDiff(task Task) bool {
+	for _, g := range task.Graders {
+		if g.Type == GraderLLM {
+			return true
+		}
+	}
+	return false
+}
+
+// worktreeDiff returns the agent's changes under dir, new files included.
+// The worktree is thrown away afterwards, so staging in it is harmless.
+func worktreeDiff(ctx context.Context, dir string) string {
+	if _, err := runGit(ctx, dir, "add", "-A", "."); err != nil {
+		return ""
+	}
+	out, err := runGit(ctx, dir, "diff", "--cached", "HEAD", "--", ".")
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// fixture is a git repository tasks can be checked out from.
+type fixture struct {
+	root   string // git toplevel
+	subdir string // task directory relative to root
+	temp   bool   // root is a throwaway copy of a plain directory
+}
+
+// fixtureSet prepares each distinct task repo once per run.
+type fixtureSet struct {
+	keep bool
+
+	mu   sync.Mutex
+	byID map[string]*fixtureEntry
+}
+
+type fixtureEntry struct {
+	once sync.Once
+	fx   fixture
+	err  error
+}
+
+func (s *fixtureSet) get(ctx context.Context, repo string) (fixture, error) {
+	s.mu.Lock()
+	if s.byID == nil {
+		s.byID = make(map[string]*fixtureEntry)
+	}
+	e, ok := s.byID[repo]
+	if !ok {
+		e = &fixtureEntry{}
+		s.byID[repo] = e
+	}
+	s.mu.Unlock()
+	e.once.Do(func() { e.fx, e.err = prepareFixture(ctx, repo) })
+	return e.fx, e.err
+}
+
+func (s *fixtureSet) cleanup() {
+	if s.keep {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.byID {
+		if e.err == nil && e.fx.temp {
+			os.RemoveAll(e.fx.root)
+		}
+	}
+}
+
+// prepareFixture locates the git repository containing repo. A directory
+// that is not under git is copied to a temporary repository with a single
+// commit, so every task still starts from a clean, resettable tree. Note
+// that uncommitted changes in a git fixture are not part of the worktree.
+func prepareFixture(ctx context.Context, repo string) (fixture, error) {
+	info, err := os.Stat(repo)
+	if err != nil {
+		return fixture{}, fmt.Errorf("fixture: %w", err)
+	}
+	if !info.IsDir() {
+		return fixture{}, fmt.Errorf("fixture %s is not a directory", repo)
+	}
+	if top, err := runGit(ctx, repo, "rev-parse", "--show-toplevel"); err == nil {
+		root := strings.TrimSpace(top)
+		abs, err := filepath.EvalSymlinks(repo)
+		if err != nil {
+			return fixture{}, err
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			return fixture{}, err
+		}
+		return fixture{root: root, subdir: rel}, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "rubichan-eval-")
+	if err != nil {
+		return fixture{}, err
+	}
+	if err := copyTree(repo, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return fixture{}, fmt.Errorf("copying fixture %s: %w", repo, err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=rubichan-eval", "-c", "user.email=eval@rubichan.invalid", "commit", "-q", "--allow-empty", "-m", "fixture"},
+	} {
+		if _, err := runGit(ctx, tmp, args...); err != nil {
+			os.RemoveAll(tmp)
+			return fixture{}, fmt.Errorf("preparing fixture %s: %w", repo, err)
+		}
+	}
+	return fixture{root: tmp, subdir: ".", temp: true}, nil
+}
+
+// copyTree copies regular files and directories from src into dst.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			if d.Name() == ".rubichan" && rel != "." {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,190 @@
+package eval
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutor stands in for the agent: it applies edit in the task
+// directory and answers with a fixed text.
+type fakeExecutor struct {
+	edit    func(dir string) error
+	answer  string
+	block   bool
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (f *fakeExecutor) Execute(ctx context.Context, task Task, dir string) (Transcript, error) {
+	n := f.running.Add(1)
+	defer f.running.Add(-1)
+	for {
+		p := f.peak.Load()
+		if n <= p || f.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	if f.block {
+		<-ctx.Done()
+		return Transcript{}, ctx.Err()
+	}
+	time.Sleep(20 * time.Millisecond)
+	if f.edit != nil {
+		if err := f.edit(dir); err != nil {
+			return Transcript{}, err
+		}
+	}
+	return Transcript{Answer: f.answer + " (" + task.ID + ")", Turns: 2, ToolCalls: 1}, nil
+}
+
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	return dir
+}
+
+func requireGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+}
+
+func TestRunnerGradesEachTaskInItsOwnWorktree(t *testing.T) {
+	requireGit(t)
+	repo := gitRepo(t, map[string]string{"app/calc.txt": "a - b\n", "README": "hi\n"})
+	suite := &Suite{Name: "core", Dir: repo, Tasks: []Task{
+		{ID: "fix", Repo: filepath.Join(repo, "app"), Prompt: "fix calc", Graders: []GraderSpec{
+			{Type: GraderFile, Path: "calc.txt", Contains: "a + b"},
+			{Type: GraderCommand, Run: "test ! -e README"},
+			{Type: GraderLLM, Rubric: "uses addition"},
+		}},
+		{ID: "answer", Repo: filepath.Join(repo, "app"), Prompt: "explain", Graders: []GraderSpec{
+			{Type: GraderRegex, Pattern: "subtraction"},
+		}},
+	}}
+	require.NoError(t, suite.normalize())
+
+	var dirs []string
+	ex := &fakeExecutor{answer: "it used addition", edit: func(dir string) error {
+		dirs = append(dirs, dir)
+		return os.WriteFile(filepath.Join(dir, "calc.txt"), []byte("a + b\n"), 0o644)
+	}}
+	judge := &stubJudge{reply: `{"pass": true, "score": 1, "reason": "ok"}`}
+	var progress []string
+	r := &Runner{Executor: ex, Judge: judge, Label: "test", Progress: func(tr TaskResult) {
+		progress = append(progress, tr.ID)
+	}}
+	rep, err := r.Run(context.Background(), suite)
+	require.NoError(t, err)
+
+	require.Len(t, rep.Tasks, 2)
+	fix := rep.Tasks[0]
+	assert.True(t, fix.Passed, "%+v", fix.Grades)
+	assert.Equal(t, 1.0, fix.Score)
+	assert.Equal(t, 2, fix.Turns)
+	assert.Contains(t, judge.prompt, "+a + b", "the judge sees the diff")
+	assert.False(t, rep.Tasks[1].Passed)
+	assert.Equal(t, 1, rep.Passed)
+	assert.Equal(t, 2, rep.Total)
+	assert.Equal(t, 0.5, rep.Score)
+	assert.ElementsMatch(t, []string{"fix", "answer"}, progress)
+
+	for _, d := range dirs {
+		assert.True(t, strings.HasSuffix(d, "app"), "runs in the fixture subdirectory: %s", d)
+		assert.NoDirExists(t, d, "worktrees are removed afterwards")
+	}
+	data, err := os.ReadFile(filepath.Join(repo, "app", "calc.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "a - b\n", string(data), "the fixture itself is untouched")
+}
+
+func TestRunnerCopiesPlainDirectoryFixtures(t *testing.T) {
+	requireGit(t)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("todo\n"), 0o644))
+	suite := &Suite{Name: "plain", Tasks: []Task{{ID: "t", Repo: dir, Prompt: "p", Graders: []GraderSpec{
+		{Type: GraderFile, Path: "notes.txt", Contains: "done"},
+	}}}}
+	require.NoError(t, suite.normalize())
+
+	r := &Runner{Executor: &fakeExecutor{edit: func(d string) error {
+		return os.WriteFile(filepath.Join(d, "notes.txt"), []byte("done\n"), 0o644)
+	}}, KeepWorktrees: true}
+	rep, err := r.Run(context.Background(), suite)
+	require.NoError(t, err)
+	require.True(t, rep.Tasks[0].Passed, "%+v", rep.Tasks[0])
+	require.NotEmpty(t, rep.Tasks[0].Worktree)
+	assert.FileExists(t, filepath.Join(rep.Tasks[0].Worktree, "notes.txt"), "kept worktrees stay for inspection")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(filepath.Dir(filepath.Dir(rep.Tasks[0].Worktree)))) })
+
+	data, err := os.ReadFile(filepath.Join(dir, "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "todo\n", string(data))
+}
+
+func TestRunnerParallelismAndTimeouts(t *testing.T) {
+	requireGit(t)
+	repo := gitRepo(t, map[string]string{"f": "x"})
+	var tasks []Task
+	for _, id := range []string{"a", "b", "c", "d"} {
+		tasks = append(tasks, Task{ID: id, Repo: repo, Prompt: "p", Timeout: Duration(100 * time.Millisecond),
+			Graders: []GraderSpec{{Type: GraderFile, Path: "f"}}})
+	}
+	suite := &Suite{Name: "slow", Tasks: tasks}
+	require.NoError(t, suite.normalize())
+
+	ex := &fakeExecutor{block: true}
+	rep, err := (&Runner{Executor: ex, Parallel: 2}).Run(context.Background(), suite)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), ex.peak.Load())
+	for _, task := range rep.Tasks {
+		assert.False(t, task.Passed)
+		assert.Zero(t, task.Score)
+		assert.Contains(t, task.Error, "timed out after 100ms")
+		require.Len(t, task.Grades, 1, "graders still look at partial work")
+		assert.True(t, task.Grades[0].Pass)
+	}
+	assert.Equal(t, 0, rep.Passed)
+}
+
+func TestCommandExecutorPassesEnv(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "rubichan")
+	script := "#!/bin/sh\nprintf '{\"response\":\"%s %s\",\"turn_count\":1}' \"$EVAL_TEST_KEY\" \"$*\"\n"
+	require.NoError(t, os.WriteFile(bin, []byte(script), 0o755))
+
+	ex := &CommandExecutor{Path: bin, Args: []string{"--model", "m"}, Env: []string{"EVAL_TEST_KEY=secret"}}
+	tr, err := ex.Execute(context.Background(), Task{Prompt: "p", MaxTurns: 1, Timeout: Duration(time.Minute)}, dir)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(tr.Answer, "secret "), tr.Answer)
+	assert.NotContains(t, strings.TrimPrefix(tr.Answer, "secret "), "secret")
+	assert.Contains(t, tr.Answer, "--model m")
+}
@@ -0,0 +1,279 @@
+// Package eval runs benchmark suites against the agent: each task starts
+// from a repo fixture in its own git worktree, runs a prompt to completion
+// and is scored by graders — test commands, file assertions, regexes over
+// the answer, or an LLM judge. Reports are written as JSON and markdown so
+// runs with different models or configs can be compared over time.
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Default task limits, used when neither the task nor the suite sets one.
+const (
+	DefaultMaxTurns = 30
+	DefaultTimeout  = 10 * time.Minute
+)
+
+// Grader types.
+const (
+	GraderCommand = "command"
+	GraderFile    = "file"
+	GraderRegex   = "regex"
+	GraderLLM     = "llm"
+)
+
+// Suite is a named set of tasks, loaded from YAML:
+//
+//	name: core
+//	defaults:
+//	  repo: .
+//	  max_turns: 20
+//	  timeout: 5m
+//	tasks:
+//	  - id: fix-add
+//	    repo: evals/fixtures/calc
+//	    prompt: Make the failing test in calc_test.go pass.
+//	    graders:
+//	      - type: command
+//	        run: go test ./...
+//	      - type: file
+//	        path: calc.go
+//	        contains: "return a + b"
+//	      - type: llm
+//	        rubric: The answer names the bug and the fix.
+type Suite struct {
+	Name     string       `yaml:"name"`
+	Defaults TaskDefaults `yaml:"defaults"`
+	Tasks    []Task       `yaml:"tasks"`
+
+	// Dir is the directory of the suite file; relative repo paths are
+	// resolved against it.
+	Dir string `yaml:"-"`
+}
+
+// TaskDefaults holds settings shared by every task of a suite.
+type TaskDefaults struct {
+	Repo     string   `yaml:"repo"`
+	Ref      string   `yaml:"ref"`
+	MaxTurns int      `yaml:"max_turns"`
+	Timeout  Duration `yaml:"timeout"`
+}
+
+// Task is one benchmark task.
+type Task struct {
+	ID       string       `yaml:"id"`
+	Repo     string       `yaml:"repo"` // git repo or plain directory; a subdirectory of a repo works too
+	Ref      string       `yaml:"ref"`  // commit the worktree starts from (default HEAD)
+	Prompt   string       `yaml:"prompt"`
+	MaxTurns int          `yaml:"max_turns"`
+	Timeout  Duration     `yaml:"timeout"`
+	Graders  []GraderSpec `yaml:"graders"`
+}
+
+// GraderSpec configures one grader of a task. Which fields apply depends on
+// Type:
+//
+//   - command: Run is executed with sh in the task directory and passes on
+//     exit status 0.
+//   - file: Path must exist (or must not, with exists: false) and, if set,
+//     contain Contains and match Matches.
+//   - regex: Pattern must match the agent's final answer.
+//   - llm: a judge model scores the answer and diff against Rubric.
+type GraderSpec struct {
+	Type     string   `yaml:"type"`
+	Name     string   `yaml:"name"`
+	Weight   float64  `yaml:"weight"`
+	Run      string   `yaml:"run"`
+	Timeout  Duration `yaml:"timeout"`
+	Path     string   `yaml:"path"`
+	Exists   *bool    `yaml:"exists"`
+	Contains string   `yaml:"contains"`
+	Matches  string   `yaml:"matches"`
+	Pattern  string   `yaml:"pattern"`
+	Rubric   string   `yaml:"rubric"`
+}
+
+// Duration is a time.Duration that unmarshals from strings such as "5m".
+type Duration time.Duration
+
+// UnmarshalYAML parses a Go duration string.
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LoadSuite reads and validates a suite file, applying its defaults to
+// every task.
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading suite: %w", err)
+	}
+	var s Suite
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("parsing suite %s: %w", path, err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	s.Dir = filepath.Dir(abs)
+	if s.Name == "" {
+		s.Name = trimExt(filepath.Base(path))
+	}
+	if err := s.normalize(); err != nil {
+		return nil, fmt.Errorf("suite %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+func trimExt(name string) string {
+	return name[:len(name)-len(filepath.Ext(name))]
+}
+
+// normalize applies defaults and validates every task.
+func (s *Suite) normalize() error {
+	if len(s.Tasks) == 0 {
+		return fmt.Errorf("no tasks")
+	}
+	seen := make(map[string]bool, len(s.Tasks))
+	for i := range s.Tasks {
+		t := &s.Tasks[i]
+		if t.ID == "" {
+			return fmt.Errorf("task %d: id is required", i+1)
+		}
+		if err := validateID(t.ID); err != nil {
+			return err
+		}
+		if seen[t.ID] {
+			return fmt.Errorf("task %s: duplicate id", t.ID)
+		}
+		seen[t.ID] = true
+		if t.Prompt == "" {
+			return fmt.Errorf("task %s: prompt is required", t.ID)
+		}
+		if t.Repo == "" {
+			t.Repo = s.Defaults.Repo
+		}
+		if t.Repo == "" {
+			t.Repo = "."
+		}
+		if !filepath.IsAbs(t.Repo) {
+			t.Repo = filepath.Join(s.Dir, t.Repo)
+		}
+		if t.Ref == "" {
+			t.Ref = s.Defaults.Ref
+		}
+		if t.Ref == "" {
+			t.Ref = "HEAD"
+		}
+		if t.MaxTurns == 0 {
+			t.MaxTurns = s.Defaults.MaxTurns
+		}
+		if t.MaxTurns == 0 {
+			t.MaxTurns = DefaultMaxTurns
+		}
+		if t.Timeout == 0 {
+			t.Timeout = s.Defaults.Timeout
+		}
+		if t.Timeout == 0 {
+			t.Timeout = Duration(DefaultTimeout)
+		}
+		if len(t.Graders) == 0 {
+			return fmt.Errorf("task %s: at least one grader is required", t.ID)
+		}
+		for j := range t.Graders {
+			if err := t.Graders[j].normalize(); err != nil {
+				return fmt.Errorf("task %s grader %d: %w", t.ID, j+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// validateID keeps task IDs usable in worktree and branch names.
+func validateID(id string) error {
+	if !idPattern.MatchString(id) || len(id) > 64 {
+		return fmt.Errorf("task %q: id must be 1-64 letters, digits, '.', '_' or '-'", id)
+	}
+	return nil
+}
+
+func (g *GraderSpec) normalize() error {
+	if g.Weight < 0 {
+		return fmt.Errorf("weight must not be negative")
+	}
+	if g.Weight == 0 {
+		g.Weight = 1
+	}
+	switch g.Type {
+	case GraderCommand:
+		if g.Run == "" {
+			return fmt.Errorf("command grader needs run")
+		}
+	case GraderFile:
+		if g.Path == "" {
+			return fmt.Errorf("file grader needs path")
+		}
+		if filepath.IsAbs(g.Path) {
+			return fmt.Errorf("file grader path %q must be relative to the task directory", g.Path)
+		}
+		if g.Matches != "" {
+			if _, err := regexp.Compile(g.Matches); err != nil {
+				return fmt.Errorf("invalid matches pattern: %w", err)
+			}
+		}
+	case GraderRegex:
+		if g.Pattern == "" {
+			return fmt.Errorf("regex grader needs pattern")
+		}
+		if _, err := regexp.Compile(g.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case GraderLLM:
+		if g.Rubric == "" {
+			return fmt.Errorf("llm grader needs rubric")
+		}
+	case "":
+		return fmt.Errorf("type is required")
+	default:
+		return fmt.Errorf("unknown type %q (want command, file, regex or llm)", g.Type)
+	}
+	if g.Name == "" {
+		g.Name = g.defaultName()
+	}
+	return nil
+}
+
+func (g *GraderSpec) defaultName() string {
+	switch g.Type {
+	case GraderCommand:
+		return g.Run
+	case GraderFile:
+		return "file " + g.Path
+	case GraderRegex:
+		return "answer matches " + g.Pattern
+	default:
+		return "llm judge"
+	}
+}
@@ -0,0 +1,88 @@
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSuite(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "core.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+	return path
+}
+
+func TestLoadSuiteAppliesDefaults(t *testing.T) {
+	path := writeSuite(t, `
+defaults:
+  max_turns: 7
+  timeout: 90s
+tasks:
+  - id: fix-add
+    repo: fixtures/calc
+    prompt: Fix the add function.
+    graders:
+      - type: command
+        run: go test ./...
+      - type: file
+        path: calc.go
+        contains: "a + b"
+        weight: 2
+  - id: explain
+    prompt: Explain main.go.
+    max_turns: 3
+    timeout: 2m
+    ref: v1.0
+    graders:
+      - type: regex
+        pattern: "(?i)entry point"
+`)
+	s, err := LoadSuite(path)
+	require.NoError(t, err)
+	assert.Equal(t, "core", s.Name, "name defaults to the file name")
+	require.Len(t, s.Tasks, 2)
+
+	fix := s.Tasks[0]
+	assert.Equal(t, filepath.Join(s.Dir, "fixtures/calc"), fix.Repo)
+	assert.Equal(t, "HEAD", fix.Ref)
+	assert.Equal(t, 7, fix.MaxTurns)
+	assert.Equal(t, Duration(90*time.Second), fix.Timeout)
+	assert.Equal(t, "go test ./...", fix.Graders[0].Name)
+	assert.Equal(t, 1.0, fix.Graders[0].Weight)
+	assert.Equal(t, 2.0, fix.Graders[1].Weight)
+
+	explain := s.Tasks[1]
+	assert.Equal(t, s.Dir, explain.Repo)
+	assert.Equal(t, "v1.0", explain.Ref)
+	assert.Equal(t, 3, explain.MaxTurns)
+	assert.Equal(t, Duration(2*time.Minute), explain.Timeout)
+}
+
+func TestLoadSuiteRejectsInvalidSuites(t *testing.T) {
+	cases := map[string]struct{ body, want string }{
+		"no tasks":       {"name: x\n", "no tasks"},
+		"unknown field":  {"tasks:\n  - id: a\n    promt: typo\n", "promt"},
+		"missing prompt": {"tasks:\n  - id: a\n    graders: [{type: regex, pattern: x}]\n", "prompt is required"},
+		"bad id":         {"tasks:\n  - id: a/b\n    prompt: p\n    graders: [{type: regex, pattern: x}]\n", "id must be"},
+		"duplicate id": {"tasks:\n  - {id: a, prompt: p, graders: [{type: regex, pattern: x}]}\n" +
+			"  - {id: a, prompt: p, graders: [{type: regex, pattern: x}]}\n", "duplicate id"},
+		"no graders":     {"tasks:\n  - id: a\n    prompt: p\n", "at least one grader"},
+		"unknown grader": {"tasks:\n  - {id: a, prompt: p, graders: [{type: vibes}]}\n", `unknown type "vibes"`},
+		"bad regex":      {"tasks:\n  - {id: a, prompt: p, graders: [{type: regex, pattern: \"(\"}]}\n", "invalid pattern"},
+		"absolute path":  {"tasks:\n  - {id: a, prompt: p, graders: [{type: file, path: /etc/passwd}]}\n", "must be relative"},
+		"bad duration":   {"tasks:\n  - {id: a, prompt: p, timeout: soon, graders: [{type: regex, pattern: x}]}\n", "invalid duration"},
+		"llm no rubric":  {"tasks:\n  - {id: a, prompt: p, graders: [{type: llm}]}\n", "needs rubric"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadSuite(writeSuite(t, tc.body))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
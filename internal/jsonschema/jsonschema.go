@@ -0,0 +1,591 @@
+// Package jsonschema validates JSON documents against a JSON Schema.
+//
+// It implements the subset of draft 2020-12 that structured-output schemas
+// use in practice: type, enum, const, properties, patternProperties,
+// additionalProperties, required, dependentRequired, propertyNames,
+// min/maxProperties, items, prefixItems, contains, min/maxContains,
+// min/maxItems, uniqueItems, min/maxLength, pattern, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not,
+// if/then/else, and local $ref pointers such as "#/$defs/entry".
+// Annotation keywords (title, description, format, default, examples and
+// the like) are accepted and ignored. Compile rejects any other keyword, so
+// a schema is never enforced only in part.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	raw      json.RawMessage
+	root     any
+	patterns map[string]*regexp.Regexp
+}
+
+// ValidationError lists every way a document failed its schema. Each
+// problem is prefixed with the JSON pointer of the offending value.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Compile parses a schema document and checks that its patterns compile
+// and its $refs resolve.
+func Compile(data []byte) (*Schema, error) {
+	root, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+	switch root.(type) {
+	case map[string]any, bool:
+	default:
+		return nil, fmt.Errorf("schema must be a JSON object or boolean")
+	}
+	s := &Schema{
+		raw:      append(json.RawMessage(nil), bytes.TrimSpace(data)...),
+		root:     root,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	if err := s.check(root, "#"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Raw returns the schema document as given to Compile.
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// Root returns the decoded top-level schema, or nil for a boolean schema.
+func (s *Schema) Root() map[string]any {
+	m, _ := s.root.(map[string]any)
+	return m
+}
+
+// Validate checks a JSON document against the schema. It returns a
+// *ValidationError when the document is valid JSON but does not match.
+func (s *Schema) Validate(data []byte) error {
+	doc, err := decode(data)
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return s.ValidateValue(doc)
+}
+
+// ValidateValue checks an already-decoded value (as produced by
+// encoding/json with UseNumber) against the schema.
+func (s *Schema) ValidateValue(v any) error {
+	var problems []string
+	s.validate(s.root, v, "", 0, &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+// keywords lists the keywords Compile accepts: those validate enforces,
+// plus annotations that never affect validation.
+var keywords = map[string]bool{
+	"type": true, "enum": true, "const": true,
+	"properties": true, "patternProperties": true, "additionalProperties": true,
+	"required": true, "dependentRequired": true, "propertyNames": true,
+	"minProperties": true, "maxProperties": true,
+	"items": true, "prefixItems": true, "contains": true, "minContains": true, "maxContains": true,
+	"minItems": true, "maxItems": true, "uniqueItems": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true, "multipleOf": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "not": true, "if": true, "then": true, "else": true,
+	"$ref": true, "$defs": true, "definitions": true,
+	// Annotations.
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"format": true, "default": true, "examples": true, "deprecated": true,
+	"readOnly": true, "writeOnly": true, "contentMediaType": true, "contentEncoding": true,
+}
+
+// check walks a schema at compile time.
+func (s *Schema) check(node any, at string) error {
+	switch n := node.(type) {
+	case bool:
+		return nil
+	case map[string]any:
+		for _, key := range sortedKeys(n) {
+			if !keywords[key] {
+				return fmt.Errorf("%s: unsupported keyword %q", at, key)
+			}
+		}
+		if ref, ok := n["$ref"].(string); ok {
+			if _, err := s.resolve(ref); err != nil {
+				return fmt.Errorf("%s: %w", at, err)
+			}
+		}
+		if p, ok := n["pattern"].(string); ok {
+			if _, err := s.pattern(p); err != nil {
+				return fmt.Errorf("%s/pattern: %w", at, err)
+			}
+		}
+		for _, key := range []string{"items", "additionalProperties", "not", "propertyNames", "contains", "if", "then", "else"} {
+			if sub, ok := n[key]; ok {
+				if err := s.check(sub, at+"/"+key); err != nil {
+					return err
+				}
+			}
+		}
+		if m, ok := n["patternProperties"].(map[string]any); ok {
+			for _, p := range sortedKeys(m) {
+				if _, err := s.pattern(p); err != nil {
+					return fmt.Errorf("%s/patternProperties: %w", at, err)
+				}
+			}
+		}
+		if m, ok := n["dependentRequired"].(map[string]any); ok {
+			for _, name := range sortedKeys(m) {
+				list, ok := m[name].([]any)
+				if !ok {
+					return fmt.Errorf("%s/dependentRequired/%s: must be an array of property names", at, name)
+				}
+				for _, dep := range list {
+					if _, ok := dep.(string); !ok {
+						return fmt.Errorf("%s/dependentRequired/%s: must be an array of property names", at, name)
+					}
+				}
+			}
+		}
+		for _, key := range []string{"properties", "patternProperties", "$defs", "definitions"} {
+			if m, ok := n[key].(map[string]any); ok {
+				for _, name := range sortedKeys(m) {
+					if err := s.check(m[name], at+"/"+key+"/"+name); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		for _, key := range []string{"allOf", "anyOf", "oneOf", "prefixItems"} {
+			if list, ok := n[key].([]any); ok {
+				for i, sub := range list {
+					if err := s.check(sub, fmt.Sprintf("%s/%s/%d", at, key, i)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: schema must be a JSON object or boolean", at)
+	}
+}
+
+// resolve follows a local JSON pointer reference.
+func (s *Schema) resolve(ref string) (any, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are allowed", ref)
+	}
+	node := s.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		part, err := url.PathUnescape(part)
+		if err != nil {
+			return nil, fmt.Errorf("$ref %q: %w", ref, err)
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		switch n := node.(type) {
+		case map[string]any:
+			next, ok := n[part]
+			if !ok {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			node = next
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+	}
+	return node, nil
+}
+
+func (s *Schema) pattern(p string) (*regexp.Regexp, error) {
+	if re, ok := s.patterns[p]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns[p] = re
+	return re, nil
+}
+
+// maxRefDepth bounds $ref chains that do not consume any of the document,
+// such as a schema that references itself.
+const maxRefDepth = 64
+
+func (s *Schema) validate(node, v any, path string, depth int, problems *[]string) {
+	fail := func(format string, args ...any) {
+		where := path
+		if where == "" {
+			where = "/"
+		}
+		*problems = append(*problems, where+": "+fmt.Sprintf(format, args...))
+	}
+
+	n, ok := node.(map[string]any)
+	if !ok {
+		if b, _ := node.(bool); !b {
+			fail("no value is allowed here")
+		}
+		return
+	}
+
+	if ref, ok := n["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			fail("$ref %q nests deeper than %d levels", ref, maxRefDepth)
+			return
+		}
+		target, _ := s.resolve(ref) // checked by Compile
+		s.validate(target, v, path, depth+1, problems)
+	}
+
+	if t, ok := n["type"]; ok && !matchesType(t, v) {
+		fail("expected %s, got %s", describeType(t), typeName(v))
+		return
+	}
+	if enum, ok := n["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %s is not one of %s", compact(v), compact(enum))
+		}
+	}
+	if c, ok := n["const"]; ok && !jsonEqual(c, v) {
+		fail("value %s must equal %s", compact(v), compact(c))
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		s.validateObject(n, val, path, problems, fail)
+	case []any:
+		s.validateArray(n, val, path, problems, fail)
+	case string:
+		length := utf8.RuneCountInString(val)
+		if min, ok := number(n["minLength"]); ok && float64(length) < min {
+			fail("string is shorter than %v characters", min)
+		}
+		if max, ok := number(n["maxLength"]); ok && float64(length) > max {
+			fail("string is longer than %v characters", max)
+		}
+		if p, ok := n["pattern"].(string); ok {
+			if re, err := s.pattern(p); err == nil && !re.MatchString(val) {
+				fail("string %q does not match pattern %q", val, p)
+			}
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if min, ok := number(n["minimum"]); ok && f < min {
+			fail("%s is less than the minimum %v", val, min)
+		}
+		if max, ok := number(n["maximum"]); ok && f > max {
+			fail("%s is greater than the maximum %v", val, max)
+		}
+		if min, ok := number(n["exclusiveMinimum"]); ok && f <= min {
+			fail("%s must be greater than %v", val, min)
+		}
+		if max, ok := number(n["exclusiveMaximum"]); ok && f >= max {
+			fail("%s must be less than %v", val, max)
+		}
+		if m, ok := number(n["multipleOf"]); ok && m > 0 {
+			if q := f / m; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("%s is not a multiple of %v", val, m)
+			}
+		}
+	}
+
+	if list, ok := n["allOf"].([]any); ok {
+		for _, sub := range list {
+			s.validate(sub, v, path, depth, problems)
+		}
+	}
+	if list, ok := n["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range list {
+			if s.matches(sub, v, depth) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("value does not match any of the anyOf schemas")
+		}
+	}
+	if list, ok := n["oneOf"].([]any); ok {
+		count := 0
+		for _, sub := range list {
+			if s.matches(sub, v, depth) {
+				count++
+			}
+		}
+		if count != 1 {
+			fail("value matches %d of the oneOf schemas, want exactly 1", count)
+		}
+	}
+	if not, ok := n["not"]; ok && s.matches(not, v, depth) {
+		fail("value must not match the \"not\" schema")
+	}
+	if cond, ok := n["if"]; ok {
+		branch := "else"
+		if s.matches(cond, v, depth) {
+			branch = "then"
+		}
+		if sub, ok := n[branch]; ok {
+			s.validate(sub, v, path, depth, problems)
+		}
+	}
+}
+
+func (s *Schema) validateObject(n map[string]any, obj map[string]any, path string, problems *[]string, fail func(string, ...any)) {
+	if required, ok := n["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+	}
+	if min, ok := number(n["minProperties"]); ok && float64(len(obj)) < min {
+		fail("object has fewer than %v properties", min)
+	}
+	if max, ok := number(n["maxProperties"]); ok && float64(len(obj)) > max {
+		fail("object has more than %v properties", max)
+	}
+	if deps, ok := n["dependentRequired"].(map[string]any); ok {
+		for _, name := range sortedKeys(deps) {
+			if _, present := obj[name]; !present {
+				continue
+			}
+			list, _ := deps[name].([]any)
+			for _, dep := range list {
+				depName, _ := dep.(string)
+				if _, ok := obj[depName]; !ok {
+					fail("property %q requires property %q", name, depName)
+				}
+			}
+		}
+	}
+	props, _ := n["properties"].(map[string]any)
+	patternProps, _ := n["patternProperties"].(map[string]any)
+	for _, name := range sortedKeys(obj) {
+		child := path + "/" + escapePointer(name)
+		if names, ok := n["propertyNames"]; ok && !s.matches(names, name, 0) {
+			fail("property name %q does not match propertyNames", name)
+		}
+		matched := false
+		if sub, ok := props[name]; ok {
+			s.validate(sub, obj[name], child, 0, problems)
+			matched = true
+		}
+		for _, p := range sortedKeys(patternProps) {
+			if re, err := s.pattern(p); err == nil && re.MatchString(name) {
+				s.validate(patternProps[p], obj[name], child, 0, problems)
+				matched = true
+			}
+		}
+		if matched {
+			continue
+		}
+		if extra, ok := n["additionalProperties"]; ok {
+			if b, isBool := extra.(bool); isBool && !b {
+				fail("property %q is not allowed", name)
+				continue
+			}
+			s.validate(extra, obj[name], child, 0, problems)
+		}
+	}
+}
+
+func (s *Schema) validateArray(n map[string]any, arr []any, path string, problems *[]string, fail func(string, ...any)) {
+	if min, ok := number(n["minItems"]); ok && float64(len(arr)) < min {
+		fail("array has fewer than %v items", min)
+	}
+	if max, ok := number(n["maxItems"]); ok && float64(len(arr)) > max {
+		fail("array has more than %v items", max)
+	}
+	if unique, _ := n["uniqueItems"].(bool); unique {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if jsonEqual(arr[i], arr[j]) {
+					fail("items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+	if contains, ok := n["contains"]; ok {
+		count := 0
+		for _, item := range arr {
+			if s.matches(contains, item, 0) {
+				count++
+			}
+		}
+		min, ok := number(n["minContains"])
+		if !ok {
+			min = 1
+		}
+		if float64(count) < min {
+			fail("array has %d items matching contains, want at least %v", count, min)
+		}
+		if max, ok := number(n["maxContains"]); ok && float64(count) > max {
+			fail("array has %d items matching contains, want at most %v", count, max)
+		}
+	}
+	prefix, _ := n["prefixItems"].([]any)
+	for i, item := range arr {
+		child := fmt.Sprintf("%s/%d", path, i)
+		if i < len(prefix) {
+			s.validate(prefix[i], item, child, 0, problems)
+		} else if items, ok := n["items"]; ok {
+			s.validate(items, item, child, 0, problems)
+		}
+	}
+}
+
+func (s *Schema) matches(node, v any, depth int) bool {
+	var problems []string
+	s.validate(node, v, "", depth, &problems)
+	return len(problems) == 0
+}
+
+func matchesType(t, v any) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, v)
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); ok && isType(s, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isType(name string, v any) bool {
+	switch name {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	default:
+		return typeName(v) == name
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func describeType(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v any) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// jsonEqual compares decoded JSON values, treating numbers by value.
+func jsonEqual(a, b any) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, _ := an.Float64()
+		bf, _ := bn.Float64()
+		return af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compact(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
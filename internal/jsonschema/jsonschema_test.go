@@ -0,0 +1,188 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const verdictSchema = `{
+	"type": "object",
+	"properties": {
+		"verdict": {"enum": ["approve", "request_changes"]},
+		"score": {"type": "integer", "minimum": 0, "maximum": 10},
+		"summary": {"type": "string", "minLength": 1, "maxLength": 20},
+		"labels": {"type": "array", "items": {"type": "string", "pattern": "^[a-z-]+$"}, "uniqueItems": true, "maxItems": 3},
+		"entries": {"type": "array", "items": {"$ref": "#/$defs/entry"}}
+	},
+	"required": ["verdict", "score"],
+	"additionalProperties": false,
+	"$defs": {
+		"entry": {
+			"type": "object",
+			"properties": {"kind": {"const": "fix"}, "text": {"type": ["string", "null"]}},
+			"required": ["kind"]
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Compile([]byte(verdictSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		doc      string
+		problems []string
+	}{
+		{"valid", `{"verdict":"approve","score":7,"labels":["bug","ui-fix"],"entries":[{"kind":"fix","text":null}]}`, nil},
+		{"missing required", `{"verdict":"approve"}`, []string{`/: missing required property "score"`}},
+		{"wrong type", `{"verdict":"approve","score":"7"}`, []string{"/score: expected integer, got string"}},
+		{"not an integer", `{"verdict":"approve","score":7.5}`, []string{"/score: expected integer, got number"}},
+		{"enum", `{"verdict":"maybe","score":1}`, []string{`/verdict: value "maybe" is not one of ["approve","request_changes"]`}},
+		{"range", `{"verdict":"approve","score":11}`, []string{"/score: 11 is greater than the maximum 10"}},
+		{"string length", `{"verdict":"approve","score":1,"summary":""}`, []string{"/summary: string is shorter than 1 characters"}},
+		{"pattern and unique", `{"verdict":"approve","score":1,"labels":["Bug","Bug"]}`, []string{
+			"/labels: items 0 and 1 are equal",
+			`/labels/0: string "Bug" does not match pattern "^[a-z-]+$"`,
+			`/labels/1: string "Bug" does not match pattern "^[a-z-]+$"`,
+		}},
+		{"additional property", `{"verdict":"approve","score":1,"extra":true}`, []string{`/: property "extra" is not allowed`}},
+		{"ref", `{"verdict":"approve","score":1,"entries":[{"kind":"feat","text":3}]}`, []string{
+			`/entries/0/kind: value "feat" must equal "fix"`,
+			"/entries/0/text: expected string or null, got number",
+		}},
+		{"root type", `[]`, []string{"/: expected object, got array"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tt.doc))
+			if tt.problems == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.problems, verr.Problems)
+		})
+	}
+}
+
+func TestValidateCombinators(t *testing.T) {
+	schema, err := Compile([]byte(`{
+		"anyOf": [{"type": "string"}, {"type": "number", "multipleOf": 5}],
+		"oneOf": [{"type": "string", "maxLength": 3}, {"type": "string", "minLength": 2}, {"type": "number"}],
+		"not": {"const": 10}
+	}`))
+	require.NoError(t, err)
+
+	assert.NoError(t, schema.Validate([]byte(`"a"`)))
+	assert.NoError(t, schema.Validate([]byte(`15`)))
+	assert.ErrorContains(t, schema.Validate([]byte(`7`)), "does not match any of the anyOf schemas")
+	assert.ErrorContains(t, schema.Validate([]byte(`"ab"`)), "matches 2 of the oneOf schemas")
+	assert.ErrorContains(t, schema.Validate([]byte(`10`)), `must not match the "not" schema`)
+}
+
+func TestValidateObjectKeywords(t *testing.T) {
+	schema, err := Compile([]byte(`{
+		"type": "object",
+		"properties": {"name": {"type": "string"}},
+		"patternProperties": {"^x-": {"type": "integer"}},
+		"additionalProperties": false,
+		"propertyNames": {"maxLength": 6},
+		"minProperties": 1,
+		"maxProperties": 3,
+		"dependentRequired": {"x-b": ["name"]}
+	}`))
+	require.NoError(t, err)
+
+	assert.NoError(t, schema.Validate([]byte(`{"name":"a","x-a":1}`)))
+	assert.ErrorContains(t, schema.Validate([]byte(`{"x-a":"one"}`)), "/x-a: expected integer")
+	assert.ErrorContains(t, schema.Validate([]byte(`{"other":1}`)), `property "other" is not allowed`)
+	assert.ErrorContains(t, schema.Validate([]byte(`{"x-toolong":1}`)), `property name "x-toolong" does not match propertyNames`)
+	assert.ErrorContains(t, schema.Validate([]byte(`{}`)), "fewer than 1 properties")
+	assert.ErrorContains(t, schema.Validate([]byte(`{"name":"a","x-a":1,"x-c":2,"x-d":3}`)), "more than 3 properties")
+	assert.ErrorContains(t, schema.Validate([]byte(`{"x-b":1}`)), `property "x-b" requires property "name"`)
+}
+
+func TestValidateContainsAndConditionals(t *testing.T) {
+	schema, err := Compile([]byte(`{
+		"type": "array",
+		"contains": {"const": "fix"},
+		"maxContains": 2,
+		"if": {"minItems": 3},
+		"then": {"items": {"type": "string"}},
+		"else": {"prefixItems": [{"const": "fix"}]}
+	}`))
+	require.NoError(t, err)
+
+	assert.NoError(t, schema.Validate([]byte(`["fix", 1]`)))
+	assert.NoError(t, schema.Validate([]byte(`["a", "fix", "b"]`)))
+	assert.ErrorContains(t, schema.Validate([]byte(`["a"]`)), "0 items matching contains, want at least 1")
+	assert.ErrorContains(t, schema.Validate([]byte(`["fix", "fix", "fix"]`)), "3 items matching contains, want at most 2")
+	assert.ErrorContains(t, schema.Validate([]byte(`["fix", "a", 3]`)), "/2: expected string")
+	assert.ErrorContains(t, schema.Validate([]byte(`[1, "fix"]`)), `/0: value 1 must equal "fix"`)
+}
+
+func TestValidateInvalidJSON(t *testing.T) {
+	schema, err := Compile([]byte(`true`))
+	require.NoError(t, err)
+
+	err = schema.Validate([]byte(`{"a":`))
+	assert.ErrorContains(t, err, "invalid JSON")
+	var verr *ValidationError
+	assert.NotErrorAs(t, err, &verr)
+
+	assert.ErrorContains(t, schema.Validate([]byte(`{} {}`)), "unexpected data")
+}
+
+func TestBooleanSchemas(t *testing.T) {
+	schema, err := Compile([]byte(`{"type":"object","properties":{"never":false,"anything":true}}`))
+	require.NoError(t, err)
+
+	assert.NoError(t, schema.Validate([]byte(`{"anything":[1,{"x":null}]}`)))
+	assert.ErrorContains(t, schema.Validate([]byte(`{"never":1}`)), "/never: no value is allowed here")
+}
+
+func TestSelfReferenceIsBounded(t *testing.T) {
+	schema, err := Compile([]byte(`{"$ref":"#"}`))
+	require.NoError(t, err)
+	assert.ErrorContains(t, schema.Validate([]byte(`1`)), "nests deeper than")
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"not json", `{`, "parsing schema"},
+		{"not an object", `"string"`, "schema must be a JSON object or boolean"},
+		{"bad subschema", `{"properties":{"a":1}}`, "#/properties/a: schema must be a JSON object or boolean"},
+		{"bad pattern", `{"items":{"pattern":"("}}`, "#/items/pattern"},
+		{"dangling ref", `{"$ref":"#/$defs/missing"}`, `$ref "#/$defs/missing" does not resolve`},
+		{"remote ref", `{"$ref":"https://example.com/s.json"}`, "only local references"},
+		{"unsupported keyword", `{"type":"object","unevaluatedProperties":false}`, `#: unsupported keyword "unevaluatedProperties"`},
+		{"nested unsupported keyword", `{"properties":{"a":{"dependentSchemas":{}}}}`, `#/properties/a: unsupported keyword "dependentSchemas"`},
+		{"bad pattern property", `{"patternProperties":{"(":true}}`, "#/patternProperties"},
+		{"bad dependentRequired", `{"dependentRequired":{"a":"b"}}`, "#/dependentRequired/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile([]byte(tt.schema))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestRawAndRoot(t *testing.T) {
+	schema, err := Compile([]byte("  {\"type\":\"object\"}\n"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object"}`, string(schema.Raw()))
+	assert.Equal(t, "object", schema.Root()["type"])
+
+	boolean, err := Compile([]byte(`false`))
+	require.NoError(t, err)
+	assert.Nil(t, boolean.Root())
+}
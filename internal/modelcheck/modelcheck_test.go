@@ -268,7 +268,7 @@ func TestRunDrainsTheToolProbeStream(t *testing.T) {
 
 // blockingProvider mimics how real providers emit: a goroutine sending on an
 // unbuffered channel with `select { case ch <- evt: case <-ctx.Done(): }`, as
-// internal/provider/ollama and internal/provider/ssecompat both do. A consumer
+// internal/provider/ollama and pkg/provider/ssecompat both do. A consumer
 // that stops reading strands that goroutine until the context is cancelled,
 // which a fake with a buffered, pre-closed channel can never reveal.
 type blockingProvider struct {
@@ -1,7 +1,11 @@
 // internal/output/formatter.go
 package output
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
 
 // RunResult holds the collected output from a headless agent run.
 type RunResult struct {
@@ -9,7 +13,9 @@ type RunResult struct {
 	Response         string               `json:"response"`
 	Summary          string               `json:"summary,omitempty"`
 	EvidenceSummary  string               `json:"evidence_summary,omitempty"`
+	StructuredOutput json.RawMessage      `json:"structured_output,omitempty"`
 	ToolCalls        []ToolCallLog        `json:"tool_calls,omitempty"`
+	Plan             []agentsdk.PlanEntry `json:"plan,omitempty"`
 	TurnCount        int                  `json:"turn_count"`
 	DurationMs       int64                `json:"duration_ms"`
 	Mode             string               `json:"mode"`
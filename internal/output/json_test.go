@@ -138,3 +138,22 @@ func TestJSONFormatterNoSecurityFields(t *testing.T) {
 	_, hasSummary := decoded["security_summary"]
 	assert.False(t, hasSummary, "should omit nil security_summary")
 }
+
+func TestJSONFormatterStructuredOutput(t *testing.T) {
+	t.Parallel()
+
+	out, err := NewJSONFormatter().Format(&RunResult{StructuredOutput: json.RawMessage(`{"verdict":"approve"}`)})
+	require.NoError(t, err)
+
+	var decoded struct {
+		StructuredOutput struct {
+			Verdict string `json:"verdict"`
+		} `json:"structured_output"`
+	}
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, "approve", decoded.StructuredOutput.Verdict)
+
+	out, err = NewJSONFormatter().Format(&RunResult{})
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "structured_output")
+}
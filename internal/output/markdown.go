@@ -2,9 +2,12 @@
 package output
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
 )
 
 // MarkdownFormatter outputs RunResult as human-readable Markdown.
@@ -34,12 +37,37 @@ func (f *MarkdownFormatter) Format(result *RunResult) ([]byte, error) {
 		b.WriteString("\n")
 	}
 
+	if len(result.StructuredOutput) > 0 {
+		b.WriteString("\n## Structured Output\n\n```json\n")
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, result.StructuredOutput, "", "  "); err != nil {
+			pretty.Reset()
+			pretty.Write(result.StructuredOutput)
+		}
+		b.Write(pretty.Bytes())
+		b.WriteString("\n```\n")
+	}
+
 	if strings.TrimSpace(result.EvidenceSummary) != "" {
 		b.WriteString("\n## Evidence\n\n")
 		b.WriteString(strings.TrimSpace(result.EvidenceSummary))
 		b.WriteString("\n")
 	}
 
+	if len(result.Plan) > 0 {
+		b.WriteString("\n## Plan\n\n")
+		for _, item := range result.Plan {
+			mark := " "
+			switch item.Status {
+			case agentsdk.PlanEntryCompleted:
+				mark = "x"
+			case agentsdk.PlanEntryInProgress:
+				mark = "~"
+			}
+			b.WriteString(fmt.Sprintf("- [%s] %s\n", mark, item.Content))
+		}
+	}
+
 	if len(result.ToolCalls) > 0 {
 		b.WriteString("\n## Tool Calls\n\n")
 		for i, tc := range result.ToolCalls {
@@ -8,6 +8,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
 )
 
 func TestMarkdownFormatterBasic(t *testing.T) {
@@ -57,6 +59,27 @@ func TestMarkdownFormatterWithToolCalls(t *testing.T) {
 	assert.Contains(t, s, "3 turns")
 }
 
+func TestMarkdownFormatterWithPlan(t *testing.T) {
+	t.Parallel()
+
+	f := NewMarkdownFormatter()
+	result := &RunResult{
+		Response: "Done.",
+		Plan: []agentsdk.PlanEntry{
+			{Content: "Write the handler", Status: agentsdk.PlanEntryCompleted},
+			{Content: "Add tests", Status: agentsdk.PlanEntryInProgress},
+			{Content: "Update docs", Status: agentsdk.PlanEntryPending},
+		},
+	}
+
+	out, err := f.Format(result)
+	require.NoError(t, err)
+
+	s := string(out)
+	assert.Contains(t, s, "## Plan")
+	assert.Contains(t, s, "- [x] Write the handler\n- [~] Add tests\n- [ ] Update docs\n")
+}
+
 func TestMarkdownFormatterWithError(t *testing.T) {
 	t.Parallel()
 
@@ -140,6 +163,19 @@ func TestMarkdownFormatterIncludesEvidenceSection(t *testing.T) {
 	assert.Contains(t, s, "Files changed")
 }
 
+func TestMarkdownFormatterIncludesStructuredOutput(t *testing.T) {
+	t.Parallel()
+
+	f := NewMarkdownFormatter()
+	out, err := f.Format(&RunResult{
+		Response:         "Done",
+		StructuredOutput: json.RawMessage(`{"label":"bug"}`),
+		TurnCount:        1,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "## Structured Output\n\n```json\n{\n  \"label\": \"bug\"\n}\n```\n")
+}
+
 func TestMarkdownFormatterIgnoresWhitespaceOnlySummaryAndEvidence(t *testing.T) {
 	t.Parallel()
 
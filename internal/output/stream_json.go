@@ -0,0 +1,121 @@
+// internal/output/stream_json.go
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// StreamEvent is the JSONL wire shape of one TurnEvent in stream-json
+// output. Field names follow the WebSocket transport so consumers can share
+// a decoder; the error is flattened to its message and ExitReason uses its
+// stable lowercase String() form.
+type StreamEvent struct {
+	Type         string                    `json:"type"`
+	Text         string                    `json:"text,omitempty"`
+	Model        string                    `json:"model,omitempty"`
+	ToolCall     *agentsdk.ToolCallEvent   `json:"tool_call,omitempty"`
+	ToolResult   *agentsdk.ToolResultEvent `json:"tool_result,omitempty"`
+	ToolProgress *StreamToolProgress       `json:"tool_progress,omitempty"`
+	UIRequest    *agentsdk.UIRequest       `json:"ui_request,omitempty"`
+	UIUpdate     *agentsdk.UIUpdate        `json:"ui_update,omitempty"`
+	UIResponse   *agentsdk.UIResponse      `json:"ui_response,omitempty"`
+	Plan         []agentsdk.PlanEntry      `json:"plan,omitempty"`
+	Error        string                    `json:"error,omitempty"`
+	InputTokens  int                       `json:"input_tokens,omitempty"`
+	OutputTokens int                       `json:"output_tokens,omitempty"`
+	CostUSD      float64                   `json:"cost_usd,omitempty"`
+	DiffSummary  string                    `json:"diff_summary,omitempty"`
+	ExitReason   string                    `json:"exit_reason,omitempty"`
+}
+
+// StreamToolProgress is the wire shape of a tool_progress payload.
+type StreamToolProgress struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Stage   string `json:"stage"`
+	Content string `json:"content,omitempty"`
+	IsError bool   `json:"is_error,omitempty"`
+}
+
+// NewStreamEvent converts a TurnEvent to its wire shape.
+func NewStreamEvent(evt agentsdk.TurnEvent) StreamEvent {
+	w := StreamEvent{
+		Type:         evt.Type,
+		Text:         evt.Text,
+		Model:        evt.Model,
+		ToolCall:     evt.ToolCall,
+		ToolResult:   evt.ToolResult,
+		UIRequest:    evt.UIRequest,
+		UIUpdate:     evt.UIUpdate,
+		UIResponse:   evt.UIResponse,
+		Plan:         evt.Plan,
+		InputTokens:  evt.InputTokens,
+		OutputTokens: evt.OutputTokens,
+		CostUSD:      evt.CostUSD,
+		DiffSummary:  evt.DiffSummary,
+	}
+	// ExitUnknown on a done event is a bug signal, on any other event noise.
+	if evt.Type == "done" && evt.ExitReason != agentsdk.ExitUnknown {
+		w.ExitReason = evt.ExitReason.String()
+	}
+	if evt.Error != nil {
+		w.Error = evt.Error.Error()
+	}
+	if evt.ToolProgress != nil {
+		w.ToolProgress = &StreamToolProgress{
+			ID:      evt.ToolProgress.ID,
+			Name:    evt.ToolProgress.Name,
+			Stage:   evt.ToolProgress.Stage.String(),
+			Content: evt.ToolProgress.Content,
+			IsError: evt.ToolProgress.IsError,
+		}
+	}
+	return w
+}
+
+// StreamJSONWriter writes TurnEvents as JSON lines while a run is in
+// progress. It is safe for concurrent use.
+type StreamJSONWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewStreamJSONWriter creates a StreamJSONWriter that writes to w.
+func NewStreamJSONWriter(w io.Writer) *StreamJSONWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &StreamJSONWriter{enc: enc}
+}
+
+// WriteEvent writes evt as one line.
+func (s *StreamJSONWriter) WriteEvent(evt agentsdk.TurnEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(NewStreamEvent(evt))
+}
+
+// StreamJSONFormatter outputs RunResult as the closing "result" line of a
+// stream-json run.
+type StreamJSONFormatter struct{}
+
+// NewStreamJSONFormatter creates a new StreamJSONFormatter.
+func NewStreamJSONFormatter() *StreamJSONFormatter {
+	return &StreamJSONFormatter{}
+}
+
+// Format marshals the RunResult as a single JSON line tagged
+// "type":"result".
+func (f *StreamJSONFormatter) Format(result *RunResult) ([]byte, error) {
+	b, err := json.Marshal(struct {
+		Type string `json:"type"`
+		*RunResult
+	}{"result", result})
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,88 @@
+// internal/output/stream_json_test.go
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+func TestStreamJSONWriterWritesOneLinePerEvent(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := NewStreamJSONWriter(&buf)
+	require.NoError(t, w.WriteEvent(agentsdk.TurnEvent{Type: "text_delta", Text: "a <b>"}))
+	require.NoError(t, w.WriteEvent(agentsdk.TurnEvent{
+		Type:     "tool_call",
+		ToolCall: &agentsdk.ToolCallEvent{ID: "t1", Name: "shell", Input: json.RawMessage(`{"command":"ls"}`)},
+	}))
+	require.NoError(t, w.WriteEvent(agentsdk.TurnEvent{
+		Type:         "tool_progress",
+		ToolProgress: &agentsdk.ToolProgressEvent{ID: "t1", Name: "shell", Stage: agentsdk.EventBegin},
+	}))
+	require.NoError(t, w.WriteEvent(agentsdk.TurnEvent{Type: "error", Error: errors.New("boom")}))
+	require.NoError(t, w.WriteEvent(agentsdk.TurnEvent{
+		Type: "done", InputTokens: 10, OutputTokens: 5, CostUSD: 0.25, ExitReason: agentsdk.ExitCompleted,
+	}))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, `{"type":"text_delta","text":"a <b>"}`, lines[0])
+
+	var call map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &call))
+	assert.Equal(t, "tool_call", call["type"])
+	assert.NotNil(t, call["tool_call"])
+
+	var progress StreamEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &progress))
+	require.NotNil(t, progress.ToolProgress)
+	assert.Equal(t, agentsdk.EventBegin.String(), progress.ToolProgress.Stage)
+
+	assert.Equal(t, `{"type":"error","error":"boom"}`, lines[3])
+
+	var done StreamEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[4]), &done))
+	assert.Equal(t, 10, done.InputTokens)
+	assert.Equal(t, 5, done.OutputTokens)
+	assert.Equal(t, 0.25, done.CostUSD)
+	assert.Equal(t, agentsdk.ExitCompleted.String(), done.ExitReason)
+}
+
+func TestStreamEventOmitsExitReasonOutsideDone(t *testing.T) {
+	t.Parallel()
+
+	evt := NewStreamEvent(agentsdk.TurnEvent{Type: "text_delta", ExitReason: agentsdk.ExitCompleted})
+	assert.Empty(t, evt.ExitReason)
+
+	evt = NewStreamEvent(agentsdk.TurnEvent{Type: "done"})
+	assert.Empty(t, evt.ExitReason)
+}
+
+func TestStreamJSONFormatterResultLine(t *testing.T) {
+	t.Parallel()
+
+	out, err := NewStreamJSONFormatter().Format(&RunResult{
+		Prompt:    "say hello",
+		Response:  "Hello!",
+		TurnCount: 1,
+		Mode:      "generic",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(out, []byte("\n")))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, "result", decoded["type"])
+	assert.Equal(t, "say hello", decoded["prompt"])
+	assert.Equal(t, "Hello!", decoded["response"])
+	assert.Equal(t, float64(1), decoded["turn_count"])
+}
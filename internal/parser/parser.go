@@ -7,7 +7,10 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/c"
@@ -22,15 +25,20 @@ import (
 )
 
 // FunctionDef represents a function or method definition found in source code.
+// StartByte and EndByte delimit the whole definition, so callers can tell
+// which function encloses a query match.
 type FunctionDef struct {
 	Name      string
 	StartLine int
 	EndLine   int
+	StartByte int
+	EndByte   int
 }
 
 // langInfo holds tree-sitter language metadata including which node types
 // represent functions and imports for a given programming language.
 type langInfo struct {
+	name           string
 	lang           *sitter.Language
 	funcNodeTypes  []string
 	importNodeType []string
@@ -39,16 +47,19 @@ type langInfo struct {
 // registry maps file extensions to language info for auto-detection.
 var registry = map[string]langInfo{
 	".go": {
+		name:           "go",
 		lang:           golang.GetLanguage(),
 		funcNodeTypes:  []string{"function_declaration", "method_declaration"},
 		importNodeType: []string{"import_declaration"},
 	},
 	".py": {
+		name:           "python",
 		lang:           python.GetLanguage(),
 		funcNodeTypes:  []string{"function_definition"},
 		importNodeType: []string{"import_statement", "import_from_statement"},
 	},
 	".js": {
+		name: "javascript",
 		lang: javascript.GetLanguage(),
 		funcNodeTypes: []string{
 			"function_declaration",
@@ -60,6 +71,7 @@ var registry = map[string]langInfo{
 		importNodeType: []string{"import_statement"},
 	},
 	".ts": {
+		name: "typescript",
 		lang: typescript.GetLanguage(),
 		funcNodeTypes: []string{
 			"function_declaration",
@@ -71,6 +83,7 @@ var registry = map[string]langInfo{
 		importNodeType: []string{"import_statement"},
 	},
 	".tsx": {
+		name: "typescript",
 		lang: typescript.GetLanguage(),
 		funcNodeTypes: []string{
 			"function_declaration",
@@ -82,6 +95,7 @@ var registry = map[string]langInfo{
 		importNodeType: []string{"import_statement"},
 	},
 	".jsx": {
+		name: "javascript",
 		lang: javascript.GetLanguage(),
 		funcNodeTypes: []string{
 			"function_declaration",
@@ -93,42 +107,93 @@ var registry = map[string]langInfo{
 		importNodeType: []string{"import_statement"},
 	},
 	".java": {
+		name:           "java",
 		lang:           java.GetLanguage(),
 		funcNodeTypes:  []string{"method_declaration", "constructor_declaration"},
 		importNodeType: []string{"import_declaration"},
 	},
 	".rs": {
+		name:           "rust",
 		lang:           rust.GetLanguage(),
 		funcNodeTypes:  []string{"function_item"},
 		importNodeType: []string{"use_declaration"},
 	},
 	".rb": {
+		name:           "ruby",
 		lang:           ruby.GetLanguage(),
 		funcNodeTypes:  []string{"method"},
 		importNodeType: []string{"call"}, // require/require_relative calls
 	},
 	".c": {
+		name:           "c",
 		lang:           c.GetLanguage(),
 		funcNodeTypes:  []string{"function_definition"},
 		importNodeType: []string{"preproc_include"},
 	},
 	".h": {
+		name:           "c",
 		lang:           c.GetLanguage(),
 		funcNodeTypes:  []string{"function_definition"},
 		importNodeType: []string{"preproc_include"},
 	},
 	".cc": {
+		name:           "cpp",
 		lang:           cpp.GetLanguage(),
 		funcNodeTypes:  []string{"function_definition"},
 		importNodeType: []string{"preproc_include"},
 	},
 	".cpp": {
+		name:           "cpp",
 		lang:           cpp.GetLanguage(),
 		funcNodeTypes:  []string{"function_definition"},
 		importNodeType: []string{"preproc_include"},
 	},
 }
 
+// LanguageForFile returns the name of the language a file is parsed as
+// (e.g. "go", "typescript", "cpp"), detected from its extension.
+func LanguageForFile(filename string) (string, bool) {
+	info, ok := registry[filepath.Ext(filename)]
+	if !ok {
+		return "", false
+	}
+	return info.name, true
+}
+
+// Languages returns the sorted names of every supported language.
+func Languages() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, info := range registry {
+		if !seen[info.name] {
+			seen[info.name] = true
+			names = append(names, info.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Extensions returns the sorted file extensions the parser recognizes.
+func Extensions() []string {
+	exts := make([]string, 0, len(registry))
+	for ext := range registry {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// languageByName looks up the tree-sitter grammar for a language name.
+func languageByName(name string) (*sitter.Language, bool) {
+	for _, info := range registry {
+		if info.name == name {
+			return info.lang, true
+		}
+	}
+	return nil, false
+}
+
 // Parser parses source files with automatic language detection.
 // It is safe for concurrent use — each call to Parse creates its own tree-sitter
 // parser instance internally.
@@ -182,6 +247,11 @@ func (t *Tree) Close() {
 	}
 }
 
+// Language returns the name of the language the tree was parsed as.
+func (t *Tree) Language() string {
+	return t.info.name
+}
+
 // RootNode returns the root node of the parsed syntax tree.
 func (t *Tree) RootNode() *sitter.Node {
 	return t.tree.RootNode()
@@ -207,6 +277,8 @@ func (t *Tree) Functions() []FunctionDef {
 			Name:      name,
 			StartLine: int(node.StartPoint().Row) + 1, // 0-indexed to 1-indexed
 			EndLine:   int(node.EndPoint().Row) + 1,
+			StartByte: int(node.StartByte()),
+			EndByte:   int(node.EndByte()),
 		})
 	})
 
@@ -240,37 +312,145 @@ type Match struct {
 	EndLine   int
 }
 
+// Capture is a node captured by name in a query match.
+type Capture struct {
+	Name      string
+	Type      string // tree-sitter node type, e.g. "identifier"
+	Text      string
+	StartLine int
+	EndLine   int
+	StartByte int
+	EndByte   int
+}
+
+// QueryMatch groups the captures of one match of a query pattern.
+type QueryMatch struct {
+	Captures []Capture
+}
+
+// Capture returns the first capture with the given name.
+func (m QueryMatch) Capture(name string) (Capture, bool) {
+	for _, c := range m.Captures {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Capture{}, false
+}
+
 // Query runs a tree-sitter S-expression pattern against the parsed tree and
 // returns all captured matches.
 func (t *Tree) Query(pattern string) ([]Match, error) {
-	q, err := sitter.NewQuery([]byte(pattern), t.info.lang)
+	qms, err := t.QueryMatches(pattern)
 	if err != nil {
-		return nil, fmt.Errorf("compile query: %w", err)
+		return nil, err
+	}
+
+	var matches []Match
+	for _, qm := range qms {
+		for _, c := range qm.Captures {
+			matches = append(matches, Match{
+				Text:      c.Text,
+				StartLine: c.StartLine,
+				EndLine:   c.EndLine,
+			})
+		}
+	}
+	return matches, nil
+}
+
+// QueryMatches runs a tree-sitter S-expression pattern against the parsed
+// tree and returns each match with its named captures. The #eq?, #not-eq?,
+// #match? and #not-match? predicates are applied. Compiled queries are
+// cached, so running the same pattern over many files compiles it once.
+func (t *Tree) QueryMatches(pattern string) ([]QueryMatch, error) {
+	q, err := compileQuery(t.info.name, t.info.lang, pattern)
+	if err != nil {
+		return nil, err
 	}
-	defer q.Close()
 
 	cursor := sitter.NewQueryCursor()
 	defer cursor.Close()
 	cursor.Exec(q, t.tree.RootNode())
 
-	var matches []Match
+	var matches []QueryMatch
 	for {
 		m, ok := cursor.NextMatch()
 		if !ok {
 			break
 		}
-		for _, capture := range m.Captures {
-			matches = append(matches, Match{
-				Text:      capture.Node.Content(t.source),
-				StartLine: int(capture.Node.StartPoint().Row) + 1,
-				EndLine:   int(capture.Node.EndPoint().Row) + 1,
+		m = cursor.FilterPredicates(m, t.source)
+		if len(m.Captures) == 0 {
+			continue
+		}
+		qm := QueryMatch{Captures: make([]Capture, 0, len(m.Captures))}
+		for _, c := range m.Captures {
+			qm.Captures = append(qm.Captures, Capture{
+				Name:      q.CaptureNameForId(c.Index),
+				Type:      c.Node.Type(),
+				Text:      c.Node.Content(t.source),
+				StartLine: int(c.Node.StartPoint().Row) + 1,
+				EndLine:   int(c.Node.EndPoint().Row) + 1,
+				StartByte: int(c.Node.StartByte()),
+				EndByte:   int(c.Node.EndByte()),
 			})
 		}
+		matches = append(matches, qm)
 	}
 
 	return matches, nil
 }
 
+// ValidateQuery reports whether pattern compiles for the named language,
+// including the regular expressions of any #match? predicates.
+func ValidateQuery(language, pattern string) error {
+	lang, ok := languageByName(language)
+	if !ok {
+		return fmt.Errorf("unsupported language %q", language)
+	}
+	_, err := compileQuery(language, lang, pattern)
+	return err
+}
+
+type queryKey struct {
+	language string
+	pattern  string
+}
+
+// queryCache holds compiled queries by language and pattern. Compiled
+// queries are immutable, so one can serve any number of cursors at once.
+var queryCache sync.Map // queryKey -> *sitter.Query
+
+func compileQuery(name string, lang *sitter.Language, pattern string) (*sitter.Query, error) {
+	key := queryKey{language: name, pattern: pattern}
+	if q, ok := queryCache.Load(key); ok {
+		return q.(*sitter.Query), nil
+	}
+
+	q, err := sitter.NewQuery([]byte(pattern), lang)
+	if err != nil {
+		return nil, fmt.Errorf("compile query: %w", err)
+	}
+	// FilterPredicates panics on a bad #match? regex, so reject it here.
+	for i := uint32(0); i < q.PatternCount(); i++ {
+		for _, steps := range q.PredicatesForPattern(i) {
+			if len(steps) < 3 || steps[2].Type != sitter.QueryPredicateStepTypeString {
+				continue
+			}
+			switch op := q.StringValueForId(steps[0].ValueId); op {
+			case "match?", "not-match?":
+				if _, err := regexp.Compile(q.StringValueForId(steps[2].ValueId)); err != nil {
+					q.Close()
+					return nil, fmt.Errorf("compile query: #%s: %w", op, err)
+				}
+			}
+		}
+	}
+
+	actual, _ := queryCache.LoadOrStore(key, q)
+	return actual.(*sitter.Query), nil
+}
+
 // walk performs a depth-first traversal of the syntax tree, calling fn for each node.
 func walk(node *sitter.Node, fn func(*sitter.Node)) {
 	if node == nil {
@@ -600,3 +600,101 @@ var x = 42
 	require.NoError(t, err)
 	assert.Empty(t, matches)
 }
+
+func TestQueryMatchesNamedCapturesAndPredicates(t *testing.T) {
+	t.Parallel()
+
+	src := `package main
+
+func main() {
+	exec.Command("ls")
+	os.Open("x")
+}
+`
+	p := NewParser()
+	tree, err := p.Parse("main.go", []byte(src))
+	require.NoError(t, err)
+	defer tree.Close()
+
+	matches, err := tree.QueryMatches(`((call_expression
+  function: (selector_expression operand: (identifier) @pkg)
+  arguments: (argument_list) @args)
+ (#eq? @pkg "exec"))`)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	pkg, ok := matches[0].Capture("pkg")
+	require.True(t, ok)
+	assert.Equal(t, "exec", pkg.Text)
+	assert.Equal(t, "identifier", pkg.Type)
+	assert.Equal(t, 4, pkg.StartLine)
+
+	args, ok := matches[0].Capture("args")
+	require.True(t, ok)
+	assert.Equal(t, `("ls")`, args.Text)
+	assert.Equal(t, args.Text, src[args.StartByte:args.EndByte])
+
+	_, ok = matches[0].Capture("missing")
+	assert.False(t, ok)
+}
+
+func TestQueryMatchesRejectsInvalidRegex(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser()
+	tree, err := p.Parse("main.go", []byte("package main\n"))
+	require.NoError(t, err)
+	defer tree.Close()
+
+	_, err = tree.QueryMatches(`((identifier) @id (#match? @id "[("))`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "compile query")
+}
+
+func TestFunctionsByteRange(t *testing.T) {
+	t.Parallel()
+
+	src := "package main\n\nfunc hello() {}\n"
+	p := NewParser()
+	tree, err := p.Parse("main.go", []byte(src))
+	require.NoError(t, err)
+	defer tree.Close()
+
+	funcs := tree.Functions()
+	require.Len(t, funcs, 1)
+	assert.Equal(t, "func hello() {}", src[funcs[0].StartByte:funcs[0].EndByte])
+}
+
+func TestLanguageForFile(t *testing.T) {
+	t.Parallel()
+
+	for file, want := range map[string]string{
+		"main.go": "go", "app.tsx": "typescript", "app.jsx": "javascript",
+		"lib.h": "c", "lib.cc": "cpp", "Main.java": "java",
+	} {
+		got, ok := LanguageForFile(file)
+		assert.True(t, ok, file)
+		assert.Equal(t, want, got, file)
+	}
+	_, ok := LanguageForFile("main.kt")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"c", "cpp", "go", "java", "javascript", "python", "ruby", "rust", "typescript"}, Languages())
+	assert.Contains(t, Extensions(), ".rb")
+
+	tree, err := NewParser().Parse("main.rs", []byte("fn main() {}"))
+	require.NoError(t, err)
+	defer tree.Close()
+	assert.Equal(t, "rust", tree.Language())
+}
+
+func TestValidateQuery(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateQuery("ruby", "(method name: (identifier) @name)"))
+	assert.Error(t, ValidateQuery("ruby", "(function_declaration) @f"))
+
+	err := ValidateQuery("cobol", "(identifier) @id")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported language")
+}
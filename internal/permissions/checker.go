@@ -200,6 +200,13 @@ func containsCommandPattern(fullCommand, pattern string) bool {
 	return false
 }
 
+// ShellCommandAllowed reports whether every sub-command of command starts
+// with one of the given command prefixes, the way Shell.AllowCommands
+// patterns are matched. An empty command is never allowed.
+func ShellCommandAllowed(command string, prefixes []string) bool {
+	return strings.TrimSpace(command) != "" && len(prefixes) > 0 && allSubCommandsAllowed(command, prefixes)
+}
+
 // allSubCommandsAllowed returns true only if every sub-command in a compound
 // command matches at least one allow pattern. This prevents "go test && rm -rf /"
 // from being auto-approved when only "go test" is in the allow list.
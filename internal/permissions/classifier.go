@@ -56,7 +56,9 @@ type ClassifierTelemetry struct {
 	Stage2Latency time.Duration
 }
 
-// NewYOLOClassifier creates a classifier with the given provider.
+// NewYOLOClassifier creates a classifier with the given provider. Stage-2
+// requests leave Model unset, so callers that bill usage should pass a
+// provider wrapped by agent.UsageMeter.Wrap with the model to record.
 func NewYOLOClassifier(prov agentsdk.LLMProvider, fastMax, slowMax int) *YOLOClassifier {
 	if fastMax <= 0 {
 		fastMax = 64
@@ -0,0 +1,191 @@
+// Package pricing converts provider token usage into US dollar cost using a
+// per-model price table. The built-in table covers common hosted models;
+// config.Config.Pricing overrides or extends it.
+package pricing
+
+import (
+	"strings"
+
+	"github.com/julianshen/rubichan/internal/config"
+)
+
+// Price is the cost of one model in US dollars per million tokens. A zero
+// CacheRead or CacheWrite price bills cache tokens at the Input price, and a
+// zero Reasoning price bills reasoning tokens at the Output price.
+type Price struct {
+	Input      float64
+	Output     float64
+	CacheRead  float64
+	CacheWrite float64
+	Reasoning  float64
+}
+
+// Usage is the token usage of one or more provider calls. Input excludes
+// cache reads and writes, which are counted separately (the Anthropic
+// convention; OpenAI-compatible streams are normalized to it). Reasoning is a
+// subset of Output.
+type Usage struct {
+	Input      int
+	Output     int
+	CacheRead  int
+	CacheWrite int
+	Reasoning  int
+}
+
+// Add returns the element-wise sum of u and o.
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		Input:      u.Input + o.Input,
+		Output:     u.Output + o.Output,
+		CacheRead:  u.CacheRead + o.CacheRead,
+		CacheWrite: u.CacheWrite + o.CacheWrite,
+		Reasoning:  u.Reasoning + o.Reasoning,
+	}
+}
+
+// IsZero reports whether no tokens were used.
+func (u Usage) IsZero() bool {
+	return u == Usage{}
+}
+
+// Cost returns the dollar cost of u at price p.
+func (p Price) Cost(u Usage) float64 {
+	cacheRead, cacheWrite, reasoning := p.CacheRead, p.CacheWrite, p.Reasoning
+	if cacheRead == 0 {
+		cacheRead = p.Input
+	}
+	if cacheWrite == 0 {
+		cacheWrite = p.Input
+	}
+	if reasoning == 0 {
+		reasoning = p.Output
+	}
+	visibleOutput := u.Output - u.Reasoning
+	if visibleOutput < 0 {
+		visibleOutput = 0
+	}
+	return (float64(u.Input)*p.Input +
+		float64(visibleOutput)*p.Output +
+		float64(u.Reasoning)*reasoning +
+		float64(u.CacheRead)*cacheRead +
+		float64(u.CacheWrite)*cacheWrite) / 1_000_000
+}
+
+// defaultPrices are list prices for common hosted models. Dated snapshots
+// (claude-sonnet-4-5-20250929) and -latest aliases match their undated key by
+// prefix. Claude 3.x IDs put the version before the family
+// (claude-3-5-haiku), Claude 4 and later put it after (claude-haiku-4-5).
+var defaultPrices = map[string]Price{
+	"claude-opus-4-5":   {Input: 5.0, Output: 25.0, CacheRead: 0.50, CacheWrite: 6.25},
+	"claude-opus-4-1":   {Input: 15.0, Output: 75.0, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-opus-4":     {Input: 15.0, Output: 75.0, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-sonnet-4-5": {Input: 3.0, Output: 15.0, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-sonnet-4":   {Input: 3.0, Output: 15.0, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-haiku-4-5":  {Input: 1.0, Output: 5.0, CacheRead: 0.10, CacheWrite: 1.25},
+	"claude-3-7-sonnet": {Input: 3.0, Output: 15.0, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-5-sonnet": {Input: 3.0, Output: 15.0, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4.0, CacheRead: 0.08, CacheWrite: 1.0},
+	"claude-3-opus":     {Input: 15.0, Output: 75.0, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.30},
+	"gpt-4o":            {Input: 2.50, Output: 10.0, CacheRead: 1.25},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.60, CacheRead: 0.075},
+	"gpt-4.1":           {Input: 2.0, Output: 8.0, CacheRead: 0.50},
+	"gpt-4.1-mini":      {Input: 0.40, Output: 1.60, CacheRead: 0.10},
+	"o3":                {Input: 2.0, Output: 8.0, CacheRead: 0.50},
+	"o3-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.55},
+	"o4-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.275},
+}
+
+// Table maps model IDs to prices. The zero value has no prices; use Default
+// or NewTable.
+type Table struct {
+	prices map[string]Price
+}
+
+// Default returns a table holding only the built-in prices.
+func Default() *Table {
+	return NewTable(nil)
+}
+
+// NewTable returns the built-in prices with overrides applied on top. An
+// override replaces the built-in entry for the same key entirely.
+func NewTable(overrides map[string]config.ModelPricingConfig) *Table {
+	prices := make(map[string]Price, len(defaultPrices)+len(overrides))
+	for model, p := range defaultPrices {
+		prices[model] = p
+	}
+	for model, p := range overrides {
+		prices[model] = Price{
+			Input:      p.Input,
+			Output:     p.Output,
+			CacheRead:  p.CacheRead,
+			CacheWrite: p.CacheWrite,
+			Reasoning:  p.Reasoning,
+		}
+	}
+	return &Table{prices: prices}
+}
+
+// Lookup returns the price for model. An exact key wins; otherwise the
+// longest key that prefixes model at a "-", ":" or "@" boundary is used, so
+// "claude-sonnet-4-5-20250929" resolves to "claude-sonnet-4-5". A prefix
+// never stretches across a size tier: "gpt-4.1-nano" is not billed as
+// "gpt-4.1" (see sizeTiers). Router-style IDs ("openai/gpt-4o") are retried
+// without their vendor prefix.
+func (t *Table) Lookup(model string) (Price, bool) {
+	if t == nil || model == "" {
+		return Price{}, false
+	}
+	if p, ok := t.lookup(model); ok {
+		return p, true
+	}
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		return t.lookup(model[i+1:])
+	}
+	return Price{}, false
+}
+
+func (t *Table) lookup(model string) (Price, bool) {
+	if p, ok := t.prices[model]; ok {
+		return p, true
+	}
+	best := ""
+	for key := range t.prices {
+		if len(key) <= len(best) || len(key) >= len(model) || !strings.HasPrefix(model, key) {
+			continue
+		}
+		switch model[len(key)] {
+		case '-', ':', '@':
+			if !startsWithSizeTier(model[len(key)+1:]) {
+				best = key
+			}
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t.prices[best], true
+}
+
+// sizeTiers name smaller or larger variants priced differently from their
+// base model, so "o3-mini" must never fall back to "o3".
+var sizeTiers = []string{"mini", "nano", "pro"}
+
+func startsWithSizeTier(rest string) bool {
+	for _, tier := range sizeTiers {
+		if rest == tier || strings.HasPrefix(rest, tier+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// Cost returns the dollar cost of u on model, or 0 when the model has no
+// known price (local models, or hosted ones missing from the table).
+func (t *Table) Cost(model string, u Usage) float64 {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return 0
+	}
+	return p.Cost(u)
+}
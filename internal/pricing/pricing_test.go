@@ -0,0 +1,133 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceCost(t *testing.T) {
+	p := Price{Input: 3.0, Output: 15.0, CacheRead: 0.30, CacheWrite: 3.75}
+
+	assert.InDelta(t, 18.0, p.Cost(Usage{Input: 1_000_000, Output: 1_000_000}), 1e-9)
+	assert.InDelta(t, 0.30, p.Cost(Usage{CacheRead: 1_000_000}), 1e-9)
+	assert.InDelta(t, 3.75, p.Cost(Usage{CacheWrite: 1_000_000}), 1e-9)
+	assert.Zero(t, p.Cost(Usage{}))
+}
+
+func TestPriceCostFallbacks(t *testing.T) {
+	p := Price{Input: 2.0, Output: 8.0}
+
+	// Unset cache prices bill at the input rate.
+	assert.InDelta(t, 4.0, p.Cost(Usage{CacheRead: 1_000_000, CacheWrite: 1_000_000}), 1e-9)
+	// Unset reasoning price bills reasoning at the output rate, so splitting
+	// output into reasoning does not change the total.
+	assert.InDelta(t, 8.0, p.Cost(Usage{Output: 1_000_000, Reasoning: 600_000}), 1e-9)
+}
+
+func TestPriceCostReasoningRate(t *testing.T) {
+	p := Price{Input: 2.0, Output: 8.0, Reasoning: 4.0}
+	// 400k visible output at 8.0 + 600k reasoning at 4.0.
+	assert.InDelta(t, 3.2+2.4, p.Cost(Usage{Output: 1_000_000, Reasoning: 600_000}), 1e-9)
+}
+
+func TestTableLookup(t *testing.T) {
+	table := Default()
+
+	tests := []struct {
+		model string
+		want  string // key whose price should be returned; "" = unknown
+	}{
+		{"claude-sonnet-4-5", "claude-sonnet-4-5"},
+		{"claude-sonnet-4-5-20250929", "claude-sonnet-4-5"},
+		{"claude-sonnet-4-20250514", "claude-sonnet-4"},
+		{"gpt-4o-mini", "gpt-4o-mini"},
+		{"gpt-4o-2024-08-06", "gpt-4o"},
+		{"openai/gpt-4o", "gpt-4o"},
+		{"anthropic/claude-haiku-4-5", "claude-haiku-4-5"},
+		{"o3-mini", "o3-mini"},
+		{"o3-mini-2025-01-31", "o3-mini"},
+		{"o3-2025-04-16", "o3"},
+		{"gpt-4.1-nano", ""},
+		{"o3-pro", ""},
+		{"gpt-4oxyz", ""},
+		{"llama3:8b", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := table.Lookup(tt.model)
+			if tt.want == "" {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, defaultPrices[tt.want], got)
+		})
+	}
+}
+
+func TestTableLookupAnthropicModelIDs(t *testing.T) {
+	table := Default()
+
+	// Real model IDs as the Anthropic API reports them, with their list
+	// prices in dollars per million input and output tokens.
+	tests := []struct {
+		model         string
+		input, output float64
+	}{
+		{"claude-opus-4-5-20251101", 5.0, 25.0},
+		{"claude-opus-4-1-20250805", 15.0, 75.0},
+		{"claude-opus-4-20250514", 15.0, 75.0},
+		{"claude-sonnet-4-5-20250929", 3.0, 15.0},
+		{"claude-sonnet-4-20250514", 3.0, 15.0},
+		{"claude-haiku-4-5-20251001", 1.0, 5.0},
+		{"claude-3-7-sonnet-20250219", 3.0, 15.0},
+		{"claude-3-7-sonnet-latest", 3.0, 15.0},
+		{"claude-3-5-sonnet-20241022", 3.0, 15.0},
+		{"claude-3-5-sonnet-20240620", 3.0, 15.0},
+		{"claude-3-5-haiku-20241022", 0.80, 4.0},
+		{"claude-3-5-haiku-latest", 0.80, 4.0},
+		{"claude-3-opus-20240229", 15.0, 75.0},
+		{"claude-3-haiku-20240307", 0.25, 1.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := table.Lookup(tt.model)
+			assert.True(t, ok)
+			assert.Equal(t, tt.input, got.Input)
+			assert.Equal(t, tt.output, got.Output)
+		})
+	}
+}
+
+func TestNewTableOverrides(t *testing.T) {
+	table := NewTable(map[string]config.ModelPricingConfig{
+		"gpt-4o":     {Input: 1.0, Output: 2.0},
+		"qwen2.5:7b": {Input: 0.01, Output: 0.02},
+	})
+
+	p, ok := table.Lookup("gpt-4o")
+	assert.True(t, ok)
+	assert.Equal(t, Price{Input: 1.0, Output: 2.0}, p, "override replaces the built-in entry")
+
+	assert.InDelta(t, 0.03, table.Cost("qwen2.5:7b", Usage{Input: 1_000_000, Output: 1_000_000}), 1e-9)
+
+	// Built-ins not overridden are kept.
+	_, ok = table.Lookup("claude-sonnet-4-5")
+	assert.True(t, ok)
+}
+
+func TestTableCostUnknownModel(t *testing.T) {
+	assert.Zero(t, Default().Cost("mystery-model", Usage{Input: 1000, Output: 1000}))
+	var nilTable *Table
+	assert.Zero(t, nilTable.Cost("gpt-4o", Usage{Input: 1000}))
+}
+
+func TestUsageAdd(t *testing.T) {
+	a := Usage{Input: 1, Output: 2, CacheRead: 3, CacheWrite: 4, Reasoning: 5}
+	assert.Equal(t, Usage{Input: 2, Output: 4, CacheRead: 6, CacheWrite: 8, Reasoning: 10}, a.Add(a))
+	assert.True(t, Usage{}.IsZero())
+	assert.False(t, a.IsZero())
+}
@@ -0,0 +1,42 @@
+package anthropic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderDef_BaseURLAndAuth(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "test-anthropic-key")
+
+	def := providerDef()
+	cfg := config.DefaultConfig()
+
+	assert.Equal(t, "https://api.anthropic.com", def.BaseURL(cfg))
+
+	apiKey, headers, err := def.Auth(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "test-anthropic-key", apiKey)
+	assert.Nil(t, headers)
+}
+
+func TestProviderDef_AuthMissingKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	def := providerDef()
+	cfg := config.DefaultConfig()
+
+	_, _, err := def.Auth(cfg)
+	require.Error(t, err)
+}
+
+func TestProviderDef_DefaultModel(t *testing.T) {
+	def := providerDef()
+
+	model, err := def.DefaultModel(context.Background(), config.DefaultConfig())
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-5", model)
+}
@@ -15,8 +15,9 @@ func NewProvider(cfg *config.Config) (LLMProvider, error) {
 
 // NewProviderWithDebug creates an LLMProvider and optionally enables debug
 // logging of HTTP request/response details to stderr via log.Printf.
+// A configured failover chain is wrapped around the primary provider.
 func NewProviderWithDebug(cfg *config.Config, debug bool) (LLMProvider, error) {
-	p, err := Default.New(cfg)
+	p, err := Default.NewChain(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("creating provider: %w", err)
 	}
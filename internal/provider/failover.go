@@ -0,0 +1,309 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/agent/errorclass"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// Failover defaults used when the config leaves the fields unset.
+const (
+	DefaultFailoverThreshold = 3
+	DefaultFailoverCooldown  = 60 * time.Second
+)
+
+// ChainEntry is one link in a failover chain. The first entry serves the
+// request's own model whenever one is set, so the primary keeps honoring
+// per-request overrides such as WithFallbackModel and summary models;
+// secondaries always use their configured Model.
+type ChainEntry struct {
+	Name     string
+	Model    string
+	Provider LLMProvider
+}
+
+// ActiveProvider describes the chain entry that served the most recent
+// successful call, and why the chain moved to it.
+type ActiveProvider struct {
+	Name     string
+	Model    string
+	Previous string // "provider/model" active before the latest switch
+	Reason   string // why the chain moved here; empty until the first switch
+	Switches int    // total switches so far; lets pollers detect a change
+}
+
+// Label renders the entry as "provider/model", or just the provider when the
+// model is unset.
+func (a ActiveProvider) Label() string {
+	if a.Model == "" {
+		return a.Name
+	}
+	return a.Name + "/" + a.Model
+}
+
+// ActiveProviderReporter is implemented by providers that may route a call
+// to a different backend than the configured one. The agent loop polls it
+// after each call to emit a provider_switch TurnEvent when it changes.
+type ActiveProviderReporter interface {
+	ActiveProvider() ActiveProvider
+}
+
+// FailoverProvider tries an ordered chain of providers, skipping ones whose
+// circuit breaker is open. A provider's circuit opens after Threshold
+// consecutive provider-side failures and stays open for Cooldown; the next
+// call after that probes it again, and a success closes the circuit. Because
+// the chain is always walked in order, a recovered primary is re-promoted
+// automatically.
+type FailoverProvider struct {
+	entries   []ChainEntry
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	breakers []breakerState
+	active   ActiveProvider
+}
+
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+}
+
+// NewFailoverProvider builds a chain from entries. threshold <= 0 and
+// cooldown <= 0 fall back to the package defaults.
+func NewFailoverProvider(entries []ChainEntry, threshold int, cooldown time.Duration) *FailoverProvider {
+	if threshold <= 0 {
+		threshold = DefaultFailoverThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultFailoverCooldown
+	}
+	fp := &FailoverProvider{
+		entries:   entries,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		breakers:  make([]breakerState, len(entries)),
+	}
+	if len(entries) > 0 {
+		fp.active = ActiveProvider{Name: entries[0].Name, Model: entries[0].Model}
+	}
+	return fp
+}
+
+// ActiveProvider returns the entry that served the most recent successful call.
+func (f *FailoverProvider) ActiveProvider() ActiveProvider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.active
+}
+
+// SetDebugLogger forwards the logger to every chain member that accepts one.
+func (f *FailoverProvider) SetDebugLogger(logger DebugLogger) {
+	for _, e := range f.entries {
+		if dlc, ok := e.Provider.(DebugLogConfigurer); ok {
+			dlc.SetDebugLogger(logger)
+		}
+	}
+}
+
+// Stream sends req to the first healthy provider in the chain, moving down
+// the chain on provider-side failures. Request-side failures (context
+// overflow, invalid request, content filtered) are returned immediately: a
+// different vendor would reject the same request. When every circuit is
+// open the chain is still tried in order rather than failing unattempted.
+//
+// Throttling and overload (see isTransient) usually clear within seconds,
+// and callers such as the agent's turn retry back off and call again. So a
+// transient error is handed back to the caller, keeping the same provider
+// first in line, until its consecutive failures reach the threshold; only
+// then does the chain move on.
+func (f *FailoverProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamEvent, error) {
+	order := f.callOrder()
+	var errs []error
+	for _, i := range order {
+		entry := f.entries[i]
+		entryReq := f.requestFor(i, req)
+		ch, err := entry.Provider.Stream(ctx, entryReq)
+		if err == nil {
+			f.markActive(i, errors.Join(errs...))
+			return f.watch(ctx, i, ch), nil
+		}
+		if ctx.Err() != nil || !ShouldFailover(err) {
+			return nil, err
+		}
+		if opened := f.recordFailure(i); !opened && isTransient(err) {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", entry.Name, err))
+	}
+	if len(errs) == 1 {
+		return nil, errors.Unwrap(errs[0])
+	}
+	return nil, fmt.Errorf("all providers in failover chain failed: %w", errors.Join(errs...))
+}
+
+// callOrder returns chain indexes with closed (or half-open) circuits first,
+// in chain order, followed by open ones.
+func (f *FailoverProvider) callOrder() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	healthy := make([]int, 0, len(f.entries))
+	var open []int
+	for i := range f.entries {
+		if now.Before(f.breakers[i].openUntil) {
+			open = append(open, i)
+			continue
+		}
+		healthy = append(healthy, i)
+	}
+	return append(healthy, open...)
+}
+
+// requestFor rewrites req for chain entry i. Secondary entries get their own
+// model and a capability set detected for that provider+model, keeping only
+// the caller's reasoning effort.
+func (f *FailoverProvider) requestFor(i int, req CompletionRequest) CompletionRequest {
+	entry := f.entries[i]
+	if entry.Model == "" || (i == 0 && req.Model != "") {
+		return req
+	}
+	out := req
+	out.Model = entry.Model
+	caps := DetectCapabilities(entry.Name, entry.Model)
+	caps.ReasoningEffort = req.Capabilities.ReasoningEffort
+	out.Capabilities = caps
+	return out
+}
+
+func (f *FailoverProvider) markActive(i int, cause error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	model := f.entries[i].Model
+	if f.active.Name == f.entries[i].Name && f.active.Model == model {
+		return
+	}
+	var reason string
+	switch {
+	case cause != nil:
+		reason = cause.Error()
+	case i == 0:
+		reason = "primary provider recovered"
+	default:
+		reason = "earlier providers unavailable"
+	}
+	f.active = ActiveProvider{
+		Name:     f.entries[i].Name,
+		Model:    model,
+		Previous: f.active.Label(),
+		Reason:   reason,
+		Switches: f.active.Switches + 1,
+	}
+}
+
+// recordFailure counts a provider-side failure against entry i and reports
+// whether its circuit is now open.
+func (f *FailoverProvider) recordFailure(i int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b := &f.breakers[i]
+	b.failures++
+	if b.failures >= f.threshold {
+		b.openUntil = f.now().Add(f.cooldown)
+		return true
+	}
+	return false
+}
+
+// watch forwards ch, counting a mid-stream provider-side error against entry
+// i's breaker so the next call skips a backend that dies after connecting.
+// Only a stream that ends without one resets the count: connecting alone
+// says nothing about whether the backend can finish a response.
+func (f *FailoverProvider) watch(ctx context.Context, i int, ch <-chan StreamEvent) <-chan StreamEvent {
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+		failed := false
+		for ev := range ch {
+			if ev.Type == agentsdk.EventError && ev.Error != nil && ShouldFailover(ev.Error) {
+				f.recordFailure(i)
+				failed = true
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if !failed {
+			f.recordSuccess(i)
+		}
+	}()
+	return out
+}
+
+// recordSuccess closes entry i's circuit and clears its failure count.
+func (f *FailoverProvider) recordSuccess(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.breakers[i] = breakerState{}
+}
+
+// ShouldFailover reports whether err indicates the provider, rather than
+// the request, is at fault. It consults agentsdk.ProviderErrorClassifier
+// first and falls back to errorclass string classification; unclassified
+// errors (network failures, timeouts) count as provider-side.
+func ShouldFailover(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var classified agentsdk.ProviderErrorClassifier
+	if errors.As(err, &classified) {
+		switch classified.ProviderErrorKind() {
+		case ErrContextOverflow.String(), ErrInvalidRequest.String(), ErrContentFiltered.String():
+			return false
+		default:
+			return true
+		}
+	}
+	switch errorclass.Classify(err) {
+	case errorclass.ClassPromptTooLong, errorclass.ClassMaxOutputTokens, errorclass.ClassMediaSize:
+		return false
+	}
+	return true
+}
+
+// isTransient reports whether err is throttling or overload (429, 503, 529)
+// rather than an outage, so the same provider is worth retrying after a
+// backoff before the chain gives up on it.
+func isTransient(err error) bool {
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		return pe.Kind == ErrRateLimited || pe.StatusCode == 503 || pe.StatusCode == 529
+	}
+	var classified agentsdk.ProviderErrorClassifier
+	if errors.As(err, &classified) {
+		return classified.ProviderErrorKind() == ErrRateLimited.String()
+	}
+	return errorclass.Classify(err) == errorclass.ClassModelOverloaded
+}
+
+// String renders the chain as "anthropic → openrouter/model → ...", for logs.
+func (f *FailoverProvider) String() string {
+	parts := make([]string, len(f.entries))
+	for i, e := range f.entries {
+		parts[i] = e.Name
+		if e.Model != "" {
+			parts[i] += "/" + e.Model
+		}
+	}
+	return strings.Join(parts, " → ")
+}
@@ -0,0 +1,374 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider returns errs in order, then succeeds with a single
+// text_delta and stop event. It records every request it receives.
+type scriptedProvider struct {
+	errs   []error
+	events []StreamEvent
+	reqs   []CompletionRequest
+}
+
+func (s *scriptedProvider) Stream(_ context.Context, req CompletionRequest) (<-chan StreamEvent, error) {
+	s.reqs = append(s.reqs, req)
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	events := s.events
+	if events == nil {
+		events = []StreamEvent{{Type: "text_delta", Text: "ok"}, {Type: "stop"}}
+	}
+	ch := make(chan StreamEvent, len(events))
+	for _, ev := range events {
+		ch <- ev
+	}
+	close(ch)
+	return ch, nil
+}
+
+func serverErr(name string) error {
+	return &ProviderError{Kind: ErrServerError, Provider: name, Message: "503"}
+}
+
+func drain(ch <-chan StreamEvent) []StreamEvent {
+	var out []StreamEvent
+	for ev := range ch {
+		out = append(out, ev)
+	}
+	return out
+}
+
+func newTestChain(primary, secondary *scriptedProvider) *FailoverProvider {
+	return NewFailoverProvider([]ChainEntry{
+		{Name: "anthropic", Model: "claude-sonnet-4-5", Provider: primary},
+		{Name: "openrouter", Model: "gpt-4o", Provider: secondary},
+	}, 1, time.Minute)
+}
+
+func TestFailoverProviderUsesPrimaryWhenHealthy(t *testing.T) {
+	primary := &scriptedProvider{}
+	secondary := &scriptedProvider{}
+	fp := newTestChain(primary, secondary)
+
+	ch, err := fp.Stream(context.Background(), CompletionRequest{Model: "claude-sonnet-4-5"})
+	require.NoError(t, err)
+	drain(ch)
+
+	assert.Len(t, primary.reqs, 1)
+	assert.Empty(t, secondary.reqs)
+	active := fp.ActiveProvider()
+	assert.Equal(t, "anthropic", active.Name)
+	assert.Zero(t, active.Switches)
+}
+
+func TestFailoverProviderFailsOverOnServerError(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{serverErr("anthropic")}}
+	secondary := &scriptedProvider{}
+	fp := newTestChain(primary, secondary)
+
+	ch, err := fp.Stream(context.Background(), CompletionRequest{Model: "claude-sonnet-4-5"})
+	require.NoError(t, err)
+	events := drain(ch)
+	require.NotEmpty(t, events)
+	assert.Equal(t, "ok", events[0].Text)
+
+	require.Len(t, secondary.reqs, 1)
+	assert.Equal(t, "gpt-4o", secondary.reqs[0].Model)
+
+	active := fp.ActiveProvider()
+	assert.Equal(t, "openrouter/gpt-4o", active.Label())
+	assert.Equal(t, "anthropic/claude-sonnet-4-5", active.Previous)
+	assert.Equal(t, 1, active.Switches)
+	assert.Contains(t, active.Reason, "server error")
+}
+
+func TestFailoverProviderSecondaryGetsOwnCapabilities(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{serverErr("anthropic")}}
+	secondary := &scriptedProvider{}
+	fp := newTestChain(primary, secondary)
+
+	req := CompletionRequest{Model: "claude-sonnet-4-5"}
+	req.Capabilities = DetectCapabilities("anthropic", "claude-sonnet-4-5")
+	req.Capabilities.ReasoningEffort = "high"
+	ch, err := fp.Stream(context.Background(), req)
+	require.NoError(t, err)
+	drain(ch)
+
+	require.Len(t, secondary.reqs, 1)
+	want := DetectCapabilities("openrouter", "gpt-4o")
+	want.ReasoningEffort = "high"
+	assert.Equal(t, want, secondary.reqs[0].Capabilities)
+}
+
+func TestFailoverProviderDoesNotFailOverOnRequestErrors(t *testing.T) {
+	for _, kind := range []ErrorKind{ErrContextOverflow, ErrInvalidRequest, ErrContentFiltered} {
+		t.Run(kind.String(), func(t *testing.T) {
+			reqErr := &ProviderError{Kind: kind, Provider: "anthropic", Message: "rejected"}
+			primary := &scriptedProvider{errs: []error{reqErr}}
+			secondary := &scriptedProvider{}
+			fp := newTestChain(primary, secondary)
+
+			_, err := fp.Stream(context.Background(), CompletionRequest{})
+			require.Error(t, err)
+			assert.ErrorIs(t, err, reqErr)
+			assert.Empty(t, secondary.reqs)
+		})
+	}
+}
+
+func TestFailoverProviderOpenCircuitSkipsPrimary(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{serverErr("anthropic")}}
+	secondary := &scriptedProvider{}
+	fp := newTestChain(primary, secondary)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fp.now = func() time.Time { return now }
+
+	ch, err := fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+
+	now = now.Add(30 * time.Second)
+	ch, err = fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+
+	assert.Len(t, primary.reqs, 1, "open circuit should skip the primary")
+	assert.Len(t, secondary.reqs, 2)
+	assert.Equal(t, 1, fp.ActiveProvider().Switches)
+}
+
+func TestFailoverProviderRepromotesPrimaryAfterCooldown(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{serverErr("anthropic")}}
+	secondary := &scriptedProvider{}
+	fp := newTestChain(primary, secondary)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fp.now = func() time.Time { return now }
+
+	ch, err := fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+
+	now = now.Add(2 * time.Minute)
+	ch, err = fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+
+	assert.Len(t, primary.reqs, 2)
+	active := fp.ActiveProvider()
+	assert.Equal(t, "anthropic", active.Name)
+	assert.Equal(t, "primary provider recovered", active.Reason)
+	assert.Equal(t, 2, active.Switches)
+}
+
+func TestFailoverProviderThresholdDelaysOpening(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{serverErr("anthropic")}}
+	secondary := &scriptedProvider{}
+	fp := NewFailoverProvider([]ChainEntry{
+		{Name: "anthropic", Provider: primary},
+		{Name: "ollama", Model: "llama3", Provider: secondary},
+	}, 2, time.Minute)
+
+	ch, err := fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+
+	// One failure is below the threshold, so the primary is tried again.
+	ch, err = fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+
+	assert.Len(t, primary.reqs, 2)
+	assert.Equal(t, "anthropic", fp.ActiveProvider().Name)
+}
+
+func TestFailoverProviderAllFailed(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{serverErr("anthropic")}}
+	secondary := &scriptedProvider{errs: []error{fmt.Errorf("connection refused")}}
+	fp := newTestChain(primary, secondary)
+
+	_, err := fp.Stream(context.Background(), CompletionRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all providers in failover chain failed")
+	assert.Contains(t, err.Error(), "anthropic:")
+	assert.Contains(t, err.Error(), "openrouter: connection refused")
+
+	var pe *ProviderError
+	assert.True(t, errors.As(err, &pe), "joined error should still expose the ProviderError")
+}
+
+func TestFailoverProviderCountsMidStreamErrors(t *testing.T) {
+	primary := &scriptedProvider{events: []StreamEvent{
+		{Type: "text_delta", Text: "par"},
+		{Type: "error", Error: serverErr("anthropic")},
+	}}
+	secondary := &scriptedProvider{}
+	fp := newTestChain(primary, secondary)
+
+	ch, err := fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	events := drain(ch)
+	require.Len(t, events, 2)
+	assert.Equal(t, "error", events[1].Type)
+
+	ch, err = fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+	assert.Len(t, primary.reqs, 1, "mid-stream failure should open the primary's circuit")
+	assert.Len(t, secondary.reqs, 1)
+}
+
+func TestFailoverProviderOpensAfterRepeatedMidStreamErrors(t *testing.T) {
+	primary := &scriptedProvider{events: []StreamEvent{
+		{Type: "text_delta", Text: "par"},
+		{Type: "error", Error: serverErr("anthropic")},
+	}}
+	secondary := &scriptedProvider{}
+	fp := NewFailoverProvider([]ChainEntry{
+		{Name: "anthropic", Provider: primary},
+		{Name: "ollama", Model: "llama3", Provider: secondary},
+	}, DefaultFailoverThreshold, time.Minute)
+
+	// Each stream connects, so only the mid-stream errors count; a connect
+	// must not wipe the failures recorded before it.
+	for range DefaultFailoverThreshold {
+		ch, err := fp.Stream(context.Background(), CompletionRequest{})
+		require.NoError(t, err)
+		drain(ch)
+	}
+	assert.Empty(t, secondary.reqs)
+
+	ch, err := fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+	assert.Len(t, primary.reqs, DefaultFailoverThreshold, "the circuit opens after the threshold-th failed stream")
+	assert.Len(t, secondary.reqs, 1)
+}
+
+func TestFailoverProviderCleanStreamResetsMidStreamCount(t *testing.T) {
+	failing := []StreamEvent{{Type: "error", Error: serverErr("anthropic")}}
+	primary := &scriptedProvider{events: failing}
+	secondary := &scriptedProvider{}
+	fp := NewFailoverProvider([]ChainEntry{
+		{Name: "anthropic", Provider: primary},
+		{Name: "ollama", Model: "llama3", Provider: secondary},
+	}, 2, time.Minute)
+
+	stream := func(events []StreamEvent) {
+		primary.events = events
+		ch, err := fp.Stream(context.Background(), CompletionRequest{})
+		require.NoError(t, err)
+		drain(ch)
+	}
+	stream(failing)
+	stream(nil)
+	stream(failing)
+	stream(nil)
+	assert.Len(t, primary.reqs, 4, "a clean stream in between resets the consecutive count")
+	assert.Empty(t, secondary.reqs)
+}
+
+func TestFailoverProviderCanceledContext(t *testing.T) {
+	primary := &scriptedProvider{errs: []error{context.Canceled}}
+	secondary := &scriptedProvider{}
+	fp := newTestChain(primary, secondary)
+
+	_, err := fp.Stream(context.Background(), CompletionRequest{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, secondary.reqs)
+}
+
+func TestShouldFailover(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", context.Canceled, false},
+		{"server error", serverErr("x"), true},
+		{"rate limited", &ProviderError{Kind: ErrRateLimited}, true},
+		{"auth failed", &ProviderError{Kind: ErrAuthFailed}, true},
+		{"context overflow", &ProviderError{Kind: ErrContextOverflow}, false},
+		{"invalid request", &ProviderError{Kind: ErrInvalidRequest}, false},
+		{"content filtered", &ProviderError{Kind: ErrContentFiltered}, false},
+		{"wrapped overflow", fmt.Errorf("stream: %w", &ProviderError{Kind: ErrContextOverflow}), false},
+		{"network", errors.New("dial tcp: connection refused"), true},
+		{"prompt too long string", errors.New("prompt is too long: 250000 tokens"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ShouldFailover(tt.err))
+		})
+	}
+}
+
+func TestFailoverProviderString(t *testing.T) {
+	fp := NewFailoverProvider([]ChainEntry{
+		{Name: "anthropic"},
+		{Name: "openrouter", Model: "gpt-4o"},
+	}, 0, 0)
+	assert.Equal(t, "anthropic → openrouter/gpt-4o", fp.String())
+	assert.Equal(t, DefaultFailoverThreshold, fp.threshold)
+	assert.Equal(t, DefaultFailoverCooldown, fp.cooldown)
+}
+
+// TestFailoverProviderRetriesPrimaryOnTransientErrors pins the interaction
+// with the agent's turn retry, which wraps the whole chain: a single 429 must
+// not shunt the call to a secondary and open the primary's circuit. The
+// error goes back to the caller, which backs off and calls the primary again,
+// until consecutive transient failures reach the threshold.
+func TestFailoverProviderRetriesPrimaryOnTransientErrors(t *testing.T) {
+	limited := &ProviderError{Kind: ErrRateLimited, Provider: "anthropic", StatusCode: 429, Message: "slow down"}
+	primary := &scriptedProvider{errs: []error{limited, limited, limited}}
+	secondary := &scriptedProvider{}
+	fp := NewFailoverProvider([]ChainEntry{
+		{Name: "anthropic", Provider: primary},
+		{Name: "ollama", Model: "llama3", Provider: secondary},
+	}, 0, time.Minute)
+
+	for range DefaultFailoverThreshold - 1 {
+		_, err := fp.Stream(context.Background(), CompletionRequest{})
+		require.ErrorIs(t, err, limited)
+	}
+	assert.Empty(t, secondary.reqs, "transient errors below the threshold stay on the primary")
+
+	ch, err := fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+	assert.Len(t, primary.reqs, DefaultFailoverThreshold)
+	assert.Len(t, secondary.reqs, 1, "the threshold-th transient failure opens the circuit")
+	assert.Equal(t, "ollama", fp.ActiveProvider().Name)
+}
+
+func TestFailoverProviderTransientSuccessResetsCount(t *testing.T) {
+	overloaded := &ProviderError{Kind: ErrServerError, Provider: "anthropic", StatusCode: 529, Message: "overloaded"}
+	primary := &scriptedProvider{errs: []error{overloaded, nil, overloaded}}
+	secondary := &scriptedProvider{}
+	fp := NewFailoverProvider([]ChainEntry{
+		{Name: "anthropic", Provider: primary},
+		{Name: "ollama", Model: "llama3", Provider: secondary},
+	}, 2, time.Minute)
+
+	_, err := fp.Stream(context.Background(), CompletionRequest{})
+	require.Error(t, err)
+	ch, err := fp.Stream(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	drain(ch)
+	_, err = fp.Stream(context.Background(), CompletionRequest{})
+	require.Error(t, err, "a success in between resets the consecutive count")
+	assert.Empty(t, secondary.reqs)
+}
@@ -0,0 +1,106 @@
+package ollama
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/testutil"
+	ollamapkg "github.com/julianshen/rubichan/pkg/provider/ollama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveDefaultModel_SingleModel(t *testing.T) {
+	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"models": [{"name": "llama3.2:latest", "size": 4294967296}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Ollama.BaseURL = srv.URL
+
+	model, err := resolveDefaultModel(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "llama3.2:latest", model)
+}
+
+func TestResolveDefaultModel_NoModels(t *testing.T) {
+	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"models": []}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Ollama.BaseURL = srv.URL
+
+	_, err := resolveDefaultModel(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no models found")
+}
+
+func TestResolveDefaultModel_MultipleModels(t *testing.T) {
+	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"models": [
+			{"name": "llama3.2:latest", "size": 4294967296},
+			{"name": "codellama:7b", "size": 3758096384}
+		]}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Ollama.BaseURL = srv.URL
+
+	model, err := resolveDefaultModel(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "llama3.2:latest", model) // returns first model
+}
+
+func TestResolveDefaultModel_ConnectionError(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Provider.Ollama.BaseURL = "http://localhost:1"
+
+	_, err := resolveDefaultModel(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "listing Ollama models")
+}
+
+func TestListModels(t *testing.T) {
+	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"models": [{"name": "llama3.2:latest", "size": 1}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Ollama.BaseURL = srv.URL
+
+	models, err := listModels(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Model{{ID: "llama3.2:latest", Name: "llama3.2:latest"}}, models)
+}
+
+func TestProviderDef_BaseURLDefault(t *testing.T) {
+	def := providerDef()
+	cfg := config.DefaultConfig()
+
+	assert.Equal(t, ollamapkg.DefaultBaseURL, def.BaseURL(cfg))
+}
+
+func TestProviderDef_BaseURLOverride(t *testing.T) {
+	def := providerDef()
+	cfg := config.DefaultConfig()
+	cfg.Provider.Ollama.BaseURL = "http://custom-ollama:1234"
+
+	assert.Equal(t, "http://custom-ollama:1234", def.BaseURL(cfg))
+}
+
+func TestProviderDef_AuthIsKeyless(t *testing.T) {
+	def := providerDef()
+
+	apiKey, headers, err := def.Auth(config.DefaultConfig())
+	require.NoError(t, err)
+	assert.Empty(t, apiKey)
+	assert.Nil(t, headers)
+}
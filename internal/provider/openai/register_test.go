@@ -0,0 +1,64 @@
+package openai
+
+import (
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/internal/provider"
+	openaipkg "github.com/julianshen/rubichan/pkg/provider/openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderDef_BaseURLAndAuth(t *testing.T) {
+	def := providerDef()
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "openrouter"
+	cfg.Provider.OpenAI = []config.OpenAICompatibleConfig{
+		{Name: "openrouter", BaseURL: "https://openrouter.ai/api/v1", APIKeySource: "config", APIKey: "test-key", ExtraHeaders: map[string]string{"X-Test": "1"}},
+	}
+
+	assert.Equal(t, "https://openrouter.ai/api/v1", def.BaseURL(cfg))
+
+	apiKey, headers, err := def.Auth(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "test-key", apiKey)
+	assert.Equal(t, map[string]string{"X-Test": "1"}, headers)
+}
+
+func TestProviderDef_AuthUnknownProvider(t *testing.T) {
+	def := providerDef()
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "does-not-exist"
+
+	_, _, err := def.Auth(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"does-not-exist"`)
+}
+
+func TestProviderDef_DefaultModelIsNil(t *testing.T) {
+	def := providerDef()
+	assert.Nil(t, def.DefaultModel)
+}
+
+func TestProviderDefConfigureStreamUsageOptOut(t *testing.T) {
+	off := false
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "strict-proxy"
+	cfg.Provider.OpenAI = []config.OpenAICompatibleConfig{
+		{Name: "strict-proxy", BaseURL: "http://localhost:1234/v1", APIKeySource: "config", APIKey: "none", StreamUsage: &off},
+	}
+	reg := provider.NewRegistry()
+	reg.RegisterFallback(providerDef())
+
+	p, err := reg.New(cfg)
+	require.NoError(t, err)
+	op, ok := p.(*openaipkg.Provider)
+	require.True(t, ok)
+	assert.False(t, op.StreamUsage())
+
+	cfg.Provider.OpenAI[0].StreamUsage = nil
+	p, err = reg.New(cfg)
+	require.NoError(t, err)
+	assert.True(t, p.(*openaipkg.Provider).StreamUsage(), "usage is requested by default")
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	publicprovider "github.com/julianshen/rubichan/pkg/provider"
+)
+
+// Aliases for the provider building blocks that live in pkg/provider, so
+// embedders and this core share one implementation of error
+// classification, retry, capability detection and stream watchdogs.
+
+type ErrorKind = publicprovider.ErrorKind
+type ProviderError = publicprovider.ProviderError
+type RetryContext = publicprovider.RetryContext
+type RetryConfig = publicprovider.RetryConfig
+type ModelCapabilities = publicprovider.ModelCapabilities
+type WatchdogConfig = publicprovider.WatchdogConfig
+type DebugLogger = publicprovider.DebugLogger
+type DebugLogConfigurer = publicprovider.DebugLogConfigurer
+type MessageTransformer = publicprovider.MessageTransformer
+type ToolSchemaCache = publicprovider.ToolSchemaCache
+
+const (
+	ErrUnknown         = publicprovider.ErrUnknown
+	ErrRateLimited     = publicprovider.ErrRateLimited
+	ErrAuthFailed      = publicprovider.ErrAuthFailed
+	ErrContextOverflow = publicprovider.ErrContextOverflow
+	ErrModelNotFound   = publicprovider.ErrModelNotFound
+	ErrServerError     = publicprovider.ErrServerError
+	ErrStreamError     = publicprovider.ErrStreamError
+	ErrContentFiltered = publicprovider.ErrContentFiltered
+	ErrInvalidRequest  = publicprovider.ErrInvalidRequest
+	ErrQuotaExceeded   = publicprovider.ErrQuotaExceeded
+	ErrOther           = publicprovider.ErrOther
+)
+
+const (
+	RetryForeground = publicprovider.RetryForeground
+	RetryBackground = publicprovider.RetryBackground
+)
+
+var (
+	FormatAPIError               = publicprovider.FormatAPIError
+	ClassifyAPIError             = publicprovider.ClassifyAPIError
+	ClassifyAPIErrorWithResponse = publicprovider.ClassifyAPIErrorWithResponse
+	WrapScannerError             = publicprovider.WrapScannerError
+	DoWithRetry                  = publicprovider.DoWithRetry
+	DoWithRetryConfig            = publicprovider.DoWithRetryConfig
+	NewHTTPClient                = publicprovider.NewHTTPClient
+	DefaultCapabilities          = publicprovider.DefaultCapabilities
+	DetectCapabilities           = publicprovider.DetectCapabilities
+	WatchBody                    = publicprovider.WatchBody
+	EnableDebugLogging           = publicprovider.EnableDebugLogging
+	LogRequest                   = publicprovider.LogRequest
+	LogResponse                  = publicprovider.LogResponse
+	NewToolSchemaCache           = publicprovider.NewToolSchemaCache
+)
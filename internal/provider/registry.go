@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/julianshen/rubichan/internal/config"
 )
@@ -55,6 +56,10 @@ type ProviderDef struct {
 	// ListModels returns the provider's available models. nil means the
 	// provider doesn't support dynamic listing.
 	ListModels func(ctx context.Context, cfg *config.Config) ([]Model, error)
+
+	// Configure applies provider-specific config to a freshly constructed
+	// provider. Optional.
+	Configure func(cfg *config.Config, p LLMProvider)
 }
 
 // Registry holds ProviderDefs, registered by each provider package's init().
@@ -130,6 +135,9 @@ func (r *Registry) New(cfg *config.Config) (LLMProvider, error) {
 		return nil, err
 	}
 	p := def.Constructor(def.BaseURL(cfg), apiKey, headers)
+	if def.Configure != nil {
+		def.Configure(cfg, p)
+	}
 	if ka := cfg.Agent.Cache.OllamaKeepAlive; ka != "" {
 		if kac, ok := p.(KeepAliveConfigurer); ok {
 			kac.SetKeepAlive(ka)
@@ -138,6 +146,37 @@ func (r *Registry) New(cfg *config.Config) (LLMProvider, error) {
 	return p, nil
 }
 
+// NewChain builds the provider for cfg.Provider.Default and, when
+// cfg.Provider.Failover declares a chain, wraps it together with each
+// secondary provider+model in a FailoverProvider. Without a chain it is
+// identical to New. A secondary that cannot be constructed (unknown ID,
+// missing credentials) is an error: a silently shortened chain would only
+// surface during the outage it was meant to cover.
+func (r *Registry) NewChain(cfg *config.Config) (LLMProvider, error) {
+	primary, err := r.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	fo := cfg.Provider.Failover
+	if len(fo.Chain) == 0 {
+		return primary, nil
+	}
+	entries := []ChainEntry{{Name: cfg.Provider.Default, Model: cfg.Provider.Model, Provider: primary}}
+	for _, link := range fo.Chain {
+		linkCfg := *cfg
+		linkCfg.Provider.Default = link.Provider
+		linkCfg.Provider.Model = link.Model
+		p, err := r.New(&linkCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failover provider %q: %w", link.Provider, err)
+		}
+		entries = append(entries, ChainEntry{Name: link.Provider, Model: link.Model, Provider: p})
+	}
+	// Validated at config load; a parse failure here falls back to the default.
+	cooldown, _ := time.ParseDuration(fo.Cooldown)
+	return NewFailoverProvider(entries, fo.FailureThreshold, cooldown), nil
+}
+
 // ResolveDefaultModel returns the model to use for cfg.Provider.Default when
 // the user hasn't specified one. Returns ErrNoDefaultModel if the provider
 // has no DefaultModel resolver (distinct from any other error, which means
@@ -298,3 +298,49 @@ func TestNewSkipsBaseURLWhenAuthFails(t *testing.T) {
 	require.Error(t, err)
 	require.False(t, baseURLCalled, "BaseURL must not run once Auth has failed")
 }
+
+func TestRegistry_NewChain_WithoutChainReturnsPrimary(t *testing.T) {
+	r := provider.NewRegistry()
+	r.Register(fakeDef("acme"))
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "acme"
+
+	p, err := r.NewChain(cfg)
+	require.NoError(t, err)
+	_, ok := p.(*fakeProvider)
+	assert.True(t, ok)
+}
+
+func TestRegistry_NewChain_BuildsFailoverProvider(t *testing.T) {
+	r := provider.NewRegistry()
+	r.Register(fakeDef("acme"))
+	r.Register(fakeDef("backup"))
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "acme"
+	cfg.Provider.Model = "big-model"
+	cfg.Provider.Failover.Chain = []config.FailoverEntryConfig{{Provider: "backup", Model: "small-model"}}
+
+	p, err := r.NewChain(cfg)
+	require.NoError(t, err)
+	fp, ok := p.(*provider.FailoverProvider)
+	require.True(t, ok)
+	assert.Equal(t, "acme/big-model → backup/small-model", fp.String())
+	assert.Equal(t, "acme", fp.ActiveProvider().Name)
+	// The caller's config is not mutated by building chain links.
+	assert.Equal(t, "acme", cfg.Provider.Default)
+}
+
+func TestRegistry_NewChain_UnknownLink(t *testing.T) {
+	r := provider.NewRegistry()
+	r.Register(fakeDef("acme"))
+
+	cfg := config.DefaultConfig()
+	cfg.Provider.Default = "acme"
+	cfg.Provider.Failover.Chain = []config.FailoverEntryConfig{{Provider: "nope", Model: "m"}}
+
+	_, err := r.NewChain(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failover provider "nope"`)
+}
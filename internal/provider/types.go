@@ -24,6 +24,6 @@ func NewUserMessage(text string) Message {
 
 // NewToolResultMessage creates a new tool result message. Delegates to the
 // canonical constructor; see agentsdk.NewToolResultMessage.
-func NewToolResultMessage(toolUseID, content string, isError bool) Message {
-	return agentsdk.NewToolResultMessage(toolUseID, content, isError)
+func NewToolResultMessage(toolUseID, content string, isError bool, media ...ContentBlock) Message {
+	return agentsdk.NewToolResultMessage(toolUseID, content, isError, media...)
 }
@@ -0,0 +1,111 @@
+// internal/runner/answers.go
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// AnswersFallback is the answers file key used for questions no other key
+// matches.
+const AnswersFallback = "*"
+
+// Answers answers ask_user questions in headless runs from a file supplied
+// up front with --answers. The file is a JSON object from question to
+// answer: a string, or a list of strings for multi-choice questions.
+//
+//	{"Which database?": "postgres", "Which platforms?": ["linux", "darwin"], "*": "use your judgment"}
+//
+// A question takes the answer of the key equal to it, ignoring case and
+// spacing, else of the longest key it contains, else of "*".
+type Answers struct {
+	answers map[string][]string // normalized key → answer
+}
+
+// LoadAnswers reads an answers file.
+func LoadAnswers(path string) (*Answers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading answers file: %w", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing answers file %s: %w", path, err)
+	}
+	a := &Answers{answers: make(map[string][]string, len(raw))}
+	for key, value := range raw {
+		var one string
+		var many []string
+		switch {
+		case json.Unmarshal(value, &one) == nil:
+			many = []string{one}
+		case json.Unmarshal(value, &many) == nil:
+		default:
+			return nil, fmt.Errorf("answers file %s: answer to %q must be a string or a list of strings", path, key)
+		}
+		a.answers[normalizeQuestion(key)] = many
+	}
+	return a, nil
+}
+
+func normalizeQuestion(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// lookup finds the answer to question.
+func (a *Answers) lookup(question string) ([]string, bool) {
+	q := normalizeQuestion(question)
+	if ans, ok := a.answers[q]; ok {
+		return ans, true
+	}
+	best := ""
+	for key := range a.answers {
+		if key != AnswersFallback && len(key) > len(best) && strings.Contains(q, key) {
+			best = key
+		}
+	}
+	if best != "" {
+		return a.answers[best], true
+	}
+	ans, ok := a.answers[AnswersFallback]
+	return ans, ok
+}
+
+// Request answers an ask_user request, making Answers an
+// agentsdk.UIRequestHandler for the tool. Other requests, and questions
+// the file has no answer for, are errors.
+func (a *Answers) Request(_ context.Context, req agentsdk.UIRequest) (agentsdk.UIResponse, error) {
+	if req.Metadata["tool"] != tools.AskUserName {
+		return agentsdk.UIResponse{}, fmt.Errorf("answers file cannot answer %s requests", req.Kind)
+	}
+	ans, ok := a.lookup(req.Message)
+	if !ok {
+		return agentsdk.UIResponse{}, fmt.Errorf("the answers file has no answer for %q", req.Message)
+	}
+	resp := agentsdk.UIResponse{RequestID: req.ID, ActionID: "submit"}
+	var values any
+	switch req.Metadata["question_kind"] {
+	case tools.AskSingle:
+		if len(ans) != 1 {
+			return agentsdk.UIResponse{}, fmt.Errorf("the answers file gives %d answers to the single-choice question %q", len(ans), req.Message)
+		}
+		resp.ActionID = ans[0]
+		return resp, nil
+	case tools.AskMulti:
+		values = tools.AskMultiValues{Answers: ans}
+	default:
+		values = tools.AskTextValues{Answer: strings.Join(ans, ", ")}
+	}
+	v, err := json.Marshal(values)
+	if err != nil {
+		return agentsdk.UIResponse{}, fmt.Errorf("encoding answer: %w", err)
+	}
+	resp.Values = v
+	return resp, nil
+}
@@ -0,0 +1,80 @@
+// internal/runner/answers_test.go
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/tools"
+)
+
+func writeAnswers(t *testing.T, content string) *Answers {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "answers.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	a, err := LoadAnswers(path)
+	require.NoError(t, err)
+	return a
+}
+
+func askWith(t *testing.T, a *Answers, input string) tools.ToolResult {
+	t.Helper()
+	var answers []tools.AskUserAnswer
+	tool := tools.NewAskUserTool(tools.AskUserConfig{
+		Handler:  a,
+		OnAnswer: func(ans tools.AskUserAnswer) { answers = append(answers, ans) },
+	})
+	res, err := tool.Execute(context.Background(), json.RawMessage(input))
+	require.NoError(t, err)
+	if !res.IsError {
+		require.Len(t, answers, 1, "answers are recorded")
+	}
+	return res
+}
+
+func TestAnswersAnswerAskUser(t *testing.T) {
+	a := writeAnswers(t, `{
+		"Which  DATABASE should I use?": "postgres",
+		"platforms": ["linux", "darwin"],
+		"*": "keep it simple"
+	}`)
+
+	res := askWith(t, a, `{"question":"Which database should I use?","options":["sqlite","postgres"]}`)
+	assert.False(t, res.IsError, res.Content)
+	assert.Equal(t, "The user answered: postgres", res.Content)
+
+	res = askWith(t, a, `{"question":"Which platforms do you target?","kind":"multi","options":["linux","darwin","windows"]}`)
+	assert.False(t, res.IsError, res.Content)
+	assert.Equal(t, "The user selected: linux, darwin", res.Content)
+
+	res = askWith(t, a, `{"question":"How should errors be reported?"}`)
+	assert.Equal(t, "The user answered: keep it simple", res.Content)
+}
+
+func TestAnswersMissingAnswerFailsCleanly(t *testing.T) {
+	a := writeAnswers(t, `{"database": "postgres"}`)
+
+	res := askWith(t, a, `{"question":"What name?"}`)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content, "no answer")
+
+	res = askWith(t, a, `{"question":"Which database?","options":["sqlite","mysql"]}`)
+	assert.True(t, res.IsError, "an answer that is not an option is rejected")
+}
+
+func TestLoadAnswersRejectsBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LoadAnswers(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"q": 3}`), 0o644))
+	_, err = LoadAnswers(path)
+	assert.ErrorContains(t, err, "string or a list of strings")
+}
@@ -4,7 +4,9 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -18,12 +20,29 @@ import (
 // TurnFunc matches the signature of agent.Agent.Turn.
 type TurnFunc func(ctx context.Context, msg string) (<-chan agent.TurnEvent, error)
 
+// StructuredOutput collects the schema-validated final answer of a
+// --json-schema run. tools.StructuredOutputTool implements it.
+type StructuredOutput interface {
+	// Output returns the accepted answer, if any.
+	Output() (json.RawMessage, bool)
+	// Accept validates answer and records it if it matches.
+	Accept(answer []byte) error
+	// LastError explains why the most recent answer was rejected.
+	LastError() string
+	// Reset forgets the recorded answer before a new prompt.
+	Reset()
+}
+
 // HeadlessRunner executes a single agent turn and collects the result.
 type HeadlessRunner struct {
-	turn          TurnFunc
-	eventSink     session.EventSink
-	modelName     string
-	toolEvaluator evaluator.Evaluator
+	turn              TurnFunc
+	eventSink         session.EventSink
+	modelName         string
+	toolEvaluator     evaluator.Evaluator
+	onEvent           func(agent.TurnEvent)
+	structured        StructuredOutput
+	structuredRetries int
+	turnTimeout       time.Duration
 }
 
 // NewHeadlessRunner creates a new HeadlessRunner with the given turn function.
@@ -46,11 +65,40 @@ func (r *HeadlessRunner) SetToolEvaluator(eval evaluator.Evaluator) {
 	r.toolEvaluator = eval
 }
 
+// SetTurnEventHandler registers fn to receive every TurnEvent as it
+// arrives, before the runner interprets it. Used for stream-json output.
+func (r *HeadlessRunner) SetTurnEventHandler(fn func(agent.TurnEvent)) {
+	r.onEvent = fn
+}
+
+// SetStructuredOutput requires every run to end with an answer accepted by
+// so. When a turn ends without one, the runner starts up to retries more
+// turns that feed the validation errors back to the model before failing
+// the run.
+func (r *HeadlessRunner) SetStructuredOutput(so StructuredOutput, retries int) {
+	r.structured = so
+	r.structuredRetries = retries
+}
+
+// SetTurnTimeout bounds each Run to d, on top of any deadline of the context
+// it is given. Zero, the default, leaves turns bounded only by that context.
+func (r *HeadlessRunner) SetTurnTimeout(d time.Duration) {
+	r.turnTimeout = d
+}
+
 // Run executes the agent with the given prompt and collects a RunResult.
 func (r *HeadlessRunner) Run(ctx context.Context, prompt, mode string) (*output.RunResult, error) {
+	if r.turnTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.turnTimeout)
+		defer cancel()
+	}
 	start := time.Now()
 	state := session.NewState()
 	state.ResetForPrompt(prompt)
+	if r.structured != nil {
+		r.structured.Reset()
+	}
 	r.emitEvent(session.NewTurnStartedEvent(prompt, r.modelName))
 	r.emitEvent(session.NewCheckpointCreatedEvent("turn-1", "turn_started"))
 
@@ -66,74 +114,105 @@ func (r *HeadlessRunner) Run(ctx context.Context, prompt, mode string) (*output.
 
 	var textBuf strings.Builder
 	var toolCalls []output.ToolCallLog
+	var plan []agent.PlanEntry
 	var lastErr string
 	turns := 0
 	doneDiffSummary := ""
 	doneInputTokens := 0
 	doneOutputTokens := 0
+	attempt := 0
+	attemptStart := 0
 
-	for evt := range ch {
-		switch evt.Type {
-		case "text_delta":
-			textBuf.WriteString(evt.Text)
-		case "tool_call":
-			if evt.ToolCall != nil {
-				state.ApplyEvent(evt)
+	for {
+		for evt := range ch {
+			if r.onEvent != nil {
+				r.onEvent(evt)
+			}
+			switch evt.Type {
+			case "text_delta":
+				textBuf.WriteString(evt.Text)
+			case "tool_call":
+				if evt.ToolCall != nil {
+					state.ApplyEvent(evt)
+
+					// Evaluate the tool call before recording it
+					if r.toolEvaluator != nil {
+						evalResult, _ := r.toolEvaluator.Evaluate(ctx, evaluator.EvaluationRequest{
+							ToolName: evt.ToolCall.Name,
+							Input:    evt.ToolCall.Input,
+							Context:  prompt,
+						})
+						if !evalResult.Approved() {
+							// Evaluation failed: emit error result and continue without executing
+							lastErr = fmt.Sprintf("tool evaluation rejected: %s", evalResult.Reason)
+							r.emitEvent(session.NewToolResultEvent(
+								evt.ToolCall.ID,
+								evt.ToolCall.Name,
+								lastErr,
+								true, // isError
+							))
+							continue
+						}
+					}
 
-				// Evaluate the tool call before recording it
-				if r.toolEvaluator != nil {
-					evalResult, _ := r.toolEvaluator.Evaluate(ctx, evaluator.EvaluationRequest{
-						ToolName: evt.ToolCall.Name,
-						Input:    evt.ToolCall.Input,
-						Context:  prompt,
+					// Tool call approved: record it
+					r.emitEvent(session.NewToolCallEvent(evt.ToolCall.ID, evt.ToolCall.Name, evt.ToolCall.Input))
+					toolCalls = append(toolCalls, output.ToolCallLog{
+						ID:    evt.ToolCall.ID,
+						Name:  evt.ToolCall.Name,
+						Input: json.RawMessage(evt.ToolCall.Input),
 					})
-					if !evalResult.Approved() {
-						// Evaluation failed: emit error result and continue without executing
-						lastErr = fmt.Sprintf("tool evaluation rejected: %s", evalResult.Reason)
-						r.emitEvent(session.NewToolResultEvent(
-							evt.ToolCall.ID,
-							evt.ToolCall.Name,
-							lastErr,
-							true, // isError
-						))
-						continue
+				}
+			case "tool_result":
+				if evt.ToolResult != nil {
+					state.ApplyEvent(evt)
+					result := evt.ToolResult.DisplayContent
+					if result == "" {
+						result = evt.ToolResult.Content
+					}
+					r.emitEvent(session.NewToolResultEvent(evt.ToolResult.ID, evt.ToolResult.Name, result, evt.ToolResult.IsError))
+					for i := range toolCalls {
+						if toolCalls[i].ID == evt.ToolResult.ID {
+							// Prefer DisplayContent for user-facing output.
+							toolCalls[i].Result = result
+							toolCalls[i].IsError = evt.ToolResult.IsError
+							break
+						}
 					}
 				}
-
-				// Tool call approved: record it
-				r.emitEvent(session.NewToolCallEvent(evt.ToolCall.ID, evt.ToolCall.Name, evt.ToolCall.Input))
-				toolCalls = append(toolCalls, output.ToolCallLog{
-					ID:    evt.ToolCall.ID,
-					Name:  evt.ToolCall.Name,
-					Input: json.RawMessage(evt.ToolCall.Input),
-				})
-			}
-		case "tool_result":
-			if evt.ToolResult != nil {
+			case "plan_update":
 				state.ApplyEvent(evt)
-				result := evt.ToolResult.DisplayContent
-				if result == "" {
-					result = evt.ToolResult.Content
-				}
-				r.emitEvent(session.NewToolResultEvent(evt.ToolResult.ID, evt.ToolResult.Name, result, evt.ToolResult.IsError))
-				for i := range toolCalls {
-					if toolCalls[i].ID == evt.ToolResult.ID {
-						// Prefer DisplayContent for user-facing output.
-						toolCalls[i].Result = result
-						toolCalls[i].IsError = evt.ToolResult.IsError
-						break
-					}
+				plan = evt.Plan
+				r.emitEvent(session.NewPlanUpdatedEvent("todo", state.Plan()))
+			case "error":
+				if evt.Error != nil {
+					lastErr = evt.Error.Error()
 				}
+			case "done":
+				turns++
+				doneDiffSummary = evt.DiffSummary
+				doneInputTokens = evt.InputTokens
+				doneOutputTokens = evt.OutputTokens
 			}
-		case "error":
-			if evt.Error != nil {
-				lastErr = evt.Error.Error()
+		}
+
+		retry, ok := r.structuredOutputRetry(ctx, textBuf.String()[attemptStart:], lastErr, attempt)
+		if !ok {
+			break
+		}
+		if retry == "" {
+			lastErr = fmt.Sprintf("no answer matching --json-schema after %d attempt(s)", attempt+1)
+			if reason := r.structured.LastError(); reason != "" {
+				lastErr += ": " + reason
 			}
-		case "done":
-			turns++
-			doneDiffSummary = evt.DiffSummary
-			doneInputTokens = evt.InputTokens
-			doneOutputTokens = evt.OutputTokens
+			break
+		}
+		attempt++
+		attemptStart = textBuf.Len()
+		ch, err = r.turn(ctx, retry)
+		if err != nil {
+			lastErr = err.Error()
+			break
 		}
 	}
 
@@ -164,19 +243,99 @@ func (r *HeadlessRunner) Run(ctx context.Context, prompt, mode string) (*output.
 	}
 	r.emitEvent(session.NewTurnCompletedEvent(doneDiffSummary, doneInputTokens, doneOutputTokens))
 
+	var structuredOutput json.RawMessage
+	if r.structured != nil {
+		structuredOutput, _ = r.structured.Output()
+	}
+
 	return &output.RunResult{
-		Prompt:          prompt,
-		Response:        response,
-		Summary:         summary,
-		EvidenceSummary: evidenceSummary,
-		ToolCalls:       toolCalls,
-		TurnCount:       turns,
-		DurationMs:      time.Since(start).Milliseconds(),
-		Mode:            mode,
-		Error:           lastErr,
+		Prompt:           prompt,
+		Response:         response,
+		Summary:          summary,
+		EvidenceSummary:  evidenceSummary,
+		StructuredOutput: structuredOutput,
+		ToolCalls:        toolCalls,
+		Plan:             plan,
+		TurnCount:        turns,
+		DurationMs:       time.Since(start).Milliseconds(),
+		Mode:             mode,
+		Error:            lastErr,
 	}, nil
 }
 
+// structuredOutputRetry decides what follows a turn of a --json-schema
+// run. It reports ok=false when the run is over: no schema is set, an
+// answer was accepted, or the turn failed for another reason. Otherwise it
+// returns the prompt for the next attempt, or "" once retries are used up.
+// A final text response that is itself a matching JSON document is
+// accepted in place of a tool call.
+func (r *HeadlessRunner) structuredOutputRetry(ctx context.Context, text, lastErr string, attempt int) (string, bool) {
+	if r.structured == nil || lastErr != "" || ctx.Err() != nil {
+		return "", false
+	}
+	if _, ok := r.structured.Output(); ok {
+		return "", false
+	}
+	if answer := extractJSONAnswer(text); answer != nil && r.structured.Accept(answer) == nil {
+		return "", false
+	}
+	if attempt >= r.structuredRetries {
+		return "", true
+	}
+	if reason := r.structured.LastError(); reason != "" {
+		return fmt.Sprintf("Your answer does not match the required JSON schema: %s\n\nCall the structured_output tool again with a corrected answer.", reason), true
+	}
+	return "You have not submitted a final answer. Call the structured_output tool with an answer that matches its input schema.", true
+}
+
+// extractJSONAnswer returns the JSON document in a final text response:
+// the whole response, or the body of a single fenced code block. It
+// returns nil when the response is not JSON.
+func extractJSONAnswer(text string) []byte {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		body := strings.TrimPrefix(text, "```")
+		if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+			body = body[nl+1:]
+		}
+		body, ok := strings.CutSuffix(strings.TrimSpace(body), "```")
+		if !ok {
+			return nil
+		}
+		text = strings.TrimSpace(body)
+	}
+	if text == "" || !json.Valid([]byte(text)) {
+		return nil
+	}
+	return []byte(text)
+}
+
+// RunFollowUps runs one turn per user message read from in until in is
+// exhausted, passing each turn's result to onResult as soon as it
+// completes. It returns the last result, or nil if no message arrived.
+// Waiting for a message is bounded only by ctx, so a session may idle; use
+// SetTurnTimeout to bound each turn.
+func (r *HeadlessRunner) RunFollowUps(ctx context.Context, in *StreamInput, mode string, onResult func(*output.RunResult) error) (*output.RunResult, error) {
+	var last *output.RunResult
+	for {
+		msg, err := in.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return last, nil
+		}
+		if err != nil {
+			return last, err
+		}
+		result, err := r.Run(ctx, msg, mode)
+		if err != nil {
+			return last, err
+		}
+		last = result
+		if err := onResult(result); err != nil {
+			return last, err
+		}
+	}
+}
+
 func (r *HeadlessRunner) emitEvent(evt session.Event) {
 	if r == nil || r.eventSink == nil {
 		return
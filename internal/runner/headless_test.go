@@ -4,6 +4,7 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -70,6 +71,41 @@ func TestHeadlessRunnerWithToolCalls(t *testing.T) {
 	assert.Equal(t, "package main", result.ToolCalls[0].Result)
 }
 
+func TestHeadlessRunnerReportsFinalPlan(t *testing.T) {
+	turnFn := func(_ context.Context, msg string) (<-chan agent.TurnEvent, error) {
+		return makeEventCh(
+			agent.TurnEvent{Type: "plan_update", Plan: []agent.PlanEntry{
+				{Content: "Write the handler", Status: "in_progress"},
+				{Content: "Add tests", Status: "pending"},
+			}},
+			agent.TurnEvent{Type: "plan_update", Plan: []agent.PlanEntry{
+				{Content: "Write the handler", Status: "completed"},
+				{Content: "Add tests", Status: "completed"},
+			}},
+			agent.TurnEvent{Type: "text_delta", Text: "Done"},
+			agent.TurnEvent{Type: "done"},
+		), nil
+	}
+
+	var events []session.Event
+	r := NewHeadlessRunner(turnFn)
+	r.SetEventSink(session.SinkFunc(func(evt session.Event) { events = append(events, evt) }))
+	result, err := r.Run(context.Background(), "add an endpoint", "generic")
+	require.NoError(t, err)
+
+	assert.Equal(t, []agent.PlanEntry{
+		{Content: "Write the handler", Status: "completed"},
+		{Content: "Add tests", Status: "completed"},
+	}, result.Plan)
+	var planEvents int
+	for _, evt := range events {
+		if evt.Type == session.EventTypePlanUpdated && evt.Plan.Reason == "todo" {
+			planEvents++
+		}
+	}
+	assert.Equal(t, 2, planEvents, "each todo list change is recorded")
+}
+
 func TestHeadlessRunnerError(t *testing.T) {
 	turnFn := func(_ context.Context, msg string) (<-chan agent.TurnEvent, error) {
 		return makeEventCh(
@@ -820,3 +856,118 @@ func TestHeadlessRunnerWithEvaluatorRejectsInvalidToolCalls(t *testing.T) {
 	// The tool call should be rejected before execution
 	assert.Contains(t, result.Error, "missing required field")
 }
+
+// fakeStructuredOutput accepts answers whose "ok" field is true.
+type fakeStructuredOutput struct {
+	output  json.RawMessage
+	lastErr string
+	resets  int
+}
+
+func (f *fakeStructuredOutput) Output() (json.RawMessage, bool) { return f.output, f.output != nil }
+func (f *fakeStructuredOutput) LastError() string               { return f.lastErr }
+func (f *fakeStructuredOutput) Reset()                          { f.output, f.lastErr = nil, ""; f.resets++ }
+func (f *fakeStructuredOutput) Accept(answer []byte) error {
+	var in struct {
+		OK bool `json:"ok"`
+	}
+	if err := json.Unmarshal(answer, &in); err != nil || !in.OK {
+		f.lastErr = "/ok: expected true"
+		return errors.New(f.lastErr)
+	}
+	f.output = answer
+	return nil
+}
+
+func TestHeadlessRunnerStructuredOutputFromTool(t *testing.T) {
+	so := &fakeStructuredOutput{}
+	calls := 0
+	turnFn := func(_ context.Context, msg string) (<-chan agent.TurnEvent, error) {
+		calls++
+		require.NoError(t, so.Accept([]byte(`{"ok":true}`)))
+		return makeEventCh(agent.TurnEvent{Type: "done"}), nil
+	}
+
+	r := NewHeadlessRunner(turnFn)
+	r.SetStructuredOutput(so, 2)
+	result, err := r.Run(context.Background(), "classify", "generic")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, so.resets)
+	assert.Empty(t, result.Error)
+	assert.JSONEq(t, `{"ok":true}`, string(result.StructuredOutput))
+}
+
+func TestHeadlessRunnerStructuredOutputRetriesWithErrors(t *testing.T) {
+	so := &fakeStructuredOutput{}
+	var prompts []string
+	turnFn := func(_ context.Context, msg string) (<-chan agent.TurnEvent, error) {
+		prompts = append(prompts, msg)
+		switch len(prompts) {
+		case 1:
+			return makeEventCh(agent.TurnEvent{Type: "text_delta", Text: "It is a bug."}, agent.TurnEvent{Type: "done"}), nil
+		case 2:
+			_ = so.Accept([]byte(`{"ok":false}`))
+			return makeEventCh(agent.TurnEvent{Type: "done"}), nil
+		default:
+			return makeEventCh(agent.TurnEvent{Type: "text_delta", Text: "```json\n{\"ok\": true}\n```"}, agent.TurnEvent{Type: "done"}), nil
+		}
+	}
+
+	r := NewHeadlessRunner(turnFn)
+	r.SetStructuredOutput(so, 2)
+	result, err := r.Run(context.Background(), "classify", "generic")
+	require.NoError(t, err)
+
+	require.Len(t, prompts, 3)
+	assert.Contains(t, prompts[1], "have not submitted a final answer")
+	assert.Contains(t, prompts[2], "/ok: expected true")
+	assert.Empty(t, result.Error)
+	assert.Equal(t, 3, result.TurnCount)
+	assert.JSONEq(t, `{"ok": true}`, string(result.StructuredOutput))
+}
+
+func TestHeadlessRunnerStructuredOutputGivesUp(t *testing.T) {
+	so := &fakeStructuredOutput{}
+	calls := 0
+	turnFn := func(_ context.Context, msg string) (<-chan agent.TurnEvent, error) {
+		calls++
+		return makeEventCh(agent.TurnEvent{Type: "text_delta", Text: `{"ok":false}`}, agent.TurnEvent{Type: "done"}), nil
+	}
+
+	r := NewHeadlessRunner(turnFn)
+	r.SetStructuredOutput(so, 1)
+	result, err := r.Run(context.Background(), "classify", "generic")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "no answer matching --json-schema after 2 attempt(s): /ok: expected true", result.Error)
+	assert.Nil(t, result.StructuredOutput)
+}
+
+func TestHeadlessRunnerStructuredOutputSkipsRetryAfterError(t *testing.T) {
+	so := &fakeStructuredOutput{}
+	calls := 0
+	turnFn := func(_ context.Context, msg string) (<-chan agent.TurnEvent, error) {
+		calls++
+		return makeEventCh(agent.TurnEvent{Type: "error", Error: errors.New("provider down")}, agent.TurnEvent{Type: "done"}), nil
+	}
+
+	r := NewHeadlessRunner(turnFn)
+	r.SetStructuredOutput(so, 2)
+	result, err := r.Run(context.Background(), "classify", "generic")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "provider down", result.Error)
+}
+
+func TestExtractJSONAnswer(t *testing.T) {
+	assert.Equal(t, `{"a":1}`, string(extractJSONAnswer(" {\"a\":1} ")))
+	assert.Equal(t, `[1, 2]`, string(extractJSONAnswer("```json\n[1, 2]\n```")))
+	assert.Equal(t, `"x"`, string(extractJSONAnswer("```\n\"x\"\n```")))
+	assert.Nil(t, extractJSONAnswer("The answer is {\"a\":1}"))
+	assert.Nil(t, extractJSONAnswer("```json\n{\"a\":1}"))
+	assert.Nil(t, extractJSONAnswer(""))
+}
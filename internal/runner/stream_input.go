@@ -0,0 +1,177 @@
+// internal/runner/stream_input.go
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// maxStreamInputLine bounds one stream-json input line; follow-up messages
+// may carry pasted files, so this is well above bufio's 64 KiB default.
+const maxStreamInputLine = 16 << 20
+
+// streamInputMessage is one line of stream-json input.
+type streamInputMessage struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	ID     string `json:"id"`
+	Action string `json:"action"`
+}
+
+// StreamInput reads --input-format stream-json from a long-lived stdin:
+// one JSON object per line, either a user message or the answer to an
+// approval request emitted as a ui_request event.
+//
+//	{"type":"user","text":"now add tests"}
+//	{"type":"approval","id":"toolu_01","action":"allow"}
+//
+// Actions are allow, deny, allow_always and deny_always. User messages are
+// consumed with Next; approvals are consumed through Request, which makes a
+// StreamInput an agentsdk.UIRequestHandler. An approval may arrive before
+// the request it answers.
+type StreamInput struct {
+	mu        sync.Mutex
+	messages  []string
+	approvals map[string]string
+	changed   chan struct{} // closed and replaced whenever state changes
+	closed    bool
+	err       error
+}
+
+// NewStreamInput starts reading r in the background.
+func NewStreamInput(r io.Reader) *StreamInput {
+	in := &StreamInput{
+		approvals: make(map[string]string),
+		changed:   make(chan struct{}),
+	}
+	go in.read(r)
+	return in
+}
+
+func (in *StreamInput) read(r io.Reader) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxStreamInputLine)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := in.handleLine(line); err != nil {
+			in.finish(fmt.Errorf("stream-json input line %d: %w", lineNo, err))
+			return
+		}
+	}
+	if err := sc.Err(); err != nil {
+		in.finish(fmt.Errorf("reading stream-json input: %w", err))
+		return
+	}
+	in.finish(nil)
+}
+
+func (in *StreamInput) handleLine(line []byte) error {
+	var msg streamInputMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return err
+	}
+	switch msg.Type {
+	case "user":
+		text := strings.TrimSpace(msg.Text)
+		if text == "" {
+			return fmt.Errorf("user message has no text")
+		}
+		in.update(func() { in.messages = append(in.messages, text) })
+	case "approval":
+		if msg.ID == "" {
+			return fmt.Errorf("approval has no id")
+		}
+		switch msg.Action {
+		case "allow", "deny", "allow_always", "deny_always":
+		default:
+			return fmt.Errorf("approval %s: unsupported action %q", msg.ID, msg.Action)
+		}
+		in.update(func() { in.approvals[msg.ID] = msg.Action })
+	default:
+		return fmt.Errorf("unsupported message type %q", msg.Type)
+	}
+	return nil
+}
+
+// update applies fn under the lock and wakes every waiter.
+func (in *StreamInput) update(fn func()) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	fn()
+	close(in.changed)
+	in.changed = make(chan struct{})
+}
+
+func (in *StreamInput) finish(err error) {
+	in.update(func() {
+		in.closed = true
+		in.err = err
+	})
+}
+
+// Next blocks until the next user message arrives. It returns io.EOF once
+// the input is closed and every message has been consumed.
+func (in *StreamInput) Next(ctx context.Context) (string, error) {
+	for {
+		in.mu.Lock()
+		if len(in.messages) > 0 {
+			msg := in.messages[0]
+			in.messages = in.messages[1:]
+			in.mu.Unlock()
+			return msg, nil
+		}
+		closed, err, changed := in.closed, in.err, in.changed
+		in.mu.Unlock()
+
+		if closed {
+			if err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Request waits for the approval line answering req.
+func (in *StreamInput) Request(ctx context.Context, req agentsdk.UIRequest) (agentsdk.UIResponse, error) {
+	for {
+		in.mu.Lock()
+		if action, ok := in.approvals[req.ID]; ok {
+			delete(in.approvals, req.ID)
+			in.mu.Unlock()
+			return agentsdk.UIResponse{RequestID: req.ID, ActionID: action}, nil
+		}
+		closed, err, changed := in.closed, in.err, in.changed
+		in.mu.Unlock()
+
+		if closed {
+			if err != nil {
+				return agentsdk.UIResponse{}, err
+			}
+			return agentsdk.UIResponse{}, fmt.Errorf("stream-json input closed before approval of %s", req.ID)
+		}
+		select {
+		case <-ctx.Done():
+			return agentsdk.UIResponse{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// internal/runner/stream_input_test.go
+package runner
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/agent"
+	"github.com/julianshen/rubichan/internal/output"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+func TestStreamInputUserMessages(t *testing.T) {
+	in := NewStreamInput(strings.NewReader(
+		`{"type":"user","text":"first"}` + "\n\n" +
+			`{"type":"user","text":"  second  "}` + "\n",
+	))
+	ctx := context.Background()
+
+	msg, err := in.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "first", msg)
+
+	msg, err = in.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "second", msg)
+
+	_, err = in.Next(ctx)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestStreamInputApprovalBeforeRequest(t *testing.T) {
+	in := NewStreamInput(strings.NewReader(`{"type":"approval","id":"t1","action":"deny_always"}` + "\n"))
+
+	resp, err := in.Request(context.Background(), agentsdk.UIRequest{ID: "t1", Kind: agentsdk.UIKindApproval})
+	require.NoError(t, err)
+	assert.Equal(t, agentsdk.UIResponse{RequestID: "t1", ActionID: "deny_always"}, resp)
+}
+
+func TestStreamInputApprovalAfterRequest(t *testing.T) {
+	pr, pw := io.Pipe()
+	in := NewStreamInput(pr)
+
+	done := make(chan agentsdk.UIResponse, 1)
+	go func() {
+		resp, err := in.Request(context.Background(), agentsdk.UIRequest{ID: "t2"})
+		assert.NoError(t, err)
+		done <- resp
+	}()
+
+	_, err := io.WriteString(pw, `{"type":"approval","id":"other","action":"allow"}`+"\n")
+	require.NoError(t, err)
+	_, err = io.WriteString(pw, `{"type":"approval","id":"t2","action":"allow"}`+"\n")
+	require.NoError(t, err)
+
+	select {
+	case resp := <-done:
+		assert.Equal(t, "allow", resp.ActionID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("approval was not delivered")
+	}
+	require.NoError(t, pw.Close())
+}
+
+func TestStreamInputRequestFailsWhenInputCloses(t *testing.T) {
+	in := NewStreamInput(strings.NewReader(""))
+
+	_, err := in.Request(context.Background(), agentsdk.UIRequest{ID: "t1"})
+	assert.ErrorContains(t, err, "closed before approval of t1")
+}
+
+func TestStreamInputRespectsContext(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	in := NewStreamInput(pr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := in.Next(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStreamInputRejectsBadLines(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"malformed", `{"type":`, "line 2"},
+		{"unknown type", `{"type":"interrupt"}`, `unsupported message type "interrupt"`},
+		{"empty user text", `{"type":"user","text":" "}`, "user message has no text"},
+		{"approval without id", `{"type":"approval","action":"allow"}`, "approval has no id"},
+		{"bad action", `{"type":"approval","id":"t1","action":"maybe"}`, `unsupported action "maybe"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewStreamInput(strings.NewReader(`{"type":"user","text":"ok"}` + "\n" + tt.line + "\n"))
+
+			msg, err := in.Next(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, "ok", msg)
+
+			_, err = in.Next(context.Background())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "stream-json input line 2")
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestHeadlessRunnerRunFollowUps(t *testing.T) {
+	var prompts []string
+	turnFn := func(_ context.Context, msg string) (<-chan agent.TurnEvent, error) {
+		prompts = append(prompts, msg)
+		return makeEventCh(
+			agent.TurnEvent{Type: "text_delta", Text: "re: " + msg},
+			agent.TurnEvent{Type: "done"},
+		), nil
+	}
+	in := NewStreamInput(strings.NewReader(
+		`{"type":"user","text":"one"}` + "\n" + `{"type":"user","text":"two"}` + "\n",
+	))
+
+	var streamed []string
+	r := NewHeadlessRunner(turnFn)
+	r.SetTurnEventHandler(func(evt agent.TurnEvent) { streamed = append(streamed, evt.Type) })
+
+	var results []string
+	last, err := r.RunFollowUps(context.Background(), in, "generic", func(res *output.RunResult) error {
+		results = append(results, res.Response)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"one", "two"}, prompts)
+	assert.Equal(t, []string{"re: one", "re: two"}, results)
+	assert.Equal(t, []string{"text_delta", "done", "text_delta", "done"}, streamed)
+	require.NotNil(t, last)
+	assert.Equal(t, "two", last.Prompt)
+}
+
+func TestHeadlessRunnerRunFollowUpsNoMessages(t *testing.T) {
+	r := NewHeadlessRunner(func(context.Context, string) (<-chan agent.TurnEvent, error) {
+		t.Fatal("turn should not run")
+		return nil, nil
+	})
+	last, err := r.RunFollowUps(context.Background(), NewStreamInput(strings.NewReader("")), "generic",
+		func(*output.RunResult) error { return nil })
+	require.NoError(t, err)
+	assert.Nil(t, last)
+}
+
+func TestHeadlessRunnerRunFollowUpsTimesOutTurnsNotIdling(t *testing.T) {
+	const turnTimeout = 50 * time.Millisecond
+	var deadlines []time.Duration
+	turnFn := func(ctx context.Context, msg string) (<-chan agent.TurnEvent, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok, "each turn has a deadline")
+		deadlines = append(deadlines, time.Until(deadline))
+		return makeEventCh(agent.TurnEvent{Type: "text_delta", Text: msg}, agent.TurnEvent{Type: "done"}), nil
+	}
+
+	pr, pw := io.Pipe()
+	in := NewStreamInput(pr)
+	go func() {
+		_, _ = io.WriteString(pw, `{"type":"user","text":"one"}`+"\n")
+		// Idle for longer than a turn may take before the next message.
+		time.Sleep(3 * turnTimeout)
+		_, _ = io.WriteString(pw, `{"type":"user","text":"two"}`+"\n")
+		_ = pw.Close()
+	}()
+
+	r := NewHeadlessRunner(turnFn)
+	r.SetTurnTimeout(turnTimeout)
+	var results []string
+	last, err := r.RunFollowUps(context.Background(), in, "generic", func(res *output.RunResult) error {
+		results = append(results, res.Response)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotNil(t, last)
+	assert.Equal(t, []string{"one", "two"}, results)
+	for _, d := range deadlines {
+		assert.LessOrEqual(t, d, turnTimeout)
+	}
+}
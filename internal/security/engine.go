@@ -112,6 +112,13 @@ func (e *Engine) Run(ctx context.Context, target ScanTarget) (*Report, error) {
 		},
 		Errors: allErrors,
 	}
+	for _, s := range e.scanners {
+		if r, ok := s.(VulnDBReporter); ok {
+			if age, ok := r.VulnDBAge(); ok {
+				report.Stats.VulnDBAge = age
+			}
+		}
+	}
 
 	if e.config.OnScanComplete != nil {
 		e.config.OnScanComplete(ctx, report)
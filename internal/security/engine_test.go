@@ -208,3 +208,22 @@ func TestEngineEmptyTarget(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, report.Findings)
 }
+
+// dbAgeScanner is a scanner that reports the age of its advisory database.
+type dbAgeScanner struct {
+	mockScanner
+	age time.Duration
+}
+
+func (d *dbAgeScanner) VulnDBAge() (time.Duration, bool) { return d.age, true }
+
+func TestEngineReportsVulnDBAge(t *testing.T) {
+	t.Parallel()
+
+	e := NewEngine(EngineConfig{Concurrency: 1})
+	e.AddScanner(&dbAgeScanner{mockScanner: mockScanner{name: "deps"}, age: 36 * time.Hour})
+
+	report, err := e.Run(context.Background(), ScanTarget{RootDir: t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, report.Stats.VulnDBAge)
+}
@@ -64,9 +64,10 @@ type jsonSummary struct {
 
 // jsonStats holds scan timing and count metrics.
 type jsonStats struct {
-	DurationMS     int64 `json:"duration_ms"`
-	FilesScanned   int   `json:"files_scanned"`
-	ChunksAnalyzed int   `json:"chunks_analyzed"`
+	DurationMS       int64 `json:"duration_ms"`
+	FilesScanned     int   `json:"files_scanned"`
+	ChunksAnalyzed   int   `json:"chunks_analyzed"`
+	VulnDBAgeSeconds int64 `json:"vuln_db_age_seconds,omitempty"`
 }
 
 // JSONFormatter formats a security report as JSON.
@@ -89,9 +90,10 @@ func (f *JSONFormatter) Format(report *security.Report) ([]byte, error) {
 		AttackChains: convertChains(report.AttackChains),
 		Summary:      convertSummary(report.Summary()),
 		Stats: jsonStats{
-			DurationMS:     report.Stats.Duration.Milliseconds(),
-			FilesScanned:   report.Stats.FilesScanned,
-			ChunksAnalyzed: report.Stats.ChunksAnalyzed,
+			DurationMS:       report.Stats.Duration.Milliseconds(),
+			FilesScanned:     report.Stats.FilesScanned,
+			ChunksAnalyzed:   report.Stats.ChunksAnalyzed,
+			VulnDBAgeSeconds: int64(report.Stats.VulnDBAge.Seconds()),
 		},
 	}
 
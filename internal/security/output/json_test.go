@@ -150,3 +150,12 @@ func TestJSONFormatterPreservesAllFields(t *testing.T) {
 	assert.Equal(t, "test", rf.Metadata["source"])
 	assert.Equal(t, "crypto-skill", rf.SkillSource)
 }
+
+func TestJSONFormatterVulnDBAge(t *testing.T) {
+	f := NewJSONFormatter()
+	report := &security.Report{Stats: security.ScanStats{VulnDBAge: 2 * time.Hour}}
+
+	data, err := f.Format(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"vuln_db_age_seconds": 7200`)
+}
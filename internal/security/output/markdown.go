@@ -3,6 +3,7 @@ package output
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/julianshen/rubichan/internal/security"
 )
@@ -68,6 +69,9 @@ func (f *MarkdownFormatter) Format(report *security.Report) ([]byte, error) {
 	b.WriteString("\n")
 	b.WriteString(fmt.Sprintf("**Total findings:** %d | **Attack chains:** %d | **Duration:** %dms\n\n",
 		summary.Total, summary.Chains, report.Stats.Duration.Milliseconds()))
+	if age := report.Stats.VulnDBAge; age > 0 {
+		b.WriteString(fmt.Sprintf("_Vulnerability database last updated %s ago._\n\n", formatDBAge(age)))
+	}
 
 	// Group findings by severity
 	bySeverity := make(map[security.Severity][]security.Finding)
@@ -112,6 +116,14 @@ func formatLocation(loc security.Location) string {
 	return s
 }
 
+// formatDBAge renders a database age in days, or hours when under a day.
+func formatDBAge(age time.Duration) string {
+	if age < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
+
 // writeFinding writes a single finding as Markdown.
 func writeFinding(b *strings.Builder, f security.Finding) {
 	b.WriteString(fmt.Sprintf("### [%s] %s\n\n", f.ID, f.Title))
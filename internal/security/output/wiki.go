@@ -49,6 +49,9 @@ func buildOverviewPage(report *security.Report) string {
 	b.WriteString(fmt.Sprintf("- **Duration:** %dms\n", report.Stats.Duration.Milliseconds()))
 	b.WriteString(fmt.Sprintf("- **Files scanned:** %d\n", report.Stats.FilesScanned))
 	b.WriteString(fmt.Sprintf("- **Chunks analyzed:** %d\n", report.Stats.ChunksAnalyzed))
+	if age := report.Stats.VulnDBAge; age > 0 {
+		b.WriteString(fmt.Sprintf("- **Vulnerability database age:** %s\n", formatDBAge(age)))
+	}
 	b.WriteString("\n")
 
 	b.WriteString("## Findings Summary\n\n")
@@ -12,6 +12,7 @@ import (
 // ProjectSecurityConfig represents a project-level .security.yaml file.
 type ProjectSecurityConfig struct {
 	Rules     []CustomRule   `yaml:"rules"`
+	SASTRules []SASTRule     `yaml:"sast_rules"`
 	Overrides []Override     `yaml:"overrides"`
 	CI        CIConfig       `yaml:"ci"`
 	ToolRules []ToolRuleYAML `yaml:"tool_rules"`
@@ -34,6 +35,52 @@ type CustomRule struct {
 	Category string `yaml:"category"`
 }
 
+// SASTRule defines a syntax-aware rule for the SAST scanner. Each query is a
+// tree-sitter S-expression for the rule's languages.
+//
+// A pattern rule reports every node its Pattern captures as @match. A taint
+// rule reports a @sink capture from Sinks that carries data from a @source
+// capture in Sources, either directly or through local variables within one
+// function, unless the data passes through a @sanitizer capture first. A
+// source that captures an identifier, such as a parameter name, taints that
+// variable. Taint rules without Sources or Sanitizers use the language's
+// built-in ones.
+type SASTRule struct {
+	ID          string   `yaml:"id"`
+	Languages   []string `yaml:"languages"`
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Severity    string   `yaml:"severity"`
+	Category    string   `yaml:"category"`
+	CWE         string   `yaml:"cwe"`
+	Confidence  string   `yaml:"confidence"`
+	Pattern     string   `yaml:"pattern"`
+	Sources     []string `yaml:"sources"`
+	Sinks       []string `yaml:"sinks"`
+	Sanitizers  []string `yaml:"sanitizers"`
+}
+
+// Validate checks that the rule has an id, a known severity and something
+// to match. Queries are checked when the scanner compiles them.
+func (r SASTRule) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("sast rule is missing required id field")
+	}
+	if r.Severity != "" && SeverityRank(Severity(r.Severity)) == 0 {
+		return fmt.Errorf("sast rule %q has invalid severity %q (must be critical, high, medium, low, or info)", r.ID, r.Severity)
+	}
+	if len(r.Languages) == 0 {
+		return fmt.Errorf("sast rule %q lists no languages", r.ID)
+	}
+	switch {
+	case r.Pattern != "" && len(r.Sinks) > 0:
+		return fmt.Errorf("sast rule %q sets both pattern and sinks", r.ID)
+	case r.Pattern == "" && len(r.Sinks) == 0:
+		return fmt.Errorf("sast rule %q needs a pattern or sinks", r.ID)
+	}
+	return nil
+}
+
 // Override changes the severity of a specific finding by ID.
 type Override struct {
 	FindingID string `yaml:"finding_id"`
@@ -75,6 +122,11 @@ func LoadProjectConfig(dir string) (*ProjectSecurityConfig, error) {
 			return nil, fmt.Errorf(".security.yaml: rule at index %d is missing required id field", i)
 		}
 	}
+	for i, r := range cfg.SASTRules {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf(".security.yaml: sast_rules[%d]: %w", i, err)
+		}
+	}
 	for _, o := range cfg.Overrides {
 		if o.Severity != "" && SeverityRank(Severity(o.Severity)) == 0 {
 			return nil, fmt.Errorf(".security.yaml: override for %q has invalid severity %q (must be critical, high, medium, low, or info)", o.FindingID, o.Severity)
@@ -175,3 +175,54 @@ rules:
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "missing required id")
 }
+
+func TestLoadProjectConfig_SASTRules(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	yaml := `
+sast_rules:
+  - id: app-eval
+    languages: [python]
+    title: "eval of request data"
+    severity: critical
+    category: injection
+    cwe: CWE-95
+    sinks:
+      - '(call function: (identifier) @fn arguments: (argument_list (_) @sink) (#eq? @fn "eval"))'
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".security.yaml"), []byte(yaml), 0o644))
+
+	cfg, err := LoadProjectConfig(dir)
+	require.NoError(t, err)
+	require.Len(t, cfg.SASTRules, 1)
+	assert.Equal(t, "app-eval", cfg.SASTRules[0].ID)
+	assert.Equal(t, []string{"python"}, cfg.SASTRules[0].Languages)
+	assert.Len(t, cfg.SASTRules[0].Sinks, 1)
+}
+
+func TestLoadProjectConfig_InvalidSASTRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule string
+		want string
+	}{
+		{"missing id", "languages: [go]\n    pattern: (identifier) @match", "missing required id"},
+		{"bad severity", "id: r\n    languages: [go]\n    severity: urgent\n    pattern: (identifier) @match", "invalid severity"},
+		{"no languages", "id: r\n    pattern: (identifier) @match", "no languages"},
+		{"nothing to match", "id: r\n    languages: [go]", "pattern or sinks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			content := "sast_rules:\n  - " + tt.rule + "\n"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, ".security.yaml"), []byte(content), 0o644))
+
+			_, err := LoadProjectConfig(dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
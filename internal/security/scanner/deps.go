@@ -6,39 +6,86 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/julianshen/rubichan/internal/security"
 )
 
 const defaultOSVBaseURL = "https://api.osv.dev"
 
-// dependency represents a parsed package with its version.
+// dependency represents a parsed package with its version. Relation and
+// Path say how the project came to depend on it, when the lockfile records
+// that.
 type dependency struct {
-	Name    string
-	Version string
+	Name     string
+	Version  string
+	Relation depRelation
+	// Path is the chain of packages from a direct dependency down to this
+	// one, e.g. "express > body-parser > qs". Empty unless transitive.
+	Path string
 }
 
+// depRelation is how a dependency relates to the project.
+type depRelation int
+
+const (
+	relationUnknown depRelation = iota
+	relationDirect
+	relationTransitive
+)
+
 // lockfileParser defines how to parse a specific lockfile format.
 type lockfileParser struct {
 	filename  string
 	ecosystem string
-	parse     func(data []byte) ([]dependency, error)
+	// manifest names the sibling file declaring the project's direct
+	// dependencies, for lockfiles that do not record them. It is passed to
+	// parse, or nil when absent.
+	manifest string
+	// supersededBy names a sibling lockfile that, when present, lists the
+	// same dependencies more precisely; this one is then skipped.
+	supersededBy string
+	// supersedes, when set, reports whether the supersededBy file's content
+	// lists every dependency this lockfile does; it is consulted before
+	// skipping this one.
+	supersedes func(data []byte) bool
+	parse      func(lockfile, manifest []byte) ([]dependency, error)
+}
+
+// lockfileOnly adapts a parser that needs no manifest.
+func lockfileOnly(parse func(data []byte) ([]dependency, error)) func(lockfile, manifest []byte) ([]dependency, error) {
+	return func(lockfile, _ []byte) ([]dependency, error) {
+		return parse(lockfile)
+	}
 }
 
 // DepScanner audits project dependencies for known vulnerabilities
-// by parsing lockfiles and querying the OSV API.
+// by parsing lockfiles and matching them against OSV advisories. Advisories
+// come from a local OSVDB when one is set and holds the lockfile's
+// ecosystem; otherwise the OSV API is queried when an HTTP client was
+// given. Lookups are cached per package version, and fetched advisories by
+// ID, for the scanner's lifetime.
 type DepScanner struct {
 	client         *http.Client
 	OSVBaseURL     string
 	parsers        []lockfileParser
+	db             *OSVDB
 	findingCounter int
 	mu             sync.Mutex
+	cache          map[string][]osvVuln
+	vulns          map[string]osvVuln
+	dbAge          time.Duration
+	dbAgeKnown     bool
 }
 
 // NewDepScanner creates a DepScanner. If client is nil, OSV queries are skipped.
@@ -46,117 +93,402 @@ func NewDepScanner(client *http.Client) *DepScanner {
 	s := &DepScanner{
 		client:     client,
 		OSVBaseURL: defaultOSVBaseURL,
+		cache:      make(map[string][]osvVuln),
+		vulns:      make(map[string]osvVuln),
 	}
 	s.parsers = []lockfileParser{
-		{filename: "go.sum", ecosystem: "Go", parse: parseGoSum},
-		{filename: "package-lock.json", ecosystem: "npm", parse: parsePackageLock},
-		{filename: "requirements.txt", ecosystem: "PyPI", parse: parseRequirementsTxt},
-		{filename: "Gemfile.lock", ecosystem: "RubyGems", parse: parseGemfileLock},
-		{filename: "Cargo.lock", ecosystem: "crates.io", parse: parseCargoLock},
-		{filename: "Podfile.lock", ecosystem: "CocoaPods", parse: parsePodfileLock},
+		{filename: "go.mod", ecosystem: "Go", parse: lockfileOnly(parseGoMod)},
+		// From go 1.17 on, go.mod lists every module in the build at its
+		// selected version; go.sum also holds versions that lost selection.
+		// Older go.mod files list direct dependencies only.
+		{filename: "go.sum", ecosystem: "Go", supersededBy: "go.mod", supersedes: goModListsAllModules, parse: lockfileOnly(parseGoSum)},
+		{filename: "package-lock.json", ecosystem: "npm", parse: lockfileOnly(parsePackageLock)},
+		{filename: "yarn.lock", ecosystem: "npm", manifest: "package.json", parse: parseYarnLock},
+		{filename: "pnpm-lock.yaml", ecosystem: "npm", parse: lockfileOnly(parsePnpmLock)},
+		{filename: "requirements.txt", ecosystem: "PyPI", parse: lockfileOnly(parseRequirementsTxt)},
+		{filename: "poetry.lock", ecosystem: "PyPI", manifest: "pyproject.toml", parse: parsePoetryLock},
+		{filename: "uv.lock", ecosystem: "PyPI", parse: lockfileOnly(parseUvLock)},
+		{filename: "Pipfile.lock", ecosystem: "PyPI", manifest: "Pipfile", parse: parsePipfileLock},
+		{filename: "Gemfile.lock", ecosystem: "RubyGems", parse: lockfileOnly(parseGemfileLock)},
+		{filename: "Cargo.lock", ecosystem: "crates.io", parse: lockfileOnly(parseCargoLock)},
+		{filename: "Podfile.lock", ecosystem: "CocoaPods", parse: lockfileOnly(parsePodfileLock)},
+		{filename: "composer.lock", ecosystem: "Packagist", manifest: "composer.json", parse: parseComposerLock},
+		{filename: "pom.xml", ecosystem: "Maven", parse: lockfileOnly(parsePomXML)},
+		{filename: "gradle.lockfile", ecosystem: "Maven", parse: lockfileOnly(parseGradleLockfile)},
+		{filename: "buildscript-gradle.lockfile", ecosystem: "Maven", parse: lockfileOnly(parseGradleLockfile)},
 	}
 	return s
 }
 
+// SetDatabase makes the scanner match dependencies against a local OSV
+// index. The HTTP client, if any, is then only used for ecosystems the
+// index has never imported.
+func (s *DepScanner) SetDatabase(db *OSVDB) {
+	s.db = db
+}
+
+// Ecosystems returns the OSV ecosystems of the lockfiles the scanner
+// understands, in the order it scans them.
+func (s *DepScanner) Ecosystems() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, p := range s.parsers {
+		if !seen[p.ecosystem] {
+			seen[p.ecosystem] = true
+			out = append(out, p.ecosystem)
+		}
+	}
+	return out
+}
+
+// VulnDBAge reports how long ago the local OSV index was last updated,
+// as observed by the most recent Scan. ok is false when no index was
+// consulted.
+func (s *DepScanner) VulnDBAge() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dbAge, s.dbAgeKnown
+}
+
 // Name returns the scanner name.
 func (s *DepScanner) Name() string {
 	return "dependency-audit"
 }
 
-// Scan finds lockfiles in the target directory, parses them, and queries OSV
-// for known vulnerabilities.
+// Scan finds lockfiles anywhere under the target directory, parses them,
+// and looks up known vulnerabilities for every dependency.
 func (s *DepScanner) Scan(ctx context.Context, target security.ScanTarget) ([]security.Finding, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, fmt.Errorf("dependency auditor cancelled: %w", err)
 	}
 
-	var findings []security.Finding
+	if s.db != nil {
+		if updated, ok, err := s.db.UpdatedAt(ctx); err == nil && ok {
+			s.mu.Lock()
+			s.dbAge, s.dbAgeKnown = time.Since(updated), true
+			s.mu.Unlock()
+		}
+	}
+
+	dirs, present := s.findLockfiles(target)
 
-	for _, parser := range s.parsers {
-		if err := ctx.Err(); err != nil {
-			return nil, fmt.Errorf("dependency auditor cancelled: %w", err)
+	var findings []security.Finding
+	for _, dir := range dirs {
+		for _, parser := range s.parsers {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("dependency auditor cancelled: %w", err)
+			}
+			lockfile := filepath.Join(dir, parser.filename)
+			if !present[lockfile] {
+				continue
+			}
+			if parser.supersededBy != "" && superseded(target.RootDir, filepath.Join(dir, parser.supersededBy), parser, present) {
+				continue
+			}
+			findings = append(findings, s.scanLockfile(ctx, target.RootDir, lockfile, parser)...)
 		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("dependency auditor cancelled: %w", err)
+	}
+	return findings, nil
+}
 
-		lockfilePath := filepath.Join(target.RootDir, parser.filename)
-		data, err := os.ReadFile(lockfilePath)
+// superseded reports whether the sibling lockfile, relative to root,
+// replaces parser's.
+func superseded(root, sibling string, parser lockfileParser, present map[string]bool) bool {
+	if !present[sibling] {
+		return false
+	}
+	if parser.supersedes == nil {
+		return true
+	}
+	data, err := os.ReadFile(filepath.Join(root, sibling))
+	return err == nil && parser.supersedes(data)
+}
+
+// lockfileSkipDirs are directories holding installed or vendored packages
+// rather than projects of their own.
+var lockfileSkipDirs = map[string]bool{
+	".git": true, "node_modules": true, "bower_components": true,
+	"vendor": true, ".venv": true, "venv": true,
+}
+
+// findLockfiles walks the target for files any parser understands. It
+// returns the directories holding them, sorted, and the set of lockfile
+// paths relative to RootDir.
+func (s *DepScanner) findLockfiles(target security.ScanTarget) ([]string, map[string]bool) {
+	names := make(map[string]bool, len(s.parsers))
+	for _, p := range s.parsers {
+		names[p.filename] = true
+	}
+
+	present := make(map[string]bool)
+	seenDir := make(map[string]bool)
+	var dirs []string
+	_ = filepath.WalkDir(target.RootDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			continue // lockfile not present — skip silently
+			return nil
+		}
+		rel, relErr := filepath.Rel(target.RootDir, path)
+		if relErr != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if rel != "." && (lockfileSkipDirs[d.Name()] || security.IsExcluded(rel, target.ExcludePatterns)) {
+				return filepath.SkipDir
+			}
+			return nil
 		}
+		if !names[d.Name()] || security.IsExcluded(rel, target.ExcludePatterns) {
+			return nil
+		}
+		present[rel] = true
+		if dir := filepath.Dir(rel); !seenDir[dir] {
+			seenDir[dir] = true
+			dirs = append(dirs, dir)
+		}
+		return nil
+	})
+	sort.Strings(dirs)
+	return dirs, present
+}
 
-		deps, err := parser.parse(data)
-		if err != nil {
-			continue // parse error — skip silently
+// scanLockfile parses one lockfile and reports its vulnerable dependencies.
+// Unreadable or malformed lockfiles are skipped silently.
+func (s *DepScanner) scanLockfile(ctx context.Context, root, lockfile string, parser lockfileParser) []security.Finding {
+	data, err := os.ReadFile(filepath.Join(root, lockfile))
+	if err != nil {
+		return nil
+	}
+	var manifest []byte
+	if parser.manifest != "" {
+		manifest, _ = os.ReadFile(filepath.Join(root, filepath.Dir(lockfile), parser.manifest))
+	}
+	deps, err := parser.parse(data, manifest)
+	if err != nil {
+		return nil
+	}
+
+	var findings []security.Finding
+	vulns, lookupErr := s.lookup(ctx, parser.ecosystem, deps)
+	if ctx.Err() != nil {
+		return nil
+	}
+	for _, dep := range deps {
+		for _, vuln := range vulns[dep] {
+			findings = append(findings, s.newVulnFinding(dep, vuln, classifyOSVSeverity(vuln), lockfile))
 		}
+	}
+	if lookupErr != nil {
+		findings = append(findings, s.newInfoFinding(lookupErr.title, lookupErr.description, lockfile))
+	}
+	return findings
+}
+
+// lookupFailure is a failed advisory lookup, reported as an info finding.
+type lookupFailure struct {
+	title       string
+	description string
+}
 
-		if s.client == nil {
-			continue // no HTTP client — skip OSV queries
+// lookup returns the advisories affecting each dependency, from the cache,
+// the local index or the OSV API in that order. On an API failure the
+// results gathered before it are still returned.
+func (s *DepScanner) lookup(ctx context.Context, ecosystem string, deps []dependency) (map[dependency][]osvVuln, *lookupFailure) {
+	result := make(map[dependency][]osvVuln)
+	var missing []dependency
+	s.mu.Lock()
+	for _, dep := range deps {
+		if vulns, ok := s.cache[cacheKey(ecosystem, dep)]; ok {
+			result[dep] = vulns
+		} else {
+			missing = append(missing, dep)
 		}
+	}
+	s.mu.Unlock()
+	if len(missing) == 0 {
+		return result, nil
+	}
 
-		for _, dep := range deps {
-			if err := ctx.Err(); err != nil {
-				return nil, fmt.Errorf("dependency auditor cancelled: %w", err)
-			}
+	var (
+		found   map[dependency][]osvVuln
+		checked []dependency
+		lerr    *lookupFailure
+	)
+	useDB := false
+	if s.db != nil {
+		has, err := s.db.HasEcosystem(ctx, ecosystem)
+		if err != nil {
+			return result, &lookupFailure{title: "OSV database unavailable", description: fmt.Sprintf("Could not read OSV database: %s", err)}
+		}
+		useDB = has || s.client == nil
+	}
+	switch {
+	case useDB:
+		var err error
+		found, err = s.db.lookup(ctx, ecosystem, missing)
+		if err != nil {
+			return result, &lookupFailure{title: "OSV database unavailable", description: fmt.Sprintf("Could not read OSV database: %s", err)}
+		}
+		checked = missing
+	case s.client != nil:
+		found, checked, lerr = s.queryOSVAll(ctx, ecosystem, missing)
+	default:
+		return result, nil
+	}
+
+	s.mu.Lock()
+	for _, dep := range checked {
+		s.cache[cacheKey(ecosystem, dep)] = found[dep]
+		result[dep] = found[dep]
+	}
+	s.mu.Unlock()
+	return result, lerr
+}
 
-			vulnFindings, err := s.queryOSV(ctx, dep, parser.ecosystem, parser.filename)
-			if err != nil {
-				// OSV unavailable — add an info finding and stop querying this lockfile.
-				findings = append(findings, s.newInfoFinding(
-					"OSV API unavailable",
-					fmt.Sprintf("Could not query OSV API for %s: %s", dep.Name, err),
-					parser.filename,
-				))
-				break
+func cacheKey(ecosystem string, dep dependency) string {
+	return ecosystem + "\x00" + normalizePackageName(ecosystem, dep.Name) + "\x00" + dep.Version
+}
+
+// osvBatchLimit is the most queries the OSV API accepts in one
+// querybatch request.
+const osvBatchLimit = 1000
+
+// queryOSVAll looks the dependencies up with OSV's querybatch endpoint in
+// chunks of osvBatchLimit, then fetches the full record of each advisory
+// found. It stops at the first failure and returns the dependencies it got
+// an answer for.
+func (s *DepScanner) queryOSVAll(ctx context.Context, ecosystem string, deps []dependency) (map[dependency][]osvVuln, []dependency, *lookupFailure) {
+	found := make(map[dependency][]osvVuln)
+	var checked []dependency
+	for start := 0; start < len(deps); start += osvBatchLimit {
+		if ctx.Err() != nil {
+			return found, checked, nil
+		}
+		chunk := deps[start:min(start+osvBatchLimit, len(deps))]
+		ids, err := s.queryOSVBatch(ctx, ecosystem, chunk)
+		if err != nil {
+			// OSV unavailable — stop querying this lockfile.
+			return found, checked, &lookupFailure{
+				title:       "OSV API unavailable",
+				description: fmt.Sprintf("Could not query OSV API: %s", err),
 			}
-			findings = append(findings, vulnFindings...)
+		}
+		for i, dep := range chunk {
+			vulns := make([]osvVuln, 0, len(ids[i]))
+			for _, id := range ids[i] {
+				vuln, err := s.fetchOSVVuln(ctx, id)
+				if err != nil {
+					return found, checked, &lookupFailure{
+						title:       "OSV API unavailable",
+						description: fmt.Sprintf("Could not fetch OSV advisory %s for %s: %s", id, dep.Name, err),
+					}
+				}
+				vulns = append(vulns, vuln)
+			}
+			found[dep] = vulns
+			checked = append(checked, dep)
 		}
 	}
+	return found, checked, nil
+}
 
-	return findings, nil
+// queryOSVBatch sends one querybatch request for deps and returns the IDs
+// of the advisories affecting each, in order. Results that OSV paginates
+// are followed until complete.
+func (s *DepScanner) queryOSVBatch(ctx context.Context, ecosystem string, deps []dependency) ([][]string, error) {
+	ids := make([][]string, len(deps))
+	queries := make([]osvQueryRequest, len(deps))
+	for i, dep := range deps {
+		queries[i] = osvQueryRequest{
+			Package: osvPackage{Name: dep.Name, Ecosystem: ecosystem},
+			Version: dep.Version,
+		}
+	}
+	pending := make([]int, len(deps))
+	for i := range pending {
+		pending[i] = i
+	}
+	for len(pending) > 0 {
+		req := osvBatchRequest{Queries: make([]osvQueryRequest, len(pending))}
+		for j, i := range pending {
+			req.Queries[j] = queries[i]
+		}
+		var resp osvBatchResponse
+		if err := s.callOSV(ctx, http.MethodPost, "/v1/querybatch", req, &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Results) != len(pending) {
+			return nil, fmt.Errorf("OSV API returned %d results for %d queries", len(resp.Results), len(pending))
+		}
+		var next []int
+		for j, result := range resp.Results {
+			i := pending[j]
+			for _, v := range result.Vulns {
+				ids[i] = append(ids[i], v.ID)
+			}
+			if result.NextPageToken != "" {
+				queries[i].PageToken = result.NextPageToken
+				next = append(next, i)
+			}
+		}
+		pending = next
+	}
+	return ids, nil
 }
 
-// queryOSV queries the OSV API for vulnerabilities affecting the given dependency.
-func (s *DepScanner) queryOSV(ctx context.Context, dep dependency, ecosystem, lockfile string) ([]security.Finding, error) {
-	reqBody := osvQueryRequest{
-		Package: osvPackage{
-			Name:      dep.Name,
-			Ecosystem: ecosystem,
-		},
-		Version: dep.Version,
+// fetchOSVVuln returns the full OSV record for an advisory ID. Records are
+// cached for the scanner's lifetime since many packages share advisories.
+func (s *DepScanner) fetchOSVVuln(ctx context.Context, id string) (osvVuln, error) {
+	s.mu.Lock()
+	vuln, ok := s.vulns[id]
+	s.mu.Unlock()
+	if ok {
+		return vuln, nil
 	}
+	if err := s.callOSV(ctx, http.MethodGet, "/v1/vulns/"+url.PathEscape(id), nil, &vuln); err != nil {
+		return osvVuln{}, err
+	}
+	s.mu.Lock()
+	s.vulns[id] = vuln
+	s.mu.Unlock()
+	return vuln, nil
+}
 
-	bodyBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("marshaling OSV request: %w", err)
+// callOSV sends a request to the OSV API and decodes the JSON response
+// into out. A nil body sends no request body.
+func (s *DepScanner) callOSV(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshaling OSV request: %w", err)
+		}
+		reader = bytes.NewReader(data)
 	}
 
-	url := s.OSVBaseURL + "/v1/query"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, method, s.OSVBaseURL+path, reader)
 	if err != nil {
-		return nil, fmt.Errorf("creating OSV request: %w", err)
+		return fmt.Errorf("creating OSV request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("OSV API request failed: %w", err)
+		return fmt.Errorf("OSV API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OSV API returned status %d", resp.StatusCode)
+		return fmt.Errorf("OSV API returned status %d", resp.StatusCode)
 	}
-
-	var osvResp osvQueryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&osvResp); err != nil {
-		return nil, fmt.Errorf("decoding OSV response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding OSV response: %w", err)
 	}
-
-	var findings []security.Finding
-	for _, vuln := range osvResp.Vulns {
-		sev := classifyOSVSeverity(vuln)
-		findings = append(findings, s.newVulnFinding(dep, vuln, sev, lockfile))
-	}
-	return findings, nil
+	return nil
 }
 
 // newVulnFinding creates a Finding for a discovered vulnerability.
@@ -171,6 +503,27 @@ func (s *DepScanner) newVulnFinding(dep dependency, vuln osvVuln, sev security.S
 		refs = append(refs, r.URL)
 	}
 
+	evidence := fmt.Sprintf("Package %s@%s has known vulnerability %s", dep.Name, dep.Version, vuln.ID)
+	metadata := map[string]string{
+		"vuln_id":   vuln.ID,
+		"package":   dep.Name,
+		"version":   dep.Version,
+		"ecosystem": lockfile,
+	}
+	switch dep.Relation {
+	case relationDirect:
+		evidence += " (direct dependency)"
+		metadata["dependency_type"] = "direct"
+	case relationTransitive:
+		if dep.Path != "" {
+			evidence += fmt.Sprintf(" (pulled in by %s: %s)", strings.SplitN(dep.Path, " > ", 2)[0], dep.Path)
+			metadata["dependency_path"] = dep.Path
+		} else {
+			evidence += " (indirect dependency)"
+		}
+		metadata["dependency_type"] = "transitive"
+	}
+
 	return security.Finding{
 		ID:          id,
 		Scanner:     "dependency-audit",
@@ -182,15 +535,10 @@ func (s *DepScanner) newVulnFinding(dep dependency, vuln osvVuln, sev security.S
 			File: lockfile,
 		},
 		CWE:        "CWE-1035",
-		Evidence:   fmt.Sprintf("Package %s@%s has known vulnerability %s", dep.Name, dep.Version, vuln.ID),
+		Evidence:   evidence,
 		Confidence: security.ConfidenceHigh,
 		References: refs,
-		Metadata: map[string]string{
-			"vuln_id":   vuln.ID,
-			"package":   dep.Name,
-			"version":   dep.Version,
-			"ecosystem": lockfile,
-		},
+		Metadata:   metadata,
 	}
 }
 
@@ -248,8 +596,9 @@ func parseCVSSScore(s string) float64 {
 // ─── OSV API types ──────────────────────────────────────────────────────────
 
 type osvQueryRequest struct {
-	Package osvPackage `json:"package"`
-	Version string     `json:"version"`
+	Package   osvPackage `json:"package"`
+	Version   string     `json:"version"`
+	PageToken string     `json:"page_token,omitempty"`
 }
 
 type osvPackage struct {
@@ -257,13 +606,30 @@ type osvPackage struct {
 	Ecosystem string `json:"ecosystem"`
 }
 
-type osvQueryResponse struct {
-	Vulns []osvVuln `json:"vulns"`
+type osvBatchRequest struct {
+	Queries []osvQueryRequest `json:"queries"`
+}
+
+type osvBatchResponse struct {
+	Results []osvBatchResult `json:"results"`
+}
+
+// osvBatchResult lists the advisories matching one query by ID only; the
+// full records come from the vulns endpoint.
+type osvBatchResult struct {
+	Vulns         []osvVulnRef `json:"vulns"`
+	NextPageToken string       `json:"next_page_token,omitempty"`
+}
+
+type osvVulnRef struct {
+	ID       string `json:"id"`
+	Modified string `json:"modified"`
 }
 
 type osvVuln struct {
 	ID         string         `json:"id"`
 	Summary    string         `json:"summary"`
+	Withdrawn  string         `json:"withdrawn,omitempty"`
 	Severity   []osvSeverity  `json:"severity"`
 	Affected   []osvAffected  `json:"affected"`
 	References []osvReference `json:"references"`
@@ -275,8 +641,9 @@ type osvSeverity struct {
 }
 
 type osvAffected struct {
-	Package osvAffectedPackage `json:"package"`
-	Ranges  []osvRange         `json:"ranges"`
+	Package  osvAffectedPackage `json:"package"`
+	Ranges   []osvRange         `json:"ranges"`
+	Versions []string           `json:"versions,omitempty"`
 }
 
 type osvAffectedPackage struct {
@@ -290,8 +657,10 @@ type osvRange struct {
 }
 
 type osvEvent struct {
-	Introduced string `json:"introduced,omitempty"`
-	Fixed      string `json:"fixed,omitempty"`
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
 }
 
 type osvReference struct {
@@ -343,6 +712,8 @@ func parseGoSum(data []byte) ([]dependency, error) {
 
 // parsePackageLock extracts packages from a package-lock.json file.
 // Supports both lockfileVersion 3 ("packages") and v1 ("dependencies").
+// For v2/v3 the dependency path of each package is resolved the way npm
+// does: from the requiring package's node_modules outwards.
 func parsePackageLock(data []byte) ([]dependency, error) {
 	var lockfile struct {
 		Packages     map[string]packageLockEntry `json:"packages"`
@@ -354,6 +725,8 @@ func parsePackageLock(data []byte) ([]dependency, error) {
 	}
 
 	var deps []dependency
+	graph := newDepGraph()
+	keys := make(map[string]string) // package path -> dependency key
 
 	// lockfileVersion 2/3: uses "packages" with "node_modules/" prefixes.
 	for key, entry := range lockfile.Packages {
@@ -369,7 +742,30 @@ func parsePackageLock(data []byte) ([]dependency, error) {
 			continue
 		}
 		deps = append(deps, dependency{Name: name, Version: entry.Version})
+		keys[key] = depKey(name, entry.Version)
 	}
+	for path, entry := range lockfile.Packages {
+		from, isPackage := keys[path]
+		if !isPackage && path != "" {
+			continue
+		}
+		required := entry.requires()
+		if path == "" {
+			required = append(required, keysOf(entry.DevDependencies)...)
+		}
+		for _, name := range required {
+			resolved, ok := resolveNodeModule(lockfile.Packages, path, name)
+			if !ok || keys[resolved] == "" {
+				continue
+			}
+			if path == "" {
+				graph.addDirect(keys[resolved])
+			} else {
+				graph.addEdge(from, keys[resolved])
+			}
+		}
+	}
+	graph.annotate(deps)
 
 	// lockfileVersion 1: uses "dependencies" with flat names.
 	if len(deps) == 0 {
@@ -385,8 +781,42 @@ func parsePackageLock(data []byte) ([]dependency, error) {
 }
 
 type packageLockEntry struct {
-	Version  string `json:"version"`
-	Resolved string `json:"resolved"`
+	Version              string            `json:"version"`
+	Resolved             string            `json:"resolved"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+}
+
+// requires returns the names of the packages an entry needs installed.
+func (e packageLockEntry) requires() []string {
+	names := keysOf(e.Dependencies)
+	names = append(names, keysOf(e.OptionalDependencies)...)
+	return append(names, keysOf(e.PeerDependencies)...)
+}
+
+// resolveNodeModule finds the package path npm would load name from when
+// required by the package at from, searching each enclosing node_modules
+// up to the project root.
+func resolveNodeModule(packages map[string]packageLockEntry, from, name string) (string, bool) {
+	for {
+		candidate := "node_modules/" + name
+		if from != "" {
+			candidate = from + "/node_modules/" + name
+		}
+		if _, ok := packages[candidate]; ok {
+			return candidate, true
+		}
+		if from == "" {
+			return "", false
+		}
+		if idx := strings.LastIndex(from, "/node_modules/"); idx >= 0 {
+			from = from[:idx]
+		} else {
+			from = ""
+		}
+	}
 }
 
 // parseRequirementsTxt extracts package==version from requirements.txt.
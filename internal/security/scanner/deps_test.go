@@ -3,7 +3,10 @@ package scanner
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/julianshen/rubichan/internal/security"
@@ -25,56 +28,89 @@ func TestDepScannerInterface(t *testing.T) {
 	var _ security.StaticScanner = NewDepScanner(nil)
 }
 
+// newOSVServer fakes the OSV API's querybatch and vulns endpoints, asking
+// vulnsFor for the advisories matching each query. A nil vulnsFor matches
+// nothing.
+func newOSVServer(t *testing.T, vulnsFor func(osvQueryRequest) []osvVuln) *testutil.Server {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		records = make(map[string]osvVuln)
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/querybatch", func(w http.ResponseWriter, r *http.Request) {
+		var req osvBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		resp := osvBatchResponse{Results: make([]osvBatchResult, len(req.Queries))}
+		for i, q := range req.Queries {
+			if vulnsFor == nil {
+				continue
+			}
+			for _, v := range vulnsFor(q) {
+				mu.Lock()
+				records[v.ID] = v
+				mu.Unlock()
+				resp.Results[i].Vulns = append(resp.Results[i].Vulns, osvVulnRef{ID: v.ID})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("GET /v1/vulns/{id}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		v, ok := records[r.PathValue("id")]
+		mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	})
+	return testutil.NewServer(t, mux)
+}
+
 func TestDepScannerParsesGoSum(t *testing.T) {
 	t.Parallel()
 
 	// Mock OSV server that returns a vulnerability for "golang.org/x/text".
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var req osvQueryRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "bad request", http.StatusBadRequest)
-			return
+	srv := newOSVServer(t, func(req osvQueryRequest) []osvVuln {
+		if req.Package.Name != "golang.org/x/text" {
+			return nil
 		}
-		if req.Package.Name == "golang.org/x/text" {
-			resp := osvQueryResponse{
-				Vulns: []osvVuln{
+		return []osvVuln{
+			{
+				ID:      "GO-2022-1059",
+				Summary: "Denial of service via crafted Accept-Language header",
+				Severity: []osvSeverity{
+					{Type: "CVSS_V3", Score: "7.5"},
+				},
+				Affected: []osvAffected{
 					{
-						ID:      "GO-2022-1059",
-						Summary: "Denial of service via crafted Accept-Language header",
-						Severity: []osvSeverity{
-							{Type: "CVSS_V3", Score: "7.5"},
+						Package: osvAffectedPackage{
+							Name:      "golang.org/x/text",
+							Ecosystem: "Go",
 						},
-						Affected: []osvAffected{
+						Ranges: []osvRange{
 							{
-								Package: osvAffectedPackage{
-									Name:      "golang.org/x/text",
-									Ecosystem: "Go",
-								},
-								Ranges: []osvRange{
-									{
-										Type: "SEMVER",
-										Events: []osvEvent{
-											{Introduced: "0"},
-											{Fixed: "0.3.8"},
-										},
-									},
+								Type: "SEMVER",
+								Events: []osvEvent{
+									{Introduced: "0"},
+									{Fixed: "0.3.8"},
 								},
 							},
 						},
-						References: []osvReference{
-							{Type: "ADVISORY", URL: "https://nvd.nist.gov/vuln/detail/CVE-2022-32149"},
-						},
 					},
 				},
-			}
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(resp)
-			return
+				References: []osvReference{
+					{Type: "ADVISORY", URL: "https://nvd.nist.gov/vuln/detail/CVE-2022-32149"},
+				},
+			},
 		}
-		// No vulns for other packages.
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(osvQueryResponse{})
-	}))
+	})
 	defer srv.Close()
 
 	dir := t.TempDir()
@@ -108,10 +144,7 @@ func TestDepScannerParsesPackageLock(t *testing.T) {
 	t.Parallel()
 
 	// Mock OSV server that returns no vulnerabilities.
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(osvQueryResponse{})
-	}))
+	srv := newOSVServer(t, nil)
 	defer srv.Close()
 
 	dir := t.TempDir()
@@ -202,31 +235,20 @@ golang.org/x/text v0.3.7/go.mod h1:u+2+/6zg+i71rQMx5EYifcz6MCKuco9NR6JIITiCfzQ=
 func TestDepScannerParsesRequirementsTxt(t *testing.T) {
 	t.Parallel()
 
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var req osvQueryRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "bad request", http.StatusBadRequest)
-			return
+	srv := newOSVServer(t, func(req osvQueryRequest) []osvVuln {
+		if req.Package.Name != "django" {
+			return nil
 		}
-		if req.Package.Name == "django" {
-			resp := osvQueryResponse{
-				Vulns: []osvVuln{
-					{
-						ID:      "PYSEC-2023-100",
-						Summary: "SQL injection in Django ORM",
-						Severity: []osvSeverity{
-							{Type: "CVSS_V3", Score: "9.8"},
-						},
-					},
+		return []osvVuln{
+			{
+				ID:      "PYSEC-2023-100",
+				Summary: "SQL injection in Django ORM",
+				Severity: []osvSeverity{
+					{Type: "CVSS_V3", Score: "9.8"},
 				},
-			}
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(resp)
-			return
+			},
 		}
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(osvQueryResponse{})
-	}))
+	})
 	defer srv.Close()
 
 	dir := t.TempDir()
@@ -256,10 +278,7 @@ flask==2.3.0
 func TestDepScannerParsesGemfileLock(t *testing.T) {
 	t.Parallel()
 
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(osvQueryResponse{})
-	}))
+	srv := newOSVServer(t, nil)
 	defer srv.Close()
 
 	dir := t.TempDir()
@@ -291,10 +310,7 @@ DEPENDENCIES
 func TestDepScannerParsesCargoLock(t *testing.T) {
 	t.Parallel()
 
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(osvQueryResponse{})
-	}))
+	srv := newOSVServer(t, nil)
 	defer srv.Close()
 
 	dir := t.TempDir()
@@ -321,10 +337,7 @@ source = "registry+https://github.com/rust-lang/crates.io-index"
 func TestDepScannerParsesPodfileLock(t *testing.T) {
 	t.Parallel()
 
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(osvQueryResponse{})
-	}))
+	srv := newOSVServer(t, nil)
 	defer srv.Close()
 
 	dir := t.TempDir()
@@ -354,10 +367,7 @@ func TestDepScannerPackageLockV1Dependencies(t *testing.T) {
 	t.Parallel()
 
 	// Test parsing the older lockfileVersion 1 format with "dependencies" field.
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(osvQueryResponse{})
-	}))
+	srv := newOSVServer(t, nil)
 	defer srv.Close()
 
 	dir := t.TempDir()
@@ -498,6 +508,67 @@ func TestDepScannerQueryOSVInvalidJSON(t *testing.T) {
 	assert.Contains(t, findings[0].Title, "OSV API unavailable")
 }
 
+func TestDepScannerBatchesOSVQueries(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu        sync.Mutex
+		batches   []int
+		vulnGets  int
+		paginated bool
+	)
+	advisory := osvVuln{ID: "GO-2024-0001", Summary: "shared advisory"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/querybatch", func(w http.ResponseWriter, r *http.Request) {
+		var req osvBatchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, len(req.Queries))
+		resp := osvBatchResponse{Results: make([]osvBatchResult, len(req.Queries))}
+		for i, q := range req.Queries {
+			switch {
+			case q.Package.Name == "example.com/m0" && q.PageToken == "":
+				// The first page ends early; the advisory is on the next one.
+				resp.Results[i].NextPageToken = "page-2"
+			case q.Package.Name == "example.com/m0" && q.PageToken == "page-2":
+				paginated = true
+				resp.Results[i].Vulns = []osvVulnRef{{ID: advisory.ID}}
+			case q.Package.Name == "example.com/m1000":
+				resp.Results[i].Vulns = []osvVulnRef{{ID: advisory.ID}}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("GET /v1/vulns/{id}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		vulnGets++
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(advisory)
+	})
+	srv := testutil.NewServer(t, mux)
+
+	var goSum strings.Builder
+	for i := 0; i <= osvBatchLimit; i++ {
+		fmt.Fprintf(&goSum, "example.com/m%d v1.0.0 h1:abc=\n", i)
+	}
+	dir := t.TempDir()
+	writeFile(t, dir, "go.sum", goSum.String())
+
+	s := NewDepScanner(srv.Client())
+	s.OSVBaseURL = srv.URL
+	findings, err := s.Scan(context.Background(), security.ScanTarget{RootDir: dir})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{osvBatchLimit, 1, 1}, batches, "one request per chunk plus one for the next page")
+	assert.True(t, paginated)
+	assert.Equal(t, 1, vulnGets, "the shared advisory is fetched once")
+	require.Len(t, findings, 2)
+	for _, f := range findings {
+		assert.Equal(t, advisory.ID, f.Metadata["vuln_id"])
+	}
+}
+
 func TestDepScannerPackageLockEmptyVersion(t *testing.T) {
 	t.Parallel()
 
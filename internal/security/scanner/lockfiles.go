@@ -0,0 +1,883 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"go/version"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+// ─── Dependency graph ───────────────────────────────────────────────────────
+
+// depGraph records which packages a lockfile's entries depend on, so a
+// finding can name the direct dependency that pulled a package in. Nodes
+// are depKey strings.
+type depGraph struct {
+	direct []string
+	edges  map[string][]string
+}
+
+func newDepGraph() *depGraph {
+	return &depGraph{edges: make(map[string][]string)}
+}
+
+func depKey(name, version string) string {
+	return name + "@" + version
+}
+
+func (g *depGraph) addDirect(key string) {
+	g.direct = append(g.direct, key)
+}
+
+func (g *depGraph) addEdge(from, to string) {
+	g.edges[from] = append(g.edges[from], to)
+}
+
+// annotate sets Relation and Path on every dependency reachable from a
+// direct one, using the shortest chain. Dependencies the graph cannot
+// reach keep relationUnknown. Without any direct dependencies the graph
+// says nothing, and deps are left untouched.
+func (g *depGraph) annotate(deps []dependency) {
+	if len(g.direct) == 0 {
+		return
+	}
+	names := make(map[string]string, len(deps))
+	for _, d := range deps {
+		names[depKey(d.Name, d.Version)] = d.Name
+	}
+
+	parent := make(map[string]string)
+	visited := make(map[string]bool)
+	var queue []string
+	for _, k := range g.direct {
+		if !visited[k] {
+			visited[k] = true
+			queue = append(queue, k)
+		}
+	}
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		children := append([]string(nil), g.edges[k]...)
+		sort.Strings(children) // deterministic paths
+		for _, c := range children {
+			if !visited[c] {
+				visited[c] = true
+				parent[c] = k
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	for i := range deps {
+		key := depKey(deps[i].Name, deps[i].Version)
+		if !visited[key] {
+			continue
+		}
+		p, ok := parent[key]
+		if !ok {
+			deps[i].Relation = relationDirect
+			continue
+		}
+		chain := []string{deps[i].Name}
+		for ; ok; p, ok = parent[p] {
+			chain = append(chain, names[p])
+		}
+		for l, r := 0, len(chain)-1; l < r; l, r = l+1, r-1 {
+			chain[l], chain[r] = chain[r], chain[l]
+		}
+		deps[i].Relation = relationTransitive
+		deps[i].Path = strings.Join(chain, " > ")
+	}
+}
+
+// dedupeDeps drops repeated name@version pairs, keeping the first.
+func dedupeDeps(deps []dependency) []dependency {
+	seen := make(map[string]bool, len(deps))
+	out := deps[:0]
+	for _, d := range deps {
+		key := depKey(d.Name, d.Version)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, d)
+	}
+	return out
+}
+
+func keysOf[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+// ─── Go ─────────────────────────────────────────────────────────────────────
+
+// parseGoMod extracts required modules from go.mod, applying replace
+// directives. Modules replaced by a local directory are not published and
+// are skipped. go.mod marks requirements the main module does not import
+// as "// indirect" but does not say which module needs them.
+func parseGoMod(data []byte) ([]dependency, error) {
+	f, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing go.mod: %w", err)
+	}
+
+	var deps []dependency
+	for _, req := range f.Require {
+		name, version := req.Mod.Path, req.Mod.Version
+		if rep := goModReplacement(f.Replace, req.Mod.Path, req.Mod.Version); rep != nil {
+			name, version = rep.New.Path, rep.New.Version
+		}
+		if version == "" {
+			continue // replaced by a local directory
+		}
+		relation := relationDirect
+		if req.Indirect {
+			relation = relationTransitive
+		}
+		deps = append(deps, dependency{Name: name, Version: strings.TrimPrefix(version, "v"), Relation: relation})
+	}
+	return dedupeDeps(deps), nil
+}
+
+// goModListsAllModules reports whether a go.mod lists the module's whole
+// build graph, which the go command does from go 1.17 on.
+func goModListsAllModules(data []byte) bool {
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil || f.Go == nil {
+		return false
+	}
+	return version.Compare("go"+f.Go.Version, "go1.17") >= 0
+}
+
+// goModReplacement returns the replace directive applying to path@version.
+// A version-specific replace wins over one for every version.
+func goModReplacement(replaces []*modfile.Replace, path, version string) *modfile.Replace {
+	var match *modfile.Replace
+	for _, rep := range replaces {
+		if rep.Old.Path != path {
+			continue
+		}
+		if rep.Old.Version == version {
+			return rep
+		}
+		if rep.Old.Version == "" {
+			match = rep
+		}
+	}
+	return match
+}
+
+// ─── npm ────────────────────────────────────────────────────────────────────
+
+// packageJSON is the part of package.json naming direct dependencies.
+type packageJSON struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// descriptors returns the name@range descriptors yarn keys entries by.
+func (p packageJSON) descriptors() []string {
+	var out []string
+	for _, m := range []map[string]string{p.Dependencies, p.DevDependencies, p.OptionalDependencies} {
+		for name, rng := range m {
+			out = append(out, name+"@"+rng)
+		}
+	}
+	return out
+}
+
+// descriptorName returns the package name of a name@range descriptor,
+// allowing for scoped names such as @babel/core@^7.0.0.
+func descriptorName(descriptor string) string {
+	if idx := strings.LastIndex(descriptor, "@"); idx > 0 {
+		return descriptor[:idx]
+	}
+	return descriptor
+}
+
+// parseYarnLock extracts packages from a yarn.lock, in either the classic
+// v1 format or the YAML format of Yarn 2+ ("berry"). Direct dependencies
+// come from the sibling package.json for v1 and from the workspace
+// entries for berry.
+func parseYarnLock(data, manifest []byte) ([]dependency, error) {
+	if bytes.Contains(data, []byte("\n__metadata:")) || bytes.HasPrefix(data, []byte("__metadata:")) {
+		return parseYarnBerryLock(data)
+	}
+
+	var (
+		deps        []dependency
+		descriptors = make(map[string]string) // name@range -> key
+		children    = make(map[string][]string)
+		current     []string
+		version     string
+		requires    []string
+		inDeps      bool
+	)
+	flush := func() {
+		if len(current) > 0 && version != "" {
+			name := descriptorName(current[0])
+			key := depKey(name, version)
+			deps = append(deps, dependency{Name: name, Version: version})
+			for _, d := range current {
+				descriptors[d] = key
+			}
+			children[key] = append(children[key], requires...)
+		}
+		current, version, requires, inDeps = nil, "", nil, false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		text := strings.TrimSpace(line)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		switch indent := len(line) - len(strings.TrimLeft(line, " ")); {
+		case indent == 0:
+			flush()
+			for _, d := range strings.Split(strings.TrimSuffix(text, ":"), ",") {
+				current = append(current, strings.Trim(strings.TrimSpace(d), `"`))
+			}
+		case indent == 2:
+			inDeps = text == "dependencies:" || text == "optionalDependencies:"
+			if v, ok := strings.CutPrefix(text, "version "); ok {
+				version = strings.Trim(v, `"`)
+			}
+		case inDeps:
+			name, rng, ok := strings.Cut(text, " ")
+			if ok {
+				requires = append(requires, strings.Trim(name, `"`)+"@"+strings.Trim(strings.TrimSpace(rng), `"`))
+			}
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	graph := newDepGraph()
+	for key, reqs := range children {
+		for _, d := range reqs {
+			if to, ok := descriptors[d]; ok {
+				graph.addEdge(key, to)
+			}
+		}
+	}
+	var pkg packageJSON
+	if len(manifest) > 0 && json.Unmarshal(manifest, &pkg) == nil {
+		for _, d := range pkg.descriptors() {
+			if key, ok := descriptors[d]; ok {
+				graph.addDirect(key)
+			}
+		}
+	}
+	deps = dedupeDeps(deps)
+	graph.annotate(deps)
+	return deps, nil
+}
+
+type yarnBerryEntry struct {
+	Version              string            `yaml:"version"`
+	Resolution           string            `yaml:"resolution"`
+	Dependencies         map[string]string `yaml:"dependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+}
+
+// parseYarnBerryLock parses a Yarn 2+ lockfile. Entries resolved through
+// anything but the npm registry (workspaces, links, patches, git) are not
+// reported; the workspaces' own dependencies are the direct ones.
+func parseYarnBerryLock(data []byte) ([]dependency, error) {
+	var lock map[string]yarnBerryEntry
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing yarn.lock: %w", err)
+	}
+	delete(lock, "__metadata")
+
+	var deps []dependency
+	descriptors := make(map[string]string)
+	var workspaces []yarnBerryEntry
+	for spec, entry := range lock {
+		if strings.Contains(entry.Resolution, "@workspace:") {
+			workspaces = append(workspaces, entry)
+			continue
+		}
+		if entry.Version == "" || !strings.Contains(entry.Resolution, "@npm:") {
+			continue
+		}
+		// The resolution is name@npm:version.
+		name := strings.TrimSuffix(descriptorName(entry.Resolution), "@npm")
+		key := depKey(name, entry.Version)
+		deps = append(deps, dependency{Name: name, Version: entry.Version})
+		for _, d := range strings.Split(spec, ",") {
+			descriptors[strings.TrimSpace(d)] = key
+		}
+	}
+
+	// Dependencies are written without the default npm: protocol.
+	resolve := func(name, rng string) (string, bool) {
+		if key, ok := descriptors[name+"@"+rng]; ok {
+			return key, true
+		}
+		key, ok := descriptors[name+"@npm:"+rng]
+		return key, ok
+	}
+	graph := newDepGraph()
+	for spec, entry := range lock {
+		from, ok := descriptors[strings.TrimSpace(strings.Split(spec, ",")[0])]
+		if !ok {
+			continue
+		}
+		for _, m := range []map[string]string{entry.Dependencies, entry.OptionalDependencies} {
+			for name, rng := range m {
+				if to, ok := resolve(name, rng); ok {
+					graph.addEdge(from, to)
+				}
+			}
+		}
+	}
+	for _, ws := range workspaces {
+		for _, m := range []map[string]string{ws.Dependencies, ws.OptionalDependencies} {
+			for name, rng := range m {
+				if key, ok := resolve(name, rng); ok {
+					graph.addDirect(key)
+				}
+			}
+		}
+	}
+	deps = dedupeDeps(deps)
+	graph.annotate(deps)
+	return deps, nil
+}
+
+// pnpmLock covers pnpm lockfile versions 5 through 9. Single-project
+// lockfiles before v9 list the root's dependencies at the top level;
+// workspaces and v9 list them per importer. v9 moves each package's
+// dependencies from packages to snapshots.
+type pnpmLock struct {
+	Importers    map[string]pnpmImporter `yaml:"importers"`
+	pnpmImporter `yaml:",inline"`
+	Packages     map[string]pnpmPackage `yaml:"packages"`
+	Snapshots    map[string]pnpmPackage `yaml:"snapshots"`
+}
+
+type pnpmImporter struct {
+	Dependencies         map[string]pnpmDepRef `yaml:"dependencies"`
+	DevDependencies      map[string]pnpmDepRef `yaml:"devDependencies"`
+	OptionalDependencies map[string]pnpmDepRef `yaml:"optionalDependencies"`
+}
+
+type pnpmPackage struct {
+	Dependencies         map[string]string `yaml:"dependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+}
+
+// pnpmDepRef is an importer's resolved version: a bare string before
+// lockfile v6 and a {specifier, version} mapping since.
+type pnpmDepRef string
+
+func (r *pnpmDepRef) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*r = pnpmDepRef(node.Value)
+		return nil
+	}
+	var v struct {
+		Version string `yaml:"version"`
+	}
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	*r = pnpmDepRef(v.Version)
+	return nil
+}
+
+// parsePnpmLock extracts packages from pnpm-lock.yaml.
+func parsePnpmLock(data []byte) ([]dependency, error) {
+	var lock pnpmLock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing pnpm-lock.yaml: %w", err)
+	}
+
+	var deps []dependency
+	for key := range lock.Packages {
+		if name, version, ok := pnpmPackageKey(key); ok {
+			deps = append(deps, dependency{Name: name, Version: version})
+		}
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		return depKey(deps[i].Name, deps[i].Version) < depKey(deps[j].Name, deps[j].Version)
+	})
+
+	graph := newDepGraph()
+	snapshots := lock.Snapshots
+	if snapshots == nil {
+		snapshots = lock.Packages
+	}
+	for key, pkg := range snapshots {
+		name, version, ok := pnpmPackageKey(key)
+		if !ok {
+			continue
+		}
+		for _, m := range []map[string]string{pkg.Dependencies, pkg.OptionalDependencies} {
+			for dep, ref := range m {
+				if v, ok := pnpmVersion(ref); ok {
+					graph.addEdge(depKey(name, version), depKey(dep, v))
+				}
+			}
+		}
+	}
+	importers := append([]pnpmImporter{lock.pnpmImporter}, valuesOf(lock.Importers)...)
+	for _, imp := range importers {
+		for _, m := range []map[string]pnpmDepRef{imp.Dependencies, imp.DevDependencies, imp.OptionalDependencies} {
+			for dep, ref := range m {
+				if v, ok := pnpmVersion(string(ref)); ok {
+					graph.addDirect(depKey(dep, v))
+				}
+			}
+		}
+	}
+	deps = dedupeDeps(deps)
+	graph.annotate(deps)
+	return deps, nil
+}
+
+// pnpmPackageKey splits a packages/snapshots key into name and version:
+// "/name/1.0.0_peer" (v5), "/name@1.0.0(peer@2.0.0)" (v6) or
+// "name@1.0.0(peer@2.0.0)" (v9).
+func pnpmPackageKey(key string) (name, version string, ok bool) {
+	k := strings.TrimPrefix(key, "/")
+	if i := strings.Index(k, "("); i >= 0 {
+		k = k[:i]
+	}
+	// v5 keys end in /version, where the version may carry an _peer
+	// suffix that itself contains "@".
+	if slash := strings.LastIndex(k, "/"); slash > 0 {
+		v, _, _ := strings.Cut(k[slash+1:], "_")
+		if v != "" && v[0] >= '0' && v[0] <= '9' && !strings.Contains(v, "@") {
+			return k[:slash], v, true
+		}
+	}
+	if at := strings.LastIndex(k, "@"); at > 0 {
+		return k[:at], k[at+1:], !strings.Contains(k[at+1:], ":")
+	}
+	return "", "", false
+}
+
+// pnpmVersion strips peer suffixes from a dependency reference. Links,
+// aliases and tarball references do not name a registry version.
+func pnpmVersion(ref string) (string, bool) {
+	if ref == "" || strings.Contains(ref, ":") || strings.HasPrefix(ref, "/") {
+		return "", false
+	}
+	if i := strings.Index(ref, "("); i >= 0 {
+		ref = ref[:i]
+	}
+	ref, _, _ = strings.Cut(ref, "_")
+	return ref, true
+}
+
+func valuesOf[V any](m map[string]V) []V {
+	out := make([]V, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	return out
+}
+
+// ─── Python ─────────────────────────────────────────────────────────────────
+
+// requirementName matches the project name at the start of a PEP 508
+// requirement such as "requests[socks]>=2.0; python_version>'3'".
+var requirementName = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// pythonDirectNames returns the normalized names pyproject.toml declares as
+// dependencies, under PEP 621, PEP 735 dependency groups or Poetry.
+func pythonDirectNames(pyproject []byte) []string {
+	var py struct {
+		Project struct {
+			Dependencies         []string            `toml:"dependencies"`
+			OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+		} `toml:"project"`
+		DependencyGroups map[string][]any `toml:"dependency-groups"`
+		Tool             struct {
+			Poetry struct {
+				Dependencies    map[string]any `toml:"dependencies"`
+				DevDependencies map[string]any `toml:"dev-dependencies"`
+				Group           map[string]struct {
+					Dependencies map[string]any `toml:"dependencies"`
+				} `toml:"group"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+	if len(pyproject) == 0 {
+		return nil
+	}
+	if _, err := toml.Decode(string(pyproject), &py); err != nil {
+		return nil
+	}
+
+	var names []string
+	addRequirements := func(reqs []string) {
+		for _, r := range reqs {
+			if m := requirementName.FindStringSubmatch(r); m != nil {
+				names = append(names, m[1])
+			}
+		}
+	}
+	addRequirements(py.Project.Dependencies)
+	for _, reqs := range py.Project.OptionalDependencies {
+		addRequirements(reqs)
+	}
+	for _, group := range py.DependencyGroups {
+		for _, r := range group {
+			if s, ok := r.(string); ok { // tables are {include-group = ...}
+				addRequirements([]string{s})
+			}
+		}
+	}
+	poetry := py.Tool.Poetry
+	tables := []map[string]any{poetry.Dependencies, poetry.DevDependencies}
+	for _, g := range poetry.Group {
+		tables = append(tables, g.Dependencies)
+	}
+	for _, t := range tables {
+		for name := range t {
+			if name != "python" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for i, n := range names {
+		names[i] = normalizePackageName("PyPI", n)
+	}
+	return names
+}
+
+// pythonGraph links Python packages by normalized name; Python lockfiles
+// pin one version of each package.
+type pythonGraph struct {
+	*depGraph
+	byName map[string]string
+}
+
+func newPythonGraph(deps []dependency) pythonGraph {
+	g := pythonGraph{depGraph: newDepGraph(), byName: make(map[string]string, len(deps))}
+	for _, d := range deps {
+		g.byName[normalizePackageName("PyPI", d.Name)] = depKey(d.Name, d.Version)
+	}
+	return g
+}
+
+func (g pythonGraph) link(from, to string) {
+	if key, ok := g.byName[normalizePackageName("PyPI", to)]; ok {
+		g.addEdge(from, key)
+	}
+}
+
+func (g pythonGraph) direct(name string) {
+	if key, ok := g.byName[normalizePackageName("PyPI", name)]; ok {
+		g.addDirect(key)
+	}
+}
+
+// parsePoetryLock extracts packages from poetry.lock, taking the direct
+// dependencies from the sibling pyproject.toml.
+func parsePoetryLock(data, manifest []byte) ([]dependency, error) {
+	var lock struct {
+		Package []struct {
+			Name         string         `toml:"name"`
+			Version      string         `toml:"version"`
+			Dependencies map[string]any `toml:"dependencies"`
+		} `toml:"package"`
+	}
+	if _, err := toml.Decode(string(data), &lock); err != nil {
+		return nil, fmt.Errorf("parsing poetry.lock: %w", err)
+	}
+
+	var deps []dependency
+	for _, p := range lock.Package {
+		if p.Name != "" && p.Version != "" {
+			deps = append(deps, dependency{Name: p.Name, Version: p.Version})
+		}
+	}
+	graph := newPythonGraph(deps)
+	for _, p := range lock.Package {
+		for name := range p.Dependencies {
+			graph.link(depKey(p.Name, p.Version), name)
+		}
+	}
+	for _, name := range pythonDirectNames(manifest) {
+		graph.direct(name)
+	}
+	deps = dedupeDeps(deps)
+	graph.annotate(deps)
+	return deps, nil
+}
+
+type uvDependency struct {
+	Name string `toml:"name"`
+}
+
+// parseUvLock extracts packages from uv.lock. The project's own workspace
+// members (editable or virtual sources) are not published; their
+// dependencies are the direct ones. Packages from git or local paths have
+// no registry version and are skipped.
+func parseUvLock(data []byte) ([]dependency, error) {
+	var lock struct {
+		Package []struct {
+			Name                 string                    `toml:"name"`
+			Version              string                    `toml:"version"`
+			Source               map[string]any            `toml:"source"`
+			Dependencies         []uvDependency            `toml:"dependencies"`
+			OptionalDependencies map[string][]uvDependency `toml:"optional-dependencies"`
+			DevDependencies      map[string][]uvDependency `toml:"dev-dependencies"`
+		} `toml:"package"`
+	}
+	if _, err := toml.Decode(string(data), &lock); err != nil {
+		return nil, fmt.Errorf("parsing uv.lock: %w", err)
+	}
+
+	var deps []dependency
+	for _, p := range lock.Package {
+		if _, ok := p.Source["registry"]; ok && p.Version != "" {
+			deps = append(deps, dependency{Name: p.Name, Version: p.Version})
+		}
+	}
+	graph := newPythonGraph(deps)
+	for _, p := range lock.Package {
+		all := append([]uvDependency(nil), p.Dependencies...)
+		for _, group := range p.OptionalDependencies {
+			all = append(all, group...)
+		}
+		for _, group := range p.DevDependencies {
+			all = append(all, group...)
+		}
+		_, editable := p.Source["editable"]
+		_, virtual := p.Source["virtual"]
+		for _, d := range all {
+			if editable || virtual {
+				graph.direct(d.Name)
+			} else {
+				graph.link(depKey(p.Name, p.Version), d.Name)
+			}
+		}
+	}
+	deps = dedupeDeps(deps)
+	graph.annotate(deps)
+	return deps, nil
+}
+
+// parsePipfileLock extracts the default and develop packages from
+// Pipfile.lock. The lockfile is flat, so only the packages named in the
+// sibling Pipfile can be told apart, as direct.
+func parsePipfileLock(data, manifest []byte) ([]dependency, error) {
+	type pipfileEntry struct {
+		Version string `json:"version"`
+	}
+	var lock struct {
+		Default map[string]pipfileEntry `json:"default"`
+		Develop map[string]pipfileEntry `json:"develop"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing Pipfile.lock: %w", err)
+	}
+
+	var deps []dependency
+	for _, section := range []map[string]pipfileEntry{lock.Default, lock.Develop} {
+		names := keysOf(section)
+		sort.Strings(names)
+		for _, name := range names {
+			// Git and path installs carry no pinned version.
+			if v := strings.TrimPrefix(section[name].Version, "=="); v != "" {
+				deps = append(deps, dependency{Name: name, Version: v})
+			}
+		}
+	}
+
+	var pipfile struct {
+		Packages    map[string]any `toml:"packages"`
+		DevPackages map[string]any `toml:"dev-packages"`
+	}
+	graph := newPythonGraph(deps)
+	if len(manifest) > 0 {
+		if _, err := toml.Decode(string(manifest), &pipfile); err == nil {
+			for _, name := range append(keysOf(pipfile.Packages), keysOf(pipfile.DevPackages)...) {
+				graph.direct(name)
+			}
+		}
+	}
+	deps = dedupeDeps(deps)
+	graph.annotate(deps)
+	return deps, nil
+}
+
+// ─── PHP ────────────────────────────────────────────────────────────────────
+
+type composerPackage struct {
+	Name    string            `json:"name"`
+	Version string            `json:"version"`
+	Require map[string]string `json:"require"`
+}
+
+// parseComposerLock extracts packages from composer.lock, taking the direct
+// dependencies from the sibling composer.json. Platform requirements such
+// as php and ext-json have no "/" and are ignored.
+func parseComposerLock(data, manifest []byte) ([]dependency, error) {
+	var lock struct {
+		Packages    []composerPackage `json:"packages"`
+		PackagesDev []composerPackage `json:"packages-dev"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing composer.lock: %w", err)
+	}
+
+	all := append(lock.Packages, lock.PackagesDev...)
+	var deps []dependency
+	byName := make(map[string]string)
+	for _, p := range all {
+		if p.Name == "" || p.Version == "" {
+			continue
+		}
+		version := strings.TrimPrefix(p.Version, "v")
+		deps = append(deps, dependency{Name: p.Name, Version: version})
+		byName[strings.ToLower(p.Name)] = depKey(p.Name, version)
+	}
+
+	graph := newDepGraph()
+	for _, p := range all {
+		from := depKey(p.Name, strings.TrimPrefix(p.Version, "v"))
+		for name := range p.Require {
+			if to, ok := byName[strings.ToLower(name)]; ok {
+				graph.addEdge(from, to)
+			}
+		}
+	}
+	var composerJSON struct {
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if len(manifest) > 0 && json.Unmarshal(manifest, &composerJSON) == nil {
+		for _, name := range append(keysOf(composerJSON.Require), keysOf(composerJSON.RequireDev)...) {
+			if key, ok := byName[strings.ToLower(name)]; ok {
+				graph.addDirect(key)
+			}
+		}
+	}
+	deps = dedupeDeps(deps)
+	graph.annotate(deps)
+	return deps, nil
+}
+
+// ─── JVM ────────────────────────────────────────────────────────────────────
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+type pomProperty struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type pomProject struct {
+	GroupID    string        `xml:"groupId"`
+	Version    string        `xml:"version"`
+	Parent     pomDependency `xml:"parent"`
+	Properties struct {
+		Entries []pomProperty `xml:",any"`
+	} `xml:"properties"`
+	Dependencies         []pomDependency `xml:"dependencies>dependency"`
+	DependencyManagement []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+}
+
+var pomPropertyRef = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// parsePomXML extracts the dependencies a pom.xml pins to a concrete
+// version, either directly, through a ${property} defined in the same pom,
+// or through its dependencyManagement section. Version ranges and
+// properties inherited from a parent pom cannot be resolved offline and
+// are skipped. Every pom dependency is direct.
+func parsePomXML(data []byte) ([]dependency, error) {
+	var pom pomProject
+	if err := xml.Unmarshal(data, &pom); err != nil {
+		return nil, fmt.Errorf("parsing pom.xml: %w", err)
+	}
+
+	props := map[string]string{
+		"project.version":        pom.Version,
+		"project.parent.version": pom.Parent.Version,
+		"project.groupId":        pom.GroupID,
+	}
+	if pom.Version == "" {
+		props["project.version"] = pom.Parent.Version
+	}
+	for _, p := range pom.Properties.Entries {
+		props[p.XMLName.Local] = strings.TrimSpace(p.Value)
+	}
+	resolve := func(s string) string {
+		return pomPropertyRef.ReplaceAllStringFunc(strings.TrimSpace(s), func(ref string) string {
+			if v, ok := props[ref[2:len(ref)-1]]; ok {
+				return v
+			}
+			return ref
+		})
+	}
+
+	managed := make(map[string]string)
+	for _, d := range pom.DependencyManagement {
+		managed[resolve(d.GroupID)+":"+resolve(d.ArtifactID)] = resolve(d.Version)
+	}
+
+	var deps []dependency
+	for _, d := range pom.Dependencies {
+		name := resolve(d.GroupID) + ":" + resolve(d.ArtifactID)
+		version := resolve(d.Version)
+		if version == "" {
+			version = managed[name]
+		}
+		if version == "" || strings.Contains(version, "${") || strings.ContainsAny(version[:1], "[(") {
+			continue
+		}
+		deps = append(deps, dependency{Name: name, Version: version, Relation: relationDirect})
+	}
+	return dedupeDeps(deps), nil
+}
+
+// parseGradleLockfile extracts group:artifact:version entries from a Gradle
+// dependency lockfile. Gradle does not record which configuration entry
+// pulled each artifact in.
+func parseGradleLockfile(data []byte) ([]dependency, error) {
+	var deps []dependency
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		coords, _, _ := strings.Cut(line, "=")
+		parts := strings.Split(coords, ":")
+		if len(parts) != 3 {
+			continue // e.g. "empty=annotationProcessor"
+		}
+		deps = append(deps, dependency{Name: parts[0] + ":" + parts[1], Version: parts[2]})
+	}
+	return dedupeDeps(deps), scanner.Err()
+}
@@ -0,0 +1,471 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// depsByName indexes parsed dependencies for assertions.
+func depsByName(deps []dependency) map[string]dependency {
+	out := make(map[string]dependency, len(deps))
+	for _, d := range deps {
+		out[d.Name] = d
+	}
+	return out
+}
+
+func TestParseGoModDirectIndirectAndReplace(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parseGoMod([]byte(`module example.com/app
+
+go 1.22
+
+require (
+	github.com/spf13/cobra v1.8.0
+	golang.org/x/text v0.3.7 // indirect
+	example.com/local v1.0.0
+	github.com/old/lib v1.2.0
+)
+
+replace example.com/local => ../local
+
+replace github.com/old/lib => github.com/new/lib v1.0.0
+replace github.com/old/lib v1.2.0 => github.com/fork/lib v1.2.1
+`))
+	require.NoError(t, err)
+
+	byName := depsByName(deps)
+	assert.Len(t, deps, 3)
+	assert.Equal(t, dependency{Name: "github.com/spf13/cobra", Version: "1.8.0", Relation: relationDirect}, byName["github.com/spf13/cobra"])
+	assert.Equal(t, relationTransitive, byName["golang.org/x/text"].Relation)
+	assert.Equal(t, "1.2.1", byName["github.com/fork/lib"].Version, "version-specific replace wins")
+	assert.NotContains(t, byName, "example.com/local")
+}
+
+func TestParsePackageLockDependencyPath(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parsePackageLock([]byte(`{
+		"lockfileVersion": 3,
+		"packages": {
+			"": {"name": "app", "dependencies": {"express": "^4.18.0"}, "devDependencies": {"jest": "^29.0.0"}},
+			"node_modules/express": {"version": "4.18.2", "dependencies": {"body-parser": "1.20.1"}},
+			"node_modules/body-parser": {"version": "1.20.1", "dependencies": {"qs": "6.11.0"}},
+			"node_modules/qs": {"version": "6.5.0"},
+			"node_modules/body-parser/node_modules/qs": {"version": "6.11.0"},
+			"node_modules/jest": {"version": "29.7.0"}
+		}
+	}`))
+	require.NoError(t, err)
+
+	for _, d := range deps {
+		switch d.Name + "@" + d.Version {
+		case "express@4.18.2", "jest@29.7.0":
+			assert.Equal(t, relationDirect, d.Relation, d.Name)
+		case "body-parser@1.20.1":
+			assert.Equal(t, "express > body-parser", d.Path)
+		case "qs@6.11.0":
+			assert.Equal(t, "express > body-parser > qs", d.Path, "nested node_modules wins")
+		case "qs@6.5.0":
+			assert.Equal(t, relationUnknown, d.Relation, "hoisted copy nobody requires")
+		}
+	}
+}
+
+func TestParseYarnLockV1(t *testing.T) {
+	t.Parallel()
+
+	lock := []byte(`# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/core@^7.0.0":
+  version "7.22.0"
+  dependencies:
+    json5 "^2.2.2"
+
+json5@^2.2.2, json5@^2.1.0:
+  version "2.2.3"
+  resolved "https://registry.yarnpkg.com/json5/-/json5-2.2.3.tgz"
+
+lodash@^4.17.0:
+  version "4.17.20"
+`)
+	manifest := []byte(`{"dependencies": {"lodash": "^4.17.0"}, "devDependencies": {"@babel/core": "^7.0.0"}}`)
+
+	deps, err := parseYarnLock(lock, manifest)
+	require.NoError(t, err)
+	byName := depsByName(deps)
+	require.Len(t, deps, 3)
+	assert.Equal(t, "7.22.0", byName["@babel/core"].Version)
+	assert.Equal(t, relationDirect, byName["lodash"].Relation)
+	assert.Equal(t, "@babel/core > json5", byName["json5"].Path)
+
+	deps, err = parseYarnLock(lock, nil)
+	require.NoError(t, err)
+	assert.Equal(t, relationUnknown, depsByName(deps)["json5"].Relation, "no package.json, no paths")
+}
+
+func TestParseYarnBerryLock(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parseYarnLock([]byte(`# This file is generated by running "yarn install" inside your project.
+
+__metadata:
+  version: 6
+  cacheKey: 8
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+  dependencies:
+    express: ^4.18.0
+  languageName: unknown
+  linkType: soft
+
+"express@npm:^4.18.0":
+  version: 4.18.2
+  resolution: "express@npm:4.18.2"
+  dependencies:
+    "@types/qs": ^6.9.0
+  languageName: node
+  linkType: hard
+
+"@types/qs@npm:^6.9.0, @types/qs@npm:^6.9.7":
+  version: 6.9.7
+  resolution: "@types/qs@npm:6.9.7"
+  languageName: node
+  linkType: hard
+`), nil)
+	require.NoError(t, err)
+
+	byName := depsByName(deps)
+	require.Len(t, deps, 2)
+	assert.Equal(t, relationDirect, byName["express"].Relation)
+	assert.Equal(t, "express > @types/qs", byName["@types/qs"].Path)
+	assert.Equal(t, "6.9.7", byName["@types/qs"].Version)
+}
+
+func TestParsePnpmLock(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"v5": `lockfileVersion: 5.4
+specifiers:
+  express: ^4.18.0
+dependencies:
+  express: 4.18.2
+packages:
+  /express/4.18.2:
+    dependencies:
+      qs: 6.11.0_debug@2.6.9
+  /qs/6.11.0_debug@2.6.9:
+    dev: false
+`,
+		"v6": `lockfileVersion: '6.0'
+dependencies:
+  express:
+    specifier: ^4.18.0
+    version: 4.18.2
+packages:
+  /express@4.18.2:
+    dependencies:
+      qs: 6.11.0(debug@2.6.9)
+  /qs@6.11.0(debug@2.6.9):
+    dev: false
+`,
+		"v9": `lockfileVersion: '9.0'
+importers:
+  .:
+    dependencies:
+      express:
+        specifier: ^4.18.0
+        version: 4.18.2
+packages:
+  express@4.18.2:
+    resolution: {integrity: sha512-x}
+  qs@6.11.0:
+    resolution: {integrity: sha512-y}
+snapshots:
+  express@4.18.2:
+    dependencies:
+      qs: 6.11.0(debug@2.6.9)
+  qs@6.11.0(debug@2.6.9): {}
+`,
+	}
+	for name, lock := range tests {
+		t.Run(name, func(t *testing.T) {
+			deps, err := parsePnpmLock([]byte(lock))
+			require.NoError(t, err)
+			byName := depsByName(deps)
+			require.Len(t, deps, 2)
+			assert.Equal(t, relationDirect, byName["express"].Relation)
+			assert.Equal(t, dependency{Name: "qs", Version: "6.11.0", Relation: relationTransitive, Path: "express > qs"}, byName["qs"])
+		})
+	}
+}
+
+func TestParsePoetryLock(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parsePoetryLock([]byte(`[[package]]
+name = "requests"
+version = "2.28.0"
+
+[package.dependencies]
+certifi = ">=2017.4.17"
+urllib3 = {version = ">=1.21.1,<1.27", optional = true}
+
+[[package]]
+name = "certifi"
+version = "2022.12.7"
+
+[[package]]
+name = "urllib3"
+version = "1.26.5"
+`), []byte(`[tool.poetry.dependencies]
+python = "^3.10"
+Requests = "^2.28"
+`))
+	require.NoError(t, err)
+
+	byName := depsByName(deps)
+	require.Len(t, deps, 3)
+	assert.Equal(t, relationDirect, byName["requests"].Relation)
+	assert.Equal(t, "requests > urllib3", byName["urllib3"].Path)
+}
+
+func TestParseUvLock(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parseUvLock([]byte(`version = 1
+requires-python = ">=3.12"
+
+[[package]]
+name = "app"
+version = "0.1.0"
+source = { editable = "." }
+dependencies = [{ name = "flask" }]
+
+[package.dev-dependencies]
+dev = [{ name = "pytest" }]
+
+[[package]]
+name = "flask"
+version = "2.2.0"
+source = { registry = "https://pypi.org/simple" }
+dependencies = [{ name = "werkzeug" }]
+
+[[package]]
+name = "werkzeug"
+version = "2.2.2"
+source = { registry = "https://pypi.org/simple" }
+
+[[package]]
+name = "pytest"
+version = "8.0.0"
+source = { registry = "https://pypi.org/simple" }
+
+[[package]]
+name = "tool"
+version = "0.0.1"
+source = { git = "https://github.com/example/tool" }
+`))
+	require.NoError(t, err)
+
+	byName := depsByName(deps)
+	require.Len(t, deps, 3)
+	assert.NotContains(t, byName, "app")
+	assert.NotContains(t, byName, "tool")
+	assert.Equal(t, relationDirect, byName["pytest"].Relation)
+	assert.Equal(t, "flask > werkzeug", byName["werkzeug"].Path)
+}
+
+func TestParsePipfileLock(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parsePipfileLock([]byte(`{
+		"_meta": {"hash": {"sha256": "x"}},
+		"default": {
+			"django": {"version": "==3.2.0"},
+			"sqlparse": {"version": "==0.4.1"},
+			"mylib": {"git": "https://example.com/mylib.git"}
+		},
+		"develop": {"pytest": {"version": "==7.0.0"}}
+	}`), []byte("[packages]\ndjango = \"*\"\n\n[dev-packages]\npytest = \"*\"\n"))
+	require.NoError(t, err)
+
+	byName := depsByName(deps)
+	require.Len(t, deps, 3)
+	assert.Equal(t, "3.2.0", byName["django"].Version)
+	assert.Equal(t, relationDirect, byName["pytest"].Relation)
+	assert.Equal(t, relationUnknown, byName["sqlparse"].Relation)
+}
+
+func TestParseComposerLock(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parseComposerLock([]byte(`{
+		"packages": [
+			{"name": "laravel/framework", "version": "v9.0.0", "require": {"php": "^8.0", "symfony/http-kernel": "^6.0"}},
+			{"name": "symfony/http-kernel", "version": "v6.0.1"}
+		],
+		"packages-dev": [
+			{"name": "phpunit/phpunit", "version": "9.5.0"}
+		]
+	}`), []byte(`{"require": {"php": "^8.0", "laravel/framework": "^9.0"}, "require-dev": {"phpunit/phpunit": "^9.5"}}`))
+	require.NoError(t, err)
+
+	byName := depsByName(deps)
+	require.Len(t, deps, 3)
+	assert.Equal(t, "9.0.0", byName["laravel/framework"].Version)
+	assert.Equal(t, "laravel/framework > symfony/http-kernel", byName["symfony/http-kernel"].Path)
+	assert.Equal(t, relationDirect, byName["phpunit/phpunit"].Relation)
+}
+
+func TestParsePomXML(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parsePomXML([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0.0</version>
+  <properties>
+    <jackson.version>2.13.0</jackson.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>org.apache.logging.log4j</groupId>
+        <artifactId>log4j-core</artifactId>
+        <version>2.14.1</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-databind</artifactId>
+      <version>${jackson.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.apache.logging.log4j</groupId>
+      <artifactId>log4j-core</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>sibling</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>[4.0,5.0)</version>
+    </dependency>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>inherited</artifactId>
+      <version>${parent.only}</version>
+    </dependency>
+  </dependencies>
+</project>`))
+	require.NoError(t, err)
+
+	byName := depsByName(deps)
+	require.Len(t, deps, 3)
+	assert.Equal(t, "2.13.0", byName["com.fasterxml.jackson.core:jackson-databind"].Version)
+	assert.Equal(t, "2.14.1", byName["org.apache.logging.log4j:log4j-core"].Version)
+	assert.Equal(t, "1.0.0", byName["com.example:sibling"].Version)
+	assert.Equal(t, relationDirect, byName["com.example:sibling"].Relation)
+}
+
+func TestParseGradleLockfile(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parseGradleLockfile([]byte(`# This is a Gradle generated file for dependency locking.
+# Manual edits can break the build and are not advised.
+# This file is expected to be part of source control.
+com.google.guava:guava:31.0-jre=compileClasspath,runtimeClasspath
+org.apache.logging.log4j:log4j-core:2.14.1=runtimeClasspath
+empty=annotationProcessor
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []dependency{
+		{Name: "com.google.guava:guava", Version: "31.0-jre"},
+		{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"},
+	}, deps)
+}
+
+func TestDepScannerKeepsGoSumForPre117Modules(t *testing.T) {
+	t.Parallel()
+
+	var queried []string
+	srv := newOSVServer(t, func(req osvQueryRequest) []osvVuln {
+		queried = append(queried, req.Package.Name+"@"+req.Version)
+		return nil
+	})
+
+	// Before go 1.17, go.mod lists direct dependencies only; the modules
+	// they pull in are found in go.sum alone.
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", "module example.com/app\n\ngo 1.16\n\nrequire github.com/gin-gonic/gin v1.7.0\n")
+	writeFile(t, dir, "go.sum", "github.com/gin-gonic/gin v1.7.0 h1:abc=\ngithub.com/gin-gonic/gin v1.7.0/go.mod h1:def=\ngolang.org/x/text v0.3.2 h1:ghi=\n")
+
+	s := NewDepScanner(srv.Client())
+	s.OSVBaseURL = srv.URL
+	_, err := s.Scan(context.Background(), security.ScanTarget{RootDir: dir})
+	require.NoError(t, err)
+	assert.Contains(t, queried, "golang.org/x/text@0.3.2", "go.sum is scanned for a go 1.16 module")
+
+	assert.False(t, goModListsAllModules([]byte("module m\n\ngo 1.16\n")))
+	assert.False(t, goModListsAllModules([]byte("module m\n")))
+	assert.True(t, goModListsAllModules([]byte("module m\n\ngo 1.17\n")))
+	assert.True(t, goModListsAllModules([]byte("module m\n\ngo 1.21rc1\n")))
+}
+
+func TestDepScannerScansMonorepoLockfiles(t *testing.T) {
+	t.Parallel()
+
+	var queried []string
+	srv := newOSVServer(t, func(req osvQueryRequest) []osvVuln {
+		queried = append(queried, req.Package.Ecosystem+":"+req.Package.Name+"@"+req.Version)
+		if req.Package.Name == "qs" {
+			return []osvVuln{{ID: "GHSA-hrpp-h998-j3pp", Summary: "qs prototype pollution"}}
+		}
+		return nil
+	})
+
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", "module example.com/app\n\ngo 1.22\n\nrequire golang.org/x/text v0.3.8\n")
+	writeFile(t, dir, "go.sum", "golang.org/x/text v0.3.7 h1:abc=\ngolang.org/x/text v0.3.8 h1:def=\n")
+	writeFile(t, dir, "web/package.json", `{"dependencies": {"express": "^4.17.0"}}`)
+	writeFile(t, dir, "web/yarn.lock", `express@^4.17.0:
+  version "4.17.1"
+  dependencies:
+    qs "6.7.0"
+
+qs@6.7.0:
+  version "6.7.0"
+`)
+	writeFile(t, dir, "web/node_modules/qs/yarn.lock", "ignored@^1.0.0:\n  version \"1.0.0\"\n")
+
+	s := NewDepScanner(srv.Client())
+	s.OSVBaseURL = srv.URL
+	findings, err := s.Scan(context.Background(), security.ScanTarget{RootDir: dir})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"Go:golang.org/x/text@0.3.8", "npm:express@4.17.1", "npm:qs@6.7.0"}, queried,
+		"go.mod supersedes go.sum and node_modules is skipped")
+	require.Len(t, findings, 1)
+	f := findings[0]
+	assert.Equal(t, "web/yarn.lock", f.Location.File)
+	assert.Equal(t, "transitive", f.Metadata["dependency_type"])
+	assert.Equal(t, "express > qs", f.Metadata["dependency_path"])
+	assert.Contains(t, f.Evidence, "pulled in by express")
+}
@@ -0,0 +1,362 @@
+package scanner
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// DefaultOSVExportURL is where OSV publishes a zip of every advisory per
+// ecosystem, as <url>/<ecosystem>/all.zip.
+const DefaultOSVExportURL = "https://osv-vulnerabilities.storage.googleapis.com"
+
+// osvLookupBatch bounds the package names bound into a single lookup query,
+// well below SQLite's host parameter limit.
+const osvLookupBatch = 500
+
+// OSVDB is a local SQLite index of OSV advisories, loaded from OSV's
+// per-ecosystem export zips, so dependency scanning works without network
+// access.
+type OSVDB struct {
+	db *sql.DB
+}
+
+// OSVImportStats summarises one import.
+type OSVImportStats struct {
+	Advisories int
+	Skipped    int
+	Ecosystems []string
+}
+
+// OpenOSVDB opens (or creates) the index at path.
+func OpenOSVDB(path string) (*OSVDB, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating OSV database directory: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening OSV database: %w", err)
+	}
+	// A single connection serialises writers and keeps pragmas in effect.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(osvSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating OSV schema: %w", err)
+	}
+	return &OSVDB{db: db}, nil
+}
+
+const osvSchema = `
+CREATE TABLE IF NOT EXISTS osv_vulns (
+	id     TEXT PRIMARY KEY,
+	record TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS osv_affected (
+	ecosystem TEXT NOT NULL,
+	name      TEXT NOT NULL,
+	vuln_id   TEXT NOT NULL,
+	PRIMARY KEY (ecosystem, name, vuln_id)
+);
+CREATE INDEX IF NOT EXISTS osv_affected_vuln ON osv_affected (vuln_id);
+CREATE TABLE IF NOT EXISTS osv_ecosystems (
+	ecosystem   TEXT PRIMARY KEY,
+	imported_at TEXT NOT NULL
+);
+`
+
+// Close closes the index.
+func (d *OSVDB) Close() error { return d.db.Close() }
+
+// Update downloads the export zip for each ecosystem from baseURL and
+// imports it. An empty baseURL means DefaultOSVExportURL.
+func (d *OSVDB) Update(ctx context.Context, client *http.Client, baseURL string, ecosystems []string) (OSVImportStats, error) {
+	if baseURL == "" {
+		baseURL = DefaultOSVExportURL
+	}
+	var total OSVImportStats
+	for _, eco := range ecosystems {
+		stats, err := d.updateEcosystem(ctx, client, strings.TrimSuffix(baseURL, "/"), eco)
+		if err != nil {
+			return total, fmt.Errorf("updating %s advisories: %w", eco, err)
+		}
+		total.Advisories += stats.Advisories
+		total.Skipped += stats.Skipped
+		total.Ecosystems = append(total.Ecosystems, eco)
+	}
+	return total, nil
+}
+
+func (d *OSVDB) updateEcosystem(ctx context.Context, client *http.Client, baseURL, ecosystem string) (OSVImportStats, error) {
+	url := fmt.Sprintf("%s/%s/all.zip", baseURL, ecosystem)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return OSVImportStats{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return OSVImportStats{}, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return OSVImportStats{}, fmt.Errorf("downloading %s: status %d", url, resp.StatusCode)
+	}
+
+	// zip needs random access, so spool the download to disk first.
+	tmp, err := os.CreateTemp("", "osv-*.zip")
+	if err != nil {
+		return OSVImportStats{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, resp.Body)
+	if err != nil {
+		return OSVImportStats{}, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	return d.ImportZip(ctx, tmp, size)
+}
+
+// ImportFile imports an OSV export zip from disk.
+func (d *OSVDB) ImportFile(ctx context.Context, path string) (OSVImportStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return OSVImportStats{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return OSVImportStats{}, err
+	}
+	return d.ImportZip(ctx, f, info.Size())
+}
+
+// ImportZip imports every advisory in an OSV export zip in one transaction,
+// replacing earlier copies of the same advisories. Withdrawn advisories are
+// removed rather than imported. Each ecosystem seen is marked as imported
+// now.
+func (d *OSVDB) ImportZip(ctx context.Context, r io.ReaderAt, size int64) (OSVImportStats, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return OSVImportStats{}, fmt.Errorf("reading OSV export: %w", err)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return OSVImportStats{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	var stats OSVImportStats
+	seen := make(map[string]bool)
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		if !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return stats, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		var vuln osvVuln
+		if err := json.Unmarshal(data, &vuln); err != nil || vuln.ID == "" {
+			stats.Skipped++
+			continue
+		}
+		if err := importVuln(ctx, tx, vuln, data); err != nil {
+			return stats, fmt.Errorf("importing %s: %w", vuln.ID, err)
+		}
+		if vuln.Withdrawn != "" {
+			stats.Skipped++
+			continue
+		}
+		stats.Advisories++
+		for _, aff := range vuln.Affected {
+			eco := baseEcosystem(aff.Package.Ecosystem)
+			if eco != "" && !seen[eco] {
+				seen[eco] = true
+				stats.Ecosystems = append(stats.Ecosystems, eco)
+			}
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, eco := range stats.Ecosystems {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT OR REPLACE INTO osv_ecosystems (ecosystem, imported_at) VALUES (?, ?)`, eco, now); err != nil {
+			return stats, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("committing OSV import: %w", err)
+	}
+	return stats, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func importVuln(ctx context.Context, tx *sql.Tx, vuln osvVuln, record []byte) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM osv_affected WHERE vuln_id = ?`, vuln.ID); err != nil {
+		return err
+	}
+	if vuln.Withdrawn != "" {
+		_, err := tx.ExecContext(ctx, `DELETE FROM osv_vulns WHERE id = ?`, vuln.ID)
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT OR REPLACE INTO osv_vulns (id, record) VALUES (?, ?)`, vuln.ID, string(record)); err != nil {
+		return err
+	}
+	for _, aff := range vuln.Affected {
+		eco := baseEcosystem(aff.Package.Ecosystem)
+		if eco == "" || aff.Package.Name == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO osv_affected (ecosystem, name, vuln_id) VALUES (?, ?, ?)`,
+			eco, normalizePackageName(eco, aff.Package.Name), vuln.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasEcosystem reports whether advisories for ecosystem were ever imported.
+func (d *OSVDB) HasEcosystem(ctx context.Context, ecosystem string) (bool, error) {
+	var n int
+	err := d.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM osv_ecosystems WHERE ecosystem = ?`, ecosystem).Scan(&n)
+	return n > 0, err
+}
+
+// UpdatedAt returns when the least recently imported ecosystem was
+// imported, which bounds how stale any lookup can be. ok is false for an
+// empty index.
+func (d *OSVDB) UpdatedAt(ctx context.Context) (time.Time, bool, error) {
+	var oldest sql.NullString
+	if err := d.db.QueryRowContext(ctx, `SELECT MIN(imported_at) FROM osv_ecosystems`).Scan(&oldest); err != nil {
+		return time.Time{}, false, err
+	}
+	if !oldest.Valid {
+		return time.Time{}, false, nil
+	}
+	t, err := time.Parse(time.RFC3339, oldest.String)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("parsing import time: %w", err)
+	}
+	return t, true, nil
+}
+
+// lookup returns the advisories affecting each dependency, matching
+// versions against the advisories' ranges locally. Names are looked up in
+// batches, one query per batch.
+func (d *OSVDB) lookup(ctx context.Context, ecosystem string, deps []dependency) (map[dependency][]osvVuln, error) {
+	byName := make(map[string][]dependency)
+	var names []string
+	for _, dep := range deps {
+		n := normalizePackageName(ecosystem, dep.Name)
+		if _, ok := byName[n]; !ok {
+			names = append(names, n)
+		}
+		byName[n] = append(byName[n], dep)
+	}
+
+	result := make(map[dependency][]osvVuln, len(deps))
+	for start := 0; start < len(names); start += osvLookupBatch {
+		end := min(start+osvLookupBatch, len(names))
+		batch := names[start:end]
+
+		args := make([]any, 0, len(batch)+1)
+		args = append(args, ecosystem)
+		for _, n := range batch {
+			args = append(args, n)
+		}
+		rows, err := d.db.QueryContext(ctx,
+			`SELECT a.name, v.record FROM osv_affected a JOIN osv_vulns v ON v.id = a.vuln_id
+			 WHERE a.ecosystem = ? AND a.name IN (?`+strings.Repeat(",?", len(batch)-1)+`)
+			 ORDER BY v.id`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("querying OSV database: %w", err)
+		}
+		err = func() error {
+			defer rows.Close()
+			for rows.Next() {
+				var name, record string
+				if err := rows.Scan(&name, &record); err != nil {
+					return err
+				}
+				var vuln osvVuln
+				if err := json.Unmarshal([]byte(record), &vuln); err != nil {
+					continue
+				}
+				for _, dep := range byName[name] {
+					if vulnAffects(vuln, ecosystem, dep) {
+						result[dep] = append(result[dep], vuln)
+					}
+				}
+			}
+			return rows.Err()
+		}()
+		if err != nil {
+			return nil, fmt.Errorf("reading OSV database: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// vulnAffects reports whether any of the advisory's affected entries for
+// the dependency's package covers its version.
+func vulnAffects(vuln osvVuln, ecosystem string, dep dependency) bool {
+	name := normalizePackageName(ecosystem, dep.Name)
+	for _, aff := range vuln.Affected {
+		if baseEcosystem(aff.Package.Ecosystem) != ecosystem ||
+			normalizePackageName(ecosystem, aff.Package.Name) != name {
+			continue
+		}
+		if affectsVersion(ecosystem, aff, dep.Version) {
+			return true
+		}
+	}
+	return false
+}
+
+// baseEcosystem drops an ecosystem's release suffix, as in "Debian:11".
+func baseEcosystem(eco string) string {
+	base, _, _ := strings.Cut(eco, ":")
+	return base
+}
+
+var pypiNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePackageName applies the ecosystem's name equivalence: PyPI names
+// are case-insensitive and treat runs of -, _ and . alike (PEP 503), and
+// RubyGems and Packagist names are case-insensitive.
+func normalizePackageName(ecosystem, name string) string {
+	switch ecosystem {
+	case "PyPI":
+		return pypiNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	case "RubyGems", "Packagist":
+		return strings.ToLower(name)
+	}
+	return name
+}
@@ -0,0 +1,215 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/security"
+	"github.com/julianshen/rubichan/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// osvExport builds an OSV export zip holding the given advisories.
+func osvExport(t *testing.T, vulns ...osvVuln) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, v := range vulns {
+		w, err := zw.Create(v.ID + ".json")
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(w).Encode(v))
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func rangeVuln(id, ecosystem, name, introduced, fixed string) osvVuln {
+	return osvVuln{
+		ID:       id,
+		Summary:  id + " summary",
+		Severity: []osvSeverity{{Type: "CVSS_V3", Score: "7.5"}},
+		Affected: []osvAffected{{
+			Package: osvAffectedPackage{Name: name, Ecosystem: ecosystem},
+			Ranges: []osvRange{{
+				Type:   "ECOSYSTEM",
+				Events: []osvEvent{{Introduced: introduced}, {Fixed: fixed}},
+			}},
+		}},
+	}
+}
+
+func openTestOSVDB(t *testing.T, vulns ...osvVuln) *OSVDB {
+	t.Helper()
+	db, err := OpenOSVDB(filepath.Join(t.TempDir(), "osv", "osv.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	data := osvExport(t, vulns...)
+	_, err = db.ImportZip(context.Background(), bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	return db
+}
+
+func TestOSVDBImportZip(t *testing.T) {
+	t.Parallel()
+
+	db, err := OpenOSVDB(filepath.Join(t.TempDir(), "osv.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	withdrawn := rangeVuln("GO-2020-0001", "Go", "example.com/old", "0", "1.0.0")
+	withdrawn.Withdrawn = "2021-01-01T00:00:00Z"
+	data := osvExport(t,
+		rangeVuln("GO-2022-1059", "Go", "golang.org/x/text", "0", "0.3.8"),
+		rangeVuln("PYSEC-2023-1", "PyPI:3", "Requests", "2.0", "2.31.0"),
+		withdrawn,
+	)
+	stats, err := db.ImportZip(context.Background(), bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Advisories)
+	assert.Equal(t, 1, stats.Skipped)
+	assert.ElementsMatch(t, []string{"Go", "PyPI"}, stats.Ecosystems)
+
+	ok, err := db.HasEcosystem(context.Background(), "PyPI")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.HasEcosystem(context.Background(), "npm")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, known, err := db.UpdatedAt(context.Background())
+	require.NoError(t, err)
+	assert.True(t, known)
+
+	found, err := db.lookup(context.Background(), "Go", []dependency{{Name: "example.com/old", Version: "0.5.0"}})
+	require.NoError(t, err)
+	assert.Empty(t, found, "withdrawn advisories must not match")
+}
+
+func TestOSVDBImportFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "all.zip")
+	require.NoError(t, os.WriteFile(path, osvExport(t, rangeVuln("RUSTSEC-2021-1", "crates.io", "smallvec", "0.6.3", "0.6.14")), 0o644))
+
+	db, err := OpenOSVDB(filepath.Join(t.TempDir(), "osv.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	stats, err := db.ImportFile(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Advisories)
+	assert.Equal(t, []string{"crates.io"}, stats.Ecosystems)
+}
+
+func TestOSVDBUpdateDownloadsExports(t *testing.T) {
+	t.Parallel()
+
+	export := osvExport(t, rangeVuln("GHSA-xxxx", "npm", "lodash", "0", "4.17.21"))
+	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/npm/all.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(export)
+	}))
+
+	db, err := OpenOSVDB(filepath.Join(t.TempDir(), "osv.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	stats, err := db.Update(context.Background(), srv.Client(), srv.URL, []string{"npm"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Advisories)
+
+	_, err = db.Update(context.Background(), srv.Client(), srv.URL, []string{"PyPI"})
+	assert.Error(t, err)
+}
+
+func TestDepScannerMatchesLocalDatabase(t *testing.T) {
+	t.Parallel()
+
+	db := openTestOSVDB(t,
+		rangeVuln("GO-2022-1059", "Go", "golang.org/x/text", "0", "0.3.8"),
+		rangeVuln("PYSEC-2023-74", "PyPI", "Requests", "2.0", "2.31.0"),
+	)
+
+	dir := t.TempDir()
+	writeFile(t, dir, "go.sum", `golang.org/x/text v0.3.7 h1:olpwvP2KacW1ZWvsR7uQhoyTYvKAupfQrRGBFM352Gk=
+golang.org/x/net v0.1.0 h1:abc=
+`)
+	writeFile(t, dir, "requirements.txt", "requests==2.28.0\nflask==2.0.0\n")
+
+	s := NewDepScanner(nil)
+	s.SetDatabase(db)
+	findings, err := s.Scan(context.Background(), security.ScanTarget{RootDir: dir})
+	require.NoError(t, err)
+
+	var titles []string
+	for _, f := range findings {
+		titles = append(titles, f.Title)
+	}
+	require.Len(t, findings, 2, "findings: %v", titles)
+	assert.Contains(t, findings[0].Title, "golang.org/x/text")
+	assert.Contains(t, findings[1].Title, "requests")
+
+	age, ok := s.VulnDBAge()
+	assert.True(t, ok)
+	assert.Less(t, age.Hours(), 1.0)
+}
+
+func TestDepScannerDatabaseSkipsFixedVersions(t *testing.T) {
+	t.Parallel()
+
+	db := openTestOSVDB(t, rangeVuln("GO-2022-1059", "Go", "golang.org/x/text", "0", "0.3.8"))
+	dir := t.TempDir()
+	writeFile(t, dir, "go.sum", "golang.org/x/text v0.3.8 h1:abc=\n")
+
+	s := NewDepScanner(nil)
+	s.SetDatabase(db)
+	findings, err := s.Scan(context.Background(), security.ScanTarget{RootDir: dir})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestDepScannerFallsBackOnlineForMissingEcosystem(t *testing.T) {
+	t.Parallel()
+
+	var queries atomic.Int32
+	srv := newOSVServer(t, func(osvQueryRequest) []osvVuln {
+		queries.Add(1)
+		return []osvVuln{rangeVuln("GHSA-p6mc", "npm", "lodash", "0", "4.17.21")}
+	})
+
+	// The index only knows Go, so npm goes to the API.
+	db := openTestOSVDB(t, rangeVuln("GO-2022-1059", "Go", "golang.org/x/text", "0", "0.3.8"))
+	dir := t.TempDir()
+	writeFile(t, dir, "package-lock.json", `{"lockfileVersion":3,"packages":{"node_modules/lodash":{"version":"4.17.20"}}}`)
+
+	s := NewDepScanner(srv.Client())
+	s.OSVBaseURL = srv.URL
+	s.SetDatabase(db)
+
+	for i := 0; i < 2; i++ {
+		findings, err := s.Scan(context.Background(), security.ScanTarget{RootDir: dir})
+		require.NoError(t, err)
+		require.Len(t, findings, 1)
+		assert.Contains(t, findings[0].Title, "lodash")
+	}
+	assert.Equal(t, int32(1), queries.Load(), "second scan should be served from the cache")
+}
+
+func TestNormalizePackageName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "zope-interface", normalizePackageName("PyPI", "Zope.Interface"))
+	assert.Equal(t, "rails", normalizePackageName("RubyGems", "Rails"))
+	assert.Equal(t, "github.com/Foo/bar", normalizePackageName("Go", "github.com/Foo/bar"))
+}
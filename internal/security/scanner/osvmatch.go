@@ -0,0 +1,235 @@
+package scanner
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// affectsVersion reports whether an OSV affected entry covers version. An
+// explicit versions list is checked first; SEMVER and ECOSYSTEM ranges are
+// then evaluated as the OSV schema describes. GIT ranges name commits, not
+// releases, and are ignored.
+func affectsVersion(ecosystem string, aff osvAffected, version string) bool {
+	for _, v := range aff.Versions {
+		if v == version {
+			return true
+		}
+	}
+	for _, r := range aff.Ranges {
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			continue
+		}
+		cmp := ecosystemComparator(ecosystem)
+		if r.Type == "SEMVER" {
+			cmp = compareSemver
+		}
+		if rangeContains(r.Events, version, cmp) {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeContains walks the range's events in version order, applying each
+// event at or below version: introduced opens the range, fixed closes it,
+// and last_affected closes it for versions strictly above.
+func rangeContains(events []osvEvent, version string, cmp func(a, b string) int) bool {
+	sorted := make([]osvEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareEventVersions(sorted[i].version(), sorted[j].version(), cmp) < 0
+	})
+
+	affected := false
+	for _, ev := range sorted {
+		switch {
+		case ev.Introduced != "":
+			if compareEventVersions(ev.Introduced, version, cmp) > 0 {
+				return affected
+			}
+			affected = true
+		case ev.Fixed != "":
+			if cmp(version, ev.Fixed) < 0 {
+				return affected
+			}
+			affected = false
+		case ev.LastAffected != "":
+			if cmp(version, ev.LastAffected) <= 0 {
+				return affected
+			}
+			affected = false
+		}
+	}
+	return affected
+}
+
+// version returns whichever version the event carries.
+func (e osvEvent) version() string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	case e.LastAffected != "":
+		return e.LastAffected
+	}
+	return e.Limit
+}
+
+// compareEventVersions is cmp with OSV's "0" treated as lower than any
+// version.
+func compareEventVersions(a, b string, cmp func(a, b string) int) int {
+	switch {
+	case a == "0" && b == "0":
+		return 0
+	case a == "0":
+		return -1
+	case b == "0":
+		return 1
+	}
+	return cmp(a, b)
+}
+
+// ecosystemComparator returns the version ordering for an ecosystem.
+// Ecosystems that publish semantic versions compare as semver; the rest use
+// a segment-wise ordering that understands the pre- and post-release
+// markers PyPI, RubyGems and Maven use.
+func ecosystemComparator(ecosystem string) func(a, b string) int {
+	switch ecosystem {
+	case "Go", "npm", "crates.io", "Hex", "Pub", "NuGet":
+		return compareSemver
+	default:
+		return compareGeneric
+	}
+}
+
+// compareSemver orders two semantic versions, falling back to the generic
+// ordering when either does not parse.
+func compareSemver(a, b string) int {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA != nil || errB != nil {
+		return compareGeneric(a, b)
+	}
+	return va.Compare(vb)
+}
+
+var versionSeparators = regexp.MustCompile(`[.\-_+~]+`)
+
+// preReleaseRank orders release qualifiers. Qualifiers below releaseRank
+// sort before the release they qualify (1.0a1 < 1.0) and those above sort
+// after it (1.0.post1 > 1.0). Plain numbers rank above every qualifier, so
+// 1.0.post1 < 1.0.1.
+var preReleaseRank = map[string]int{
+	"dev": 0, "snapshot": 0,
+	"a": 1, "alpha": 1,
+	"b": 2, "beta": 2,
+	"c": 3, "pre": 3, "preview": 3, "rc": 3, "cr": 3, "m": 3, "milestone": 3,
+	"final": releaseRank, "ga": releaseRank, "release": releaseRank,
+	"post": 5, "sp": 5, "patch": 5, "p": 5,
+}
+
+const (
+	releaseRank = 4
+	numberRank  = 6
+)
+
+type versionToken struct {
+	rank int
+	num  int64
+	text string
+}
+
+// compareGeneric orders versions segment by segment: numbers numerically,
+// known qualifiers by preReleaseRank and other words lexically. When one
+// version runs out, the other is greater unless its next segment is a
+// pre-release qualifier.
+func compareGeneric(a, b string) int {
+	ta, tb := tokenizeVersion(a), tokenizeVersion(b)
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		switch {
+		case i >= len(ta):
+			return -sideAfterEnd(tb[i])
+		case i >= len(tb):
+			return sideAfterEnd(ta[i])
+		}
+		if c := compareTokens(ta[i], tb[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// sideAfterEnd is the sign of a version that continues with tok where the
+// other one ended.
+func sideAfterEnd(tok versionToken) int {
+	if tok.rank < releaseRank {
+		return -1
+	}
+	if tok.rank == releaseRank {
+		// "1.0.final" equals "1.0".
+		return 0
+	}
+	return 1
+}
+
+func compareTokens(a, b versionToken) int {
+	if a.rank != b.rank {
+		if a.rank < b.rank {
+			return -1
+		}
+		return 1
+	}
+	if a.rank == numberRank {
+		switch {
+		case a.num < b.num:
+			return -1
+		case a.num > b.num:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a.text, b.text)
+}
+
+// tokenizeVersion splits a version on separators and on digit/letter
+// boundaries, so "1.0rc2" becomes 1, 0, rc, 2.
+func tokenizeVersion(v string) []versionToken {
+	v = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(v)), "v")
+	var out []versionToken
+	for _, part := range versionSeparators.Split(v, -1) {
+		for _, piece := range splitDigitRuns(part) {
+			if piece == "" {
+				continue
+			}
+			if n, err := strconv.ParseInt(piece, 10, 64); err == nil {
+				out = append(out, versionToken{rank: numberRank, num: n})
+				continue
+			}
+			rank, ok := preReleaseRank[piece]
+			if !ok {
+				// Unknown words are treated like pre-release labels.
+				rank = 1
+			}
+			out = append(out, versionToken{rank: rank, text: piece})
+		}
+	}
+	return out
+}
+
+func splitDigitRuns(s string) []string {
+	var out []string
+	start := 0
+	for i := 1; i < len(s); i++ {
+		if unicode.IsDigit(rune(s[i])) != unicode.IsDigit(rune(s[i-1])) {
+			out = append(out, s[start:i])
+			start = i
+		}
+	}
+	return append(out, s[start:])
+}
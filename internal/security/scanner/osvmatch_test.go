@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffectsVersionSemverRange(t *testing.T) {
+	t.Parallel()
+
+	aff := osvAffected{Ranges: []osvRange{{
+		Type:   "SEMVER",
+		Events: []osvEvent{{Introduced: "1.2.0"}, {Fixed: "1.4.1"}, {Introduced: "2.0.0"}, {Fixed: "2.0.3"}},
+	}}}
+
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"1.1.9", false},
+		{"1.2.0", true},
+		{"1.4.0", true},
+		{"1.4.1", false},
+		{"1.9.0", false},
+		{"2.0.2", true},
+		{"2.0.3", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, affectsVersion("npm", aff, tt.version), tt.version)
+	}
+}
+
+func TestAffectsVersionLastAffected(t *testing.T) {
+	t.Parallel()
+
+	aff := osvAffected{Ranges: []osvRange{{
+		Type:   "ECOSYSTEM",
+		Events: []osvEvent{{Introduced: "0"}, {LastAffected: "0.9.5"}},
+	}}}
+
+	assert.True(t, affectsVersion("crates.io", aff, "0.1.0"))
+	assert.True(t, affectsVersion("crates.io", aff, "0.9.5"))
+	assert.False(t, affectsVersion("crates.io", aff, "0.9.6"))
+}
+
+func TestAffectsVersionExplicitVersions(t *testing.T) {
+	t.Parallel()
+
+	aff := osvAffected{
+		Versions: []string{"3.1.4"},
+		Ranges:   []osvRange{{Type: "GIT", Events: []osvEvent{{Introduced: "0"}}}},
+	}
+
+	assert.True(t, affectsVersion("PyPI", aff, "3.1.4"))
+	assert.False(t, affectsVersion("PyPI", aff, "3.1.5"), "GIT ranges are ignored")
+}
+
+func TestCompareGenericPreAndPostReleases(t *testing.T) {
+	t.Parallel()
+
+	ordered := []string{"1.0.dev1", "1.0a1", "1.0b2", "1.0rc1", "1.0", "1.0.post1", "1.0.1", "1.1"}
+	for i := 0; i < len(ordered)-1; i++ {
+		assert.Negative(t, compareGeneric(ordered[i], ordered[i+1]), "%s < %s", ordered[i], ordered[i+1])
+		assert.Positive(t, compareGeneric(ordered[i+1], ordered[i]), "%s > %s", ordered[i+1], ordered[i])
+	}
+	assert.Zero(t, compareGeneric("1.0", "1.0.final"))
+	assert.Negative(t, compareGeneric("2.9", "2.10"))
+}
+
+func TestRangeContainsUnsortedEvents(t *testing.T) {
+	t.Parallel()
+
+	events := []osvEvent{{Fixed: "2.5"}, {Introduced: "2.0"}}
+	assert.True(t, rangeContains(events, "2.1", compareGeneric))
+	assert.False(t, rangeContains(events, "1.9", compareGeneric))
+	assert.False(t, rangeContains(events, "2.5", compareGeneric))
+}
@@ -2,44 +2,208 @@ package scanner
 
 import (
 	"context"
+	"embed"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/julianshen/rubichan/internal/parser"
 	"github.com/julianshen/rubichan/internal/security"
 )
 
-// sastPattern defines a single SAST detection pattern for a specific language.
-type sastPattern struct {
-	name       string
-	language   string
-	pattern    *regexp.Regexp
+// builtinRules holds the rule packs shipped with the scanner, one or more
+// per language the parser supports.
+//
+//go:embed rules/*.yaml
+var builtinRules embed.FS
+
+// sastRulePack is the layout of a rules/*.yaml file. Its sources and
+// sanitizers are the defaults for every taint rule in its languages,
+// including rules loaded from .security.yaml; rules that list no languages
+// apply to all of the pack's.
+type sastRulePack struct {
+	Languages  []string            `yaml:"languages"`
+	Sources    []string            `yaml:"sources"`
+	Sanitizers []string            `yaml:"sanitizers"`
+	Rules      []security.SASTRule `yaml:"rules"`
+}
+
+// taintDefaults are the sources and sanitizers a language's taint rules
+// extend.
+type taintDefaults struct {
+	sources    []string
+	sanitizers []string
+}
+
+// sastRule is a rule resolved for one language: its queries have been
+// checked against that language's grammar and its sources and sanitizers
+// include the language defaults.
+type sastRule struct {
+	security.SASTRule
 	severity   security.Severity
 	category   security.Category
-	cwe        string
-	title      string
 	confidence security.Confidence
 }
 
-// SASTScanner detects common security vulnerabilities in source code using
-// tree-sitter AST parsing combined with regex pattern matching on function bodies.
+// SASTScanner detects common security vulnerabilities in source code by
+// running tree-sitter query rules over each file: pattern rules flag
+// dangerous constructs outright, and taint rules flag sinks reached by
+// untrusted data within a function.
 type SASTScanner struct {
 	parser         *parser.Parser
-	patterns       []sastPattern
+	rules          map[string][]sastRule // by language
 	findingCounter int
 	mu             sync.Mutex
 }
 
-// NewSASTScanner creates a SASTScanner with the standard detection patterns.
+// NewSASTScanner creates a SASTScanner with the built-in rule packs. The
+// packs are compiled into the binary and covered by tests, so a rule that
+// fails to load is a programming error and panics.
 func NewSASTScanner() *SASTScanner {
-	return &SASTScanner{
-		parser:   parser.NewParser(),
-		patterns: defaultSASTPatterns(),
+	packs, err := loadBuiltinRulePacks()
+	if err != nil {
+		panic(err)
+	}
+	var rules []security.SASTRule
+	for _, pack := range packs {
+		rules = append(rules, pack.Rules...)
+	}
+	s, err := newSASTScanner(packDefaults(packs), rules)
+	if err != nil {
+		panic(fmt.Sprintf("built-in sast rules: %v", err))
+	}
+	return s
+}
+
+// NewSASTScannerFromRules creates a SASTScanner that runs only the given
+// rules, typically the sast_rules of a project's .security.yaml. Taint
+// rules still use the built-in sources and sanitizers of their languages.
+func NewSASTScannerFromRules(rules []security.SASTRule) (*SASTScanner, error) {
+	packs, err := loadBuiltinRulePacks()
+	if err != nil {
+		return nil, err
+	}
+	return newSASTScanner(packDefaults(packs), rules)
+}
+
+func newSASTScanner(defaults map[string]taintDefaults, rules []security.SASTRule) (*SASTScanner, error) {
+	s := &SASTScanner{
+		parser: parser.NewParser(),
+		rules:  make(map[string][]sastRule),
+	}
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		for _, lang := range r.Languages {
+			resolved, err := resolveSASTRule(r, lang, defaults[lang])
+			if err != nil {
+				return nil, err
+			}
+			s.rules[lang] = append(s.rules[lang], resolved)
+		}
+	}
+	return s, nil
+}
+
+// resolveSASTRule specializes r for one language and checks that every
+// query compiles and captures what the rule needs.
+func resolveSASTRule(r security.SASTRule, lang string, defaults taintDefaults) (sastRule, error) {
+	if r.Pattern == "" {
+		r.Sources = append(append([]string(nil), defaults.sources...), r.Sources...)
+		r.Sanitizers = append(append([]string(nil), defaults.sanitizers...), r.Sanitizers...)
+		if len(r.Sources) == 0 {
+			return sastRule{}, fmt.Errorf("sast rule %q: no sources for %s", r.ID, lang)
+		}
+	}
+	r.Languages = []string{lang}
+
+	queries := []struct {
+		patterns []string
+		capture  string
+	}{
+		{[]string{r.Pattern}, "@match"},
+		{r.Sources, "@source"},
+		{r.Sinks, "@sink"},
+		{r.Sanitizers, "@sanitizer"},
+	}
+	for _, q := range queries {
+		for _, pattern := range q.patterns {
+			if pattern == "" {
+				continue
+			}
+			if !strings.Contains(pattern, q.capture) {
+				return sastRule{}, fmt.Errorf("sast rule %q: query does not capture %s: %s", r.ID, q.capture, pattern)
+			}
+			if err := parser.ValidateQuery(lang, pattern); err != nil {
+				return sastRule{}, fmt.Errorf("sast rule %q (%s): %w", r.ID, lang, err)
+			}
+		}
+	}
+
+	resolved := sastRule{
+		SASTRule:   r,
+		severity:   security.Severity(r.Severity),
+		category:   security.Category(r.Category),
+		confidence: security.Confidence(r.Confidence),
+	}
+	if resolved.severity == "" {
+		resolved.severity = security.SeverityMedium
+	}
+	if resolved.category == "" {
+		resolved.category = security.CategoryInputValidation
+	}
+	if resolved.confidence == "" {
+		resolved.confidence = security.ConfidenceMedium
+	}
+	return resolved, nil
+}
+
+// loadBuiltinRulePacks parses the embedded rule packs, filling in each
+// rule's languages from its pack.
+func loadBuiltinRulePacks() ([]sastRulePack, error) {
+	entries, err := builtinRules.ReadDir("rules")
+	if err != nil {
+		return nil, fmt.Errorf("reading built-in sast rules: %w", err)
+	}
+	var packs []sastRulePack
+	for _, entry := range entries {
+		data, err := builtinRules.ReadFile(path.Join("rules", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading built-in sast rules: %w", err)
+		}
+		var pack sastRulePack
+		if err := yaml.Unmarshal(data, &pack); err != nil {
+			return nil, fmt.Errorf("parsing rules/%s: %w", entry.Name(), err)
+		}
+		for i := range pack.Rules {
+			if len(pack.Rules[i].Languages) == 0 {
+				pack.Rules[i].Languages = pack.Languages
+			}
+		}
+		packs = append(packs, pack)
 	}
+	return packs, nil
+}
+
+// packDefaults gathers each language's default sources and sanitizers from
+// every pack that covers it.
+func packDefaults(packs []sastRulePack) map[string]taintDefaults {
+	defaults := make(map[string]taintDefaults)
+	for _, pack := range packs {
+		for _, lang := range pack.Languages {
+			d := defaults[lang]
+			d.sources = append(d.sources, pack.Sources...)
+			d.sanitizers = append(d.sanitizers, pack.Sanitizers...)
+			defaults[lang] = d
+		}
+	}
+	return defaults
 }
 
 // Name returns the scanner name.
@@ -47,14 +211,14 @@ func (s *SASTScanner) Name() string {
 	return "sast"
 }
 
-// Scan walks the target files, parses supported source files with tree-sitter,
-// and applies regex-based pattern matching on function bodies to detect vulnerabilities.
+// Scan walks the target files, parses every file in a language the parser
+// supports, and runs that language's rules over it.
 func (s *SASTScanner) Scan(ctx context.Context, target security.ScanTarget) ([]security.Finding, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, fmt.Errorf("sast scanner cancelled: %w", err)
 	}
 
-	files, err := security.CollectFiles(target, []string{".go", ".py", ".js", ".ts", ".tsx", ".jsx"})
+	files, err := security.CollectFiles(target, parser.Extensions())
 	if err != nil {
 		return nil, fmt.Errorf("collecting files: %w", err)
 	}
@@ -73,26 +237,15 @@ func (s *SASTScanner) Scan(ctx context.Context, target security.ScanTarget) ([]s
 	return findings, nil
 }
 
-// supportedExtensions lists file extensions the SAST scanner can analyze.
-var supportedExtensions = map[string]string{
-	".go":  "go",
-	".py":  "python",
-	".js":  "javascript",
-	".ts":  "typescript",
-	".tsx": "typescript",
-	".jsx": "javascript",
-}
-
-// scanFile parses a single source file and applies SAST patterns to its function bodies.
+// scanFile parses a single source file and runs its language's rules.
 func (s *SASTScanner) scanFile(absPath, relPath string) []security.Finding {
-	source, err := os.ReadFile(absPath)
-	if err != nil {
+	lang, ok := parser.LanguageForFile(relPath)
+	if !ok || len(s.rules[lang]) == 0 {
 		return nil
 	}
 
-	ext := filepath.Ext(relPath)
-	lang, ok := supportedExtensions[ext]
-	if !ok {
+	source, err := os.ReadFile(absPath)
+	if err != nil {
 		return nil
 	}
 
@@ -102,223 +255,58 @@ func (s *SASTScanner) scanFile(absPath, relPath string) []security.Finding {
 	}
 	defer tree.Close()
 
-	// Check import-level patterns (e.g., weak crypto imports in Go).
+	file := newTaintFile(tree)
 	var findings []security.Finding
-	findings = append(findings, s.checkImports(tree, source, relPath, lang)...)
-
-	// Get function boundaries and check each function body.
-	funcs := tree.Functions()
-	lines := strings.Split(string(source), "\n")
-
-	for _, fn := range funcs {
-		// Extract function body text (0-indexed lines).
-		startIdx := fn.StartLine - 1
-		endIdx := fn.EndLine
-		if startIdx < 0 {
-			startIdx = 0
+	for _, rule := range s.rules[lang] {
+		var hits []parser.Capture
+		if rule.Pattern != "" {
+			hits = file.captures(rule.Pattern, "match")
+		} else {
+			hits = file.taintedSinks(rule.Sources, rule.Sinks, rule.Sanitizers)
 		}
-		if endIdx > len(lines) {
-			endIdx = len(lines)
-		}
-		body := strings.Join(lines[startIdx:endIdx], "\n")
-
-		for _, pat := range s.patterns {
-			if pat.language != lang {
-				continue
-			}
-			// Skip import-level patterns handled separately.
-			if pat.name == "go-weak-crypto" {
-				continue
-			}
-
-			if pat.pattern.MatchString(body) {
-				// Find the specific line within the function body.
-				matchLine := s.findMatchLine(lines, startIdx, endIdx, pat.pattern)
-				findings = append(findings, s.newFinding(pat, relPath, matchLine, fn.Name))
-			}
+		for _, hit := range hits {
+			findings = append(findings, s.newFinding(rule, relPath, hit, file.enclosingFunction(hit)))
 		}
 	}
-
 	return findings
 }
 
-// checkImports checks for import-level patterns like weak crypto imports.
-func (s *SASTScanner) checkImports(tree *parser.Tree, source []byte, relPath, lang string) []security.Finding {
-	var findings []security.Finding
-	imports := tree.Imports()
-	lines := strings.Split(string(source), "\n")
-
-	for _, imp := range imports {
-		for _, pat := range s.patterns {
-			if pat.language != lang || pat.name != "go-weak-crypto" {
-				continue
-			}
-			if pat.pattern.MatchString(imp) {
-				// Find the line number of this import.
-				lineNum := 1
-				for i, line := range lines {
-					if strings.Contains(line, imp) {
-						lineNum = i + 1
-						break
-					}
-				}
-				findings = append(findings, s.newFinding(pat, relPath, lineNum, ""))
-			}
-		}
-	}
-	return findings
-}
-
-// findMatchLine locates the first line within a range that matches the pattern.
-func (s *SASTScanner) findMatchLine(lines []string, startIdx, endIdx int, pattern *regexp.Regexp) int {
-	for i := startIdx; i < endIdx && i < len(lines); i++ {
-		if pattern.MatchString(lines[i]) {
-			return i + 1 // 1-indexed
-		}
-	}
-	return startIdx + 1 // fallback to function start
-}
-
-// newFinding creates a properly formatted Finding from a matched SAST pattern.
-func (s *SASTScanner) newFinding(pat sastPattern, file string, line int, funcName string) security.Finding {
+// newFinding creates a properly formatted Finding from a rule hit.
+func (s *SASTScanner) newFinding(rule sastRule, file string, hit parser.Capture, funcName string) security.Finding {
 	s.mu.Lock()
 	s.findingCounter++
 	id := fmt.Sprintf("SAST-%04d", s.findingCounter)
 	s.mu.Unlock()
 
+	description := rule.Description
+	if description == "" {
+		description = fmt.Sprintf("%s found at %s:%d", rule.Title, file, hit.StartLine)
+	}
+
 	return security.Finding{
-		ID:       id,
-		Scanner:  "sast",
-		Severity: pat.severity,
-		Category: pat.category,
-		Title:    pat.title,
-		Description: fmt.Sprintf(
-			"%s found at %s:%d",
-			pat.title, file, line,
-		),
+		ID:          id,
+		Scanner:     "sast",
+		Severity:    rule.severity,
+		Category:    rule.category,
+		Title:       rule.Title,
+		Description: description,
 		Location: security.Location{
 			File:      file,
-			StartLine: line,
-			EndLine:   line,
+			StartLine: hit.StartLine,
+			EndLine:   hit.EndLine,
 			Function:  funcName,
 		},
-		CWE:        pat.cwe,
-		Confidence: pat.confidence,
+		CWE:        rule.CWE,
+		Evidence:   evidenceLine(hit.Text),
+		Confidence: rule.confidence,
+		Metadata:   map[string]string{"rule_id": rule.ID},
 	}
 }
 
-// defaultSASTPatterns returns the standard set of detection patterns.
-func defaultSASTPatterns() []sastPattern {
-	return []sastPattern{
-		// Go: SQL injection - string concatenation in db.Query/db.Exec/db.QueryRow
-		{
-			name:       "go-sql-injection",
-			language:   "go",
-			pattern:    regexp.MustCompile(`db\.(Query|Exec|QueryRow)\s*\([^)]*\+`),
-			severity:   security.SeverityHigh,
-			category:   security.CategoryInjection,
-			cwe:        "CWE-89",
-			title:      "Potential SQL injection via string concatenation",
-			confidence: security.ConfidenceMedium,
-		},
-		// Go: Command injection - exec.Command with shell invocation
-		{
-			name:       "go-command-injection",
-			language:   "go",
-			pattern:    regexp.MustCompile(`exec\.Command\s*\(\s*"sh"\s*,\s*"-c"\s*,`),
-			severity:   security.SeverityHigh,
-			category:   security.CategoryInjection,
-			cwe:        "CWE-78",
-			title:      "Potential command injection via exec.Command with shell",
-			confidence: security.ConfidenceMedium,
-		},
-		// Go: Weak crypto - import of known weak algorithms
-		{
-			name:       "go-weak-crypto",
-			language:   "go",
-			pattern:    regexp.MustCompile(`crypto/(md5|sha1|des|rc4)`),
-			severity:   security.SeverityMedium,
-			category:   security.CategoryCryptography,
-			cwe:        "CWE-327",
-			title:      "Use of weak cryptographic algorithm",
-			confidence: security.ConfidenceHigh,
-		},
-		// Go: Path traversal - os.Open/os.ReadFile with variable
-		{
-			name:       "go-path-traversal",
-			language:   "go",
-			pattern:    regexp.MustCompile(`os\.(Open|ReadFile)\s*\(\s*[a-z]`),
-			severity:   security.SeverityMedium,
-			category:   security.CategoryInputValidation,
-			cwe:        "CWE-22",
-			title:      "Potential path traversal via user-controlled file path",
-			confidence: security.ConfidenceLow,
-		},
-		// Python: SQL injection - string concatenation with .execute(
-		{
-			name:       "python-sql-injection",
-			language:   "python",
-			pattern:    regexp.MustCompile(`\.execute\s*\([^)]*\+`),
-			severity:   security.SeverityHigh,
-			category:   security.CategoryInjection,
-			cwe:        "CWE-89",
-			title:      "Potential SQL injection via string concatenation",
-			confidence: security.ConfidenceMedium,
-		},
-		// Python: Command injection - os.system(variable) or subprocess with shell=True
-		{
-			name:       "python-command-injection",
-			language:   "python",
-			pattern:    regexp.MustCompile(`(os\.system\s*\(\s*[a-z]|subprocess\.\w+\s*\([^)]*shell\s*=\s*True)`),
-			severity:   security.SeverityHigh,
-			category:   security.CategoryInjection,
-			cwe:        "CWE-78",
-			title:      "Potential command injection via shell execution",
-			confidence: security.ConfidenceMedium,
-		},
-		// JavaScript: XSS - innerHTML or document.write with variable
-		{
-			name:       "js-xss",
-			language:   "javascript",
-			pattern:    regexp.MustCompile(`(\.innerHTML\s*=\s*[a-z]|document\.write\s*\(\s*[a-z])`),
-			severity:   security.SeverityHigh,
-			category:   security.CategoryInjection,
-			cwe:        "CWE-79",
-			title:      "Potential XSS via unsafe DOM manipulation",
-			confidence: security.ConfidenceMedium,
-		},
-		// TypeScript: XSS - same pattern
-		{
-			name:       "ts-xss",
-			language:   "typescript",
-			pattern:    regexp.MustCompile(`(\.innerHTML\s*=\s*[a-z]|document\.write\s*\(\s*[a-z])`),
-			severity:   security.SeverityHigh,
-			category:   security.CategoryInjection,
-			cwe:        "CWE-79",
-			title:      "Potential XSS via unsafe DOM manipulation",
-			confidence: security.ConfidenceMedium,
-		},
-		// JavaScript: SQL injection - string concatenation with .query(
-		{
-			name:       "js-sql-injection",
-			language:   "javascript",
-			pattern:    regexp.MustCompile(`\.query\s*\([^)]*\+`),
-			severity:   security.SeverityHigh,
-			category:   security.CategoryInjection,
-			cwe:        "CWE-89",
-			title:      "Potential SQL injection via string concatenation",
-			confidence: security.ConfidenceMedium,
-		},
-		// TypeScript: SQL injection - same pattern
-		{
-			name:       "ts-sql-injection",
-			language:   "typescript",
-			pattern:    regexp.MustCompile(`\.query\s*\([^)]*\+`),
-			severity:   security.SeverityHigh,
-			category:   security.CategoryInjection,
-			cwe:        "CWE-89",
-			title:      "Potential SQL injection via string concatenation",
-			confidence: security.ConfidenceMedium,
-		},
+// evidenceLine trims captured source to its first line.
+func evidenceLine(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i] + " ..."
 	}
+	return strings.TrimSpace(text)
 }
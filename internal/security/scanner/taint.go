@@ -0,0 +1,426 @@
+package scanner
+
+import (
+	"sort"
+
+	"github.com/julianshen/rubichan/internal/parser"
+)
+
+// taintLanguage describes how data moves between local variables in one
+// language, for intra-procedural taint tracking.
+//
+// assignments captures a variable that is overwritten (@lhs) or extended
+// (@lhs_aug) with the value of @rhs; extending covers compound assignment
+// and calls that append to or fill in their receiver or an out-parameter.
+// identifiers captures variable references as @id, and names captures the
+// identifiers among them that name a field, method or keyword argument
+// instead.
+type taintLanguage struct {
+	assignments string
+	identifiers string
+	names       string
+}
+
+const plainIdentifiers = `(identifier) @id`
+
+var taintLanguages = map[string]taintLanguage{
+	"go": {
+		assignments: `
+(short_var_declaration left: (expression_list (identifier) @lhs) right: (expression_list) @rhs)
+(var_spec name: (identifier) @lhs value: (expression_list) @rhs)
+(assignment_statement left: (expression_list (identifier) @lhs) operator: "=" right: (expression_list) @rhs)
+(assignment_statement left: (expression_list (identifier) @lhs_aug) right: (expression_list) @rhs)
+(range_clause left: (expression_list (identifier) @lhs) right: (_) @rhs)
+((call_expression
+   function: (selector_expression operand: (identifier) @lhs_aug field: (field_identifier) @method)
+   arguments: (argument_list) @rhs)
+ (#match? @method "^Write"))
+(call_expression arguments: (argument_list (unary_expression operator: "&" operand: (identifier) @lhs_aug)) @rhs)
+`,
+		identifiers: plainIdentifiers,
+	},
+	"python": {
+		assignments: `
+(assignment left: (identifier) @lhs right: (_) @rhs)
+(assignment left: (pattern_list (identifier) @lhs) right: (_) @rhs)
+(assignment left: (tuple_pattern (identifier) @lhs) right: (_) @rhs)
+(augmented_assignment left: (identifier) @lhs_aug right: (_) @rhs)
+(named_expression name: (identifier) @lhs value: (_) @rhs)
+(for_statement left: (identifier) @lhs right: (_) @rhs)
+(for_statement left: (pattern_list (identifier) @lhs) right: (_) @rhs)
+((call
+   function: (attribute object: (identifier) @lhs_aug attribute: (identifier) @method)
+   arguments: (argument_list) @rhs)
+ (#match? @method "^(append|extend|insert|add|update|write)$"))
+`,
+		identifiers: plainIdentifiers,
+		names: `
+(attribute attribute: (identifier) @name)
+(keyword_argument name: (identifier) @name)
+`,
+	},
+	"javascript": {
+		assignments: ecmascriptAssignments,
+		identifiers: ecmascriptIdentifiers,
+	},
+	"typescript": {
+		assignments: ecmascriptAssignments,
+		identifiers: ecmascriptIdentifiers,
+	},
+	"java": {
+		assignments: `
+(variable_declarator name: (identifier) @lhs value: (_) @rhs)
+(assignment_expression left: (identifier) @lhs operator: "=" right: (_) @rhs)
+(assignment_expression left: (identifier) @lhs_aug right: (_) @rhs)
+(enhanced_for_statement name: (identifier) @lhs value: (_) @rhs)
+((method_invocation
+   object: (identifier) @lhs_aug name: (identifier) @method
+   arguments: (argument_list) @rhs)
+ (#match? @method "^(append|add|addAll|insert|put|putAll|write)$"))
+`,
+		identifiers: plainIdentifiers,
+		names: `
+(method_invocation name: (identifier) @name)
+(field_access field: (identifier) @name)
+`,
+	},
+	"rust": {
+		assignments: `
+(let_declaration pattern: (identifier) @lhs value: (_) @rhs)
+(let_declaration pattern: (tuple_pattern (identifier) @lhs) value: (_) @rhs)
+(assignment_expression left: (identifier) @lhs right: (_) @rhs)
+(compound_assignment_expr left: (identifier) @lhs_aug right: (_) @rhs)
+(for_expression pattern: (identifier) @lhs value: (_) @rhs)
+((call_expression
+   function: (field_expression value: (identifier) @lhs_aug field: (field_identifier) @method)
+   arguments: (arguments) @rhs)
+ (#match? @method "^(push_str|push|extend|insert|write_str|write_all)$"))
+`,
+		identifiers: plainIdentifiers,
+		names: `
+(scoped_identifier name: (identifier) @name)
+(macro_invocation macro: (identifier) @name)
+`,
+	},
+	"ruby": {
+		assignments: `
+(assignment left: (identifier) @lhs right: (_) @rhs)
+(assignment left: (left_assignment_list (identifier) @lhs) right: (_) @rhs)
+(operator_assignment left: (identifier) @lhs_aug right: (_) @rhs)
+(binary left: (identifier) @lhs_aug operator: "<<" right: (_) @rhs)
+`,
+		identifiers: plainIdentifiers,
+		names: `
+(call method: (identifier) @name)
+`,
+	},
+	"c":   {assignments: cAssignments, identifiers: plainIdentifiers},
+	"cpp": {assignments: cAssignments, identifiers: plainIdentifiers},
+}
+
+const ecmascriptAssignments = `
+(variable_declarator name: (identifier) @lhs value: (_) @rhs)
+(variable_declarator name: (object_pattern (shorthand_property_identifier_pattern) @lhs) value: (_) @rhs)
+(variable_declarator name: (array_pattern (identifier) @lhs) value: (_) @rhs)
+(assignment_expression left: (identifier) @lhs right: (_) @rhs)
+(augmented_assignment_expression left: (identifier) @lhs_aug right: (_) @rhs)
+(for_in_statement left: (identifier) @lhs right: (_) @rhs)
+((call_expression
+   function: (member_expression object: (identifier) @lhs_aug property: (property_identifier) @method)
+   arguments: (arguments) @rhs)
+ (#match? @method "^(push|unshift|append|set|add)$"))
+`
+
+const ecmascriptIdentifiers = `[(identifier) (shorthand_property_identifier)] @id`
+
+// cAssignments also treats the string and memory copying functions as
+// assignments to their destination argument.
+const cAssignments = `
+(init_declarator declarator: (identifier) @lhs value: (_) @rhs)
+(init_declarator declarator: (pointer_declarator declarator: (identifier) @lhs) value: (_) @rhs)
+(init_declarator declarator: (array_declarator declarator: (identifier) @lhs) value: (_) @rhs)
+(assignment_expression left: (identifier) @lhs operator: "=" right: (_) @rhs)
+(assignment_expression left: (identifier) @lhs_aug right: (_) @rhs)
+((call_expression
+   function: (identifier) @fn
+   arguments: (argument_list . (identifier) @lhs_aug)) @rhs
+ (#match? @fn "^(strcpy|strncpy|strcat|strncat|stpcpy|sprintf|snprintf|vsprintf|vsnprintf|memcpy|memmove)$"))
+`
+
+// variableNodeTypes are the node types a source can capture to taint the
+// variable they name, such as a parameter or a destructured binding.
+var variableNodeTypes = map[string]bool{
+	"identifier":                            true,
+	"shorthand_property_identifier_pattern": true,
+}
+
+type span struct{ start, end int }
+
+func spanOf(c parser.Capture) span { return span{c.StartByte, c.EndByte} }
+
+func (s span) contains(o span) bool { return s.start <= o.start && o.end <= s.end }
+
+type assignment struct {
+	lhs string
+	rhs span
+	aug bool
+}
+
+// taintFile runs the rules for one parsed file, sharing query results
+// between rules.
+type taintFile struct {
+	tree    *parser.Tree
+	lang    taintLanguage
+	funcs   []parser.FunctionDef
+	matches map[string][]parser.QueryMatch
+
+	flowLoaded  bool
+	identifiers []parser.Capture // variable references by position
+	assignments []assignment
+}
+
+func newTaintFile(tree *parser.Tree) *taintFile {
+	return &taintFile{
+		tree:    tree,
+		lang:    taintLanguages[tree.Language()],
+		funcs:   tree.Functions(),
+		matches: make(map[string][]parser.QueryMatch),
+	}
+}
+
+// query runs pattern once per file. Rule queries are validated when the
+// scanner is built, so an error here only means no matches.
+func (f *taintFile) query(pattern string) []parser.QueryMatch {
+	if m, ok := f.matches[pattern]; ok {
+		return m
+	}
+	m, _ := f.tree.QueryMatches(pattern)
+	f.matches[pattern] = m
+	return m
+}
+
+// captures returns the distinct nodes pattern captures under name.
+func (f *taintFile) captures(pattern, name string) []parser.Capture {
+	seen := make(map[span]bool)
+	var out []parser.Capture
+	for _, m := range f.query(pattern) {
+		for _, c := range m.Captures {
+			if c.Name != name || seen[spanOf(c)] {
+				continue
+			}
+			seen[spanOf(c)] = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// enclosingFunction names the innermost function containing c, or "" at
+// file level.
+func (f *taintFile) enclosingFunction(c parser.Capture) string {
+	name, size := "", -1
+	for _, fn := range f.funcs {
+		if !(span{fn.StartByte, fn.EndByte}).contains(spanOf(c)) {
+			continue
+		}
+		if size < 0 || fn.EndByte-fn.StartByte < size {
+			name, size = fn.Name, fn.EndByte-fn.StartByte
+		}
+	}
+	return name
+}
+
+func (f *taintFile) loadFlow() {
+	if f.flowLoaded {
+		return
+	}
+	f.flowLoaded = true
+
+	names := make(map[span]bool)
+	if f.lang.names != "" {
+		for _, c := range f.captures(f.lang.names, "name") {
+			names[spanOf(c)] = true
+		}
+	}
+	if f.lang.identifiers != "" {
+		for _, c := range f.captures(f.lang.identifiers, "id") {
+			if !names[spanOf(c)] {
+				f.identifiers = append(f.identifiers, c)
+			}
+		}
+	}
+	sort.Slice(f.identifiers, func(i, j int) bool {
+		return f.identifiers[i].StartByte < f.identifiers[j].StartByte
+	})
+
+	if f.lang.assignments == "" {
+		return
+	}
+	for _, m := range f.query(f.lang.assignments) {
+		rhs, ok := m.Capture("rhs")
+		if !ok {
+			continue
+		}
+		for _, c := range m.Captures {
+			if c.Name == "lhs" || c.Name == "lhs_aug" {
+				f.assignments = append(f.assignments, assignment{lhs: c.Text, rhs: spanOf(rhs), aug: c.Name == "lhs_aug"})
+			}
+		}
+	}
+}
+
+// taintFlow holds one taint rule's captures in a file.
+type taintFlow struct {
+	sources    []parser.Capture
+	sanitizers []span
+}
+
+func (t *taintFlow) sanitized(s span) bool {
+	for _, san := range t.sanitizers {
+		if san.contains(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// taintedSinks returns the sinks that untrusted data reaches. Each function
+// is analyzed on its own, and code outside any function as one more scope.
+// Within a scope, statements are followed in source order: a variable is
+// tainted by a source that names it or by an assignment from a tainted
+// expression, and cleared by an assignment from a clean one. An expression
+// is tainted when it contains a source or a tainted variable that is not
+// wrapped in a sanitizer. Loops are not iterated, so taint that only flows
+// backwards through a loop body is missed.
+func (f *taintFile) taintedSinks(sources, sinks, sanitizers []string) []parser.Capture {
+	var sinkCaps []parser.Capture
+	for _, p := range sinks {
+		sinkCaps = append(sinkCaps, f.captures(p, "sink")...)
+	}
+	if len(sinkCaps) == 0 {
+		return nil
+	}
+
+	f.loadFlow()
+	flow := &taintFlow{}
+	for _, p := range sources {
+		flow.sources = append(flow.sources, f.captures(p, "source")...)
+	}
+	if len(flow.sources) == 0 {
+		return nil
+	}
+	for _, p := range sanitizers {
+		for _, c := range f.captures(p, "sanitizer") {
+			flow.sanitizers = append(flow.sanitizers, spanOf(c))
+		}
+	}
+
+	hit := make(map[span]parser.Capture)
+	for _, fn := range f.funcs {
+		f.runScope(span{fn.StartByte, fn.EndByte}, false, flow, sinkCaps, hit)
+	}
+	f.runScope(span{0, int(^uint(0) >> 1)}, true, flow, sinkCaps, hit)
+
+	out := make([]parser.Capture, 0, len(hit))
+	for _, c := range hit {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartByte < out[j].StartByte })
+	return out
+}
+
+type taintEventKind int
+
+const (
+	eventSource taintEventKind = iota
+	eventAssign
+	eventSink
+)
+
+type taintEvent struct {
+	at     int
+	kind   taintEventKind
+	name   string
+	assign assignment
+	sink   parser.Capture
+}
+
+// runScope follows one scope in source order, recording tainted sinks in
+// hit. With fileLevel set, everything inside a function is left out.
+func (f *taintFile) runScope(scope span, fileLevel bool, flow *taintFlow, sinks []parser.Capture, hit map[span]parser.Capture) {
+	inScope := func(s span) bool {
+		if !scope.contains(s) {
+			return false
+		}
+		if fileLevel {
+			for _, fn := range f.funcs {
+				if (span{fn.StartByte, fn.EndByte}).contains(s) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	var events []taintEvent
+	for _, src := range flow.sources {
+		if variableNodeTypes[src.Type] && inScope(spanOf(src)) {
+			events = append(events, taintEvent{at: src.EndByte, kind: eventSource, name: src.Text})
+		}
+	}
+	for _, a := range f.assignments {
+		if inScope(a.rhs) {
+			events = append(events, taintEvent{at: a.rhs.end, kind: eventAssign, assign: a})
+		}
+	}
+	for _, s := range sinks {
+		if inScope(spanOf(s)) {
+			events = append(events, taintEvent{at: s.StartByte, kind: eventSink, sink: s})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].at != events[j].at {
+			return events[i].at < events[j].at
+		}
+		return events[i].kind < events[j].kind
+	})
+
+	tainted := make(map[string]bool)
+	for _, ev := range events {
+		switch ev.kind {
+		case eventSource:
+			tainted[ev.name] = true
+		case eventAssign:
+			switch {
+			case f.spanTainted(ev.assign.rhs, flow, tainted):
+				tainted[ev.assign.lhs] = true
+			case !ev.assign.aug:
+				delete(tainted, ev.assign.lhs)
+			}
+		case eventSink:
+			if f.spanTainted(spanOf(ev.sink), flow, tainted) {
+				hit[spanOf(ev.sink)] = ev.sink
+			}
+		}
+	}
+}
+
+// spanTainted reports whether the expression at s carries untrusted data.
+func (f *taintFile) spanTainted(s span, flow *taintFlow, tainted map[string]bool) bool {
+	for _, src := range flow.sources {
+		if s.contains(spanOf(src)) && !flow.sanitized(spanOf(src)) {
+			return true
+		}
+	}
+	if len(tainted) == 0 {
+		return false
+	}
+	i := sort.Search(len(f.identifiers), func(i int) bool { return f.identifiers[i].StartByte >= s.start })
+	for ; i < len(f.identifiers) && f.identifiers[i].StartByte < s.end; i++ {
+		id := f.identifiers[i]
+		if tainted[id.Text] && s.contains(spanOf(id)) && !flow.sanitized(spanOf(id)) {
+			return true
+		}
+	}
+	return false
+}
@@ -160,6 +160,10 @@ type ScanStats struct {
 	ChunksAnalyzed int
 	FindingsCount  int
 	ChainCount     int
+	// VulnDBAge is how long ago the offline vulnerability database the
+	// dependency scanner matched against was updated; zero when none was
+	// used.
+	VulnDBAge time.Duration
 }
 
 // ReportSummary provides aggregate counts of findings by severity.
@@ -210,6 +214,12 @@ type StaticScanner interface {
 	Scan(ctx context.Context, target ScanTarget) ([]Finding, error)
 }
 
+// VulnDBReporter is implemented by scanners that match against a local
+// vulnerability database, so the engine can report its age in ScanStats.
+type VulnDBReporter interface {
+	VulnDBAge() (time.Duration, bool)
+}
+
 // LLMAnalyzer is the interface for LLM-powered security analyzers that run
 // in the second phase on prioritized code segments.
 type LLMAnalyzer interface {
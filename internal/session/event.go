@@ -5,6 +5,7 @@ import (
 	"io"
 	"log"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,6 +25,7 @@ const (
 	EventTypeGateFailed           EventType = "gate_failed"
 	EventTypeCheckpointCreated    EventType = "checkpoint_created"
 	EventTypeCheckpointRestored   EventType = "checkpoint_restored"
+	EventTypeUserAnswer           EventType = "user_answer"
 )
 
 // Event is a UI-agnostic session event suitable for logging or external sinks.
@@ -42,6 +44,7 @@ type Event struct {
 	Plan         *PlanUpdatedEvent          `json:"plan,omitempty"`
 	Gate         *GateFailedEvent           `json:"gate,omitempty"`
 	Checkpoint   *CheckpointEvent           `json:"checkpoint,omitempty"`
+	Answer       *UserAnswerEvent           `json:"answer,omitempty"`
 }
 
 // Actor identifies which agent produced an event.
@@ -126,6 +129,16 @@ type CheckpointEvent struct {
 	Reason string `json:"reason,omitempty"`
 }
 
+// UserAnswerEvent captures the user's answer to a question the model asked
+// with the ask_user tool.
+type UserAnswerEvent struct {
+	RequestID string   `json:"request_id,omitempty"`
+	Question  string   `json:"question"`
+	Kind      string   `json:"kind"`
+	Answers   []string `json:"answers,omitempty"`
+	Defaulted bool     `json:"defaulted,omitempty"` // no answer arrived in time; Answers is the default
+}
+
 // EventSink receives structured session events.
 type EventSink interface {
 	Emit(Event)
@@ -177,10 +190,15 @@ func NewJSONLSink(w io.Writer) EventSink {
 		return SinkFunc(func(Event) {})
 	}
 	enc := json.NewEncoder(w)
+	// Tools such as ask_user emit from the agent's goroutine while hosts
+	// emit from their own, so writes are serialized.
+	var mu sync.Mutex
 	return SinkFunc(func(evt Event) {
 		if evt.Timestamp.IsZero() {
 			evt.Timestamp = time.Now().UTC()
 		}
+		mu.Lock()
+		defer mu.Unlock()
 		if err := enc.Encode(evt); err != nil {
 			log.Printf("session event encode error: type=%s timestamp=%s err=%v", evt.Type, evt.Timestamp.UTC().Format(time.RFC3339Nano), err)
 		}
@@ -381,6 +399,21 @@ func NewCheckpointRestoredEvent(id, reason string) Event {
 	}
 }
 
+// NewUserAnswerEvent constructs a user_answer event.
+func NewUserAnswerEvent(requestID, question, kind string, answers []string, defaulted bool) Event {
+	return Event{
+		Timestamp: time.Now().UTC(),
+		Type:      EventTypeUserAnswer,
+		Answer: &UserAnswerEvent{
+			RequestID: requestID,
+			Question:  strings.TrimSpace(question),
+			Kind:      kind,
+			Answers:   append([]string(nil), answers...),
+			Defaulted: defaulted,
+		},
+	}
+}
+
 // ParseVerificationSnapshot extracts verdict and reason from a snapshot string.
 func ParseVerificationSnapshot(snapshot string) (verdict string, reason string) {
 	for _, line := range strings.Split(snapshot, "\n") {
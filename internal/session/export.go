@@ -0,0 +1,317 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+// exportItem is one rendered entry of an exported session: a message's
+// text or a tool call together with its result.
+type exportItem struct {
+	Role string
+	Text string
+	Call *exportCall
+}
+
+// exportCall is a tool call paired with the result that answered it.
+type exportCall struct {
+	Name     string
+	Input    string // indented JSON
+	Diff     string // unified diff of a file edit, if the call is one
+	Result   string
+	IsError  bool
+	Answered bool
+}
+
+// exportItems flattens the messages of a bundle into rendered entries.
+// Tool results are folded into the call they answer; a result without a
+// matching call is shown on its own.
+func exportItems(msgs []store.BundleMessage) []exportItem {
+	calls := map[string]*exportCall{}
+	var items []exportItem
+	for _, m := range msgs {
+		for _, b := range m.Content {
+			switch b.Type {
+			case "text":
+				if strings.TrimSpace(b.Text) != "" {
+					items = append(items, exportItem{Role: m.Role, Text: b.Text})
+				}
+			case "tool_use":
+				c := &exportCall{Name: b.Name, Input: indentJSON(b.Input), Diff: editDiff(b.Input)}
+				calls[b.ID] = c
+				items = append(items, exportItem{Role: m.Role, Call: c})
+			case "tool_result":
+				if c, ok := calls[b.ToolUseID]; ok && !c.Answered {
+					c.Result, c.IsError, c.Answered = b.Text, b.IsError, true
+					continue
+				}
+				items = append(items, exportItem{Role: m.Role, Call: &exportCall{
+					Name: "tool result", Result: b.Text, IsError: b.IsError, Answered: true,
+				}})
+			case "image", "document":
+				items = append(items, exportItem{Role: m.Role, Text: "[" + b.Type + "]"})
+			}
+		}
+	}
+	return items
+}
+
+func indentJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+// editDiff renders a file tool edit (a path with old_string and
+// new_string) as a unified diff. Other inputs have no diff.
+func editDiff(raw json.RawMessage) string {
+	var in struct {
+		Path      string  `json:"path"`
+		OldString *string `json:"old_string"`
+		NewString *string `json:"new_string"`
+	}
+	if json.Unmarshal(raw, &in) != nil || in.OldString == nil || in.NewString == nil {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n@@ edit @@\n", in.Path, in.Path)
+	for _, line := range strings.Split(*in.OldString, "\n") {
+		b.WriteString("-" + line + "\n")
+	}
+	for _, line := range strings.Split(*in.NewString, "\n") {
+		b.WriteString("+" + line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// isDiff reports whether s reads as a unified diff.
+func isDiff(s string) bool {
+	return (strings.HasPrefix(s, "--- ") || strings.HasPrefix(s, "diff ") || strings.HasPrefix(s, "@@ ")) &&
+		strings.Contains(s, "\n+")
+}
+
+func roleTitle(role string) string {
+	switch role {
+	case "user":
+		return "User"
+	case "assistant":
+		return "Assistant"
+	}
+	return role
+}
+
+func sessionTitle(b *store.Bundle) string {
+	if b.Session.Title != "" {
+		return b.Session.Title
+	}
+	return "Session " + b.Session.ID
+}
+
+// fence returns a code fence longer than any backtick run in s.
+func fence(s string) string {
+	f := "```"
+	for strings.Contains(s, f) {
+		f += "`"
+	}
+	return f
+}
+
+// RenderMarkdown writes a session bundle as a Markdown transcript. Tool
+// calls are collapsed in <details> blocks and file edits shown as diffs.
+func RenderMarkdown(w io.Writer, b *store.Bundle) error {
+	var out strings.Builder
+	fmt.Fprintf(&out, "# %s\n\n", sessionTitle(b))
+	fmt.Fprintf(&out, "- Session: `%s`\n", b.Session.ID)
+	fmt.Fprintf(&out, "- Model: %s\n", b.Session.Model)
+	fmt.Fprintf(&out, "- Working directory: `%s`\n", b.Session.WorkingDir)
+	fmt.Fprintf(&out, "- Created: %s\n", b.Session.CreatedAt.Format("2006-01-02 15:04 MST"))
+	if b.Session.ForkedFrom != "" {
+		fmt.Fprintf(&out, "- Forked from: `%s`\n", b.Session.ForkedFrom)
+	}
+	if len(b.Checkpoints) > 0 {
+		fmt.Fprintf(&out, "- Checkpoints: %d\n", len(b.Checkpoints))
+	}
+
+	lastRole := ""
+	for _, item := range exportItems(b.Messages) {
+		if item.Role != lastRole {
+			fmt.Fprintf(&out, "\n## %s\n", roleTitle(item.Role))
+			lastRole = item.Role
+		}
+		if item.Call == nil {
+			fmt.Fprintf(&out, "\n%s\n", strings.TrimSpace(item.Text))
+			continue
+		}
+		c := item.Call
+		summary := "Tool: " + c.Name
+		if c.IsError {
+			summary += " (error)"
+		}
+		fmt.Fprintf(&out, "\n<details>\n<summary>%s</summary>\n\n", template.HTMLEscapeString(summary))
+		if c.Diff != "" {
+			writeMarkdownCode(&out, "diff", c.Diff)
+		} else if c.Input != "" {
+			writeMarkdownCode(&out, "json", c.Input)
+		}
+		if c.Answered {
+			lang := ""
+			if isDiff(c.Result) {
+				lang = "diff"
+			}
+			out.WriteString("Result:\n\n")
+			writeMarkdownCode(&out, lang, c.Result)
+		}
+		out.WriteString("</details>\n")
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func writeMarkdownCode(out *strings.Builder, lang, code string) {
+	f := fence(code)
+	fmt.Fprintf(out, "%s%s\n%s\n%s\n\n", f, lang, strings.TrimRight(code, "\n"), f)
+}
+
+// diffLine is one line of a diff in the HTML export, with the CSS class
+// that colors it.
+type diffLine struct {
+	Class string
+	Text  string
+}
+
+func diffLines(s string) []diffLine {
+	var lines []diffLine
+	for _, l := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		class := ""
+		switch {
+		case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"), strings.HasPrefix(l, "diff "):
+			class = "file"
+		case strings.HasPrefix(l, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(l, "+"):
+			class = "add"
+		case strings.HasPrefix(l, "-"):
+			class = "del"
+		}
+		lines = append(lines, diffLine{Class: class, Text: l})
+	}
+	return lines
+}
+
+var htmlExportFuncs = template.FuncMap{
+	"roleTitle": roleTitle,
+	"isDiff":    isDiff,
+	"diffLines": diffLines,
+}
+
+var htmlExportTemplate = template.Must(template.New("session").Funcs(htmlExportFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 960px; margin: 2rem auto; padding: 0 1rem; color: #1f2328; background: #fff; line-height: 1.5; }
+header dl { display: grid; grid-template-columns: max-content auto; gap: .25rem 1rem; color: #59636e; }
+header dt { font-weight: 600; }
+header dd { margin: 0; }
+.msg { border-left: 4px solid #d0d7de; margin: 1.25rem 0; padding: .25rem 1rem; }
+.msg.user { border-color: #0969da; }
+.msg.assistant { border-color: #8250df; }
+.role { font-weight: 600; font-size: .85rem; text-transform: uppercase; color: #59636e; }
+.text { white-space: pre-wrap; word-wrap: break-word; }
+pre { background: #f6f8fa; padding: .75rem; overflow-x: auto; border-radius: 6px; font-size: .85rem; }
+details { border: 1px solid #d0d7de; border-radius: 6px; margin: .5rem 0; padding: .25rem .75rem; }
+details.error { border-color: #cf222e; }
+summary { cursor: pointer; font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: .9rem; }
+.diff span { display: block; }
+.diff .add { background: #dafbe1; color: #116329; }
+.diff .del { background: #ffebe9; color: #82071e; }
+.diff .hunk { color: #0550ae; }
+.diff .file { font-weight: 600; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<dl>
+<dt>Session</dt><dd><code>{{.Session.ID}}</code></dd>
+<dt>Model</dt><dd>{{.Session.Model}}</dd>
+<dt>Working directory</dt><dd><code>{{.Session.WorkingDir}}</code></dd>
+<dt>Created</dt><dd>{{.Session.CreatedAt.Format "2006-01-02 15:04 MST"}}</dd>
+{{- if .Session.ForkedFrom}}
+<dt>Forked from</dt><dd><code>{{.Session.ForkedFrom}}</code></dd>
+{{- end}}
+{{- if .Checkpoints}}
+<dt>Checkpoints</dt><dd>{{.Checkpoints}}</dd>
+{{- end}}
+</dl>
+</header>
+<main>
+{{- range .Groups}}
+<section class="msg {{.Role}}">
+<div class="role">{{roleTitle .Role}}</div>
+{{- range .Items}}
+{{- if .Call}}{{with .Call}}
+<details{{if .IsError}} class="error"{{end}}>
+<summary>{{.Name}}{{if .IsError}} (error){{end}}</summary>
+{{- if .Diff}}
+<pre class="diff">{{range diffLines .Diff}}<span class="{{.Class}}">{{.Text}}</span>{{end}}</pre>
+{{- else if .Input}}
+<pre>{{.Input}}</pre>
+{{- end}}
+{{- if .Answered}}
+{{- if isDiff .Result}}
+<pre class="diff">{{range diffLines .Result}}<span class="{{.Class}}">{{.Text}}</span>{{end}}</pre>
+{{- else}}
+<pre>{{.Result}}</pre>
+{{- end}}
+{{- end}}
+</details>
+{{- end}}{{else}}
+<div class="text">{{.Text}}</div>
+{{- end}}
+{{- end}}
+</section>
+{{- end}}
+</main>
+</body>
+</html>
+`))
+
+// exportGroup is a run of consecutive entries from the same role.
+type exportGroup struct {
+	Role  string
+	Items []exportItem
+}
+
+// RenderHTML writes a session bundle as a self-contained HTML page: styles
+// are inline, tool calls collapse into <details> elements and diffs are
+// colored.
+func RenderHTML(w io.Writer, b *store.Bundle) error {
+	var groups []exportGroup
+	for _, item := range exportItems(b.Messages) {
+		if n := len(groups); n > 0 && groups[n-1].Role == item.Role {
+			groups[n-1].Items = append(groups[n-1].Items, item)
+			continue
+		}
+		groups = append(groups, exportGroup{Role: item.Role, Items: []exportItem{item}})
+	}
+	return htmlExportTemplate.Execute(w, struct {
+		Title       string
+		Session     store.BundleSession
+		Checkpoints int
+		Groups      []exportGroup
+	}{sessionTitle(b), b.Session, len(b.Checkpoints), groups})
+}
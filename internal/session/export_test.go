@@ -0,0 +1,75 @@
+package session
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+func exportTestBundle() *store.Bundle {
+	return &store.Bundle{
+		Version: store.BundleVersion,
+		Session: store.BundleSession{
+			ID: "s1", Title: "fix the <flaky> test", Model: "claude", WorkingDir: "/work/api",
+			CreatedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		},
+		Messages: []store.BundleMessage{
+			{Seq: 0, Role: "user", Content: []provider.ContentBlock{{Type: "text", Text: "fix the flaky test"}}},
+			{Seq: 1, Role: "assistant", Content: []provider.ContentBlock{
+				{Type: "text", Text: "Patching it."},
+				{Type: "tool_use", ID: "c1", Name: "file", Input: json.RawMessage(
+					`{"operation":"patch","path":"db.go","old_string":"lock()","new_string":"tryLock()"}`)},
+				{Type: "tool_use", ID: "c2", Name: "shell", Input: json.RawMessage(`{"command":"go test ./..."}`)},
+			}},
+			{Seq: 2, Role: "user", Content: []provider.ContentBlock{
+				{Type: "tool_result", ToolUseID: "c1", Text: "patched db.go"},
+				{Type: "tool_result", ToolUseID: "c2", Text: "FAIL ```x```", IsError: true},
+			}},
+			{Seq: 3, Role: "assistant", Content: []provider.ContentBlock{{Type: "text", Text: "Done."}}},
+		},
+		Checkpoints: []store.FileCheckpoint{{ID: "cp1", FilePath: "db.go"}},
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, RenderMarkdown(&b, exportTestBundle()))
+	out := b.String()
+
+	assert.Contains(t, out, "# fix the <flaky> test")
+	assert.Contains(t, out, "- Checkpoints: 1")
+	assert.Contains(t, out, "## User\n\nfix the flaky test")
+	assert.Contains(t, out, "<summary>Tool: file</summary>")
+	assert.Contains(t, out, "```diff\n--- a/db.go\n+++ b/db.go\n@@ edit @@\n-lock()\n+tryLock()\n```")
+	assert.Contains(t, out, "<summary>Tool: shell (error)</summary>")
+	assert.Contains(t, out, "````\nFAIL ```x```\n````", "fences outgrow backticks in the content")
+	// Tool results are shown with their call, not as a user message.
+	assert.Equal(t, 1, strings.Count(out, "## User"))
+}
+
+func TestRenderHTML(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, RenderHTML(&b, exportTestBundle()))
+	out := b.String()
+
+	assert.Contains(t, out, "<title>fix the &lt;flaky&gt; test</title>")
+	assert.NotContains(t, out, "<script src")
+	assert.NotContains(t, out, `<link rel="stylesheet"`)
+	assert.Contains(t, out, `<span class="del">-lock()</span><span class="add">&#43;tryLock()</span>`)
+	assert.Contains(t, out, `<details class="error">`)
+	assert.Contains(t, out, "patched db.go")
+	assert.Equal(t, 1, strings.Count(out, `class="msg user"`))
+}
+
+func TestIsDiff(t *testing.T) {
+	assert.True(t, isDiff("--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+b"))
+	assert.False(t, isDiff("--- not a diff"))
+	assert.False(t, isDiff("ok"))
+}
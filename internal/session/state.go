@@ -43,6 +43,7 @@ type State struct {
 	toolCalls      []ToolCall
 	toolCallArgs   map[string]json.RawMessage
 	plan           []PlanItem
+	todos          []PlanItem // the model's todo list; overrides plan when set
 	verdictHistory *VerdictHistory
 }
 
@@ -60,7 +61,7 @@ func (s *State) ResetForPrompt(prompt string) {
 	s.toolCalls = nil
 	s.plan = nil
 	clear(s.toolCallArgs)
-	// NOTE: verdictHistory is NOT cleared - it persists across turns
+	// NOTE: verdictHistory and todos are NOT cleared - they persist across turns
 	if looksLikeBackendVerificationPrompt(prompt) {
 		s.plan = []PlanItem{{
 			Step:   "Backend verification",
@@ -76,10 +77,15 @@ func (s *State) ToolCalls() []ToolCall {
 	return out
 }
 
-// Plan returns a copy of the current reducer-level plan.
+// Plan returns a copy of the current plan: the todo list the model keeps
+// when it has one, else the reducer-level plan.
 func (s *State) Plan() []PlanItem {
-	out := make([]PlanItem, len(s.plan))
-	copy(out, s.plan)
+	src := s.plan
+	if len(s.todos) > 0 {
+		src = s.todos
+	}
+	out := make([]PlanItem, len(src))
+	copy(out, src)
 	return out
 }
 
@@ -101,6 +107,11 @@ func (s *State) ApplyEvent(evt agentsdk.TurnEvent) {
 			Name:  evt.ToolCall.Name,
 			Input: append(json.RawMessage(nil), evt.ToolCall.Input...),
 		})
+	case "plan_update":
+		s.todos = s.todos[:0]
+		for _, entry := range evt.Plan {
+			s.todos = append(s.todos, PlanItem{Step: entry.Content, Status: PlanStatus(entry.Status)})
+		}
 	case "tool_result":
 		if evt.ToolResult == nil {
 			return
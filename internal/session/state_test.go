@@ -481,6 +481,31 @@ func TestStatePlanTransitionsToReverifyRequiredAfterEdit(t *testing.T) {
 	assert.Equal(t, PlanStatusReverifyRequired, s.Plan()[0].Status)
 }
 
+func TestStatePlanUpdateOverridesHeuristicPlan(t *testing.T) {
+	s := NewState()
+	s.ResetForPrompt("Verify this backend SQLite todo API end to end")
+	s.ApplyEvent(agentsdk.TurnEvent{
+		Type: "plan_update",
+		Plan: []agentsdk.PlanEntry{
+			{Content: "Write the handler", Status: agentsdk.PlanEntryCompleted},
+			{Content: "Add tests", Status: agentsdk.PlanEntryInProgress},
+		},
+	})
+	want := []PlanItem{
+		{Step: "Write the handler", Status: PlanStatusCompleted},
+		{Step: "Add tests", Status: PlanStatusInProgress},
+	}
+	assert.Equal(t, want, s.Plan())
+
+	// The todo list outlives the prompt and verification bookkeeping.
+	s.ResetForPrompt("Verify this backend SQLite todo API end to end")
+	_ = s.BuildVerificationSnapshot()
+	assert.Equal(t, want, s.Plan())
+
+	s.ApplyEvent(agentsdk.TurnEvent{Type: "plan_update"})
+	assert.Len(t, s.Plan(), 1, "an empty todo list falls back to the heuristic plan")
+}
+
 func TestStateBuildVerificationSnapshotSchemaMissingIsSoftFail(t *testing.T) {
 	s := NewState()
 	s.ResetForPrompt("Verify this backend SQLite todo API end to end")
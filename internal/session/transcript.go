@@ -127,6 +127,14 @@ func BuildTranscript(events []Event) string {
 				}
 				b.WriteString(line + "\n")
 			}
+		case EventTypeUserAnswer:
+			if evt.Answer != nil {
+				answer := strings.Join(evt.Answer.Answers, ", ")
+				if evt.Answer.Defaulted {
+					answer += " (default)"
+				}
+				fmt.Fprintf(&b, "User answer%s: %s => %s\n", prefix, evt.Answer.Question, answer)
+			}
 		case EventTypeCommandResult:
 			if evt.Command != nil {
 				line := "Command: " + strings.TrimSpace(evt.Command.Command)
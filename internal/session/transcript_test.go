@@ -148,3 +148,14 @@ func TestBuildTranscriptIncludesPlanGateAndCheckpointEvents(t *testing.T) {
 	assert.Contains(t, text, "Checkpoints created: 1")
 	assert.Contains(t, text, "Last gate failure: verification: verification was invalidated by later edits")
 }
+
+func TestBuildTranscriptIncludesUserAnswers(t *testing.T) {
+	events := []Event{
+		NewUserAnswerEvent("ask-1", "Which database?", "single", []string{"postgres"}, false).WithActor(PrimaryActor()),
+		NewUserAnswerEvent("ask-2", "Proceed?", "text", []string{"yes"}, true),
+	}
+
+	out := BuildTranscript(events)
+	assert.Contains(t, out, "User answer (primary): Which database? => postgres")
+	assert.Contains(t, out, "User answer: Proceed? => yes (default)")
+}
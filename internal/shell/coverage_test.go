@@ -88,8 +88,8 @@ func TestShellHost_SlashCommandNil(t *testing.T) {
 func TestShellHost_SlashCommandError(t *testing.T) {
 	t.Parallel()
 
-	slashFn := func(_ context.Context, _ string, _ []string) (string, bool, error) {
-		return "", false, errors.New("boom")
+	slashFn := func(_ context.Context, _ string, _ []string) (SlashResult, error) {
+		return SlashResult{}, errors.New("boom")
 	}
 	host := NewShellHost(ShellHostConfig{
 		WorkDir:        "/project",
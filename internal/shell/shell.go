@@ -24,8 +24,17 @@ type AgentTurnFunc func(ctx context.Context, userMessage string) (<-chan TurnEve
 // ShellExecFunc executes a shell command and returns stdout, stderr, and exit code.
 type ShellExecFunc func(ctx context.Context, command string, workDir string) (stdout string, stderr string, exitCode int, err error)
 
-// SlashCommandFunc handles a slash command. Returns output text, whether to quit, and error.
-type SlashCommandFunc func(ctx context.Context, name string, args []string) (output string, quit bool, err error)
+// SlashResult is the outcome of a slash command.
+type SlashResult struct {
+	Output string
+	Quit   bool
+	// Turn, when set, starts the agent turn the command asked for. Its
+	// events are streamed like those of a natural-language query.
+	Turn func(ctx context.Context) (<-chan TurnEvent, error)
+}
+
+// SlashCommandFunc handles a slash command.
+type SlashCommandFunc func(ctx context.Context, name string, args []string) (SlashResult, error)
 
 // ErrExit is the sentinel error indicating normal exit from the shell.
 var ErrExit = errors.New("exit")
@@ -412,15 +421,23 @@ func (h *ShellHost) handleSlashCommand(ctx context.Context, input ClassifiedInpu
 		return false
 	}
 
-	output, quit, err := h.slashCommandFn(ctx, input.Command, input.Args)
+	result, err := h.slashCommandFn(ctx, input.Command, input.Args)
 	if err != nil {
 		fmt.Fprintf(h.stderr, "error: %v\n", err)
 		return false
 	}
-	if output != "" {
-		fmt.Fprintln(h.stdout, output)
+	if result.Output != "" {
+		fmt.Fprintln(h.stdout, result.Output)
+	}
+	if result.Turn != nil {
+		events, err := result.Turn(ctx)
+		if err != nil {
+			fmt.Fprintf(h.stderr, "error: %v\n", err)
+			return result.Quit
+		}
+		h.streamEvents(events)
 	}
-	return quit
+	return result.Quit
 }
 
 // truncateForDisplay truncates a string to maxLen runes, adding ellipsis if needed.
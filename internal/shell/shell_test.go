@@ -169,10 +169,10 @@ func TestShellHost_SlashCommandDelegation(t *testing.T) {
 
 	var capturedName string
 	var capturedArgs []string
-	slashFn := func(_ context.Context, name string, args []string) (string, bool, error) {
+	slashFn := func(_ context.Context, name string, args []string) (SlashResult, error) {
 		capturedName = name
 		capturedArgs = args
-		return "model switched", false, nil
+		return SlashResult{Output: "model switched"}, nil
 	}
 
 	stdout := &bytes.Buffer{}
@@ -198,11 +198,11 @@ func TestShellHost_SlashCommandDelegation(t *testing.T) {
 func TestShellHost_SlashCommandQuit(t *testing.T) {
 	t.Parallel()
 
-	slashFn := func(_ context.Context, name string, _ []string) (string, bool, error) {
+	slashFn := func(_ context.Context, name string, _ []string) (SlashResult, error) {
 		if name == "quit" {
-			return "", true, nil
+			return SlashResult{Quit: true}, nil
 		}
-		return "", false, nil
+		return SlashResult{}, nil
 	}
 
 	host := NewShellHost(ShellHostConfig{
@@ -220,6 +220,36 @@ func TestShellHost_SlashCommandQuit(t *testing.T) {
 	assert.ErrorIs(t, err, ErrExit)
 }
 
+func TestShellHost_SlashCommandTurn(t *testing.T) {
+	t.Parallel()
+
+	slashFn := func(_ context.Context, name string, args []string) (SlashResult, error) {
+		return SlashResult{Turn: func(context.Context) (<-chan TurnEvent, error) {
+			ch := make(chan TurnEvent, 2)
+			ch <- TurnEvent{Type: "text_delta", Text: "notes for " + args[0]}
+			ch <- TurnEvent{Type: "done"}
+			close(ch)
+			return ch, nil
+		}}, nil
+	}
+
+	stdout := &bytes.Buffer{}
+	host := NewShellHost(ShellHostConfig{
+		WorkDir:        "/project",
+		HomeDir:        "/home/user",
+		Executables:    map[string]bool{},
+		SlashCommandFn: slashFn,
+		Stdin:          strings.NewReader("/release-notes v2\n"),
+		Stdout:         stdout,
+		Stderr:         &bytes.Buffer{},
+		GitBranchFn:    func(string) string { return "" },
+	})
+
+	err := host.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "notes for v2")
+}
+
 func TestShellHost_ToolCallEvents(t *testing.T) {
 	t.Parallel()
 
@@ -232,6 +232,7 @@ func newBackendFactory(ctx context.Context, deps backendDeps) func(skills.SkillM
 				manifest.Implementation.MCPCommand,
 				manifest.Implementation.MCPArgs,
 				manifest.Implementation.MCPURL,
+				manifest.Implementation.MCPHeaders,
 			)
 
 		default:
@@ -122,5 +122,18 @@ func (bt *BrokeredTool) Execute(ctx context.Context, input json.RawMessage) (too
 	return bt.inner.Execute(ctx, input)
 }
 
+// ExecuteStream implements tools.StreamingTool. It applies the same broker
+// check as Execute and streams through the wrapped tool when it supports
+// streaming; otherwise it falls back to a plain Execute.
+func (bt *BrokeredTool) ExecuteStream(ctx context.Context, input json.RawMessage, emit tools.ToolEventEmitter) (tools.ToolResult, error) {
+	if err := bt.broker.CheckExecution(ctx, bt.inner.Name(), input); err != nil {
+		return tools.ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	if st, ok := bt.inner.(tools.StreamingTool); ok {
+		return st.ExecuteStream(ctx, input, emit)
+	}
+	return bt.inner.Execute(ctx, input)
+}
+
 // Inner returns the wrapped tool, useful for testing.
 func (bt *BrokeredTool) Inner() tools.Tool { return bt.inner }
@@ -79,6 +79,45 @@ func TestBrokeredToolBlocksOnBrokerDenial(t *testing.T) {
 	assert.False(t, inner.called, "inner tool should NOT be called when denied")
 }
 
+// streamingStubTool emits one delta before returning its result.
+type streamingStubTool struct{ stubTool }
+
+func (s *streamingStubTool) ExecuteStream(_ context.Context, _ json.RawMessage, emit tools.ToolEventEmitter) (tools.ToolResult, error) {
+	emit(tools.ToolEvent{Stage: tools.EventDelta, Content: "working"})
+	return s.result, nil
+}
+
+func TestBrokeredToolStreamsThroughInner(t *testing.T) {
+	inner := &streamingStubTool{stubTool{name: "slow", result: tools.ToolResult{Content: "ok"}}}
+	bt := NewBrokeredTool(inner, &stubBroker{allow: true})
+
+	var events []tools.ToolEvent
+	result, err := bt.ExecuteStream(context.Background(), json.RawMessage(`{}`), func(ev tools.ToolEvent) {
+		events = append(events, ev)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.Content)
+	require.Len(t, events, 1)
+	assert.Equal(t, "working", events[0].Content)
+
+	denied := NewBrokeredTool(inner, &stubBroker{allow: false, err: fmt.Errorf("denied")})
+	result, err = denied.ExecuteStream(context.Background(), json.RawMessage(`{}`), func(tools.ToolEvent) {
+		t.Fatal("denied call must not stream")
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestBrokeredToolStreamFallsBackToExecute(t *testing.T) {
+	inner := &stubTool{name: "plain", result: tools.ToolResult{Content: "ok"}}
+	bt := NewBrokeredTool(inner, &stubBroker{allow: true})
+
+	result, err := bt.ExecuteStream(context.Background(), json.RawMessage(`{}`), func(tools.ToolEvent) {})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.Content)
+	assert.True(t, inner.called)
+}
+
 func TestBrokeredToolPreservesMetadata(t *testing.T) {
 	schema := json.RawMessage(`{"type":"object"}`)
 	inner := &stubTool{name: "my_tool", desc: "does stuff", schema: schema}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/julianshen/rubichan/internal/commands"
 	"github.com/julianshen/rubichan/internal/parser"
@@ -84,7 +85,7 @@ func (t *generateWikiTool) InputSchema() json.RawMessage {
 		},
 		"format": {
 			"type": "string",
-			"enum": ["raw-md", "hugo", "docusaurus"],
+			"enum": ["raw-md", "hugo", "docusaurus", "html"],
 			"description": "Output format (default: raw-md)"
 		},
 		"outdir": {
@@ -94,6 +95,10 @@ func (t *generateWikiTool) InputSchema() json.RawMessage {
 		"concurrency": {
 			"type": "integer",
 			"description": "Maximum parallel LLM calls (default: 5)"
+		},
+		"since": {
+			"type": "string",
+			"description": "Git ref to update the wiki from; only code changed since it is re-analyzed (default: regenerate everything)"
 		}
 	},
 	"additionalProperties": false
@@ -105,6 +110,7 @@ type generateWikiInput struct {
 	Format      string `json:"format"`
 	OutDir      string `json:"outdir"`
 	Concurrency int    `json:"concurrency"`
+	Since       string `json:"since"`
 }
 
 func (t *generateWikiTool) Execute(ctx context.Context, input json.RawMessage) (tools.ToolResult, error) {
@@ -138,12 +144,15 @@ func (t *generateWikiTool) Execute(ctx context.Context, input json.RawMessage) (
 		Format:      format,
 		DiagramFmt:  "mermaid",
 		Concurrency: concurrency,
+		Since:       params.Since,
 	}, t.llm, psr)
 	if err != nil {
 		return tools.ToolResult{Content: fmt.Sprintf("wiki generation failed: %v", err), IsError: true}, nil
 	}
 
-	return tools.ToolResult{
-		Content: fmt.Sprintf("Wiki generated successfully in %s (%d documents, format: %s)", result.OutputDir, result.Documents, result.Format),
-	}, nil
+	content := fmt.Sprintf("Wiki generated successfully in %s (%d documents, format: %s)", result.OutputDir, result.Documents, result.Format)
+	if len(result.BreakingAPIChanges) > 0 {
+		content += "\nBreaking API changes since the last run:\n- " + strings.Join(result.BreakingAPIChanges, "\n- ")
+	}
+	return tools.ToolResult{Content: content}, nil
 }
@@ -203,6 +203,7 @@ func (l *Loader) Discover(explicit []string) ([]DiscoveredSkill, []string, error
 					MCPCommand:   srv.Command,
 					MCPArgs:      srv.Args,
 					MCPURL:       srv.URL,
+					MCPHeaders:   srv.Headers,
 				},
 			},
 			Dir:    "",
@@ -168,10 +168,11 @@ type ImplementationConfig struct {
 
 	// MCP transport fields — populated programmatically for BackendMCP skills
 	// discovered from config.MCPServerConfig. Not set via YAML.
-	MCPTransport string   `yaml:"-" json:"-"`
-	MCPCommand   string   `yaml:"-" json:"-"`
-	MCPArgs      []string `yaml:"-" json:"-"`
-	MCPURL       string   `yaml:"-" json:"-"`
+	MCPTransport string            `yaml:"-" json:"-"`
+	MCPCommand   string            `yaml:"-" json:"-"`
+	MCPArgs      []string          `yaml:"-" json:"-"`
+	MCPURL       string            `yaml:"-" json:"-"`
+	MCPHeaders   map[string]string `yaml:"-" json:"-"`
 }
 
 // Dependency represents a dependency on another skill.
@@ -2,7 +2,11 @@ package mcpbackend
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/julianshen/rubichan/internal/commands"
@@ -11,18 +15,36 @@ import (
 	mcpclient "github.com/julianshen/rubichan/internal/tools/mcp"
 )
 
+// refreshTimeout bounds the tools/list a list_changed notification triggers.
+const refreshTimeout = 30 * time.Second
+
 // MCPBackend implements skills.SkillBackend for MCP-discovered skills.
 // It connects to an MCP server, discovers its tools, and wraps them as
-// tools.Tool instances that can be registered in the tool registry.
+// tools.Tool instances that can be registered in the tool registry. Servers
+// offering resources get an extra tool to list and read them, and prompts
+// become slash commands.
 type MCPBackend struct {
 	serverName string
 	transport  mcpclient.Transport
 	client     *mcpclient.Client
-	tools      []tools.Tool
+
+	mu             sync.Mutex
+	tools          []tools.Tool
+	extraTools     []tools.Tool
+	commands       []commands.SlashCommand
+	onToolsChanged func(previous []tools.Tool)
+	// refreshMu serializes tool refreshes, so a slow tools/list answer
+	// can't land after a newer one and the runtime sees one change at a
+	// time.
+	refreshMu sync.Mutex
 }
 
-// compile-time check: MCPBackend implements skills.SkillBackend.
-var _ skills.SkillBackend = (*MCPBackend)(nil)
+// compile-time checks: MCPBackend implements skills.SkillBackend and
+// reports tool list changes to the runtime.
+var (
+	_ skills.SkillBackend        = (*MCPBackend)(nil)
+	_ skills.ToolsChangeNotifier = (*MCPBackend)(nil)
+)
 
 // NewMCPBackend creates a new MCP-backed skill backend from an existing transport.
 func NewMCPBackend(serverName string, transport mcpclient.Transport) *MCPBackend {
@@ -35,32 +57,17 @@ func NewMCPBackend(serverName string, transport mcpclient.Transport) *MCPBackend
 // NewMCPBackendFromConfig creates an MCP backend by constructing the appropriate
 // transport from config fields. This is the factory used by the backendFactory
 // in main.go to wire BackendMCP skills discovered from MCPServerConfig.
-func NewMCPBackendFromConfig(ctx context.Context, serverName, transport, command string, args []string, sseURL string) (*MCPBackend, error) {
-	var t mcpclient.Transport
-	var err error
-
+// headers are sent with every request of the http transport.
+func NewMCPBackendFromConfig(ctx context.Context, serverName, transport, command string, args []string, url string, headers map[string]string) (*MCPBackend, error) {
 	switch transport {
-	case "stdio":
-		if command == "" {
-			return nil, fmt.Errorf("mcp backend: stdio transport requires a command")
-		}
-		t, err = mcpclient.NewStdioTransport(command, args)
-		if err != nil {
-			return nil, fmt.Errorf("mcp backend: create stdio transport: %w", err)
-		}
-	case "sse":
-		if sseURL == "" {
-			return nil, fmt.Errorf("mcp backend: sse transport requires a url")
-		}
-		initCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-		defer cancel()
-		t, err = mcpclient.NewSSETransport(initCtx, sseURL)
-		if err != nil {
-			return nil, fmt.Errorf("mcp backend: create sse transport: %w", err)
-		}
+	case "stdio", "http", "sse":
 	default:
 		return nil, fmt.Errorf("mcp backend: unsupported transport %q", transport)
 	}
+	t, err := mcpclient.NewTransport(ctx, transport, command, args, url, headers)
+	if err != nil {
+		return nil, fmt.Errorf("mcp backend: create %s transport: %w", transport, err)
+	}
 
 	return &MCPBackend{
 		serverName: serverName,
@@ -68,37 +75,133 @@ func NewMCPBackendFromConfig(ctx context.Context, serverName, transport, command
 	}, nil
 }
 
-// LoadWithContext connects to the MCP server and discovers its tools using the
-// provided context. This allows callers to cancel or timeout the initialization.
+// LoadWithContext connects to the MCP server and discovers its tools,
+// resources and prompts using the provided context. This allows callers to
+// cancel or timeout the initialization.
 func (b *MCPBackend) LoadWithContext(ctx context.Context) error {
 	b.client = mcpclient.NewClient(b.serverName, b.transport)
+	b.client.OnNotification(b.handleNotification)
 
 	if err := b.client.Initialize(ctx); err != nil {
 		return fmt.Errorf("initialize MCP server %q: %w", b.serverName, err)
 	}
 
-	mcpTools, err := b.client.ListTools(ctx)
+	wrapped, err := b.listTools(ctx)
 	if err != nil {
-		return fmt.Errorf("list MCP tools from %q: %w", b.serverName, err)
+		return err
 	}
 
-	b.tools = make([]tools.Tool, len(mcpTools))
-	for i, mt := range mcpTools {
-		b.tools[i] = mcpclient.WrapTool(b.serverName, b.client, mt)
+	caps := b.client.Capabilities()
+	var extra []tools.Tool
+	if caps.Resources != nil {
+		extra = append(extra, newResourceTool(b.serverName, b.client))
+	}
+
+	var cmds []commands.SlashCommand
+	if caps.Prompts != nil {
+		prompts, err := b.client.ListPrompts(ctx)
+		if err != nil {
+			return fmt.Errorf("list MCP prompts from %q: %w", b.serverName, err)
+		}
+		for _, p := range prompts {
+			cmds = append(cmds, newPromptCommand(b.serverName, b.client, p))
+		}
 	}
 
+	b.mu.Lock()
+	b.tools = wrapped
+	b.extraTools = extra
+	b.commands = cmds
+	b.mu.Unlock()
 	return nil
 }
 
+func (b *MCPBackend) listTools(ctx context.Context) ([]tools.Tool, error) {
+	mcpTools, err := b.client.ListTools(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list MCP tools from %q: %w", b.serverName, err)
+	}
+	wrapped := make([]tools.Tool, len(mcpTools))
+	for i, mt := range mcpTools {
+		wrapped[i] = mcpclient.WrapTool(b.serverName, b.client, mt)
+	}
+	return wrapped, nil
+}
+
+// handleNotification runs on the client's read loop, so the tool refresh
+// it triggers must run elsewhere: it waits on a response that same loop
+// delivers.
+func (b *MCPBackend) handleNotification(method string, _ json.RawMessage) {
+	if method == "notifications/tools/list_changed" {
+		go b.refreshTools()
+	}
+}
+
+// refreshTools re-lists the server's tools and hands the previous set to
+// the runtime so it can swap the registrations.
+func (b *MCPBackend) refreshTools() {
+	b.refreshMu.Lock()
+	defer b.refreshMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+	defer cancel()
+	wrapped, err := b.listTools(ctx)
+	if err != nil {
+		log.Printf("[mcp] refresh tools for %q: %v", b.serverName, err)
+		return
+	}
+
+	b.mu.Lock()
+	previous := append(append([]tools.Tool(nil), b.tools...), b.extraTools...)
+	b.tools = wrapped
+	fn := b.onToolsChanged
+	b.mu.Unlock()
+	if fn != nil {
+		fn(previous)
+	}
+}
+
+// OnToolsChanged registers fn to run after the server changes its tool
+// list and Tools reflects the new set. fn receives the tools it replaced;
+// calls for successive changes never overlap.
+func (b *MCPBackend) OnToolsChanged(fn func(previous []tools.Tool)) {
+	b.mu.Lock()
+	b.onToolsChanged = fn
+	b.mu.Unlock()
+}
+
 // Load connects to the MCP server and discovers its tools.
 // It delegates to LoadWithContext with a background context.
 func (b *MCPBackend) Load(_ skills.SkillManifest, _ skills.PermissionChecker) error {
 	return b.LoadWithContext(context.Background())
 }
 
-// Tools returns the wrapped MCP tools.
+// ServerName returns the name of the MCP server the backend connects to.
+func (b *MCPBackend) ServerName() string { return b.serverName }
+
+// ReadResource reads the resource at uri from the server, for resolving
+// @mcp: mentions.
+func (b *MCPBackend) ReadResource(ctx context.Context, uri string) ([]mcpclient.ResourceContents, error) {
+	if b.client == nil {
+		return nil, fmt.Errorf("mcp server %q is not connected", b.serverName)
+	}
+	if b.client.Capabilities().Resources == nil {
+		return nil, fmt.Errorf("mcp server %q offers no resources", b.serverName)
+	}
+	return b.client.ReadResource(ctx, uri)
+}
+
+// Tools returns the wrapped MCP tools, plus the resources tool when the
+// server offers resources.
 func (b *MCPBackend) Tools() []tools.Tool {
-	return b.tools
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tools == nil && b.extraTools == nil {
+		return nil
+	}
+	out := make([]tools.Tool, 0, len(b.tools)+len(b.extraTools))
+	out = append(out, b.tools...)
+	return append(out, b.extraTools...)
 }
 
 // Hooks returns no hooks — MCP skills don't register hooks.
@@ -106,8 +209,12 @@ func (b *MCPBackend) Hooks() map[skills.HookPhase]skills.HookHandler {
 	return nil
 }
 
-// Commands returns nil — MCP skills do not provide slash commands.
-func (b *MCPBackend) Commands() []commands.SlashCommand { return nil }
+// Commands returns one slash command per prompt the server offers.
+func (b *MCPBackend) Commands() []commands.SlashCommand {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.commands
+}
 
 // Agents returns nil — MCP skills do not provide agent definitions.
 func (b *MCPBackend) Agents() []*skills.AgentDefinition { return nil }
@@ -117,9 +224,28 @@ func (b *MCPBackend) Workflows() map[string]skills.WorkflowHandler { return nil
 
 // Unload disconnects from the MCP server.
 func (b *MCPBackend) Unload() error {
+	b.mu.Lock()
 	b.tools = nil
+	b.extraTools = nil
+	b.commands = nil
+	b.onToolsChanged = nil
+	b.mu.Unlock()
 	if b.client != nil {
 		return b.client.Close()
 	}
 	return nil
 }
+
+// renderContents formats resource contents as text for the model. Binary
+// contents cannot be shown and are only noted.
+func renderContents(contents []mcpclient.ResourceContents) string {
+	parts := make([]string, 0, len(contents))
+	for _, c := range contents {
+		if c.Text == "" && c.Blob != "" {
+			parts = append(parts, fmt.Sprintf("[binary %s content omitted: %s]", c.MimeType, c.URI))
+			continue
+		}
+		parts = append(parts, c.Text)
+	}
+	return strings.Join(parts, "\n")
+}
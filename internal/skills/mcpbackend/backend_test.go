@@ -124,26 +124,26 @@ func (m *mockTransport) Receive(_ context.Context, result any) error {
 func (m *mockTransport) Close() error { return nil }
 
 func TestNewMCPBackendFromConfigUnsupportedTransport(t *testing.T) {
-	_, err := NewMCPBackendFromConfig(context.Background(), "test-server", "websocket", "", nil, "")
+	_, err := NewMCPBackendFromConfig(context.Background(), "test-server", "websocket", "", nil, "", nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported transport")
 }
 
 func TestNewMCPBackendFromConfigStdioRequiresCommand(t *testing.T) {
-	_, err := NewMCPBackendFromConfig(context.Background(), "test-server", "stdio", "", nil, "")
+	_, err := NewMCPBackendFromConfig(context.Background(), "test-server", "stdio", "", nil, "", nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "requires a command")
 }
 
 func TestNewMCPBackendFromConfigSSERequiresURL(t *testing.T) {
-	_, err := NewMCPBackendFromConfig(context.Background(), "test-server", "sse", "", nil, "")
+	_, err := NewMCPBackendFromConfig(context.Background(), "test-server", "sse", "", nil, "", nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "requires a url")
 }
 
 func TestNewMCPBackendFromConfigStdioSuccess(t *testing.T) {
 	// "cat" is available on macOS/Linux and reads stdin until EOF.
-	backend, err := NewMCPBackendFromConfig(context.Background(), "stdio-server", "stdio", "cat", nil, "")
+	backend, err := NewMCPBackendFromConfig(context.Background(), "stdio-server", "stdio", "cat", nil, "", nil)
 	require.NoError(t, err)
 	require.NotNil(t, backend)
 	assert.Equal(t, "stdio-server", backend.serverName)
@@ -154,7 +154,7 @@ func TestNewMCPBackendFromConfigStdioSuccess(t *testing.T) {
 }
 
 func TestNewMCPBackendFromConfigStdioBadCommand(t *testing.T) {
-	_, err := NewMCPBackendFromConfig(context.Background(), "bad", "stdio", "/nonexistent/binary/xyz", nil, "")
+	_, err := NewMCPBackendFromConfig(context.Background(), "bad", "stdio", "/nonexistent/binary/xyz", nil, "", nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "create stdio transport")
 }
@@ -173,7 +173,7 @@ func TestNewMCPBackendFromConfigSSESuccess(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	backend, err := NewMCPBackendFromConfig(context.Background(), "sse-server", "sse", "", nil, srv.URL)
+	backend, err := NewMCPBackendFromConfig(context.Background(), "sse-server", "sse", "", nil, srv.URL, nil)
 	require.NoError(t, err)
 	require.NotNil(t, backend)
 	assert.Equal(t, "sse-server", backend.serverName)
@@ -184,7 +184,7 @@ func TestNewMCPBackendFromConfigSSESuccess(t *testing.T) {
 
 func TestNewMCPBackendFromConfigSSEConnectionError(t *testing.T) {
 	// Use a URL that will fail to connect.
-	_, err := NewMCPBackendFromConfig(context.Background(), "bad-sse", "sse", "", nil, "http://127.0.0.1:1")
+	_, err := NewMCPBackendFromConfig(context.Background(), "bad-sse", "sse", "", nil, "http://127.0.0.1:1", nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "create sse transport")
 }
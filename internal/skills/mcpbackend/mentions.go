@@ -0,0 +1,69 @@
+package mcpbackend
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/skills"
+)
+
+// mentionRe matches @mcp:<server>:<uri>. The URI runs to the next space;
+// trailing sentence punctuation is trimmed afterwards.
+var mentionRe = regexp.MustCompile(`@mcp:([A-Za-z0-9_.-]+):(\S+)`)
+
+// MentionResolver returns a resolver that expands @mcp:<server>:<uri>
+// mentions by reading each resource from the active MCP skill for that
+// server. Every mention yields one text block, holding either the resource
+// or the reason it could not be read, so the model never silently misses
+// a reference the user made.
+func MentionResolver(rt *skills.Runtime) func(ctx context.Context, text string) []provider.ContentBlock {
+	return func(ctx context.Context, text string) []provider.ContentBlock {
+		matches := mentionRe.FindAllStringSubmatch(text, -1)
+		if len(matches) == 0 {
+			return nil
+		}
+		backends := activeBackends(rt)
+
+		var blocks []provider.ContentBlock
+		seen := make(map[string]bool)
+		for _, m := range matches {
+			server, uri := m[1], strings.TrimRight(m[2], ".,;:!?)")
+			key := server + ":" + uri
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			var body string
+			if b, ok := backends[server]; !ok {
+				body = fmt.Sprintf("error: no active MCP server named %q", server)
+			} else if contents, err := b.ReadResource(ctx, uri); err != nil {
+				body = fmt.Sprintf("error: %v", err)
+			} else {
+				body = renderContents(contents)
+			}
+			blocks = append(blocks, provider.ContentBlock{
+				Type: "text",
+				Text: fmt.Sprintf("<mcp-resource server=%q uri=%q>\n%s\n</mcp-resource>", server, uri, body),
+			})
+		}
+		return blocks
+	}
+}
+
+// activeBackends indexes the runtime's active MCP backends by server name.
+func activeBackends(rt *skills.Runtime) map[string]*MCPBackend {
+	out := make(map[string]*MCPBackend)
+	if rt == nil {
+		return out
+	}
+	for _, sk := range rt.GetActiveSkills() {
+		if b, ok := sk.Backend.(*MCPBackend); ok {
+			out[b.ServerName()] = b
+		}
+	}
+	return out
+}
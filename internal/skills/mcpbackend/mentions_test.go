@@ -0,0 +1,40 @@
+package mcpbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/julianshen/rubichan/internal/skills"
+	"github.com/julianshen/rubichan/internal/store"
+	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMentionResolverReadsResources(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	backendFactory := func(skills.SkillManifest, string) (skills.SkillBackend, error) {
+		return NewMCPBackend("docs", newServerTransport(&fakeServer{})), nil
+	}
+	sandboxFactory := func(string, []skills.Permission) skills.PermissionChecker { return &noopChecker{} }
+	loader := skills.NewLoader("", "")
+	loader.RegisterBuiltin(&skills.SkillManifest{
+		Name: "mcp-docs", Version: "1.0.0", Description: "docs",
+		Types: []skills.SkillType{skills.SkillTypeTool},
+	})
+	rt := skills.NewRuntime(loader, s, tools.NewRegistry(), []string{"mcp-docs"}, backendFactory, sandboxFactory)
+	require.NoError(t, rt.Discover(nil))
+	require.NoError(t, rt.Activate("mcp-docs"))
+	defer rt.Deactivate("mcp-docs")
+
+	resolve := MentionResolver(rt)
+	assert.Nil(t, resolve(context.Background(), "no mentions here"))
+
+	blocks := resolve(context.Background(), "see @mcp:docs:file:///readme, and @mcp:docs:file:///readme. Also @mcp:other:x://y")
+	require.Len(t, blocks, 2)
+	assert.Equal(t, "<mcp-resource server=\"docs\" uri=\"file:///readme\">\nhello\n</mcp-resource>", blocks[0].Text)
+	assert.Contains(t, blocks[1].Text, `no active MCP server named "other"`)
+}
@@ -0,0 +1,151 @@
+package mcpbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/julianshen/rubichan/internal/commands"
+	"github.com/julianshen/rubichan/internal/tools"
+	mcpclient "github.com/julianshen/rubichan/internal/tools/mcp"
+)
+
+// resourceTool lists and reads an MCP server's resources.
+type resourceTool struct {
+	serverName string
+	client     *mcpclient.Client
+}
+
+func newResourceTool(serverName string, client *mcpclient.Client) *resourceTool {
+	return &resourceTool{serverName: serverName, client: client}
+}
+
+func (r *resourceTool) Name() string {
+	return fmt.Sprintf("mcp_%s_resources", r.serverName)
+}
+
+func (r *resourceTool) Description() string {
+	return fmt.Sprintf("List or read resources offered by the %s MCP server. "+
+		"Use action \"list\" to see available URIs, then \"read\" with a uri.", r.serverName)
+}
+
+func (r *resourceTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {"type": "string", "enum": ["list", "read"], "description": "list resources or read one"},
+			"uri": {"type": "string", "description": "Resource URI to read (read only)"}
+		},
+		"required": ["action"]
+	}`)
+}
+
+type resourceInput struct {
+	Action string `json:"action"`
+	URI    string `json:"uri"`
+}
+
+func (r *resourceTool) Execute(ctx context.Context, input json.RawMessage) (tools.ToolResult, error) {
+	var in resourceInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return tools.ToolResult{Content: fmt.Sprintf("invalid input: %s", err), IsError: true}, nil
+	}
+
+	switch in.Action {
+	case "list":
+		resources, err := r.client.ListResources(ctx)
+		if err != nil {
+			return tools.ToolResult{}, fmt.Errorf("mcp list resources: %w", err)
+		}
+		if len(resources) == 0 {
+			return tools.ToolResult{Content: "no resources"}, nil
+		}
+		var b strings.Builder
+		for _, res := range resources {
+			fmt.Fprintf(&b, "%s — %s", res.URI, res.Name)
+			if res.Description != "" {
+				fmt.Fprintf(&b, ": %s", res.Description)
+			}
+			b.WriteString("\n")
+		}
+		return tools.ToolResult{Content: b.String()}, nil
+	case "read":
+		if in.URI == "" {
+			return tools.ToolResult{Content: "uri is required for read", IsError: true}, nil
+		}
+		contents, err := r.client.ReadResource(ctx, in.URI)
+		if err != nil {
+			return tools.ToolResult{}, fmt.Errorf("mcp read resource %q: %w", in.URI, err)
+		}
+		return tools.ToolResult{Content: renderContents(contents)}, nil
+	default:
+		return tools.ToolResult{Content: fmt.Sprintf("unknown action: %s", in.Action), IsError: true}, nil
+	}
+}
+
+// promptCommand exposes an MCP prompt as the slash command
+// /mcp:<server>:<prompt>. Positional arguments fill the prompt's declared
+// arguments in order; any extra words go to the last one.
+type promptCommand struct {
+	serverName string
+	client     *mcpclient.Client
+	prompt     mcpclient.Prompt
+}
+
+func newPromptCommand(serverName string, client *mcpclient.Client, p mcpclient.Prompt) *promptCommand {
+	return &promptCommand{serverName: serverName, client: client, prompt: p}
+}
+
+func (c *promptCommand) Name() string {
+	return fmt.Sprintf("mcp:%s:%s", c.serverName, c.prompt.Name)
+}
+
+func (c *promptCommand) Description() string {
+	if c.prompt.Description != "" {
+		return c.prompt.Description
+	}
+	return fmt.Sprintf("MCP prompt from %s", c.serverName)
+}
+
+func (c *promptCommand) Arguments() []commands.ArgumentDef {
+	defs := make([]commands.ArgumentDef, len(c.prompt.Arguments))
+	for i, a := range c.prompt.Arguments {
+		defs[i] = commands.ArgumentDef{Name: a.Name, Description: a.Description, Required: a.Required}
+	}
+	return defs
+}
+
+func (c *promptCommand) Complete(context.Context, []string) []commands.Candidate { return nil }
+
+func (c *promptCommand) Execute(ctx context.Context, args []string) (commands.Result, error) {
+	declared := c.prompt.Arguments
+	values := make(map[string]string, len(declared))
+	for i, a := range declared {
+		switch {
+		case i >= len(args):
+			if a.Required {
+				return commands.Result{}, fmt.Errorf("missing required argument %q", a.Name)
+			}
+		case i == len(declared)-1:
+			values[a.Name] = strings.Join(args[i:], " ")
+		default:
+			values[a.Name] = args[i]
+		}
+	}
+
+	result, err := c.client.GetPrompt(ctx, c.prompt.Name, values)
+	if err != nil {
+		return commands.Result{}, fmt.Errorf("get MCP prompt %q: %w", c.prompt.Name, err)
+	}
+	var parts []string
+	for _, m := range result.Messages {
+		if m.Content.Text != "" {
+			parts = append(parts, m.Content.Text)
+		}
+	}
+	if len(parts) == 0 {
+		return commands.Result{}, fmt.Errorf("MCP prompt %q returned no text", c.prompt.Name)
+	}
+	return commands.Result{Prompt: strings.Join(parts, "\n\n")}, nil
+}
@@ -0,0 +1,253 @@
+package mcpbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/skills"
+	"github.com/julianshen/rubichan/internal/tools"
+	mcpclient "github.com/julianshen/rubichan/internal/tools/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer backs an in-process mcpclient.Server.
+type fakeServer struct {
+	mu    sync.Mutex
+	tools []mcpclient.MCPTool
+	// onList, when set, runs after ListTools has read the tool list and
+	// before it answers.
+	onList func()
+}
+
+func (f *fakeServer) ListTools(context.Context) []mcpclient.MCPTool {
+	f.mu.Lock()
+	list, onList := f.tools, f.onList
+	f.mu.Unlock()
+	if onList != nil {
+		onList()
+	}
+	return list
+}
+
+func (f *fakeServer) CallTool(_ context.Context, name string, _ json.RawMessage) (*mcpclient.ToolResult, error) {
+	return &mcpclient.ToolResult{Content: []mcpclient.ContentBlock{{Type: "text", Text: name}}}, nil
+}
+
+func (f *fakeServer) ListResources(context.Context) ([]mcpclient.Resource, error) {
+	return []mcpclient.Resource{{URI: "file:///readme", Name: "readme", Description: "Project readme"}}, nil
+}
+
+func (f *fakeServer) ListResourceTemplates(context.Context) ([]mcpclient.ResourceTemplate, error) {
+	return nil, nil
+}
+
+func (f *fakeServer) ReadResource(_ context.Context, uri string) ([]mcpclient.ResourceContents, error) {
+	if uri != "file:///readme" {
+		return nil, fmt.Errorf("unknown resource %s", uri)
+	}
+	return []mcpclient.ResourceContents{{URI: uri, Text: "hello"}}, nil
+}
+
+func (f *fakeServer) ListPrompts(context.Context) []mcpclient.Prompt {
+	return []mcpclient.Prompt{{
+		Name:      "review",
+		Arguments: []mcpclient.PromptArgument{{Name: "target", Required: true}, {Name: "focus"}},
+	}}
+}
+
+func (f *fakeServer) GetPrompt(_ context.Context, _ string, args map[string]string) (*mcpclient.PromptResult, error) {
+	return &mcpclient.PromptResult{Messages: []mcpclient.PromptMessage{{
+		Role:    "user",
+		Content: mcpclient.ContentBlock{Type: "text", Text: fmt.Sprintf("review %s for %s", args["target"], args["focus"])},
+	}}}, nil
+}
+
+// serverTransport delivers each message straight to an in-process server.
+// notify pushes a server notification to the client.
+type serverTransport struct {
+	server *mcpclient.Server
+	inbox  chan []byte
+}
+
+func newServerTransport(f *fakeServer) *serverTransport {
+	return &serverTransport{
+		server: mcpclient.NewServer(mcpclient.ServerConfig{Tools: f, Resources: f, Prompts: f}),
+		inbox:  make(chan []byte, 16),
+	}
+}
+
+func (s *serverTransport) Send(ctx context.Context, msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if resp := s.server.Handle(ctx, data); resp != nil {
+		s.inbox <- resp
+	}
+	return nil
+}
+
+func (s *serverTransport) Receive(ctx context.Context, result any) error {
+	select {
+	case msg, ok := <-s.inbox:
+		if !ok {
+			return io.EOF
+		}
+		return json.Unmarshal(msg, result)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *serverTransport) notify(method string) {
+	s.inbox <- []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":%q}`, method))
+}
+
+func (s *serverTransport) Close() error { return nil }
+
+func loadFakeBackend(t *testing.T, f *fakeServer) (*MCPBackend, *serverTransport) {
+	t.Helper()
+	st := newServerTransport(f)
+	backend := NewMCPBackend("docs", st)
+	require.NoError(t, backend.Load(skills.SkillManifest{Name: "mcp-docs"}, &noopChecker{}))
+	t.Cleanup(func() { _ = backend.Unload() })
+	return backend, st
+}
+
+func findTool(list []tools.Tool, name string) tools.Tool {
+	for _, tool := range list {
+		if tool.Name() == name {
+			return tool
+		}
+	}
+	return nil
+}
+
+func TestMCPBackendResourceTool(t *testing.T) {
+	backend, _ := loadFakeBackend(t, &fakeServer{tools: []mcpclient.MCPTool{{Name: "search"}}})
+
+	tool := findTool(backend.Tools(), "mcp_docs_resources")
+	require.NotNil(t, tool)
+
+	res, err := tool.Execute(context.Background(), json.RawMessage(`{"action":"list"}`))
+	require.NoError(t, err)
+	assert.Contains(t, res.Content, "file:///readme — readme: Project readme")
+
+	res, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"read","uri":"file:///readme"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", res.Content)
+
+	res, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"read"}`))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+
+	contents, err := backend.ReadResource(context.Background(), "file:///readme")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", contents[0].Text)
+}
+
+func TestMCPBackendPromptCommands(t *testing.T) {
+	backend, _ := loadFakeBackend(t, &fakeServer{})
+
+	cmds := backend.Commands()
+	require.Len(t, cmds, 1)
+	assert.Equal(t, "mcp:docs:review", cmds[0].Name())
+	assert.True(t, cmds[0].Arguments()[0].Required)
+
+	res, err := cmds[0].Execute(context.Background(), []string{"main.go", "error", "handling"})
+	require.NoError(t, err)
+	assert.Equal(t, "review main.go for error handling", res.Prompt)
+
+	_, err = cmds[0].Execute(context.Background(), nil)
+	assert.ErrorContains(t, err, `missing required argument "target"`)
+}
+
+func TestMCPBackendRefreshesToolsOnListChanged(t *testing.T) {
+	f := &fakeServer{tools: []mcpclient.MCPTool{{Name: "old"}}}
+	backend, st := loadFakeBackend(t, f)
+	require.NotNil(t, findTool(backend.Tools(), "mcp_docs_old"))
+
+	changed := make(chan []tools.Tool, 1)
+	backend.OnToolsChanged(func(previous []tools.Tool) { changed <- previous })
+
+	f.mu.Lock()
+	f.tools = []mcpclient.MCPTool{{Name: "new"}}
+	f.mu.Unlock()
+	st.notify("notifications/tools/list_changed")
+
+	select {
+	case previous := <-changed:
+		assert.NotNil(t, findTool(previous, "mcp_docs_old"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools were not refreshed")
+	}
+	current := backend.Tools()
+	assert.Nil(t, findTool(current, "mcp_docs_old"))
+	assert.NotNil(t, findTool(current, "mcp_docs_new"))
+	assert.NotNil(t, findTool(current, "mcp_docs_resources"))
+}
+
+func TestMCPBackendSerializesToolRefreshes(t *testing.T) {
+	f := &fakeServer{tools: []mcpclient.MCPTool{{Name: "v1"}}}
+	backend, st := loadFakeBackend(t, f)
+
+	var (
+		mu      sync.Mutex
+		running int
+		overlap bool
+	)
+	changed := make(chan struct{}, 2)
+	backend.OnToolsChanged(func([]tools.Tool) {
+		mu.Lock()
+		running++
+		overlap = overlap || running > 1
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		changed <- struct{}{}
+	})
+
+	// The first refresh reads v2, then stalls until the server has moved
+	// on to v3 and announced it.
+	listed := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	f.mu.Lock()
+	f.tools = []mcpclient.MCPTool{{Name: "v2"}}
+	f.onList = func() {
+		once.Do(func() {
+			close(listed)
+			<-release
+		})
+	}
+	f.mu.Unlock()
+	st.notify("notifications/tools/list_changed")
+	<-listed
+
+	f.mu.Lock()
+	f.tools = []mcpclient.MCPTool{{Name: "v3"}}
+	f.mu.Unlock()
+	st.notify("notifications/tools/list_changed")
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-changed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("tools were not refreshed")
+		}
+	}
+	assert.False(t, overlap, "change callbacks must not overlap")
+	current := backend.Tools()
+	assert.NotNil(t, findTool(current, "mcp_docs_v3"), "the newest tool list wins")
+	assert.Nil(t, findTool(current, "mcp_docs_v2"))
+}
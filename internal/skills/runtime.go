@@ -397,6 +397,12 @@ func (rt *Runtime) Activate(name string) error {
 		}
 	}
 
+	if n, ok := backend.(ToolsChangeNotifier); ok {
+		n.OnToolsChanged(func(previous []tools.Tool) {
+			rt.reregisterTools(name, backend, broker, previous)
+		})
+	}
+
 	priority := sourcePriority(source)
 	for phase, handler := range backend.Hooks() {
 		rt.lifecycle.Register(phase, name, priority, handler)
@@ -455,6 +461,30 @@ func (rt *Runtime) Activate(name string) error {
 	return nil
 }
 
+// reregisterTools swaps a skill's registered tools after its backend
+// reported a changed tool set. Nothing happens if the skill was deactivated
+// or reactivated with another backend in the meantime.
+func (rt *Runtime) reregisterTools(name string, backend SkillBackend, broker CapabilityBroker, previous []tools.Tool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	sk, ok := rt.active[name]
+	if !ok || sk.Backend != backend {
+		return
+	}
+	for _, tool := range previous {
+		_ = rt.registry.Unregister(tool.Name())
+	}
+	for _, tool := range backend.Tools() {
+		if rt.toolAdmissionFunc != nil && !rt.toolAdmissionFunc(tool.Name()) {
+			continue
+		}
+		if err := rt.registry.Register(NewBrokeredTool(tool, broker)); err != nil {
+			log.Printf("[skill-runtime] re-register tool %q for %q: %v", tool.Name(), name, err)
+		}
+	}
+}
+
 // Deactivate transitions a skill from Active to Inactive. It unregisters
 // tools, unregisters hooks, calls backend.Unload, and clears the backend.
 func (rt *Runtime) Deactivate(name string) error {
@@ -1582,3 +1582,48 @@ func TestRuntimeActivateBundledSkillMaterializes(t *testing.T) {
 	_, err = os.ReadFile(filepath.Join(sk.Dir, "SKILL.md"))
 	require.NoError(t, err)
 }
+
+// mockChangingBackend reports tool list changes like an MCP server does.
+type mockChangingBackend struct {
+	mockBackend
+	onChange func(previous []tools.Tool)
+}
+
+func (m *mockChangingBackend) OnToolsChanged(fn func(previous []tools.Tool)) { m.onChange = fn }
+
+func TestRuntimeReregistersToolsOnChange(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	registry := tools.NewRegistry()
+	backend := &mockChangingBackend{mockBackend: mockBackend{
+		tools: []tools.Tool{&runtimeMockTool{name: "old-tool"}},
+	}}
+	backendFactory := func(SkillManifest, string) (SkillBackend, error) { return backend, nil }
+	sandboxFactory := func(string, []Permission) PermissionChecker { return &mockPermissionChecker{} }
+
+	rt := NewRuntime(NewLoader("", ""), s, registry, []string{"live"}, backendFactory, sandboxFactory)
+	rt.loader.RegisterBuiltin(testManifest("live"))
+	require.NoError(t, rt.Discover(nil))
+	require.NoError(t, rt.Activate("live"))
+	require.NotNil(t, backend.onChange)
+
+	previous := backend.tools
+	backend.tools = []tools.Tool{&runtimeMockTool{name: "new-tool"}}
+	backend.onChange(previous)
+
+	_, found := registry.Get("old-tool")
+	assert.False(t, found, "replaced tool should be unregistered")
+	tool, found := registry.Get("new-tool")
+	require.True(t, found, "new tool should be registered")
+	_, brokered := tool.(*BrokeredTool)
+	assert.True(t, brokered, "new tool should go through the capability broker")
+
+	// A change reported after deactivation must not register anything.
+	require.NoError(t, rt.Deactivate("live"))
+	backend.tools = []tools.Tool{&runtimeMockTool{name: "late-tool"}}
+	backend.onChange(nil)
+	_, found = registry.Get("late-tool")
+	assert.False(t, found)
+}
@@ -218,6 +218,16 @@ type SkillBackend interface {
 	Unload() error
 }
 
+// ToolsChangeNotifier is an optional interface for backends whose tool set
+// can change while the skill is active, such as MCP servers that announce
+// tools/list_changed. The runtime swaps the skill's registered tools each
+// time fn runs; previous holds the tools Tools returned before the change.
+// Implementations must not run fn concurrently with itself, so each swap
+// starts from the registrations the last one left.
+type ToolsChangeNotifier interface {
+	OnToolsChanged(fn func(previous []tools.Tool))
+}
+
 // Skill is the runtime representation of a loaded skill. It combines the
 // static manifest with runtime state, filesystem location, discovery source,
 // and the implementation backend.
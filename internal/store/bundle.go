@@ -0,0 +1,264 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/provider"
+)
+
+// BundleVersion is the Bundle format ExportSession writes. ImportSession
+// accepts this version and older ones.
+const BundleVersion = 1
+
+// Bundle is a self-contained copy of one session, for moving it to
+// another machine with ExportSession and ImportSession.
+type Bundle struct {
+	Version     int              `json:"version"`
+	ExportedAt  time.Time        `json:"exported_at"`
+	Session     BundleSession    `json:"session"`
+	Messages    []BundleMessage  `json:"messages"`
+	Snapshot    *BundleSnapshot  `json:"snapshot,omitempty"`
+	Blobs       []BundleBlob     `json:"blobs,omitempty"`
+	Checkpoints []FileCheckpoint `json:"checkpoints,omitempty"`
+}
+
+// BundleSession is the session record of a Bundle.
+type BundleSession struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Model        string    `json:"model"`
+	WorkingDir   string    `json:"working_dir"`
+	SystemPrompt string    `json:"system_prompt,omitempty"`
+	ForkedFrom   string    `json:"forked_from,omitempty"`
+	TokenCount   int       `json:"token_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// BundleMessage is one stored message of a Bundle.
+type BundleMessage struct {
+	Seq       int                     `json:"seq"`
+	Role      string                  `json:"role"`
+	Content   []provider.ContentBlock `json:"content"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+// BundleSnapshot is the post-compaction conversation a resume starts from.
+type BundleSnapshot struct {
+	Messages   []provider.Message `json:"messages"`
+	TokenCount int                `json:"token_count"`
+}
+
+// BundleBlob is a large tool result that messages refer to by ID.
+type BundleBlob struct {
+	ID        string    `json:"id"`
+	ToolName  string    `json:"tool_name"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportSession returns a Bundle of the session: its messages, compaction
+// snapshot, recorded checkpoints and the tool-result blobs it can read,
+// including those a fork shares with the sessions it was forked from.
+func (s *Store) ExportSession(id string) (*Bundle, error) {
+	sess, err := s.GetSession(id)
+	if err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	if sess == nil {
+		return nil, fmt.Errorf("export: session %q not found", id)
+	}
+	b := &Bundle{
+		Version:    BundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Session: BundleSession{
+			ID: sess.ID, Title: sess.Title, Model: sess.Model, WorkingDir: sess.WorkingDir,
+			SystemPrompt: sess.SystemPrompt, ForkedFrom: sess.ForkedFrom, TokenCount: sess.TokenCount,
+			CreatedAt: sess.CreatedAt, UpdatedAt: sess.UpdatedAt,
+		},
+	}
+
+	msgs, err := s.GetMessages(id)
+	if err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	b.Messages = make([]BundleMessage, 0, len(msgs))
+	for _, m := range msgs {
+		b.Messages = append(b.Messages, BundleMessage{Seq: m.Seq, Role: m.Role, Content: m.Content, CreatedAt: m.CreatedAt})
+	}
+
+	var snapJSON string
+	var snapTokens int
+	err = s.db.QueryRow(
+		`SELECT messages, token_count FROM session_snapshots WHERE session_id = ?`, id,
+	).Scan(&snapJSON, &snapTokens)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return nil, fmt.Errorf("export: get snapshot: %w", err)
+	default:
+		b.Snapshot = &BundleSnapshot{TokenCount: snapTokens}
+		if err := json.Unmarshal([]byte(snapJSON), &b.Snapshot.Messages); err != nil {
+			return nil, fmt.Errorf("export: unmarshal snapshot: %w", err)
+		}
+	}
+
+	if b.Blobs, err = s.sessionBlobs(sess); err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	if b.Checkpoints, err = s.GetCheckpoints(id); err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	return b, nil
+}
+
+// sessionBlobs returns the blobs stored for sess and for the sessions it
+// was forked from, which forks read blobs through.
+func (s *Store) sessionBlobs(sess *Session) ([]BundleBlob, error) {
+	var blobs []BundleBlob
+	seen := map[string]bool{}
+	for cur := sess; cur != nil && !seen[cur.ID]; {
+		seen[cur.ID] = true
+		rows, err := s.db.Query(
+			`SELECT id, tool_name, content, created_at FROM tool_result_blobs
+			 WHERE session_id = ? ORDER BY created_at, id`, cur.ID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get blobs: %w", err)
+		}
+		for rows.Next() {
+			var blob BundleBlob
+			var createdStr string
+			if err := rows.Scan(&blob.ID, &blob.ToolName, &blob.Content, &createdStr); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan blob: %w", err)
+			}
+			blob.CreatedAt, _ = parseSQLiteDatetime(createdStr)
+			blobs = append(blobs, blob)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		if cur.ForkedFrom == "" {
+			break
+		}
+		if cur, err = s.GetSession(cur.ForkedFrom); err != nil {
+			return nil, err
+		}
+	}
+	return blobs, nil
+}
+
+// ImportSession stores the session of a Bundle under its original ID, so
+// it can be resumed or forked like a local one. workingDir, when set,
+// replaces the working directory recorded in the bundle. Importing a
+// session that already exists is an error, as is a bundle recording
+// checkpoints with IDs or file paths that are unsafe to resume (see
+// validateImportedCheckpoint).
+func (s *Store) ImportSession(b *Bundle, workingDir string) error {
+	if b.Version < 1 || b.Version > BundleVersion {
+		return fmt.Errorf("import: unsupported bundle version %d", b.Version)
+	}
+	sess := b.Session
+	if sess.ID == "" {
+		return fmt.Errorf("import: bundle has no session id")
+	}
+	existing, err := s.GetSession(sess.ID)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("import: session %q already exists", sess.ID)
+	}
+	if workingDir != "" {
+		sess.WorkingDir = normalizeWorkingDirPath(workingDir)
+	}
+	for _, cp := range b.Checkpoints {
+		if err := validateImportedCheckpoint(cp); err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("import: begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.Exec(
+		`INSERT INTO sessions (id, title, model, working_dir, system_prompt, token_count, forked_from, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sess.ID, sess.Title, sess.Model, sess.WorkingDir, sess.SystemPrompt, sess.TokenCount, sess.ForkedFrom,
+		bundleTime(sess.CreatedAt), bundleTime(sess.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("import: create session: %w", err)
+	}
+	for _, m := range b.Messages {
+		content, err := json.Marshal(m.Content)
+		if err != nil {
+			return fmt.Errorf("import: marshal message %d: %w", m.Seq, err)
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO messages (session_id, seq, role, content, created_at) VALUES (?, ?, ?, ?, ?)`,
+			sess.ID, m.Seq, m.Role, string(content), bundleTime(m.CreatedAt),
+		); err != nil {
+			return fmt.Errorf("import: message %d: %w", m.Seq, err)
+		}
+	}
+	if b.Snapshot != nil {
+		data, err := json.Marshal(b.Snapshot.Messages)
+		if err != nil {
+			return fmt.Errorf("import: marshal snapshot: %w", err)
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO session_snapshots (session_id, messages, token_count) VALUES (?, ?, ?)`,
+			sess.ID, string(data), b.Snapshot.TokenCount,
+		); err != nil {
+			return fmt.Errorf("import: snapshot: %w", err)
+		}
+	}
+	// Blob IDs are global: a blob already present, say from an earlier
+	// import of the session this one was forked from, is kept.
+	for _, blob := range b.Blobs {
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO tool_result_blobs (id, session_id, tool_name, content, byte_size, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			blob.ID, sess.ID, blob.ToolName, blob.Content, len(blob.Content), bundleTime(blob.CreatedAt),
+		); err != nil {
+			return fmt.Errorf("import: blob %s: %w", blob.ID, err)
+		}
+	}
+	if err := insertCheckpoints(tx, sess.ID, b.Checkpoints); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	// Like AppendMessage, indexing is best-effort: NewStore indexes any
+	// message missed here.
+	msgs, err := s.GetMessages(sess.ID)
+	if err != nil {
+		return nil
+	}
+	pending := make([]pendingIndex, len(msgs))
+	for i, m := range msgs {
+		pending[i] = pendingIndex{id: m.ID, sessionID: sess.ID, content: m.Content}
+	}
+	_ = s.indexMessages(pending)
+	return nil
+}
+
+// bundleTime formats t for a DATETIME column, using now for a zero time.
+func bundleTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format(usageTimeFormat)
+}
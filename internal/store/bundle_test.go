@@ -0,0 +1,148 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/provider"
+)
+
+func TestSaveAndGetCheckpoints(t *testing.T) {
+	s := newUsageStore(t)
+	require.NoError(t, s.CreateSession(Session{ID: "s1", Model: "m"}))
+
+	cps := []FileCheckpoint{
+		{ID: "c1", FilePath: "main.go", Turn: 1, Operation: "write", Existed: true, FileMode: 0o644, Data: []byte("package main")},
+		{ID: "c2", FilePath: "new.go", Turn: 2, Operation: "write"},
+	}
+	require.NoError(t, s.SaveCheckpoints("s1", cps))
+	// Saving the same checkpoints again does not duplicate them.
+	require.NoError(t, s.SaveCheckpoints("s1", cps))
+
+	got, err := s.GetCheckpoints("s1")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "main.go", got[0].FilePath)
+	assert.Equal(t, []byte("package main"), got[0].Data)
+	assert.Equal(t, uint32(0o644), got[0].FileMode)
+	assert.False(t, got[1].Existed)
+	assert.Nil(t, got[1].Data)
+
+	// A checkpoint left out, because it was undone, is dropped.
+	require.NoError(t, s.SaveCheckpoints("s1", cps[:1]))
+	got, err = s.GetCheckpoints("s1")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "c1", got[0].ID)
+}
+
+func TestExportImportSessionRoundTrip(t *testing.T) {
+	src := newUsageStore(t)
+	seedSearchSession(t, src, "root", "/work/api", "claude", "fix the flaky migration test", "deadlock detected")
+	require.NoError(t, src.SaveBlob("blob-1", "root", "shell", "a very long output", 18))
+	require.NoError(t, src.ForkSession("root", "s1"))
+	require.NoError(t, src.SaveSnapshot("s1", []provider.Message{
+		{Role: "user", Content: []provider.ContentBlock{{Type: "text", Text: "summary"}}},
+	}, 42))
+	require.NoError(t, src.SaveCheckpoints("s1", []FileCheckpoint{
+		{ID: "c1", FilePath: "db.go", Turn: 1, Operation: "patch", Existed: true, Data: []byte("old")},
+	}))
+
+	b, err := src.ExportSession("s1")
+	require.NoError(t, err)
+	assert.Equal(t, BundleVersion, b.Version)
+	assert.Equal(t, "root", b.Session.ForkedFrom)
+	require.Len(t, b.Messages, 4)
+	require.NotNil(t, b.Snapshot)
+	assert.Equal(t, 42, b.Snapshot.TokenCount)
+	require.Len(t, b.Blobs, 1, "blobs shared from the fork source are exported")
+	require.Len(t, b.Checkpoints, 1)
+
+	// The bundle survives a JSON round trip onto another machine.
+	data, err := json.Marshal(b)
+	require.NoError(t, err)
+	var decoded Bundle
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	dst := newUsageStore(t)
+	require.NoError(t, dst.ImportSession(&decoded, "/home/me/api"))
+
+	sess, err := dst.GetSession("s1")
+	require.NoError(t, err)
+	require.NotNil(t, sess)
+	assert.Equal(t, "/home/me/api", sess.WorkingDir)
+	assert.Equal(t, b.Session.CreatedAt, sess.CreatedAt)
+
+	msgs, err := dst.GetMessages("s1")
+	require.NoError(t, err)
+	require.Len(t, msgs, 4)
+	assert.Equal(t, "shell", msgs[1].Content[1].Name)
+
+	snap, err := dst.GetSnapshot("s1")
+	require.NoError(t, err)
+	require.Len(t, snap, 1)
+	blob, err := dst.GetBlob("blob-1")
+	require.NoError(t, err)
+	assert.Equal(t, "a very long output", blob)
+	cps, err := dst.GetCheckpoints("s1")
+	require.NoError(t, err)
+	require.Len(t, cps, 1)
+	assert.Equal(t, []byte("old"), cps[0].Data)
+
+	hits, err := dst.SearchMessages(SearchQuery{Text: "deadlock", Tool: "shell"})
+	require.NoError(t, err)
+	assert.Len(t, hits, 1, "imported messages are searchable")
+
+	// The imported session can be forked like a local one.
+	require.NoError(t, dst.ForkSessionAtTurn("s1", "s2", 0))
+}
+
+func TestImportSessionRejectsDuplicatesAndNewerVersions(t *testing.T) {
+	s := newUsageStore(t)
+	seedSearchSession(t, s, "s1", "/w", "m", "prompt", "ok")
+	b, err := s.ExportSession("s1")
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, s.ImportSession(b, ""), "already exists")
+
+	b.Session.ID = "s2"
+	b.Version = BundleVersion + 1
+	assert.ErrorContains(t, s.ImportSession(b, ""), "unsupported bundle version")
+
+	_, err = s.ExportSession("missing")
+	assert.Error(t, err)
+}
+
+func TestImportSessionRejectsUnsafeCheckpoints(t *testing.T) {
+	tests := []struct {
+		name string
+		cp   FileCheckpoint
+		want string
+	}{
+		{"traversal id", FileCheckpoint{ID: "../../../../home/u/x", FilePath: "main.go"}, "invalid id"},
+		{"id with separator", FileCheckpoint{ID: "a/b", FilePath: "main.go"}, "invalid id"},
+		{"empty id", FileCheckpoint{ID: "", FilePath: "main.go"}, "invalid id"},
+		{"absolute path", FileCheckpoint{ID: "c1", FilePath: "/etc/passwd"}, "not relative"},
+		{"empty path", FileCheckpoint{ID: "c1", FilePath: ""}, "not relative"},
+		{"parent path", FileCheckpoint{ID: "c1", FilePath: "../outside.go"}, "leaves the working directory"},
+		{"nested parent path", FileCheckpoint{ID: "c1", FilePath: "pkg/../../outside.go"}, "leaves the working directory"},
+		{"windows parent path", FileCheckpoint{ID: "c1", FilePath: `pkg\..\..\outside.go`}, "leaves the working directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newUsageStore(t)
+			b := &Bundle{
+				Version:     BundleVersion,
+				Session:     BundleSession{ID: "s1", Model: "m", WorkingDir: "/w"},
+				Checkpoints: []FileCheckpoint{{ID: "ok-1", FilePath: "main.go"}, tt.cp},
+			}
+			assert.ErrorContains(t, s.ImportSession(b, ""), tt.want)
+			sess, err := s.GetSession("s1")
+			require.NoError(t, err)
+			assert.Nil(t, sess, "nothing of a rejected bundle is stored")
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/checkpoint"
+)
+
+// FileCheckpoint is a file's content from before the agent changed it,
+// kept with the session after the run that captured it ends.
+type FileCheckpoint struct {
+	ID        string    `json:"id"`
+	FilePath  string    `json:"file_path"` // relative to the session's working dir when inside it
+	Turn      int       `json:"turn"`
+	Operation string    `json:"operation"`
+	Existed   bool      `json:"existed"` // false when the change created the file
+	FileMode  uint32    `json:"file_mode,omitempty"`
+	Data      []byte    `json:"data,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SaveCheckpoints makes cps the checkpoints recorded for a session.
+// Checkpoints already recorded, by ID, are left as they are; those missing
+// from cps, because they were undone or rewound, are deleted.
+func (s *Store) SaveCheckpoints(sessionID string, cps []FileCheckpoint) error {
+	ids := make([]string, len(cps))
+	for i, cp := range cps {
+		ids[i] = cp.ID
+	}
+	idList, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("save checkpoints: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("save checkpoints: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.Exec(
+		`DELETE FROM session_checkpoints
+		 WHERE session_id = ? AND id NOT IN (SELECT value FROM json_each(?))`,
+		sessionID, string(idList),
+	); err != nil {
+		return fmt.Errorf("save checkpoints: %w", err)
+	}
+	if err := insertCheckpoints(tx, sessionID, cps); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("save checkpoints: %w", err)
+	}
+	return nil
+}
+
+func insertCheckpoints(tx *sql.Tx, sessionID string, cps []FileCheckpoint) error {
+	for _, cp := range cps {
+		_, err := tx.Exec(
+			`INSERT OR IGNORE INTO session_checkpoints
+			   (id, session_id, file_path, turn, operation, existed, file_mode, data, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			cp.ID, sessionID, cp.FilePath, cp.Turn, cp.Operation, cp.Existed, cp.FileMode, cp.Data,
+			bundleTime(cp.CreatedAt),
+		)
+		if err != nil {
+			return fmt.Errorf("save checkpoint: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetCheckpoints returns the checkpoints recorded for a session, oldest first.
+func (s *Store) GetCheckpoints(sessionID string) ([]FileCheckpoint, error) {
+	rows, err := s.db.Query(
+		`SELECT id, file_path, turn, operation, existed, file_mode, data, created_at
+		 FROM session_checkpoints WHERE session_id = ? ORDER BY created_at, rowid`,
+		sessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var cps []FileCheckpoint
+	for rows.Next() {
+		var cp FileCheckpoint
+		var createdStr string
+		if err := rows.Scan(&cp.ID, &cp.FilePath, &cp.Turn, &cp.Operation, &cp.Existed,
+			&cp.FileMode, &cp.Data, &createdStr); err != nil {
+			return nil, fmt.Errorf("scan checkpoint: %w", err)
+		}
+		cp.CreatedAt, _ = parseSQLiteDatetime(createdStr)
+		cps = append(cps, cp)
+	}
+	return cps, rows.Err()
+}
+
+// validateImportedCheckpoint rejects a checkpoint from a bundle whose ID is
+// not a plain checkpoint ID or whose path is not relative to the session's
+// working directory. Bundles come from other machines; resuming a session
+// turns both into filesystem paths.
+func validateImportedCheckpoint(cp FileCheckpoint) error {
+	if !checkpoint.ValidID(cp.ID) {
+		return fmt.Errorf("checkpoint has invalid id %q", cp.ID)
+	}
+	p := filepath.ToSlash(cp.FilePath)
+	if p == "" || path.IsAbs(p) || filepath.IsAbs(cp.FilePath) || filepath.VolumeName(cp.FilePath) != "" {
+		return fmt.Errorf("checkpoint %s: path %q is not relative to the working directory", cp.ID, cp.FilePath)
+	}
+	for _, part := range strings.Split(strings.ReplaceAll(p, `\`, "/"), "/") {
+		if part == ".." {
+			return fmt.Errorf("checkpoint %s: path %q leaves the working directory", cp.ID, cp.FilePath)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,416 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/provider"
+)
+
+// Snippet markers around the matched terms in SearchHit.Snippet.
+const (
+	SnippetOpen  = "«"
+	SnippetClose = "»"
+)
+
+// SearchQuery selects the messages SearchMessages returns. Text is
+// required; every other field narrows the results when set. Zero
+// Since/Until leave that end of the range open.
+type SearchQuery struct {
+	Text       string
+	WorkingDir string // sessions run in this directory or below it
+	Since      time.Time
+	Until      time.Time
+	Tool       string // messages calling the tool or carrying its result
+	Model      string
+	Limit      int // defaults to 20
+}
+
+// SearchHit is one message matching a search, best match first.
+type SearchHit struct {
+	SessionID    string
+	SessionTitle string
+	WorkingDir   string
+	Model        string
+	MessageID    int64
+	Seq          int
+	Role         string
+	Snippet      string   // matched text, terms between SnippetOpen and SnippetClose
+	Tools        []string // tools the message calls or returns results for
+	CreatedAt    time.Time
+}
+
+const defaultSearchLimit = 20
+
+// SearchMessages runs a full-text search over the text, tool calls and tool
+// results of every stored session. Each word of q.Text must match; a word
+// ending in * matches as a prefix.
+func (s *Store) SearchMessages(q SearchQuery) ([]SearchHit, error) {
+	match := ftsQuery(q.Text)
+	if match == "" {
+		return nil, fmt.Errorf("search: empty query")
+	}
+
+	where := "messages_fts MATCH ?"
+	args := []any{match}
+	if q.WorkingDir != "" {
+		dir := normalizeWorkingDirPath(q.WorkingDir)
+		where += ` AND (s.working_dir = ? OR s.working_dir LIKE ? ESCAPE '\')`
+		args = append(args, dir, escapeLike(strings.TrimSuffix(dir, string(filepath.Separator)))+string(filepath.Separator)+"%")
+	}
+	if !q.Since.IsZero() {
+		where += " AND m.created_at >= ?"
+		args = append(args, q.Since.UTC().Format(usageTimeFormat))
+	}
+	if !q.Until.IsZero() {
+		where += " AND m.created_at < ?"
+		args = append(args, q.Until.UTC().Format(usageTimeFormat))
+	}
+	if q.Tool != "" {
+		where += " AND EXISTS (SELECT 1 FROM message_tools t WHERE t.message_id = m.id AND t.tool_name = ?)"
+		args = append(args, q.Tool)
+	}
+	if q.Model != "" {
+		where += " AND s.model = ?"
+		args = append(args, q.Model)
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.Query(
+		`SELECT m.id, m.session_id, m.seq, m.role, m.created_at, s.title, s.working_dir, s.model,
+		        snippet(messages_fts, 0, '`+SnippetOpen+`', '`+SnippetClose+`', '…', 24),
+		        (SELECT group_concat(DISTINCT t.tool_name) FROM message_tools t WHERE t.message_id = m.id)
+		 FROM messages_fts
+		 JOIN messages m ON m.id = messages_fts.rowid
+		 JOIN sessions s ON s.id = m.session_id
+		 WHERE `+where+`
+		 ORDER BY rank LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search messages: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var h SearchHit
+		var createdStr string
+		var tools sql.NullString
+		if err := rows.Scan(&h.MessageID, &h.SessionID, &h.Seq, &h.Role, &createdStr,
+			&h.SessionTitle, &h.WorkingDir, &h.Model, &h.Snippet, &tools); err != nil {
+			return nil, fmt.Errorf("scan search hit: %w", err)
+		}
+		h.CreatedAt, _ = parseSQLiteDatetime(createdStr)
+		if tools.String != "" {
+			h.Tools = strings.Split(tools.String, ",")
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+// ftsQuery quotes each word of text so FTS5 reads punctuation such as "-"
+// or ":" literally. A trailing * is kept as a prefix match.
+func ftsQuery(text string) string {
+	words := strings.Fields(text)
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		prefix := strings.HasSuffix(w, "*")
+		w = strings.TrimRight(w, "*")
+		if w == "" {
+			continue
+		}
+		term := `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
+
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+// ForkSessionAtTurn creates a new session from the source's history up to
+// the end of the turn that contains message seq: the turn's tool calls and
+// results are kept, the next user prompt and everything after it are not.
+func (s *Store) ForkSessionAtTurn(sourceID, newID string, seq int) error {
+	msgs, err := s.GetMessages(sourceID)
+	if err != nil {
+		return fmt.Errorf("fork: %w", err)
+	}
+	end := -1
+	for _, m := range msgs {
+		if m.Seq < seq {
+			continue
+		}
+		if m.Seq > seq && m.Role == "user" && !hasToolResult(m.Content) {
+			break
+		}
+		end = m.Seq
+	}
+	if end < 0 {
+		return fmt.Errorf("fork: session %q has no message %d", sourceID, seq)
+	}
+	return s.forkSession(sourceID, newID, end)
+}
+
+func hasToolResult(content []provider.ContentBlock) bool {
+	for _, b := range content {
+		if b.Type == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingIndex is a stored message to add to the search index.
+type pendingIndex struct {
+	id        int64
+	sessionID string
+	content   []provider.ContentBlock
+}
+
+// indexBatchSize is how many messages indexUnindexedMessages reads at a
+// time; tests lower it.
+var indexBatchSize = 500
+
+// indexMessage adds a message to the search index.
+func (s *Store) indexMessage(id int64, sessionID string, content []provider.ContentBlock) error {
+	return s.indexMessages([]pendingIndex{{id: id, sessionID: sessionID, content: content}})
+}
+
+// indexMessages adds messages to the search index in one transaction.
+func (s *Store) indexMessages(msgs []pendingIndex) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("index messages: begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := indexMessagesTx(tx, msgs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// indexMessagesTx indexes each message's text, the tool calls it makes and
+// the tool results it carries. Tool results are attributed to the tool whose
+// call they answer, found with one query for the whole batch; calls made
+// earlier in the batch count, so messages must come in order.
+func indexMessagesTx(tx *sql.Tx, msgs []pendingIndex) error {
+	type toolResult struct {
+		messageID int64
+		use       toolUse
+	}
+	var results []toolResult
+	for _, m := range msgs {
+		var body []string
+		for _, b := range m.content {
+			switch b.Type {
+			case "text":
+				body = append(body, b.Text)
+			case "tool_use":
+				body = append(body, b.Name)
+				if len(b.Input) > 0 {
+					body = append(body, string(b.Input))
+				}
+				if _, err := tx.Exec(
+					`INSERT INTO message_tools (message_id, tool_use_id, tool_name) VALUES (?, ?, ?)`,
+					m.id, b.ID, b.Name,
+				); err != nil {
+					return fmt.Errorf("index message tools: %w", err)
+				}
+			case "tool_result":
+				body = append(body, b.Text)
+				for _, nested := range b.Content {
+					if nested.Type == "text" {
+						body = append(body, nested.Text)
+					}
+				}
+				results = append(results, toolResult{messageID: m.id, use: toolUse{sessionID: m.sessionID, id: b.ToolUseID}})
+			}
+		}
+		if _, err := tx.Exec(
+			`INSERT OR REPLACE INTO messages_fts (rowid, body) VALUES (?, ?)`,
+			m.id, strings.Join(body, "\n"),
+		); err != nil {
+			return fmt.Errorf("index message: %w", err)
+		}
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	useIDs := make([]string, len(results))
+	for i, r := range results {
+		useIDs[i] = r.use.id
+	}
+	names, err := toolNames(tx, useIDs)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		name, ok := names[r.use]
+		if !ok {
+			continue
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO message_tools (message_id, tool_use_id, tool_name) VALUES (?, '', ?)`,
+			r.messageID, name,
+		); err != nil {
+			return fmt.Errorf("index message tools: %w", err)
+		}
+	}
+	return nil
+}
+
+// toolUse identifies a tool call within a session.
+type toolUse struct {
+	sessionID string
+	id        string
+}
+
+// toolNames returns the tool each of the given indexed tool calls invoked.
+func toolNames(tx *sql.Tx, useIDs []string) (map[toolUse]string, error) {
+	ids, err := json.Marshal(useIDs)
+	if err != nil {
+		return nil, fmt.Errorf("find tools of results: %w", err)
+	}
+	rows, err := tx.Query(
+		`SELECT m.session_id, t.tool_use_id, t.tool_name FROM message_tools t
+		 JOIN messages m ON m.id = t.message_id
+		 WHERE t.tool_use_id IN (SELECT value FROM json_each(?))`,
+		string(ids),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("find tools of results: %w", err)
+	}
+	defer rows.Close()
+
+	names := make(map[toolUse]string)
+	for rows.Next() {
+		var use toolUse
+		var name string
+		if err := rows.Scan(&use.sessionID, &use.id, &name); err != nil {
+			return nil, fmt.Errorf("scan tool of result: %w", err)
+		}
+		if _, ok := names[use]; !ok {
+			names[use] = name
+		}
+	}
+	return names, rows.Err()
+}
+
+// indexUnindexedMessages indexes messages stored before the search index
+// existed, or whose indexing failed. It runs in one transaction, reading
+// indexBatchSize messages at a time in order so tool results find the calls
+// they answer.
+func (s *Store) indexUnindexedMessages() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("index messages: begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var afterSession string
+	afterSeq := -1
+	for {
+		batch, lastSeq, err := unindexedMessages(tx, afterSession, afterSeq)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		afterSession, afterSeq = batch[len(batch)-1].sessionID, lastSeq
+
+		// A partly indexed message is cleared first so its tools are not
+		// recorded twice.
+		ids := make([]int64, len(batch))
+		for i, p := range batch {
+			ids[i] = p.id
+		}
+		idsJSON, err := json.Marshal(ids)
+		if err != nil {
+			return fmt.Errorf("clear message tools: %w", err)
+		}
+		if _, err := tx.Exec(
+			`DELETE FROM message_tools WHERE message_id IN (SELECT value FROM json_each(?))`,
+			string(idsJSON),
+		); err != nil {
+			return fmt.Errorf("clear message tools: %w", err)
+		}
+		if err := indexMessagesTx(tx, batch); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// unindexedMessages returns the next batch of unindexed messages after the
+// given session and seq, and the seq of the last one.
+func unindexedMessages(tx *sql.Tx, afterSession string, afterSeq int) ([]pendingIndex, int, error) {
+	rows, err := tx.Query(
+		`SELECT id, session_id, seq, content FROM messages
+		 WHERE (session_id, seq) > (?, ?) AND id NOT IN (SELECT rowid FROM messages_fts)
+		 ORDER BY session_id, seq LIMIT ?`,
+		afterSession, afterSeq, indexBatchSize,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list unindexed messages: %w", err)
+	}
+	defer rows.Close()
+
+	var batch []pendingIndex
+	var seq int
+	for rows.Next() {
+		var p pendingIndex
+		var contentJSON string
+		if err := rows.Scan(&p.id, &p.sessionID, &seq, &contentJSON); err != nil {
+			return nil, 0, fmt.Errorf("scan message: %w", err)
+		}
+		// Unreadable content is indexed as empty so it is not retried.
+		_ = json.Unmarshal([]byte(contentJSON), &p.content)
+		batch = append(batch, p)
+	}
+	return batch, seq, rows.Err()
+}
+
+// copyMessageIndex copies the search index entries of the messages copied
+// from one session to another; messages pair up by seq.
+func copyMessageIndex(tx *sql.Tx, sourceID, newID string) error {
+	if _, err := tx.Exec(
+		`INSERT INTO messages_fts (rowid, body)
+		 SELECT dst.id, f.body FROM messages dst
+		 JOIN messages src ON src.session_id = ? AND src.seq = dst.seq
+		 JOIN messages_fts f ON f.rowid = src.id
+		 WHERE dst.session_id = ?`,
+		sourceID, newID,
+	); err != nil {
+		return fmt.Errorf("copy search index: %w", err)
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO message_tools (message_id, tool_use_id, tool_name)
+		 SELECT dst.id, t.tool_use_id, t.tool_name FROM messages dst
+		 JOIN messages src ON src.session_id = ? AND src.seq = dst.seq
+		 JOIN message_tools t ON t.message_id = src.id
+		 WHERE dst.session_id = ?`,
+		sourceID, newID,
+	); err != nil {
+		return fmt.Errorf("copy message tools: %w", err)
+	}
+	return nil
+}
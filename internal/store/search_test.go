@@ -0,0 +1,220 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/provider"
+)
+
+// seedSearchSession stores a session with a prompt, a shell call and its
+// result, and a closing answer.
+func seedSearchSession(t *testing.T, s *Store, id, dir, model, prompt, output string) {
+	t.Helper()
+	require.NoError(t, s.CreateSession(Session{ID: id, Model: model, WorkingDir: dir}))
+	require.NoError(t, s.AppendMessage(id, "user", []provider.ContentBlock{{Type: "text", Text: prompt}}))
+	require.NoError(t, s.AppendMessage(id, "assistant", []provider.ContentBlock{
+		{Type: "text", Text: "Running the tests."},
+		{Type: "tool_use", ID: id + "-call", Name: "shell", Input: json.RawMessage(`{"command":"go test ./migrations"}`)},
+	}))
+	require.NoError(t, s.AppendMessage(id, "user", []provider.ContentBlock{
+		{Type: "tool_result", ToolUseID: id + "-call", Text: output},
+	}))
+	require.NoError(t, s.AppendMessage(id, "assistant", []provider.ContentBlock{{Type: "text", Text: "Done."}}))
+}
+
+func TestSearchMessagesMatchesTextAndToolResults(t *testing.T) {
+	s := newUsageStore(t)
+	seedSearchSession(t, s, "s1", "/work/api", "claude", "fix the flaky migration test", "FAIL TestMigrateUsers: deadlock detected")
+	seedSearchSession(t, s, "s2", "/work/web", "gpt", "update the README", "ok")
+
+	hits, err := s.SearchMessages(SearchQuery{Text: "flaky migration"})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "s1", hits[0].SessionID)
+	assert.Equal(t, 0, hits[0].Seq)
+	assert.Equal(t, "user", hits[0].Role)
+	assert.Contains(t, hits[0].Snippet, SnippetOpen+"flaky"+SnippetClose)
+	assert.Equal(t, "fix the flaky migration test", hits[0].SessionTitle)
+
+	hits, err = s.SearchMessages(SearchQuery{Text: "deadlock"})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, 2, hits[0].Seq)
+	assert.Equal(t, []string{"shell"}, hits[0].Tools, "results are attributed to the tool they answer")
+
+	// Tool call input is searchable; porter stemming matches word forms.
+	hits, err = s.SearchMessages(SearchQuery{Text: "migrations"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, hits)
+	hits, err = s.SearchMessages(SearchQuery{Text: "migrat*"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, hits)
+}
+
+func TestSearchMessagesFilters(t *testing.T) {
+	s := newUsageStore(t)
+	seedSearchSession(t, s, "s1", "/work/api", "claude", "tests", "ok")
+	seedSearchSession(t, s, "s2", "/work/api/sub", "gpt", "tests", "ok")
+	seedSearchSession(t, s, "s3", "/work/apiary", "claude", "tests", "ok")
+
+	sessions := func(q SearchQuery) []string {
+		t.Helper()
+		q.Text = "tests"
+		hits, err := s.SearchMessages(q)
+		require.NoError(t, err)
+		seen := map[string]bool{}
+		var out []string
+		for _, h := range hits {
+			if !seen[h.SessionID] {
+				seen[h.SessionID] = true
+				out = append(out, h.SessionID)
+			}
+		}
+		return out
+	}
+
+	assert.ElementsMatch(t, []string{"s1", "s2"}, sessions(SearchQuery{WorkingDir: "/work/api"}))
+	assert.ElementsMatch(t, []string{"s1", "s3"}, sessions(SearchQuery{Model: "claude"}))
+	assert.Empty(t, sessions(SearchQuery{Tool: "read_file"}))
+	assert.Len(t, sessions(SearchQuery{Tool: "shell"}), 3)
+	assert.Empty(t, sessions(SearchQuery{Since: time.Now().Add(time.Hour)}))
+	assert.Empty(t, sessions(SearchQuery{Until: time.Now().Add(-time.Hour)}))
+	assert.Len(t, sessions(SearchQuery{Since: time.Now().Add(-time.Hour)}), 3)
+}
+
+func TestSearchMessagesNormalizesWorkingDir(t *testing.T) {
+	s := newUsageStore(t)
+	real := t.TempDir()
+	link := filepath.Join(t.TempDir(), "link")
+	require.NoError(t, os.Symlink(real, link))
+	seedSearchSession(t, s, "s1", link+"/", "m", "tests", "ok")
+
+	sess, err := s.GetSession("s1")
+	require.NoError(t, err)
+	assert.Equal(t, normalizeWorkingDirPath(real), sess.WorkingDir, "stored resolved")
+
+	for _, dir := range []string{real, link, filepath.Join(link, "sub", "..")} {
+		hits, err := s.SearchMessages(SearchQuery{Text: "tests", WorkingDir: dir})
+		require.NoError(t, err)
+		assert.NotEmpty(t, hits, dir)
+	}
+
+	t.Chdir(real)
+	hits, err := s.SearchMessages(SearchQuery{Text: "tests", WorkingDir: "."})
+	require.NoError(t, err)
+	assert.NotEmpty(t, hits, "relative to the current directory")
+}
+
+func TestSearchMessagesQuotesPunctuation(t *testing.T) {
+	s := newUsageStore(t)
+	seedSearchSession(t, s, "s1", "/w", "m", "why does user-service crash: nil map", "ok")
+
+	hits, err := s.SearchMessages(SearchQuery{Text: `user-service crash: "nil`})
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	_, err = s.SearchMessages(SearchQuery{Text: "  "})
+	assert.Error(t, err)
+}
+
+func TestSearchIndexFollowsForkAndDelete(t *testing.T) {
+	s := newUsageStore(t)
+	seedSearchSession(t, s, "s1", "/w", "m", "flaky migration", "deadlock")
+
+	require.NoError(t, s.ForkSession("s1", "fork"))
+	hits, err := s.SearchMessages(SearchQuery{Text: "deadlock", Tool: "shell"})
+	require.NoError(t, err)
+	assert.Len(t, hits, 2)
+
+	require.NoError(t, s.DeleteSession("fork"))
+	hits, err = s.SearchMessages(SearchQuery{Text: "deadlock"})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "s1", hits[0].SessionID)
+}
+
+func TestNewStoreIndexesExistingMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rubichan.db")
+	s, err := NewStore(path)
+	require.NoError(t, err)
+	seedSearchSession(t, s, "s1", "/w", "m", "flaky migration", "deadlock")
+	// Simulate a database from before the index existed.
+	_, err = s.db.Exec(`DELETE FROM messages_fts`)
+	require.NoError(t, err)
+	_, err = s.db.Exec(`DELETE FROM message_tools`)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = NewStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+	hits, err := s.SearchMessages(SearchQuery{Text: "deadlock", Tool: "shell"})
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+}
+
+func TestNewStoreIndexesInBatches(t *testing.T) {
+	orig := indexBatchSize
+	indexBatchSize = 2
+	t.Cleanup(func() { indexBatchSize = orig })
+
+	path := filepath.Join(t.TempDir(), "rubichan.db")
+	s, err := NewStore(path)
+	require.NoError(t, err)
+	seedSearchSession(t, s, "s1", "/w", "m", "flaky migration", "deadlock")
+	seedSearchSession(t, s, "s2", "/w", "m", "slow build", "timeout")
+	// Leave s1's tool call half indexed: its tools are recorded, its text
+	// is not.
+	_, err = s.db.Exec(`DELETE FROM messages_fts`)
+	require.NoError(t, err)
+	_, err = s.db.Exec(`DELETE FROM message_tools WHERE message_id NOT IN
+		(SELECT id FROM messages WHERE session_id = 's1' AND seq = 1)`)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = NewStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	for _, text := range []string{"deadlock", "timeout", "flaky", "slow"} {
+		hits, err := s.SearchMessages(SearchQuery{Text: text})
+		require.NoError(t, err)
+		assert.Len(t, hits, 1, text)
+	}
+	// The results sit in a later batch than the calls they answer.
+	hits, err := s.SearchMessages(SearchQuery{Text: "deadlock", Tool: "shell"})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, []string{"shell"}, hits[0].Tools)
+
+	var calls int
+	require.NoError(t, s.db.QueryRow(`SELECT COUNT(*) FROM message_tools WHERE tool_use_id != ''`).Scan(&calls))
+	assert.Equal(t, 2, calls, "the half indexed call is not recorded twice")
+}
+
+func TestForkSessionAtTurn(t *testing.T) {
+	s := newUsageStore(t)
+	seedSearchSession(t, s, "s1", "/w", "m", "first prompt", "ok")
+	require.NoError(t, s.AppendMessage("s1", "user", []provider.ContentBlock{{Type: "text", Text: "second prompt"}}))
+	require.NoError(t, s.AppendMessage("s1", "assistant", []provider.ContentBlock{{Type: "text", Text: "second answer"}}))
+
+	// Forking at the tool call keeps the rest of that turn.
+	require.NoError(t, s.ForkSessionAtTurn("s1", "fork", 1))
+	msgs, err := s.GetMessages("fork")
+	require.NoError(t, err)
+	require.Len(t, msgs, 4)
+	assert.Equal(t, "Done.", msgs[3].Content[0].Text)
+
+	fork, err := s.GetSession("fork")
+	require.NoError(t, err)
+	assert.Equal(t, "s1", fork.ForkedFrom)
+
+	assert.Error(t, s.ForkSessionAtTurn("s1", "bad", 99))
+}
@@ -1,5 +1,9 @@
 // Package store provides SQLite-backed persistence for skill permission
-// approvals, skill install state, and registry cache entries.
+// approvals, skill install state, registry cache entries, sessions, the
+// provider usage ledger, the model's todo lists, and the interactive TUI's
+// open tabs. Stored
+// messages are full-text indexed for SearchMessages, and a session can be
+// exported to and imported from a Bundle.
 package store
 
 import (
@@ -11,6 +15,7 @@ import (
 	"time"
 
 	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
 	_ "modernc.org/sqlite"
 )
 
@@ -126,7 +131,12 @@ func NewStore(dbPath string) (*Store, error) {
 		return nil, fmt.Errorf("create tables: %w", err)
 	}
 
-	return &Store{db: db}, nil
+	s := &Store{db: db}
+	if err := s.indexUnindexedMessages(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("index messages: %w", err)
+	}
+	return s, nil
 }
 
 // Close closes the underlying database connection.
@@ -210,6 +220,60 @@ func createTables(db *sql.DB) error {
 			approved_at  DATETIME NOT NULL DEFAULT (datetime('now')),
 			PRIMARY KEY (project_path, hook_hash)
 		)`,
+		`CREATE TABLE IF NOT EXISTS usage_records (
+			id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id         TEXT NOT NULL,
+			project            TEXT NOT NULL DEFAULT '',
+			model              TEXT NOT NULL,
+			input_tokens       INTEGER NOT NULL DEFAULT 0,
+			output_tokens      INTEGER NOT NULL DEFAULT 0,
+			cache_read_tokens  INTEGER NOT NULL DEFAULT 0,
+			cache_write_tokens INTEGER NOT NULL DEFAULT 0,
+			reasoning_tokens   INTEGER NOT NULL DEFAULT 0,
+			cost_usd           REAL NOT NULL DEFAULT 0,
+			created_at         DATETIME NOT NULL DEFAULT (datetime('now'))
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_usage_session ON usage_records(session_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_usage_project ON usage_records(project, created_at)`,
+		`CREATE TABLE IF NOT EXISTS tui_tabs (
+			project    TEXT NOT NULL,
+			position   INTEGER NOT NULL,
+			name       TEXT NOT NULL DEFAULT '',
+			session_id TEXT NOT NULL DEFAULT '',
+			worktree   TEXT NOT NULL DEFAULT '',
+			active     INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (project, position)
+		)`,
+		// messages_fts indexes the searchable text of each message under the
+		// message's id; see search.go.
+		`CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(body, tokenize = 'porter unicode61')`,
+		`CREATE TRIGGER IF NOT EXISTS messages_fts_delete AFTER DELETE ON messages BEGIN
+			DELETE FROM messages_fts WHERE rowid = old.id;
+		END`,
+		`CREATE TABLE IF NOT EXISTS message_tools (
+			message_id  INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+			tool_use_id TEXT NOT NULL DEFAULT '',
+			tool_name   TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_tools_message ON message_tools(message_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_tools_use ON message_tools(tool_use_id)`,
+		`CREATE TABLE IF NOT EXISTS session_checkpoints (
+			id         TEXT PRIMARY KEY,
+			session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+			file_path  TEXT NOT NULL,
+			turn       INTEGER NOT NULL,
+			operation  TEXT NOT NULL,
+			existed    INTEGER NOT NULL,
+			file_mode  INTEGER NOT NULL DEFAULT 0,
+			data       BLOB,
+			created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_checkpoints_session ON session_checkpoints(session_id)`,
+		`CREATE TABLE IF NOT EXISTS session_todos (
+			session_id TEXT PRIMARY KEY REFERENCES sessions(id) ON DELETE CASCADE,
+			items      TEXT NOT NULL,
+			updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
+		)`,
 	}
 	for _, stmt := range stmts {
 		if _, err := db.Exec(stmt); err != nil {
@@ -462,8 +526,12 @@ func (s *Store) CacheRegistryEntry(entry RegistryEntry) error {
 	return nil
 }
 
-// CreateSession inserts a new session. The ID must be unique.
+// CreateSession inserts a new session. The ID must be unique. The working
+// directory is stored normalized, so searches by directory find it.
 func (s *Store) CreateSession(sess Session) error {
+	if sess.WorkingDir != "" {
+		sess.WorkingDir = normalizeWorkingDirPath(sess.WorkingDir)
+	}
 	_, err := s.db.Exec(
 		`INSERT INTO sessions (id, title, model, working_dir, system_prompt, token_count, forked_from, created_at, updated_at)
 		 VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
@@ -564,6 +632,13 @@ func (s *Store) DeleteEmptySessions(olderThan time.Time) (int64, error) {
 
 // ForkSession creates a new session by deep-copying all data from the source.
 func (s *Store) ForkSession(sourceID, newID string) error {
+	return s.forkSession(sourceID, newID, -1)
+}
+
+// forkSession copies the source session's messages through throughSeq, or
+// all of them when throughSeq is negative. A partial copy leaves out the
+// compaction snapshot, which summarizes the whole source conversation.
+func (s *Store) forkSession(sourceID, newID string, throughSeq int) error {
 	src, err := s.GetSession(sourceID)
 	if err != nil {
 		return fmt.Errorf("fork: get source: %w", err)
@@ -588,24 +663,38 @@ func (s *Store) ForkSession(sourceID, newID string) error {
 		return fmt.Errorf("fork: create session: %w", err)
 	}
 
-	// 2. Bulk copy messages
+	// 2. Bulk copy messages, and their search index entries
 	_, err = tx.Exec(
 		`INSERT INTO messages (session_id, seq, role, content, created_at)
-		 SELECT ?, seq, role, content, created_at FROM messages WHERE session_id = ? ORDER BY seq`,
-		newID, sourceID,
+		 SELECT ?, seq, role, content, created_at FROM messages
+		 WHERE session_id = ? AND (? < 0 OR seq <= ?) ORDER BY seq`,
+		newID, sourceID, throughSeq, throughSeq,
 	)
 	if err != nil {
 		return fmt.Errorf("fork: copy messages: %w", err)
 	}
+	if err := copyMessageIndex(tx, sourceID, newID); err != nil {
+		return fmt.Errorf("fork: %w", err)
+	}
 
 	// 3. Copy snapshot if exists
-	_, err = tx.Exec(
-		`INSERT OR IGNORE INTO session_snapshots (session_id, messages, token_count, created_at)
-		 SELECT ?, messages, token_count, created_at FROM session_snapshots WHERE session_id = ?`,
-		newID, sourceID,
-	)
-	if err != nil {
-		return fmt.Errorf("fork: copy snapshot: %w", err)
+	if throughSeq < 0 {
+		_, err = tx.Exec(
+			`INSERT OR IGNORE INTO session_snapshots (session_id, messages, token_count, created_at)
+			 SELECT ?, messages, token_count, created_at FROM session_snapshots WHERE session_id = ?`,
+			newID, sourceID,
+		)
+		if err != nil {
+			return fmt.Errorf("fork: copy snapshot: %w", err)
+		}
+		_, err = tx.Exec(
+			`INSERT OR IGNORE INTO session_todos (session_id, items, updated_at)
+			 SELECT ?, items, updated_at FROM session_todos WHERE session_id = ?`,
+			newID, sourceID,
+		)
+		if err != nil {
+			return fmt.Errorf("fork: copy todos: %w", err)
+		}
 	}
 
 	// 4. Blobs: message content references blobs by their primary key (id).
@@ -675,10 +764,13 @@ func (s *Store) GetCachedRegistry(name string) (*RegistryEntry, error) {
 
 // AppendMessage adds a message to a session, auto-incrementing the sequence number.
 // The content blocks are serialized to JSON for storage.
+// Image and document blocks that reference a file on disk, including those
+// attached to tool results, are inlined as base64 first, so a resumed session
+// does not depend on a screenshot that lived in a temp directory.
 // On the first user message, if the session title is empty, it auto-generates a
 // title from the first 50 characters of the message text.
 func (s *Store) AppendMessage(sessionID, role string, content []provider.ContentBlock) error {
-	contentJSON, err := json.Marshal(content)
+	contentJSON, err := json.Marshal(inlineMediaSources(content))
 	if err != nil {
 		return fmt.Errorf("marshal content: %w", err)
 	}
@@ -687,7 +779,7 @@ func (s *Store) AppendMessage(sessionID, role string, content []provider.Content
 	// because MaxOpenConns(1) serializes all database access, preventing
 	// concurrent callers from computing the same MAX(seq). Do not increase
 	// MaxOpenConns without wrapping this in an explicit transaction.
-	_, err = s.db.Exec(
+	res, err := s.db.Exec(
 		`INSERT INTO messages (session_id, seq, role, content, created_at)
 		 VALUES (?, COALESCE((SELECT MAX(seq) FROM messages WHERE session_id = ?), -1) + 1, ?, ?, datetime('now'))`,
 		sessionID, sessionID, role, string(contentJSON),
@@ -696,6 +788,11 @@ func (s *Store) AppendMessage(sessionID, role string, content []provider.Content
 		return fmt.Errorf("append message: %w", err)
 	}
 
+	// Indexing is best-effort: NewStore indexes any message missed here.
+	if id, err := res.LastInsertId(); err == nil {
+		_ = s.indexMessage(id, sessionID, content)
+	}
+
 	// Auto-title: on first user message, set session title if still empty.
 	if role == "user" {
 		s.autoTitleSession(sessionID, content)
@@ -704,6 +801,46 @@ func (s *Store) AppendMessage(sessionID, role string, content []provider.Content
 	return nil
 }
 
+// inlineMediaSources returns content with file-backed media sources replaced
+// by their base64 form. Unreadable files keep their path reference: the
+// transformers already degrade those to a placeholder, and failing the
+// append would lose the rest of the message.
+func inlineMediaSources(content []provider.ContentBlock) []provider.ContentBlock {
+	out, _ := inlineMediaBlocks(content)
+	return out
+}
+
+// inlineMediaBlocks does the work of inlineMediaSources and reports whether
+// anything changed. content is never modified; a copy is made on first change.
+func inlineMediaBlocks(content []provider.ContentBlock) ([]provider.ContentBlock, bool) {
+	var out []provider.ContentBlock
+	for i, block := range content {
+		changed := false
+		if nested, ok := inlineMediaBlocks(block.Content); ok {
+			block.Content = nested
+			changed = true
+		}
+		if agentsdk.IsMediaBlock(block) && block.Source != nil && block.Source.Type == agentsdk.MediaSourceFile {
+			if src, err := agentsdk.ResolveMediaSource(block.Source); err == nil {
+				block.Source = src
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = make([]provider.ContentBlock, len(content))
+			copy(out, content)
+		}
+		out[i] = block
+	}
+	if out == nil {
+		return content, false
+	}
+	return out, true
+}
+
 // autoTitleSession sets the session title from the first user message text,
 // but only if the title is currently empty. Errors are silently ignored
 // because titling is best-effort and should not block message persistence.
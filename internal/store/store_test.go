@@ -3,11 +3,13 @@ package store
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -578,6 +580,69 @@ func TestAppendMessageWithMalformedToolInputStillPersists(t *testing.T) {
 	assert.Equal(t, json.RawMessage(`"{\"path\":\"broken\""`), msgs[0].Content[0].Input)
 }
 
+func TestAppendMessagePersistsMediaBlocks(t *testing.T) {
+	s, err := NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.CreateSession(Session{ID: "media", Model: "m"}))
+
+	path := filepath.Join(t.TempDir(), "shot.png")
+	require.NoError(t, os.WriteFile(path, []byte("png-bytes"), 0o644))
+	fileImg, err := agentsdk.NewMediaFileBlock(path)
+	require.NoError(t, err)
+	inline := agentsdk.NewDocumentBlock("application/pdf", []byte("%PDF-1.7"))
+
+	require.NoError(t, s.AppendMessage("media", "user", []provider.ContentBlock{
+		{Type: "text", Text: "see attached"},
+		fileImg,
+		inline,
+	}))
+
+	msgs, err := s.GetMessages("media")
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Len(t, msgs[0].Content, 3)
+
+	img := msgs[0].Content[1]
+	assert.Equal(t, agentsdk.BlockTypeImage, img.Type)
+	assert.Equal(t, "shot.png", img.Name)
+	require.NotNil(t, img.Source)
+	assert.Equal(t, agentsdk.MediaSourceBase64, img.Source.Type, "file sources are inlined on append")
+	assert.Equal(t, "image/png", img.Source.MediaType)
+	assert.Equal(t, "cG5nLWJ5dGVz", img.Source.Data)
+	assert.Equal(t, inline, msgs[0].Content[2])
+
+	// The caller's slice is not mutated.
+	assert.Equal(t, agentsdk.MediaSourceFile, fileImg.Source.Type)
+}
+
+func TestAppendMessageInlinesToolResultMedia(t *testing.T) {
+	s, err := NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.CreateSession(Session{ID: "tool-media", Model: "m"}))
+
+	path := filepath.Join(t.TempDir(), "shot.png")
+	require.NoError(t, os.WriteFile(path, []byte("png-bytes"), 0o644))
+	fileImg, err := agentsdk.NewMediaFileBlock(path)
+	require.NoError(t, err)
+	msg := provider.NewToolResultMessage("t1", "saved", false, fileImg)
+
+	require.NoError(t, s.AppendMessage("tool-media", "user", msg.Content))
+
+	msgs, err := s.GetMessages("tool-media")
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	result := msgs[0].Content[0]
+	assert.Equal(t, "saved", result.Text)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, agentsdk.MediaSourceBase64, result.Content[0].Source.Type)
+	assert.Equal(t, "cG5nLWJ5dGVz", result.Content[0].Source.Data)
+	assert.Equal(t, agentsdk.MediaSourceFile, msg.Content[0].Content[0].Source.Type)
+}
+
 func TestStoreOperationsAfterClose(t *testing.T) {
 	s, err := NewStore(":memory:")
 	require.NoError(t, err)
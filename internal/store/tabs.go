@@ -0,0 +1,64 @@
+package store
+
+import "fmt"
+
+// Tab is one open tab of the interactive TUI. Tabs are not tied to the
+// sessions table by a foreign key: a tab whose session is gone reopens
+// with a fresh session.
+type Tab struct {
+	Name      string
+	SessionID string
+	Worktree  string // worktree the tab runs in; empty for the project dir
+	Active    bool
+}
+
+// SaveTabs replaces the tabs recorded for a project with tabs, in order.
+func (s *Store) SaveTabs(project string, tabs []Tab) error {
+	project = normalizeWorkingDirPath(project)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("save tabs: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.Exec(`DELETE FROM tui_tabs WHERE project = ?`, project); err != nil {
+		return fmt.Errorf("save tabs: %w", err)
+	}
+	for i, t := range tabs {
+		_, err := tx.Exec(
+			`INSERT INTO tui_tabs (project, position, name, session_id, worktree, active)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			project, i, t.Name, t.SessionID, t.Worktree, t.Active,
+		)
+		if err != nil {
+			return fmt.Errorf("save tabs: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("save tabs: %w", err)
+	}
+	return nil
+}
+
+// LoadTabs returns the tabs recorded for a project, in order.
+func (s *Store) LoadTabs(project string) ([]Tab, error) {
+	rows, err := s.db.Query(
+		`SELECT name, session_id, worktree, active FROM tui_tabs
+		 WHERE project = ? ORDER BY position`,
+		normalizeWorkingDirPath(project),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load tabs: %w", err)
+	}
+	defer rows.Close()
+
+	var tabs []Tab
+	for rows.Next() {
+		var t Tab
+		if err := rows.Scan(&t.Name, &t.SessionID, &t.Worktree, &t.Active); err != nil {
+			return nil, fmt.Errorf("scan tab: %w", err)
+		}
+		tabs = append(tabs, t)
+	}
+	return tabs, rows.Err()
+}
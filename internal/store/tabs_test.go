@@ -0,0 +1,35 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoadTabs(t *testing.T) {
+	s := newUsageStore(t)
+	project := t.TempDir()
+	other := t.TempDir()
+
+	tabs := []Tab{
+		{Name: "main", SessionID: "s1"},
+		{Name: "spike", SessionID: "s2", Worktree: "spike", Active: true},
+	}
+	require.NoError(t, s.SaveTabs(project, tabs))
+	require.NoError(t, s.SaveTabs(other, []Tab{{Name: "x"}}))
+
+	got, err := s.LoadTabs(project)
+	require.NoError(t, err)
+	assert.Equal(t, tabs, got)
+
+	// Saving again replaces the previous set.
+	require.NoError(t, s.SaveTabs(project, tabs[:1]))
+	got, err = s.LoadTabs(project)
+	require.NoError(t, err)
+	assert.Equal(t, tabs[:1], got)
+
+	got, err = s.LoadTabs(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// SaveTodos replaces the todo list the model keeps for a session.
+func (s *Store) SaveTodos(sessionID string, items []agentsdk.PlanEntry) error {
+	if items == nil {
+		items = []agentsdk.PlanEntry{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("save todos: %w", err)
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO session_todos (session_id, items, updated_at) VALUES (?, ?, datetime('now'))
+		 ON CONFLICT(session_id) DO UPDATE SET items = excluded.items, updated_at = excluded.updated_at`,
+		sessionID, string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("save todos: %w", err)
+	}
+	return nil
+}
+
+// GetTodos returns a session's todo list, or nil if it has none.
+func (s *Store) GetTodos(sessionID string) ([]agentsdk.PlanEntry, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT items FROM session_todos WHERE session_id = ?`, sessionID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get todos: %w", err)
+	}
+	var items []agentsdk.PlanEntry
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		return nil, fmt.Errorf("get todos: decode: %w", err)
+	}
+	return items, nil
+}
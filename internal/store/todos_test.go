@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+func TestTodosSaveGetAndFork(t *testing.T) {
+	s := newUsageStore(t)
+	require.NoError(t, s.CreateSession(Session{ID: "s1", Model: "m", WorkingDir: "/w"}))
+
+	items, err := s.GetTodos("s1")
+	require.NoError(t, err)
+	assert.Nil(t, items)
+
+	require.NoError(t, s.SaveTodos("s1", []agentsdk.PlanEntry{{Content: "write tests", Status: agentsdk.PlanEntryPending}}))
+	want := []agentsdk.PlanEntry{
+		{Content: "write tests", Status: agentsdk.PlanEntryCompleted},
+		{Content: "fix bug", Status: agentsdk.PlanEntryInProgress},
+	}
+	require.NoError(t, s.SaveTodos("s1", want))
+	items, err = s.GetTodos("s1")
+	require.NoError(t, err)
+	assert.Equal(t, want, items)
+
+	require.NoError(t, s.ForkSession("s1", "fork"))
+	items, err = s.GetTodos("fork")
+	require.NoError(t, err)
+	assert.Equal(t, want, items)
+
+	require.NoError(t, s.SaveTodos("s1", nil))
+	items, err = s.GetTodos("s1")
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	require.NoError(t, s.DeleteSession("fork"))
+	items, err = s.GetTodos("fork")
+	require.NoError(t, err)
+	assert.Nil(t, items)
+}
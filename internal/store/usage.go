@@ -0,0 +1,168 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// UsageRecord is the token usage and priced cost of one provider call.
+// Records are deliberately not tied to the sessions table by a foreign key:
+// deleting a session must not erase what it cost.
+type UsageRecord struct {
+	SessionID        string
+	Project          string // working directory the session ran in
+	Model            string
+	InputTokens      int
+	OutputTokens     int
+	CacheReadTokens  int
+	CacheWriteTokens int
+	ReasoningTokens  int
+	CostUSD          float64
+	CreatedAt        time.Time
+}
+
+// UsageGroup selects the key a usage report aggregates by.
+type UsageGroup string
+
+// Supported usage report groupings.
+const (
+	UsageByDay     UsageGroup = "day"
+	UsageByModel   UsageGroup = "model"
+	UsageByProject UsageGroup = "project"
+	UsageBySession UsageGroup = "session"
+)
+
+// UsageQuery filters and groups a usage report. Zero Since/Until leave that
+// end of the range open; an empty Project reports across all projects.
+type UsageQuery struct {
+	GroupBy UsageGroup
+	Since   time.Time
+	Until   time.Time
+	Project string
+}
+
+// UsageSummary is one row of a usage report.
+type UsageSummary struct {
+	Key              string // day (YYYY-MM-DD, UTC), model, project or session ID
+	Calls            int
+	InputTokens      int
+	OutputTokens     int
+	CacheReadTokens  int
+	CacheWriteTokens int
+	ReasoningTokens  int
+	CostUSD          float64
+}
+
+// usageTimeFormat matches SQLite's datetime() output so string comparison on
+// created_at orders chronologically.
+const usageTimeFormat = "2006-01-02 15:04:05"
+
+// RecordUsage appends one provider call to the usage ledger. A zero
+// CreatedAt is recorded as now.
+func (s *Store) RecordUsage(rec UsageRecord) error {
+	createdAt := rec.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	project := rec.Project
+	if project != "" {
+		project = normalizeWorkingDirPath(project)
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO usage_records
+		 (session_id, project, model, input_tokens, output_tokens, cache_read_tokens,
+		  cache_write_tokens, reasoning_tokens, cost_usd, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.SessionID, project, rec.Model, rec.InputTokens, rec.OutputTokens, rec.CacheReadTokens,
+		rec.CacheWriteTokens, rec.ReasoningTokens, rec.CostUSD, createdAt.UTC().Format(usageTimeFormat),
+	)
+	if err != nil {
+		return fmt.Errorf("record usage: %w", err)
+	}
+	return nil
+}
+
+// SessionCost returns the total recorded cost of a session.
+func (s *Store) SessionCost(sessionID string) (float64, error) {
+	var cost float64
+	err := s.db.QueryRow(
+		`SELECT COALESCE(SUM(cost_usd), 0) FROM usage_records WHERE session_id = ?`,
+		sessionID,
+	).Scan(&cost)
+	if err != nil {
+		return 0, fmt.Errorf("query session cost: %w", err)
+	}
+	return cost, nil
+}
+
+// ProjectCost returns the total recorded cost for a working directory since
+// the given time.
+func (s *Store) ProjectCost(project string, since time.Time) (float64, error) {
+	var cost float64
+	err := s.db.QueryRow(
+		`SELECT COALESCE(SUM(cost_usd), 0) FROM usage_records
+		 WHERE project = ? AND created_at >= ?`,
+		normalizeWorkingDirPath(project), since.UTC().Format(usageTimeFormat),
+	).Scan(&cost)
+	if err != nil {
+		return 0, fmt.Errorf("query project cost: %w", err)
+	}
+	return cost, nil
+}
+
+// UsageReport aggregates recorded usage by q.GroupBy. Days are ordered
+// oldest first; other groupings are ordered by cost, highest first.
+func (s *Store) UsageReport(q UsageQuery) ([]UsageSummary, error) {
+	var keyExpr, orderBy string
+	switch q.GroupBy {
+	case UsageByDay:
+		keyExpr, orderBy = "date(created_at)", "key ASC"
+	case UsageByModel:
+		keyExpr, orderBy = "model", "cost DESC, key ASC"
+	case UsageByProject:
+		keyExpr, orderBy = "project", "cost DESC, key ASC"
+	case UsageBySession:
+		keyExpr, orderBy = "session_id", "cost DESC, key ASC"
+	default:
+		return nil, fmt.Errorf("unknown usage grouping %q", q.GroupBy)
+	}
+
+	where := "1 = 1"
+	var args []any
+	if !q.Since.IsZero() {
+		where += " AND created_at >= ?"
+		args = append(args, q.Since.UTC().Format(usageTimeFormat))
+	}
+	if !q.Until.IsZero() {
+		where += " AND created_at < ?"
+		args = append(args, q.Until.UTC().Format(usageTimeFormat))
+	}
+	if q.Project != "" {
+		where += " AND project = ?"
+		args = append(args, normalizeWorkingDirPath(q.Project))
+	}
+
+	rows, err := s.db.Query(
+		`SELECT `+keyExpr+` AS key, COUNT(*), SUM(input_tokens), SUM(output_tokens),
+		        SUM(cache_read_tokens), SUM(cache_write_tokens), SUM(reasoning_tokens),
+		        SUM(cost_usd) AS cost
+		 FROM usage_records WHERE `+where+`
+		 GROUP BY key ORDER BY `+orderBy,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query usage report: %w", err)
+	}
+	defer rows.Close()
+
+	var out []UsageSummary
+	for rows.Next() {
+		var u UsageSummary
+		if err := rows.Scan(&u.Key, &u.Calls, &u.InputTokens, &u.OutputTokens,
+			&u.CacheReadTokens, &u.CacheWriteTokens, &u.ReasoningTokens, &u.CostUSD); err != nil {
+			return nil, fmt.Errorf("scan usage report: %w", err)
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
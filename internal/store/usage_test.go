@@ -0,0 +1,129 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUsageStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := NewStore(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestRecordUsageAndSessionCost(t *testing.T) {
+	s := newUsageStore(t)
+	project := t.TempDir()
+
+	require.NoError(t, s.RecordUsage(UsageRecord{SessionID: "s1", Project: project, Model: "gpt-4o", InputTokens: 100, OutputTokens: 50, CostUSD: 0.25}))
+	require.NoError(t, s.RecordUsage(UsageRecord{SessionID: "s1", Project: project, Model: "gpt-4o", InputTokens: 10, OutputTokens: 5, CostUSD: 0.5}))
+	require.NoError(t, s.RecordUsage(UsageRecord{SessionID: "s2", Project: project, Model: "gpt-4o", CostUSD: 1}))
+
+	cost, err := s.SessionCost("s1")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, cost, 1e-9)
+
+	cost, err = s.SessionCost("missing")
+	require.NoError(t, err)
+	assert.Zero(t, cost)
+}
+
+func TestUsageSurvivesSessionDelete(t *testing.T) {
+	s := newUsageStore(t)
+	require.NoError(t, s.CreateSession(Session{ID: "s1", Model: "gpt-4o"}))
+	require.NoError(t, s.RecordUsage(UsageRecord{SessionID: "s1", Model: "gpt-4o", CostUSD: 2}))
+	require.NoError(t, s.DeleteSession("s1"))
+
+	cost, err := s.SessionCost("s1")
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, cost, 1e-9)
+}
+
+func TestProjectCostSince(t *testing.T) {
+	s := newUsageStore(t)
+	project := t.TempDir()
+	other := t.TempDir()
+	monthStart := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.RecordUsage(UsageRecord{SessionID: "a", Project: project, Model: "m", CostUSD: 5, CreatedAt: monthStart.Add(-time.Hour)}))
+	require.NoError(t, s.RecordUsage(UsageRecord{SessionID: "a", Project: project, Model: "m", CostUSD: 1, CreatedAt: monthStart.Add(time.Hour)}))
+	require.NoError(t, s.RecordUsage(UsageRecord{SessionID: "b", Project: project, Model: "m", CostUSD: 2, CreatedAt: monthStart.Add(48 * time.Hour)}))
+	require.NoError(t, s.RecordUsage(UsageRecord{SessionID: "c", Project: other, Model: "m", CostUSD: 7, CreatedAt: monthStart.Add(time.Hour)}))
+
+	cost, err := s.ProjectCost(project, monthStart)
+	require.NoError(t, err)
+	assert.InDelta(t, 3.0, cost, 1e-9)
+}
+
+func TestUsageReportGroupings(t *testing.T) {
+	s := newUsageStore(t)
+	projA := t.TempDir()
+	projB := t.TempDir()
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	records := []UsageRecord{
+		{SessionID: "s1", Project: projA, Model: "claude-sonnet-4-5", InputTokens: 100, OutputTokens: 10, CacheReadTokens: 1000, CostUSD: 1.0, CreatedAt: day1},
+		{SessionID: "s1", Project: projA, Model: "gpt-4o", InputTokens: 200, OutputTokens: 20, ReasoningTokens: 5, CostUSD: 0.5, CreatedAt: day1},
+		{SessionID: "s2", Project: projB, Model: "claude-sonnet-4-5", InputTokens: 300, OutputTokens: 30, CacheWriteTokens: 50, CostUSD: 3.0, CreatedAt: day2},
+	}
+	for _, r := range records {
+		require.NoError(t, s.RecordUsage(r))
+	}
+
+	byDay, err := s.UsageReport(UsageQuery{GroupBy: UsageByDay})
+	require.NoError(t, err)
+	require.Len(t, byDay, 2)
+	assert.Equal(t, "2026-03-01", byDay[0].Key)
+	assert.Equal(t, 2, byDay[0].Calls)
+	assert.Equal(t, 300, byDay[0].InputTokens)
+	assert.Equal(t, 1000, byDay[0].CacheReadTokens)
+	assert.Equal(t, 5, byDay[0].ReasoningTokens)
+	assert.InDelta(t, 1.5, byDay[0].CostUSD, 1e-9)
+	assert.Equal(t, "2026-03-02", byDay[1].Key)
+
+	byModel, err := s.UsageReport(UsageQuery{GroupBy: UsageByModel})
+	require.NoError(t, err)
+	require.Len(t, byModel, 2)
+	assert.Equal(t, "claude-sonnet-4-5", byModel[0].Key, "highest cost first")
+	assert.InDelta(t, 4.0, byModel[0].CostUSD, 1e-9)
+	assert.Equal(t, 50, byModel[0].CacheWriteTokens)
+
+	byProject, err := s.UsageReport(UsageQuery{GroupBy: UsageByProject})
+	require.NoError(t, err)
+	require.Len(t, byProject, 2)
+	assert.Equal(t, normalizeWorkingDirPath(projB), byProject[0].Key)
+
+	bySession, err := s.UsageReport(UsageQuery{GroupBy: UsageBySession, Project: projA})
+	require.NoError(t, err)
+	require.Len(t, bySession, 1)
+	assert.Equal(t, "s1", bySession[0].Key)
+
+	ranged, err := s.UsageReport(UsageQuery{GroupBy: UsageByModel, Since: day2})
+	require.NoError(t, err)
+	require.Len(t, ranged, 1)
+	assert.InDelta(t, 3.0, ranged[0].CostUSD, 1e-9)
+
+	ranged, err = s.UsageReport(UsageQuery{GroupBy: UsageByModel, Until: day2})
+	require.NoError(t, err)
+	assert.Len(t, ranged, 2)
+}
+
+func TestUsageReportUnknownGrouping(t *testing.T) {
+	s := newUsageStore(t)
+	_, err := s.UsageReport(UsageQuery{GroupBy: "week"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown usage grouping")
+}
+
+func TestUsageReportEmpty(t *testing.T) {
+	s := newUsageStore(t)
+	rows, err := s.UsageReport(UsageQuery{GroupBy: UsageByDay})
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
@@ -51,6 +51,8 @@ var builtinExact = map[string]Category{
 	"tool_search":     CategoryAgent,
 	"task":            CategoryAgent,
 	"list_tasks":      CategoryAgent,
+	"ask_user":        CategoryAgent,
+	"todo":            CategoryAgent,
 }
 
 // builtinPrefixes maps tool name prefixes to categories.
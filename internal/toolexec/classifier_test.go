@@ -23,6 +23,7 @@ func TestClassifyBuiltinTools(t *testing.T) {
 		{"compact_context", toolexec.CategoryAgent},
 		{"read_result", toolexec.CategoryAgent},
 		{"notes", toolexec.CategoryAgent},
+		{"todo", toolexec.CategoryAgent},
 		{"tool_search", toolexec.CategoryAgent},
 		{"task", toolexec.CategoryAgent},
 		{"list_tasks", toolexec.CategoryAgent},
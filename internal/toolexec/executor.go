@@ -46,6 +46,7 @@ func RegistryExecutor(lookup ToolLookup) HandlerFunc {
 			Content:        out.Content,
 			DisplayContent: out.DisplayContent,
 			IsError:        out.IsError,
+			Media:          out.Media,
 		}
 	}
 }
@@ -0,0 +1,287 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// AskUserName is the name of the ask_user tool.
+const AskUserName = "ask_user"
+
+// Question kinds accepted by ask_user.
+const (
+	AskSingle = "single" // pick one option
+	AskMulti  = "multi"  // pick any number of options
+	AskText   = "text"   // free-text answer
+)
+
+// Values payloads of the UIResponse answering an ask_user request. A
+// single-choice question is answered with the chosen option as ActionID
+// instead.
+type (
+	// AskTextValues answers a free-text question.
+	AskTextValues struct {
+		Answer string `json:"answer"`
+	}
+	// AskMultiValues answers a multi-choice question.
+	AskMultiValues struct {
+		Answers []string `json:"answers"`
+	}
+)
+
+// AskCancelAction is the ActionID of a response declining to answer.
+const AskCancelAction = "cancel"
+
+// AskUserAnswer is a question ask_user asked and the answer it got.
+type AskUserAnswer struct {
+	RequestID string
+	Question  string
+	Kind      string
+	Answers   []string // empty when the user declined
+	Defaulted bool     // the question timed out and Answers is the default
+}
+
+// AskUserConfig configures the ask_user tool.
+type AskUserConfig struct {
+	// Handler presents questions to the user. Without one the tool fails,
+	// telling the model no user is available.
+	Handler agentsdk.UIRequestHandler
+	// Timeout bounds the wait for an answer; zero waits until the turn is
+	// cancelled.
+	Timeout time.Duration
+	// Default answers a question that times out without a default of its own.
+	Default string
+	// OnAnswer, when set, is called with every answer, for the session
+	// event log.
+	OnAnswer func(AskUserAnswer)
+}
+
+// AskUserTool lets the model ask the user a clarifying question mid-task
+// through the host's UIRequest handler.
+type AskUserTool struct {
+	cfg AskUserConfig
+}
+
+// NewAskUserTool creates an ask_user tool.
+func NewAskUserTool(cfg AskUserConfig) *AskUserTool {
+	return &AskUserTool{cfg: cfg}
+}
+
+func (t *AskUserTool) Name() string { return AskUserName }
+
+func (t *AskUserTool) Description() string {
+	return "Ask the user a clarifying question and wait for the answer. Use it when a decision " +
+		"is the user's to make or the task is ambiguous, instead of guessing. Kinds: single " +
+		"(pick one of options), multi (pick any of options) and text (free-form answer)."
+}
+
+func (t *AskUserTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"question": {
+				"type": "string",
+				"description": "The question to ask, with enough context to answer it"
+			},
+			"kind": {
+				"type": "string",
+				"enum": ["single", "multi", "text"],
+				"description": "single or multi choice among options, or a free-text answer (default text, or single when options are given)"
+			},
+			"options": {
+				"type": "array",
+				"items": {"type": "string"},
+				"description": "The choices for single and multi questions"
+			},
+			"default": {
+				"type": "string",
+				"description": "Answer to use if the user does not answer in time; for choice questions, one of options"
+			}
+		},
+		"required": ["question"]
+	}`)
+}
+
+type askUserInput struct {
+	Question string   `json:"question"`
+	Kind     string   `json:"kind"`
+	Options  []string `json:"options"`
+	Default  string   `json:"default"`
+}
+
+func (t *AskUserTool) Execute(ctx context.Context, input json.RawMessage) (ToolResult, error) {
+	var in askUserInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolResult{Content: fmt.Sprintf("invalid input: %s", err), IsError: true}, nil
+	}
+	in.Question = strings.TrimSpace(in.Question)
+	if in.Question == "" {
+		return ToolResult{Content: "question is required", IsError: true}, nil
+	}
+	if in.Kind == "" {
+		in.Kind = AskText
+		if len(in.Options) > 0 {
+			in.Kind = AskSingle
+		}
+	}
+	switch in.Kind {
+	case AskSingle, AskMulti:
+		if len(in.Options) < 2 {
+			return ToolResult{Content: fmt.Sprintf("%s questions need at least two options", in.Kind), IsError: true}, nil
+		}
+		if in.Default != "" && !containsString(in.Options, in.Default) {
+			return ToolResult{Content: fmt.Sprintf("default %q is not one of the options", in.Default), IsError: true}, nil
+		}
+	case AskText:
+	default:
+		return ToolResult{Content: fmt.Sprintf("unknown kind: %s (use single, multi, or text)", in.Kind), IsError: true}, nil
+	}
+	if t.cfg.Handler == nil {
+		return ToolResult{
+			Content: "No user is available to answer questions in this session. Proceed with your best judgment and state the assumptions you make.",
+			IsError: true,
+		}, nil
+	}
+
+	req := askUserRequest(in, t.cfg.Timeout)
+	askCtx := ctx
+	if t.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		askCtx, cancel = context.WithTimeout(ctx, t.cfg.Timeout)
+		defer cancel()
+	}
+	resp, err := t.cfg.Handler.Request(askCtx, req)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(askCtx.Err(), context.DeadlineExceeded) {
+			return t.timedOut(req.ID, in), nil
+		}
+		return ToolResult{Content: fmt.Sprintf("asking the user failed: %s", err), IsError: true}, nil
+	}
+
+	answers, declined, err := askUserAnswers(in, resp)
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	t.record(AskUserAnswer{RequestID: req.ID, Question: in.Question, Kind: in.Kind, Answers: answers})
+	if declined {
+		return ToolResult{Content: "The user declined to answer."}, nil
+	}
+	return ToolResult{Content: askUserResult(in.Kind, answers)}, nil
+}
+
+// askUserRequest builds the UIRequest for a question: a select request for
+// single choice, and a form for multi choice and free text, whose Values
+// are AskMultiValues and AskTextValues.
+func askUserRequest(in askUserInput, timeout time.Duration) agentsdk.UIRequest {
+	req := agentsdk.UIRequest{
+		ID:             "ask-" + uuid.New().String(),
+		Kind:           agentsdk.UIKindForm,
+		Title:          "Question",
+		Message:        in.Question,
+		TimeoutSeconds: int(timeout / time.Second),
+		Metadata:       map[string]string{"tool": AskUserName, "question_kind": in.Kind},
+	}
+	if in.Default != "" {
+		req.Metadata["default"] = in.Default
+	}
+	for _, opt := range in.Options {
+		req.Actions = append(req.Actions, agentsdk.UIAction{ID: opt, Label: opt, Default: opt == in.Default})
+	}
+	switch in.Kind {
+	case AskSingle:
+		req.Kind = agentsdk.UIKindSelect
+	case AskMulti:
+		items, _ := json.Marshal(in.Options)
+		req.Schema = json.RawMessage(`{"type":"object","properties":{"answers":{"type":"array","items":{"type":"string","enum":` +
+			string(items) + `}}},"required":["answers"]}`)
+	default:
+		req.Schema = json.RawMessage(`{"type":"object","properties":{"answer":{"type":"string"}},"required":["answer"]}`)
+	}
+	return req
+}
+
+// askUserAnswers extracts the answers from a response, checking choices
+// against the options. A cancelled request, or an empty text answer, is
+// declined.
+func askUserAnswers(in askUserInput, resp agentsdk.UIResponse) (answers []string, declined bool, err error) {
+	if resp.ActionID == AskCancelAction && (in.Kind != AskSingle || !containsString(in.Options, resp.ActionID)) {
+		return nil, true, nil
+	}
+	switch in.Kind {
+	case AskSingle:
+		if !containsString(in.Options, resp.ActionID) {
+			return nil, false, fmt.Errorf("the answer %q is not one of the options", resp.ActionID)
+		}
+		return []string{resp.ActionID}, false, nil
+	case AskMulti:
+		var v AskMultiValues
+		if err := json.Unmarshal(resp.Values, &v); err != nil {
+			return nil, false, fmt.Errorf("invalid answer: %s", err)
+		}
+		for _, a := range v.Answers {
+			if !containsString(in.Options, a) {
+				return nil, false, fmt.Errorf("the answer %q is not one of the options", a)
+			}
+		}
+		return v.Answers, false, nil
+	default:
+		var v AskTextValues
+		if err := json.Unmarshal(resp.Values, &v); err != nil {
+			return nil, false, fmt.Errorf("invalid answer: %s", err)
+		}
+		if strings.TrimSpace(v.Answer) == "" {
+			return nil, true, nil
+		}
+		return []string{v.Answer}, false, nil
+	}
+}
+
+// timedOut answers a question nobody answered in time with its default,
+// or the configured one.
+func (t *AskUserTool) timedOut(requestID string, in askUserInput) ToolResult {
+	def := in.Default
+	if def == "" && (in.Kind == AskText || containsString(in.Options, t.cfg.Default)) {
+		def = t.cfg.Default
+	}
+	if def == "" {
+		return ToolResult{
+			Content: fmt.Sprintf("The user did not answer within %s. Proceed with your best judgment and state the assumptions you make.", t.cfg.Timeout),
+			IsError: true,
+		}
+	}
+	t.record(AskUserAnswer{RequestID: requestID, Question: in.Question, Kind: in.Kind, Answers: []string{def}, Defaulted: true})
+	return ToolResult{Content: fmt.Sprintf("The user did not answer within %s; using the default: %s", t.cfg.Timeout, def)}
+}
+
+func (t *AskUserTool) record(a AskUserAnswer) {
+	if t.cfg.OnAnswer != nil {
+		t.cfg.OnAnswer(a)
+	}
+}
+
+func askUserResult(kind string, answers []string) string {
+	if kind == AskMulti {
+		if len(answers) == 0 {
+			return "The user selected none of the options."
+		}
+		return "The user selected: " + strings.Join(answers, ", ")
+	}
+	return "The user answered: " + answers[0]
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
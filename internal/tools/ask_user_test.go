@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// answerWith returns a handler that records the request and answers it.
+func answerWith(got *agentsdk.UIRequest, resp agentsdk.UIResponse) agentsdk.UIRequestHandler {
+	return agentsdk.UIRequestFunc(func(_ context.Context, req agentsdk.UIRequest) (agentsdk.UIResponse, error) {
+		*got = req
+		resp.RequestID = req.ID
+		return resp, nil
+	})
+}
+
+func runAskUser(t *testing.T, cfg AskUserConfig, input string) ToolResult {
+	t.Helper()
+	res, err := NewAskUserTool(cfg).Execute(context.Background(), json.RawMessage(input))
+	require.NoError(t, err)
+	return res
+}
+
+func TestAskUserSingleChoice(t *testing.T) {
+	var req agentsdk.UIRequest
+	var recorded []AskUserAnswer
+	cfg := AskUserConfig{
+		Handler:  answerWith(&req, agentsdk.UIResponse{ActionID: "postgres"}),
+		Timeout:  time.Minute,
+		OnAnswer: func(a AskUserAnswer) { recorded = append(recorded, a) },
+	}
+
+	res := runAskUser(t, cfg, `{"question":"Which database?","options":["postgres","sqlite"],"default":"sqlite"}`)
+	assert.False(t, res.IsError)
+	assert.Equal(t, "The user answered: postgres", res.Content)
+
+	assert.Equal(t, agentsdk.UIKindSelect, req.Kind)
+	assert.Equal(t, "Which database?", req.Message)
+	assert.Equal(t, 60, req.TimeoutSeconds)
+	assert.Equal(t, AskUserName, req.Metadata["tool"])
+	require.Len(t, req.Actions, 2)
+	assert.True(t, req.Actions[1].Default)
+
+	require.Len(t, recorded, 1)
+	assert.Equal(t, req.ID, recorded[0].RequestID)
+	assert.Equal(t, []string{"postgres"}, recorded[0].Answers)
+	assert.False(t, recorded[0].Defaulted)
+}
+
+func TestAskUserMultiChoiceAndText(t *testing.T) {
+	var req agentsdk.UIRequest
+	values, _ := json.Marshal(AskMultiValues{Answers: []string{"lint", "test"}})
+	res := runAskUser(t, AskUserConfig{Handler: answerWith(&req, agentsdk.UIResponse{ActionID: "submit", Values: values})},
+		`{"question":"Which checks?","kind":"multi","options":["lint","test","bench"]}`)
+	assert.Equal(t, "The user selected: lint, test", res.Content)
+	assert.Equal(t, agentsdk.UIKindForm, req.Kind)
+	assert.Contains(t, string(req.Schema), `"enum":["lint","test","bench"]`)
+
+	values, _ = json.Marshal(AskMultiValues{Answers: []string{"deploy"}})
+	res = runAskUser(t, AskUserConfig{Handler: answerWith(&req, agentsdk.UIResponse{Values: values})},
+		`{"question":"Which checks?","kind":"multi","options":["lint","test"]}`)
+	assert.True(t, res.IsError, "answers outside the options are rejected")
+
+	values, _ = json.Marshal(AskTextValues{Answer: "v2 of the API"})
+	res = runAskUser(t, AskUserConfig{Handler: answerWith(&req, agentsdk.UIResponse{ActionID: "submit", Values: values})},
+		`{"question":"Which API version?"}`)
+	assert.Equal(t, "The user answered: v2 of the API", res.Content)
+	assert.Equal(t, "text", req.Metadata["question_kind"])
+
+	res = runAskUser(t, AskUserConfig{Handler: answerWith(&req, agentsdk.UIResponse{ActionID: AskCancelAction})},
+		`{"question":"Which API version?"}`)
+	assert.False(t, res.IsError)
+	assert.Equal(t, "The user declined to answer.", res.Content)
+}
+
+func TestAskUserTimeoutUsesDefault(t *testing.T) {
+	block := agentsdk.UIRequestFunc(func(ctx context.Context, _ agentsdk.UIRequest) (agentsdk.UIResponse, error) {
+		<-ctx.Done()
+		return agentsdk.UIResponse{}, ctx.Err()
+	})
+	var recorded []AskUserAnswer
+	cfg := AskUserConfig{
+		Handler:  block,
+		Timeout:  10 * time.Millisecond,
+		Default:  "go ahead",
+		OnAnswer: func(a AskUserAnswer) { recorded = append(recorded, a) },
+	}
+
+	res := runAskUser(t, cfg, `{"question":"Which database?","options":["postgres","sqlite"],"default":"sqlite"}`)
+	assert.False(t, res.IsError)
+	assert.Contains(t, res.Content, "using the default: sqlite")
+
+	// The configured default answers text questions without one.
+	res = runAskUser(t, cfg, `{"question":"Anything else?"}`)
+	assert.Contains(t, res.Content, "using the default: go ahead")
+	require.Len(t, recorded, 2)
+	assert.True(t, recorded[1].Defaulted)
+
+	// ...but not choice questions it is no option of.
+	res = runAskUser(t, cfg, `{"question":"Which database?","options":["postgres","sqlite"]}`)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content, "did not answer")
+}
+
+func TestAskUserFailsCleanly(t *testing.T) {
+	res := runAskUser(t, AskUserConfig{}, `{"question":"Which database?"}`)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content, "No user is available")
+
+	failing := agentsdk.UIRequestFunc(func(context.Context, agentsdk.UIRequest) (agentsdk.UIResponse, error) {
+		return agentsdk.UIResponse{}, errors.New("no answer for this question")
+	})
+	res = runAskUser(t, AskUserConfig{Handler: failing}, `{"question":"Which database?"}`)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content, "no answer for this question")
+
+	for _, input := range []string{
+		`{"question":""}`,
+		`{"question":"Pick","kind":"single","options":["only"]}`,
+		`{"question":"Pick","options":["a","b"],"default":"c"}`,
+		`{"question":"Pick","kind":"essay"}`,
+	} {
+		res := runAskUser(t, AskUserConfig{Handler: failing}, input)
+		assert.True(t, res.IsError, input)
+	}
+}
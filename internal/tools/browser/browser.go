@@ -17,6 +17,7 @@ import (
 	"github.com/julianshen/rubichan/internal/config"
 	"github.com/julianshen/rubichan/internal/tools"
 	"github.com/julianshen/rubichan/internal/tools/netutil"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
 )
 
 // OpenOptions configures a browser navigation request.
@@ -315,7 +316,13 @@ func (s *Service) Screenshot(ctx context.Context, input json.RawMessage) (tools.
 	if err != nil {
 		return errResult("browser_screenshot failed: %s", err), nil
 	}
-	return tools.ToolResult{Content: fmt.Sprintf("saved screenshot to %s", res.Path)}, nil
+	result := tools.ToolResult{Content: fmt.Sprintf("saved screenshot to %s", res.Path)}
+	// Attach the image so vision models can see the page; the transformers
+	// downgrade it to a placeholder for models that cannot.
+	if img, err := agentsdk.NewMediaFileBlock(res.Path); err == nil {
+		result.Media = []agentsdk.ContentBlock{img}
+	}
+	return result, nil
 }
 
 // Wait blocks until a selector is visible, text appears, or a timeout elapses.
@@ -505,7 +512,7 @@ func NewTools(service *Service) []tools.Tool {
 		},
 		&tool{
 			name:        "browser_screenshot",
-			description: "Save a screenshot of the current page or a matching element.",
+			description: "Save a screenshot of the current page or a matching element and return the image.",
 			schema:      json.RawMessage(`{"type":"object","properties":{"session_id":{"type":"string"},"selector":{"type":"string"},"full_page":{"type":"boolean"}},"required":["session_id"]}`),
 			run:         service.Screenshot,
 		},
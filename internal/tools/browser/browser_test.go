@@ -16,6 +16,7 @@ import (
 	"github.com/julianshen/rubichan/internal/config"
 	"github.com/julianshen/rubichan/internal/tools"
 	mcpclient "github.com/julianshen/rubichan/internal/tools/mcp"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -523,6 +524,9 @@ func TestServiceScreenshot(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, result.IsError)
 	assert.Contains(t, result.Content, "saved screenshot")
+	require.Len(t, result.Media, 1)
+	assert.Equal(t, agentsdk.BlockTypeImage, result.Media[0].Type)
+	assert.Equal(t, "/tmp/test.png", result.Media[0].Source.Path)
 }
 
 func TestServiceScreenshotInvalidSessionID(t *testing.T) {
@@ -185,18 +185,7 @@ func (b *MCPBackend) ensureClient(ctx context.Context) error {
 		return nil
 	}
 
-	var transport mcpclient.Transport
-	var err error
-	switch b.server.Transport {
-	case "stdio":
-		transport, err = mcpclient.NewStdioTransport(b.server.Command, b.server.Args)
-	case "sse":
-		initCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-		defer cancel()
-		transport, err = mcpclient.NewSSETransport(initCtx, b.server.URL)
-	default:
-		err = fmt.Errorf("unsupported mcp transport %q", b.server.Transport)
-	}
+	transport, err := mcpclient.NewTransport(ctx, b.server.Transport, b.server.Command, b.server.Args, b.server.URL, b.server.Headers)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	coretools "github.com/julianshen/rubichan/pkg/tools"
+)
+
+// Aliases for the core tools that live in pkg/tools, so embedders and this
+// core register the same file, search, shell and process implementations.
+
+type FileTool = coretools.FileTool
+type LSPNotifier = coretools.LSPNotifier
+type FileReadCache = coretools.FileReadCache
+type FileStateInfo = coretools.FileStateInfo
+type SearchTool = coretools.SearchTool
+type ShellTool = coretools.ShellTool
+type ShellSandbox = coretools.ShellSandbox
+type ShellSandboxPolicy = coretools.ShellSandboxPolicy
+type CommandAllowlist = coretools.CommandAllowlist
+type ProcessTool = coretools.ProcessTool
+type ProcessIO = coretools.ProcessIO
+type PipeProcessIO = coretools.PipeProcessIO
+type ProcessStatus = coretools.ProcessStatus
+type ProcessInfo = coretools.ProcessInfo
+type ProcessManager = coretools.ProcessManager
+type ProcessManagerConfig = coretools.ProcessManagerConfig
+type ReadResultTool = coretools.ReadResultTool
+type ResultRetriever = coretools.ResultRetriever
+type RingBuffer = coretools.RingBuffer
+type DiffTracker = coretools.DiffTracker
+type FileChange = coretools.FileChange
+type Operation = coretools.Operation
+
+const (
+	ProcessRunning = coretools.ProcessRunning
+	ProcessExited  = coretools.ProcessExited
+	ProcessKilled  = coretools.ProcessKilled
+)
+
+const (
+	OpCreated  = coretools.OpCreated
+	OpModified = coretools.OpModified
+	OpDeleted  = coretools.OpDeleted
+)
+
+var (
+	NewFileTool               = coretools.NewFileTool
+	NewFileReadCache          = coretools.NewFileReadCache
+	NewSearchTool             = coretools.NewSearchTool
+	NewShellTool              = coretools.NewShellTool
+	IsExcludedFromSandbox     = coretools.IsExcludedFromSandbox
+	IsReadOnlyCommand         = coretools.IsReadOnlyCommand
+	NewCommandAllowlist       = coretools.NewCommandAllowlist
+	DefaultShellSandboxPolicy = coretools.DefaultShellSandboxPolicy
+	BuildSandboxPolicy        = coretools.BuildSandboxPolicy
+	NewDefaultShellSandbox    = coretools.NewDefaultShellSandbox
+	NewShellSandboxWithPolicy = coretools.NewShellSandboxWithPolicy
+	NewProcessTool            = coretools.NewProcessTool
+	NewPipeProcessIO          = coretools.NewPipeProcessIO
+	NewProcessManager         = coretools.NewProcessManager
+	NewReadResultTool         = coretools.NewReadResultTool
+	NewRingBuffer             = coretools.NewRingBuffer
+	NewDiffTracker            = coretools.NewDiffTracker
+)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"sync"
 	"sync/atomic"
 )
 
@@ -20,25 +22,78 @@ type ToolResult struct {
 	IsError bool           `json:"isError,omitempty"`
 }
 
-// ContentBlock is a content block in a tool result.
+// ContentBlock is a content block in a tool result. Text blocks carry Text;
+// image blocks carry base64 Data and its MimeType.
 type ContentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
 }
 
+// ServerCapabilities is the subset of a server's initialize capabilities
+// the client acts on. A nil field means the server does not offer it.
+type ServerCapabilities struct {
+	Tools     *ListCapability `json:"tools,omitempty"`
+	Resources *ListCapability `json:"resources,omitempty"`
+	Prompts   *ListCapability `json:"prompts,omitempty"`
+}
+
+// ListCapability describes a listable capability.
+type ListCapability struct {
+	// ListChanged means the server sends notifications/<kind>/list_changed.
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// Progress is one notifications/progress update for a request.
+type Progress struct {
+	Progress float64 `json:"progress"`
+	Total    float64 `json:"total,omitempty"`
+	Message  string  `json:"message,omitempty"`
+}
+
+// NotificationHandler receives server notifications other than progress,
+// such as notifications/tools/list_changed. It runs on the client's read
+// loop, so it must not block on a request to the same client.
+type NotificationHandler func(method string, params json.RawMessage)
+
 // Client manages a single MCP server connection.
+//
+// A background read loop, started by the first request, routes responses to
+// the request awaiting them, progress to the call that asked for it, and
+// other notifications to the NotificationHandler. Requests may therefore be
+// issued concurrently.
 type Client struct {
-	name       string
-	transport  Transport
-	nextID     atomic.Int64
-	serverName string
+	name         string
+	transport    Transport
+	nextID       atomic.Int64
+	serverName   string
+	capabilities ServerCapabilities
+
+	readOnce sync.Once
+	readCtx  context.Context
+	stopRead context.CancelFunc
+	mu       sync.Mutex
+	pending  map[int64]chan *jsonRPCResponse
+	early    map[int64]*jsonRPCResponse
+	readErr  error
+	progress map[string]func(Progress)
+	onNotify NotificationHandler
+	readDone chan struct{}
 }
 
 // NewClient creates a new MCP client.
 func NewClient(name string, transport Transport) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Client{
 		name:      name,
 		transport: transport,
+		readCtx:   ctx,
+		stopRead:  cancel,
+		pending:   make(map[int64]chan *jsonRPCResponse),
+		early:     make(map[int64]*jsonRPCResponse),
+		progress:  make(map[string]func(Progress)),
+		readDone:  make(chan struct{}),
 	}
 }
 
@@ -47,12 +102,22 @@ func (c *Client) ServerName() string {
 	return c.serverName
 }
 
+// Capabilities returns what the server advertised in Initialize.
+func (c *Client) Capabilities() ServerCapabilities {
+	return c.capabilities
+}
+
+// OnNotification sets the handler for server notifications.
+func (c *Client) OnNotification(h NotificationHandler) {
+	c.mu.Lock()
+	c.onNotify = h
+	c.mu.Unlock()
+}
+
 // Initialize performs the MCP protocol handshake.
 func (c *Client) Initialize(ctx context.Context) error {
-	id := c.nextID.Add(1)
-
 	params, _ := json.Marshal(map[string]any{
-		"protocolVersion": "2024-11-05",
+		"protocolVersion": latestProtocolVersion,
 		"capabilities":    map[string]any{},
 		"clientInfo": map[string]any{
 			"name":    "rubichan",
@@ -60,28 +125,17 @@ func (c *Client) Initialize(ctx context.Context) error {
 		},
 	})
 
-	req := jsonRPCRequest{
-		JSONRPC: "2.0",
-		ID:      id,
-		Method:  "initialize",
-		Params:  params,
-	}
-
-	if err := c.transport.Send(ctx, req); err != nil {
-		return fmt.Errorf("send initialize: %w", err)
-	}
-
-	resp, err := c.receiveResponse(ctx, id)
+	resp, err := c.roundTrip(ctx, "initialize", params, nil)
 	if err != nil {
-		return fmt.Errorf("receive initialize response: %w", err)
+		return err
 	}
-
 	if resp.Error != nil {
 		return resp.Error
 	}
 
 	var initResult struct {
-		ServerInfo struct {
+		Capabilities ServerCapabilities `json:"capabilities"`
+		ServerInfo   struct {
 			Name    string `json:"name"`
 			Version string `json:"version"`
 		} `json:"serverInfo"`
@@ -91,6 +145,7 @@ func (c *Client) Initialize(ctx context.Context) error {
 	}
 
 	c.serverName = initResult.ServerInfo.Name
+	c.capabilities = initResult.Capabilities
 
 	// Per MCP spec, client MUST send notifications/initialized after successful handshake.
 	notification := jsonRPCRequest{
@@ -106,113 +161,279 @@ func (c *Client) Initialize(ctx context.Context) error {
 
 // ListTools discovers available tools from the MCP server.
 func (c *Client) ListTools(ctx context.Context) ([]MCPTool, error) {
-	id := c.nextID.Add(1)
-
-	req := jsonRPCRequest{
-		JSONRPC: "2.0",
-		ID:      id,
-		Method:  "tools/list",
-	}
-
-	if err := c.transport.Send(ctx, req); err != nil {
-		return nil, fmt.Errorf("send tools/list: %w", err)
-	}
-
-	resp, err := c.receiveResponse(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("receive tools/list response: %w", err)
-	}
-
-	if resp.Error != nil {
-		return nil, resp.Error
-	}
-
 	var listResult struct {
 		Tools []MCPTool `json:"tools"`
 	}
-	if err := json.Unmarshal(resp.Result, &listResult); err != nil {
-		return nil, fmt.Errorf("parse tools/list result: %w", err)
+	if err := c.call(ctx, "tools/list", nil, nil, &listResult); err != nil {
+		return nil, err
 	}
-
 	return listResult.Tools, nil
 }
 
 // CallTool executes a tool on the MCP server.
 func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (*ToolResult, error) {
-	id := c.nextID.Add(1)
+	return c.CallToolWithProgress(ctx, name, args, nil)
+}
 
+// CallToolWithProgress is CallTool that asks the server for progress
+// notifications and passes each to onProgress. A nil onProgress requests
+// none.
+func (c *Client) CallToolWithProgress(ctx context.Context, name string, args map[string]any, onProgress func(Progress)) (*ToolResult, error) {
 	// MCP spec requires "arguments" to be an object, never null.
 	if args == nil {
 		args = map[string]any{}
 	}
 
-	params, err := json.Marshal(map[string]any{
+	var result ToolResult
+	if err := c.call(ctx, "tools/call", map[string]any{
 		"name":      name,
 		"arguments": args,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("marshal tools/call params: %w", err)
+	}, onProgress, &result); err != nil {
+		return nil, err
 	}
+	return &result, nil
+}
 
-	req := jsonRPCRequest{
-		JSONRPC: "2.0",
-		ID:      id,
-		Method:  "tools/call",
-		Params:  params,
+// ListResources lists the server's resources.
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	var result struct {
+		Resources []Resource `json:"resources"`
+	}
+	if err := c.call(ctx, "resources/list", nil, nil, &result); err != nil {
+		return nil, err
 	}
+	return result.Resources, nil
+}
 
-	if err := c.transport.Send(ctx, req); err != nil {
-		return nil, fmt.Errorf("send tools/call: %w", err)
+// ReadResource reads the resource at uri.
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	var result struct {
+		Contents []ResourceContents `json:"contents"`
 	}
+	if err := c.call(ctx, "resources/read", map[string]any{"uri": uri}, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
 
-	resp, err := c.receiveResponse(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("receive tools/call response: %w", err)
+// ListPrompts lists the server's prompt templates.
+func (c *Client) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	var result struct {
+		Prompts []Prompt `json:"prompts"`
+	}
+	if err := c.call(ctx, "prompts/list", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Prompts, nil
+}
+
+// GetPrompt renders the named prompt with args.
+func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error) {
+	params := map[string]any{"name": name}
+	if len(args) > 0 {
+		params["arguments"] = args
+	}
+	var result PromptResult
+	if err := c.call(ctx, "prompts/get", params, nil, &result); err != nil {
+		return nil, err
 	}
+	return &result, nil
+}
 
+// call issues a request and decodes its result into out.
+func (c *Client) call(ctx context.Context, method string, params map[string]any, onProgress func(Progress), out any) error {
+	var raw json.RawMessage
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("marshal %s params: %w", method, err)
+		}
+		raw = data
+	}
+	resp, err := c.roundTrip(ctx, method, raw, onProgress)
+	if err != nil {
+		return err
+	}
 	if resp.Error != nil {
-		return nil, resp.Error
+		return resp.Error
 	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		return fmt.Errorf("parse %s result: %w", method, err)
+	}
+	return nil
+}
 
-	var result ToolResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
-		return nil, fmt.Errorf("parse tools/call result: %w", err)
+// roundTrip sends a request and waits for its response. When onProgress is
+// set, the request carries a progress token and the server's progress
+// notifications for it are delivered until the response arrives.
+func (c *Client) roundTrip(ctx context.Context, method string, params json.RawMessage, onProgress func(Progress)) (*jsonRPCResponse, error) {
+	id := c.nextID.Add(1)
+	if onProgress != nil {
+		token := strconv.FormatInt(id, 10)
+		withMeta := map[string]any{}
+		if len(params) > 0 {
+			if err := json.Unmarshal(params, &withMeta); err != nil {
+				return nil, fmt.Errorf("marshal %s params: %w", method, err)
+			}
+		}
+		withMeta["_meta"] = map[string]any{"progressToken": token}
+		params, _ = json.Marshal(withMeta)
+		c.mu.Lock()
+		c.progress[token] = onProgress
+		c.mu.Unlock()
+		defer func() {
+			c.mu.Lock()
+			delete(c.progress, token)
+			c.mu.Unlock()
+		}()
 	}
 
-	return &result, nil
+	ch := make(chan *jsonRPCResponse, 1)
+	c.mu.Lock()
+	if resp, ok := c.early[id]; ok {
+		delete(c.early, id)
+		ch <- resp
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	req := jsonRPCRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params}
+	if err := c.transport.Send(ctx, req); err != nil {
+		return nil, fmt.Errorf("send %s: %w", method, err)
+	}
+	c.readOnce.Do(func() { go c.readLoop() })
+
+	resp, err := c.await(ctx, ch)
+	if err != nil {
+		return nil, fmt.Errorf("receive %s response: %w", method, err)
+	}
+	return resp, nil
 }
 
-// receiveResponse reads from the transport, skipping server-sent notifications
-// (messages without an ID), and returns the first response whose ID matches
-// the expected request ID.
-//
-// This method is NOT safe for concurrent use. The Client serializes requests
-// through its public API methods (Initialize, ListTools, CallTool), and each
-// waits for its response before returning. Do not call receiveResponse from
-// multiple goroutines.
-func (c *Client) receiveResponse(ctx context.Context, expectedID int64) (*jsonRPCResponse, error) {
+// await returns the response routed to ch, or the read loop's error if it
+// stopped first.
+func (c *Client) await(ctx context.Context, ch chan *jsonRPCResponse) (*jsonRPCResponse, error) {
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-c.readDone:
+		// The loop may have routed our response just before it stopped.
+		select {
+		case resp := <-ch:
+			return resp, nil
+		default:
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return nil, c.readErr
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// inboundMessage is anything the server sends: a response, a notification
+// or a request of its own.
+type inboundMessage struct {
+	ID     any             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonRPCError   `json:"error"`
+}
+
+// readLoop routes inbound messages until the transport fails or the client
+// is closed.
+func (c *Client) readLoop() {
+	defer close(c.readDone)
 	for {
-		var resp jsonRPCResponse
-		if err := c.transport.Receive(ctx, &resp); err != nil {
-			return nil, err
+		var msg inboundMessage
+		if err := c.transport.Receive(c.readCtx, &msg); err != nil {
+			c.mu.Lock()
+			c.readErr = err
+			c.mu.Unlock()
+			return
+		}
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			c.answerServerRequest(msg)
+		case msg.Method != "":
+			c.dispatchNotification(msg.Method, msg.Params)
+		case msg.ID != nil:
+			c.deliver(&jsonRPCResponse{JSONRPC: "2.0", ID: msg.ID, Result: msg.Result, Error: msg.Error})
 		}
+	}
+}
 
-		// Notifications have no ID — skip them.
-		if resp.ID == nil {
-			continue
+func (c *Client) deliver(resp *jsonRPCResponse) {
+	// JSON numbers unmarshal as float64.
+	f, ok := resp.ID.(float64)
+	if !ok {
+		return
+	}
+	id := int64(f)
+	c.mu.Lock()
+	ch, ok := c.pending[id]
+	if !ok {
+		// A response for an ID not yet issued is kept for the request that
+		// will claim it: a transport replaying canned responses can deliver
+		// one before its request is sent. Anything else is stale.
+		if id > c.nextID.Load() {
+			c.early[id] = resp
 		}
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+	select {
+	case ch <- resp:
+	default: // a duplicate; the first one already answered the request
+	}
+}
 
-		// JSON numbers unmarshal as float64. Compare via float64 for robustness.
-		if id, ok := resp.ID.(float64); ok && int64(id) == expectedID {
-			return &resp, nil
+func (c *Client) dispatchNotification(method string, params json.RawMessage) {
+	if method == "notifications/progress" {
+		var p struct {
+			ProgressToken any `json:"progressToken"`
+			Progress
 		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return
+		}
+		c.mu.Lock()
+		fn := c.progress[fmt.Sprint(p.ProgressToken)]
+		c.mu.Unlock()
+		if fn != nil {
+			fn(p.Progress)
+		}
+		return
+	}
+	c.mu.Lock()
+	h := c.onNotify
+	c.mu.Unlock()
+	if h != nil {
+		h(method, params)
+	}
+}
 
-		// Non-matching ID — skip (could be a stale or out-of-order response).
-		continue
+// answerServerRequest replies to a request the server sent. The client
+// offers no capabilities beyond ping, so everything else is refused.
+func (c *Client) answerServerRequest(msg inboundMessage) {
+	resp := jsonRPCResponse{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == "ping" {
+		resp.Result = json.RawMessage(`{}`)
+	} else {
+		resp.Error = &jsonRPCError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
 	}
+	_ = c.transport.Send(c.readCtx, resp)
 }
 
 // Close shuts down the client and its transport.
 func (c *Client) Close() error {
+	c.stopRead()
 	return c.transport.Close()
 }
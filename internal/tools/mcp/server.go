@@ -0,0 +1,362 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// JSON-RPC error codes the server answers with.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// latestProtocolVersion is the newest MCP revision the server speaks. The
+// server echoes a client's requested version when it knows it, and offers
+// this one otherwise, as the spec's version negotiation prescribes.
+const latestProtocolVersion = "2025-03-26"
+
+var supportedProtocolVersions = map[string]bool{
+	"2024-11-05":          true,
+	latestProtocolVersion: true,
+}
+
+// Resource is a readable item a server lists in resources/list.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate is a parameterised URI a client fills in to read a
+// resource the server cannot enumerate, such as a search.
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is one item of a resources/read result.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Prompt is a prompt template listed in prompts/list.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes an argument a prompt accepts.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is one message of a rendered prompt.
+type PromptMessage struct {
+	Role    string       `json:"role"`
+	Content ContentBlock `json:"content"`
+}
+
+// PromptResult is the result of prompts/get.
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// ServerTools is what a server exposes through tools/list and tools/call.
+type ServerTools interface {
+	ListTools(ctx context.Context) []MCPTool
+	// CallTool runs the named tool. A returned error is a protocol failure
+	// (unknown tool, malformed arguments); a tool that ran and failed
+	// reports it through ToolResult.IsError.
+	CallTool(ctx context.Context, name string, args json.RawMessage) (*ToolResult, error)
+}
+
+// ServerResources is what a server exposes through the resources methods.
+type ServerResources interface {
+	ListResources(ctx context.Context) ([]Resource, error)
+	ListResourceTemplates(ctx context.Context) ([]ResourceTemplate, error)
+	ReadResource(ctx context.Context, uri string) ([]ResourceContents, error)
+}
+
+// ServerPrompts is what a server exposes through prompts/list and
+// prompts/get.
+type ServerPrompts interface {
+	ListPrompts(ctx context.Context) []Prompt
+	GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error)
+}
+
+// ServerConfig configures a Server. Any of Tools, Resources and Prompts may
+// be nil, in which case the server does not advertise that capability.
+type ServerConfig struct {
+	Name      string
+	Version   string
+	Tools     ServerTools
+	Resources ServerResources
+	Prompts   ServerPrompts
+	// AllowedOrigins lists the browser origins, such as
+	// "https://app.example.com", that may call the HTTP transport besides
+	// loopback ones.
+	AllowedOrigins []string
+}
+
+// Server answers MCP requests. It is transport-agnostic: ServeStdio and
+// HTTPHandler feed it messages, and Handle may be called concurrently.
+type Server struct {
+	cfg ServerConfig
+}
+
+// NewServer creates an MCP server.
+func NewServer(cfg ServerConfig) *Server {
+	if cfg.Name == "" {
+		cfg.Name = "rubichan"
+	}
+	if cfg.Version == "" {
+		cfg.Version = "1.0.0"
+	}
+	return &Server{cfg: cfg}
+}
+
+// Handle processes one JSON-RPC message and returns the encoded response,
+// or nil when the message is a notification and needs none.
+func (s *Server) Handle(ctx context.Context, msg []byte) []byte {
+	var req jsonRPCRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return encodeResponse(jsonRPCResponse{JSONRPC: "2.0", Error: &jsonRPCError{Code: codeParseError, Message: err.Error()}})
+	}
+	if req.ID == nil {
+		// Notifications (notifications/initialized, cancellations) carry
+		// nothing the server must act on.
+		return nil
+	}
+	resp := jsonRPCResponse{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "" {
+		resp.Error = &jsonRPCError{Code: codeInvalidRequest, Message: "missing method"}
+		return encodeResponse(resp)
+	}
+	result, err := s.dispatch(ctx, req.Method, req.Params)
+	if err != nil {
+		var rpcErr *jsonRPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &jsonRPCError{Code: codeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return encodeResponse(resp)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &jsonRPCError{Code: codeInternalError, Message: err.Error()}
+		return encodeResponse(resp)
+	}
+	resp.Result = data
+	return encodeResponse(resp)
+}
+
+func encodeResponse(resp jsonRPCResponse) []byte {
+	data, _ := json.Marshal(resp)
+	return data
+}
+
+func invalidParams(format string, args ...any) error {
+	return &jsonRPCError{Code: codeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) dispatch(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		return s.initialize(params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		if s.cfg.Tools != nil {
+			tools := s.cfg.Tools.ListTools(ctx)
+			if tools == nil {
+				tools = []MCPTool{}
+			}
+			return map[string]any{"tools": tools}, nil
+		}
+	case "tools/call":
+		if s.cfg.Tools != nil {
+			var p struct {
+				Name      string          `json:"name"`
+				Arguments json.RawMessage `json:"arguments"`
+			}
+			if err := json.Unmarshal(params, &p); err != nil || p.Name == "" {
+				return nil, invalidParams("tools/call needs a tool name")
+			}
+			if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+				p.Arguments = json.RawMessage(`{}`)
+			}
+			return s.cfg.Tools.CallTool(ctx, p.Name, p.Arguments)
+		}
+	case "resources/list":
+		if s.cfg.Resources != nil {
+			resources, err := s.cfg.Resources.ListResources(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if resources == nil {
+				resources = []Resource{}
+			}
+			return map[string]any{"resources": resources}, nil
+		}
+	case "resources/templates/list":
+		if s.cfg.Resources != nil {
+			templates, err := s.cfg.Resources.ListResourceTemplates(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if templates == nil {
+				templates = []ResourceTemplate{}
+			}
+			return map[string]any{"resourceTemplates": templates}, nil
+		}
+	case "resources/read":
+		if s.cfg.Resources != nil {
+			var p struct {
+				URI string `json:"uri"`
+			}
+			if err := json.Unmarshal(params, &p); err != nil || p.URI == "" {
+				return nil, invalidParams("resources/read needs a uri")
+			}
+			contents, err := s.cfg.Resources.ReadResource(ctx, p.URI)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"contents": contents}, nil
+		}
+	case "prompts/list":
+		if s.cfg.Prompts != nil {
+			prompts := s.cfg.Prompts.ListPrompts(ctx)
+			if prompts == nil {
+				prompts = []Prompt{}
+			}
+			return map[string]any{"prompts": prompts}, nil
+		}
+	case "prompts/get":
+		if s.cfg.Prompts != nil {
+			var p struct {
+				Name      string            `json:"name"`
+				Arguments map[string]string `json:"arguments"`
+			}
+			if err := json.Unmarshal(params, &p); err != nil || p.Name == "" {
+				return nil, invalidParams("prompts/get needs a prompt name")
+			}
+			return s.cfg.Prompts.GetPrompt(ctx, p.Name, p.Arguments)
+		}
+	}
+	return nil, &jsonRPCError{Code: codeMethodNotFound, Message: "method not found: " + method}
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, invalidParams("initialize: %v", err)
+		}
+	}
+	version := latestProtocolVersion
+	if supportedProtocolVersions[p.ProtocolVersion] {
+		version = p.ProtocolVersion
+	}
+	caps := map[string]any{}
+	if s.cfg.Tools != nil {
+		caps["tools"] = map[string]any{}
+	}
+	if s.cfg.Resources != nil {
+		caps["resources"] = map[string]any{}
+	}
+	if s.cfg.Prompts != nil {
+		caps["prompts"] = map[string]any{}
+	}
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities":    caps,
+		"serverInfo": map[string]any{
+			"name":    s.cfg.Name,
+			"version": s.cfg.Version,
+		},
+	}, nil
+}
+
+// ServeStdio reads newline-delimited JSON-RPC messages from r and writes
+// responses to w until r is exhausted or ctx is cancelled. Requests are
+// handled concurrently so a long tool call does not block a ping; writes
+// are serialised so responses never interleave.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		writeMu sync.Mutex
+	)
+	defer wg.Wait()
+
+	lines := make(chan []byte)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		// Tool arguments can carry whole files; match the client's 1MB.
+		scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			if len(line) == 0 {
+				continue
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				select {
+				case err := <-scanErr:
+					return err
+				default:
+					return nil
+				}
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp := s.Handle(ctx, line)
+				if resp == nil {
+					return
+				}
+				writeMu.Lock()
+				defer writeMu.Unlock()
+				_, _ = w.Write(append(resp, '\n'))
+			}()
+		}
+	}
+}
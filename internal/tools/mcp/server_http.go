@@ -0,0 +1,243 @@
+package mcp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionHeader carries the streamable HTTP session ID in both directions.
+const sessionHeader = "Mcp-Session-Id"
+
+// maxHTTPMessage bounds a POSTed JSON-RPC body.
+const maxHTTPMessage = 4 * 1024 * 1024
+
+const (
+	// sessionIdleTimeout ends a session that has sent nothing for this
+	// long; clients that exit without a DELETE would otherwise leak it.
+	sessionIdleTimeout = 30 * time.Minute
+	// maxHTTPSessions caps live sessions. Past it, a new initialize ends
+	// the least recently used session.
+	maxHTTPSessions = 1000
+)
+
+// HTTPHandler serves the server over MCP's streamable HTTP transport.
+//
+// Clients POST JSON-RPC messages (singly or batched) and receive the
+// responses as an application/json body; the server never initiates
+// messages, so it offers no GET event stream. A successful initialize
+// assigns a session ID that every later request must carry, and DELETE
+// ends the session. Sessions idle for sessionIdleTimeout end on their own,
+// and at most maxHTTPSessions are kept.
+//
+// To block DNS rebinding, requests carrying an Origin header are refused
+// unless the origin is a loopback one or listed in AllowedOrigins.
+func (s *Server) HTTPHandler() http.Handler {
+	allowed := make(map[string]bool, len(s.cfg.AllowedOrigins))
+	for _, o := range s.cfg.AllowedOrigins {
+		allowed[normalizeOrigin(o)] = true
+	}
+	return &httpHandler{
+		server:         s,
+		allowedOrigins: allowed,
+		sessions:       make(map[string]time.Time),
+		idleTimeout:    sessionIdleTimeout,
+		maxSessions:    maxHTTPSessions,
+		now:            time.Now,
+	}
+}
+
+type httpHandler struct {
+	server         *Server
+	allowedOrigins map[string]bool
+	mu             sync.Mutex
+	sessions       map[string]time.Time // session ID → last request
+	idleTimeout    time.Duration
+	maxSessions    int
+	now            func() time.Time
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && !h.originAllowed(origin) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		h.post(w, r)
+	case http.MethodDelete:
+		if !h.endSession(r.Header.Get(sessionHeader)) {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *httpHandler) post(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPMessage+1))
+	if err != nil {
+		http.Error(w, "read body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHTTPMessage {
+		http.Error(w, "message too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var batch []json.RawMessage
+	trimmed := bytes.TrimSpace(body)
+	isBatch := len(trimmed) > 0 && trimmed[0] == '['
+	if isBatch {
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			http.Error(w, "invalid JSON-RPC batch", http.StatusBadRequest)
+			return
+		}
+	} else {
+		batch = []json.RawMessage{trimmed}
+	}
+
+	initializing := false
+	for _, msg := range batch {
+		var probe struct {
+			Method string `json:"method"`
+		}
+		_ = json.Unmarshal(msg, &probe)
+		if probe.Method == "initialize" {
+			initializing = true
+		}
+	}
+
+	sessionID := r.Header.Get(sessionHeader)
+	if initializing {
+		sessionID = newSessionID()
+	} else {
+		if sessionID == "" {
+			http.Error(w, "missing "+sessionHeader+" header", http.StatusBadRequest)
+			return
+		}
+		if !h.touchSession(sessionID) {
+			// 404 tells the client to start over with initialize.
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+	}
+
+	var responses []json.RawMessage
+	for _, msg := range batch {
+		if resp := h.server.Handle(r.Context(), msg); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	if initializing {
+		h.addSession(sessionID)
+		w.Header().Set(sessionHeader, sessionID)
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if isBatch {
+		_ = json.NewEncoder(w).Encode(responses)
+		return
+	}
+	_, _ = w.Write(responses[0])
+}
+
+// touchSession reports whether id names a live session and marks it used.
+func (h *httpHandler) touchSession(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expireLocked()
+	if _, ok := h.sessions[id]; !ok {
+		return false
+	}
+	h.sessions[id] = h.now()
+	return true
+}
+
+// addSession starts a session, ending the least recently used one when the
+// cap is reached.
+func (h *httpHandler) addSession(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expireLocked()
+	if len(h.sessions) >= h.maxSessions {
+		var oldest string
+		var oldestAt time.Time
+		for sid, at := range h.sessions {
+			if oldest == "" || at.Before(oldestAt) {
+				oldest, oldestAt = sid, at
+			}
+		}
+		delete(h.sessions, oldest)
+	}
+	h.sessions[id] = h.now()
+}
+
+// endSession removes id and reports whether it was live.
+func (h *httpHandler) endSession(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expireLocked()
+	_, ok := h.sessions[id]
+	delete(h.sessions, id)
+	return ok
+}
+
+// expireLocked drops sessions idle past the timeout. The caller must hold
+// h.mu.
+func (h *httpHandler) expireLocked() {
+	cutoff := h.now().Add(-h.idleTimeout)
+	for id, at := range h.sessions {
+		if at.Before(cutoff) {
+			delete(h.sessions, id)
+		}
+	}
+}
+
+func newSessionID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// originAllowed reports whether a browser at origin may call the server.
+func (h *httpHandler) originAllowed(origin string) bool {
+	if h.allowedOrigins[normalizeOrigin(origin)] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false // includes the opaque "null" origin
+	}
+	return IsLoopbackHost(u.Hostname())
+}
+
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+}
+
+// IsLoopbackHost reports whether host names the local machine only:
+// "localhost" or a loopback IP address. The empty host, which listens on
+// every interface, is not loopback.
+func IsLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
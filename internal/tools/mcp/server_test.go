@@ -0,0 +1,263 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeServerBackend struct {
+	calls []string
+}
+
+func (f *fakeServerBackend) ListTools(context.Context) []MCPTool {
+	return []MCPTool{{Name: "echo", Description: "Echo input", InputSchema: json.RawMessage(`{"type":"object"}`)}}
+}
+
+func (f *fakeServerBackend) CallTool(_ context.Context, name string, args json.RawMessage) (*ToolResult, error) {
+	if name != "echo" {
+		return nil, invalidParams("unknown tool %q", name)
+	}
+	f.calls = append(f.calls, string(args))
+	return &ToolResult{Content: []ContentBlock{{Type: "text", Text: string(args)}}}, nil
+}
+
+func (f *fakeServerBackend) ListResources(context.Context) ([]Resource, error) {
+	return []Resource{{URI: "knowledge://adr-1", Name: "ADR 1"}}, nil
+}
+
+func (f *fakeServerBackend) ListResourceTemplates(context.Context) ([]ResourceTemplate, error) {
+	return nil, nil
+}
+
+func (f *fakeServerBackend) ReadResource(_ context.Context, uri string) ([]ResourceContents, error) {
+	return []ResourceContents{{URI: uri, MimeType: "text/markdown", Text: "body"}}, nil
+}
+
+func (f *fakeServerBackend) ListPrompts(context.Context) []Prompt {
+	return []Prompt{{Name: "review", Arguments: []PromptArgument{{Name: "arguments"}}}}
+}
+
+func (f *fakeServerBackend) GetPrompt(_ context.Context, name string, args map[string]string) (*PromptResult, error) {
+	return &PromptResult{Messages: []PromptMessage{{Role: "user", Content: ContentBlock{Type: "text", Text: name + " " + args["arguments"]}}}}, nil
+}
+
+func newFakeServer() (*Server, *fakeServerBackend) {
+	b := &fakeServerBackend{}
+	return NewServer(ServerConfig{Tools: b, Resources: b, Prompts: b}), b
+}
+
+func handle(t *testing.T, s *Server, msg string) jsonRPCResponse {
+	t.Helper()
+	out := s.Handle(context.Background(), []byte(msg))
+	require.NotNil(t, out)
+	var resp jsonRPCResponse
+	require.NoError(t, json.Unmarshal(out, &resp))
+	return resp
+}
+
+func TestServerInitialize(t *testing.T) {
+	s, _ := newFakeServer()
+	resp := handle(t, s, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`)
+	require.Nil(t, resp.Error)
+
+	var result struct {
+		ProtocolVersion string         `json:"protocolVersion"`
+		Capabilities    map[string]any `json:"capabilities"`
+		ServerInfo      struct {
+			Name string `json:"name"`
+		} `json:"serverInfo"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Result, &result))
+	assert.Equal(t, "2024-11-05", result.ProtocolVersion)
+	assert.Equal(t, "rubichan", result.ServerInfo.Name)
+	assert.Contains(t, result.Capabilities, "tools")
+	assert.Contains(t, result.Capabilities, "resources")
+	assert.Contains(t, result.Capabilities, "prompts")
+
+	resp = handle(t, s, `{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`)
+	require.NoError(t, json.Unmarshal(resp.Result, &result))
+	assert.Equal(t, latestProtocolVersion, result.ProtocolVersion)
+}
+
+func TestServerMethods(t *testing.T) {
+	s, backend := newFakeServer()
+
+	resp := handle(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	assert.Contains(t, string(resp.Result), `"echo"`)
+
+	resp = handle(t, s, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}`)
+	require.Nil(t, resp.Error)
+	assert.Equal(t, []string{"{}"}, backend.calls)
+
+	resp = handle(t, s, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"nope"}}`)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codeInvalidParams, resp.Error.Code)
+
+	resp = handle(t, s, `{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"knowledge://adr-1"}}`)
+	assert.JSONEq(t, `{"contents":[{"uri":"knowledge://adr-1","mimeType":"text/markdown","text":"body"}]}`, string(resp.Result))
+
+	resp = handle(t, s, `{"jsonrpc":"2.0","id":5,"method":"resources/templates/list"}`)
+	assert.JSONEq(t, `{"resourceTemplates":[]}`, string(resp.Result))
+
+	resp = handle(t, s, `{"jsonrpc":"2.0","id":6,"method":"prompts/get","params":{"name":"review","arguments":{"arguments":"main"}}}`)
+	assert.Contains(t, string(resp.Result), `"review main"`)
+
+	resp = handle(t, s, `{"jsonrpc":"2.0","id":7,"method":"sampling/createMessage"}`)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codeMethodNotFound, resp.Error.Code)
+
+	assert.Nil(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)))
+}
+
+func TestServerWithoutCapabilities(t *testing.T) {
+	s := NewServer(ServerConfig{})
+	resp := handle(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codeMethodNotFound, resp.Error.Code)
+}
+
+func TestServerServeStdio(t *testing.T) {
+	s, _ := newFakeServer()
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}` + "\n" +
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}` + "\n")
+	var out bytes.Buffer
+	require.NoError(t, s.ServeStdio(context.Background(), in, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, out.String(), `"id":2`)
+}
+
+func postMCP(t *testing.T, url, session, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if session != "" {
+		req.Header.Set(sessionHeader, session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServerHTTPSessions(t *testing.T) {
+	s, _ := newFakeServer()
+	srv := testutil.NewServer(t, s.HTTPHandler())
+	defer srv.Close()
+
+	resp := postMCP(t, srv.URL, "", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postMCP(t, srv.URL, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	session := resp.Header.Get(sessionHeader)
+	require.NotEmpty(t, session)
+
+	resp = postMCP(t, srv.URL, session, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp = postMCP(t, srv.URL, session, `[{"jsonrpc":"2.0","id":2,"method":"ping"},{"jsonrpc":"2.0","id":3,"method":"tools/list"}]`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var batch []jsonRPCResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&batch))
+	require.Len(t, batch, 2)
+	assert.Equal(t, float64(3), batch[1].ID)
+
+	resp = postMCP(t, srv.URL, "stale", `{"jsonrpc":"2.0","id":4,"method":"ping"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodDelete, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(sessionHeader, session)
+	del, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	del.Body.Close()
+	assert.Equal(t, http.StatusNoContent, del.StatusCode)
+
+	resp = postMCP(t, srv.URL, session, `{"jsonrpc":"2.0","id":5,"method":"ping"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, fmt.Sprintf("session %s should be gone", session))
+}
+
+func TestServerHTTPEndsIdleAndExcessSessions(t *testing.T) {
+	s, _ := newFakeServer()
+	h := s.HTTPHandler().(*httpHandler)
+	clock := time.Unix(1_700_000_000, 0)
+	h.now = func() time.Time { return clock }
+	h.maxSessions = 2
+	srv := testutil.NewServer(t, h)
+	defer srv.Close()
+
+	initialize := func() string {
+		resp := postMCP(t, srv.URL, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return resp.Header.Get(sessionHeader)
+	}
+	ping := func(session string) int {
+		return postMCP(t, srv.URL, session, `{"jsonrpc":"2.0","id":2,"method":"ping"}`).StatusCode
+	}
+
+	idle := initialize()
+	clock = clock.Add(sessionIdleTimeout + time.Second)
+	assert.Equal(t, http.StatusNotFound, ping(idle), "an idle session ends without a DELETE")
+
+	first := initialize()
+	clock = clock.Add(time.Minute)
+	second := initialize()
+	clock = clock.Add(time.Minute)
+	assert.Equal(t, http.StatusOK, ping(first), "a request keeps the session alive")
+	third := initialize()
+	assert.Equal(t, http.StatusNotFound, ping(second), "the least recently used session makes room")
+	assert.Equal(t, http.StatusOK, ping(first))
+	assert.Equal(t, http.StatusOK, ping(third))
+	assert.Len(t, h.sessions, 2)
+}
+
+func TestServerHTTPRejectsForeignOrigins(t *testing.T) {
+	b := &fakeServerBackend{}
+	s := NewServer(ServerConfig{Tools: b, AllowedOrigins: []string{"https://app.example.com/"}})
+	srv := testutil.NewServer(t, s.HTTPHandler())
+	defer srv.Close()
+
+	for origin, want := range map[string]int{
+		"":                         http.StatusOK,
+		"http://localhost:3000":    http.StatusOK,
+		"http://127.0.0.1":         http.StatusOK,
+		"http://[::1]:8080":        http.StatusOK,
+		"https://APP.example.com":  http.StatusOK,
+		"https://evil.example.com": http.StatusForbidden,
+		"http://localhost.evil.io": http.StatusForbidden,
+		"null":                     http.StatusForbidden,
+	} {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`))
+		require.NoError(t, err)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, want, resp.StatusCode, "origin %q", origin)
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"localhost": true, "LOCALHOST": true, "127.0.0.1": true, "127.1.2.3": true, "::1": true, "[::1]": true,
+		"": false, "0.0.0.0": false, "::": false, "192.168.1.10": false, "example.com": false,
+	} {
+		assert.Equal(t, want, IsLoopbackHost(host), host)
+	}
+}
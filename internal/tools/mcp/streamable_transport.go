@@ -0,0 +1,380 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compile-time check: StreamableHTTPTransport implements Transport.
+var _ Transport = (*StreamableHTTPTransport)(nil)
+
+// ErrSessionExpired reports that the server no longer knows the session the
+// transport was using; the client must reconnect and initialize again.
+var ErrSessionExpired = errors.New("mcp session expired")
+
+// maxStreamResumes bounds how often a broken event stream is resumed with
+// Last-Event-ID before the transport gives up on it.
+const maxStreamResumes = 3
+
+// StreamableHTTPTransport speaks MCP's streamable HTTP transport: every
+// message is POSTed to a single endpoint, and the server answers with
+// either a JSON body or an event stream. The session ID the server assigns
+// at initialize is sent on every later request. An event stream that breaks
+// is resumed from its last event ID, and once a session exists a GET stream
+// is held open for server-initiated notifications.
+type StreamableHTTPTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	inbox  chan inboxItem
+	wg     sync.WaitGroup
+
+	mu        sync.Mutex
+	sessionID string
+	listening bool
+
+	closeOnce sync.Once
+}
+
+type inboxItem struct {
+	data json.RawMessage
+	err  error
+}
+
+// NewStreamableHTTPTransport creates a transport for the endpoint at url.
+// headers are added to every request, typically for authorization.
+func NewStreamableHTTPTransport(url string, headers map[string]string) *StreamableHTTPTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StreamableHTTPTransport{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{},
+		ctx:     ctx,
+		cancel:  cancel,
+		inbox:   make(chan inboxItem, 64),
+	}
+}
+
+// SessionID returns the session the server assigned, if any.
+func (t *StreamableHTTPTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+func (t *StreamableHTTPTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	if id := t.SessionID(); id != "" {
+		req.Header.Set(sessionHeader, id)
+	}
+	return req, nil
+}
+
+// Send POSTs msg. A response stream outlives the call: it is read in the
+// background, tied to the transport rather than to ctx, which only bounds
+// the wait for the server to accept the message.
+func (t *StreamableHTTPTransport) Send(ctx context.Context, msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	reqCtx, cancelReq := context.WithCancel(t.ctx)
+	stop := context.AfterFunc(ctx, cancelReq)
+	req, err := t.newRequest(reqCtx, http.MethodPost, bytes.NewReader(data))
+	if err != nil {
+		cancelReq()
+		return fmt.Errorf("create POST request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if !stop() {
+		// ctx ended while the request was in flight.
+		cancelReq()
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return ctx.Err()
+	}
+	if err != nil {
+		cancelReq()
+		return fmt.Errorf("send POST: %w", err)
+	}
+
+	if err := t.checkStatus(resp); err != nil {
+		resp.Body.Close()
+		cancelReq()
+		return err
+	}
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusAccepted:
+		resp.Body.Close()
+		cancelReq()
+	case strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"):
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			defer cancelReq()
+			t.readStream(reqCtx, resp.Body, false)
+		}()
+	default:
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancelReq()
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		if err := t.enqueueBody(body); err != nil {
+			return err
+		}
+	}
+
+	t.maybeListen()
+	return nil
+}
+
+func (t *StreamableHTTPTransport) checkStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound && t.SessionID() != "" {
+		return ErrSessionExpired
+	}
+	if resp.StatusCode >= 400 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("POST returned %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
+
+// enqueueBody queues a JSON body holding one message or a batch.
+func (t *StreamableHTTPTransport) enqueueBody(body []byte) error {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	if body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return fmt.Errorf("parse response batch: %w", err)
+		}
+		for _, m := range batch {
+			t.enqueue(inboxItem{data: m})
+		}
+		return nil
+	}
+	t.enqueue(inboxItem{data: json.RawMessage(body)})
+	return nil
+}
+
+func (t *StreamableHTTPTransport) enqueue(item inboxItem) {
+	select {
+	case t.inbox <- item:
+	case <-t.ctx.Done():
+	}
+}
+
+// maybeListen opens the GET notification stream once a session exists.
+func (t *StreamableHTTPTransport) maybeListen() {
+	t.mu.Lock()
+	if t.listening || t.sessionID == "" {
+		t.mu.Unlock()
+		return
+	}
+	t.listening = true
+	t.mu.Unlock()
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.readStream(t.ctx, nil, true)
+	}()
+}
+
+// readStream consumes an event stream, resuming it with Last-Event-ID when
+// it breaks. body is the stream a POST returned; nil (for the standalone
+// notification stream) opens one with GET. A POST stream ends normally when
+// the server closes it after the response; the GET stream is reopened
+// until the transport closes, unless the server does not offer one.
+func (t *StreamableHTTPTransport) readStream(ctx context.Context, body io.ReadCloser, standalone bool) {
+	lastID := ""
+	failures := 0
+	for {
+		if body == nil {
+			var err error
+			body, err = t.openStream(ctx, lastID)
+			if err != nil {
+				if errors.Is(err, errNoStream) || ctx.Err() != nil {
+					return
+				}
+				failures++
+				if failures > maxStreamResumes {
+					if !standalone {
+						t.enqueue(inboxItem{err: fmt.Errorf("resume event stream: %w", err)})
+					}
+					return
+				}
+				if !sleepCtx(ctx, time.Duration(failures)*time.Second) {
+					return
+				}
+				continue
+			}
+		}
+
+		id, err := t.readEvents(ctx, body)
+		body.Close()
+		body = nil
+		if id != "" {
+			lastID = id
+			failures = 0
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && !standalone {
+			return
+		}
+		if err == nil && !sleepCtx(ctx, time.Second) {
+			// A closed notification stream is reopened, but not in a
+			// tight loop against a server that closes it at once.
+			return
+		}
+		if !standalone && lastID == "" {
+			// Without an event ID there is nothing to resume from.
+			t.enqueue(inboxItem{err: fmt.Errorf("event stream broke: %w", err)})
+			return
+		}
+	}
+}
+
+// errNoStream means the server does not offer a GET stream.
+var errNoStream = errors.New("server offers no event stream")
+
+func (t *StreamableHTTPTransport) openStream(ctx context.Context, lastID string) (io.ReadCloser, error) {
+	req, err := t.newRequest(ctx, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		resp.Body.Close()
+		return nil, errNoStream
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET returned %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// readEvents queues the messages of an event stream and returns the last
+// event ID seen. A nil error means the server closed the stream cleanly.
+func (t *StreamableHTTPTransport) readEvents(ctx context.Context, body io.Reader) (string, error) {
+	scanner := bufio.NewScanner(body)
+	// Events can carry large MCP responses; match the stdio transport's 1MB.
+	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+	var (
+		lastID    string
+		eventType string
+		data      []string
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch {
+		case line == "":
+			if len(data) > 0 && (eventType == "" || eventType == "message") {
+				t.enqueue(inboxItem{data: json.RawMessage(strings.Join(data, "\n"))})
+			}
+			eventType, data = "", nil
+		case field == "id":
+			lastID = value
+		case field == "event":
+			eventType = value
+		case field == "data":
+			data = append(data, value)
+		}
+		if ctx.Err() != nil {
+			return lastID, ctx.Err()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return lastID, err
+	}
+	return lastID, nil
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Receive returns the next message from any response or event stream.
+func (t *StreamableHTTPTransport) Receive(ctx context.Context, result any) error {
+	select {
+	case item := <-t.inbox:
+		if item.err != nil {
+			return item.err
+		}
+		return json.Unmarshal(item.data, result)
+	case <-t.ctx.Done():
+		return io.EOF
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close ends the session, if any, and stops every stream.
+func (t *StreamableHTTPTransport) Close() error {
+	t.closeOnce.Do(func() {
+		if t.SessionID() != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if req, err := t.newRequest(ctx, http.MethodDelete, nil); err == nil {
+				if resp, err := t.client.Do(req); err == nil {
+					resp.Body.Close()
+				}
+			}
+			cancel()
+		}
+		t.cancel()
+	})
+	t.wg.Wait()
+	return nil
+}
@@ -0,0 +1,197 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julianshen/rubichan/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamableHTTPTransportAgainstServer(t *testing.T) {
+	s, _ := newFakeServer()
+	srv := testutil.NewServer(t, s.HTTPHandler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewStreamableHTTPTransport(srv.URL, map[string]string{"Authorization": "Bearer x"})
+	client := NewClient("fake", transport)
+	require.NoError(t, client.Initialize(ctx))
+	assert.NotEmpty(t, transport.SessionID())
+	assert.NotNil(t, client.Capabilities().Resources)
+
+	tools, err := client.ListTools(ctx)
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+
+	contents, err := client.ReadResource(ctx, "knowledge://adr-1")
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	assert.Equal(t, "body", contents[0].Text)
+
+	prompt, err := client.GetPrompt(ctx, "review", map[string]string{"arguments": "main"})
+	require.NoError(t, err)
+	assert.Equal(t, "review main", prompt.Messages[0].Content.Text)
+
+	require.NoError(t, client.Close())
+}
+
+func TestStreamableHTTPTransportSessionExpired(t *testing.T) {
+	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(sessionHeader) == "" {
+			w.Header().Set(sessionHeader, "s1")
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":{}}`)
+			return
+		}
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	transport := NewStreamableHTTPTransport(srv.URL, nil)
+	defer transport.Close()
+	require.NoError(t, transport.Send(context.Background(), jsonRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize"}))
+	err := transport.Send(context.Background(), jsonRPCRequest{JSONRPC: "2.0", ID: 2, Method: "ping"})
+	assert.ErrorIs(t, err, ErrSessionExpired)
+}
+
+// TestStreamableHTTPTransportResumesStream breaks a POST event stream after
+// its first event and checks the rest arrives over a GET carrying
+// Last-Event-ID.
+func TestStreamableHTTPTransportResumesStream(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		resumedFrom string
+	)
+	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(sessionHeader, "s1")
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "id: e1\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{\"progressToken\":\"1\",\"progress\":1}}\n\n")
+			w.(http.Flusher).Flush()
+			// Abort mid-stream so the client sees a broken connection.
+			panic(http.ErrAbortHandler)
+		case http.MethodGet:
+			last := r.Header.Get("Last-Event-ID")
+			if last == "" {
+				http.Error(w, "no standalone stream", http.StatusMethodNotAllowed)
+				return
+			}
+			mu.Lock()
+			resumedFrom = last
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "id: e2\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{\"content\":[{\"type\":\"text\",\"text\":\"done\"}]}}\n\n")
+		}
+	}))
+	defer srv.Close()
+
+	transport := NewStreamableHTTPTransport(srv.URL, nil)
+	defer transport.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, transport.Send(ctx, jsonRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call"}))
+
+	var first, second map[string]any
+	require.NoError(t, transport.Receive(ctx, &first))
+	assert.Equal(t, "notifications/progress", first["method"])
+	require.NoError(t, transport.Receive(ctx, &second))
+	assert.Equal(t, float64(1), second["id"])
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "e1", resumedFrom)
+}
+
+// scriptedTransport answers each request method with a fixed sequence of
+// messages, released only once the request is sent.
+type scriptedTransport struct {
+	mu      sync.Mutex
+	replies map[string][]string
+	sent    []json.RawMessage
+	inbox   chan json.RawMessage
+}
+
+func newScriptedTransport(replies map[string][]string) *scriptedTransport {
+	return &scriptedTransport{replies: replies, inbox: make(chan json.RawMessage, 16)}
+}
+
+func (s *scriptedTransport) Send(_ context.Context, msg any) error {
+	data, _ := json.Marshal(msg)
+	var req struct {
+		Method string `json:"method"`
+	}
+	_ = json.Unmarshal(data, &req)
+	s.mu.Lock()
+	s.sent = append(s.sent, data)
+	s.mu.Unlock()
+	for _, r := range s.replies[req.Method] {
+		s.inbox <- json.RawMessage(r)
+	}
+	return nil
+}
+
+func (s *scriptedTransport) Receive(ctx context.Context, result any) error {
+	select {
+	case msg := <-s.inbox:
+		return json.Unmarshal(msg, result)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *scriptedTransport) Close() error { return nil }
+
+func TestClientForwardsProgressAndNotifications(t *testing.T) {
+	st := newScriptedTransport(map[string][]string{
+		"initialize": {`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"tools":{"listChanged":true}},"serverInfo":{"name":"test"}}}`},
+		"tools/call": {
+			`{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`,
+			`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"2","progress":1,"total":2,"message":"halfway"}}`,
+			`{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"ok"}]}}`,
+		},
+	})
+	client := NewClient("test", st)
+	defer client.Close()
+
+	var (
+		mu       sync.Mutex
+		notified []string
+	)
+	client.OnNotification(func(method string, _ json.RawMessage) {
+		mu.Lock()
+		notified = append(notified, method)
+		mu.Unlock()
+	})
+	require.NoError(t, client.Initialize(context.Background()))
+	assert.True(t, client.Capabilities().Tools.ListChanged)
+
+	var progress []Progress
+	result, err := client.CallToolWithProgress(context.Background(), "slow", nil, func(p Progress) {
+		progress = append(progress, p)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.Content[0].Text)
+	require.Len(t, progress, 1)
+	assert.Equal(t, "halfway", progress[0].Message)
+
+	st.mu.Lock()
+	var sent map[string]any
+	require.NoError(t, json.Unmarshal(st.sent[2], &sent))
+	st.mu.Unlock()
+	assert.Equal(t, map[string]any{"progressToken": "2"}, sent["params"].(map[string]any)["_meta"])
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"notifications/tools/list_changed"}, notified)
+}
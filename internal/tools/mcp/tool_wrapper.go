@@ -16,8 +16,11 @@ type wrappedTool struct {
 	mcpTool    MCPTool
 }
 
-// compile-time check
-var _ tools.Tool = (*wrappedTool)(nil)
+// compile-time checks
+var (
+	_ tools.Tool          = (*wrappedTool)(nil)
+	_ tools.StreamingTool = (*wrappedTool)(nil)
+)
 
 // WrapTool creates a tools.Tool adapter for an MCP tool.
 func WrapTool(serverName string, client *Client, mcpTool MCPTool) tools.Tool {
@@ -44,6 +47,33 @@ func (w *wrappedTool) InputSchema() json.RawMessage {
 }
 
 func (w *wrappedTool) Execute(ctx context.Context, input json.RawMessage) (tools.ToolResult, error) {
+	return w.call(ctx, input, nil)
+}
+
+// ExecuteStream implements StreamingTool. Progress notifications the server
+// sends while the call runs are emitted as Delta events.
+func (w *wrappedTool) ExecuteStream(ctx context.Context, input json.RawMessage, emit tools.ToolEventEmitter) (tools.ToolResult, error) {
+	return w.call(ctx, input, func(p Progress) {
+		emit(tools.ToolEvent{Stage: tools.EventDelta, Content: formatProgress(p)})
+	})
+}
+
+// formatProgress renders a progress notification as a single line.
+func formatProgress(p Progress) string {
+	var b strings.Builder
+	if p.Total > 0 {
+		fmt.Fprintf(&b, "[%g/%g]", p.Progress, p.Total)
+	} else {
+		fmt.Fprintf(&b, "[%g]", p.Progress)
+	}
+	if p.Message != "" {
+		b.WriteString(" " + p.Message)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (w *wrappedTool) call(ctx context.Context, input json.RawMessage, onProgress func(Progress)) (tools.ToolResult, error) {
 	var args map[string]any
 	if len(input) > 0 {
 		if err := json.Unmarshal(input, &args); err != nil {
@@ -51,7 +81,7 @@ func (w *wrappedTool) Execute(ctx context.Context, input json.RawMessage) (tools
 		}
 	}
 
-	result, err := w.client.CallTool(ctx, w.mcpTool.Name, args)
+	result, err := w.client.CallToolWithProgress(ctx, w.mcpTool.Name, args, onProgress)
 	if err != nil {
 		// Transport/protocol errors (JSON-RPC errors, network failures) are
 		// propagated as Go errors so the caller can retry or surface them.
@@ -137,3 +137,30 @@ func TestWrappedToolExecuteEmptyInput(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "ok", result.Content)
 }
+
+func TestWrappedToolStreamsProgress(t *testing.T) {
+	st := newScriptedTransport(map[string][]string{
+		"initialize": {`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"tools":{}},"serverInfo":{"name":"fs"}}}`},
+		"tools/call": {
+			`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"2","progress":1,"total":4,"message":"indexing"}}`,
+			`{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"done"}]}}`,
+		},
+	})
+	client := NewClient("fs", st)
+	defer client.Close()
+	require.NoError(t, client.Initialize(context.Background()))
+
+	wrapped := WrapTool("fs", client, MCPTool{Name: "index"})
+	streaming, ok := wrapped.(tools.StreamingTool)
+	require.True(t, ok)
+
+	var events []tools.ToolEvent
+	result, err := streaming.ExecuteStream(context.Background(), nil, func(ev tools.ToolEvent) {
+		events = append(events, ev)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", result.Content)
+	require.Len(t, events, 1)
+	assert.Equal(t, tools.EventDelta, events[0].Stage)
+	assert.Equal(t, "[1/4] indexing\n", events[0].Content)
+}
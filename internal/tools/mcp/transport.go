@@ -189,3 +189,30 @@ func (t *StdioTransport) Close() error {
 	})
 	return closeErr
 }
+
+// NewTransport connects with the named transport: "stdio" spawns command
+// with args, "http" uses the streamable HTTP endpoint at url, and "sse" the
+// legacy SSE endpoint at url. headers apply to the http transport only.
+func NewTransport(ctx context.Context, transport, command string, args []string, url string, headers map[string]string) (Transport, error) {
+	switch transport {
+	case "stdio":
+		if command == "" {
+			return nil, fmt.Errorf("stdio transport requires a command")
+		}
+		return NewStdioTransport(command, args)
+	case "http":
+		if url == "" {
+			return nil, fmt.Errorf("http transport requires a url")
+		}
+		return NewStreamableHTTPTransport(url, headers), nil
+	case "sse":
+		if url == "" {
+			return nil, fmt.Errorf("sse transport requires a url")
+		}
+		initCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		return NewSSETransport(initCtx, url)
+	default:
+		return nil, fmt.Errorf("unsupported mcp transport %q", transport)
+	}
+}
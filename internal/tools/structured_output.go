@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/julianshen/rubichan/internal/jsonschema"
+)
+
+// StructuredOutputName is the canonical name of the structured output tool.
+const StructuredOutputName = "structured_output"
+
+// StructuredOutputTool collects the final answer of a headless run that was
+// given --json-schema. Its input schema is the caller's schema, so
+// providers that honour tool schemas constrain the answer as it is
+// generated; the answer is validated again on receipt and the validation
+// errors are returned to the model so it can correct itself.
+//
+// Tool input must be a JSON object, so a schema whose root is not an
+// object is wrapped as the "value" property and unwrapped on receipt.
+type StructuredOutputTool struct {
+	schema  *jsonschema.Schema
+	wrapped bool
+
+	mu      sync.Mutex
+	output  json.RawMessage
+	lastErr string
+}
+
+// NewStructuredOutputTool creates a StructuredOutputTool for schema.
+func NewStructuredOutputTool(schema *jsonschema.Schema) *StructuredOutputTool {
+	root := schema.Root()
+	return &StructuredOutputTool{
+		schema:  schema,
+		wrapped: root == nil || root["type"] != "object",
+	}
+}
+
+func (t *StructuredOutputTool) Name() string { return StructuredOutputName }
+
+func (t *StructuredOutputTool) Description() string {
+	desc := "Submit the final answer of this task. Call this exactly once, after all other work is done, " +
+		"with an answer that matches the input schema. If the answer is rejected, fix the listed problems and call it again."
+	if t.wrapped {
+		desc += " Put the answer in the \"value\" property."
+	}
+	return desc
+}
+
+func (t *StructuredOutputTool) InputSchema() json.RawMessage {
+	if !t.wrapped {
+		return t.schema.Raw()
+	}
+	return json.RawMessage(`{"type":"object","properties":{"value":` + string(t.schema.Raw()) + `},"required":["value"]}`)
+}
+
+func (t *StructuredOutputTool) Execute(_ context.Context, input json.RawMessage) (ToolResult, error) {
+	answer := input
+	if t.wrapped {
+		var in struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(input, &in); err != nil || in.Value == nil {
+			return t.reject(fmt.Errorf("missing required property \"value\""))
+		}
+		answer = in.Value
+	}
+	if err := t.Accept(answer); err != nil {
+		return t.reject(err)
+	}
+	return ToolResult{Content: "Answer recorded. The task is complete; do not call any more tools."}, nil
+}
+
+func (t *StructuredOutputTool) reject(err error) (ToolResult, error) {
+	t.mu.Lock()
+	t.lastErr = err.Error()
+	t.mu.Unlock()
+	return ToolResult{
+		Content: fmt.Sprintf("answer does not match the required schema: %s", err),
+		IsError: true,
+	}, nil
+}
+
+// Accept validates an answer and records it if it matches the schema.
+// The headless runner also offers it a final text response, for models
+// that answer in JSON instead of calling the tool.
+func (t *StructuredOutputTool) Accept(answer []byte) error {
+	if err := t.schema.Validate(answer); err != nil {
+		t.mu.Lock()
+		t.lastErr = err.Error()
+		t.mu.Unlock()
+		return err
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, answer); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.output = compacted.Bytes()
+	t.lastErr = ""
+	t.mu.Unlock()
+	return nil
+}
+
+// Output returns the accepted answer, if any.
+func (t *StructuredOutputTool) Output() (json.RawMessage, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.output, t.output != nil
+}
+
+// LastError returns why the most recent answer was rejected, or "" if
+// none was.
+func (t *StructuredOutputTool) LastError() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastErr
+}
+
+// Reset forgets the recorded answer so the tool can collect the answer of
+// a follow-up turn.
+func (t *StructuredOutputTool) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.output = nil
+	t.lastErr = ""
+}
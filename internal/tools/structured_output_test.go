@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/jsonschema"
+)
+
+func mustCompileSchema(t *testing.T, schema string) *jsonschema.Schema {
+	t.Helper()
+	s, err := jsonschema.Compile([]byte(schema))
+	require.NoError(t, err)
+	return s
+}
+
+func TestStructuredOutputToolObjectSchema(t *testing.T) {
+	schema := `{"type":"object","properties":{"label":{"enum":["bug","feature"]}},"required":["label"]}`
+	tool := NewStructuredOutputTool(mustCompileSchema(t, schema))
+
+	assert.Equal(t, StructuredOutputName, tool.Name())
+	assert.Equal(t, CategoryCore, Categorize(tool.Name()))
+	assert.JSONEq(t, schema, string(tool.InputSchema()))
+
+	res, err := tool.Execute(context.Background(), json.RawMessage(`{"label":"question"}`))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content, `/label: value "question" is not one of`)
+	assert.Contains(t, tool.LastError(), "/label")
+	_, ok := tool.Output()
+	assert.False(t, ok)
+
+	res, err = tool.Execute(context.Background(), json.RawMessage(`{ "label": "bug" }`))
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+	out, ok := tool.Output()
+	require.True(t, ok)
+	assert.Equal(t, `{"label":"bug"}`, string(out))
+	assert.Empty(t, tool.LastError())
+
+	tool.Reset()
+	_, ok = tool.Output()
+	assert.False(t, ok)
+}
+
+func TestStructuredOutputToolWrapsNonObjectSchema(t *testing.T) {
+	tool := NewStructuredOutputTool(mustCompileSchema(t, `{"type":"array","items":{"type":"string"}}`))
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(tool.InputSchema(), &schema))
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, []any{"value"}, schema["required"])
+	assert.Contains(t, tool.Description(), `"value"`)
+
+	res, err := tool.Execute(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content, `missing required property "value"`)
+
+	res, err = tool.Execute(context.Background(), json.RawMessage(`{"value":["a","b"]}`))
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+	out, ok := tool.Output()
+	require.True(t, ok)
+	assert.Equal(t, `["a","b"]`, string(out))
+}
+
+func TestStructuredOutputToolAccept(t *testing.T) {
+	tool := NewStructuredOutputTool(mustCompileSchema(t, `{"type":"object","required":["a"]}`))
+
+	assert.Error(t, tool.Accept([]byte(`{"b":1}`)))
+	assert.Contains(t, tool.LastError(), `missing required property "a"`)
+
+	require.NoError(t, tool.Accept([]byte("{\n  \"a\": 1\n}")))
+	out, _ := tool.Output()
+	assert.Equal(t, `{"a":1}`, string(out))
+}
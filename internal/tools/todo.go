@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// TodoName is the name of the todo tool.
+const TodoName = "todo"
+
+// TodoAccess is the interface for reading and replacing the agent's todo
+// list. Defined here to break the import cycle between tools/ and agent/.
+type TodoAccess interface {
+	Items() []agentsdk.PlanEntry
+	Set(items []agentsdk.PlanEntry)
+}
+
+// TodoTool lets the model keep a todo list for the task at hand. The list
+// drives the hosts' plan display and is shown to the model every turn.
+type TodoTool struct {
+	todos TodoAccess
+}
+
+// NewTodoTool creates a TodoTool backed by the given list.
+func NewTodoTool(todos TodoAccess) *TodoTool {
+	return &TodoTool{todos: todos}
+}
+
+func (t *TodoTool) Name() string { return TodoName }
+
+func (t *TodoTool) Description() string {
+	return "Keep a todo list for multi-step tasks: plan the steps up front, mark the step you " +
+		"start in_progress and check steps off as they complete. Only one step is in_progress " +
+		"at a time. Actions: set (replace the list with items), add (content, optional " +
+		"position), update (index, status and/or content), move (index to position), " +
+		"remove (index), list. Indexes and positions are 1-based."
+}
+
+func (t *TodoTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {
+				"type": "string",
+				"enum": ["set", "add", "update", "move", "remove", "list"],
+				"description": "The action to perform"
+			},
+			"items": {
+				"type": "array",
+				"description": "The new list (set)",
+				"items": {
+					"type": "object",
+					"properties": {
+						"content": {"type": "string"},
+						"status": {"type": "string", "enum": ["pending", "in_progress", "completed"]}
+					},
+					"required": ["content"]
+				}
+			},
+			"index": {
+				"type": "integer",
+				"description": "The item to change (update, move, remove)"
+			},
+			"position": {
+				"type": "integer",
+				"description": "Where to put the item (add, move); add appends without one"
+			},
+			"content": {
+				"type": "string",
+				"description": "The item text (add, update)"
+			},
+			"status": {
+				"type": "string",
+				"enum": ["pending", "in_progress", "completed"],
+				"description": "The item status (add, update)"
+			}
+		},
+		"required": ["action"]
+	}`)
+}
+
+type todoInput struct {
+	Action   string               `json:"action"`
+	Items    []agentsdk.PlanEntry `json:"items"`
+	Index    int                  `json:"index"`
+	Position int                  `json:"position"`
+	Content  string               `json:"content"`
+	Status   string               `json:"status"`
+}
+
+func (t *TodoTool) Execute(_ context.Context, input json.RawMessage) (ToolResult, error) {
+	if t.todos == nil {
+		return ToolResult{Content: "todo tool not initialized", IsError: true}, nil
+	}
+
+	var in todoInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return ToolResult{Content: fmt.Sprintf("invalid input: %s", err), IsError: true}, nil
+	}
+
+	items := t.todos.Items()
+	inRange := func(i int) bool { return i >= 1 && i <= len(items) }
+	focus := -1 // index of the item whose status was just set
+
+	switch in.Action {
+	case "list":
+		return ToolResult{Content: renderTodos(items)}, nil
+	case "set":
+		items = make([]agentsdk.PlanEntry, 0, len(in.Items))
+		for _, item := range in.Items {
+			entry, err := todoEntry(item.Content, item.Status)
+			if err != nil {
+				return ToolResult{Content: err.Error(), IsError: true}, nil
+			}
+			if entry.Status == agentsdk.PlanEntryInProgress {
+				focus = len(items)
+			}
+			items = append(items, entry)
+		}
+	case "add":
+		entry, err := todoEntry(in.Content, in.Status)
+		if err != nil {
+			return ToolResult{Content: err.Error(), IsError: true}, nil
+		}
+		pos := len(items)
+		if in.Position != 0 {
+			if in.Position < 1 || in.Position > len(items)+1 {
+				return ToolResult{Content: fmt.Sprintf("position %d is out of range (1-%d)", in.Position, len(items)+1), IsError: true}, nil
+			}
+			pos = in.Position - 1
+		}
+		items = append(items[:pos], append([]agentsdk.PlanEntry{entry}, items[pos:]...)...)
+		if entry.Status == agentsdk.PlanEntryInProgress {
+			focus = pos
+		}
+	case "update":
+		if !inRange(in.Index) {
+			return ToolResult{Content: fmt.Sprintf("index %d is out of range (1-%d)", in.Index, len(items)), IsError: true}, nil
+		}
+		item := &items[in.Index-1]
+		if in.Content == "" && in.Status == "" {
+			return ToolResult{Content: "content or status is required for update action", IsError: true}, nil
+		}
+		if in.Content != "" {
+			item.Content = strings.TrimSpace(in.Content)
+		}
+		if in.Status != "" {
+			if !validTodoStatus(in.Status) {
+				return ToolResult{Content: fmt.Sprintf("unknown status: %s (use pending, in_progress, or completed)", in.Status), IsError: true}, nil
+			}
+			item.Status = in.Status
+			focus = in.Index - 1
+		}
+	case "move":
+		if !inRange(in.Index) || !inRange(in.Position) {
+			return ToolResult{Content: fmt.Sprintf("index and position must be in range (1-%d)", len(items)), IsError: true}, nil
+		}
+		item := items[in.Index-1]
+		items = append(items[:in.Index-1], items[in.Index:]...)
+		pos := in.Position - 1
+		items = append(items[:pos], append([]agentsdk.PlanEntry{item}, items[pos:]...)...)
+	case "remove":
+		if !inRange(in.Index) {
+			return ToolResult{Content: fmt.Sprintf("index %d is out of range (1-%d)", in.Index, len(items)), IsError: true}, nil
+		}
+		items = append(items[:in.Index-1], items[in.Index:]...)
+	default:
+		return ToolResult{Content: fmt.Sprintf("unknown action: %s (use set, add, update, move, remove, or list)", in.Action), IsError: true}, nil
+	}
+
+	// Keep a single step in progress: the one just started wins.
+	if focus >= 0 && items[focus].Status == agentsdk.PlanEntryInProgress {
+		for i := range items {
+			if i != focus && items[i].Status == agentsdk.PlanEntryInProgress {
+				items[i].Status = agentsdk.PlanEntryPending
+			}
+		}
+	}
+	t.todos.Set(items)
+	return ToolResult{Content: renderTodos(items)}, nil
+}
+
+func todoEntry(content, status string) (agentsdk.PlanEntry, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return agentsdk.PlanEntry{}, fmt.Errorf("todo items need content")
+	}
+	if status == "" {
+		status = agentsdk.PlanEntryPending
+	}
+	if !validTodoStatus(status) {
+		return agentsdk.PlanEntry{}, fmt.Errorf("unknown status: %s (use pending, in_progress, or completed)", status)
+	}
+	return agentsdk.PlanEntry{Content: content, Status: status}, nil
+}
+
+func validTodoStatus(status string) bool {
+	switch status {
+	case agentsdk.PlanEntryPending, agentsdk.PlanEntryInProgress, agentsdk.PlanEntryCompleted:
+		return true
+	}
+	return false
+}
+
+func renderTodos(items []agentsdk.PlanEntry) string {
+	if len(items) == 0 {
+		return "The todo list is empty."
+	}
+	var sb strings.Builder
+	done := 0
+	for i, item := range items {
+		if item.Status == agentsdk.PlanEntryCompleted {
+			done++
+		}
+		fmt.Fprintf(&sb, "%d. [%s] %s\n", i+1, item.Status, item.Content)
+	}
+	fmt.Fprintf(&sb, "%d of %d done.", done, len(items))
+	return sb.String()
+}
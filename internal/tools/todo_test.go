@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// simpleTodos implements TodoAccess for testing.
+type simpleTodos struct {
+	items []agentsdk.PlanEntry
+	sets  int
+}
+
+func (s *simpleTodos) Items() []agentsdk.PlanEntry {
+	return append([]agentsdk.PlanEntry(nil), s.items...)
+}
+
+func (s *simpleTodos) Set(items []agentsdk.PlanEntry) {
+	s.items = items
+	s.sets++
+}
+
+func runTodo(t *testing.T, tool *TodoTool, input string) ToolResult {
+	t.Helper()
+	res, err := tool.Execute(context.Background(), json.RawMessage(input))
+	require.NoError(t, err)
+	return res
+}
+
+func todoContents(items []agentsdk.PlanEntry) []string {
+	var out []string
+	for _, item := range items {
+		out = append(out, item.Content+":"+item.Status)
+	}
+	return out
+}
+
+func TestTodoToolSetAddMoveRemove(t *testing.T) {
+	todos := &simpleTodos{}
+	tool := NewTodoTool(todos)
+	assert.Equal(t, "todo", tool.Name())
+
+	res := runTodo(t, tool, `{"action":"set","items":[{"content":"read code"},{"content":"write fix","status":"in_progress"},{"content":"run tests"}]}`)
+	require.False(t, res.IsError, res.Content)
+	assert.Contains(t, res.Content, "2. [in_progress] write fix")
+	assert.Contains(t, res.Content, "0 of 3 done.")
+
+	res = runTodo(t, tool, `{"action":"add","content":"update docs","position":1}`)
+	require.False(t, res.IsError, res.Content)
+	res = runTodo(t, tool, `{"action":"move","index":1,"position":4}`)
+	require.False(t, res.IsError, res.Content)
+	res = runTodo(t, tool, `{"action":"remove","index":1}`)
+	require.False(t, res.IsError, res.Content)
+	assert.Equal(t, []string{"write fix:in_progress", "run tests:pending", "update docs:pending"}, todoContents(todos.items))
+}
+
+func TestTodoToolKeepsOneInProgress(t *testing.T) {
+	todos := &simpleTodos{}
+	tool := NewTodoTool(todos)
+	runTodo(t, tool, `{"action":"set","items":[{"content":"a","status":"in_progress"},{"content":"b"}]}`)
+
+	res := runTodo(t, tool, `{"action":"update","index":2,"status":"in_progress"}`)
+	require.False(t, res.IsError, res.Content)
+	assert.Equal(t, []string{"a:pending", "b:in_progress"}, todoContents(todos.items))
+
+	res = runTodo(t, tool, `{"action":"update","index":2,"status":"completed","content":"b done"}`)
+	require.False(t, res.IsError, res.Content)
+	assert.Equal(t, []string{"a:pending", "b done:completed"}, todoContents(todos.items))
+	assert.Contains(t, res.Content, "1 of 2 done.")
+}
+
+func TestTodoToolErrors(t *testing.T) {
+	todos := &simpleTodos{}
+	tool := NewTodoTool(todos)
+
+	res := runTodo(t, tool, `{"action":"list"}`)
+	assert.False(t, res.IsError)
+	assert.Equal(t, "The todo list is empty.", res.Content)
+
+	for _, input := range []string{
+		`{"action":"update","index":1,"status":"completed"}`,
+		`{"action":"add","content":"  "}`,
+		`{"action":"add","content":"x","status":"done"}`,
+		`{"action":"set","items":[{"content":"x","status":"blocked"}]}`,
+		`{"action":"move","index":1,"position":2}`,
+		`{"action":"frobnicate"}`,
+		`not json`,
+	} {
+		res := runTodo(t, tool, input)
+		assert.True(t, res.IsError, input)
+	}
+	assert.Zero(t, todos.sets, "failed actions leave the list alone")
+
+	res = runTodo(t, NewTodoTool(nil), `{"action":"list"}`)
+	assert.True(t, res.IsError)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
 )
 
 type simctlMode string
@@ -94,7 +95,7 @@ func (s *SimctlTool) Description() string {
 	case simctlLaunch:
 		return "Launch an installed app on a simulator device by bundle ID."
 	case simctlScreenshot:
-		return "Take a screenshot of a simulator device and save to output path."
+		return "Take a screenshot of a simulator device, save it to output path, and return the image."
 	default:
 		return "iOS Simulator operation."
 	}
@@ -259,10 +260,17 @@ func (s *SimctlTool) executeCommand(ctx context.Context, in simctlInput) (tools.
 		return tools.ToolResult{Content: fmt.Sprintf("simctl %s failed: %s", s.mode, err), IsError: true}, nil
 	}
 
+	result := tools.ToolResult{Content: output}
 	if output == "" {
-		return tools.ToolResult{Content: fmt.Sprintf("simctl %s succeeded", s.mode)}, nil
+		result.Content = fmt.Sprintf("simctl %s succeeded", s.mode)
 	}
-	return tools.ToolResult{Content: output}, nil
+	if s.mode == simctlScreenshot {
+		// Hand the image back so vision models can inspect the UI.
+		if img, err := agentsdk.NewMediaFileBlock(in.OutputPath); err == nil {
+			result.Media = []agentsdk.ContentBlock{img}
+		}
+	}
+	return result, nil
 }
 
 // ParseSimctlDevices parses the nested JSON structure from xcrun simctl list -j devices.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/julianshen/rubichan/internal/tools"
@@ -423,6 +424,9 @@ func TestSimctlTool_Execute_ScreenshotSuccess(t *testing.T) {
 	// Verify the screenshot command uses "io" subcommand format.
 	assert.Contains(t, capturedArgs, "io")
 	assert.Contains(t, capturedArgs, "screenshot")
+	// The saved image is attached for the model.
+	require.Len(t, result.Media, 1)
+	assert.Equal(t, filepath.Join(rootDir, "screenshot.png"), result.Media[0].Source.Path)
 }
 
 func TestSimctlTool_Execute_ScreenshotPathTraversal(t *testing.T) {
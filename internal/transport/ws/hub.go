@@ -697,6 +697,7 @@ type wireTurnEvent struct {
 	UIRequest    *agentsdk.UIRequest       `json:"ui_request,omitempty"`
 	UIUpdate     *agentsdk.UIUpdate        `json:"ui_update,omitempty"`
 	UIResponse   *agentsdk.UIResponse      `json:"ui_response,omitempty"`
+	Plan         []agentsdk.PlanEntry      `json:"plan,omitempty"` // absent on a plan_update means the list is empty
 	Error        string                    `json:"error,omitempty"`
 	InputTokens  int                       `json:"input_tokens,omitempty"`
 	OutputTokens int                       `json:"output_tokens,omitempty"`
@@ -721,6 +722,7 @@ func marshalTurnEvent(evt agentsdk.TurnEvent) (json.RawMessage, error) {
 		UIRequest:    evt.UIRequest,
 		UIUpdate:     evt.UIUpdate,
 		UIResponse:   evt.UIResponse,
+		Plan:         evt.Plan,
 		InputTokens:  evt.InputTokens,
 		OutputTokens: evt.OutputTokens,
 		DiffSummary:  evt.DiffSummary,
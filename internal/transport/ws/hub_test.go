@@ -314,3 +314,21 @@ func TestClient_ReadPump_BinaryIgnored(t *testing.T) {
 	env := readEnvelope(t, conn)
 	assert.Equal(t, TypePong, env.Type)
 }
+
+func TestHub_MarshalTurnEvent_WithPlan(t *testing.T) {
+	raw, err := marshalTurnEvent(agentsdk.TurnEvent{
+		Type: "plan_update",
+		Plan: []agentsdk.PlanEntry{
+			{Content: "Write the handler", Status: agentsdk.PlanEntryCompleted},
+			{Content: "Add tests", Status: agentsdk.PlanEntryInProgress},
+		},
+	})
+	require.NoError(t, err)
+
+	var wire wireTurnEvent
+	require.NoError(t, json.Unmarshal(raw, &wire))
+	assert.Equal(t, "plan_update", wire.Type)
+	require.Len(t, wire.Plan, 2)
+	assert.Equal(t, "Add tests", wire.Plan[1].Content)
+	assert.Equal(t, "in_progress", wire.Plan[1].Status)
+}
@@ -79,7 +79,7 @@ Learn More:
   • GitHub: github.com/julianshen/rubichan
   • Spec: See spec.md for full architecture details
 
-Press Esc or 'q' to close this screen.
+Press ` + activeKeys.Label(ActionClose) + ` to close this screen.
 `
 }
 
@@ -87,36 +87,11 @@ Press Esc or 'q' to close this screen.
 func (a *AboutOverlay) Update(msg tea.Msg) (Overlay, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEscape:
-			a.cancelled = true
-			return a, nil
-		case tea.KeyUp:
-			a.viewport.ScrollUp(1)
-			return a, nil
-		case tea.KeyDown:
-			a.viewport.ScrollDown(1)
-			return a, nil
-		case tea.KeyPgUp:
-			a.viewport.HalfPageUp()
-			return a, nil
-		case tea.KeyPgDown:
-			a.viewport.HalfPageDown()
-			return a, nil
-		case tea.KeyHome:
-			a.viewport.GotoTop()
-			return a, nil
-		case tea.KeyEnd:
-			a.viewport.GotoBottom()
-			return a, nil
-		}
-
-		// Check for character keys q/Q.
-		switch msg.String() {
-		case "q", "Q":
+		if activeKeys.Matches(msg, ActionClose) {
 			a.cancelled = true
 			return a, nil
 		}
+		activeKeys.scrollViewport(&a.viewport, msg)
 
 	case tea.WindowSizeMsg:
 		a.width = msg.Width
@@ -134,7 +109,7 @@ func (a *AboutOverlay) View() string {
 	content := border.Render(a.viewport.View())
 
 	// Hints at bottom
-	hints := styleTextDim.Render("↑↓/jk = scroll · q/Esc = close")
+	hints := styleTextDim.Render(activeKeys.scrollHints())
 	about := fmt.Sprintf("%s\n%s\n", content, styleKeyHint.Render(hints))
 
 	return about
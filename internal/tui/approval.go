@@ -9,6 +9,8 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/julianshen/rubichan/internal/agent"
 )
 
 // ansiEscapePattern matches ANSI escape sequences: CSI (e.g. \x1b[31m),
@@ -78,14 +80,6 @@ func isDestructiveCommand(args string) bool {
 	return false
 }
 
-// Risk and warning styles use the centralized pink theme.
-var (
-	riskHighStyle   = styleRiskHigh
-	riskMediumStyle = styleRiskMedium
-	riskLowStyle    = styleRiskLow
-	warningStyle    = styleDestructiveWarning
-)
-
 // OptionsForRisk returns the default set of approval options based on risk
 // level and whether the command is destructive. Destructive or high-risk
 // commands omit "always" to prevent accidental blanket approval.
@@ -127,10 +121,11 @@ type ApprovalPrompt struct {
 	done          bool
 	box           lipgloss.Style
 	argsViewport  viewport.Model
-	useViewport   bool // true when formatted args > 5 lines
-	showBatchHint bool // true when batch hint should be displayed
-	hasAlways     bool // cached: true if ApprovalAlways is in options
-	hasDenyAlways bool // cached: true if ApprovalDenyAlways is in options
+	useViewport   bool        // true when formatted args > 5 lines
+	showBatchHint bool        // true when batch hint should be displayed
+	hasAlways     bool        // cached: true if ApprovalAlways is in options
+	hasDenyAlways bool        // cached: true if ApprovalDenyAlways is in options
+	review        *diffReview // set for file writes and patches that can be diffed
 }
 
 // NewApprovalPrompt creates a new approval prompt for the given tool and args.
@@ -149,10 +144,16 @@ func NewApprovalPrompt(tool, args, workDir string, width int, options []Approval
 		options = OptionsForRisk(tool, args)
 	}
 
+	// File writes and patches are reviewed as a diff instead of as args.
+	var review *diffReview
+	if edit, ok := fileEditFromToolCall(tool, args, workDir); ok {
+		review = newDiffReview(edit, boxWidth-4, diffReviewHeight)
+	}
+
 	// Detect if formatted args need viewport scrolling (> 5 lines).
 	formatted := formatToolArgs(tool, args)
 	argLines := strings.Split(formatted, "\n")
-	useViewport := len(argLines) > 5
+	useViewport := review == nil && len(argLines) > 5
 
 	var argsViewport viewport.Model
 	if useViewport {
@@ -183,6 +184,7 @@ func NewApprovalPrompt(tool, args, workDir string, width int, options []Approval
 		showBatchHint: showBatchHint,
 		hasAlways:     hasAlways,
 		hasDenyAlways: hasDenyAlways,
+		review:        review,
 	}
 }
 
@@ -214,17 +216,17 @@ func (a *ApprovalPrompt) hasOption(opt ApprovalResult) bool {
 func (a *ApprovalPrompt) HandleKey(msg tea.KeyMsg) bool {
 	// When viewport is active, forward navigation keys to it.
 	if a.useViewport {
-		switch msg.Type {
-		case tea.KeyUp:
+		switch {
+		case msg.Type == tea.KeyUp:
 			a.argsViewport.ScrollUp(1)
 			return true
-		case tea.KeyDown:
+		case msg.Type == tea.KeyDown:
 			a.argsViewport.ScrollDown(1)
 			return true
-		case tea.KeyPgUp:
+		case activeKeys.Matches(msg, ActionPageUp):
 			a.argsViewport.HalfPageUp()
 			return true
-		case tea.KeyPgDown:
+		case activeKeys.Matches(msg, ActionPageDown):
 			a.argsViewport.HalfPageDown()
 			return true
 		}
@@ -232,16 +234,16 @@ func (a *ApprovalPrompt) HandleKey(msg tea.KeyMsg) bool {
 
 	var target ApprovalResult
 	var isBatchKey bool
-	switch msg.String() {
-	case "y", "Y":
+	switch {
+	case activeKeys.Matches(msg, ActionApprove):
 		target = ApprovalYes
-	case "n", "N":
+	case activeKeys.Matches(msg, ActionDeny):
 		target = ApprovalNo
-	case "a", "A":
+	case activeKeys.Matches(msg, ActionApproveAlways):
 		target = ApprovalAlways
-	case "d", "D":
+	case activeKeys.Matches(msg, ActionDenyAlways):
 		target = ApprovalDenyAlways
-	case "b", "B":
+	case activeKeys.Matches(msg, ActionApproveBatch):
 		if !a.showBatchHint {
 			return false
 		}
@@ -442,20 +444,39 @@ func fallbackFormatArgs(args map[string]json.RawMessage) string {
 	return "(no arguments)"
 }
 
-// optionLabel returns the rendered label for an approval option.
+// optionLabel returns the rendered label for an approval option. The key
+// is folded into the word ("[Y]es") while it is the word's initial.
 func optionLabel(opt ApprovalResult) string {
+	var action Action
+	var word string
 	switch opt {
 	case ApprovalYes:
-		return styleApprovalKey.Render("[Y]") + styleApprovalLabel.Render("es")
+		action, word = ActionApprove, "Yes"
 	case ApprovalNo:
-		return styleApprovalKey.Render("[N]") + styleApprovalLabel.Render("o")
+		action, word = ActionDeny, "No"
 	case ApprovalAlways:
-		return styleApprovalKey.Render("[A]") + styleApprovalLabel.Render("lways allow")
+		action, word = ActionApproveAlways, "Always allow"
 	case ApprovalDenyAlways:
-		return styleApprovalKey.Render("[D]") + styleApprovalLabel.Render("eny always")
+		action, word = ActionDenyAlways, "Deny always"
 	default:
 		return ""
 	}
+	return keyedLabel(activeKeys.Label(action), word)
+}
+
+// keyedLabel renders a key and the word it triggers.
+func keyedLabel(key, word string) string {
+	if strings.EqualFold(key, word[:1]) {
+		return styleApprovalKey.Render("["+word[:1]+"]") + styleApprovalLabel.Render(word[1:])
+	}
+	return styleApprovalKey.Render("["+key+"]") + styleApprovalLabel.Render(" "+strings.ToLower(word))
+}
+
+// ApprovalDecision is the result of an approval overlay whose diff review
+// changed the tool call: some hunks were rejected or the content was edited.
+type ApprovalDecision struct {
+	Result ApprovalResult
+	Edit   *agent.ApprovalEdit
 }
 
 // ApprovalOverlay adapts ApprovalPrompt to the Overlay interface.
@@ -471,8 +492,18 @@ func NewApprovalOverlay(tool, args, workDir string, width int, options []Approva
 }
 
 func (a *ApprovalOverlay) Update(msg tea.Msg) (Overlay, tea.Cmd) {
-	if keyMsg, ok := msg.(tea.KeyMsg); ok {
-		a.prompt.HandleKey(keyMsg)
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if review := a.prompt.review; review != nil {
+			if cmd, ok := review.HandleKey(msg); ok {
+				return a, cmd
+			}
+		}
+		a.prompt.HandleKey(msg)
+	case diffEditorDoneMsg:
+		if a.prompt.review != nil {
+			a.prompt.review.finishEdit(msg)
+		}
 	}
 	return a, nil
 }
@@ -485,11 +516,24 @@ func (a *ApprovalOverlay) Done() bool {
 	return a.prompt.Done()
 }
 
+// Result returns an ApprovalResult, or an ApprovalDecision when the diff
+// review changed what an approved call will write.
 func (a *ApprovalOverlay) Result() any {
-	if a.prompt.Done() {
-		return a.prompt.Result()
+	if !a.prompt.Done() {
+		return nil
+	}
+	r := a.prompt.Result()
+	if a.prompt.review == nil || (r != ApprovalYes && r != ApprovalAlways) {
+		return r
+	}
+	apply, edit := a.prompt.review.decision()
+	if !apply {
+		r = ApprovalNo
 	}
-	return nil
+	if edit == nil {
+		return r
+	}
+	return ApprovalDecision{Result: r, Edit: edit}
 }
 
 // View renders the approval prompt as a bordered box with tool info,
@@ -504,18 +548,29 @@ func (a *ApprovalPrompt) View() string {
 	var icon string
 	switch risk {
 	case RiskHigh:
-		icon = riskHighStyle.Render("⚠")
+		icon = styleRiskHigh.Render("⚠")
 	case RiskMedium:
-		icon = riskMediumStyle.Render("●")
+		icon = styleRiskMedium.Render("●")
 	default:
-		icon = riskLowStyle.Render("●")
+		icon = styleRiskLow.Render("●")
 	}
 
 	header := fmt.Sprintf("  %s %s", icon, styleApprovalKey.Render(displayName))
 
-	// Format args: use viewport if available, otherwise inline.
+	// Format args: diff for reviewable edits, viewport if available,
+	// otherwise inline.
 	var detail string
-	if a.useViewport {
+	if a.review != nil {
+		path := stripANSI(a.review.edit.path)
+		if a.review.edit.created {
+			path += " (new file)"
+		}
+		lines := strings.Split(a.review.View(), "\n")
+		for i := range lines {
+			lines[i] = "  " + lines[i]
+		}
+		detail = "    " + styleSectionLabel.Render(path) + "\n" + strings.Join(lines, "\n")
+	} else if a.useViewport {
 		detail = styleTextDim.Render("    [use ↑↓ to scroll]\n") + "    " + a.argsViewport.View()
 	} else {
 		// Inline args — indent each line.
@@ -536,7 +591,7 @@ func (a *ApprovalPrompt) View() string {
 
 	// Destructive warning.
 	if isDestructiveCommand(sanitizedArgs) {
-		body += "\n" + warningStyle.Render("  ⚠ Destructive command detected")
+		body += "\n" + styleDestructiveWarning.Render("  ⚠ Destructive command detected")
 	}
 
 	// Render only the allowed options.
@@ -551,13 +606,14 @@ func (a *ApprovalPrompt) View() string {
 
 		// Add tip text when both Always and DenyAlways options are available.
 		if a.hasAlways && a.hasDenyAlways {
-			body += "\n" + styleTextDim.Render("  A = allow for this session · D = deny always")
+			body += "\n" + styleTextDim.Render(fmt.Sprintf("  %s = allow for this session · %s = deny always",
+				strings.ToUpper(activeKeys.Label(ActionApproveAlways)), strings.ToUpper(activeKeys.Label(ActionDenyAlways))))
 		}
 	}
 
 	// Add batch hint if applicable.
 	if a.showBatchHint {
-		body += "\n" + styleTextDim.Render("  B = batch allow all")
+		body += "\n" + styleTextDim.Render("  "+strings.ToUpper(activeKeys.Label(ActionApproveBatch))+" = batch allow all")
 	}
 
 	return a.box.Render(body) + "\n"
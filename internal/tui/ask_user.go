@@ -0,0 +1,283 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/julianshen/rubichan/internal/agent"
+	"github.com/julianshen/rubichan/internal/tools"
+)
+
+// askRequest carries an ask_user question from the agent goroutine to the
+// TUI. response is buffered so answering never blocks on a handler that
+// has already given up.
+type askRequest struct {
+	req      agent.UIRequest
+	response chan agent.UIResponse
+}
+
+// askRequestMsg is the Bubble Tea message type for ask_user questions.
+type askRequestMsg askRequest
+
+// askExpiredMsg closes the question overlay once the request has timed out.
+type askExpiredMsg struct{ id string }
+
+// AskUserResult carries the answer from the question overlay.
+type AskUserResult struct {
+	Response agent.UIResponse
+}
+
+// AskUserOverlay asks the user a question from the ask_user tool: pick one
+// option, toggle any number of them, or type a free-text answer.
+type AskUserOverlay struct {
+	req     agent.UIRequest
+	kind    string
+	options []string
+	index   int
+	checked map[int]bool
+	input   textinput.Model
+	width   int
+	done    bool
+	result  agent.UIResponse
+}
+
+// NewAskUserOverlay creates the overlay for an ask_user request.
+func NewAskUserOverlay(req agent.UIRequest, width int) *AskUserOverlay {
+	o := &AskUserOverlay{req: req, kind: req.Metadata["question_kind"], checked: map[int]bool{}, width: width}
+	for i, a := range req.Actions {
+		o.options = append(o.options, a.ID)
+		if a.Default {
+			o.index = i
+		}
+	}
+	if o.kind == "" {
+		o.kind = tools.AskText
+		if req.Kind == agent.UIKindSelect {
+			o.kind = tools.AskSingle
+		}
+	}
+	o.input = textinput.New()
+	o.input.Placeholder = req.Metadata["default"]
+	o.input.Width = o.boxWidth() - 6
+	o.input.Focus()
+	return o
+}
+
+func (o *AskUserOverlay) Update(msg tea.Msg) (Overlay, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		if o.kind == tools.AskText {
+			var cmd tea.Cmd
+			o.input, cmd = o.input.Update(msg)
+			return o, cmd
+		}
+		return o, nil
+	}
+
+	if o.kind == tools.AskText {
+		// Typing owns every key but the literal enter and esc, so remapped
+		// keys never swallow characters.
+		switch keyMsg.Type {
+		case tea.KeyEsc:
+			o.finish(tools.AskCancelAction, nil)
+		case tea.KeyEnter:
+			o.finish("submit", tools.AskTextValues{Answer: strings.TrimSpace(o.input.Value())})
+		default:
+			var cmd tea.Cmd
+			o.input, cmd = o.input.Update(msg)
+			return o, cmd
+		}
+		return o, nil
+	}
+
+	switch {
+	case activeKeys.Matches(keyMsg, ActionClose):
+		o.finish(tools.AskCancelAction, nil)
+	case activeKeys.Matches(keyMsg, ActionUp):
+		if o.index > 0 {
+			o.index--
+		}
+	case activeKeys.Matches(keyMsg, ActionDown):
+		if o.index < len(o.options)-1 {
+			o.index++
+		}
+	case o.kind == tools.AskMulti && keyMsg.String() == " ":
+		o.checked[o.index] = !o.checked[o.index]
+	case activeKeys.Matches(keyMsg, ActionSelect):
+		if o.kind == tools.AskMulti {
+			answers := []string{}
+			for i, opt := range o.options {
+				if o.checked[i] {
+					answers = append(answers, opt)
+				}
+			}
+			o.finish("submit", tools.AskMultiValues{Answers: answers})
+		} else if len(o.options) > 0 {
+			o.finish(o.options[o.index], nil)
+		}
+	}
+	return o, nil
+}
+
+// finish completes the overlay with a response; values, when non-nil, is
+// the form payload.
+func (o *AskUserOverlay) finish(actionID string, values any) {
+	o.result = agent.UIResponse{RequestID: o.req.ID, ActionID: actionID}
+	if values != nil {
+		o.result.Values, _ = json.Marshal(values)
+	}
+	o.done = true
+}
+
+func (o *AskUserOverlay) View() string {
+	k := activeKeys
+	var b strings.Builder
+	b.WriteString(styleApprovalLabel.Render("Question") + "\n\n")
+	b.WriteString(stripANSI(o.req.Message) + "\n\n")
+
+	switch o.kind {
+	case tools.AskText:
+		b.WriteString(o.input.View())
+		b.WriteString("\n\n[enter] answer  [esc] decline")
+	default:
+		for i, opt := range o.options {
+			marker := "  "
+			if i == o.index {
+				marker = "> "
+			}
+			if o.kind == tools.AskMulti {
+				box := "[ ] "
+				if o.checked[i] {
+					box = "[x] "
+				}
+				marker += box
+			}
+			b.WriteString(marker + stripANSI(opt) + "\n")
+		}
+		if o.kind == tools.AskMulti {
+			fmt.Fprintf(&b, "\n[%s/%s] navigate  [space] toggle  [%s] submit  [%s] decline",
+				k.Label(ActionUp), k.Label(ActionDown), k.Label(ActionSelect), k.Label(ActionClose))
+		} else {
+			fmt.Fprintf(&b, "\n[%s/%s] navigate  [%s] choose  [%s] decline",
+				k.Label(ActionUp), k.Label(ActionDown), k.Label(ActionSelect), k.Label(ActionClose))
+		}
+	}
+	if def := o.req.Metadata["default"]; def != "" && o.req.TimeoutSeconds > 0 {
+		fmt.Fprintf(&b, "\n%s", styleTextDim.Render(fmt.Sprintf("Defaults to %q after %s.", stripANSI(def), time.Duration(o.req.TimeoutSeconds)*time.Second)))
+	}
+	return styleApprovalBorder.Width(o.boxWidth()).Render(b.String())
+}
+
+func (o *AskUserOverlay) boxWidth() int {
+	w := o.width - 4
+	if w < 30 {
+		w = 30
+	}
+	return w
+}
+
+func (o *AskUserOverlay) Done() bool {
+	return o.done
+}
+
+func (o *AskUserOverlay) Result() any {
+	if !o.done {
+		return nil
+	}
+	return AskUserResult{Response: o.result}
+}
+
+// waitForAsk returns a tea.Cmd that blocks until an ask_user question
+// arrives, then delivers it as an askRequestMsg.
+func (m *Model) waitForAsk() tea.Cmd {
+	ch := m.askCh
+	return func() tea.Msg {
+		req := <-ch
+		return askRequestMsg(req)
+	}
+}
+
+// askUser forwards an ask_user request to the TUI and waits for the answer
+// or for ctx to end.
+func (m *Model) askUser(ctx context.Context, req agent.UIRequest) (agent.UIResponse, error) {
+	respCh := make(chan agent.UIResponse, 1)
+	select {
+	case m.askCh <- askRequest{req: req, response: respCh}:
+	case <-ctx.Done():
+		return agent.UIResponse{}, ctx.Err()
+	}
+	select {
+	case resp := <-respCh:
+		return resp, nil
+	case <-ctx.Done():
+		return agent.UIResponse{}, ctx.Err()
+	}
+}
+
+// handleAskRequest opens the question overlay. While another overlay is
+// showing, such as an approval, the question waits until it closes; no
+// further question is accepted meanwhile. A request with a timeout also
+// schedules closing the overlay when it expires.
+func (m *Model) handleAskRequest(msg askRequestMsg) tea.Cmd {
+	pending := askRequest(msg)
+	if m.activeOverlay != nil {
+		m.queuedAsk = &pending
+		return nil
+	}
+	m.state = StateAwaitingApproval
+	m.activeOverlay = NewAskUserOverlay(msg.req, m.width)
+	m.notifyIfSupported("Rubichan has a question")
+	m.pendingAsk = &pending
+	cmds := []tea.Cmd{m.waitForAsk()}
+	if msg.req.TimeoutSeconds > 0 {
+		id := msg.req.ID
+		cmds = append(cmds, tea.Tick(time.Duration(msg.req.TimeoutSeconds)*time.Second, func(time.Time) tea.Msg {
+			return askExpiredMsg{id: id}
+		}))
+	}
+	return tea.Batch(cmds...)
+}
+
+// openQueuedAsk opens a question that arrived while another overlay was
+// showing.
+func (m *Model) openQueuedAsk() tea.Cmd {
+	if m.queuedAsk == nil || m.activeOverlay != nil {
+		return nil
+	}
+	queued := *m.queuedAsk
+	m.queuedAsk = nil
+	return m.handleAskRequest(askRequestMsg(queued))
+}
+
+// resolveAsk delivers the answer to the pending question and resumes
+// streaming.
+func (m *Model) resolveAsk(resp agent.UIResponse) tea.Cmd {
+	if m.pendingAsk == nil {
+		m.state = StateInput
+		return nil
+	}
+	m.pendingAsk.response <- resp
+	m.pendingAsk = nil
+	m.state = StateStreaming
+	return m.waitForEvent()
+}
+
+// expireAsk closes the question overlay if it is still showing the
+// request that timed out.
+func (m *Model) expireAsk(id string) tea.Cmd {
+	if m.pendingAsk == nil || m.pendingAsk.req.ID != id {
+		return nil
+	}
+	m.pendingAsk = nil
+	m.activeOverlay = nil
+	m.state = StateStreaming
+	m.content.WriteString("The question timed out.\n")
+	m.setContentAndAutoScroll()
+	return m.waitForEvent()
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/agent"
+	"github.com/julianshen/rubichan/internal/tools"
+)
+
+func askUserRequest(kind string, options ...string) agent.UIRequest {
+	req := agent.UIRequest{
+		ID:       "ask-1",
+		Kind:     agent.UIKindForm,
+		Message:  "Which database?",
+		Metadata: map[string]string{"tool": tools.AskUserName, "question_kind": kind},
+	}
+	if kind == tools.AskSingle {
+		req.Kind = agent.UIKindSelect
+	}
+	for _, opt := range options {
+		req.Actions = append(req.Actions, agent.UIAction{ID: opt, Label: opt})
+	}
+	return req
+}
+
+// askThroughModel sends req through the model's UI handler, feeds keys to
+// the question overlay and returns the response.
+func askThroughModel(t *testing.T, req agent.UIRequest, keys ...tea.KeyMsg) agent.UIResponse {
+	t.Helper()
+	m := NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)
+	handler := m.MakeUIRequestHandler()
+
+	respCh := make(chan agent.UIResponse, 1)
+	go func() {
+		resp, err := handler.Request(context.Background(), req)
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+
+	updated, _ := m.Update(m.waitForAsk()())
+	m = updated.(*Model)
+	require.IsType(t, &AskUserOverlay{}, m.activeOverlay)
+	assert.Contains(t, m.activeOverlay.View(), "Which database?")
+
+	ch := make(chan agent.TurnEvent, 1)
+	ch <- agent.TurnEvent{Type: "done"}
+	m.eventCh = ch
+	for _, k := range keys {
+		updated, _ = m.Update(k)
+		m = updated.(*Model)
+	}
+	assert.Nil(t, m.activeOverlay)
+	assert.Equal(t, StateStreaming, m.state)
+
+	select {
+	case resp := <-respCh:
+		assert.Equal(t, "ask-1", resp.RequestID)
+		return resp
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the answer")
+		return agent.UIResponse{}
+	}
+}
+
+func TestAskUserOverlaySingleChoice(t *testing.T) {
+	resp := askThroughModel(t, askUserRequest(tools.AskSingle, "postgres", "sqlite"),
+		tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "sqlite", resp.ActionID)
+}
+
+func TestAskUserOverlayMultiChoice(t *testing.T) {
+	resp := askThroughModel(t, askUserRequest(tools.AskMulti, "a", "b", "c"),
+		tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}},
+		tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown},
+		tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}},
+		tea.KeyMsg{Type: tea.KeyEnter})
+	var v tools.AskMultiValues
+	require.NoError(t, json.Unmarshal(resp.Values, &v))
+	assert.Equal(t, []string{"a", "c"}, v.Answers)
+}
+
+func TestAskUserOverlayTextAndDecline(t *testing.T) {
+	resp := askThroughModel(t, askUserRequest(tools.AskText),
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("qa")},
+		tea.KeyMsg{Type: tea.KeyEnter})
+	var v tools.AskTextValues
+	require.NoError(t, json.Unmarshal(resp.Values, &v))
+	assert.Equal(t, "qa", v.Answer)
+
+	resp = askThroughModel(t, askUserRequest(tools.AskText), tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Equal(t, tools.AskCancelAction, resp.ActionID)
+}
+
+func TestAskUserQueuedBehindOverlayAndExpiry(t *testing.T) {
+	m := NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)
+	m.activeOverlay = NewAboutOverlay(80, 24)
+
+	req := askUserRequest(tools.AskSingle, "x", "y")
+	req.TimeoutSeconds = 30
+	m.Update(askRequestMsg{req: req, response: make(chan agent.UIResponse, 1)})
+	assert.IsType(t, &AboutOverlay{}, m.activeOverlay, "the question waits for the open overlay")
+	require.NotNil(t, m.queuedAsk)
+
+	m.activeOverlay = nil
+	m.Update(askExpiredMsg{id: "other"})
+	require.IsType(t, &AskUserOverlay{}, m.activeOverlay)
+
+	m.Update(askExpiredMsg{id: "ask-1"})
+	assert.Nil(t, m.activeOverlay)
+	assert.Nil(t, m.pendingAsk)
+	assert.Equal(t, StateStreaming, m.state)
+}
+
+func TestMakeUIRequestHandlerAskHonorsContext(t *testing.T) {
+	m := NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := m.MakeUIRequestHandler().Request(ctx, askUserRequest(tools.AskText))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// pastedAttachment interprets pasted text as an image or PDF dropped onto
+// the terminal. Terminals paste a dragged file as its path, often quoted,
+// as a file:// URL, or with backslash-escaped spaces. Anything that is not
+// a single existing media file returns false and is pasted as text.
+func pastedAttachment(text string) (agentsdk.ContentBlock, bool) {
+	path := strings.TrimSpace(text)
+	if path == "" || strings.ContainsAny(path, "\n\r") {
+		return agentsdk.ContentBlock{}, false
+	}
+	if len(path) >= 2 && (path[0] == '\'' || path[0] == '"') && path[len(path)-1] == path[0] {
+		path = path[1 : len(path)-1]
+	} else {
+		path = strings.ReplaceAll(path, `\ `, " ")
+	}
+	if strings.HasPrefix(path, "file://") {
+		u, err := url.Parse(path)
+		if err != nil {
+			return agentsdk.ContentBlock{}, false
+		}
+		path = u.Path
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return agentsdk.ContentBlock{}, false
+		}
+		path = filepath.Join(home, rest)
+	}
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return agentsdk.ContentBlock{}, false
+	}
+	block, err := agentsdk.NewMediaFileBlock(path)
+	if err != nil {
+		return agentsdk.ContentBlock{}, false
+	}
+	return block, true
+}
+
+// attachmentLabel is the marker inserted into the input for a pasted
+// attachment, so the prompt text can refer to it.
+func attachmentLabel(b agentsdk.ContentBlock) string {
+	return "[" + b.Type + ": " + b.Name + "]"
+}
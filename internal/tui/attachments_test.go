@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPastedAttachment(t *testing.T) {
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "my shot.png")
+	require.NoError(t, os.WriteFile(shot, []byte("png"), 0o644))
+	notes := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(notes, []byte("hi"), 0o644))
+
+	for _, text := range []string{
+		shot,
+		"'" + shot + "'",
+		`"` + shot + `"`,
+		filepath.Join(dir, `my\ shot.png`),
+		"file://" + shot + "\n",
+	} {
+		b, ok := pastedAttachment(text)
+		require.True(t, ok, text)
+		assert.Equal(t, agentsdk.BlockTypeImage, b.Type)
+		assert.Equal(t, shot, b.Source.Path)
+		assert.Equal(t, "[image: my shot.png]", attachmentLabel(b))
+	}
+
+	for _, text := range []string{
+		"",
+		"fix the bug in main.go",
+		notes,
+		dir,
+		filepath.Join(dir, "missing.png"),
+		shot + "\n" + shot,
+	} {
+		_, ok := pastedAttachment(text)
+		assert.False(t, ok, text)
+	}
+}
@@ -8,9 +8,6 @@ import (
 	"github.com/julianshen/rubichan/internal/persona"
 )
 
-// bannerColors is a pink gradient applied line-by-line to the banner.
-var bannerColors = bannerGradient
-
 // Banner is the ASCII art displayed on TUI startup. It spells "RUBICHAN".
 const Banner = ` _  .-')             .-. .-')                             ('-. .-.   ('-.         .-') _
 ( \( -O )            \  ( OO )                           ( OO )  /  ( OO ).-.    ( OO ) )
@@ -28,7 +25,7 @@ func RenderBanner() string {
 	lines := strings.Split(Banner, "\n")
 	styled := make([]string, len(lines))
 	for i, line := range lines {
-		color := bannerColors[i%len(bannerColors)]
+		color := bannerGradient[i%len(bannerGradient)]
 		style := lipgloss.NewStyle().Foreground(color).Bold(true)
 		styled[i] = style.Render(line)
 	}
@@ -132,7 +132,7 @@ func NewBootstrapForm(savePath string) *BootstrapForm {
 	).Title("Model").
 		WithHideFunc(func() bool { return cfg.Provider.Default != "zai" })
 
-	bf.form = huh.NewForm(providerGroup, anthropicKeyGroup, openaiGroup, zaiKeyGroup, anthropicModelGroup, openaiModelGroup, zaiModelGroup)
+	bf.form = newForm(providerGroup, anthropicKeyGroup, openaiGroup, zaiKeyGroup, anthropicModelGroup, openaiModelGroup, zaiModelGroup)
 	return bf
 }
 
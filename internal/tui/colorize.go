@@ -8,13 +8,6 @@ import (
 	"github.com/alecthomas/chroma/v2/formatters"
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/alecthomas/chroma/v2/styles"
-	"github.com/charmbracelet/lipgloss"
-)
-
-var (
-	styleMDHeading = lipgloss.NewStyle().Bold(true).Foreground(colorPrimaryLight)
-	styleMDCode    = lipgloss.NewStyle().Foreground(colorSuccess)
-	styleMDBold    = lipgloss.NewStyle().Bold(true)
 )
 
 // Cached Chroma resources — looked up once, reused across renders.
@@ -177,13 +170,13 @@ func colorizeDiffContent(content string) string {
 	for i, line := range lines {
 		switch {
 		case strings.HasPrefix(line, "@@ "):
-			lines[i] = diffHunkStyle.Render(line)
+			lines[i] = styleDiffHunk.Render(line)
 		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
 			continue
 		case strings.HasPrefix(line, "+"):
-			lines[i] = diffAddedStyle.Render(line)
+			lines[i] = styleDiffAdded.Render(line)
 		case strings.HasPrefix(line, "-"):
-			lines[i] = diffRemovedStyle.Render(line)
+			lines[i] = styleDiffRemoved.Render(line)
 		}
 	}
 	return strings.Join(lines, "\n")
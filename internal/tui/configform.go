@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -132,13 +133,36 @@ func NewConfigForm(cfg *config.Config, savePath string) *ConfigForm {
 			Value(&cfg.Security.FailOn),
 	).Title("Security")
 
-	cf.form = huh.NewForm(providerGroup, anthropicGroup, openaiGroup, zaiGroup, ollamaGroup, modelGroup, agentGroup, securityGroup)
+	if cfg.UI.Theme == "" {
+		cfg.UI.Theme = DefaultThemeName
+	}
+	themeNames := ThemeNames(ThemesDir(savePath))
+	if !slices.Contains(themeNames, cfg.UI.Theme) {
+		themeNames = append(themeNames, cfg.UI.Theme) // a theme given by path
+	}
+	var themeOptions []huh.Option[string]
+	for _, name := range themeNames {
+		themeOptions = append(themeOptions, huh.NewOption(name, name))
+	}
+	appearanceGroup := huh.NewGroup(
+		huh.NewSelect[string]().
+			Title("Theme").
+			Description("Theme files go in "+ThemesDir(savePath)).
+			Options(themeOptions...).
+			Value(&cfg.UI.Theme),
+		huh.NewConfirm().
+			Title("Vi Mode").
+			Description("Esc switches the input to vi normal mode").
+			Value(&cfg.UI.ViMode),
+	).Title("Appearance")
+
+	cf.form = newForm(providerGroup, anthropicGroup, openaiGroup, zaiGroup, ollamaGroup, modelGroup, agentGroup, securityGroup, appearanceGroup)
 
 	return cf
 }
 
 // GroupCount returns the number of form groups.
-func (c *ConfigForm) GroupCount() int { return 8 }
+func (c *ConfigForm) GroupCount() int { return 9 }
 
 // findOpenAICompatibleEntry returns the entry with the given name and
 // whether it was found.
@@ -20,7 +20,7 @@ func TestConfigFormCreation(t *testing.T) {
 func TestConfigFormGroupCount(t *testing.T) {
 	cfg := config.DefaultConfig()
 	form := NewConfigForm(cfg, "/tmp/test-config.toml")
-	assert.Equal(t, 8, form.GroupCount())
+	assert.Equal(t, 9, form.GroupCount())
 }
 
 func TestConfigFormIsCompletedAborted(t *testing.T) {
@@ -0,0 +1,498 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/julianshen/rubichan/internal/agent"
+)
+
+// maxReviewFileSize bounds the files the approval prompt will diff; larger
+// writes fall back to the plain argument summary.
+const maxReviewFileSize = 1 << 20
+
+// diffContext is the number of unchanged lines shown around each hunk.
+const diffContext = 3
+
+// diffReviewHeight is the number of diff lines visible at once.
+const diffReviewHeight = 16
+
+// fileEdit is a proposed change to one file, reconstructed from a file
+// tool call so it can be reviewed as a diff before it is applied.
+type fileEdit struct {
+	path    string // as the model gave it, relative to the working directory
+	oldText string
+	newText string
+	created bool // the file does not exist yet
+}
+
+// fileEditFromToolCall reconstructs the change a write or patch call of
+// the file tool would make. ok is false for other calls, and for calls the
+// tool would reject anyway (a path outside workDir, a patch whose
+// old_string is not in the file).
+func fileEditFromToolCall(tool, input, workDir string) (*fileEdit, bool) {
+	if tool != "file" || workDir == "" {
+		return nil, false
+	}
+	var in struct {
+		Operation string `json:"operation"`
+		Path      string `json:"path"`
+		Content   string `json:"content"`
+		OldString string `json:"old_string"`
+		NewString string `json:"new_string"`
+	}
+	if err := json.Unmarshal([]byte(input), &in); err != nil || in.Path == "" || filepath.IsAbs(in.Path) {
+		return nil, false
+	}
+	full := filepath.Join(workDir, in.Path)
+	if rel, err := filepath.Rel(workDir, full); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, false
+	}
+
+	edit := &fileEdit{path: in.Path}
+	info, err := os.Stat(full)
+	switch {
+	case err == nil:
+		if !info.Mode().IsRegular() || info.Size() > maxReviewFileSize {
+			return nil, false
+		}
+		data, err := os.ReadFile(full)
+		if err != nil {
+			return nil, false
+		}
+		edit.oldText = string(data)
+	case os.IsNotExist(err):
+		edit.created = true
+	default:
+		return nil, false
+	}
+
+	switch in.Operation {
+	case "write":
+		edit.newText = in.Content
+	case "patch":
+		if edit.created || in.OldString == "" || !strings.Contains(edit.oldText, in.OldString) {
+			return nil, false
+		}
+		edit.newText = strings.Replace(edit.oldText, in.OldString, in.NewString, 1)
+	default:
+		return nil, false
+	}
+	if len(edit.newText) > maxReviewFileSize {
+		return nil, false
+	}
+	return edit, true
+}
+
+// splitLines splits s into lines that keep their newline, so joining them
+// restores s exactly.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffHunk is one group of changes with its surrounding context.
+type diffHunk struct {
+	codes    []difflib.OpCode
+	accepted bool
+}
+
+// header returns the unified diff range line of the hunk.
+func (h diffHunk) header() string {
+	first, last := h.codes[0], h.codes[len(h.codes)-1]
+	return fmt.Sprintf("@@ -%s +%s @@", unifiedRange(first.I1, last.I2), unifiedRange(first.J1, last.J2))
+}
+
+func unifiedRange(start, stop int) string {
+	n := stop - start
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
+
+// diffReview lets the user accept or reject the hunks of a file edit, or
+// rewrite the proposed content in $EDITOR, before the edit is applied.
+type diffReview struct {
+	edit     *fileEdit
+	oldLines []string
+	newLines []string
+	codes    []difflib.OpCode
+	hunks    []diffHunk
+	owner    map[difflib.OpCode]int // change opcode -> hunk index
+	edited   bool                   // newLines came from the user's editor
+
+	cursor     int
+	sideBySide bool
+	width      int
+	viewport   viewport.Model
+	hunkLine   []int // first content line of each hunk
+
+	oldStyled []string // syntax-highlighted lines, parallel to oldLines
+	newStyled []string
+	editorErr string
+}
+
+func newDiffReview(edit *fileEdit, width, height int) *diffReview {
+	r := &diffReview{edit: edit, width: width, viewport: viewport.New(width, height)}
+	r.setNew(edit.newText, false)
+	return r
+}
+
+// setNew diffs the file against text and starts with every hunk accepted.
+func (r *diffReview) setNew(text string, edited bool) {
+	r.oldLines = splitLines(r.edit.oldText)
+	r.newLines = splitLines(text)
+	r.edited = edited
+	m := difflib.NewMatcherWithJunk(r.oldLines, r.newLines, false, nil)
+	r.codes = m.GetOpCodes()
+	r.hunks = nil
+	r.owner = make(map[difflib.OpCode]int)
+	if len(r.codes) > 0 && !(len(r.codes) == 1 && r.codes[0].Tag == 'e') {
+		for i, group := range m.GetGroupedOpCodes(diffContext) {
+			r.hunks = append(r.hunks, diffHunk{codes: group, accepted: true})
+			for _, c := range group {
+				if c.Tag != 'e' {
+					r.owner[c] = i
+				}
+			}
+		}
+	}
+	r.oldStyled = highlightLines(r.edit.path, r.oldLines)
+	r.newStyled = highlightLines(r.edit.path, r.newLines)
+	r.cursor = 0
+	r.refresh()
+}
+
+// result is the file content with the rejected hunks left out.
+func (r *diffReview) result() string {
+	var b strings.Builder
+	for _, c := range r.codes {
+		keepNew := c.Tag == 'e'
+		if i, ok := r.owner[c]; ok {
+			keepNew = r.hunks[i].accepted
+		}
+		if keepNew {
+			b.WriteString(strings.Join(r.newLines[c.J1:c.J2], ""))
+		} else {
+			b.WriteString(strings.Join(r.oldLines[c.I1:c.I2], ""))
+		}
+	}
+	return b.String()
+}
+
+func (r *diffReview) acceptedCount() int {
+	n := 0
+	for _, h := range r.hunks {
+		if h.accepted {
+			n++
+		}
+	}
+	return n
+}
+
+// decision turns the review into the approval outcome: whether the edit
+// should run at all, and the edit to report back to the agent when the
+// user changed what the model proposed.
+func (r *diffReview) decision() (apply bool, edit *agent.ApprovalEdit) {
+	final := r.result()
+	if len(r.hunks) > 0 && r.acceptedCount() == 0 {
+		return false, &agent.ApprovalEdit{
+			Note: fmt.Sprintf("the user rejected every hunk of this edit; %s was not changed", r.edit.path),
+		}
+	}
+	if final == r.edit.newText {
+		return true, nil
+	}
+	input, err := json.Marshal(map[string]string{"operation": "write", "path": r.edit.path, "content": final})
+	if err != nil {
+		return true, nil
+	}
+	var note strings.Builder
+	if r.edited {
+		note.WriteString("The user edited your proposed content before it was written. Changes from your proposal:\n")
+	} else {
+		fmt.Fprintf(&note, "The user rejected %d of %d hunks of this edit; only the others were applied. Rejected hunks:\n",
+			len(r.hunks)-r.acceptedCount(), len(r.hunks))
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(r.edit.newText),
+		B:        splitLines(final),
+		FromFile: "proposed/" + r.edit.path,
+		ToFile:   "applied/" + r.edit.path,
+		Context:  diffContext,
+	})
+	note.WriteString("```diff\n" + diff + "```\nRe-read the file before editing it again.")
+	return true, &agent.ApprovalEdit{Input: input, Note: note.String()}
+}
+
+// HandleKey processes review keys. It returns a command to run (the
+// editor) and whether the key was consumed.
+func (r *diffReview) HandleKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	k := activeKeys
+	switch {
+	case k.Matches(msg, ActionHunkNext):
+		r.moveCursor(1)
+	case k.Matches(msg, ActionHunkPrev):
+		r.moveCursor(-1)
+	case k.Matches(msg, ActionHunkToggle):
+		if len(r.hunks) > 0 {
+			r.hunks[r.cursor].accepted = !r.hunks[r.cursor].accepted
+			r.refresh()
+		}
+	case k.Matches(msg, ActionDiffLayout):
+		r.sideBySide = !r.sideBySide
+		r.refresh()
+	case k.Matches(msg, ActionEditContent):
+		return r.openEditor(), true
+	// Plain arrows always scroll: j/k belong to the hunk cursor here.
+	case msg.Type == tea.KeyUp:
+		r.viewport.ScrollUp(1)
+	case msg.Type == tea.KeyDown:
+		r.viewport.ScrollDown(1)
+	case k.Matches(msg, ActionPageUp):
+		r.viewport.HalfPageUp()
+	case k.Matches(msg, ActionPageDown):
+		r.viewport.HalfPageDown()
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+func (r *diffReview) moveCursor(delta int) {
+	if len(r.hunks) == 0 {
+		return
+	}
+	r.cursor = (r.cursor + delta + len(r.hunks)) % len(r.hunks)
+	r.refresh()
+	r.viewport.SetYOffset(r.hunkLine[r.cursor])
+}
+
+// diffEditorDoneMsg reports that the user closed $EDITOR.
+type diffEditorDoneMsg struct {
+	path string
+	err  error
+}
+
+// openEditor writes the current result to a temp file and suspends the
+// TUI while $VISUAL or $EDITOR (default vi) edits it.
+func (r *diffReview) openEditor() tea.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	tmp, err := os.CreateTemp("", "rubichan-edit-*"+filepath.Ext(r.edit.path))
+	if err != nil {
+		r.editorErr = err.Error()
+		return nil
+	}
+	_, werr := tmp.WriteString(r.result())
+	if cerr := tmp.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		os.Remove(tmp.Name())
+		r.editorErr = werr.Error()
+		return nil
+	}
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], tmp.Name())...)
+	path := tmp.Name()
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return diffEditorDoneMsg{path: path, err: err}
+	})
+}
+
+// finishEdit loads what the user saved in the editor.
+func (r *diffReview) finishEdit(msg diffEditorDoneMsg) {
+	defer os.Remove(msg.path)
+	if msg.err != nil {
+		r.editorErr = "editor: " + msg.err.Error()
+		r.refresh()
+		return
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		r.editorErr = err.Error()
+		r.refresh()
+		return
+	}
+	r.editorErr = ""
+	r.setNew(string(data), true)
+}
+
+// refresh re-renders the diff into the viewport.
+func (r *diffReview) refresh() {
+	var lines []string
+	r.hunkLine = r.hunkLine[:0]
+	if len(r.hunks) == 0 {
+		lines = append(lines, styleTextDim.Render("(no changes)"))
+	}
+	for i, h := range r.hunks {
+		r.hunkLine = append(r.hunkLine, len(lines))
+		mark := styleDiffAdded.Render("[✓]")
+		if !h.accepted {
+			mark = styleDiffRemoved.Render("[✗]")
+		}
+		header := styleDiffHunk.Render(h.header())
+		if i == r.cursor {
+			header = styleApprovalKey.Render("▶ ") + header
+		} else {
+			header = "  " + header
+		}
+		lines = append(lines, mark+" "+header)
+		if r.sideBySide {
+			lines = append(lines, r.sideBySideLines(h)...)
+		} else {
+			lines = append(lines, r.unifiedLines(h)...)
+		}
+	}
+	for i := range lines {
+		lines[i] = ansi.Truncate(lines[i], r.width, "…")
+	}
+	r.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+func (r *diffReview) unifiedLines(h diffHunk) []string {
+	var out []string
+	for _, c := range h.codes {
+		if c.Tag == 'e' {
+			for i := c.I1; i < c.I2; i++ {
+				out = append(out, "  "+r.oldStyled[i])
+			}
+			continue
+		}
+		for i := c.I1; i < c.I2; i++ {
+			out = append(out, r.changeLine("-", r.oldStyled[i], r.oldLines[i], h.accepted))
+		}
+		for j := c.J1; j < c.J2; j++ {
+			out = append(out, r.changeLine("+", r.newStyled[j], r.newLines[j], h.accepted))
+		}
+	}
+	return out
+}
+
+// changeLine renders a removed or added line; a rejected hunk is dimmed.
+func (r *diffReview) changeLine(sign, styled, plain string, accepted bool) string {
+	if !accepted {
+		return styleTextDim.Render(sign + " " + stripANSI(strings.TrimRight(plain, "\n")))
+	}
+	if sign == "-" {
+		return styleDiffRemoved.Render("- ") + styled
+	}
+	return styleDiffAdded.Render("+ ") + styled
+}
+
+func (r *diffReview) sideBySideLines(h diffHunk) []string {
+	col := (r.width - 3) / 2
+	if col < 10 {
+		col = 10
+	}
+	cell := func(s string) string {
+		s = ansi.Truncate(s, col, "…")
+		if pad := col - ansi.StringWidth(s); pad > 0 {
+			s += strings.Repeat(" ", pad)
+		}
+		return s
+	}
+	var out []string
+	for _, c := range h.codes {
+		if c.Tag == 'e' {
+			for i, j := c.I1, c.J1; i < c.I2; i, j = i+1, j+1 {
+				out = append(out, cell("  "+r.oldStyled[i])+" │ "+cell("  "+r.newStyled[j]))
+			}
+			continue
+		}
+		n := max(c.I2-c.I1, c.J2-c.J1)
+		for k := 0; k < n; k++ {
+			left, right := "", ""
+			if i := c.I1 + k; i < c.I2 {
+				left = r.changeLine("-", r.oldStyled[i], r.oldLines[i], h.accepted)
+			}
+			if j := c.J1 + k; j < c.J2 {
+				right = r.changeLine("+", r.newStyled[j], r.newLines[j], h.accepted)
+			}
+			out = append(out, cell(left)+" │ "+cell(right))
+		}
+	}
+	return out
+}
+
+// View renders the diff with a status line of the review state.
+func (r *diffReview) View() string {
+	status := fmt.Sprintf("hunk %d/%d · %d accepted", min(r.cursor+1, len(r.hunks)), len(r.hunks), r.acceptedCount())
+	if r.edited {
+		status += " · edited"
+	}
+	var b strings.Builder
+	b.WriteString(r.viewport.View())
+	b.WriteString("\n" + styleTextDim.Render(status))
+	k := activeKeys
+	b.WriteString("\n" + styleTextDim.Render(fmt.Sprintf("%s toggle hunk · %s/%s next/prev · %s side-by-side · %s edit in $EDITOR · ↑↓ scroll",
+		strings.ToLower(k.Label(ActionHunkToggle)), k.Label(ActionHunkNext), k.Label(ActionHunkPrev),
+		k.Label(ActionDiffLayout), k.Label(ActionEditContent))))
+	if r.editorErr != "" {
+		b.WriteString("\n" + styleDiffRemoved.Render(r.editorErr))
+	}
+	return b.String()
+}
+
+// highlightLines syntax-highlights lines as one document, so multi-line
+// constructs colour correctly, and splits the result back into lines.
+// It falls back to the plain lines when no lexer matches. Escape sequences
+// in the content are stripped first; it comes from the model.
+func highlightLines(path string, lines []string) []string {
+	plain := make([]string, len(lines))
+	for i, l := range lines {
+		plain[i] = stripANSI(strings.TrimRight(l, "\n"))
+	}
+	if chromaFormatter == nil || len(lines) == 0 {
+		return plain
+	}
+	lexer := lexers.Match(filepath.Base(path))
+	if lexer == nil {
+		return plain
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, strings.Join(plain, "\n"))
+	if err != nil {
+		return plain
+	}
+	var buf strings.Builder
+	if err := chromaFormatter.Format(&buf, chromaStyle, iterator); err != nil {
+		return plain
+	}
+	styled := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(styled) < len(lines) {
+		return plain
+	}
+	for i := range plain {
+		// Tokens can span lines; reset so colour never leaks.
+		plain[i] = styled[i] + "\x1b[0m"
+	}
+	return plain
+}
@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// twoHunkFile has changes far enough apart to form separate hunks.
+func twoHunkFile(t *testing.T) (dir, oldText, newText string) {
+	t.Helper()
+	var oldLines, newLines []string
+	for i := 0; i < 20; i++ {
+		line := "line " + string(rune('a'+i)) + "\n"
+		oldLines = append(oldLines, line)
+		switch i {
+		case 1:
+			newLines = append(newLines, "LINE B\n")
+		case 18:
+			newLines = append(newLines, "LINE S\n")
+		default:
+			newLines = append(newLines, line)
+		}
+	}
+	dir = t.TempDir()
+	oldText, newText = strings.Join(oldLines, ""), strings.Join(newLines, "")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(oldText), 0o644))
+	return dir, oldText, newText
+}
+
+func writeInput(t *testing.T, op, path, content string) string {
+	t.Helper()
+	data, err := json.Marshal(map[string]string{"operation": op, "path": path, "content": content})
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestFileEditFromToolCall(t *testing.T) {
+	dir, oldText, newText := twoHunkFile(t)
+
+	edit, ok := fileEditFromToolCall("file", writeInput(t, "write", "notes.txt", newText), dir)
+	require.True(t, ok)
+	assert.Equal(t, oldText, edit.oldText)
+	assert.Equal(t, newText, edit.newText)
+	assert.False(t, edit.created)
+
+	edit, ok = fileEditFromToolCall("file", `{"operation":"patch","path":"notes.txt","old_string":"line c\n","new_string":"LINE C\n"}`, dir)
+	require.True(t, ok)
+	assert.Equal(t, strings.Replace(oldText, "line c\n", "LINE C\n", 1), edit.newText)
+
+	edit, ok = fileEditFromToolCall("file", writeInput(t, "write", "sub/new.go", "package sub\n"), dir)
+	require.True(t, ok)
+	assert.True(t, edit.created)
+	assert.Empty(t, edit.oldText)
+}
+
+func TestFileEditFromToolCallRejects(t *testing.T) {
+	dir, _, _ := twoHunkFile(t)
+	for name, tc := range map[string]struct{ tool, input string }{
+		"other tool":      {"shell", `{"command":"ls"}`},
+		"read":            {"file", `{"operation":"read","path":"notes.txt"}`},
+		"absolute path":   {"file", writeInput(t, "write", "/etc/passwd", "x")},
+		"escaping path":   {"file", writeInput(t, "write", "../outside.txt", "x")},
+		"patch not found": {"file", `{"operation":"patch","path":"notes.txt","old_string":"missing","new_string":"x"}`},
+		"invalid json":    {"file", `{`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, ok := fileEditFromToolCall(tc.tool, tc.input, dir)
+			assert.False(t, ok)
+		})
+	}
+	_, ok := fileEditFromToolCall("file", writeInput(t, "write", "notes.txt", "x"), "")
+	assert.False(t, ok, "no working directory")
+}
+
+func TestDiffReviewHunks(t *testing.T) {
+	dir, oldText, newText := twoHunkFile(t)
+	edit, ok := fileEditFromToolCall("file", writeInput(t, "write", "notes.txt", newText), dir)
+	require.True(t, ok)
+	r := newDiffReview(edit, 80, diffReviewHeight)
+
+	require.Len(t, r.hunks, 2)
+	assert.Equal(t, "@@ -1,5 +1,5 @@", r.hunks[0].header())
+	assert.Equal(t, newText, r.result())
+
+	apply, e := r.decision()
+	assert.True(t, apply)
+	assert.Nil(t, e, "accepting everything needs no edit")
+
+	// Reject the second hunk.
+	r.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	r.HandleKey(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	assert.Equal(t, 1, r.cursor)
+	assert.False(t, r.hunks[1].accepted)
+	assert.Equal(t, strings.Replace(oldText, "line b\n", "LINE B\n", 1), r.result())
+
+	apply, e = r.decision()
+	assert.True(t, apply)
+	require.NotNil(t, e)
+	var in map[string]string
+	require.NoError(t, json.Unmarshal(e.Input, &in))
+	assert.Equal(t, "write", in["operation"])
+	assert.Equal(t, "notes.txt", in["path"])
+	assert.Equal(t, r.result(), in["content"])
+	assert.Contains(t, e.Note, "rejected 1 of 2 hunks")
+	assert.Contains(t, e.Note, "+line s")
+	assert.NotContains(t, e.Note, "LINE B")
+
+	// Reject the first as well: nothing would change, so deny.
+	r.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	r.HandleKey(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	assert.Equal(t, oldText, r.result())
+	apply, e = r.decision()
+	assert.False(t, apply)
+	require.NotNil(t, e)
+	assert.Contains(t, e.Note, "rejected every hunk")
+}
+
+func TestDiffReviewEditorResult(t *testing.T) {
+	dir, _, newText := twoHunkFile(t)
+	edit, ok := fileEditFromToolCall("file", writeInput(t, "write", "notes.txt", newText), dir)
+	require.True(t, ok)
+	r := newDiffReview(edit, 80, diffReviewHeight)
+
+	edited := strings.Replace(newText, "LINE S", "line s, by hand", 1)
+	tmp := filepath.Join(t.TempDir(), "edit.txt")
+	require.NoError(t, os.WriteFile(tmp, []byte(edited), 0o644))
+	r.finishEdit(diffEditorDoneMsg{path: tmp})
+
+	assert.True(t, r.edited)
+	assert.Equal(t, edited, r.result())
+	_, err := os.Stat(tmp)
+	assert.True(t, os.IsNotExist(err), "temp file is removed")
+
+	apply, e := r.decision()
+	assert.True(t, apply)
+	require.NotNil(t, e)
+	assert.Contains(t, e.Note, "edited your proposed content")
+	assert.Contains(t, e.Note, "+line s, by hand")
+}
+
+func TestDiffReviewViewModes(t *testing.T) {
+	dir, _, newText := twoHunkFile(t)
+	edit, ok := fileEditFromToolCall("file", writeInput(t, "write", "notes.txt", newText), dir)
+	require.True(t, ok)
+	r := newDiffReview(edit, 80, 40)
+
+	view := stripANSI(r.View())
+	assert.Contains(t, view, "- line b")
+	assert.Contains(t, view, "+ LINE B")
+	assert.Contains(t, view, "hunk 1/2 · 2 accepted")
+
+	r.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	view = stripANSI(r.View())
+	assert.Contains(t, view, "│")
+	for _, line := range strings.Split(view, "\n") {
+		if strings.Contains(line, "LINE B") {
+			assert.Contains(t, line, "line b", "old and new side by side")
+		}
+	}
+}
+
+func TestApprovalOverlayDiffDecision(t *testing.T) {
+	dir, _, newText := twoHunkFile(t)
+	input := writeInput(t, "write", "notes.txt", newText)
+
+	o := NewApprovalOverlay("file", input, dir, 100, nil, false)
+	require.NotNil(t, o.prompt.review)
+	assert.Contains(t, stripANSI(o.View()), "notes.txt")
+
+	o.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	require.True(t, o.Done())
+	d, ok := o.Result().(ApprovalDecision)
+	require.True(t, ok)
+	assert.Equal(t, ApprovalYes, d.Result)
+	require.NotNil(t, d.Edit)
+	assert.Contains(t, d.Edit.Note, "rejected 1 of 2 hunks")
+
+	// Accepting as proposed is a plain result.
+	o = NewApprovalOverlay("file", input, dir, 100, nil, false)
+	o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	assert.Equal(t, ApprovalYes, o.Result())
+}
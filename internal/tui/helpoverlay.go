@@ -41,48 +41,69 @@ func NewHelpOverlay(width, height int) *HelpOverlay {
 	}
 }
 
-// helpContent generates the static help text with all key bindings.
+// helpContent generates the help text from the active keymap.
 func helpContent() string {
+	k := activeKeys
 	categories := []helpCategory{
 		{
 			name: "Navigation",
 			bindings: []helpBinding{
-				{"↑/↓ or k/j", "scroll messages"},
+				{"Ctrl+U/Ctrl+D", "scroll messages"},
 				{"Page Up/Down", "jump full page"},
-				{"Home/End", "jump to start/end"},
-				{"Ctrl+L", "jump to last error"},
+				{k.Label(ActionJumpError), "jump to last error"},
 			},
 		},
 		{
 			name: "Input",
 			bindings: []helpBinding{
-				{"Enter", "send prompt"},
-				{"Ctrl+A", "jump to start of line"},
-				{"Ctrl+E", "jump to end of line"},
-				{"Ctrl+U", "clear line"},
-				{"Ctrl+W", "delete word"},
+				{k.Label(ActionSubmit), "send prompt"},
+				{k.Label(ActionNewline), "new line"},
+				{k.Label(ActionHistoryPrev) + "/" + k.Label(ActionHistoryNext), "previous/next prompt"},
+				{k.Label(ActionComplete), "complete command or file"},
 			},
 		},
 		{
 			name: "Tool Results",
 			bindings: []helpBinding{
-				{"o", "toggle tool result"},
-				{"Ctrl+O", "toggle all tool results"},
-				{"Ctrl+E", "expand most recent"},
+				{k.Label(ActionToggleTools), "toggle all tool results"},
+				{k.Label(ActionExpandResult), "expand most recent"},
+				{k.Label(ActionToggleDiff), "toggle diff summary"},
+				{k.Label(ActionToggleAgents), "toggle subagent panel"},
+			},
+		},
+		{
+			name: "Tabs",
+			bindings: []helpBinding{
+				{k.Label(ActionTabNew), "open a new tab"},
+				{k.Label(ActionTabClose), "close the tab"},
+				{k.Label(ActionTabPrev) + "/" + k.Label(ActionTabNext), "previous/next tab"},
+				{"Alt+1…9", "go to tab"},
+				{"/tab", "list, rename or open a tab in a worktree"},
 			},
 		},
 		{
 			name: "Overlays",
 			bindings: []helpBinding{
-				{"Ctrl+F", "toggle plan panel"},
-				{"Ctrl+K", "open config"},
-				{"Ctrl+Z", "open undo"},
-				{"Ctrl+W", "open wiki generator"},
-				{"?", "show this help"},
-				{"Esc or q", "close overlay"},
+				{k.Label(ActionTogglePlan), "toggle plan panel"},
+				{k.Label(ActionHelp), "show this help"},
+				{k.Label(ActionUp) + "/" + k.Label(ActionDown), "move or scroll"},
+				{k.Label(ActionSelect), "select"},
+				{k.Label(ActionClose), "close overlay"},
 			},
 		},
 	}
+	if k.ViMode {
+		categories = append(categories, helpCategory{
+			name: "Vi Mode",
+			bindings: []helpBinding{
+				{"Esc", "normal mode"},
+				{"i/a/I/A/o", "insert mode"},
+				{"h/l/w/b/0/$", "move cursor"},
+				{"x/D/dd", "delete"},
+				{"k/j", "previous/next prompt"},
+			},
+		})
+	}
 
 	var content strings.Builder
 	content.WriteString("KEY BINDINGS\n")
@@ -105,36 +126,11 @@ func helpContent() string {
 func (h *HelpOverlay) Update(msg tea.Msg) (Overlay, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEscape:
-			h.cancelled = true
-			return h, nil
-		case tea.KeyUp:
-			h.viewport.ScrollUp(1)
-			return h, nil
-		case tea.KeyDown:
-			h.viewport.ScrollDown(1)
-			return h, nil
-		case tea.KeyPgUp:
-			h.viewport.HalfPageUp()
-			return h, nil
-		case tea.KeyPgDown:
-			h.viewport.HalfPageDown()
-			return h, nil
-		case tea.KeyHome:
-			h.viewport.GotoTop()
-			return h, nil
-		case tea.KeyEnd:
-			h.viewport.GotoBottom()
-			return h, nil
-		}
-
-		// Check for character keys q/Q.
-		switch msg.String() {
-		case "q", "Q":
+		if activeKeys.Matches(msg, ActionClose) {
 			h.cancelled = true
 			return h, nil
 		}
+		activeKeys.scrollViewport(&h.viewport, msg)
 
 	case tea.WindowSizeMsg:
 		h.width = msg.Width
@@ -152,7 +148,7 @@ func (h *HelpOverlay) View() string {
 	content := border.Render(h.viewport.View())
 
 	// Hints at bottom
-	hints := styleTextDim.Render("↑↓/jk = scroll · q/Esc = close")
+	hints := styleTextDim.Render(activeKeys.scrollHints())
 	help := fmt.Sprintf("%s\n%s\n", content, styleKeyHint.Render(hints))
 
 	return help
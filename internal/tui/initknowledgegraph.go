@@ -45,7 +45,7 @@ func NewInitKnowledgeGraphOverlay(width, height int) *InitKnowledgeGraphOverlay
 
 // buildForm constructs the 10-question form.
 func (i *InitKnowledgeGraphOverlay) buildForm() {
-	i.form = huh.NewForm(
+	i.form = newForm(
 		huh.NewGroup(
 			huh.NewInput().
 				Title("Project name").
@@ -3,7 +3,6 @@ package tui
 import (
 	"strings"
 
-	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -17,13 +16,20 @@ const (
 // InputArea wraps a bubbles textarea.Model for multi-line input.
 // It uses alt+enter/ctrl+j for newlines and delegates enter handling
 // to the parent Model for submission.
+//
+// In vi mode Esc switches to a normal mode where letters are commands
+// (h/l/w/b/0/$ move, x/D/dd delete, i/a/I/A/o insert) instead of text.
 type InputArea struct {
 	textarea textarea.Model
+	vi       bool   // vi-style editing enabled
+	normal   bool   // in vi normal mode
+	pending  string // first key of a two-key normal-mode command
 }
 
 // NewInputArea creates a new InputArea with a multi-line text area.
-// Newlines are inserted with alt+enter or ctrl+j. The parent is
-// responsible for handling plain enter as submit.
+// Newlines are inserted with the newline binding of the active keymap
+// (alt+enter or ctrl+j by default). The parent is responsible for
+// handling submit.
 func NewInputArea() *InputArea {
 	ta := textarea.New()
 	ta.Placeholder = "Type a message..."
@@ -32,18 +38,30 @@ func NewInputArea() *InputArea {
 	ta.SetHeight(inputMinHeight)
 	ta.CharLimit = 0
 
-	// Remap InsertNewline from enter to alt+enter/ctrl+j so the parent
-	// can intercept plain enter for submission.
-	ta.KeyMap.InsertNewline = key.NewBinding(
-		key.WithKeys("alt+enter", "ctrl+j"),
-		key.WithHelp("alt+enter", "new line"),
-	)
+	// Remap InsertNewline away from enter so the parent can intercept
+	// plain enter for submission.
+	ta.KeyMap.InsertNewline = activeKeys.binding(ActionNewline, "new line")
 
 	ta.FocusedStyle.CursorLine = ta.FocusedStyle.CursorLine.UnsetBackground()
 
-	return &InputArea{textarea: ta}
+	return &InputArea{textarea: ta, vi: activeKeys.ViMode}
 }
 
+// SetViMode turns vi-style editing on or off, starting in insert mode.
+func (ia *InputArea) SetViMode(on bool) {
+	ia.vi = on
+	ia.normal = false
+	ia.pending = ""
+}
+
+// SetNewlineKeys rebinds the keys that insert a newline.
+func (ia *InputArea) SetNewlineKeys(k *Keymap) {
+	ia.textarea.KeyMap.InsertNewline = k.binding(ActionNewline, "new line")
+}
+
+// ViNormal reports whether the input is in vi normal mode.
+func (ia *InputArea) ViNormal() bool { return ia.vi && ia.normal }
+
 // Value returns the current text content.
 func (ia *InputArea) Value() string {
 	return ia.textarea.Value()
@@ -55,9 +73,12 @@ func (ia *InputArea) SetValue(s string) {
 }
 
 // Reset clears the text content and shrinks back to minimum height.
+// A vi-mode input returns to insert mode.
 func (ia *InputArea) Reset() {
 	ia.textarea.Reset()
 	ia.textarea.SetHeight(inputMinHeight)
+	ia.normal = false
+	ia.pending = ""
 }
 
 // Init initializes the textarea and returns its initial command.
@@ -69,12 +90,77 @@ func (ia *InputArea) Init() tea.Cmd {
 // content line count (between inputMinHeight and inputMaxHeight), and
 // returns any command.
 func (ia *InputArea) Update(msg tea.Msg) tea.Cmd {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && ia.vi && ia.viKey(keyMsg) {
+		ia.autoGrow()
+		return nil
+	}
 	var cmd tea.Cmd
 	ia.textarea, cmd = ia.textarea.Update(msg)
 	ia.autoGrow()
 	return cmd
 }
 
+// viKey applies a key in vi mode and reports whether it was consumed.
+// In normal mode non-letter keys (arrows, backspace) still edit as usual.
+func (ia *InputArea) viKey(msg tea.KeyMsg) bool {
+	if !ia.normal {
+		if msg.Type == tea.KeyEsc {
+			ia.normal = true
+			return true
+		}
+		return false
+	}
+	if msg.Type != tea.KeyRunes || msg.Paste {
+		return false
+	}
+	cmd := ia.pending + string(msg.Runes)
+	ia.pending = ""
+	switch cmd {
+	case "i":
+		ia.normal = false
+	case "a":
+		ia.send(tea.KeyMsg{Type: tea.KeyRight})
+		ia.normal = false
+	case "I":
+		ia.textarea.CursorStart()
+		ia.normal = false
+	case "A":
+		ia.textarea.CursorEnd()
+		ia.normal = false
+	case "o":
+		ia.textarea.CursorEnd()
+		ia.textarea.InsertString("\n")
+		ia.normal = false
+	case "h":
+		ia.send(tea.KeyMsg{Type: tea.KeyLeft})
+	case "l":
+		ia.send(tea.KeyMsg{Type: tea.KeyRight})
+	case "w":
+		ia.send(tea.KeyMsg{Type: tea.KeyRight, Alt: true})
+	case "b":
+		ia.send(tea.KeyMsg{Type: tea.KeyLeft, Alt: true})
+	case "0":
+		ia.textarea.CursorStart()
+	case "$":
+		ia.textarea.CursorEnd()
+	case "x":
+		ia.send(tea.KeyMsg{Type: tea.KeyDelete})
+	case "D":
+		ia.send(tea.KeyMsg{Type: tea.KeyCtrlK})
+	case "d":
+		ia.pending = "d"
+	case "dd":
+		ia.textarea.CursorEnd()
+		ia.send(tea.KeyMsg{Type: tea.KeyCtrlU})
+	}
+	// Other letters are swallowed: normal mode never types text.
+	return true
+}
+
+func (ia *InputArea) send(msg tea.KeyMsg) {
+	ia.textarea, _ = ia.textarea.Update(msg)
+}
+
 // autoGrow adjusts the textarea height to fit the content line count,
 // clamped between inputMinHeight and inputMaxHeight.
 func (ia *InputArea) autoGrow() {
@@ -0,0 +1,291 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// Action names a remappable key binding. The names are what [ui.keys] in
+// config.toml uses.
+type Action string
+
+// Input area and conversation actions.
+const (
+	ActionSubmit       Action = "submit"
+	ActionNewline      Action = "newline"
+	ActionHistoryPrev  Action = "history_prev"
+	ActionHistoryNext  Action = "history_next"
+	ActionComplete     Action = "complete"
+	ActionToggleTools  Action = "toggle_tools"
+	ActionExpandResult Action = "expand_result"
+	ActionToggleDiff   Action = "toggle_diff"
+	ActionToggleAgents Action = "toggle_agents"
+	ActionJumpError    Action = "jump_error"
+	ActionTogglePlan   Action = "toggle_plan"
+	ActionHelp         Action = "help"
+	ActionQuit         Action = "quit"
+)
+
+// Tab actions.
+const (
+	ActionTabNew   Action = "tab_new"
+	ActionTabClose Action = "tab_close"
+	ActionTabNext  Action = "tab_next"
+	ActionTabPrev  Action = "tab_prev"
+)
+
+// Overlay actions, shared by every list and scrolling overlay.
+const (
+	ActionUp       Action = "up"
+	ActionDown     Action = "down"
+	ActionPageUp   Action = "page_up"
+	ActionPageDown Action = "page_down"
+	ActionTop      Action = "top"
+	ActionBottom   Action = "bottom"
+	ActionSelect   Action = "select"
+	ActionClose    Action = "close"
+)
+
+// Approval prompt actions.
+const (
+	ActionApprove       Action = "approve"
+	ActionDeny          Action = "deny"
+	ActionApproveAlways Action = "approve_always"
+	ActionDenyAlways    Action = "deny_always"
+	ActionApproveBatch  Action = "approve_batch"
+	ActionHunkNext      Action = "hunk_next"
+	ActionHunkPrev      Action = "hunk_prev"
+	ActionHunkToggle    Action = "hunk_toggle"
+	ActionDiffLayout    Action = "diff_layout"
+	ActionEditContent   Action = "edit_content"
+)
+
+// defaultBindings are the stock key bindings. Keys use bubbletea's
+// KeyMsg.String() names, e.g. "ctrl+p", "alt+enter", "pgup", "space".
+var defaultBindings = map[Action][]string{
+	ActionSubmit:       {"enter"},
+	ActionNewline:      {"alt+enter", "ctrl+j"},
+	ActionHistoryPrev:  {"ctrl+p"},
+	ActionHistoryNext:  {"ctrl+n"},
+	ActionComplete:     {"tab"},
+	ActionToggleTools:  {"ctrl+t"},
+	ActionExpandResult: {"ctrl+e"},
+	ActionToggleDiff:   {"ctrl+g"},
+	ActionToggleAgents: {"ctrl+a"},
+	ActionJumpError:    {"ctrl+l"},
+	ActionTogglePlan:   {"ctrl+f"},
+	ActionHelp:         {"?"},
+	ActionQuit:         {"ctrl+c"},
+
+	ActionTabNew:   {"alt+t"},
+	ActionTabClose: {"alt+w"},
+	ActionTabNext:  {"alt+right"},
+	ActionTabPrev:  {"alt+left"},
+
+	ActionUp:       {"up", "k"},
+	ActionDown:     {"down", "j"},
+	ActionPageUp:   {"pgup"},
+	ActionPageDown: {"pgdown"},
+	ActionTop:      {"home"},
+	ActionBottom:   {"end"},
+	ActionSelect:   {"enter"},
+	ActionClose:    {"esc", "q", "Q"},
+
+	ActionApprove:       {"y", "Y"},
+	ActionDeny:          {"n", "N"},
+	ActionApproveAlways: {"a", "A"},
+	ActionDenyAlways:    {"d", "D"},
+	ActionApproveBatch:  {"b", "B"},
+	ActionHunkNext:      {"j", "tab"},
+	ActionHunkPrev:      {"k", "shift+tab"},
+	ActionHunkToggle:    {" "},
+	ActionDiffLayout:    {"s"},
+	ActionEditContent:   {"e"},
+}
+
+// Keymap maps actions to the keys that trigger them.
+type Keymap struct {
+	bindings map[Action][]string
+	// ViMode enables vi-style normal/insert editing in the input area;
+	// see InputArea.
+	ViMode bool
+}
+
+// DefaultKeymap returns the stock bindings.
+func DefaultKeymap() *Keymap {
+	k := &Keymap{bindings: make(map[Action][]string, len(defaultBindings))}
+	for a, keys := range defaultBindings {
+		k.bindings[a] = append([]string(nil), keys...)
+	}
+	return k
+}
+
+// NewKeymap returns the default keymap with overrides applied. overrides
+// maps action names to their new keys and replaces the defaults of each
+// action it lists.
+func NewKeymap(overrides map[string][]string, viMode bool) (*Keymap, error) {
+	k := DefaultKeymap()
+	k.ViMode = viMode
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a := Action(name)
+		if _, ok := defaultBindings[a]; !ok {
+			return nil, fmt.Errorf("unknown key action %q", name)
+		}
+		keys := overrides[name]
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("key action %q has no keys", name)
+		}
+		normalized := make([]string, len(keys))
+		for i, s := range keys {
+			normalized[i] = normalizeKey(s)
+		}
+		k.bindings[a] = normalized
+	}
+	return k, nil
+}
+
+// normalizeKey accepts the spellings people write in config files for
+// keys whose KeyMsg.String() differs.
+func normalizeKey(s string) string {
+	switch lower := strings.ToLower(strings.TrimSpace(s)); lower {
+	case "space":
+		return " "
+	case "escape":
+		return "esc"
+	case "return":
+		return "enter"
+	case "pageup":
+		return "pgup"
+	case "pagedown":
+		return "pgdown"
+	default:
+		if len(s) == 1 {
+			return s // keep the case of single characters
+		}
+		return lower
+	}
+}
+
+// Keys returns the keys bound to a.
+func (k *Keymap) Keys(a Action) []string { return k.bindings[a] }
+
+// Matches reports whether msg triggers a.
+func (k *Keymap) Matches(msg tea.KeyMsg, a Action) bool {
+	s := msg.String()
+	for _, bound := range k.bindings[a] {
+		if bound == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Label renders the keys of a for help text, e.g. "↑/k".
+func (k *Keymap) Label(a Action) string {
+	keys := k.bindings[a]
+	labels := make([]string, 0, len(keys))
+	seen := make(map[string]bool)
+	for _, s := range keys {
+		l := keyLabel(s)
+		if seen[strings.ToLower(l)] {
+			continue // y/Y read as one key
+		}
+		seen[strings.ToLower(l)] = true
+		labels = append(labels, l)
+	}
+	return strings.Join(labels, "/")
+}
+
+var keyLabels = map[string]string{
+	"up": "↑", "down": "↓", "left": "←", "right": "→",
+	"enter": "Enter", "esc": "Esc", "tab": "Tab", "shift+tab": "Shift+Tab",
+	"pgup": "PgUp", "pgdown": "PgDn", "home": "Home", "end": "End", " ": "Space",
+}
+
+func keyLabel(s string) string {
+	if l, ok := keyLabels[s]; ok {
+		return l
+	}
+	if strings.Contains(s, "+") {
+		parts := strings.Split(s, "+")
+		for i, p := range parts {
+			if l, ok := keyLabels[p]; ok {
+				parts[i] = l
+			} else if len(p) > 1 {
+				parts[i] = strings.ToUpper(p[:1]) + p[1:]
+			} else {
+				parts[i] = strings.ToUpper(p)
+			}
+		}
+		return strings.Join(parts, "+")
+	}
+	return s
+}
+
+// binding adapts a to a bubbles key.Binding.
+func (k *Keymap) binding(a Action, help string) key.Binding {
+	return key.NewBinding(key.WithKeys(k.bindings[a]...), key.WithHelp(k.Label(a), help))
+}
+
+// huhKeyMap applies the overlay navigation bindings to huh forms, so the
+// config form and model picker move like every other overlay.
+func (k *Keymap) huhKeyMap() *huh.KeyMap {
+	km := huh.NewDefaultKeyMap()
+	km.Select.Up = k.binding(ActionUp, "up")
+	km.Select.Down = k.binding(ActionDown, "down")
+	km.Select.GotoTop = k.binding(ActionTop, "first")
+	km.Select.GotoBottom = k.binding(ActionBottom, "last")
+	km.Select.Submit = k.binding(ActionSelect, "select")
+	km.MultiSelect.Up = km.Select.Up
+	km.MultiSelect.Down = km.Select.Down
+	km.Quit = k.binding(ActionQuit, "quit")
+	return km
+}
+
+// activeKeys is the active keymap, set by ApplyKeymap.
+var activeKeys = DefaultKeymap()
+
+// ApplyKeymap makes k the active keymap for the input area and overlays
+// created afterwards.
+func ApplyKeymap(k *Keymap) { activeKeys = k }
+
+// ActiveKeymap returns the active keymap.
+func ActiveKeymap() *Keymap { return activeKeys }
+
+// scrollViewport applies the overlay scrolling actions to vp and reports
+// whether msg was one of them.
+func (k *Keymap) scrollViewport(vp *viewport.Model, msg tea.KeyMsg) bool {
+	switch {
+	case k.Matches(msg, ActionUp):
+		vp.ScrollUp(1)
+	case k.Matches(msg, ActionDown):
+		vp.ScrollDown(1)
+	case k.Matches(msg, ActionPageUp):
+		vp.HalfPageUp()
+	case k.Matches(msg, ActionPageDown):
+		vp.HalfPageDown()
+	case k.Matches(msg, ActionTop):
+		vp.GotoTop()
+	case k.Matches(msg, ActionBottom):
+		vp.GotoBottom()
+	default:
+		return false
+	}
+	return true
+}
+
+// scrollHints is the hint line of the scrolling overlays.
+func (k *Keymap) scrollHints() string {
+	return fmt.Sprintf("%s/%s = scroll · %s = close", k.Label(ActionUp), k.Label(ActionDown), k.Label(ActionClose))
+}
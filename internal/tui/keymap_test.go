@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runeKey(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestDefaultKeymapMatches(t *testing.T) {
+	k := DefaultKeymap()
+	assert.True(t, k.Matches(tea.KeyMsg{Type: tea.KeyEnter}, ActionSubmit))
+	assert.True(t, k.Matches(tea.KeyMsg{Type: tea.KeyCtrlP}, ActionHistoryPrev))
+	assert.True(t, k.Matches(runeKey("Y"), ActionApprove))
+	assert.True(t, k.Matches(tea.KeyMsg{Type: tea.KeySpace}, ActionHunkToggle))
+	assert.False(t, k.Matches(runeKey("x"), ActionApprove))
+}
+
+func TestNewKeymapOverrides(t *testing.T) {
+	k, err := NewKeymap(map[string][]string{
+		"submit":      {"Ctrl+S"},
+		"hunk_toggle": {"space"},
+		"close":       {"Escape"},
+	}, false)
+	require.NoError(t, err)
+
+	assert.True(t, k.Matches(tea.KeyMsg{Type: tea.KeyCtrlS}, ActionSubmit))
+	assert.False(t, k.Matches(tea.KeyMsg{Type: tea.KeyEnter}, ActionSubmit))
+	assert.True(t, k.Matches(tea.KeyMsg{Type: tea.KeySpace}, ActionHunkToggle))
+	assert.True(t, k.Matches(tea.KeyMsg{Type: tea.KeyEsc}, ActionClose))
+	assert.False(t, k.Matches(runeKey("q"), ActionClose))
+	// Unlisted actions keep their defaults, and the defaults are untouched.
+	assert.True(t, k.Matches(tea.KeyMsg{Type: tea.KeyCtrlP}, ActionHistoryPrev))
+	assert.True(t, DefaultKeymap().Matches(tea.KeyMsg{Type: tea.KeyEnter}, ActionSubmit))
+}
+
+func TestNewKeymapErrors(t *testing.T) {
+	_, err := NewKeymap(map[string][]string{"fly": {"f"}}, false)
+	assert.ErrorContains(t, err, "unknown key action")
+
+	_, err = NewKeymap(map[string][]string{"submit": {}}, false)
+	assert.ErrorContains(t, err, "has no keys")
+}
+
+func TestKeymapLabel(t *testing.T) {
+	k := DefaultKeymap()
+	assert.Equal(t, "↑/k", k.Label(ActionUp))
+	assert.Equal(t, "y", k.Label(ActionApprove))
+	assert.Equal(t, "Ctrl+P", k.Label(ActionHistoryPrev))
+	assert.Equal(t, "Alt+Enter/Ctrl+J", k.Label(ActionNewline))
+}
+
+func TestHelpOverlayFollowsKeymap(t *testing.T) {
+	defer ApplyKeymap(DefaultKeymap())
+	k, err := NewKeymap(map[string][]string{"toggle_plan": {"ctrl+b"}, "close": {"x"}}, true)
+	require.NoError(t, err)
+	ApplyKeymap(k)
+
+	h := NewHelpOverlay(80, 60)
+	view := h.View()
+	assert.Contains(t, view, "Ctrl+B")
+	assert.Contains(t, view, "Vi Mode")
+
+	h.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, h.Done(), "esc was remapped away")
+	h.Update(runeKey("x"))
+	assert.True(t, h.Done())
+}
+
+func TestApprovalPromptRemappedKeys(t *testing.T) {
+	defer ApplyKeymap(DefaultKeymap())
+	k, err := NewKeymap(map[string][]string{"approve": {"ctrl+y"}}, false)
+	require.NoError(t, err)
+	ApplyKeymap(k)
+
+	p := NewApprovalPrompt("file", `{"path":"a.go"}`, "", 80, []ApprovalResult{ApprovalYes, ApprovalNo}, false)
+	assert.False(t, p.HandleKey(runeKey("y")))
+	assert.True(t, p.HandleKey(tea.KeyMsg{Type: tea.KeyCtrlY}))
+	assert.Equal(t, ApprovalYes, p.Result())
+	assert.Contains(t, p.View(), "[Ctrl+Y]")
+}
+
+func TestInputAreaViMode(t *testing.T) {
+	ia := NewInputArea()
+	ia.SetViMode(true)
+	ia.textarea.Focus()
+
+	ia.Update(runeKey("hello world"))
+	assert.False(t, ia.ViNormal())
+
+	ia.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.True(t, ia.ViNormal())
+
+	// Letters are commands in normal mode, not text.
+	ia.Update(runeKey("0"))
+	ia.Update(runeKey("x"))
+	assert.Equal(t, "ello world", ia.Value())
+	ia.Update(runeKey("z"))
+	assert.Equal(t, "ello world", ia.Value())
+
+	ia.Update(runeKey("A"))
+	assert.False(t, ia.ViNormal())
+	ia.Update(runeKey("!"))
+	assert.Equal(t, "ello world!", ia.Value())
+
+	ia.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	ia.Update(runeKey("d"))
+	ia.Update(runeKey("d"))
+	assert.Equal(t, "", ia.Value())
+
+	ia.Reset()
+	assert.False(t, ia.ViNormal())
+}
+
+func TestInputAreaWithoutViModeTypesEsc(t *testing.T) {
+	ia := NewInputArea()
+	ia.textarea.Focus()
+	ia.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, ia.ViNormal())
+	ia.Update(runeKey("x"))
+	assert.Equal(t, "x", ia.Value())
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/julianshen/rubichan/internal/skills"
 	"github.com/julianshen/rubichan/internal/store"
 	"github.com/julianshen/rubichan/internal/terminal"
+	"github.com/julianshen/rubichan/internal/tools"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
 )
 
@@ -48,6 +49,7 @@ type approvalRequest struct {
 	input         string
 	options       []ApprovalResult
 	responseValue chan ApprovalResult
+	editValue     chan agent.ApprovalEdit // optional; receives diff review edits
 }
 
 // approvalRequestMsg is the Bubble Tea message type for approval requests.
@@ -84,6 +86,8 @@ const (
 	// StateFetchingModels indicates the TUI is querying a provider (Ollama)
 	// for its available models before opening the picker.
 	StateFetchingModels
+	// StateSearchOverlay indicates the TUI is showing session search results.
+	StateSearchOverlay
 )
 
 // Model is the Bubble Tea model for the Rubichan TUI.
@@ -116,6 +120,9 @@ type Model struct {
 	alwaysApproved    sync.Map
 	alwaysDenied      sync.Map
 	approvalCh        chan approvalRequest
+	askCh             chan askRequest
+	pendingAsk        *askRequest // question shown in the overlay
+	queuedAsk         *askRequest // question waiting for another overlay to close
 	assistantStartIdx int
 	assistantEndIdx   int
 	diffSummary       string
@@ -148,9 +155,11 @@ type Model struct {
 	activeSkills      []string
 	agentPanelVisible bool
 	planPanelVisible  bool
+	todoPanelShown    bool // a todo list has opened the plan panel
 	plainMode         bool
 	debug             bool
 	lastPrompt        string
+	attachments       []agentsdk.ContentBlock // pasted media sent with the next message
 	termCaps          *terminal.Caps
 	cmuxClient        cmux.Caller // nil when not running in cmux
 	sessionState      *session.State
@@ -220,6 +229,7 @@ func NewModel(a *agent.Agent, appName, modelName string, maxTurns int, configPat
 		turnCache:         cache,
 		statusBar:         sb,
 		approvalCh:        make(chan approvalRequest),
+		askCh:             make(chan askRequest),
 		state:             StateInput,
 		appName:           appName,
 		modelName:         modelName,
@@ -443,11 +453,35 @@ func (m *Model) notifyIfSupported(message string) {
 	}
 }
 
+// applyUIConfig re-applies the theme and keymap from the config after
+// /config saves it. Messages already rendered keep their old colors.
+func (m *Model) applyUIConfig() {
+	if m.cfg == nil {
+		return
+	}
+	if err := ApplyUIConfig(m.cfg.UI, m.configPath); err != nil {
+		m.content.WriteString(fmt.Sprintf("[theme not applied: %s]\n", err))
+		m.setContentAndAutoScroll()
+		return
+	}
+	m.input.SetViMode(activeKeys.ViMode)
+	m.input.SetNewlineKeys(activeKeys)
+	m.refreshRenderers()
+	m.viewport.SetContent(m.viewportContent())
+}
+
 func (m *Model) refreshRenderers() {
 	darkBg := true
 	if m.termCaps != nil {
 		darkBg = m.termCaps.DarkBackground
 	}
+	// A theme with a fixed background overrides terminal detection.
+	switch CurrentTheme().Markdown {
+	case "dark":
+		darkBg = true
+	case "light":
+		darkBg = false
+	}
 	mdRenderer, err := NewMarkdownRenderer(m.width, darkBg)
 	if err == nil {
 		m.mdRenderer = mdRenderer
@@ -662,15 +696,23 @@ func (m *Model) MakeApprovalFunc() agent.ApprovalFunc {
 
 // MakeUIRequestHandler returns a generalized UI interaction handler for the
 // current model. Approval requests are rendered with the existing inline
-// approval prompt and translated into structured action IDs.
+// approval prompt and translated into structured action IDs; ask_user
+// questions open the question overlay.
 func (m *Model) MakeUIRequestHandler() agent.UIRequestHandler {
 	return agent.UIRequestFunc(func(ctx context.Context, req agent.UIRequest) (agent.UIResponse, error) {
+		if req.Metadata["tool"] == tools.AskUserName && req.Kind != agent.UIKindApproval {
+			return m.askUser(ctx, req)
+		}
 		if req.Kind != agent.UIKindApproval {
 			return agent.UIResponse{}, fmt.Errorf("unsupported UI request kind: %s", req.Kind)
 		}
 
 		tool := req.Metadata["tool"]
 		input := req.Metadata["input"]
+		if len(req.Input) > 0 {
+			// The metadata copy is truncated; the diff review needs it all.
+			input = string(req.Input)
+		}
 		if tool == "" {
 			// Fallback for non-standard adapters that omit tool metadata.
 			tool = req.Title
@@ -680,21 +722,33 @@ func (m *Model) MakeUIRequestHandler() agent.UIRequestHandler {
 		}
 
 		respCh := make(chan ApprovalResult, 1)
+		editCh := make(chan agent.ApprovalEdit, 1)
 		m.approvalCh <- approvalRequest{
 			tool:          tool,
 			input:         input,
 			options:       optionsFromUIActions(req.Actions),
 			responseValue: respCh,
+			editValue:     editCh,
 		}
 
 		select {
 		case <-ctx.Done():
 			return agent.UIResponse{}, ctx.Err()
 		case result := <-respCh:
-			return agent.UIResponse{
+			resp := agent.UIResponse{
 				RequestID: req.ID,
 				ActionID:  actionIDFromApprovalResult(result, req.Actions),
-			}, nil
+			}
+			select {
+			case edit := <-editCh:
+				values, err := json.Marshal(edit)
+				if err != nil {
+					return agent.UIResponse{}, fmt.Errorf("encoding approval edit: %w", err)
+				}
+				resp.Values = values
+			default:
+			}
+			return resp, nil
 		}
 	})
 }
@@ -867,6 +921,9 @@ func (m *Model) handleCommandParts(line string, parts []string) tea.Cmd {
 	if startCmd := m.maybeStartRalphLoop(); startCmd != nil {
 		return tea.Batch(startCmd, m.spinner.Tick)
 	}
+	if result.Prompt != "" {
+		return m.startCommandPrompt(line, result)
+	}
 
 	switch result.Action {
 	case commands.ActionQuit:
@@ -928,6 +985,21 @@ func (m *Model) handleCommandParts(line string, parts []string) tea.Cmd {
 		m.activeOverlay = NewSessionResumeOverlay(sessions)
 		m.state = StateResumeOverlay
 		return nil
+	case commands.ActionOpenSearch:
+		if m.sessionStore == nil {
+			m.content.WriteString("Session store not available.\n")
+			m.setContentAndAutoScroll()
+			return nil
+		}
+		hits, err := m.sessionStore.SearchMessages(store.SearchQuery{Text: result.Query, Limit: maxSearchHits})
+		if err != nil {
+			m.content.WriteString(fmt.Sprintf("Search failed: %s\n", err))
+			m.setContentAndAutoScroll()
+			return nil
+		}
+		m.activeOverlay = NewSessionSearchOverlay(result.Query, hits, m.sessionStore, m.width)
+		m.state = StateSearchOverlay
+		return nil
 	case commands.ActionOpenModelPicker:
 		if m.cfg == nil {
 			m.content.WriteString("No config available\n")
@@ -1054,6 +1126,44 @@ func (m *Model) maybeStartRalphLoop() tea.Cmd {
 	return m.startTurn(m.agent, prompt)
 }
 
+// startCommandPrompt sends the prompt a custom command expanded to as the
+// next turn. The transcript shows the command line the user typed, not the
+// expansion, which may inline whole files.
+func (m *Model) startCommandPrompt(line string, result commands.Result) tea.Cmd {
+	if m.agent == nil {
+		m.content.WriteString(persona.ErrorMessage("no agent configured"))
+		m.setContentAndAutoScroll()
+		return nil
+	}
+	m.diffSummary = ""
+	m.diffExpanded = false
+	m.toolCallArgs = nil
+	m.toolApprovalCount = make(map[string]int)
+	m.content.WriteString(styleUserPrompt.Render("❯ ") + line + "\n")
+	m.setContentAndAutoScroll()
+	m.lastPrompt = line
+	if m.sessionState != nil {
+		m.sessionState.ResetForPrompt(line)
+	}
+	m.emitSessionEvent(session.NewTurnStartedEvent(line, m.modelName))
+	m.emitSessionEvent(session.NewCheckpointCreatedEvent(fmt.Sprintf("turn-%d", m.turnCount+1), "turn_started"))
+	m.assistantStartIdx = m.content.LenWithWidth(m.width)
+	m.assistantEndIdx = m.assistantStartIdx
+	m.state = StateStreaming
+	m.thinkingMsg = persona.ThinkingMessage()
+	m.statusBar.ClearElapsed()
+	m.statusBar.ClearErrorCount()
+	m.turnStartTime = time.Now()
+
+	attachments := m.attachments
+	m.attachments = nil
+	return tea.Batch(m.startTurnWithOptions(m.agent, result.Prompt, agent.TurnOptions{
+		Attachments:  attachments,
+		Model:        result.Model,
+		AllowedTools: result.AllowedTools,
+	}), m.spinner.Tick)
+}
+
 // runBootstrap executes the bootstrap and sends progress updates.
 // The context can be cancelled via the bootstrapCancel field to interrupt the process.
 func (m *Model) runBootstrap(ctx context.Context, profile *knowledgegraph.BootstrapProfile) tea.Msg {
@@ -1469,6 +1469,48 @@ func TestModelMakeUIRequestHandlerApprovalAlways(t *testing.T) {
 	assert.True(t, ok, "always-approved cache should be set after 'a'")
 }
 
+func TestModelMakeUIRequestHandlerReturnsApprovalEdit(t *testing.T) {
+	m := NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)
+	handler := m.MakeUIRequestHandler()
+
+	resultCh := make(chan agent.UIResponse, 1)
+	go func() {
+		resp, err := handler.Request(context.Background(), agent.UIRequest{
+			ID:      "req-1",
+			Kind:    agent.UIKindApproval,
+			Actions: []agent.UIAction{{ID: "allow", Label: "Allow"}, {ID: "deny", Label: "Deny"}},
+			Input:   json.RawMessage(`{"operation":"write","path":"a.txt","content":"full"}`),
+			Metadata: map[string]string{
+				"tool":  "file",
+				"input": `{"operation":"write","path":"a.txt","content":"fu...`,
+			},
+		})
+		if err == nil {
+			resultCh <- resp
+		}
+	}()
+
+	approvalMsg := m.waitForApproval()()
+	assert.Equal(t, `{"operation":"write","path":"a.txt","content":"full"}`, approvalMsg.(approvalRequestMsg).input,
+		"the untruncated input is used")
+	updated, _ := m.Update(approvalMsg)
+	m = updated.(*Model)
+
+	edit := &agent.ApprovalEdit{Input: json.RawMessage(`{"operation":"write","path":"a.txt","content":"part"}`), Note: "rejected"}
+	m.activeOverlay = nil
+	m.processOverlayResult(ApprovalDecision{Result: ApprovalYes, Edit: edit})
+
+	select {
+	case resp := <-resultCh:
+		assert.Equal(t, "allow", resp.ActionID)
+		var got agent.ApprovalEdit
+		require.NoError(t, json.Unmarshal(resp.Values, &got))
+		assert.Equal(t, *edit, got)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for UI response")
+	}
+}
+
 func TestModelMakeUIRequestHandlerUsesAlwaysDenied(t *testing.T) {
 	m := NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)
 	m.alwaysDenied.Store("shell", true)
@@ -3290,3 +3332,37 @@ func TestRenderWithSelection_PreservesANSICodesInUnselectedText(t *testing.T) {
 	// The result should contain ANSI codes (either original or from selection style)
 	assert.Greater(t, len(result), len("hello world!"), "result should contain ANSI codes")
 }
+
+func TestModelPlanUpdateDrivesPlanPanel(t *testing.T) {
+	m := NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)
+	m.state = StateStreaming
+	m.width, m.height = 100, 40
+	var events []session.Event
+	m.eventSink = session.SinkFunc(func(evt session.Event) {
+		events = append(events, evt)
+	})
+
+	_, _ = m.handleTurnEvent(TurnEventMsg(agent.TurnEvent{
+		Type: "plan_update",
+		Plan: []agent.PlanEntry{
+			{Content: "Write the handler", Status: "completed"},
+			{Content: "Add tests", Status: "in_progress"},
+		},
+	}))
+
+	assert.True(t, m.planPanelVisible, "the first todo list opens the plan panel")
+	view := m.View()
+	assert.Contains(t, view, "Write the handler")
+	assert.Contains(t, view, "Add tests")
+	require.Len(t, events, 1)
+	assert.Equal(t, session.EventTypePlanUpdated, events[0].Type)
+
+	// Once the user hides the panel, later updates leave it hidden.
+	m.planPanelVisible = false
+	_, _ = m.handleTurnEvent(TurnEventMsg(agent.TurnEvent{
+		Type: "plan_update",
+		Plan: []agent.PlanEntry{{Content: "Add tests", Status: "completed"}},
+	}))
+	assert.False(t, m.planPanelVisible)
+	assert.Equal(t, session.PlanStatusCompleted, m.sessionState.Plan()[0].Status)
+}
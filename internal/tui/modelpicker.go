@@ -50,7 +50,7 @@ func NewModelPicker(models []ModelChoice) *ModelPicker {
 		Options(opts...).
 		Value(&p.selected)
 
-	p.form = huh.NewForm(huh.NewGroup(sel))
+	p.form = newForm(huh.NewGroup(sel))
 	return p
 }
 
@@ -26,7 +26,7 @@ type ModelTextInputOverlay struct {
 func NewModelTextInputOverlay(currentModel string) (*ModelTextInputOverlay, tea.Cmd) {
 	o := &ModelTextInputOverlay{value: currentModel}
 	input := huh.NewInput().Title("Model name").Value(&o.value)
-	o.form = huh.NewForm(huh.NewGroup(input))
+	o.form = newForm(huh.NewGroup(input))
 	return o, o.form.Init()
 }
 
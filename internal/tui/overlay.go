@@ -6,6 +6,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/julianshen/rubichan/internal/agent"
 	"github.com/julianshen/rubichan/internal/knowledgegraph"
 )
 
@@ -38,31 +39,45 @@ type InitKnowledgeGraphResult struct {
 	Profile *knowledgegraph.BootstrapProfile
 }
 
+// resolveApproval answers the pending approval request. An edit from the
+// diff review is delivered before the result so the waiting handler sees it.
+func (m *Model) resolveApproval(r ApprovalResult, edit *agent.ApprovalEdit) tea.Cmd {
+	if m.pendingApproval == nil {
+		m.state = StateInput
+		return nil
+	}
+	if r == ApprovalAlways {
+		m.alwaysDenied.Delete(m.pendingApproval.tool)
+		m.alwaysApproved.Store(m.pendingApproval.tool, true)
+	}
+	if r == ApprovalDenyAlways {
+		m.alwaysApproved.Delete(m.pendingApproval.tool)
+		m.alwaysDenied.Store(m.pendingApproval.tool, true)
+	}
+	if edit != nil && m.pendingApproval.editValue != nil {
+		m.pendingApproval.editValue <- *edit
+	}
+	m.pendingApproval.responseValue <- r
+	m.approvalPrompt = nil
+	m.pendingApproval = nil
+	m.state = StateStreaming
+	return m.waitForEvent()
+}
+
 // processOverlayResult handles the typed result from a completed overlay.
 // Each case manages its own state transition and returns any follow-up command.
 func (m *Model) processOverlayResult(result any) tea.Cmd {
 	switch r := result.(type) {
 	case ApprovalResult:
-		if m.pendingApproval == nil {
-			m.state = StateInput
-			return nil
-		}
-		if r == ApprovalAlways {
-			m.alwaysDenied.Delete(m.pendingApproval.tool)
-			m.alwaysApproved.Store(m.pendingApproval.tool, true)
-		}
-		if r == ApprovalDenyAlways {
-			m.alwaysApproved.Delete(m.pendingApproval.tool)
-			m.alwaysDenied.Store(m.pendingApproval.tool, true)
-		}
-		m.pendingApproval.responseValue <- r
-		m.approvalPrompt = nil
-		m.pendingApproval = nil
-		m.state = StateStreaming
-		return m.waitForEvent()
+		return m.resolveApproval(r, nil)
+	case ApprovalDecision:
+		return m.resolveApproval(r.Result, r.Edit)
+	case AskUserResult:
+		return m.resolveAsk(r.Response)
 	case ConfigResult:
 		m.configForm = nil
 		m.state = StateInput
+		m.applyUIConfig()
 		return nil
 	case WikiResult:
 		m.wikiForm = nil
@@ -117,6 +132,25 @@ func (m *Model) processOverlayResult(result any) tea.Cmd {
 		m.setContentAndAutoScroll()
 		m.state = StateInput
 		return nil
+	case SessionSearchResult:
+		m.state = StateInput
+		if m.agent == nil {
+			m.content.WriteString("No agent available to resume session.\n")
+			m.setContentAndAutoScroll()
+			return nil
+		}
+		if !r.Fork {
+			return m.processOverlayResult(SessionResumeResult{SessionID: r.SessionID})
+		}
+		newID, err := m.agent.ForkSessionAtTurn(context.Background(), r.SessionID, r.Seq)
+		if err != nil {
+			m.content.WriteString(fmt.Sprintf("Failed to fork session: %s\n", err))
+			m.setContentAndAutoScroll()
+			return nil
+		}
+		m.content.WriteString(fmt.Sprintf("Forked session %s at message %d into %s. Conversation history restored.\n", r.SessionID, r.Seq, newID))
+		m.setContentAndAutoScroll()
+		return nil
 	case nil:
 		// Overlay was cancelled (e.g., Escape pressed).
 		// Defensive: unblock agent if approval was somehow cancelled.
@@ -20,9 +20,9 @@ func TestEstimateCostKnownModels(t *testing.T) {
 	cost := EstimateCost("claude-sonnet-4-5", 1_000_000, 1_000_000)
 	assert.InDelta(t, 18.0, cost, 0.001)
 
-	// claude-opus-4-5: $15/M input, $75/M output
+	// claude-opus-4-5: $5/M input, $25/M output
 	cost = EstimateCost("claude-opus-4-5", 1_000_000, 1_000_000)
-	assert.InDelta(t, 90.0, cost, 0.001)
+	assert.InDelta(t, 30.0, cost, 0.001)
 
 	// gpt-4o-mini: $0.15/M input, $0.60/M output
 	cost = EstimateCost("gpt-4o-mini", 1_000_000, 1_000_000)
@@ -41,42 +41,29 @@ func (o *SessionResumeOverlay) Update(msg tea.Msg) (Overlay, tea.Cmd) {
 		return o, nil
 	}
 
-	switch keyMsg.Type {
-	case tea.KeyUp:
+	switch {
+	case activeKeys.Matches(keyMsg, ActionUp):
 		if o.index > 0 {
 			o.index--
 		}
-	case tea.KeyDown:
+	case activeKeys.Matches(keyMsg, ActionDown):
 		if o.index < len(o.sessions)-1 {
 			o.index++
 		}
-	case tea.KeyEnter:
+	case activeKeys.Matches(keyMsg, ActionSelect):
 		if o.index >= 0 && o.index < len(o.sessions) {
 			o.result = &SessionResumeResult{SessionID: o.sessions[o.index].ID}
 		}
 		o.done = true
-	case tea.KeyEsc:
+	case activeKeys.Matches(keyMsg, ActionClose):
 		o.done = true
-	default:
-		switch keyMsg.String() {
-		case "k":
-			if o.index > 0 {
-				o.index--
-			}
-		case "j":
-			if o.index < len(o.sessions)-1 {
-				o.index++
-			}
-		case "q":
-			o.done = true
-		}
 	}
 	return o, nil
 }
 
 func (o *SessionResumeOverlay) View() string {
 	if len(o.sessions) == 0 {
-		return "No previous sessions found.\nPress Esc to close.\n"
+		return "No previous sessions found.\nPress " + activeKeys.Label(ActionClose) + " to close.\n"
 	}
 
 	var b strings.Builder
@@ -100,7 +87,9 @@ func (o *SessionResumeOverlay) View() string {
 		b.WriteString(fmt.Sprintf("%s%s  %s\n", marker, title, sessionTimeAgo(s.UpdatedAt)))
 	}
 
-	b.WriteString("\nUse arrows to navigate, Enter to resume, Esc to cancel\n")
+	k := activeKeys
+	fmt.Fprintf(&b, "\nUse %s/%s to navigate, %s to resume, %s to cancel\n",
+		k.Label(ActionUp), k.Label(ActionDown), k.Label(ActionSelect), k.Label(ActionClose))
 	return b.String()
 }
 
@@ -0,0 +1,245 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+// maxSearchHits is the maximum number of matches shown in the search overlay.
+const maxSearchHits = 20
+
+// maxPreviewLines caps the turn preview of the search overlay.
+const maxPreviewLines = 30
+
+// SessionSearchResult carries the session chosen in the search overlay.
+// Fork asks for a new session forked from the turn containing message Seq
+// instead of resuming the session itself.
+type SessionSearchResult struct {
+	SessionID string
+	Seq       int
+	Fork      bool
+}
+
+// SessionSearchOverlay lists the stored messages matching a search. Enter
+// shows the matching turn; r resumes the session and f forks it from that
+// turn, from the list or the turn.
+type SessionSearchOverlay struct {
+	query   string
+	hits    []store.SearchHit
+	store   *store.Store
+	index   int
+	preview []string // the selected hit's turn, nil while listing
+	width   int
+	done    bool
+	result  *SessionSearchResult // nil when cancelled
+}
+
+// NewSessionSearchOverlay creates a search overlay for hits. s, when
+// non-nil, supplies the turns shown by the preview.
+func NewSessionSearchOverlay(query string, hits []store.SearchHit, s *store.Store, width int) *SessionSearchOverlay {
+	return &SessionSearchOverlay{query: query, hits: hits, store: s, width: width}
+}
+
+func (o *SessionSearchOverlay) Update(msg tea.Msg) (Overlay, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return o, nil
+	}
+	if len(o.hits) == 0 {
+		o.done = true
+		return o, nil
+	}
+
+	hit := o.hits[o.index]
+	switch {
+	case keyMsg.String() == "r":
+		o.result = &SessionSearchResult{SessionID: hit.SessionID, Seq: hit.Seq}
+		o.done = true
+	case keyMsg.String() == "f":
+		o.result = &SessionSearchResult{SessionID: hit.SessionID, Seq: hit.Seq, Fork: true}
+		o.done = true
+	case activeKeys.Matches(keyMsg, ActionClose):
+		if o.preview != nil {
+			o.preview = nil
+		} else {
+			o.done = true
+		}
+	case o.preview != nil:
+		// The turn view only answers r, f and close.
+	case activeKeys.Matches(keyMsg, ActionUp):
+		if o.index > 0 {
+			o.index--
+		}
+	case activeKeys.Matches(keyMsg, ActionDown):
+		if o.index < len(o.hits)-1 {
+			o.index++
+		}
+	case activeKeys.Matches(keyMsg, ActionSelect):
+		o.preview = o.loadTurn(hit)
+	}
+	return o, nil
+}
+
+// loadTurn renders the turn containing hit: from the user prompt that
+// started it to the message before the next prompt.
+func (o *SessionSearchOverlay) loadTurn(hit store.SearchHit) []string {
+	if o.store == nil {
+		return []string{"Session store not available."}
+	}
+	msgs, err := o.store.GetMessages(hit.SessionID)
+	if err != nil {
+		return []string{fmt.Sprintf("Failed to load session: %s", err)}
+	}
+	start, end := -1, len(msgs)
+	for i, m := range msgs {
+		prompt := m.Role == "user" && !hasToolResultBlock(m.Content)
+		if m.Seq <= hit.Seq && prompt {
+			start = i
+		}
+		if m.Seq > hit.Seq && prompt {
+			end = i
+			break
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	width := o.boxWidth() - 4
+	var lines []string
+	for _, m := range msgs[start:end] {
+		marker := "  "
+		if m.Seq == hit.Seq {
+			marker = "> "
+		}
+		for _, text := range searchPreviewText(m.Role, m.Content) {
+			lines = append(lines, ansi.Truncate(marker+text, width, "…"))
+			marker = "  "
+		}
+	}
+	if len(lines) > maxPreviewLines {
+		lines = append(lines[:maxPreviewLines], "  …")
+	}
+	return lines
+}
+
+// searchPreviewText summarizes a message as one line per block.
+func searchPreviewText(role string, content []provider.ContentBlock) []string {
+	var out []string
+	for _, b := range content {
+		switch b.Type {
+		case "text":
+			out = append(out, fmt.Sprintf("%s: %s", role, oneLine(b.Text)))
+		case "tool_use":
+			out = append(out, fmt.Sprintf("→ %s %s", b.Name, oneLine(string(b.Input))))
+		case "tool_result":
+			out = append(out, "← "+oneLine(b.Text))
+		}
+	}
+	return out
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func hasToolResultBlock(content []provider.ContentBlock) bool {
+	for _, b := range content {
+		if b.Type == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *SessionSearchOverlay) View() string {
+	k := activeKeys
+	if len(o.hits) == 0 {
+		return styleApprovalBorder.Width(o.boxWidth()).Render(
+			fmt.Sprintf("No messages match %q. Press any key to close.", o.query))
+	}
+
+	var b strings.Builder
+	if o.preview != nil {
+		hit := o.hits[o.index]
+		fmt.Fprintf(&b, "%s — message %d\n\n", searchHitTitle(hit), hit.Seq)
+		b.WriteString(strings.Join(o.preview, "\n"))
+		fmt.Fprintf(&b, "\n\n[r] resume session  [f] fork from this turn  [%s] back", k.Label(ActionClose))
+		return styleApprovalBorder.Width(o.boxWidth()).Render(b.String())
+	}
+
+	fmt.Fprintf(&b, "Search: %s\n\n", o.query)
+	width := o.boxWidth() - 4
+	for i, hit := range o.hits {
+		marker := "  "
+		if i == o.index {
+			marker = "> "
+		}
+		header := fmt.Sprintf("%s%s  %s  %s", marker, searchHitTitle(hit), filepath.Base(hit.WorkingDir), sessionTimeAgo(hit.CreatedAt))
+		if len(hit.Tools) > 0 {
+			header += "  [" + strings.Join(hit.Tools, ", ") + "]"
+		}
+		b.WriteString(ansi.Truncate(header, width, "…") + "\n")
+		b.WriteString(ansi.Truncate("    "+highlightSnippet(oneLine(hit.Snippet)), width, "…") + "\n")
+	}
+	fmt.Fprintf(&b, "\n[%s/%s] navigate  [%s] show turn  [r] resume  [f] fork from turn  [%s] cancel",
+		k.Label(ActionUp), k.Label(ActionDown), k.Label(ActionSelect), k.Label(ActionClose))
+	return styleApprovalBorder.Width(o.boxWidth()).Render(b.String())
+}
+
+func searchHitTitle(hit store.SearchHit) string {
+	if hit.SessionTitle != "" {
+		return hit.SessionTitle
+	}
+	if len(hit.SessionID) >= 8 {
+		return hit.SessionID[:8]
+	}
+	return hit.SessionID
+}
+
+// highlightSnippet styles the matched terms of a search snippet.
+func highlightSnippet(s string) string {
+	var b strings.Builder
+	for {
+		open := strings.Index(s, store.SnippetOpen)
+		if open < 0 {
+			break
+		}
+		rest := s[open+len(store.SnippetOpen):]
+		closeIdx := strings.Index(rest, store.SnippetClose)
+		if closeIdx < 0 {
+			break
+		}
+		b.WriteString(styleTextDim.Render(s[:open]))
+		b.WriteString(styleApprovalKey.Render(rest[:closeIdx]))
+		s = rest[closeIdx+len(store.SnippetClose):]
+	}
+	b.WriteString(styleTextDim.Render(s))
+	return b.String()
+}
+
+func (o *SessionSearchOverlay) boxWidth() int {
+	w := o.width - 4
+	if w < 30 {
+		w = 30
+	}
+	return w
+}
+
+func (o *SessionSearchOverlay) Done() bool {
+	return o.done
+}
+
+func (o *SessionSearchOverlay) Result() any {
+	if o.result != nil {
+		return *o.result
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/commands"
+	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+func newSearchTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	require.NoError(t, s.CreateSession(store.Session{ID: "sess-1", Model: "m", WorkingDir: "/work/api"}))
+	for _, msg := range []struct {
+		role string
+		text string
+	}{
+		{"user", "fix the flaky migration test"},
+		{"assistant", "The migration races the seed step."},
+		{"user", "now update the changelog"},
+		{"assistant", "Updated."},
+	} {
+		require.NoError(t, s.AppendMessage("sess-1", msg.role, []provider.ContentBlock{{Type: "text", Text: msg.text}}))
+	}
+	return s
+}
+
+func TestSessionSearchOverlayListsHits(t *testing.T) {
+	s := newSearchTestStore(t)
+	hits, err := s.SearchMessages(store.SearchQuery{Text: "migration"})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+
+	o := NewSessionSearchOverlay("migration", hits, s, 100)
+	view := o.View()
+	assert.Contains(t, view, "Search: migration")
+	assert.Contains(t, view, "fix the flaky migration test")
+	assert.Contains(t, view, "api")
+}
+
+func TestSessionSearchOverlayPreviewShowsTurn(t *testing.T) {
+	s := newSearchTestStore(t)
+	hits, err := s.SearchMessages(store.SearchQuery{Text: "races"})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+
+	o := NewSessionSearchOverlay("races", hits, s, 100)
+	o.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	view := o.View()
+	assert.Contains(t, view, "user: fix the flaky migration test")
+	assert.Contains(t, view, "> assistant: The migration races the seed step.")
+	assert.NotContains(t, view, "changelog", "the preview stops at the next prompt")
+
+	// Closing the turn goes back to the list.
+	o.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, o.Done())
+	assert.Contains(t, o.View(), "Search: races")
+}
+
+func TestSessionSearchOverlayResults(t *testing.T) {
+	hits := []store.SearchHit{{SessionID: "a", Seq: 0}, {SessionID: "b", Seq: 3}}
+
+	o := NewSessionSearchOverlay("q", hits, nil, 80)
+	o.Update(tea.KeyMsg{Type: tea.KeyDown})
+	o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	require.True(t, o.Done())
+	assert.Equal(t, SessionSearchResult{SessionID: "b", Seq: 3, Fork: true}, o.Result())
+
+	o = NewSessionSearchOverlay("q", hits, nil, 80)
+	o.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	assert.Equal(t, SessionSearchResult{SessionID: "a", Seq: 0}, o.Result())
+
+	o = NewSessionSearchOverlay("q", hits, nil, 80)
+	o.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.True(t, o.Done())
+	assert.Nil(t, o.Result())
+}
+
+func TestSessionSearchOverlayNoHits(t *testing.T) {
+	o := NewSessionSearchOverlay("nothing", nil, nil, 80)
+	assert.Contains(t, o.View(), `No messages match "nothing"`)
+	o.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, o.Done())
+}
+
+func TestSearchCommandOpensOverlay(t *testing.T) {
+	reg := commands.NewRegistry()
+	require.NoError(t, reg.Register(commands.NewSearchCommand()))
+	m := NewModel(nil, "test", "model", 10, "", nil, reg)
+	m.SetSessionStore(newSearchTestStore(t))
+
+	m.handleCommand("/search flaky")
+	assert.Equal(t, StateSearchOverlay, m.state)
+	assert.IsType(t, &SessionSearchOverlay{}, m.activeOverlay)
+}
+
+func TestProcessOverlayResultSessionSearchFork(t *testing.T) {
+	s := newSearchTestStore(t)
+	a := createTestAgentWithStore(t, s)
+	m := NewModel(a, "test", "model", 10, "", nil, nil)
+	m.state = StateSearchOverlay
+
+	m.processOverlayResult(SessionSearchResult{SessionID: "sess-1", Seq: 1, Fork: true})
+
+	assert.Equal(t, StateInput, m.state)
+	assert.Contains(t, m.content.String(), "Forked session sess-1 at message 1")
+	assert.NotEqual(t, "sess-1", a.SessionID())
+	msgs, err := s.GetMessages(a.SessionID())
+	require.NoError(t, err)
+	assert.Len(t, msgs, 2, "the fork ends with the matching turn")
+}
@@ -0,0 +1,524 @@
+package tui
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/julianshen/rubichan/internal/commands"
+	"github.com/julianshen/rubichan/internal/persona"
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+// TabSpec describes the session to open in a tab.
+type TabSpec struct {
+	Name      string
+	SessionID string // store session to resume; empty starts a new one
+	Worktree  string // git worktree to run in; empty uses the project dir
+}
+
+// TabSession is a tab's session: a Model wired to its own agent,
+// conversation and checkpoint manager, and the cleanup that releases them.
+type TabSession struct {
+	Model *Model
+	Close func()
+}
+
+// TabFactory builds the session for a tab. It runs off the UI goroutine,
+// so it must not touch other tabs.
+type TabFactory func(spec TabSpec) (*TabSession, error)
+
+type tab struct {
+	id     int
+	spec   TabSpec
+	sess   *TabSession
+	unread bool // output arrived while another tab was shown
+}
+
+// tabMsg routes a message produced by a tab's commands back to that tab,
+// so agent events and approval requests reach the session that asked for
+// them whichever tab is shown.
+type tabMsg struct {
+	id  int
+	msg tea.Msg
+}
+
+// tabOpenedMsg reports the outcome of building a tab's session.
+type tabOpenedMsg struct {
+	spec TabSpec
+	sess *TabSession
+	err  error
+}
+
+type tabOp int
+
+const (
+	tabOpOpen tabOp = iota
+	tabOpClose
+)
+
+// tabRequest is a /tab request made while a tab was handling a message,
+// applied once that tab's Update returns.
+type tabRequest struct {
+	op   tabOp
+	spec TabSpec
+}
+
+// Tabs hosts several sessions as tabs and shows one at a time. Keys go to
+// the shown tab; everything a tab's commands produce goes back to that
+// tab. The tab list is saved to the store so it can be restored.
+type Tabs struct {
+	tabs     []*tab
+	active   int
+	nextID   int
+	factory  TabFactory
+	store    *store.Store
+	project  string
+	width    int
+	height   int
+	opening  int
+	requests []tabRequest
+}
+
+// Ensure Tabs satisfies the tea.Model and TabController interfaces.
+var (
+	_ tea.Model              = (*Tabs)(nil)
+	_ commands.TabController = (*Tabs)(nil)
+)
+
+// NewTabs creates an empty tab host. factory builds the sessions of tabs
+// opened at runtime; s, when non-nil, records the open tabs for project.
+func NewTabs(factory TabFactory, s *store.Store, project string) *Tabs {
+	return &Tabs{factory: factory, store: s, project: project, width: 80, height: 24}
+}
+
+// Add appends a tab for an already built session.
+func (t *Tabs) Add(spec TabSpec, sess *TabSession) {
+	t.nextID++
+	if spec.Name == "" {
+		spec.Name = fmt.Sprintf("%d", t.nextID)
+	}
+	t.tabs = append(t.tabs, &tab{id: t.nextID, spec: spec, sess: sess})
+}
+
+// Select shows the tab at index i.
+func (t *Tabs) Select(i int) {
+	if i < 0 || i >= len(t.tabs) {
+		return
+	}
+	t.active = i
+	t.tabs[i].unread = false
+}
+
+// Sessions returns the sessions of the open tabs, in order.
+func (t *Tabs) Sessions() []*TabSession {
+	out := make([]*TabSession, len(t.tabs))
+	for i, tb := range t.tabs {
+		out[i] = tb.sess
+	}
+	return out
+}
+
+func (t *Tabs) current() *tab {
+	if len(t.tabs) == 0 {
+		return nil
+	}
+	return t.tabs[t.active]
+}
+
+func (t *Tabs) byID(id int) *tab {
+	for _, tb := range t.tabs {
+		if tb.id == id {
+			return tb
+		}
+	}
+	return nil
+}
+
+// Init implements tea.Model.
+func (t *Tabs) Init() tea.Cmd {
+	cmds := make([]tea.Cmd, 0, len(t.tabs))
+	for _, tb := range t.tabs {
+		cmds = append(cmds, tagCmd(tb.id, tb.sess.Model.Init()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Update implements tea.Model.
+func (t *Tabs) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tabMsg:
+		tb := t.byID(msg.id)
+		if tb == nil {
+			return t, nil // the tab was closed
+		}
+		if tb != t.current() && isTabOutput(msg.msg) {
+			tb.unread = true
+		}
+		return t, t.forward(tb, msg.msg)
+
+	case tabOpenedMsg:
+		t.opening--
+		if msg.err != nil {
+			t.notice(persona.ErrorMessage(fmt.Sprintf("Could not open tab: %s", msg.err)))
+			return t, t.resize()
+		}
+		t.Add(msg.spec, msg.sess)
+		tb := t.tabs[len(t.tabs)-1]
+		t.show(len(t.tabs) - 1)
+		return t, tea.Batch(t.resize(), tagCmd(tb.id, tb.sess.Model.Init()))
+
+	case tea.WindowSizeMsg:
+		t.width, t.height = msg.Width, msg.Height
+		return t, t.resize()
+
+	case tea.KeyMsg:
+		if cmd, ok := t.handleTabKey(msg); ok {
+			return t, cmd
+		}
+	}
+
+	// Keys, mouse and untagged messages (such as an editor's exit) belong
+	// to the shown tab.
+	if tb := t.current(); tb != nil {
+		return t, t.forward(tb, msg)
+	}
+	return t, nil
+}
+
+// handleTabKey applies the tab switching keys.
+func (t *Tabs) handleTabKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case activeKeys.Matches(msg, ActionTabNew):
+		return tea.Batch(t.open(TabSpec{}), t.resize()), true
+	case activeKeys.Matches(msg, ActionTabClose):
+		if err := t.CloseTab(); err != nil {
+			t.notice(err.Error() + "\n")
+			return nil, true
+		}
+		return t.applyRequests(), true
+	case activeKeys.Matches(msg, ActionTabNext):
+		t.show((t.active + 1) % len(t.tabs))
+		return nil, true
+	case activeKeys.Matches(msg, ActionTabPrev):
+		t.show((t.active + len(t.tabs) - 1) % len(t.tabs))
+		return nil, true
+	}
+	// Alt+1…9 jump straight to a tab.
+	if msg.Alt && msg.Type == tea.KeyRunes && len(msg.Runes) == 1 {
+		if r := msg.Runes[0]; r >= '1' && r <= '9' {
+			if i := int(r - '1'); i < len(t.tabs) {
+				t.show(i)
+			}
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// forward delivers msg to tb and tags the commands it returns with tb.
+func (t *Tabs) forward(tb *tab, msg tea.Msg) tea.Cmd {
+	wasShown := tb == t.current()
+	_, cmd := tb.sess.Model.Update(msg)
+	cmd = tagCmd(tb.id, cmd)
+	if tb.sess.Model.quitting {
+		// Ctrl+C and /quit leave the whole TUI, not just this tab.
+		t.quitAll()
+		return tea.Batch(cmd, tea.Quit)
+	}
+	if !wasShown && tb == t.current() {
+		tb.unread = false
+	}
+	return tea.Batch(cmd, t.applyRequests())
+}
+
+// applyRequests carries out the /tab requests queued during an Update.
+func (t *Tabs) applyRequests() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, r := range t.requests {
+		switch r.op {
+		case tabOpOpen:
+			cmds = append(cmds, t.open(r.spec), t.resize())
+		case tabOpClose:
+			cmds = append(cmds, t.closeCurrent())
+		}
+	}
+	t.requests = nil
+	return tea.Batch(cmds...)
+}
+
+// open builds a session for spec in the background.
+func (t *Tabs) open(spec TabSpec) tea.Cmd {
+	if t.factory == nil {
+		t.notice("Opening tabs is not available.\n")
+		return nil
+	}
+	t.opening++
+	factory := t.factory
+	return func() tea.Msg {
+		sess, err := factory(spec)
+		return tabOpenedMsg{spec: spec, sess: sess, err: err}
+	}
+}
+
+// closeCurrent closes the shown tab. Its running turn is cancelled and
+// any approval it was waiting on is denied.
+func (t *Tabs) closeCurrent() tea.Cmd {
+	tb := t.current()
+	if tb == nil || len(t.tabs) < 2 {
+		return nil
+	}
+	m := tb.sess.Model
+	if m.turnCancel != nil {
+		m.turnCancel()
+	}
+	m.doQuit()
+	t.tabs = append(t.tabs[:t.active], t.tabs[t.active+1:]...)
+	if t.active >= len(t.tabs) {
+		t.active = len(t.tabs) - 1
+	}
+	t.tabs[t.active].unread = false
+	cmd := t.resize()
+	t.save()
+	if closeFn := tb.sess.Close; closeFn != nil {
+		return tea.Batch(cmd, func() tea.Msg {
+			closeFn()
+			return nil
+		})
+	}
+	return cmd
+}
+
+// show switches to the tab at index i.
+func (t *Tabs) show(i int) {
+	t.Select(i)
+	t.save()
+}
+
+// quitAll ends every tab's session: turns are cancelled, pending approvals
+// denied, and the tab list saved for the next start.
+func (t *Tabs) quitAll() {
+	t.save()
+	for _, tb := range t.tabs {
+		m := tb.sess.Model
+		if m.turnCancel != nil {
+			m.turnCancel()
+		}
+		if !m.quitting {
+			m.doQuit()
+		}
+	}
+}
+
+// tabBarHeight is the height of the tab bar, shown once there are two
+// or more tabs.
+func (t *Tabs) tabBarHeight() int {
+	if len(t.tabs) > 1 || t.opening > 0 {
+		return 1
+	}
+	return 0
+}
+
+// resize gives every tab the window minus the tab bar.
+func (t *Tabs) resize() tea.Cmd {
+	size := tea.WindowSizeMsg{Width: t.width, Height: t.height - t.tabBarHeight()}
+	cmds := make([]tea.Cmd, 0, len(t.tabs))
+	for _, tb := range t.tabs {
+		_, cmd := tb.sess.Model.Update(size)
+		cmds = append(cmds, tagCmd(tb.id, cmd))
+	}
+	return tea.Batch(cmds...)
+}
+
+// notice writes text into the shown tab's conversation.
+func (t *Tabs) notice(text string) {
+	if tb := t.current(); tb != nil {
+		tb.sess.Model.content.WriteString(text)
+		tb.sess.Model.setContentAndAutoScroll()
+	}
+}
+
+// save records the open tabs in the store.
+func (t *Tabs) save() {
+	if t.store == nil || t.project == "" {
+		return
+	}
+	recs := make([]store.Tab, len(t.tabs))
+	for i, tb := range t.tabs {
+		recs[i] = store.Tab{Name: tb.spec.Name, Worktree: tb.spec.Worktree, Active: i == t.active}
+		if a := tb.sess.Model.GetAgent(); a != nil {
+			recs[i].SessionID = a.SessionID()
+		}
+	}
+	if err := t.store.SaveTabs(t.project, recs); err != nil {
+		log.Printf("saving tabs: %v", err)
+	}
+}
+
+// View implements tea.Model.
+func (t *Tabs) View() string {
+	tb := t.current()
+	if tb == nil {
+		return ""
+	}
+	view := tb.sess.Model.View()
+	if t.tabBarHeight() == 0 || tb.sess.Model.quitting {
+		return view
+	}
+	return t.tabBar() + "\n" + view
+}
+
+// tabBar renders one label per tab with its status indicator.
+func (t *Tabs) tabBar() string {
+	var b strings.Builder
+	for i, tb := range t.tabs {
+		label := fmt.Sprintf("%d %s", i+1, tb.spec.Name)
+		if tb.spec.Worktree != "" {
+			label += " ⎇"
+		}
+		style := styleTabInactive
+		if i == t.active {
+			style = styleTabActive
+		}
+		b.WriteString(style.Render(label))
+		b.WriteString(tabIndicator(tb))
+		b.WriteString(" ")
+	}
+	if t.opening > 0 {
+		b.WriteString(styleTextDim.Render("opening…"))
+	}
+	return lipgloss.NewStyle().MaxWidth(t.width).Render(b.String())
+}
+
+// tabIndicator marks a tab awaiting approval (!), working (⋯) or with
+// unread output (●).
+func tabIndicator(tb *tab) string {
+	m := tb.sess.Model
+	switch {
+	case m.pendingApproval != nil:
+		return styleTabApproval.Render(" !")
+	case m.state == StateStreaming || m.state == StateFetchingModels:
+		return styleTabWorking.Render(" ⋯")
+	case tb.unread:
+		return styleTabUnread.Render(" ●")
+	}
+	return ""
+}
+
+// tabStatus describes a tab's indicator in words for /tab list.
+func tabStatus(tb *tab) string {
+	m := tb.sess.Model
+	switch {
+	case m.pendingApproval != nil:
+		return "awaiting approval"
+	case m.state == StateStreaming || m.state == StateFetchingModels:
+		return "working"
+	case tb.unread:
+		return "unread"
+	}
+	return ""
+}
+
+// isTabOutput reports whether msg adds to a tab's conversation, which
+// marks a hidden tab unread.
+func isTabOutput(msg tea.Msg) bool {
+	switch msg.(type) {
+	case TurnEventMsg, turnStartedMsg, wikiDoneMsg:
+		return true
+	}
+	return false
+}
+
+// ListTabs implements commands.TabController.
+func (t *Tabs) ListTabs() []commands.TabInfo {
+	out := make([]commands.TabInfo, len(t.tabs))
+	for i, tb := range t.tabs {
+		out[i] = commands.TabInfo{
+			Name:     tb.spec.Name,
+			Worktree: tb.spec.Worktree,
+			Active:   i == t.active,
+			Status:   tabStatus(tb),
+		}
+	}
+	return out
+}
+
+// OpenTab implements commands.TabController.
+func (t *Tabs) OpenTab(name, worktree string) error {
+	if t.factory == nil {
+		return fmt.Errorf("opening tabs is not available")
+	}
+	t.requests = append(t.requests, tabRequest{op: tabOpOpen, spec: TabSpec{Name: name, Worktree: worktree}})
+	return nil
+}
+
+// CloseTab implements commands.TabController.
+func (t *Tabs) CloseTab() error {
+	if len(t.tabs) < 2 {
+		return fmt.Errorf("this is the last tab; use /quit to exit")
+	}
+	t.requests = append(t.requests, tabRequest{op: tabOpClose})
+	return nil
+}
+
+// RenameTab implements commands.TabController.
+func (t *Tabs) RenameTab(name string) error {
+	tb := t.current()
+	if tb == nil {
+		return fmt.Errorf("no tab to rename")
+	}
+	tb.spec.Name = name
+	t.save()
+	return nil
+}
+
+// SwitchTab implements commands.TabController.
+func (t *Tabs) SwitchTab(name string) error {
+	for i, tb := range t.tabs {
+		if tb.spec.Name == name {
+			// The size is unchanged, so the switch needs no resize.
+			t.show(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("no tab named %q", name)
+}
+
+// teaPkgPath identifies Bubble Tea's own messages, which must reach the
+// runtime untagged.
+var teaPkgPath = reflect.TypeOf(tea.QuitMsg{}).PkgPath()
+
+var cmdType = reflect.TypeOf(tea.Cmd(nil))
+
+// tagCmd wraps cmd so the message it produces is routed to tab id.
+func tagCmd(id int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg { return tagMsg(id, cmd()) }
+}
+
+func tagMsg(id int, msg tea.Msg) tea.Msg {
+	if msg == nil {
+		return nil
+	}
+	v := reflect.ValueOf(msg)
+	if v.Type().PkgPath() != teaPkgPath {
+		return tabMsg{id: id, msg: msg}
+	}
+	// Batches and sequences are lists of commands the runtime runs;
+	// tag each one. Other runtime messages pass through as they are.
+	if v.Kind() == reflect.Slice && v.Type().Elem() == cmdType {
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c, _ := v.Index(i).Interface().(tea.Cmd)
+			out.Index(i).Set(reflect.ValueOf(tagCmd(id, c)))
+		}
+		return out.Interface()
+	}
+	return msg
+}
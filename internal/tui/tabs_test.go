@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/agent"
+	"github.com/julianshen/rubichan/internal/store"
+)
+
+func newTestTabs(t *testing.T, names ...string) *Tabs {
+	t.Helper()
+	tabs := NewTabs(nil, nil, "")
+	for _, name := range names {
+		m := NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)
+		tabs.Add(TabSpec{Name: name}, &TabSession{Model: m})
+	}
+	tabs.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	return tabs
+}
+
+type testTabMsg struct{}
+
+func TestTagMsgWrapsTabMessages(t *testing.T) {
+	msg := tagMsg(3, testTabMsg{})
+	assert.Equal(t, tabMsg{id: 3, msg: testTabMsg{}}, msg)
+
+	// Runtime messages pass through untagged.
+	assert.Equal(t, tea.QuitMsg{}, tagMsg(3, tea.QuitMsg{}))
+	assert.Nil(t, tagMsg(3, nil))
+}
+
+func TestTagMsgTagsBatchedCommands(t *testing.T) {
+	batch := tea.Batch(
+		func() tea.Msg { return testTabMsg{} },
+		func() tea.Msg { return testTabMsg{} },
+	)
+	msg := tagCmd(2, batch)()
+	cmds, ok := msg.(tea.BatchMsg)
+	require.True(t, ok, "a batch must reach the runtime as a batch")
+	require.Len(t, cmds, 2)
+	for _, c := range cmds {
+		assert.Equal(t, tabMsg{id: 2, msg: testTabMsg{}}, c())
+	}
+}
+
+func TestTabsAddNamesUnnamedTabs(t *testing.T) {
+	tabs := newTestTabs(t, "", "docs")
+	infos := tabs.ListTabs()
+	require.Len(t, infos, 2)
+	assert.Equal(t, "1", infos[0].Name)
+	assert.Equal(t, "docs", infos[1].Name)
+	assert.True(t, infos[0].Active)
+}
+
+func TestTabsRouteOutputToItsTab(t *testing.T) {
+	tabs := newTestTabs(t, "main", "docs")
+	docs := tabs.tabs[1]
+
+	tabs.Update(tabMsg{id: docs.id, msg: TurnEventMsg(agent.TurnEvent{Type: "text_delta", Text: "hello from docs"})})
+
+	assert.Contains(t, docs.sess.Model.content.String(), "hello from docs")
+	assert.NotContains(t, tabs.tabs[0].sess.Model.content.String(), "hello from docs")
+	assert.True(t, docs.unread)
+	assert.Equal(t, "unread", tabs.ListTabs()[1].Status)
+	assert.Contains(t, tabs.View(), "●")
+
+	tabs.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}, Alt: true})
+	assert.Equal(t, 1, tabs.active)
+	assert.False(t, docs.unread)
+}
+
+func TestTabsRouteApprovalToItsTab(t *testing.T) {
+	tabs := newTestTabs(t, "main", "docs")
+	docs := tabs.tabs[1]
+
+	tabs.Update(tabMsg{id: docs.id, msg: approvalRequestMsg{
+		tool:          "shell",
+		input:         `{"command":"ls"}`,
+		responseValue: make(chan ApprovalResult, 1),
+	}})
+
+	assert.NotNil(t, docs.sess.Model.pendingApproval)
+	assert.Nil(t, tabs.tabs[0].sess.Model.pendingApproval)
+	assert.Equal(t, "awaiting approval", tabs.ListTabs()[1].Status)
+	assert.Contains(t, tabs.View(), "!")
+}
+
+func TestTabsSwitchKeys(t *testing.T) {
+	tabs := newTestTabs(t, "a", "b", "c")
+
+	tabs.Update(tea.KeyMsg{Type: tea.KeyRight, Alt: true})
+	assert.Equal(t, 1, tabs.active)
+	tabs.Update(tea.KeyMsg{Type: tea.KeyLeft, Alt: true})
+	tabs.Update(tea.KeyMsg{Type: tea.KeyLeft, Alt: true})
+	assert.Equal(t, 2, tabs.active, "previous wraps around")
+
+	require.NoError(t, tabs.SwitchTab("a"))
+	assert.Equal(t, 0, tabs.active)
+	assert.Error(t, tabs.SwitchTab("missing"))
+}
+
+func TestTabsTabBarOnlyWithSeveralTabs(t *testing.T) {
+	one := newTestTabs(t, "main")
+	assert.Equal(t, 0, one.tabBarHeight())
+	assert.Equal(t, 24, one.tabs[0].sess.Model.height)
+
+	two := newTestTabs(t, "main", "docs")
+	assert.Equal(t, 1, two.tabBarHeight())
+	assert.Equal(t, 23, two.tabs[0].sess.Model.height)
+	assert.Contains(t, two.View(), "2 docs")
+}
+
+func TestTabsClose(t *testing.T) {
+	tabs := newTestTabs(t, "main")
+	assert.Error(t, tabs.CloseTab(), "the last tab cannot be closed")
+
+	tabs = newTestTabs(t, "main", "docs")
+	closed := false
+	tabs.tabs[1].sess.Close = func() { closed = true }
+	tabs.Select(1)
+
+	_, cmd := tabs.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'w'}, Alt: true})
+	require.Len(t, tabs.tabs, 1)
+	assert.Equal(t, "main", tabs.tabs[0].spec.Name)
+	assert.Equal(t, 0, tabs.tabBarHeight())
+
+	drainCmd(cmd)
+	assert.True(t, closed)
+}
+
+func TestTabsOpenWithoutFactory(t *testing.T) {
+	tabs := newTestTabs(t, "main")
+	assert.Error(t, tabs.OpenTab("docs", ""))
+}
+
+func TestTabsOpenRequestRunsFactory(t *testing.T) {
+	var got TabSpec
+	tabs := NewTabs(func(spec TabSpec) (*TabSession, error) {
+		got = spec
+		return &TabSession{Model: NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)}, nil
+	}, nil, "")
+	tabs.Add(TabSpec{Name: "main"}, &TabSession{Model: NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)})
+
+	require.NoError(t, tabs.OpenTab("feature", "feature"))
+	cmd := tabs.applyRequests()
+	assert.Equal(t, 1, tabs.tabBarHeight(), "the bar shows while the tab opens")
+
+	for _, msg := range drainCmd(cmd) {
+		if opened, ok := msg.(tabOpenedMsg); ok {
+			tabs.Update(opened)
+		}
+	}
+	assert.Equal(t, TabSpec{Name: "feature", Worktree: "feature"}, got)
+	require.Len(t, tabs.tabs, 2)
+	assert.Equal(t, 1, tabs.active)
+	assert.Equal(t, "feature", tabs.ListTabs()[1].Worktree)
+}
+
+func TestTabsSaveToStore(t *testing.T) {
+	s, err := store.NewStore(":memory:")
+	require.NoError(t, err)
+	defer s.Close()
+
+	tabs := NewTabs(nil, s, "/project")
+	for _, name := range []string{"main", "docs"} {
+		tabs.Add(TabSpec{Name: name}, &TabSession{Model: NewModel(nil, "rubichan", "claude-3", 50, "", nil, nil)})
+	}
+	tabs.show(1)
+	require.NoError(t, tabs.RenameTab("notes"))
+
+	saved, err := s.LoadTabs("/project")
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+	assert.Equal(t, "main", saved[0].Name)
+	assert.Equal(t, "notes", saved[1].Name)
+	assert.True(t, saved[1].Active)
+}
+
+// drainCmd runs cmd and the commands of any batch it returns, collecting
+// the messages. Commands that block (such as waiting for agent events) are
+// not expected here.
+func drainCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var out []tea.Msg
+		for _, c := range batch {
+			out = append(out, drainCmd(c)...)
+		}
+		return out
+	}
+	if msg == nil {
+		return nil
+	}
+	return []tea.Msg{msg}
+}
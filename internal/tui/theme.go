@@ -0,0 +1,541 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/julianshen/rubichan/internal/config"
+)
+
+// DefaultThemeName is the theme used when none is configured.
+const DefaultThemeName = "rubichan"
+
+// Theme is a complete TUI color scheme. Colors may be lipgloss.Color or
+// lipgloss.AdaptiveColor; adaptive colors pick a value for the terminal's
+// background.
+type Theme struct {
+	Name string
+	// Markdown is the glamour style for assistant messages: "dark" or
+	// "light". Empty follows the terminal background.
+	Markdown string
+	// Syntax is the chroma style for highlighted code.
+	Syntax string
+
+	Primary, PrimaryBold, PrimaryLight, PrimaryDim lipgloss.TerminalColor
+	Accent, AccentDim, AccentGlow                  lipgloss.TerminalColor
+	Success, Warning, Danger, Info                 lipgloss.TerminalColor
+
+	TextBright, TextNormal, TextDim, TextMuted lipgloss.TerminalColor
+	Border, BorderDim                          lipgloss.TerminalColor
+	BgSubtle, BgSelected, Selection            lipgloss.TerminalColor
+
+	Banner []lipgloss.Color
+}
+
+// themeColorRoles maps the color names used in theme files to their fields.
+var themeColorRoles = map[string]func(*Theme) *lipgloss.TerminalColor{
+	"primary":       func(t *Theme) *lipgloss.TerminalColor { return &t.Primary },
+	"primary_bold":  func(t *Theme) *lipgloss.TerminalColor { return &t.PrimaryBold },
+	"primary_light": func(t *Theme) *lipgloss.TerminalColor { return &t.PrimaryLight },
+	"primary_dim":   func(t *Theme) *lipgloss.TerminalColor { return &t.PrimaryDim },
+	"accent":        func(t *Theme) *lipgloss.TerminalColor { return &t.Accent },
+	"accent_dim":    func(t *Theme) *lipgloss.TerminalColor { return &t.AccentDim },
+	"accent_glow":   func(t *Theme) *lipgloss.TerminalColor { return &t.AccentGlow },
+	"success":       func(t *Theme) *lipgloss.TerminalColor { return &t.Success },
+	"warning":       func(t *Theme) *lipgloss.TerminalColor { return &t.Warning },
+	"danger":        func(t *Theme) *lipgloss.TerminalColor { return &t.Danger },
+	"info":          func(t *Theme) *lipgloss.TerminalColor { return &t.Info },
+	"text_bright":   func(t *Theme) *lipgloss.TerminalColor { return &t.TextBright },
+	"text_normal":   func(t *Theme) *lipgloss.TerminalColor { return &t.TextNormal },
+	"text_dim":      func(t *Theme) *lipgloss.TerminalColor { return &t.TextDim },
+	"text_muted":    func(t *Theme) *lipgloss.TerminalColor { return &t.TextMuted },
+	"border":        func(t *Theme) *lipgloss.TerminalColor { return &t.Border },
+	"border_dim":    func(t *Theme) *lipgloss.TerminalColor { return &t.BorderDim },
+	"bg_subtle":     func(t *Theme) *lipgloss.TerminalColor { return &t.BgSubtle },
+	"bg_selected":   func(t *Theme) *lipgloss.TerminalColor { return &t.BgSelected },
+	"selection":     func(t *Theme) *lipgloss.TerminalColor { return &t.Selection },
+}
+
+func adaptive(light, dark string) lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: light, Dark: dark}
+}
+
+// rubichanTheme is the original pink palette inspired by Ruby's persona.
+// Light mode uses deeper pinks for contrast against white backgrounds.
+func rubichanTheme() Theme {
+	return Theme{
+		Name:   "rubichan",
+		Syntax: "monokai",
+
+		Primary:      adaptive("#CC4477", "#FF6B9D"), // warm pink
+		PrimaryBold:  adaptive("#CC1166", "#FF3385"), // hot pink
+		PrimaryLight: adaptive("#DD6699", "#FFB3D0"), // pastel pink
+		PrimaryDim:   adaptive("#994466", "#CC5580"), // muted pink
+		Accent:       adaptive("#CC5588", "#FF85B5"), // rose
+		AccentDim:    adaptive("#AA4477", "#D4609A"), // dusty rose
+		AccentGlow:   adaptive("#DD7799", "#FF9EC7"), // light rose
+
+		Success: adaptive("#2D8B3D", "#7CDB8A"),
+		Warning: adaptive("#CC8822", "#FFB347"),
+		Danger:  adaptive("#CC3333", "#FF6B6B"),
+		Info:    adaptive("#3377AA", "#7CC4E8"),
+
+		TextBright: adaptive("#1A1A2E", "#F0E6F0"),
+		TextNormal: adaptive("#333344", "#D4C6D4"),
+		TextDim:    adaptive("#777788", "#998899"),
+		TextMuted:  adaptive("#999999", "#666677"),
+		Border:     adaptive("#CC7799", "#995577"),
+		BorderDim:  adaptive("#AA8899", "#665566"),
+		BgSubtle:   adaptive("#FFF0F5", "#2A1A24"),
+		BgSelected: adaptive("#FFD6E8", "#4A2040"),
+		Selection:  adaptive("#4A90E2", "#1D5FAD"),
+
+		// Non-adaptive because gradient ordering requires precise steps.
+		Banner: []lipgloss.Color{
+			"#FFB3D0", "#FF9EC7", "#FF85B5", "#FF6B9D", "#FF5291",
+			"#FF3385", "#E8297A", "#D4609A", "#CC5580",
+		},
+	}
+}
+
+// darkTheme is a neutral blue palette for dark terminals.
+func darkTheme() Theme {
+	return Theme{
+		Name:     "dark",
+		Markdown: "dark",
+		Syntax:   "monokai",
+
+		Primary:      lipgloss.Color("#7AA2F7"),
+		PrimaryBold:  lipgloss.Color("#9ECEFF"),
+		PrimaryLight: lipgloss.Color("#B4C8F0"),
+		PrimaryDim:   lipgloss.Color("#5A6E9A"),
+		Accent:       lipgloss.Color("#BB9AF7"),
+		AccentDim:    lipgloss.Color("#8A6FBF"),
+		AccentGlow:   lipgloss.Color("#D0B8FF"),
+
+		Success: lipgloss.Color("#9ECE6A"),
+		Warning: lipgloss.Color("#E0AF68"),
+		Danger:  lipgloss.Color("#F7768E"),
+		Info:    lipgloss.Color("#7DCFFF"),
+
+		TextBright: lipgloss.Color("#E6E9F5"),
+		TextNormal: lipgloss.Color("#C0CAF5"),
+		TextDim:    lipgloss.Color("#8A93B8"),
+		TextMuted:  lipgloss.Color("#5C6485"),
+		Border:     lipgloss.Color("#565F89"),
+		BorderDim:  lipgloss.Color("#3B4261"),
+		BgSubtle:   lipgloss.Color("#1F2335"),
+		BgSelected: lipgloss.Color("#33467C"),
+		Selection:  lipgloss.Color("#2E4A7A"),
+
+		Banner: []lipgloss.Color{
+			"#B4C8F0", "#9ECEFF", "#7DCFFF", "#7AA2F7", "#6D8FE0",
+			"#BB9AF7", "#A584E0", "#8A6FBF", "#5A6E9A",
+		},
+	}
+}
+
+// lightTheme uses dark, saturated colors that stay readable on white and
+// other light backgrounds.
+func lightTheme() Theme {
+	return Theme{
+		Name:     "light",
+		Markdown: "light",
+		Syntax:   "github",
+
+		Primary:      lipgloss.Color("#1F4FA8"),
+		PrimaryBold:  lipgloss.Color("#0B3A8C"),
+		PrimaryLight: lipgloss.Color("#3A66B8"),
+		PrimaryDim:   lipgloss.Color("#4A5A7A"),
+		Accent:       lipgloss.Color("#7A2E9E"),
+		AccentDim:    lipgloss.Color("#643A7E"),
+		AccentGlow:   lipgloss.Color("#8E44B5"),
+
+		Success: lipgloss.Color("#1E6B2A"),
+		Warning: lipgloss.Color("#8A5300"),
+		Danger:  lipgloss.Color("#B0182B"),
+		Info:    lipgloss.Color("#0B5C8C"),
+
+		TextBright: lipgloss.Color("#101018"),
+		TextNormal: lipgloss.Color("#24242E"),
+		TextDim:    lipgloss.Color("#555566"),
+		TextMuted:  lipgloss.Color("#6E6E7E"),
+		Border:     lipgloss.Color("#5A6E9A"),
+		BorderDim:  lipgloss.Color("#8A94AA"),
+		BgSubtle:   lipgloss.Color("#EEF1F8"),
+		BgSelected: lipgloss.Color("#CCD9F2"),
+		Selection:  lipgloss.Color("#A8C4F0"),
+
+		Banner: []lipgloss.Color{
+			"#3A66B8", "#2F5CB0", "#1F4FA8", "#0B3A8C", "#2D2E8F",
+			"#4B2C93", "#643A7E", "#7A2E9E", "#5A1E7A",
+		},
+	}
+}
+
+// highContrastTheme uses the terminal's own bright ANSI colors at full
+// strength, with no dimmed or muted text.
+func highContrastTheme() Theme {
+	fg := adaptive("0", "15")
+	return Theme{
+		Name:   "high-contrast",
+		Syntax: "monokai",
+
+		Primary:      adaptive("4", "14"),
+		PrimaryBold:  adaptive("4", "14"),
+		PrimaryLight: adaptive("4", "14"),
+		PrimaryDim:   fg,
+		Accent:       adaptive("5", "11"),
+		AccentDim:    adaptive("5", "11"),
+		AccentGlow:   adaptive("5", "11"),
+
+		Success: adaptive("2", "10"),
+		Warning: adaptive("3", "11"),
+		Danger:  adaptive("1", "9"),
+		Info:    adaptive("4", "14"),
+
+		TextBright: fg,
+		TextNormal: fg,
+		TextDim:    fg,
+		TextMuted:  fg,
+		Border:     fg,
+		BorderDim:  fg,
+		BgSubtle:   adaptive("15", "0"),
+		BgSelected: adaptive("11", "4"),
+		Selection:  adaptive("11", "4"),
+
+		Banner: []lipgloss.Color{"15", "14", "14", "11", "11", "15", "14", "11", "15"},
+	}
+}
+
+// colorblindTheme is built on the Okabe-Ito palette, which stays
+// distinguishable with the common forms of color vision deficiency. Added
+// and removed lines are blue and orange rather than green and red.
+func colorblindTheme() Theme {
+	var (
+		orange     = adaptive("#B35C00", "#E69F00")
+		skyBlue    = adaptive("#0072B2", "#56B4E9")
+		green      = adaptive("#006E50", "#009E73")
+		blue       = adaptive("#0072B2", "#56B4E9")
+		vermillion = adaptive("#A63D00", "#D55E00")
+		purple     = adaptive("#9E4F7F", "#CC79A7")
+	)
+	return Theme{
+		Name:   "colorblind",
+		Syntax: "monokai",
+
+		Primary:      skyBlue,
+		PrimaryBold:  blue,
+		PrimaryLight: skyBlue,
+		PrimaryDim:   adaptive("#4A6478", "#8AA4B8"),
+		Accent:       purple,
+		AccentDim:    purple,
+		AccentGlow:   purple,
+
+		Success: blue,
+		Warning: orange,
+		Danger:  vermillion,
+		Info:    green,
+
+		TextBright: adaptive("#101010", "#F5F5F5"),
+		TextNormal: adaptive("#2A2A2A", "#DDDDDD"),
+		TextDim:    adaptive("#5A5A5A", "#A8A8A8"),
+		TextMuted:  adaptive("#777777", "#808080"),
+		Border:     adaptive("#4A6478", "#8AA4B8"),
+		BorderDim:  adaptive("#8A8A8A", "#5A5A5A"),
+		BgSubtle:   adaptive("#F0F4F8", "#1A2028"),
+		BgSelected: adaptive("#CCE4F4", "#1E3A52"),
+		Selection:  adaptive("#CCE4F4", "#1E3A52"),
+
+		Banner: []lipgloss.Color{
+			"#56B4E9", "#56B4E9", "#009E73", "#009E73", "#F0E442",
+			"#E69F00", "#E69F00", "#D55E00", "#CC79A7",
+		},
+	}
+}
+
+// builtinThemes are the themes that need no file.
+var builtinThemes = map[string]func() Theme{
+	"rubichan":      rubichanTheme,
+	"dark":          darkTheme,
+	"light":         lightTheme,
+	"high-contrast": highContrastTheme,
+	"colorblind":    colorblindTheme,
+}
+
+// BuiltinThemeNames returns the names of the built-in themes, default first.
+func BuiltinThemeNames() []string {
+	return []string{"rubichan", "dark", "light", "high-contrast", "colorblind"}
+}
+
+// ThemeNames returns the built-in theme names followed by the themes found
+// in dir, sorted.
+func ThemeNames(dir string) []string {
+	names := BuiltinThemeNames()
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.toml"))
+	var found []string
+	for _, p := range paths {
+		name := strings.TrimSuffix(filepath.Base(p), ".toml")
+		if _, ok := builtinThemes[name]; !ok {
+			found = append(found, name)
+		}
+	}
+	sort.Strings(found)
+	return append(names, found...)
+}
+
+// themeFile is the TOML layout of a theme file:
+//
+//	name = "solarized"
+//	extends = "dark"      # built-in theme to start from (default rubichan)
+//	markdown = "dark"     # glamour style: dark or light
+//	syntax = "solarized-dark"
+//	banner = ["#268BD2", "#2AA198"]
+//
+//	[colors]
+//	primary = "#268BD2"
+//	text_dim = { light = "#657B83", dark = "#839496" }
+//
+// Colors are hex values or ANSI color numbers; a table with light and dark
+// values adapts to the terminal background.
+type themeFile struct {
+	Name     string                `toml:"name"`
+	Extends  string                `toml:"extends"`
+	Markdown string                `toml:"markdown"`
+	Syntax   string                `toml:"syntax"`
+	Banner   []string              `toml:"banner"`
+	Colors   map[string]themeColor `toml:"colors"`
+}
+
+// themeColor decodes either "#RRGGBB" or { light = ..., dark = ... }.
+type themeColor struct {
+	color lipgloss.TerminalColor
+}
+
+var themeColorPattern = regexp.MustCompile(`^(#[0-9A-Fa-f]{6}|#[0-9A-Fa-f]{3}|[0-9]{1,3})$`)
+
+func parseThemeColor(s string) (lipgloss.Color, error) {
+	if !themeColorPattern.MatchString(s) {
+		return "", fmt.Errorf("invalid color %q: want #RRGGBB or an ANSI color number", s)
+	}
+	return lipgloss.Color(s), nil
+}
+
+// UnmarshalTOML implements toml.Unmarshaler.
+func (c *themeColor) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		col, err := parseThemeColor(v)
+		if err != nil {
+			return err
+		}
+		c.color = col
+		return nil
+	case map[string]any:
+		light, _ := v["light"].(string)
+		dark, _ := v["dark"].(string)
+		if len(v) != 2 || light == "" || dark == "" {
+			return fmt.Errorf("adaptive color needs exactly light and dark values")
+		}
+		if _, err := parseThemeColor(light); err != nil {
+			return err
+		}
+		if _, err := parseThemeColor(dark); err != nil {
+			return err
+		}
+		c.color = adaptive(light, dark)
+		return nil
+	default:
+		return fmt.Errorf("color must be a string or a {light, dark} table")
+	}
+}
+
+// LoadTheme resolves name to a theme: a built-in theme, a file named
+// <name>.toml in dir, or a path to a theme file.
+func LoadTheme(name, dir string) (Theme, error) {
+	if name == "" {
+		name = DefaultThemeName
+	}
+	if build, ok := builtinThemes[name]; ok {
+		return build(), nil
+	}
+	path := name
+	if !strings.ContainsRune(name, filepath.Separator) && !strings.HasSuffix(name, ".toml") {
+		path = filepath.Join(dir, name+".toml")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Theme{}, fmt.Errorf("unknown theme %q (built-in themes: %s)", name, strings.Join(BuiltinThemeNames(), ", "))
+		}
+		return Theme{}, fmt.Errorf("reading theme: %w", err)
+	}
+	t, err := parseTheme(data)
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), ".toml")
+	}
+	return t, nil
+}
+
+// parseTheme decodes a theme file on top of the theme it extends.
+func parseTheme(data []byte) (Theme, error) {
+	var f themeFile
+	md, err := toml.Decode(string(data), &f)
+	if err != nil {
+		return Theme{}, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return Theme{}, fmt.Errorf("unknown key %q", undecoded[0].String())
+	}
+
+	base := f.Extends
+	if base == "" {
+		base = DefaultThemeName
+	}
+	build, ok := builtinThemes[base]
+	if !ok {
+		return Theme{}, fmt.Errorf("extends unknown theme %q", base)
+	}
+	t := build()
+	t.Name = f.Name
+
+	switch f.Markdown {
+	case "", "dark", "light":
+		if f.Markdown != "" {
+			t.Markdown = f.Markdown
+		}
+	default:
+		return Theme{}, fmt.Errorf("markdown must be dark or light, not %q", f.Markdown)
+	}
+	if f.Syntax != "" {
+		if _, ok := styles.Registry[strings.ToLower(f.Syntax)]; !ok {
+			return Theme{}, fmt.Errorf("unknown syntax style %q", f.Syntax)
+		}
+		t.Syntax = f.Syntax
+	}
+	for role, c := range f.Colors {
+		field, ok := themeColorRoles[role]
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown color %q", role)
+		}
+		*field(&t) = c.color
+	}
+	if len(f.Banner) > 0 {
+		t.Banner = t.Banner[:0:0]
+		for _, s := range f.Banner {
+			col, err := parseThemeColor(s)
+			if err != nil {
+				return Theme{}, fmt.Errorf("banner: %w", err)
+			}
+			t.Banner = append(t.Banner, col)
+		}
+	}
+	return t, nil
+}
+
+// currentTheme is the theme the styles were last built from.
+var currentTheme Theme
+
+// CurrentTheme returns the active theme.
+func CurrentTheme() Theme { return currentTheme }
+
+// ApplyTheme makes t the active theme and rebuilds every style from it.
+// Content rendered before the call keeps its old colors.
+func ApplyTheme(t Theme) {
+	currentTheme = t
+	colorPrimary, colorPrimaryBold, colorPrimaryLight, colorPrimaryDim = t.Primary, t.PrimaryBold, t.PrimaryLight, t.PrimaryDim
+	colorAccent, colorAccentDim, colorAccentGlow = t.Accent, t.AccentDim, t.AccentGlow
+	colorSuccess, colorWarning, colorDanger, colorInfo = t.Success, t.Warning, t.Danger, t.Info
+	colorTextBright, colorTextNormal, colorTextDim, colorTextMuted = t.TextBright, t.TextNormal, t.TextDim, t.TextMuted
+	colorBorder, colorBorderDim = t.Border, t.BorderDim
+	colorBgSubtle, colorBgSelected, colorSelection = t.BgSubtle, t.BgSelected, t.Selection
+	bannerGradient = t.Banner
+	applyStyles()
+
+	chromaStyle = styles.Get(t.Syntax)
+	if chromaStyle == nil {
+		chromaStyle = styles.Fallback
+	}
+}
+
+func init() {
+	ApplyTheme(rubichanTheme())
+}
+
+// ThemesDir returns the directory searched for theme files: "themes"
+// next to the config file at configPath.
+func ThemesDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "themes")
+}
+
+// ApplyUIConfig loads the theme and keymap selected by ui and makes them
+// active. On error nothing is changed.
+func ApplyUIConfig(ui config.UIConfig, configPath string) error {
+	t, err := LoadTheme(ui.Theme, ThemesDir(configPath))
+	if err != nil {
+		return err
+	}
+	k, err := NewKeymap(ui.Keys, ui.ViMode)
+	if err != nil {
+		return err
+	}
+	ApplyTheme(t)
+	ApplyKeymap(k)
+	return nil
+}
+
+// huhTheme styles the huh forms (config, model picker, bootstrap) with
+// the active theme.
+func huhTheme() *huh.Theme {
+	t := huh.ThemeCharm()
+	f := &t.Focused
+	f.Base = f.Base.BorderForeground(colorBorderDim)
+	f.Card = f.Base
+	f.Title = f.Title.Foreground(colorPrimary)
+	f.NoteTitle = f.NoteTitle.Foreground(colorPrimary)
+	f.Directory = f.Directory.Foreground(colorPrimary)
+	f.Description = f.Description.Foreground(colorTextDim)
+	f.ErrorIndicator = f.ErrorIndicator.Foreground(colorDanger)
+	f.ErrorMessage = f.ErrorMessage.Foreground(colorDanger)
+	f.SelectSelector = f.SelectSelector.Foreground(colorAccent)
+	f.NextIndicator = f.NextIndicator.Foreground(colorAccent)
+	f.PrevIndicator = f.PrevIndicator.Foreground(colorAccent)
+	f.Option = f.Option.Foreground(colorTextNormal)
+	f.MultiSelectSelector = f.MultiSelectSelector.Foreground(colorAccent)
+	f.SelectedOption = f.SelectedOption.Foreground(colorSuccess)
+	f.SelectedPrefix = f.SelectedPrefix.Foreground(colorSuccess)
+	f.UnselectedPrefix = f.UnselectedPrefix.Foreground(colorTextMuted)
+	f.UnselectedOption = f.UnselectedOption.Foreground(colorTextNormal)
+	f.FocusedButton = f.FocusedButton.Foreground(colorTextBright).Background(colorBgSelected)
+	f.Next = f.FocusedButton
+	f.BlurredButton = f.BlurredButton.Foreground(colorTextNormal).Background(colorBgSubtle)
+	f.TextInput.Cursor = f.TextInput.Cursor.Foreground(colorAccent)
+	f.TextInput.Placeholder = f.TextInput.Placeholder.Foreground(colorTextMuted)
+	f.TextInput.Prompt = f.TextInput.Prompt.Foreground(colorAccent)
+
+	t.Blurred = t.Focused
+	t.Blurred.Base = t.Focused.Base.BorderStyle(lipgloss.HiddenBorder())
+	t.Blurred.Card = t.Blurred.Base
+	t.Blurred.NextIndicator = lipgloss.NewStyle()
+	t.Blurred.PrevIndicator = lipgloss.NewStyle()
+	t.Group.Title = t.Focused.Title
+	t.Group.Description = t.Focused.Description
+	return t
+}
+
+// newForm creates a huh form styled with the active theme and navigated
+// with the active keymap.
+func newForm(groups ...*huh.Group) *huh.Form {
+	return huh.NewForm(groups...).WithTheme(huhTheme()).WithKeyMap(activeKeys.huhKeyMap())
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/julianshen/rubichan/internal/config"
+)
+
+func TestLoadThemeBuiltins(t *testing.T) {
+	for _, name := range BuiltinThemeNames() {
+		th, err := LoadTheme(name, t.TempDir())
+		require.NoError(t, err, name)
+		assert.Equal(t, name, th.Name)
+		assert.NotNil(t, th.Primary, name)
+		assert.NotEmpty(t, th.Banner, name)
+	}
+}
+
+func TestLoadThemeDefault(t *testing.T) {
+	th, err := LoadTheme("", t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultThemeName, th.Name)
+}
+
+func TestLoadThemeUnknown(t *testing.T) {
+	_, err := LoadTheme("nope", t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown theme")
+}
+
+func TestLoadThemeFile(t *testing.T) {
+	dir := t.TempDir()
+	data := `
+extends = "light"
+syntax = "dracula"
+banner = ["#111111", "#222222"]
+
+[colors]
+primary = "#268BD2"
+text_dim = { light = "#657B83", dark = "#839496" }
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "solar.toml"), []byte(data), 0o644))
+
+	th, err := LoadTheme("solar", dir)
+	require.NoError(t, err)
+	assert.Equal(t, "solar", th.Name)
+	assert.Equal(t, "light", th.Markdown, "inherited from light")
+	assert.Equal(t, "dracula", th.Syntax)
+	assert.Equal(t, lipgloss.Color("#268BD2"), th.Primary)
+	assert.Equal(t, adaptive("#657B83", "#839496"), th.TextDim)
+	assert.Equal(t, lightTheme().Danger, th.Danger)
+	assert.Equal(t, []lipgloss.Color{"#111111", "#222222"}, th.Banner)
+
+	assert.Contains(t, ThemeNames(dir), "solar")
+}
+
+func TestParseThemeErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown color role": "[colors]\nnope = \"#fff\"",
+		"bad color":          "[colors]\nprimary = \"pink\"",
+		"unknown base":       "extends = \"sepia\"",
+		"bad markdown":       "markdown = \"auto\"",
+		"unknown syntax":     "syntax = \"nope-style\"",
+		"unknown key":        "colour = \"#fff\"",
+		"half adaptive":      "[colors]\nprimary = { light = \"#fff\" }",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseTheme([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestApplyThemeRebuildsStyles(t *testing.T) {
+	defer ApplyTheme(rubichanTheme())
+
+	ApplyTheme(lightTheme())
+	assert.Equal(t, "light", CurrentTheme().Name)
+	assert.Equal(t, lightTheme().Primary, styleHeader.GetForeground())
+}
+
+func TestApplyUIConfig(t *testing.T) {
+	defer ApplyTheme(rubichanTheme())
+	defer ApplyKeymap(DefaultKeymap())
+
+	cfgPath := filepath.Join(t.TempDir(), "config.toml")
+	err := ApplyUIConfig(config.UIConfig{
+		Theme:  "high-contrast",
+		ViMode: true,
+		Keys:   map[string][]string{"submit": {"ctrl+s"}},
+	}, cfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, "high-contrast", CurrentTheme().Name)
+	assert.True(t, ActiveKeymap().ViMode)
+	assert.Equal(t, []string{"ctrl+s"}, ActiveKeymap().Keys(ActionSubmit))
+
+	// A bad keymap leaves the active theme alone.
+	err = ApplyUIConfig(config.UIConfig{Theme: "dark", Keys: map[string][]string{"fly": {"f"}}}, cfgPath)
+	require.Error(t, err)
+	assert.Equal(t, "high-contrast", CurrentTheme().Name)
+}
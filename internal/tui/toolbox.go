@@ -9,12 +9,6 @@ import (
 
 const maxToolResultLines = 20
 
-var (
-	diffAddedStyle   = styleDiffAdded
-	diffRemovedStyle = styleDiffRemoved
-	diffHunkStyle    = styleDiffHunk
-)
-
 // ToolBoxRenderer renders tool calls and results in bordered boxes.
 type ToolBoxRenderer struct {
 	width     int
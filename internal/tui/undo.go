@@ -45,26 +45,24 @@ func (u *UndoOverlay) Update(msg tea.Msg) (Overlay, tea.Cmd) {
 		u.cancelled = true
 		return u, nil
 	}
-	switch keyMsg.Type {
-	case tea.KeyUp:
+	switch {
+	case activeKeys.Matches(keyMsg, ActionUp):
 		u.selected--
 		if u.selected < 0 {
 			u.selected = len(u.checkpoints) - 1
 		}
-	case tea.KeyDown:
+	case activeKeys.Matches(keyMsg, ActionDown):
 		u.selected++
 		if u.selected >= len(u.checkpoints) {
 			u.selected = 0
 		}
-	case tea.KeyEnter:
+	case activeKeys.Matches(keyMsg, ActionSelect):
 		u.confirmed = true
-	case tea.KeyEscape:
+	case activeKeys.Matches(keyMsg, ActionClose):
 		u.cancelled = true
-	case tea.KeyRunes:
-		if len(keyMsg.Runes) > 0 && keyMsg.Runes[0] == 'a' {
-			u.confirmed = true
-			u.rewindAll = true
-		}
+	case keyMsg.String() == "a":
+		u.confirmed = true
+		u.rewindAll = true
 	}
 	return u, nil
 }
@@ -83,7 +81,9 @@ func (u *UndoOverlay) View() string {
 		name := filepath.Base(cp.FilePath)
 		b.WriteString(fmt.Sprintf("%s%d. %s (turn %d, %s)\n", cursor, i+1, name, cp.Turn, cp.Operation))
 	}
-	b.WriteString("\n[↑↓] navigate  [Enter] undo  [a] undo all from turn  [Esc] cancel")
+	k := activeKeys
+	fmt.Fprintf(&b, "\n[%s/%s] navigate  [%s] undo  [a] undo all from turn  [%s] cancel",
+		k.Label(ActionUp), k.Label(ActionDown), k.Label(ActionSelect), k.Label(ActionClose))
 	return styleApprovalBorder.Width(u.boxWidth()).Render(b.String())
 }
 
@@ -38,14 +38,14 @@ type turnStartedMsg struct {
 // Init implements tea.Model. It initializes the input area and starts
 // listening for approval requests from the agent.
 func (m *Model) Init() tea.Cmd {
-	return tea.Batch(m.input.Init(), m.waitForApproval())
+	return tea.Batch(m.input.Init(), m.waitForApproval(), m.waitForAsk())
 }
 
 // Update implements tea.Model. It processes incoming messages and returns the
 // updated model and any commands to execute.
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	// Ctrl+C intercept: copy selection if active, otherwise quit.
-	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.Type == tea.KeyCtrlC {
+	// Quit (Ctrl+C) intercept: copy selection if active, otherwise quit.
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && activeKeys.Matches(keyMsg, ActionQuit) {
 		// If selection is active and not empty, copy it instead of quitting.
 		if m.selection.Active && !m.selection.IsEmpty() {
 			m.copySelection()
@@ -74,6 +74,15 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Questions are routed here even while an overlay is showing, so they
+	// are queued rather than swallowed by it.
+	switch msg := msg.(type) {
+	case askRequestMsg:
+		return m, m.handleAskRequest(msg)
+	case askExpiredMsg:
+		return m, tea.Batch(m.expireAsk(msg.id), m.openQueuedAsk())
+	}
+
 	// Generic overlay delegation: route all messages to the active overlay.
 	if m.activeOverlay != nil {
 		if _, isResize := msg.(tea.WindowSizeMsg); !isResize {
@@ -83,7 +92,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				result := m.activeOverlay.Result()
 				m.activeOverlay = nil
 				followUp := m.processOverlayResult(result)
-				return m, tea.Batch(cmd, followUp)
+				return m, tea.Batch(cmd, followUp, m.openQueuedAsk())
 			}
 			return m, cmd
 		}
@@ -177,6 +186,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			input:         msg.input,
 			options:       msg.options,
 			responseValue: msg.responseValue,
+			editValue:     msg.editValue,
 		}
 		return m, m.waitForApproval()
 
@@ -238,8 +248,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // handleKeyMsg processes keyboard input.
 func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Ctrl+C: copy selection if active, otherwise quit (via doQuit).
-	if msg.Type == tea.KeyCtrlC {
+	// Quit: copy selection if active, otherwise quit (via doQuit).
+	if activeKeys.Matches(msg, ActionQuit) {
 		// If selection is active and not empty, copy it instead of quitting.
 		if m.selection.Active && !m.selection.IsEmpty() {
 			m.copySelection()
@@ -254,13 +264,15 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Completion overlay intercepts Tab/Up/Down/Escape when visible,
 	// before scroll keys can claim Up/Down.
 	if m.state == StateInput && m.completion != nil && m.completion.Visible() {
-		switch msg.Type {
-		case tea.KeyTab:
+		if activeKeys.Matches(msg, ActionComplete) {
 			if accepted, value := m.completion.HandleTab(); accepted {
 				m.input.SetValue("/" + value + " ")
 				m.syncCompletion()
 			}
 			return m, nil
+		}
+		// Arrow keys only: letters keep typing into the prompt.
+		switch msg.Type {
 		case tea.KeyUp, tea.KeyDown:
 			m.completion.HandleKey(msg)
 			return m, nil
@@ -272,8 +284,7 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	// File completion overlay for @ mentions.
 	if m.state == StateInput && m.fileCompletion != nil && m.fileCompletion.Visible() {
-		switch msg.Type {
-		case tea.KeyTab:
+		if activeKeys.Matches(msg, ActionComplete) {
 			if accepted, value := m.fileCompletion.HandleTab(); accepted {
 				// Replace the @query with the full path
 				cur := m.input.Value()
@@ -284,6 +295,8 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.syncCompletion()
 			}
 			return m, nil
+		}
+		switch msg.Type {
 		case tea.KeyUp, tea.KeyDown:
 			m.fileCompletion.HandleKey(msg)
 			return m, nil
@@ -295,14 +308,15 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	// Ctrl+P/N for input history navigation.
 	if m.state == StateInput && m.history != nil {
-		if msg.Type == tea.KeyCtrlP {
+		// In vi normal mode k/j walk the history, as in a vi-mode shell.
+		if activeKeys.Matches(msg, ActionHistoryPrev) || (m.input.ViNormal() && msg.String() == "k") {
 			if val, ok := m.history.Previous(m.input.Value()); ok {
 				m.input.SetValue(val)
 				m.syncCompletion()
 			}
 			return m, nil
 		}
-		if msg.Type == tea.KeyCtrlN {
+		if activeKeys.Matches(msg, ActionHistoryNext) || (m.input.ViNormal() && msg.String() == "j") {
 			if val, ok := m.history.Next(); ok {
 				m.input.SetValue(val)
 				m.syncCompletion()
@@ -319,40 +333,40 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	// Ctrl+T toggles collapse/expand on all tool results and thinking blocks.
-	if msg.Type == tea.KeyCtrlT && m.state == StateInput && m.content.HasCollapsible() {
+	if activeKeys.Matches(msg, ActionToggleTools) && m.state == StateInput && m.content.HasCollapsible() {
 		m.content.ToggleAllToolResults()
 		m.viewport.SetContent(m.viewportContent())
 		return m, nil
 	}
 
 	// Ctrl+E toggles full expansion on the most recent truncated tool result.
-	if msg.Type == tea.KeyCtrlE && m.state == StateInput && m.content.ToolResultCount() > 0 {
+	if activeKeys.Matches(msg, ActionExpandResult) && m.state == StateInput && m.content.ToolResultCount() > 0 {
 		m.content.ToggleFullExpandMostRecent()
 		m.viewport.SetContent(m.viewportContent())
 		return m, nil
 	}
 
-	if msg.Type == tea.KeyCtrlG && m.state == StateInput && strings.TrimSpace(m.diffSummary) != "" {
+	if activeKeys.Matches(msg, ActionToggleDiff) && m.state == StateInput && strings.TrimSpace(m.diffSummary) != "" {
 		m.diffExpanded = !m.diffExpanded
 		m.viewport.SetContent(m.viewportContent())
 		return m, nil
 	}
 
 	// Ctrl+A toggles the running agents detail panel.
-	if msg.Type == tea.KeyCtrlA && m.state == StateInput {
+	if activeKeys.Matches(msg, ActionToggleAgents) && m.state == StateInput {
 		m.agentPanelVisible = !m.agentPanelVisible
 		m.viewport.SetContent(m.viewportContent())
 		return m, nil
 	}
 
 	// Ctrl+L jumps to last error when one exists.
-	if msg.Type == tea.KeyCtrlL && m.state == StateInput && m.content.ErrorCount() > 0 {
+	if activeKeys.Matches(msg, ActionJumpError) && m.state == StateInput && m.content.ErrorCount() > 0 {
 		m.scrollToLastError()
 		return m, nil
 	}
 
 	// Ctrl+F toggles plan panel.
-	if msg.Type == tea.KeyCtrlF && m.state == StateInput {
+	if activeKeys.Matches(msg, ActionTogglePlan) && m.state == StateInput {
 		m.planPanelVisible = !m.planPanelVisible
 		m.reflowViewport()
 		m.viewport.SetContent(m.viewportContent())
@@ -360,13 +374,13 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	// ? opens help overlay.
-	if msg.String() == "?" && m.state == StateInput && m.activeOverlay == nil {
+	if activeKeys.Matches(msg, ActionHelp) && m.state == StateInput && m.activeOverlay == nil {
 		m.activeOverlay = NewHelpOverlay(m.width, m.height)
 		return m, nil
 	}
 
-	switch msg.Type {
-	case tea.KeyEnter:
+	switch {
+	case activeKeys.Matches(msg, ActionSubmit):
 		if m.state != StateInput {
 			return m, nil
 		}
@@ -465,11 +479,22 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.statusBar.ClearErrorCount()
 		m.turnStartTime = time.Now()
 
-		return m, tea.Batch(m.startTurn(m.agent, text), m.spinner.Tick)
+		attachments := m.attachments
+		m.attachments = nil
+		return m, tea.Batch(m.startTurn(m.agent, text, attachments...), m.spinner.Tick)
 
 	default:
 		// Forward key to input area
 		if m.state == StateInput {
+			// A dropped image or PDF arrives as a pasted path: attach the
+			// file and leave a label in the prompt instead.
+			if msg.Paste {
+				if att, ok := pastedAttachment(string(msg.Runes)); ok {
+					m.attachments = append(m.attachments, att)
+					m.input.SetValue(m.input.Value() + attachmentLabel(att) + " ")
+					return m, nil
+				}
+			}
 			prevHeight := m.input.Height()
 			cmd := m.input.Update(msg)
 			if m.input.Height() != prevHeight {
@@ -485,8 +510,14 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // startTurn initiates an agent turn and returns a tea.Cmd that sends back a
 // turnStartedMsg carrying the channel and first event. The agent is captured
 // as a parameter (not read from m.agent in the closure) to avoid a data race
-// between the Cmd goroutine and the Update goroutine.
-func (m *Model) startTurn(a *agent.Agent, text string) tea.Cmd {
+// between the Cmd goroutine and the Update goroutine. attachments are sent
+// after text as image or document blocks.
+func (m *Model) startTurn(a *agent.Agent, text string, attachments ...agentsdk.ContentBlock) tea.Cmd {
+	return m.startTurnWithOptions(a, text, agent.TurnOptions{Attachments: attachments})
+}
+
+// startTurnWithOptions is startTurn with full per-turn options.
+func (m *Model) startTurnWithOptions(a *agent.Agent, text string, opts agent.TurnOptions) tea.Cmd {
 	return func() tea.Msg {
 		if a == nil {
 			return TurnEventMsg(agent.TurnEvent{
@@ -496,7 +527,7 @@ func (m *Model) startTurn(a *agent.Agent, text string) tea.Cmd {
 		}
 
 		turnCtx, cancel := context.WithCancel(context.Background())
-		ch, err := a.Turn(turnCtx, text)
+		ch, err := a.TurnWithOptions(turnCtx, text, opts)
 		if err != nil {
 			cancel()
 			return TurnEventMsg(agent.TurnEvent{
@@ -681,6 +712,21 @@ func (m *Model) handleTurnEvent(msg TurnEventMsg) (tea.Model, tea.Cmd) {
 		m.setContentAndAutoScroll()
 		return m, m.waitForEvent()
 
+	case "plan_update":
+		if m.sessionState != nil {
+			m.sessionState.ApplyEvent(agentsdk.TurnEvent(msg))
+			m.emitSessionEvent(session.NewPlanUpdatedEvent("todo", m.sessionState.Plan()))
+		}
+		// The model's first todo list opens the plan panel; after that the
+		// panel stays where the user puts it.
+		if !m.todoPanelShown && len(msg.Plan) > 0 {
+			m.todoPanelShown = true
+			m.planPanelVisible = true
+		}
+		m.reflowViewport()
+		m.setContentAndAutoScroll()
+		return m, m.waitForEvent()
+
 	case "tool_progress":
 		if msg.ToolProgress != nil {
 			m.content.WriteString(m.toolBox.RenderToolProgress(
@@ -850,7 +896,10 @@ func (m *Model) handleTurnEvent(msg TurnEventMsg) (tea.Model, tea.Cmd) {
 				m.statusBar.SetContextWarning("")
 			}
 		}
-		cost := EstimateCost(m.modelName, msg.InputTokens, msg.OutputTokens)
+		cost := msg.CostUSD
+		if cost == 0 {
+			cost = EstimateCost(m.modelName, msg.InputTokens, msg.OutputTokens)
+		}
 		m.totalCost += cost
 		m.statusBar.SetCost(m.totalCost)
 		m.emitSessionEvent(session.NewTurnCompletedEvent(msg.DiffSummary, msg.InputTokens, msg.OutputTokens))
@@ -8,12 +8,6 @@ import (
 	"github.com/julianshen/rubichan/internal/persona"
 )
 
-// headerStyle and inputPromptStyle are aliases for the centralized pink theme styles.
-var (
-	headerStyle      = styleHeader
-	inputPromptStyle = styleInputPrompt
-)
-
 // View implements tea.Model. It renders the TUI as a string.
 func (m *Model) View() string {
 	if m.quitting {
@@ -64,7 +58,7 @@ func (m *Model) View() string {
 	}
 
 	// Header
-	header := headerStyle.Render(fmt.Sprintf("%s · %s", m.appName, m.modelName))
+	header := styleHeader.Render(fmt.Sprintf("%s · %s", m.appName, m.modelName))
 	b.WriteString(header)
 	b.WriteString("\n")
 	if line := m.activeSkillsLine(); line != "" {
@@ -140,7 +134,11 @@ func (m *Model) View() string {
 	}
 
 	// Input line
-	b.WriteString(inputPromptStyle.Render("❯ "))
+	prompt := "❯ "
+	if m.input.ViNormal() {
+		prompt = "❮ " // vi normal mode
+	}
+	b.WriteString(styleInputPrompt.Render(prompt))
 	b.WriteString(m.input.View())
 
 	return b.String()
@@ -54,6 +54,7 @@ type WikiForm struct {
 	Format         string
 	OutDir         string
 	ConcurrencyStr string
+	Since          string
 	workDir        string
 }
 
@@ -77,6 +78,7 @@ func NewWikiForm(workDir string) *WikiForm {
 				huh.NewOption("Raw Markdown", "raw-md"),
 				huh.NewOption("Hugo", "hugo"),
 				huh.NewOption("Docusaurus", "docusaurus"),
+				huh.NewOption("HTML", "html"),
 			).
 			Value(&wf.Format),
 		huh.NewInput().
@@ -85,9 +87,13 @@ func NewWikiForm(workDir string) *WikiForm {
 		huh.NewInput().
 			Title("Concurrency").
 			Value(&wf.ConcurrencyStr),
+		huh.NewInput().
+			Title("Changes Since").
+			Description("Git ref to update the wiki from; blank regenerates everything").
+			Value(&wf.Since),
 	).Title("Wiki Generation")
 
-	wf.form = huh.NewForm(group)
+	wf.form = newForm(group)
 	return wf
 }
 
@@ -176,6 +182,7 @@ func (m *Model) startWikiGeneration(wf *WikiForm) tea.Cmd {
 		OutputDir:   outDir,
 		Format:      wf.Format,
 		Concurrency: wf.Concurrency(),
+		Since:       strings.TrimSpace(wf.Since),
 	}
 
 	return func() tea.Msg {
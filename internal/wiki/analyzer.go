@@ -21,6 +21,13 @@ type LLMCompleter interface {
 // AnalyzerConfig controls the analyzer behavior.
 type AnalyzerConfig struct {
 	Concurrency int // max concurrent LLM calls in pass 1
+
+	// Cache, when non-nil, serves and records module analyses and the
+	// architecture synthesis.
+	Cache *AnalysisCache
+	// Changed lists files whose chunks are re-analyzed even when the cache
+	// holds a result for them.
+	Changed map[string]bool
 }
 
 // DefaultAnalyzerConfig returns sensible defaults for analysis.
@@ -85,6 +92,9 @@ func AnalyzeBase(ctx context.Context, chunks []Chunk, llm LLMCompleter, cfg Anal
 	summariesText := buildSummariesText(modules)
 
 	// Pass 2: architecture synthesis.
+	if cfg.Cache != nil && llm != nil {
+		llm = cfg.Cache.Completer(llm)
+	}
 	architecture, keyAbstractions, err := synthesizeArchitecture(ctx, summariesText, llm)
 	if err != nil {
 		return nil, err
@@ -186,8 +196,10 @@ func extractSuggestionsFromDocs(docs []Document) []string {
 	return suggestions
 }
 
-// analyzeModules runs pass 1: concurrent per-module summarization.
-// Results are sorted by module name for deterministic output.
+// analyzeModules runs pass 1: concurrent per-module summarization. Chunks
+// the cache holds a result for are not sent to the LLM unless one of their
+// files is in cfg.Changed. Results are sorted by module name for
+// deterministic output.
 func analyzeModules(ctx context.Context, chunks []Chunk, llm LLMCompleter, cfg AnalyzerConfig) ([]ModuleAnalysis, error) {
 	concurrency := cfg.Concurrency
 	if concurrency <= 0 {
@@ -201,6 +213,14 @@ func analyzeModules(ctx context.Context, chunks []Chunk, llm LLMCompleter, cfg A
 
 	for _, chunk := range chunks {
 		chunk := chunk // capture loop variable
+		if cfg.Cache != nil && !chunkChanged(chunk, cfg.Changed) {
+			if analysis, ok := cfg.Cache.chunk(chunk); ok {
+				mu.Lock()
+				results = append(results, analysis)
+				mu.Unlock()
+				continue
+			}
+		}
 		p.Go(func() {
 			analysis, err := analyzeModule(ctx, chunk, llm)
 			if err != nil {
@@ -210,6 +230,9 @@ func analyzeModules(ctx context.Context, chunks []Chunk, llm LLMCompleter, cfg A
 				log.Printf("WARNING: module %q analysis failed: %v", chunk.Module, err)
 				return // non-fatal: skip this module
 			}
+			if cfg.Cache != nil {
+				cfg.Cache.putChunk(chunk, analysis)
+			}
 			mu.Lock()
 			results = append(results, analysis)
 			mu.Unlock()
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"text/template"
 )
@@ -45,8 +46,10 @@ Patterns:
 Produce clear, well-organized markdown documentation. Include a brief description of each endpoint/service/command.`))
 
 // Analyze generates API surface documentation from the detected patterns.
-// Always produces api/_index.md when patterns exist. Only generates per-kind
-// docs for groups that have at least one pattern. LLM errors are non-fatal.
+// Always produces api/_index.md when patterns exist, followed by a reference
+// generated from input.APISpec. Only generates per-kind docs for groups that
+// have at least one pattern. LLM errors are non-fatal. Breaking changes since
+// input.PreviousAPI become the index's change history entry.
 func (a *APIAnalyzer) Analyze(ctx context.Context, input AnalyzerInput) (*AnalyzerOutput, error) {
 	if len(input.APIPatterns) == 0 {
 		return &AnalyzerOutput{}, nil
@@ -84,6 +87,10 @@ func (a *APIAnalyzer) Analyze(ctx context.Context, input AnalyzerInput) (*Analyz
 	// Build the overview index AFTER generating per-kind docs, only linking
 	// to kinds that actually produced output.
 	indexDoc := buildAPIIndexDoc(byKind, succeededKinds)
+	if input.APISpec != nil {
+		indexDoc.Content += buildAPIReference(input.APISpec)
+	}
+	indexDoc.ChangeSummary = breakingChangeSummary(BreakingChanges(DiffAPISpecs(input.PreviousAPI, input.APISpec)))
 	docs = append([]Document{indexDoc}, docs...)
 
 	return &AnalyzerOutput{Documents: docs}, nil
@@ -135,7 +142,7 @@ func buildAPIIndexDoc(byKind map[string][]APIPattern, succeededKinds map[string]
 		}
 		if succeededKinds[grp.kind] {
 			fmt.Fprintf(&sb, "- **%s** — %d pattern(s) detected → [%s](%s)\n",
-				grp.title, len(patterns), grp.title, grp.path)
+				grp.title, len(patterns), grp.title, strings.TrimPrefix(grp.path, "api/"))
 		} else {
 			fmt.Fprintf(&sb, "- **%s** — %d pattern(s) detected\n",
 				grp.title, len(patterns))
@@ -159,3 +166,178 @@ func buildAPIIndexDoc(byKind map[string][]APIPattern, succeededKinds map[string]
 		Content: sb.String(),
 	}
 }
+
+// maxSummarizedBreakingChanges caps the breaking changes named in a change
+// history entry.
+const maxSummarizedBreakingChanges = 5
+
+// breakingChangeSummary condenses breaking API changes into one change
+// history entry. Returns "" when there are none.
+func breakingChangeSummary(breaking []string) string {
+	if len(breaking) == 0 {
+		return ""
+	}
+	shown := breaking
+	if len(shown) > maxSummarizedBreakingChanges {
+		shown = shown[:maxSummarizedBreakingChanges]
+	}
+	summary := "Breaking API changes: " + strings.Join(shown, "; ")
+	if more := len(breaking) - len(shown); more > 0 {
+		summary += fmt.Sprintf("; and %d more", more)
+	}
+	return summary
+}
+
+// buildAPIReference renders the API spec as reference tables, linking the
+// machine-readable files written next to the wiki.
+func buildAPIReference(spec *APISpec) string {
+	var sb strings.Builder
+	sb.WriteString("\n## Machine-Readable Specs\n\n")
+	if spec.HTTP != nil {
+		fmt.Fprintf(&sb, "- [OpenAPI %s document](%s)\n", spec.HTTP.OpenAPI, strings.TrimPrefix(openAPIArtifactPath, "api/"))
+	}
+	if spec.GRPC != nil {
+		fmt.Fprintf(&sb, "- [gRPC service listing](%s)\n", strings.TrimPrefix(grpcArtifactPath, "api/"))
+	}
+
+	if doc := spec.HTTP; doc != nil {
+		sb.WriteString("\n## HTTP Operations\n\n")
+		sb.WriteString("| Method | Path | Parameters | Request | Response | Source |\n")
+		sb.WriteString("|---|---|---|---|---|---|\n")
+		paths := make([]string, 0, len(doc.Paths))
+		for path := range doc.Paths {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			for _, method := range httpMethods {
+				op := doc.Paths[path][method]
+				if op == nil {
+					continue
+				}
+				label := strings.ToUpper(method)
+				if op.AnyMethod {
+					label = "ANY"
+				}
+				var params []string
+				for _, p := range op.Parameters {
+					params = append(params, fmt.Sprintf("`%s` (%s, %s)", p.Name, p.In, schemaLabel(p.Schema)))
+				}
+				request := "—"
+				if op.RequestBody != nil {
+					request = schemaLabel(jsonBodySchema(op.RequestBody.Content))
+				}
+				fmt.Fprintf(&sb, "| %s | `%s` | %s | %s | %s | `%s` |\n",
+					label, tableCell(path), orDash(strings.Join(params, ", ")), request, responsesLabel(op.Responses), op.Source)
+			}
+		}
+
+		if doc.Components != nil && len(doc.Components.Schemas) > 0 {
+			sb.WriteString("\n## Schemas\n")
+			names := make([]string, 0, len(doc.Components.Schemas))
+			for name := range doc.Components.Schemas {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				s := doc.Components.Schemas[name]
+				fmt.Fprintf(&sb, "\n### %s\n\n", name)
+				if len(s.Properties) == 0 {
+					fmt.Fprintf(&sb, "Type: %s\n", schemaLabel(s))
+					continue
+				}
+				required := stringSet(s.Required)
+				sb.WriteString("| Field | Type | Required |\n|---|---|---|\n")
+				fields := make([]string, 0, len(s.Properties))
+				for field := range s.Properties {
+					fields = append(fields, field)
+				}
+				sort.Strings(fields)
+				for _, field := range fields {
+					req := "no"
+					if required[field] {
+						req = "yes"
+					}
+					fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", field, schemaLabel(s.Properties[field]), req)
+				}
+			}
+		}
+	}
+
+	if g := spec.GRPC; g != nil {
+		sb.WriteString("\n## gRPC Reference\n")
+		for _, svc := range g.Services {
+			fmt.Fprintf(&sb, "\n### %s\n\nDefined in `%s`.\n\n", svc.Name, svc.File)
+			sb.WriteString("| RPC | Request | Response |\n|---|---|---|\n")
+			for _, m := range svc.Methods {
+				fmt.Fprintf(&sb, "| `%s` | `%s` | `%s` |\n", m.Name, rpcType(m.Request, m.ClientStreaming), rpcType(m.Response, m.ServerStreaming))
+			}
+		}
+		for _, msg := range g.Messages {
+			fmt.Fprintf(&sb, "\n### %s\n\n", msg.Name)
+			if len(msg.Fields) == 0 {
+				sb.WriteString("No fields.\n")
+				continue
+			}
+			sb.WriteString("| # | Field | Type |\n|---|---|---|\n")
+			for _, f := range msg.Fields {
+				typ := f.Type
+				if f.Label != "" {
+					typ = f.Label + " " + typ
+				}
+				fmt.Fprintf(&sb, "| %d | `%s` | `%s` |\n", f.Number, f.Name, tableCell(typ))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// schemaLabel describes a schema in a few words for a table cell.
+func schemaLabel(s *JSONSchema) string {
+	switch {
+	case s == nil:
+		return "—"
+	case s.Ref != "":
+		return "`" + strings.TrimPrefix(s.Ref, componentRefPrefix) + "`"
+	case s.Type == "array":
+		return "array of " + schemaLabel(s.Items)
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		return "map of " + schemaLabel(s.AdditionalProperties)
+	case s.Type == "":
+		return "any"
+	case s.Format != "":
+		return s.Type + " (" + s.Format + ")"
+	default:
+		return s.Type
+	}
+}
+
+// responsesLabel lists an operation's statuses and their bodies.
+func responsesLabel(responses map[string]OpenAPIResponse) string {
+	statuses := make([]string, 0, len(responses))
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	parts := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		part := status
+		if schema := jsonBodySchema(responses[status].Content); schema != nil {
+			part += " " + schemaLabel(schema)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// tableCell escapes pipes so text stays in one Markdown table cell.
+func tableCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
@@ -120,3 +120,58 @@ func findDoc(docs []Document, path string) *Document {
 	}
 	return nil
 }
+
+func TestAPIAnalyzer_Reference(t *testing.T) {
+	a := NewAPIAnalyzer(&mockLLMCompleter{})
+
+	current := &APISpec{
+		HTTP: testOpenAPIDoc(map[string]OpenAPIPathItem{
+			"/users/{id}": {"get": {
+				Parameters: []OpenAPIParameter{{Name: "id", In: "path", Required: true, Schema: &JSONSchema{Type: "integer"}}},
+				Responses:  map[string]OpenAPIResponse{"200": {Content: jsonContent(&JSONSchema{Ref: componentRefPrefix + "User"})}},
+				Source:     "handler.go:10",
+			}},
+		}, map[string]*JSONSchema{
+			"User": userSchema([]string{"id"}, map[string]*JSONSchema{
+				"id":   {Type: "integer"},
+				"tags": {Type: "array", Items: &JSONSchema{Type: "string"}},
+			}),
+		}),
+		GRPC: &GRPCSpec{Services: []GRPCService{{Name: "shop.Orders", File: "shop.proto", Methods: []GRPCMethod{
+			{Name: "Watch", Request: "shop.WatchRequest", Response: "shop.Order", ServerStreaming: true},
+		}}}},
+	}
+	input := AnalyzerInput{
+		APIPatterns: []APIPattern{
+			{Kind: "http", Method: "GET", Path: "/users/{id}", Handler: "GetUser", File: "handler.go", Line: 10, Language: "go"},
+		},
+		APISpec: current,
+	}
+
+	out, err := a.Analyze(context.Background(), input)
+	require.NoError(t, err)
+	index := findDoc(out.Documents, "api/_index.md")
+	require.NotNil(t, index)
+	assert.Contains(t, index.Content, "[HTTP Endpoints](http-endpoints.md)")
+	assert.Contains(t, index.Content, "[OpenAPI 3.1.0 document](openapi.json)")
+	assert.Contains(t, index.Content, "[gRPC service listing](grpc-services.json)")
+	assert.Contains(t, index.Content, "| GET | `/users/{id}` | `id` (path, integer) | — | 200 `User` | `handler.go:10` |")
+	assert.Contains(t, index.Content, "| `tags` | array of string | no |")
+	assert.Contains(t, index.Content, "| `Watch` | `shop.WatchRequest` | `stream shop.Order` |")
+	assert.Empty(t, index.ChangeSummary, "no previous spec")
+
+	input.PreviousAPI = &APISpec{HTTP: testOpenAPIDoc(map[string]OpenAPIPathItem{
+		"/users": {"get": {Responses: map[string]OpenAPIResponse{"200": {}}}},
+	}, nil)}
+	out, err = a.Analyze(context.Background(), input)
+	require.NoError(t, err)
+	index = findDoc(out.Documents, "api/_index.md")
+	require.NotNil(t, index)
+	assert.Equal(t, "Breaking API changes: removed `GET /users`", index.ChangeSummary)
+}
+
+func TestBreakingChangeSummary(t *testing.T) {
+	assert.Empty(t, breakingChangeSummary(nil))
+	assert.Equal(t, "Breaking API changes: a; b; c; d; e; and 2 more",
+		breakingChangeSummary([]string{"a", "b", "c", "d", "e", "f", "g"}))
+}
@@ -0,0 +1,450 @@
+package wiki
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	openAPIArtifactPath = "api/openapi.json"
+	grpcArtifactPath    = "api/grpc-services.json"
+)
+
+// APISpec is the machine-readable API surface of a repository: an OpenAPI
+// document for its HTTP routes and the gRPC services of its .proto files.
+// Either part is nil when the repository has none.
+type APISpec struct {
+	HTTP *OpenAPIDocument
+	GRPC *GRPCSpec
+}
+
+// BuildAPISpec builds the API spec from the detected API patterns and the
+// scanned files. Returns nil when there is nothing to describe.
+func BuildAPISpec(patterns []APIPattern, files []ScannedFile, sources SourceReader) *APISpec {
+	spec := &APISpec{
+		HTTP: BuildOpenAPI(patterns, files, sources),
+		GRPC: BuildGRPCSpec(files, sources),
+	}
+	if spec.HTTP == nil && spec.GRPC == nil {
+		return nil
+	}
+	return spec
+}
+
+// artifactPath is where a machine-readable file belongs in the output of
+// cfg. Site generators serve such files from their static directory.
+func artifactPath(cfg RendererConfig, rel string) string {
+	switch cfg.Format {
+	case "hugo", "docusaurus":
+		return filepath.Join(cfg.OutputDir, "static", filepath.FromSlash(rel))
+	default:
+		return filepath.Join(cfg.OutputDir, filepath.FromSlash(rel))
+	}
+}
+
+// WriteAPISpec writes the parts of spec as pretty-printed JSON next to the
+// wiki, and removes the files of parts a previous run wrote that no longer
+// exist. Returns the paths written, relative to the wiki root.
+func WriteAPISpec(spec *APISpec, cfg RendererConfig) ([]string, error) {
+	if spec == nil {
+		spec = &APISpec{}
+	}
+	var written []string
+	for _, part := range []struct {
+		path  string
+		value any
+		ok    bool
+	}{
+		{openAPIArtifactPath, spec.HTTP, spec.HTTP != nil},
+		{grpcArtifactPath, spec.GRPC, spec.GRPC != nil},
+	} {
+		path := artifactPath(cfg, part.path)
+		if !part.ok {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return written, fmt.Errorf("removing %s: %w", path, err)
+			}
+			continue
+		}
+		data, err := json.MarshalIndent(part.value, "", "  ")
+		if err != nil {
+			return written, fmt.Errorf("encoding %s: %w", part.path, err)
+		}
+		if err := writeDoc(path, string(data)+"\n"); err != nil {
+			return written, err
+		}
+		written = append(written, part.path)
+	}
+	return written, nil
+}
+
+// LoadAPISpec reads the API spec a previous run wrote for cfg. Returns nil
+// when there is none.
+func LoadAPISpec(cfg RendererConfig) (*APISpec, error) {
+	spec := &APISpec{}
+	for _, part := range []struct {
+		path string
+		dst  any
+	}{
+		{openAPIArtifactPath, &spec.HTTP},
+		{grpcArtifactPath, &spec.GRPC},
+	} {
+		path := artifactPath(cfg, part.path)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		if err := json.Unmarshal(data, part.dst); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	if spec.HTTP == nil && spec.GRPC == nil {
+		return nil, nil
+	}
+	return spec, nil
+}
+
+// APIChange is a difference between the API specs of two runs.
+type APIChange struct {
+	Breaking    bool
+	Description string // Markdown
+}
+
+// DiffAPISpecs reports how the API changed from old to new. Removing
+// operations, services, methods, fields or response properties, changing
+// types, and newly requiring parameters or request properties are breaking;
+// additions are not. Returns nil when there is no old spec to compare with.
+func DiffAPISpecs(old, new *APISpec) []APIChange {
+	if old == nil {
+		return nil
+	}
+	if new == nil {
+		new = &APISpec{}
+	}
+	var changes []APIChange
+	changes = append(changes, diffOpenAPI(old.HTTP, new.HTTP)...)
+	changes = append(changes, diffGRPC(old.GRPC, new.GRPC)...)
+	return changes
+}
+
+// BreakingChanges returns the descriptions of the breaking changes.
+func BreakingChanges(changes []APIChange) []string {
+	var out []string
+	for _, c := range changes {
+		if c.Breaking {
+			out = append(out, c.Description)
+		}
+	}
+	return out
+}
+
+// pathParamRe matches path template parameters.
+var pathParamRe = regexp.MustCompile(`\{[^}]*\}`)
+
+// httpOperation is an operation with the document it belongs to.
+type httpOperation struct {
+	label string // e.g. "GET /users/{id}"
+	op    *OpenAPIOperation
+}
+
+// operationsByRoute indexes a document's operations by method and path,
+// ignoring the names of path parameters.
+func operationsByRoute(doc *OpenAPIDocument) (map[string]httpOperation, []string) {
+	ops := make(map[string]httpOperation)
+	var keys []string
+	if doc == nil {
+		return ops, nil
+	}
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			key := method + " " + pathParamRe.ReplaceAllString(path, "{}")
+			ops[key] = httpOperation{label: strings.ToUpper(method) + " " + path, op: op}
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return ops, keys
+}
+
+func diffOpenAPI(old, new *OpenAPIDocument) []APIChange {
+	oldOps, oldKeys := operationsByRoute(old)
+	newOps, newKeys := operationsByRoute(new)
+	var changes []APIChange
+	for _, key := range oldKeys {
+		o := oldOps[key]
+		n, ok := newOps[key]
+		if !ok {
+			changes = append(changes, APIChange{Breaking: true, Description: fmt.Sprintf("removed `%s`", o.label)})
+			continue
+		}
+		d := &schemaDiff{old: old, new: new, op: n.label, seen: make(map[string]bool)}
+		d.operation(o.op, n.op)
+		changes = append(changes, d.changes...)
+	}
+	for _, key := range newKeys {
+		if _, ok := oldOps[key]; !ok {
+			changes = append(changes, APIChange{Description: fmt.Sprintf("added `%s`", newOps[key].label)})
+		}
+	}
+	return changes
+}
+
+// schemaDiff compares one operation across two documents.
+type schemaDiff struct {
+	old, new *OpenAPIDocument
+	op       string
+	seen     map[string]bool
+	changes  []APIChange
+}
+
+func (d *schemaDiff) add(breaking bool, format string, args ...any) {
+	d.changes = append(d.changes, APIChange{
+		Breaking:    breaking,
+		Description: fmt.Sprintf("`%s` ", d.op) + fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *schemaDiff) operation(old, new *OpenAPIOperation) {
+	oldParams := make(map[string]OpenAPIParameter)
+	var oldPath, newPath []OpenAPIParameter
+	for _, p := range old.Parameters {
+		oldParams[p.In+":"+p.Name] = p
+		if p.In == "path" {
+			oldPath = append(oldPath, p)
+		}
+	}
+	for _, p := range new.Parameters {
+		if p.In == "path" {
+			newPath = append(newPath, p)
+			continue
+		}
+		if op, ok := oldParams[p.In+":"+p.Name]; p.Required && (!ok || !op.Required) {
+			d.add(true, "requires %s parameter `%s`", p.In, p.Name)
+		}
+	}
+	// Path parameters are matched by position, since their names may change.
+	for i := range oldPath {
+		if i < len(newPath) {
+			d.schema("path parameter `"+newPath[i].Name+"`", oldPath[i].Schema, newPath[i].Schema, true)
+		}
+	}
+
+	switch {
+	case old.RequestBody == nil && new.RequestBody != nil && new.RequestBody.Required:
+		d.add(true, "requires a request body")
+	case old.RequestBody != nil && new.RequestBody != nil:
+		d.schema("request body", jsonBodySchema(old.RequestBody.Content), jsonBodySchema(new.RequestBody.Content), true)
+	}
+
+	for status, resp := range old.Responses {
+		newResp, ok := new.Responses[status]
+		if !ok {
+			if isSuccess(status) {
+				d.add(true, "no longer responds %s", status)
+			}
+			continue
+		}
+		d.schema("response "+status, jsonBodySchema(resp.Content), jsonBodySchema(newResp.Content), false)
+	}
+}
+
+func jsonBodySchema(content map[string]OpenAPIMediaType) *JSONSchema {
+	return content["application/json"].Schema
+}
+
+// schema compares the schemas of a value. For request values, newly
+// required properties break clients; for responses, removed ones do.
+func (d *schemaDiff) schema(where string, old, new *JSONSchema, request bool) {
+	// Follow each reference once per descent so recursive types terminate.
+	for _, key := range []string{refKey("old", old), refKey("new", new)} {
+		if key == "" {
+			continue
+		}
+		if d.seen[key] {
+			return
+		}
+		d.seen[key] = true
+		defer delete(d.seen, key)
+	}
+	old, new = resolveRef(d.old, old), resolveRef(d.new, new)
+	if old == nil || new == nil || old.Type == "" || new.Type == "" {
+		return // unknown on one side
+	}
+	if old.Type != new.Type {
+		d.add(true, "%s changed type from %s to %s", where, old.Type, new.Type)
+		return
+	}
+	switch old.Type {
+	case "array":
+		d.schema(where+"[]", old.Items, new.Items, request)
+	case "object":
+		names := make([]string, 0, len(old.Properties)+len(new.Properties))
+		for name := range old.Properties {
+			names = append(names, name)
+		}
+		for name := range new.Properties {
+			if _, ok := old.Properties[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		oldRequired, newRequired := stringSet(old.Required), stringSet(new.Required)
+		for _, name := range names {
+			o, inOld := old.Properties[name]
+			n, inNew := new.Properties[name]
+			field := where + " field `" + name + "`"
+			switch {
+			case inOld && !inNew:
+				if !request {
+					d.add(true, "removed %s", field)
+				}
+			case request && newRequired[name] && !oldRequired[name]:
+				d.add(true, "requires %s", field)
+			case !request && oldRequired[name] && !newRequired[name] && inNew:
+				d.add(true, "made %s optional", field)
+			}
+			if inOld && inNew {
+				d.schema(where+"."+name, o, n, request)
+			}
+		}
+	}
+}
+
+func refKey(side string, s *JSONSchema) string {
+	if s == nil || s.Ref == "" {
+		return ""
+	}
+	return side + ":" + s.Ref
+}
+
+// resolveRef follows a reference to a component schema of doc.
+func resolveRef(doc *OpenAPIDocument, s *JSONSchema) *JSONSchema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	if doc == nil || doc.Components == nil {
+		return nil
+	}
+	return doc.Components.Schemas[strings.TrimPrefix(s.Ref, componentRefPrefix)]
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func diffGRPC(old, new *GRPCSpec) []APIChange {
+	if old == nil {
+		old = &GRPCSpec{}
+	}
+	if new == nil {
+		new = &GRPCSpec{}
+	}
+	var changes []APIChange
+	add := func(breaking bool, format string, args ...any) {
+		changes = append(changes, APIChange{Breaking: breaking, Description: fmt.Sprintf(format, args...)})
+	}
+
+	newServices := make(map[string]GRPCService)
+	for _, s := range new.Services {
+		newServices[s.Name] = s
+	}
+	oldServices := make(map[string]bool)
+	for _, osvc := range old.Services {
+		oldServices[osvc.Name] = true
+		ns, ok := newServices[osvc.Name]
+		if !ok {
+			add(true, "removed service `%s`", osvc.Name)
+			continue
+		}
+		newMethods := make(map[string]GRPCMethod)
+		for _, m := range ns.Methods {
+			newMethods[m.Name] = m
+		}
+		oldMethods := make(map[string]bool)
+		for _, om := range osvc.Methods {
+			oldMethods[om.Name] = true
+			rpc := osvc.Name + "/" + om.Name
+			nm, ok := newMethods[om.Name]
+			switch {
+			case !ok:
+				add(true, "removed rpc `%s`", rpc)
+			case om.Request != nm.Request || om.ClientStreaming != nm.ClientStreaming:
+				add(true, "`%s` request changed from `%s` to `%s`", rpc, rpcType(om.Request, om.ClientStreaming), rpcType(nm.Request, nm.ClientStreaming))
+			case om.Response != nm.Response || om.ServerStreaming != nm.ServerStreaming:
+				add(true, "`%s` response changed from `%s` to `%s`", rpc, rpcType(om.Response, om.ServerStreaming), rpcType(nm.Response, nm.ServerStreaming))
+			}
+		}
+		for _, nm := range ns.Methods {
+			if !oldMethods[nm.Name] {
+				add(false, "added rpc `%s/%s`", ns.Name, nm.Name)
+			}
+		}
+	}
+	for _, ns := range new.Services {
+		if !oldServices[ns.Name] {
+			add(false, "added service `%s`", ns.Name)
+		}
+	}
+
+	newMessages := make(map[string]GRPCMessage)
+	for _, m := range new.Messages {
+		newMessages[m.Name] = m
+	}
+	for _, om := range old.Messages {
+		nm, ok := newMessages[om.Name]
+		if !ok {
+			add(true, "removed message `%s`", om.Name)
+			continue
+		}
+		newFields := make(map[int]GRPCField)
+		for _, f := range nm.Fields {
+			newFields[f.Number] = f
+		}
+		oldFields := make(map[int]bool)
+		for _, of := range om.Fields {
+			oldFields[of.Number] = true
+			nf, ok := newFields[of.Number]
+			switch {
+			case !ok:
+				add(true, "removed field %d (`%s`) from `%s`", of.Number, of.Name, om.Name)
+			case of.Type != nf.Type || (of.Label == "repeated") != (nf.Label == "repeated"):
+				add(true, "field %d of `%s` changed type from `%s` to `%s`", of.Number, om.Name, fieldType(of), fieldType(nf))
+			case of.Name != nf.Name:
+				add(true, "renamed field %d of `%s` from `%s` to `%s`", of.Number, om.Name, of.Name, nf.Name)
+			}
+		}
+		for _, nf := range nm.Fields {
+			if !oldFields[nf.Number] {
+				add(false, "added field `%s` to `%s`", nf.Name, nm.Name)
+			}
+		}
+	}
+	return changes
+}
+
+func rpcType(typ string, stream bool) string {
+	if stream {
+		return "stream " + typ
+	}
+	return typ
+}
+
+func fieldType(f GRPCField) string {
+	if f.Label == "repeated" {
+		return "repeated " + f.Type
+	}
+	return f.Type
+}
@@ -0,0 +1,195 @@
+package wiki
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOpenAPIDoc(paths map[string]OpenAPIPathItem, schemas map[string]*JSONSchema) *OpenAPIDocument {
+	doc := &OpenAPIDocument{OpenAPI: OpenAPIVersion, Paths: paths}
+	if schemas != nil {
+		doc.Components = &OpenAPIComponents{Schemas: schemas}
+	}
+	return doc
+}
+
+func jsonContent(s *JSONSchema) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{"application/json": {Schema: s}}
+}
+
+func userSchema(required []string, props map[string]*JSONSchema) *JSONSchema {
+	return &JSONSchema{Type: "object", Properties: props, Required: required}
+}
+
+func TestDiffAPISpecsHTTP(t *testing.T) {
+	ref := &JSONSchema{Ref: componentRefPrefix + "User"}
+	old := testOpenAPIDoc(map[string]OpenAPIPathItem{
+		"/users/{id}": {
+			"get": {
+				Parameters: []OpenAPIParameter{{Name: "id", In: "path", Required: true, Schema: &JSONSchema{Type: "string"}}},
+				Responses:  map[string]OpenAPIResponse{"200": {Content: jsonContent(ref)}},
+			},
+			"delete": {Responses: map[string]OpenAPIResponse{"204": {}}},
+		},
+		"/users": {
+			"post": {
+				RequestBody: &OpenAPIRequestBody{Required: true, Content: jsonContent(ref)},
+				Responses:   map[string]OpenAPIResponse{"201": {Content: jsonContent(ref)}},
+			},
+		},
+	}, map[string]*JSONSchema{
+		"User": userSchema([]string{"id", "name"}, map[string]*JSONSchema{
+			"id":    {Type: "integer"},
+			"name":  {Type: "string"},
+			"email": {Type: "string"},
+			"self":  ref,
+		}),
+	})
+	new := testOpenAPIDoc(map[string]OpenAPIPathItem{
+		"/users/{userID}": {
+			"get": {
+				Parameters: []OpenAPIParameter{
+					{Name: "userID", In: "path", Required: true, Schema: &JSONSchema{Type: "integer"}},
+					{Name: "fields", In: "query", Required: true, Schema: &JSONSchema{Type: "string"}},
+				},
+				Responses: map[string]OpenAPIResponse{"200": {Content: jsonContent(ref)}},
+			},
+		},
+		"/users": {
+			"post": {
+				RequestBody: &OpenAPIRequestBody{Required: true, Content: jsonContent(ref)},
+				Responses:   map[string]OpenAPIResponse{"201": {Content: jsonContent(ref)}},
+			},
+		},
+		"/health": {"get": {Responses: map[string]OpenAPIResponse{"200": {}}}},
+	}, map[string]*JSONSchema{
+		"User": userSchema([]string{"id", "name", "email"}, map[string]*JSONSchema{
+			"id":    {Type: "string"},
+			"email": {Type: "string"},
+			"self":  ref,
+		}),
+	})
+
+	changes := DiffAPISpecs(&APISpec{HTTP: old}, &APISpec{HTTP: new})
+	assert.ElementsMatch(t, []string{
+		"removed `DELETE /users/{id}`",
+		"`GET /users/{userID}` requires query parameter `fields`",
+		"`GET /users/{userID}` path parameter `userID` changed type from string to integer",
+		"`GET /users/{userID}` response 200.id changed type from integer to string",
+		"`GET /users/{userID}` removed response 200 field `name`",
+		"`POST /users` request body.id changed type from integer to string",
+		"`POST /users` requires request body field `email`",
+		"`POST /users` response 201.id changed type from integer to string",
+		"`POST /users` removed response 201 field `name`",
+	}, BreakingChanges(changes))
+	assert.Contains(t, changes, APIChange{Description: "added `GET /health`"})
+
+	assert.Nil(t, DiffAPISpecs(nil, &APISpec{HTTP: new}), "nothing to compare with on the first run")
+	assert.Empty(t, BreakingChanges(DiffAPISpecs(&APISpec{HTTP: new}, &APISpec{HTTP: new})))
+}
+
+func TestDiffAPISpecsGRPC(t *testing.T) {
+	old := &GRPCSpec{
+		Services: []GRPCService{
+			{Name: "shop.Orders", Methods: []GRPCMethod{
+				{Name: "Get", Request: "shop.GetRequest", Response: "shop.Order"},
+				{Name: "Watch", Request: "shop.WatchRequest", Response: "shop.Order", ServerStreaming: true},
+				{Name: "Cancel", Request: "shop.CancelRequest", Response: "shop.Order"},
+			}},
+			{Name: "shop.Legacy"},
+		},
+		Messages: []GRPCMessage{
+			{Name: "shop.Order", Fields: []GRPCField{
+				{Name: "id", Number: 1, Type: "string"},
+				{Name: "items", Number: 2, Type: "shop.Item", Label: "repeated"},
+				{Name: "note", Number: 3, Type: "string"},
+				{Name: "total", Number: 4, Type: "int64"},
+			}},
+			{Name: "shop.Unused"},
+		},
+	}
+	new := &GRPCSpec{
+		Services: []GRPCService{
+			{Name: "shop.Orders", Methods: []GRPCMethod{
+				{Name: "Get", Request: "shop.GetRequest", Response: "shop.Order"},
+				{Name: "Watch", Request: "shop.WatchRequest", Response: "shop.Order"},
+				{Name: "List", Request: "shop.ListRequest", Response: "shop.ListResponse"},
+			}},
+		},
+		Messages: []GRPCMessage{
+			{Name: "shop.Order", Fields: []GRPCField{
+				{Name: "id", Number: 1, Type: "string"},
+				{Name: "items", Number: 2, Type: "shop.Item"},
+				{Name: "comment", Number: 3, Type: "string"},
+				{Name: "currency", Number: 5, Type: "string"},
+			}},
+		},
+	}
+
+	changes := DiffAPISpecs(&APISpec{GRPC: old}, &APISpec{GRPC: new})
+	assert.ElementsMatch(t, []string{
+		"`shop.Orders/Watch` response changed from `stream shop.Order` to `shop.Order`",
+		"removed rpc `shop.Orders/Cancel`",
+		"removed service `shop.Legacy`",
+		"field 2 of `shop.Order` changed type from `repeated shop.Item` to `shop.Item`",
+		"renamed field 3 of `shop.Order` from `note` to `comment`",
+		"removed field 4 (`total`) from `shop.Order`",
+		"removed message `shop.Unused`",
+	}, BreakingChanges(changes))
+	assert.Contains(t, changes, APIChange{Description: "added rpc `shop.Orders/List`"})
+	assert.Contains(t, changes, APIChange{Description: "added field `currency` to `shop.Order`"})
+
+	assert.Equal(t, []string{"removed service `shop.Orders`", "removed message `shop.Order`"},
+		BreakingChanges(DiffAPISpecs(&APISpec{GRPC: new}, nil)))
+}
+
+func TestWriteAndLoadAPISpec(t *testing.T) {
+	dir := t.TempDir()
+	spec := &APISpec{
+		HTTP: testOpenAPIDoc(map[string]OpenAPIPathItem{
+			"/users": {"get": {OperationID: "listUsers", Responses: map[string]OpenAPIResponse{"200": {Description: "OK"}}}},
+		}, nil),
+		GRPC: &GRPCSpec{Services: []GRPCService{{Name: "shop.Orders", File: "shop.proto"}}},
+	}
+
+	for _, format := range []string{"raw-md", "hugo"} {
+		t.Run(format, func(t *testing.T) {
+			cfg := RendererConfig{Format: format, OutputDir: filepath.Join(dir, format)}
+			loaded, err := LoadAPISpec(cfg)
+			require.NoError(t, err)
+			assert.Nil(t, loaded, "no previous run")
+
+			written, err := WriteAPISpec(spec, cfg)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"api/openapi.json", "api/grpc-services.json"}, written)
+			if format == "hugo" {
+				assert.FileExists(t, filepath.Join(cfg.OutputDir, "static", "api", "openapi.json"))
+			}
+
+			loaded, err = LoadAPISpec(cfg)
+			require.NoError(t, err)
+			assert.Equal(t, spec, loaded)
+
+			// A spec without gRPC services removes the stale listing.
+			written, err = WriteAPISpec(&APISpec{HTTP: spec.HTTP}, cfg)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"api/openapi.json"}, written)
+			loaded, err = LoadAPISpec(cfg)
+			require.NoError(t, err)
+			assert.Nil(t, loaded.GRPC)
+		})
+	}
+}
+
+func TestLoadAPISpecInvalid(t *testing.T) {
+	cfg := RendererConfig{Format: "raw-md", OutputDir: t.TempDir()}
+	require.NoError(t, os.MkdirAll(filepath.Join(cfg.OutputDir, "api"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "api", "openapi.json"), []byte("{"), 0o644))
+
+	_, err := LoadAPISpec(cfg)
+	assert.ErrorContains(t, err, "parsing")
+}
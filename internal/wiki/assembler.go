@@ -16,11 +16,13 @@ import (
 func Assemble(analysis *AnalysisResult, diagrams []Diagram, skillSections []SkillWikiSection, findings []security.Finding, files []ScannedFile) ([]Document, error) {
 	var docs []Document
 
+	refs := findingRefs(findings, analysis, files)
+
 	docs = append(docs, buildIndexPage(analysis))
 	docs = append(docs, buildArchitecturePages(analysis, diagrams)...)
-	docs = append(docs, buildModulePages(analysis, files)...)
+	docs = append(docs, buildModulePages(analysis, files, refs)...)
 	docs = append(docs, buildCodeStructurePage(analysis))
-	docs = append(docs, buildSecurityPage(findings))
+	docs = append(docs, buildSecurityPage(refs))
 
 	if len(analysis.Suggestions) > 0 {
 		docs = append(docs, buildSuggestionsPage(analysis.Suggestions))
@@ -124,8 +126,8 @@ func buildArchitecturePages(analysis *AnalysisResult, diagrams []Diagram) []Docu
 
 // buildModulePages creates modules/_index.md and one page per module.
 // files is used to enrich each module page with test coverage indicators and
-// public interface summaries.
-func buildModulePages(analysis *AnalysisResult, files []ScannedFile) []Document {
+// public interface summaries, and refs to link the module's security findings.
+func buildModulePages(analysis *AnalysisResult, files []ScannedFile, refs []findingRef) []Document {
 	if len(analysis.Modules) == 0 {
 		return nil
 	}
@@ -135,6 +137,12 @@ func buildModulePages(analysis *AnalysisResult, files []ScannedFile) []Document
 	for _, f := range files {
 		filesByModule[f.Module] = append(filesByModule[f.Module], f)
 	}
+	refsByModule := make(map[string][]findingRef)
+	for _, r := range refs {
+		if r.module != "" {
+			refsByModule[r.module] = append(refsByModule[r.module], r)
+		}
+	}
 
 	var docs []Document
 
@@ -183,6 +191,16 @@ func buildModulePages(analysis *AnalysisResult, files []ScannedFile) []Document
 			b.WriteString("\n\n")
 		}
 
+		if moduleRefs := refsByModule[m.Module]; len(moduleRefs) > 0 {
+			b.WriteString("## Security Findings\n\n")
+			for _, r := range moduleRefs {
+				fmt.Fprintf(&b, "- **%s**: [%s](../security/overview.md#%s) (`%s`)\n",
+					sanitizeMarkdown(string(r.finding.Severity)), sanitizeMarkdown(r.finding.Title),
+					r.anchor, sanitizeMarkdown(findingLocation(r.finding)))
+			}
+			b.WriteString("\n")
+		}
+
 		// Testing section: detect test files within the module.
 		b.WriteString("## Testing\n\n")
 		if hasTestFiles(moduleFiles) {
@@ -287,10 +305,10 @@ func buildCodeStructurePage(analysis *AnalysisResult) Document {
 }
 
 // buildSecurityPage creates security/overview.md. When findings are provided,
-// it renders a severity summary table and per-finding details. Otherwise it
-// shows placeholder text.
-func buildSecurityPage(findings []security.Finding) Document {
-	if len(findings) == 0 {
+// it renders a severity summary table and per-finding details, linking each
+// finding to its module page. Otherwise it shows placeholder text.
+func buildSecurityPage(refs []findingRef) Document {
+	if len(refs) == 0 {
 		return Document{
 			Path:    "security/overview.md",
 			Title:   "Security",
@@ -303,8 +321,8 @@ func buildSecurityPage(findings []security.Finding) Document {
 
 	// Severity summary counts.
 	counts := map[security.Severity]int{}
-	for _, f := range findings {
-		counts[f.Severity]++
+	for _, r := range refs {
+		counts[r.finding.Severity]++
 	}
 
 	b.WriteString("## Summary\n\n")
@@ -321,20 +339,20 @@ func buildSecurityPage(findings []security.Finding) Document {
 			fmt.Fprintf(&b, "| %s | %d |\n", sev, c)
 		}
 	}
-	fmt.Fprintf(&b, "| **Total** | **%d** |\n\n", len(findings))
+	fmt.Fprintf(&b, "| **Total** | **%d** |\n\n", len(refs))
 
 	// Per-finding details.
 	b.WriteString("## Findings\n\n")
-	for _, f := range findings {
+	for _, r := range refs {
+		f := r.finding
 		fmt.Fprintf(&b, "### %s\n\n", sanitizeMarkdown(f.Title))
 		fmt.Fprintf(&b, "- **Severity**: %s\n", sanitizeMarkdown(string(f.Severity)))
 		fmt.Fprintf(&b, "- **Scanner**: %s\n", sanitizeMarkdown(f.Scanner))
 		if f.Location.File != "" {
-			if f.Location.StartLine > 0 {
-				fmt.Fprintf(&b, "- **Location**: `%s:%d`\n", sanitizeMarkdown(f.Location.File), f.Location.StartLine)
-			} else {
-				fmt.Fprintf(&b, "- **Location**: `%s`\n", sanitizeMarkdown(f.Location.File))
-			}
+			fmt.Fprintf(&b, "- **Location**: `%s`\n", sanitizeMarkdown(findingLocation(f)))
+		}
+		if r.module != "" {
+			fmt.Fprintf(&b, "- **Module**: [%s](../modules/%s.md)\n", sanitizeMarkdown(r.module), sanitizeID(r.module))
 		}
 		if f.Description != "" {
 			fmt.Fprintf(&b, "\n%s\n", sanitizeMarkdown(f.Description))
@@ -349,6 +367,81 @@ func buildSecurityPage(findings []security.Finding) Document {
 	}
 }
 
+// findingRef places a security finding in the wiki: its heading anchor on the
+// security page and the module whose page it belongs on, if any.
+type findingRef struct {
+	finding security.Finding
+	anchor  string
+	module  string
+}
+
+// findingRefs resolves where each finding is shown. A finding belongs to the
+// module of the scanned file it points at; findings outside the analyzed
+// modules get no module.
+func findingRefs(findings []security.Finding, analysis *AnalysisResult, files []ScannedFile) []findingRef {
+	if len(findings) == 0 {
+		return nil
+	}
+	modules := make(map[string]bool, len(analysis.Modules))
+	for _, m := range analysis.Modules {
+		modules[m.Module] = true
+	}
+	fileModules := make(map[string]string, len(files))
+	for _, f := range files {
+		fileModules[f.Path] = f.Module
+	}
+
+	// The security page's own headings come first, so finding anchors are
+	// deduplicated against them the way the page is rendered.
+	anchors := headingAnchors{}
+	for _, h := range []string{"Security", "Summary", "Findings"} {
+		anchors.next(h)
+	}
+
+	refs := make([]findingRef, 0, len(findings))
+	for _, f := range findings {
+		ref := findingRef{finding: f, anchor: anchors.next(sanitizeMarkdown(f.Title))}
+		if f.Location.File != "" {
+			path := filepath.ToSlash(filepath.Clean(f.Location.File))
+			module, ok := fileModules[path]
+			if !ok {
+				module = moduleFromPath(path)
+			}
+			if modules[module] {
+				ref.module = module
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// findingLocation formats a finding's file and line.
+func findingLocation(f security.Finding) string {
+	if f.Location.StartLine > 0 {
+		return fmt.Sprintf("%s:%d", f.Location.File, f.Location.StartLine)
+	}
+	return f.Location.File
+}
+
+// headingAnchors hands out the anchors of a page's headings in order: the
+// heading's title slug, suffixed -1, -2, ... when it is already taken, as
+// common Markdown hosts and the HTML renderer derive them.
+type headingAnchors map[string]bool
+
+func (h headingAnchors) next(heading string) string {
+	slug := titleSlug(heading)
+	if slug == "" {
+		slug = "section"
+	}
+	anchor := slug
+	for i := 1; h[anchor]; i++ {
+		anchor = fmt.Sprintf("%s-%d", slug, i)
+	}
+	h[anchor] = true
+	return anchor
+}
+
 // buildSuggestionsPage creates suggestions/improvements.md with bullet points.
 func buildSuggestionsPage(suggestions []string) Document {
 	var b strings.Builder
@@ -343,6 +343,38 @@ func TestAssembleWithSecurityFindings(t *testing.T) {
 	assert.NotContains(t, secDoc.Content, "pending")
 }
 
+func TestAssembleCrossLinksModulesAndFindings(t *testing.T) {
+	analysis := &AnalysisResult{
+		Modules: []ModuleAnalysis{{Module: "internal/db", Summary: "Database access"}},
+	}
+	files := []ScannedFile{{Path: "internal/db/query.go", Module: "internal/db"}}
+	findings := []security.Finding{
+		{Title: "SQL Injection Risk", Severity: security.SeverityCritical, Location: security.Location{File: "internal/db/query.go", StartLine: 15}},
+		{Title: "SQL Injection Risk", Severity: security.SeverityHigh, Location: security.Location{File: "./internal/db/query.go", StartLine: 30}},
+		{Title: "Summary", Severity: security.SeverityLow, Location: security.Location{File: "scripts/deploy.sh"}},
+	}
+
+	docs, err := Assemble(analysis, nil, nil, findings, files)
+	require.NoError(t, err)
+	byPath := make(map[string]string)
+	for _, d := range docs {
+		byPath[d.Path] = d.Content
+	}
+
+	module := byPath["modules/"+sanitizeID("internal/db")+".md"]
+	assert.Contains(t, module, "## Security Findings")
+	assert.Contains(t, module, "[SQL Injection Risk](../security/overview.md#sql-injection-risk) (`internal/db/query.go:15`)")
+	assert.Contains(t, module, "[SQL Injection Risk](../security/overview.md#sql-injection-risk-1) (`./internal/db/query.go:30`)")
+	assert.NotContains(t, module, "deploy.sh", "findings outside the module are not listed")
+
+	sec := byPath["security/overview.md"]
+	assert.Equal(t, 2, strings.Count(sec, "- **Module**: [internal/db](../modules/"+sanitizeID("internal/db")+".md)"))
+	assert.NotContains(t, sec, "[scripts]", "findings outside analyzed modules get no module link")
+
+	refs := findingRefs(findings, analysis, files)
+	assert.Equal(t, "summary-1", refs[2].anchor, "anchors avoid the page's own headings")
+}
+
 func TestAssembleWithNoSecurityFindings(t *testing.T) {
 	analysis := &AnalysisResult{}
 
@@ -0,0 +1,191 @@
+package wiki
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// PromptVersion identifies the analysis prompts and the parsing of their
+// responses. Bump it whenever either changes so results produced by the old
+// prompts are not reused.
+const PromptVersion = "1"
+
+// AnalysisCache keeps LLM analysis results between wiki runs so an
+// incremental run only pays for what changed. Per-chunk module analyses are
+// keyed by a hash of the chunk's content, and the responses of the
+// repository-wide stages by a hash of their prompt; both keys include
+// PromptVersion. Save keeps only the entries the current run used, so the
+// file tracks the repository instead of growing with its history.
+type AnalysisCache struct {
+	path string
+
+	mu        sync.Mutex
+	chunks    map[string]ModuleAnalysis
+	responses map[string]string
+	used      map[string]bool
+	hits      int
+	misses    int
+}
+
+// cacheFile is the on-disk shape of an AnalysisCache.
+type cacheFile struct {
+	Chunks    map[string]ModuleAnalysis `json:"chunks"`
+	Responses map[string]string         `json:"responses"`
+}
+
+// NewAnalysisCache creates an empty cache that saves to path.
+func NewAnalysisCache(path string) *AnalysisCache {
+	return &AnalysisCache{
+		path:      path,
+		chunks:    make(map[string]ModuleAnalysis),
+		responses: make(map[string]string),
+		used:      make(map[string]bool),
+	}
+}
+
+// LoadAnalysisCache reads the cache saved at path. A missing file yields an
+// empty cache.
+func LoadAnalysisCache(path string) (*AnalysisCache, error) {
+	c := NewAnalysisCache(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading analysis cache: %w", err)
+	}
+	var f cacheFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing analysis cache %s: %w", path, err)
+	}
+	if f.Chunks != nil {
+		c.chunks = f.Chunks
+	}
+	if f.Responses != nil {
+		c.responses = f.Responses
+	}
+	return c, nil
+}
+
+// Save writes the entries used since the cache was created or loaded.
+func (c *AnalysisCache) Save() error {
+	c.mu.Lock()
+	f := cacheFile{Chunks: map[string]ModuleAnalysis{}, Responses: map[string]string{}}
+	for key, a := range c.chunks {
+		if c.used[key] {
+			f.Chunks[key] = a
+		}
+	}
+	for key, resp := range c.responses {
+		if c.used[key] {
+			f.Responses[key] = resp
+		}
+	}
+	c.mu.Unlock()
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("encoding analysis cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("creating analysis cache directory: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing analysis cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("writing analysis cache: %w", err)
+	}
+	return nil
+}
+
+// ChunkStats reports how many chunk analyses were served from the cache and
+// how many had to be produced.
+func (c *AnalysisCache) ChunkStats() (cached, analyzed int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// chunk returns the cached analysis of chunk.
+func (c *AnalysisCache) chunk(chunk Chunk) (ModuleAnalysis, bool) {
+	key := chunkCacheKey(chunk)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a, ok := c.chunks[key]
+	if ok {
+		c.used[key] = true
+		c.hits++
+	}
+	return a, ok
+}
+
+// putChunk records the analysis of chunk.
+func (c *AnalysisCache) putChunk(chunk Chunk, a ModuleAnalysis) {
+	key := chunkCacheKey(chunk)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chunks[key] = a
+	c.used[key] = true
+	c.misses++
+}
+
+// Completer wraps llm so that a prompt answered before is answered from the
+// cache, and new answers are recorded.
+func (c *AnalysisCache) Completer(llm LLMCompleter) LLMCompleter {
+	return &cachedCompleter{cache: c, llm: llm}
+}
+
+type cachedCompleter struct {
+	cache *AnalysisCache
+	llm   LLMCompleter
+}
+
+func (cc *cachedCompleter) Complete(ctx context.Context, prompt string) (string, error) {
+	key := cacheKey("prompt", prompt)
+	c := cc.cache
+	c.mu.Lock()
+	resp, ok := c.responses[key]
+	if ok {
+		c.used[key] = true
+	}
+	c.mu.Unlock()
+	if ok {
+		return resp, nil
+	}
+
+	resp, err := cc.llm.Complete(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.responses[key] = resp
+	c.used[key] = true
+	c.mu.Unlock()
+	return resp, nil
+}
+
+// chunkCacheKey keys a chunk analysis by the chunk's module and content.
+func chunkCacheKey(chunk Chunk) string {
+	return cacheKey("chunk", chunk.Module, string(chunk.Source))
+}
+
+// cacheKey hashes parts together with PromptVersion.
+func cacheKey(kind string, parts ...string) string {
+	h := sha256.New()
+	h.Write([]byte(PromptVersion))
+	for _, part := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(part))
+	}
+	return kind + ":" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,57 @@
+package wiki
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalysisCacheRoundTripKeepsOnlyUsedEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "wiki-cache.json")
+	first := NewAnalysisCache(path)
+	kept := Chunk{Module: "a", Source: []byte("package a")}
+	dropped := Chunk{Module: "b", Source: []byte("package b")}
+	first.putChunk(kept, ModuleAnalysis{Module: "a", Summary: "A"})
+	first.putChunk(dropped, ModuleAnalysis{Module: "b", Summary: "B"})
+	require.NoError(t, first.Save())
+
+	second, err := LoadAnalysisCache(path)
+	require.NoError(t, err)
+	got, ok := second.chunk(kept)
+	require.True(t, ok)
+	assert.Equal(t, "A", got.Summary)
+	_, ok = second.chunk(Chunk{Module: "a", Source: []byte("package a // edited")})
+	assert.False(t, ok, "changed content misses the cache")
+	require.NoError(t, second.Save())
+
+	third, err := LoadAnalysisCache(path)
+	require.NoError(t, err)
+	_, ok = third.chunk(kept)
+	assert.True(t, ok)
+	_, ok = third.chunk(dropped)
+	assert.False(t, ok, "entries the last run did not use are dropped")
+}
+
+func TestLoadAnalysisCacheMissingFileIsEmpty(t *testing.T) {
+	c, err := LoadAnalysisCache(filepath.Join(t.TempDir(), "none.json"))
+	require.NoError(t, err)
+	cached, analyzed := c.ChunkStats()
+	assert.Zero(t, cached)
+	assert.Zero(t, analyzed)
+}
+
+func TestAnalysisCacheCompleterAnswersRepeatedPrompts(t *testing.T) {
+	llm := &mockLLMCompleter{responses: map[string]string{"arch": "Architecture: layered"}}
+	c := NewAnalysisCache(filepath.Join(t.TempDir(), "c.json"))
+	completer := c.Completer(llm)
+
+	for i := 0; i < 2; i++ {
+		resp, err := completer.Complete(context.Background(), "describe the arch")
+		require.NoError(t, err)
+		assert.Equal(t, "Architecture: layered", resp)
+	}
+	assert.Len(t, llm.calls, 1)
+}
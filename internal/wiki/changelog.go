@@ -19,8 +19,9 @@ const (
 
 // ApplyChangelog compares each new document against existing content and
 // attaches an updated Change History section. New docs get an "Initial
-// generation" entry; changed docs get an LLM-summarized entry; unchanged docs
-// are returned as-is. LLM summary calls run concurrently.
+// generation" entry; changed docs get their ChangeSummary or else an
+// LLM-summarized entry; unchanged docs are returned as-is. LLM summary calls
+// run concurrently.
 func ApplyChangelog(ctx context.Context, existing map[string]string, newDocs []Document, llm LLMCompleter) ([]Document, error) {
 	today := time.Now().UTC().Format("2006-01-02")
 
@@ -89,6 +90,10 @@ func ApplyChangelog(ctx context.Context, existing map[string]string, newDocs []D
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			if summary := newDocs[w.idx].ChangeSummary; summary != "" {
+				summaries[j] = result{idx: w.idx, summary: summary}
+				return
+			}
 			if ctx.Err() != nil {
 				summaries[j] = result{idx: w.idx, summary: "Content updated"}
 				return
@@ -298,3 +298,30 @@ func TestApplyChangelog_LLMFallback(t *testing.T) {
 		t.Errorf("expected fallback 'Content updated' entry, got:\n%s", content)
 	}
 }
+
+// ---------- TestApplyChangelog_ChangeSummary ----------
+
+func TestApplyChangelog_ChangeSummary(t *testing.T) {
+	ctx := context.Background()
+	llm := &mockLLM{response: "LLM summary"}
+
+	existing := map[string]string{
+		"api/_index.md": "# API\n\nOld body.\n\n## Change History\n\n- **2026-01-01** — Initial generation",
+	}
+	newDocs := []Document{
+		{Path: "api/_index.md", Title: "API", Content: "# API\n\nNew body.", ChangeSummary: "Breaking API changes: removed `GET /users`"},
+	}
+
+	result, err := ApplyChangelog(ctx, existing, newDocs, llm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content := result[0].Content
+	if !strings.Contains(content, "— Breaking API changes: removed `GET /users`") {
+		t.Errorf("expected the change summary entry, got:\n%s", content)
+	}
+	if llm.calls != 0 {
+		t.Errorf("expected no LLM calls, got %d", llm.calls)
+	}
+}
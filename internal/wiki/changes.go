@@ -0,0 +1,66 @@
+package wiki
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ChangedFiles returns the files under dir that differ between ref and the
+// working tree, including uncommitted changes, untracked files that are not
+// ignored, and deletions. Paths are relative to dir, like those Scan
+// returns, and files in directories Scan skips are left out.
+func ChangedFiles(ctx context.Context, dir, ref string) (map[string]bool, error) {
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %q", ref)
+	}
+	changed := make(map[string]bool)
+	if err := gitFileList(ctx, dir, changed, "git diff "+ref,
+		"diff", "--name-only", "--relative", ref, "--"); err != nil {
+		return nil, err
+	}
+	// git diff never reports untracked files; ls-files lists them relative
+	// to dir and limited to it.
+	if err := gitFileList(ctx, dir, changed, "git ls-files",
+		"ls-files", "--others", "--exclude-standard"); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// gitFileList runs git with args in dir and adds each path it prints to
+// files. what names the command in errors.
+func gitFileList(ctx context.Context, dir string, files map[string]bool, what string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s", what, msg)
+		}
+		return fmt.Errorf("%s: %w", what, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !shouldSkip(line) {
+			files[line] = true
+		}
+	}
+	return scanner.Err()
+}
+
+// chunkChanged reports whether any of chunk's files is in changed.
+func chunkChanged(chunk Chunk, changed map[string]bool) bool {
+	for _, f := range chunk.Files {
+		if changed[f.Path] {
+			return true
+		}
+	}
+	return false
+}
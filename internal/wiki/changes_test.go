@@ -0,0 +1,66 @@
+package wiki
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFilesSinceRef(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	writeFile(t, filepath.Join(dir, "a.go"), "package a\n")
+	writeFile(t, filepath.Join(dir, "pkg", "b.go"), "package pkg\n")
+	writeFile(t, filepath.Join(dir, "pkg", "c.go"), "package pkg\n")
+	gitAdd(t, dir, ".")
+	runGit(t, dir, "tag", "base")
+
+	writeFile(t, filepath.Join(dir, "pkg", "b.go"), "package pkg\n\nfunc B() {}\n")
+	gitAdd(t, dir, ".")
+	require.NoError(t, os.Remove(filepath.Join(dir, "pkg", "c.go")))
+
+	changed, err := ChangedFiles(context.Background(), dir, "base")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"pkg/b.go": true, "pkg/c.go": true}, changed)
+
+	changed, err = ChangedFiles(context.Background(), filepath.Join(dir, "pkg"), "base")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"b.go": true, "c.go": true}, changed, "paths are relative to dir")
+}
+
+func TestChangedFilesIncludesUntrackedFiles(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	writeFile(t, filepath.Join(dir, ".gitignore"), "*.log\n")
+	writeFile(t, filepath.Join(dir, "pkg", "a.go"), "package pkg\n")
+	gitAdd(t, dir, ".")
+	runGit(t, dir, "tag", "base")
+
+	writeFile(t, filepath.Join(dir, "pkg", "new.go"), "package pkg\n\nfunc New() {}\n")
+	writeFile(t, filepath.Join(dir, "top.go"), "package top\n")
+	writeFile(t, filepath.Join(dir, "pkg", "debug.log"), "ignored\n")
+
+	changed, err := ChangedFiles(context.Background(), dir, "base")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"pkg/new.go": true, "top.go": true}, changed)
+
+	changed, err = ChangedFiles(context.Background(), filepath.Join(dir, "pkg"), "base")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"new.go": true}, changed, "untracked paths are relative to dir too")
+}
+
+func TestChangedFilesRejectsBadRefs(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	writeFile(t, filepath.Join(dir, "a.go"), "package a\n")
+	gitAdd(t, dir, "a.go")
+
+	_, err := ChangedFiles(context.Background(), dir, "no-such-ref")
+	assert.ErrorContains(t, err, "no-such-ref")
+	_, err = ChangedFiles(context.Background(), dir, "--output=x")
+	assert.ErrorContains(t, err, "invalid git ref")
+}
@@ -0,0 +1,345 @@
+package wiki
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GRPCSpec lists the gRPC services and messages declared in .proto files.
+type GRPCSpec struct {
+	Services []GRPCService `json:"services"`
+	Messages []GRPCMessage `json:"messages,omitempty"`
+}
+
+// GRPCService is a service and its methods.
+type GRPCService struct {
+	Name    string       `json:"name"` // package-qualified
+	File    string       `json:"file"`
+	Methods []GRPCMethod `json:"methods"`
+}
+
+// GRPCMethod is an rpc of a service.
+type GRPCMethod struct {
+	Name            string `json:"name"`
+	Request         string `json:"request"`
+	Response        string `json:"response"`
+	ClientStreaming bool   `json:"clientStreaming,omitempty"`
+	ServerStreaming bool   `json:"serverStreaming,omitempty"`
+}
+
+// GRPCMessage is a message type and its fields.
+type GRPCMessage struct {
+	Name   string      `json:"name"` // package-qualified, nested messages dotted
+	Fields []GRPCField `json:"fields,omitempty"`
+}
+
+// GRPCField is a message field.
+type GRPCField struct {
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+	Type   string `json:"type"`
+	Label  string `json:"label,omitempty"` // "repeated" or "optional"
+}
+
+// BuildGRPCSpec reconstructs the services and messages of the .proto files
+// among files. Returns nil when no services are declared.
+func BuildGRPCSpec(files []ScannedFile, sources SourceReader) *GRPCSpec {
+	if sources == nil {
+		return nil
+	}
+	spec := &GRPCSpec{}
+	for _, f := range files {
+		if strings.ToLower(filepath.Ext(f.Path)) != ".proto" {
+			continue
+		}
+		src, err := sources.ReadFile(f.Path)
+		if err != nil {
+			continue
+		}
+		p := &protoParser{file: f.Path, tokens: tokenizeProto(string(src))}
+		p.parse(spec)
+	}
+	if len(spec.Services) == 0 {
+		return nil
+	}
+	sort.Slice(spec.Services, func(i, j int) bool { return spec.Services[i].Name < spec.Services[j].Name })
+	sort.Slice(spec.Messages, func(i, j int) bool { return spec.Messages[i].Name < spec.Messages[j].Name })
+	return spec
+}
+
+// protoScalars are the protobuf scalar types, which are never qualified.
+var protoScalars = map[string]bool{
+	"double": true, "float": true, "int32": true, "int64": true, "uint32": true,
+	"uint64": true, "sint32": true, "sint64": true, "fixed32": true, "fixed64": true,
+	"sfixed32": true, "sfixed64": true, "bool": true, "string": true, "bytes": true,
+}
+
+// protoParser reads the declarations of one .proto file. It understands
+// enough of the language to list services and message fields, and skips
+// everything else.
+type protoParser struct {
+	file   string
+	tokens []string
+	pos    int
+	pkg    string
+}
+
+func (p *protoParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *protoParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+// skipStatement skips to the end of the current statement or block.
+func (p *protoParser) skipStatement() {
+	for t := p.next(); t != "" && t != ";"; t = p.next() {
+		if t == "{" {
+			p.skipBlock()
+			return
+		}
+	}
+}
+
+// skipBlock skips to the brace closing an open block.
+func (p *protoParser) skipBlock() {
+	for depth := 1; depth > 0; {
+		switch p.next() {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		case "":
+			return
+		}
+	}
+}
+
+func (p *protoParser) parse(spec *GRPCSpec) {
+	start := len(spec.Messages)
+	defer func() { p.resolveFieldTypes(spec, spec.Messages[start:]) }()
+	for p.peek() != "" {
+		switch p.next() {
+		case "package":
+			p.pkg = p.next()
+			p.skipStatement()
+		case "service":
+			p.parseService(spec, p.next())
+		case "message":
+			p.parseMessage(spec, p.qualify(p.next()))
+		case ";":
+		default:
+			p.skipStatement()
+		}
+	}
+}
+
+func (p *protoParser) parseService(spec *GRPCSpec, name string) {
+	if p.next() != "{" {
+		return
+	}
+	svc := GRPCService{Name: p.qualify(name), File: p.file}
+	for {
+		switch t := p.next(); t {
+		case "", "}":
+			spec.Services = append(spec.Services, svc)
+			return
+		case "rpc":
+			m := GRPCMethod{Name: p.next()}
+			m.Request, m.ClientStreaming = p.parseRPCType()
+			if p.next() != "returns" {
+				p.skipStatement()
+				continue
+			}
+			m.Response, m.ServerStreaming = p.parseRPCType()
+			svc.Methods = append(svc.Methods, m)
+			if p.peek() == "{" {
+				p.next()
+				p.skipBlock()
+			} else if p.peek() == ";" {
+				p.next()
+			}
+		case ";":
+		default:
+			p.skipStatement()
+		}
+	}
+}
+
+// parseRPCType reads "(stream Type)".
+func (p *protoParser) parseRPCType() (string, bool) {
+	if p.next() != "(" {
+		return "", false
+	}
+	typ, stream := p.next(), false
+	if typ == "stream" && p.peek() != ")" {
+		typ, stream = p.next(), true
+	}
+	for t := p.next(); t != "" && t != ")"; t = p.next() {
+	}
+	return p.qualify(typ), stream
+}
+
+func (p *protoParser) parseMessage(spec *GRPCSpec, name string) {
+	if p.next() != "{" {
+		return
+	}
+	msg := GRPCMessage{Name: name}
+	p.parseFields(spec, &msg)
+	spec.Messages = append(spec.Messages, msg)
+}
+
+// parseFields reads the body of a message or oneof up to its closing brace.
+func (p *protoParser) parseFields(spec *GRPCSpec, msg *GRPCMessage) {
+	for {
+		t := p.next()
+		switch t {
+		case "", "}":
+			return
+		case ";":
+		case "message":
+			p.parseMessage(spec, msg.Name+"."+p.next())
+		case "oneof":
+			p.next()
+			if p.next() == "{" {
+				p.parseFields(spec, msg)
+			}
+		case "enum", "extend", "option", "reserved", "extensions":
+			p.skipStatement()
+		default:
+			label := ""
+			if t == "repeated" || t == "optional" || t == "required" {
+				label, t = t, p.next()
+			}
+			typ := t
+			if t == "map" && p.peek() == "<" {
+				var b strings.Builder
+				b.WriteString("map")
+				for t := p.next(); t != "" && t != ">"; t = p.next() {
+					b.WriteString(t)
+				}
+				b.WriteString(">")
+				typ = b.String()
+			}
+			name := p.next()
+			if p.next() != "=" {
+				p.skipStatement()
+				continue
+			}
+			number, err := strconv.Atoi(p.next())
+			p.skipStatement()
+			if err == nil {
+				msg.Fields = append(msg.Fields, GRPCField{Name: name, Number: number, Type: typ, Label: label})
+			}
+		}
+	}
+}
+
+// resolveFieldTypes qualifies the message types of fields the way protobuf
+// resolves names: in the enclosing messages from the innermost out, then in
+// the package.
+func (p *protoParser) resolveFieldTypes(spec *GRPCSpec, messages []GRPCMessage) {
+	known := make(map[string]bool, len(spec.Messages))
+	for _, m := range spec.Messages {
+		known[m.Name] = true
+	}
+	for _, m := range messages {
+		for i, f := range m.Fields {
+			if !protoScalars[f.Type] && !strings.HasPrefix(f.Type, "map<") {
+				m.Fields[i].Type = p.resolve(f.Type, m.Name, known)
+			}
+		}
+	}
+}
+
+// resolve qualifies a type referenced in scope against the known messages.
+func (p *protoParser) resolve(name, scope string, known map[string]bool) string {
+	if strings.HasPrefix(name, ".") {
+		return name[1:]
+	}
+	for scope != "" && scope != p.pkg {
+		if known[scope+"."+name] {
+			return scope + "." + name
+		}
+		i := strings.LastIndex(scope, ".")
+		if i < 0 {
+			break
+		}
+		scope = scope[:i]
+	}
+	return p.qualify(name)
+}
+
+// qualify prefixes a type name declared without a package with the file's
+// package. Names that are already qualified are only stripped of a leading
+// dot.
+func (p *protoParser) qualify(name string) string {
+	if strings.HasPrefix(name, ".") {
+		return name[1:]
+	}
+	if p.pkg == "" || strings.Contains(name, ".") || protoScalars[name] {
+		return name
+	}
+	return p.pkg + "." + name
+}
+
+// tokenizeProto splits protobuf source into identifiers (including dotted
+// names), numbers, string literals and single-character symbols, dropping
+// comments.
+func tokenizeProto(src string) []string {
+	var tokens []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			tokens = append(tokens, src[i:min(j+1, len(src))])
+			i = j + 1
+		case isProtoWordByte(c):
+			j := i
+			for j < len(src) && isProtoWordByte(src[j]) {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		case unicode.IsSpace(rune(c)):
+			i++
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+func isProtoWordByte(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,91 @@
+package wiki
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const grpcTestProto = `syntax = "proto3";
+
+package shop.v1;
+
+import "google/api/annotations.proto";
+
+option go_package = "example.com/shop/v1";
+
+// Orders manages orders.
+service Orders {
+  rpc GetOrder(GetOrderRequest) returns (Order) {
+    option (google.api.http) = { get: "/v1/orders/{id}" };
+  }
+  rpc WatchOrders(stream WatchRequest) returns (stream Order);
+}
+
+message GetOrderRequest {
+  string id = 1;
+}
+
+/* An order. */
+message Order {
+  string id = 1;
+  repeated Item items = 2;
+  map<string, string> labels = 3;
+  oneof payment {
+    string card = 4;
+    string voucher = 5;
+  }
+  optional google.protobuf.Timestamp created_at = 6;
+  reserved 7, 8;
+
+  message Item {
+    string sku = 1;
+    int32 quantity = 2;
+  }
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+  }
+}
+
+message WatchRequest {}
+`
+
+func TestBuildGRPCSpec(t *testing.T) {
+	reader := &mockSourceReader{files: map[string][]byte{"proto/shop.proto": []byte(grpcTestProto)}}
+	files := []ScannedFile{{Path: "proto/shop.proto"}, {Path: "main.go", Language: "go"}}
+
+	spec := BuildGRPCSpec(files, reader)
+	require.NotNil(t, spec)
+
+	require.Len(t, spec.Services, 1)
+	svc := spec.Services[0]
+	assert.Equal(t, "shop.v1.Orders", svc.Name)
+	assert.Equal(t, "proto/shop.proto", svc.File)
+	assert.Equal(t, []GRPCMethod{
+		{Name: "GetOrder", Request: "shop.v1.GetOrderRequest", Response: "shop.v1.Order"},
+		{Name: "WatchOrders", Request: "shop.v1.WatchRequest", Response: "shop.v1.Order", ClientStreaming: true, ServerStreaming: true},
+	}, svc.Methods)
+
+	var names []string
+	for _, m := range spec.Messages {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"shop.v1.GetOrderRequest", "shop.v1.Order", "shop.v1.Order.Item", "shop.v1.WatchRequest"}, names)
+
+	assert.Equal(t, []GRPCField{
+		{Name: "id", Number: 1, Type: "string"},
+		{Name: "items", Number: 2, Type: "shop.v1.Order.Item", Label: "repeated"},
+		{Name: "labels", Number: 3, Type: "map<string,string>"},
+		{Name: "card", Number: 4, Type: "string"},
+		{Name: "voucher", Number: 5, Type: "string"},
+		{Name: "created_at", Number: 6, Type: "google.protobuf.Timestamp", Label: "optional"},
+	}, spec.Messages[1].Fields)
+	assert.Empty(t, spec.Messages[3].Fields)
+}
+
+func TestBuildGRPCSpecNoServices(t *testing.T) {
+	reader := &mockSourceReader{files: map[string][]byte{"types.proto": []byte("syntax = \"proto3\";\nmessage Empty {}\n")}}
+	assert.Nil(t, BuildGRPCSpec([]ScannedFile{{Path: "types.proto"}}, reader))
+	assert.Nil(t, BuildGRPCSpec([]ScannedFile{{Path: "types.proto"}}, nil))
+}
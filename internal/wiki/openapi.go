@@ -0,0 +1,268 @@
+package wiki
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// OpenAPIVersion is the OpenAPI version of the generated HTTP spec.
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIDocument is the subset of an OpenAPI 3.1 document that can be
+// recovered from detected HTTP routes.
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components *OpenAPIComponents         `json:"components,omitempty"`
+}
+
+// OpenAPIInfo is the document's info object.
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIPathItem maps lower-case HTTP methods to the operations of a path.
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation describes one route.
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	// AnyMethod marks routes registered without a method, which serve every
+	// method; they are listed as GET.
+	AnyMethod bool `json:"x-any-method,omitempty"`
+	// Source is the file:line where the route is registered.
+	Source string `json:"x-source,omitempty"`
+}
+
+// OpenAPIParameter is a path, query or header parameter.
+type OpenAPIParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required"`
+	Schema   *JSONSchema `json:"schema,omitempty"`
+}
+
+// OpenAPIRequestBody is an operation's request body.
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIMediaType holds the schema of a body in one media type.
+type OpenAPIMediaType struct {
+	Schema *JSONSchema `json:"schema,omitempty"`
+}
+
+// OpenAPIResponse is an operation's response for one status code.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIComponents holds the named schemas operations refer to.
+type OpenAPIComponents struct {
+	Schemas map[string]*JSONSchema `json:"schemas,omitempty"`
+}
+
+// JSONSchema is the subset of JSON Schema used for inferred types. The
+// empty schema stands for a value of unknown type.
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+}
+
+// componentRefPrefix prefixes references to component schemas.
+const componentRefPrefix = "#/components/schemas/"
+
+// BuildOpenAPI builds an OpenAPI document from the detected HTTP routes.
+// Path parameters come from the route templates of the common routers. The
+// request and response bodies of Go handlers are inferred from the types
+// they decode and encode, read from files through sources; a nil sources
+// skips inference. Returns nil when there are no HTTP routes.
+func BuildOpenAPI(patterns []APIPattern, files []ScannedFile, sources SourceReader) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    OpenAPIInfo{Title: "HTTP API", Version: "unversioned"},
+		Paths:   make(map[string]OpenAPIPathItem),
+	}
+	inferrer := newGoSchemaInferrer(files, sources)
+	defer inferrer.close()
+	operationIDs := make(map[string]bool)
+
+	for _, p := range patterns {
+		if p.Kind != "http" || p.Path == "" {
+			continue
+		}
+		method, route := strings.ToLower(p.Method), strings.TrimSpace(p.Path)
+		// Go 1.22 ServeMux patterns carry the method: "GET /users/{id}".
+		if m, rest, ok := strings.Cut(route, " "); ok && isHTTPMethod(m) {
+			method, route = strings.ToLower(m), strings.TrimSpace(rest)
+		}
+		anyMethod := !isHTTPMethod(method)
+		if anyMethod {
+			method = "get"
+		}
+		if i := strings.Index(route, "/"); i > 0 {
+			route = route[i:] // drop a host or scheme prefix
+		}
+
+		path, params := openAPIPath(route)
+		item := doc.Paths[path]
+		if item == nil {
+			item = make(OpenAPIPathItem)
+			doc.Paths[path] = item
+		}
+		if item[method] != nil {
+			continue
+		}
+
+		handler := inferrer.infer(p)
+		op := &OpenAPIOperation{
+			OperationID: operationID(handler.name, method, path, operationIDs),
+			Parameters:  params,
+			Responses:   make(map[string]OpenAPIResponse),
+			AnyMethod:   anyMethod,
+			Source:      fmt.Sprintf("%s:%d", p.File, p.Line),
+		}
+		if handler.request != nil {
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]OpenAPIMediaType{"application/json": {Schema: handler.request}},
+			}
+		}
+		status := handler.status
+		if status == "" {
+			status = "200"
+		}
+		resp := OpenAPIResponse{Description: statusDescription(status)}
+		if handler.response != nil {
+			resp.Content = map[string]OpenAPIMediaType{"application/json": {Schema: handler.response}}
+		}
+		op.Responses[status] = resp
+		item[method] = op
+	}
+
+	if len(doc.Paths) == 0 {
+		return nil
+	}
+	if len(inferrer.schemas) > 0 {
+		doc.Components = &OpenAPIComponents{Schemas: inferrer.schemas}
+	}
+	return doc
+}
+
+// httpMethods lists the methods OpenAPI path items can hold.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func isHTTPMethod(m string) bool {
+	m = strings.ToLower(m)
+	for _, hm := range httpMethods {
+		if m == hm {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// braceParamRe matches {id}, {id:[0-9]+} and Go's {path...}.
+	braceParamRe = regexp.MustCompile(`^\{([A-Za-z_]\w*)(?:\.\.\.)?(?::(.+))?\}$`)
+	// angleParamRe matches Flask's <id> and <int:id>.
+	angleParamRe = regexp.MustCompile(`^<(?:(\w+):)?(\w+)>$`)
+)
+
+// openAPIPath converts a route in any of the supported routers' syntaxes to
+// an OpenAPI path template and its path parameters.
+func openAPIPath(route string) (string, []OpenAPIParameter) {
+	segments := strings.Split(route, "/")
+	var params []OpenAPIParameter
+	for i, seg := range segments {
+		var name, pattern, converter string
+		switch {
+		case seg == "{$}": // Go: match the path exactly
+			segments[i] = ""
+			continue
+		case braceParamRe.MatchString(seg):
+			m := braceParamRe.FindStringSubmatch(seg)
+			name, pattern = m[1], m[2]
+		case angleParamRe.MatchString(seg):
+			m := angleParamRe.FindStringSubmatch(seg)
+			converter, name = m[1], m[2]
+		case len(seg) > 1 && (seg[0] == ':' || seg[0] == '*'):
+			name = strings.TrimSuffix(seg[1:], "?")
+			if open := strings.IndexByte(name, '('); open > 0 && strings.HasSuffix(name, ")") {
+				name, pattern = name[:open], name[open+1:len(name)-1]
+			}
+		default:
+			continue
+		}
+		schema := &JSONSchema{Type: "string"}
+		switch {
+		case converter == "int" || isIntegerPattern(pattern):
+			schema = &JSONSchema{Type: "integer"}
+		case converter == "float":
+			schema = &JSONSchema{Type: "number"}
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, OpenAPIParameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	path := strings.Join(segments, "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path, params
+}
+
+func isIntegerPattern(p string) bool {
+	switch p {
+	case `[0-9]+`, `\d+`, `[0-9]*`, `\d*`:
+		return true
+	}
+	return false
+}
+
+// operationID names an operation after its handler, or after its method and
+// path when the handler is anonymous, keeping names unique.
+func operationID(handler, method, path string, used map[string]bool) string {
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	if name == "" || name == "func" {
+		var b strings.Builder
+		b.WriteString(method)
+		for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+		name = b.String()
+	}
+	id := name
+	for i := 2; used[id]; i++ {
+		id = fmt.Sprintf("%s%d", name, i)
+	}
+	used[id] = true
+	return id
+}
+
+// statusDescription describes a status code for a response object.
+func statusDescription(status string) string {
+	if code, err := strconv.Atoi(status); err == nil {
+		if text := http.StatusText(code); text != "" {
+			return text
+		}
+	}
+	return "Response"
+}
@@ -0,0 +1,583 @@
+package wiki
+
+import (
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/julianshen/rubichan/internal/parser"
+)
+
+// handlerInfo is what could be inferred about the handler of a route.
+type handlerInfo struct {
+	name     string      // handler expression, e.g. "h.ListUsers"
+	request  *JSONSchema // decoded request body, nil if unknown
+	response *JSONSchema // encoded response body, nil if unknown
+	status   string      // success status code, "" if unknown
+}
+
+// goSchemaInferrer infers the bodies Go HTTP handlers decode and encode from
+// the syntax trees of their packages, collecting the named types it meets as
+// component schemas.
+type goSchemaInferrer struct {
+	sources  SourceReader
+	parser   *parser.Parser
+	goFiles  map[string][]string   // package directory -> Go files
+	packages map[string]*goPackage // parsed on first use
+	schemas  map[string]*JSONSchema
+	names    map[string]string // "dir.Type" -> component name
+}
+
+// goPackage is the parsed source of one Go package.
+type goPackage struct {
+	dir   string
+	files map[string]goFile // by path
+	types map[string]goDecl // type specs by name
+	funcs map[string]goDecl // function and method declarations by name
+}
+
+type goFile struct {
+	tree *parser.Tree
+	src  []byte
+}
+
+// goDecl is a declaration and the source of its file.
+type goDecl struct {
+	node *sitter.Node
+	src  []byte
+}
+
+func newGoSchemaInferrer(files []ScannedFile, sources SourceReader) *goSchemaInferrer {
+	g := &goSchemaInferrer{
+		sources:  sources,
+		parser:   parser.NewParser(),
+		goFiles:  make(map[string][]string),
+		packages: make(map[string]*goPackage),
+		schemas:  make(map[string]*JSONSchema),
+		names:    make(map[string]string),
+	}
+	for _, f := range files {
+		if f.Language == "go" && !strings.HasSuffix(f.Path, "_test.go") {
+			dir := path.Dir(f.Path)
+			g.goFiles[dir] = append(g.goFiles[dir], f.Path)
+		}
+	}
+	return g
+}
+
+// close releases the parsed trees.
+func (g *goSchemaInferrer) close() {
+	for _, pkg := range g.packages {
+		for _, f := range pkg.files {
+			f.tree.Close()
+		}
+	}
+}
+
+// handlerExprRe finds the handler passed after a route's path literal.
+var handlerExprRe = regexp.MustCompile(`["'` + "`" + `]\s*,\s*([\w.]+)`)
+
+// infer inspects the handler registered by p.
+func (g *goSchemaInferrer) infer(p APIPattern) handlerInfo {
+	info := handlerInfo{name: p.Handler}
+	if g.sources == nil || p.Language != "go" {
+		return info
+	}
+	pkg := g.pkg(path.Dir(p.File))
+	file, ok := pkg.files[p.File]
+	if !ok {
+		return info
+	}
+	lines := strings.Split(string(file.src), "\n")
+	if p.Line < 1 || p.Line > len(lines) {
+		return info
+	}
+	if m := handlerExprRe.FindStringSubmatch(lines[p.Line-1]); m != nil {
+		info.name = m[1]
+	}
+
+	var fn goDecl
+	if info.name == "func" {
+		// An inline handler: the function literal on the registration line.
+		walkNodes(file.tree.RootNode(), func(n *sitter.Node) bool {
+			if fn.node == nil && n.Type() == "func_literal" && int(n.StartPoint().Row) == p.Line-1 {
+				fn = goDecl{node: n, src: file.src}
+			}
+			return fn.node == nil
+		})
+	} else {
+		fn = pkg.funcs[info.name[strings.LastIndex(info.name, ".")+1:]]
+	}
+	if fn.node == nil {
+		return info
+	}
+	g.inspectHandler(pkg, fn, &info)
+	return info
+}
+
+// pkg parses the Go package in dir on first use.
+func (g *goSchemaInferrer) pkg(dir string) *goPackage {
+	if pkg, ok := g.packages[dir]; ok {
+		return pkg
+	}
+	pkg := &goPackage{
+		dir:   dir,
+		files: make(map[string]goFile),
+		types: make(map[string]goDecl),
+		funcs: make(map[string]goDecl),
+	}
+	g.packages[dir] = pkg
+	for _, file := range g.goFiles[dir] {
+		src, err := g.sources.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		tree, err := g.parser.Parse(file, src)
+		if err != nil {
+			continue
+		}
+		pkg.files[file] = goFile{tree: tree, src: src}
+		root := tree.RootNode()
+		for i := 0; i < int(root.NamedChildCount()); i++ {
+			decl := root.NamedChild(i)
+			switch decl.Type() {
+			case "function_declaration", "method_declaration":
+				if name := decl.ChildByFieldName("name"); name != nil {
+					pkg.funcs[name.Content(src)] = goDecl{node: decl, src: src}
+				}
+			case "type_declaration":
+				for j := 0; j < int(decl.NamedChildCount()); j++ {
+					spec := decl.NamedChild(j)
+					if name := spec.ChildByFieldName("name"); name != nil {
+						pkg.types[name.Content(src)] = goDecl{node: spec, src: src}
+					}
+				}
+			}
+		}
+	}
+	return pkg
+}
+
+// inspectHandler looks through a handler's body for the calls that decode
+// the request and encode the response.
+func (g *goSchemaInferrer) inspectHandler(pkg *goPackage, decl goDecl, info *handlerInfo) {
+	fn, src := decl.node, decl.src
+	var headerStatus string
+	var responses []goResponse
+	walkNodes(fn, func(n *sitter.Node) bool {
+		if n.Type() != "call_expression" {
+			return true
+		}
+		callee := n.ChildByFieldName("function")
+		args := namedArgs(n)
+		name, recv := calleeName(callee, src)
+		switch {
+		case name == "Decode" && strings.HasSuffix(recv, "NewDecoder") && len(args) == 1,
+			isBindCall(name) && len(args) == 1:
+			if info.request == nil {
+				info.request = g.exprSchema(pkg, fn, args[0], src, 0)
+			}
+		case callee.Content(src) == "json.Unmarshal" && len(args) == 2:
+			if info.request == nil {
+				info.request = g.exprSchema(pkg, fn, args[1], src, 0)
+			}
+		case name == "Encode" && strings.HasSuffix(recv, "NewEncoder") && len(args) == 1:
+			responses = append(responses, goResponse{body: args[0]})
+		case isJSONResponseCall(name) && len(args) > 0:
+			r := goResponse{body: args[len(args)-1]}
+			for _, a := range args[:len(args)-1] {
+				if s := statusCode(a, src); s != "" {
+					r.status = s
+				}
+			}
+			responses = append(responses, r)
+		case name == "WriteHeader" && len(args) == 1:
+			if s := statusCode(args[0], src); s != "" && headerStatus == "" && isSuccess(s) {
+				headerStatus = s
+			}
+		}
+		return true
+	})
+
+	// The first response that is not an error is the one documented.
+	for _, r := range responses {
+		if r.status != "" && !isSuccess(r.status) {
+			continue
+		}
+		info.response = g.exprSchema(pkg, fn, r.body, src, 0)
+		info.status = r.status
+		break
+	}
+	if info.status == "" {
+		info.status = headerStatus
+	}
+}
+
+// goResponse is a JSON response written by a handler.
+type goResponse struct {
+	body   *sitter.Node
+	status string
+}
+
+func isBindCall(name string) bool {
+	switch name {
+	case "BindJSON", "ShouldBindJSON", "Bind", "ShouldBind", "BodyParser":
+		return true
+	}
+	return false
+}
+
+// jsonHelperRe matches helpers such as writeJSON and respondJSON.
+var jsonHelperRe = regexp.MustCompile(`^(?i:(write|respond|render|send|reply)_?json)$`)
+
+func isJSONResponseCall(name string) bool {
+	switch name {
+	case "JSON", "IndentedJSON", "PureJSON":
+		return true
+	}
+	return jsonHelperRe.MatchString(name)
+}
+
+// httpStatusConstants maps net/http status constants to their codes.
+var httpStatusConstants = map[string]string{
+	"StatusOK": "200", "StatusCreated": "201", "StatusAccepted": "202", "StatusNoContent": "204",
+	"StatusMovedPermanently": "301", "StatusFound": "302", "StatusNotModified": "304",
+	"StatusBadRequest": "400", "StatusUnauthorized": "401", "StatusForbidden": "403",
+	"StatusNotFound": "404", "StatusMethodNotAllowed": "405", "StatusConflict": "409",
+	"StatusUnprocessableEntity": "422", "StatusTooManyRequests": "429",
+	"StatusInternalServerError": "500", "StatusNotImplemented": "501",
+	"StatusBadGateway": "502", "StatusServiceUnavailable": "503",
+}
+
+// statusCode reads a status code argument: a literal or a net/http constant.
+func statusCode(n *sitter.Node, src []byte) string {
+	switch n.Type() {
+	case "int_literal":
+		if code, err := strconv.Atoi(n.Content(src)); err == nil && code >= 100 && code < 600 {
+			return strconv.Itoa(code)
+		}
+	case "selector_expression":
+		if field := n.ChildByFieldName("field"); field != nil {
+			return httpStatusConstants[field.Content(src)]
+		}
+	}
+	return ""
+}
+
+func isSuccess(status string) bool {
+	return strings.HasPrefix(status, "2")
+}
+
+// calleeName returns the called function's name and, for method calls, the
+// source of the receiver expression.
+func calleeName(callee *sitter.Node, src []byte) (name, recv string) {
+	switch callee.Type() {
+	case "identifier":
+		return callee.Content(src), ""
+	case "selector_expression":
+		field := callee.ChildByFieldName("field")
+		operand := callee.ChildByFieldName("operand")
+		if field == nil || operand == nil {
+			return "", ""
+		}
+		recv = operand.Content(src)
+		if operand.Type() == "call_expression" {
+			recv = operand.ChildByFieldName("function").Content(src)
+		}
+		return field.Content(src), recv
+	}
+	return "", ""
+}
+
+// namedArgs returns the arguments of a call.
+func namedArgs(call *sitter.Node) []*sitter.Node {
+	list := call.ChildByFieldName("arguments")
+	if list == nil {
+		return nil
+	}
+	args := make([]*sitter.Node, 0, list.NamedChildCount())
+	for i := 0; i < int(list.NamedChildCount()); i++ {
+		if arg := list.NamedChild(i); arg.Type() != "comment" {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// maxExprDepth bounds how far exprSchema follows variables to their values.
+const maxExprDepth = 4
+
+// exprSchema infers the schema of an expression in fn: a composite literal,
+// or a variable declared in fn with a type or an initial value.
+func (g *goSchemaInferrer) exprSchema(pkg *goPackage, fn, expr *sitter.Node, src []byte, depth int) *JSONSchema {
+	if depth > maxExprDepth {
+		return nil
+	}
+	switch expr.Type() {
+	case "unary_expression", "parenthesized_expression":
+		if operand := expr.ChildByFieldName("operand"); operand != nil {
+			return g.exprSchema(pkg, fn, operand, src, depth+1)
+		}
+		if expr.NamedChildCount() == 1 {
+			return g.exprSchema(pkg, fn, expr.NamedChild(0), src, depth+1)
+		}
+	case "composite_literal":
+		return g.typeSchema(pkg, expr.ChildByFieldName("type"), src)
+	case "call_expression":
+		name, _ := calleeName(expr.ChildByFieldName("function"), src)
+		if args := namedArgs(expr); (name == "make" || name == "new") && len(args) > 0 {
+			return g.typeSchema(pkg, args[0], src)
+		}
+	case "identifier":
+		return g.varSchema(pkg, fn, expr.Content(src), src, depth)
+	}
+	return nil
+}
+
+// varSchema finds the declaration of the variable name in fn and infers its
+// schema from the declared type or the assigned value.
+func (g *goSchemaInferrer) varSchema(pkg *goPackage, fn *sitter.Node, name string, src []byte, depth int) *JSONSchema {
+	var schema *JSONSchema
+	found := false
+	walkNodes(fn, func(n *sitter.Node) bool {
+		if found {
+			return false
+		}
+		switch n.Type() {
+		case "parameter_declaration", "var_spec":
+			for i := 0; i < int(n.NamedChildCount()); i++ {
+				if c := n.NamedChild(i); c.Type() == "identifier" && c.Content(src) == name {
+					found = true
+					if t := n.ChildByFieldName("type"); t != nil {
+						schema = g.typeSchema(pkg, t, src)
+					} else if v := n.ChildByFieldName("value"); v != nil && v.NamedChildCount() > 0 {
+						schema = g.exprSchema(pkg, fn, v.NamedChild(0), src, depth+1)
+					}
+					return false
+				}
+			}
+		case "short_var_declaration":
+			left, right := n.ChildByFieldName("left"), n.ChildByFieldName("right")
+			if left == nil || right == nil {
+				return true
+			}
+			for i := 0; i < int(left.NamedChildCount()); i++ {
+				if left.NamedChild(i).Content(src) == name {
+					found = true
+					if i < int(right.NamedChildCount()) && left.NamedChildCount() == right.NamedChildCount() {
+						schema = g.exprSchema(pkg, fn, right.NamedChild(i), src, depth+1)
+					}
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return schema
+}
+
+// goBuiltinSchemas maps Go's predeclared types to schemas.
+var goBuiltinSchemas = map[string]JSONSchema{
+	"string": {Type: "string"}, "bool": {Type: "boolean"},
+	"int": {Type: "integer"}, "int8": {Type: "integer"}, "int16": {Type: "integer"},
+	"int32": {Type: "integer", Format: "int32"}, "int64": {Type: "integer", Format: "int64"},
+	"uint": {Type: "integer"}, "uint8": {Type: "integer"}, "uint16": {Type: "integer"},
+	"uint32": {Type: "integer"}, "uint64": {Type: "integer"}, "byte": {Type: "integer"},
+	"rune": {Type: "integer"}, "uintptr": {Type: "integer"},
+	"float32": {Type: "number", Format: "float"}, "float64": {Type: "number", Format: "double"},
+	"error": {Type: "string"}, "any": {},
+}
+
+// goQualifiedSchemas maps well-known types of other packages to schemas.
+var goQualifiedSchemas = map[string]JSONSchema{
+	"time.Time":       {Type: "string", Format: "date-time"},
+	"time.Duration":   {Type: "integer"},
+	"json.RawMessage": {},
+	"uuid.UUID":       {Type: "string", Format: "uuid"},
+	"gin.H":           {Type: "object"},
+	"echo.Map":        {Type: "object"},
+	"fiber.Map":       {Type: "object"},
+}
+
+// typeSchema converts a Go type expression to a schema. Named types of the
+// package become component schemas.
+func (g *goSchemaInferrer) typeSchema(pkg *goPackage, t *sitter.Node, src []byte) *JSONSchema {
+	if t == nil {
+		return nil
+	}
+	switch t.Type() {
+	case "type_identifier", "identifier":
+		name := t.Content(src)
+		if s, ok := goBuiltinSchemas[name]; ok {
+			return &s
+		}
+		return g.namedSchema(pkg, name)
+	case "qualified_type", "selector_expression":
+		if s, ok := goQualifiedSchemas[t.Content(src)]; ok {
+			return &s
+		}
+		return &JSONSchema{}
+	case "pointer_type", "parenthesized_type":
+		if t.NamedChildCount() == 1 {
+			return g.typeSchema(pkg, t.NamedChild(0), src)
+		}
+	case "slice_type", "array_type":
+		elem := t.ChildByFieldName("element")
+		if elem != nil && elem.Content(src) == "byte" {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: nonNil(g.typeSchema(pkg, elem, src))}
+	case "map_type":
+		return &JSONSchema{Type: "object", AdditionalProperties: nonNil(g.typeSchema(pkg, t.ChildByFieldName("value"), src))}
+	case "struct_type":
+		return g.structSchema(pkg, t, src)
+	case "generic_type":
+		return g.typeSchema(pkg, t.ChildByFieldName("type"), src)
+	}
+	return &JSONSchema{}
+}
+
+// namedSchema returns a reference to the component schema of the named type
+// of pkg, converting the type on first use.
+func (g *goSchemaInferrer) namedSchema(pkg *goPackage, name string) *JSONSchema {
+	spec, ok := pkg.types[name]
+	if !ok {
+		return &JSONSchema{}
+	}
+	key := pkg.dir + "." + name
+	component, seen := g.names[key]
+	if !seen {
+		component = name
+		if _, taken := g.schemas[component]; taken {
+			component = strings.ReplaceAll(pkg.dir, "/", ".") + "." + name
+		}
+		g.names[key] = component
+		// Reserve the name first so recursive types refer to themselves.
+		g.schemas[component] = &JSONSchema{}
+		if s := g.typeSchema(pkg, spec.node.ChildByFieldName("type"), spec.src); s != nil {
+			g.schemas[component] = s
+		}
+	}
+	return &JSONSchema{Ref: componentRefPrefix + component}
+}
+
+// structSchema converts a struct type, following encoding/json: exported
+// fields named by their json tag, embedded structs flattened. Fields that
+// are pointers or omitempty are optional.
+func (g *goSchemaInferrer) structSchema(pkg *goPackage, st *sitter.Node, src []byte) *JSONSchema {
+	schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+	var list *sitter.Node
+	for i := 0; i < int(st.NamedChildCount()); i++ {
+		if c := st.NamedChild(i); c.Type() == "field_declaration_list" {
+			list = c
+		}
+	}
+	if list == nil {
+		return schema
+	}
+	for i := 0; i < int(list.NamedChildCount()); i++ {
+		field := list.NamedChild(i)
+		if field.Type() != "field_declaration" {
+			continue
+		}
+		typ := field.ChildByFieldName("type")
+		jsonName, omitEmpty, skip := jsonTag(field.ChildByFieldName("tag"), src)
+		if skip {
+			continue
+		}
+		var names []string
+		for j := 0; j < int(field.NamedChildCount()); j++ {
+			if c := field.NamedChild(j); c.Type() == "field_identifier" {
+				names = append(names, c.Content(src))
+			}
+		}
+
+		if len(names) == 0 && jsonName == "" {
+			// Embedded: its fields are promoted into this object.
+			embedded := g.resolve(g.typeSchema(pkg, typ, src))
+			if embedded != nil && embedded.Type == "object" {
+				for name, prop := range embedded.Properties {
+					if _, ok := schema.Properties[name]; !ok {
+						schema.Properties[name] = prop
+					}
+				}
+				schema.Required = append(schema.Required, embedded.Required...)
+			}
+			continue
+		}
+		if len(names) == 0 {
+			names = []string{baseTypeName(typ, src)}
+		}
+
+		for _, name := range names {
+			if !isExported(name) {
+				continue
+			}
+			prop := name
+			if jsonName != "" {
+				prop = jsonName
+			}
+			schema.Properties[prop] = nonNil(g.typeSchema(pkg, typ, src))
+			if !omitEmpty && typ != nil && typ.Type() != "pointer_type" {
+				schema.Required = append(schema.Required, prop)
+			}
+		}
+	}
+	return schema
+}
+
+// resolve follows a component reference.
+func (g *goSchemaInferrer) resolve(s *JSONSchema) *JSONSchema {
+	if s != nil && s.Ref != "" {
+		return g.schemas[strings.TrimPrefix(s.Ref, componentRefPrefix)]
+	}
+	return s
+}
+
+// jsonTag parses a field's json struct tag.
+func jsonTag(tag *sitter.Node, src []byte) (name string, omitEmpty, skip bool) {
+	if tag == nil {
+		return "", false, false
+	}
+	raw, err := strconv.Unquote(tag.Content(src))
+	if err != nil {
+		return "", false, false
+	}
+	value, ok := reflect.StructTag(raw).Lookup("json")
+	if !ok {
+		return "", false, false
+	}
+	if value == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(value, ",")
+	return name, strings.Contains(","+opts+",", ",omitempty,") || strings.Contains(","+opts+",", ",omitzero,"), false
+}
+
+// baseTypeName is the name of an embedded field's type.
+func baseTypeName(t *sitter.Node, src []byte) string {
+	s := strings.TrimPrefix(t.Content(src), "*")
+	return s[strings.LastIndex(s, ".")+1:]
+}
+
+func nonNil(s *JSONSchema) *JSONSchema {
+	if s == nil {
+		return &JSONSchema{}
+	}
+	return s
+}
+
+// walkNodes visits n and its descendants depth-first while fn returns true
+// for the nodes whose children should be visited.
+func walkNodes(n *sitter.Node, fn func(*sitter.Node) bool) {
+	if n == nil || !fn(n) {
+		return
+	}
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		walkNodes(n.NamedChild(i), fn)
+	}
+}
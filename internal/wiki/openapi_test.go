@@ -0,0 +1,162 @@
+package wiki
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIPath(t *testing.T) {
+	tests := []struct {
+		route      string
+		wantPath   string
+		wantParams map[string]string // name -> schema type
+	}{
+		{"/users", "/users", nil},
+		{"/users/{id}", "/users/{id}", map[string]string{"id": "string"}},
+		{"/users/{id:[0-9]+}", "/users/{id}", map[string]string{"id": "integer"}},
+		{"/files/{path...}", "/files/{path}", map[string]string{"path": "string"}},
+		{"/{$}", "/", nil},
+		{"/users/:id/posts/*rest", "/users/{id}/posts/{rest}", map[string]string{"id": "string", "rest": "string"}},
+		{"/items/<int:item_id>", "/items/{item_id}", map[string]string{"item_id": "integer"}},
+		{"/prices/<float:amount>", "/prices/{amount}", map[string]string{"amount": "number"}},
+		{"health", "/health", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.route, func(t *testing.T) {
+			path, params := openAPIPath(tt.route)
+			assert.Equal(t, tt.wantPath, path)
+			got := make(map[string]string)
+			for _, p := range params {
+				assert.Equal(t, "path", p.In)
+				assert.True(t, p.Required)
+				got[p.Name] = p.Schema.Type
+			}
+			if tt.wantParams == nil {
+				assert.Empty(t, got)
+			} else {
+				assert.Equal(t, tt.wantParams, got)
+			}
+		})
+	}
+}
+
+func TestBuildOpenAPIRoutes(t *testing.T) {
+	patterns := []APIPattern{
+		{Kind: "http", Method: "GET", Path: "/users/:id", Handler: "getUser", File: "server.js", Line: 3, Language: "javascript"},
+		{Kind: "http", Path: "/health", Handler: "health", File: "app.py", Line: 7, Language: "python"},
+		{Kind: "http", Method: "GET", Path: "/users/:id", Handler: "duplicate", File: "server.js", Line: 9, Language: "javascript"},
+		{Kind: "cli", Path: "serve", File: "main.go", Line: 1, Language: "go"},
+	}
+
+	doc := BuildOpenAPI(patterns, nil, nil)
+	require.NotNil(t, doc)
+	assert.Equal(t, OpenAPIVersion, doc.OpenAPI)
+	require.Len(t, doc.Paths, 2)
+
+	get := doc.Paths["/users/{id}"]["get"]
+	require.NotNil(t, get)
+	assert.Equal(t, "getUser", get.OperationID, "first registration wins")
+	assert.Equal(t, "server.js:3", get.Source)
+	assert.Equal(t, "OK", get.Responses["200"].Description)
+	require.Len(t, get.Parameters, 1)
+	assert.Equal(t, "id", get.Parameters[0].Name)
+
+	health := doc.Paths["/health"]["get"]
+	require.NotNil(t, health)
+	assert.True(t, health.AnyMethod, "a route without a method serves every method")
+
+	assert.Nil(t, BuildOpenAPI(patterns[3:], nil, nil), "no HTTP routes")
+}
+
+const openAPIGoServer = `package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type Server struct{}
+
+type User struct {
+	ID        int       ` + "`json:\"id\"`" + `
+	Name      string    ` + "`json:\"name\"`" + `
+	Email     string    ` + "`json:\"email,omitempty\"`" + `
+	CreatedAt time.Time ` + "`json:\"created_at\"`" + `
+	Tags      []string  ` + "`json:\"tags\"`" + `
+	Manager   *User     ` + "`json:\"manager\"`" + `
+	secret    string
+}
+
+type createUserRequest struct {
+	Name string ` + "`json:\"name\"`" + `
+	Role string ` + "`json:\"-\"`" + `
+}
+
+func (s *Server) Routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /users/{id}", s.getUser)
+	mux.HandleFunc("POST /users", s.createUser)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	var u User
+	json.NewEncoder(w).Encode(u)
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var req createUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&User{Name: req.Name})
+}
+`
+
+func TestBuildOpenAPIInfersGoSchemas(t *testing.T) {
+	reader := &mockSourceReader{files: map[string][]byte{"api/server.go": []byte(openAPIGoServer)}}
+	files := []ScannedFile{{Path: "api/server.go", Language: "go"}}
+	patterns := ScanAPIPatterns(files, reader.ReadFile)
+	require.Len(t, patterns, 2)
+
+	doc := BuildOpenAPI(patterns, files, reader)
+	require.NotNil(t, doc)
+
+	get := doc.Paths["/users/{id}"]["get"]
+	require.NotNil(t, get)
+	assert.Equal(t, "getUser", get.OperationID)
+	assert.Nil(t, get.RequestBody)
+	assert.Equal(t, componentRefPrefix+"User", jsonBodySchema(get.Responses["200"].Content).Ref)
+
+	post := doc.Paths["/users"]["post"]
+	require.NotNil(t, post)
+	require.NotNil(t, post.RequestBody)
+	assert.Equal(t, componentRefPrefix+"createUserRequest", jsonBodySchema(post.RequestBody.Content).Ref)
+	require.Contains(t, post.Responses, "201")
+	assert.Equal(t, componentRefPrefix+"User", jsonBodySchema(post.Responses["201"].Content).Ref)
+
+	require.NotNil(t, doc.Components)
+	user := doc.Components.Schemas["User"]
+	require.NotNil(t, user)
+	assert.Equal(t, "object", user.Type)
+	assert.ElementsMatch(t, []string{"id", "name", "email", "created_at", "tags", "manager"}, keys(user.Properties))
+	assert.ElementsMatch(t, []string{"id", "name", "created_at", "tags"}, user.Required)
+	assert.Equal(t, &JSONSchema{Type: "string", Format: "date-time"}, user.Properties["created_at"])
+	assert.Equal(t, &JSONSchema{Type: "array", Items: &JSONSchema{Type: "string"}}, user.Properties["tags"])
+	assert.Equal(t, componentRefPrefix+"User", user.Properties["manager"].Ref, "recursive types refer to themselves")
+
+	req := doc.Components.Schemas["createUserRequest"]
+	require.NotNil(t, req)
+	assert.Equal(t, []string{"name"}, keys(req.Properties))
+}
+
+func keys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
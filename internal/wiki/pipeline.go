@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,11 +18,21 @@ import (
 type Config struct {
 	Dir              string
 	OutputDir        string
-	Format           string // raw-md, hugo, docusaurus
+	Format           string // raw-md, hugo, docusaurus, html
 	DiagramFmt       string // mermaid
 	Concurrency      int    // parallel LLM calls
 	SecurityFindings []security.Finding
 
+	// Since, when set to a git ref, makes the run incremental: analyses
+	// cached by earlier runs are reused, and only chunks whose files changed
+	// since the ref go back to the LLM. Stages whose inputs did not change
+	// are answered from the cache too, so unaffected documents and diagrams
+	// come out as they were. Without it the repository is analyzed afresh.
+	Since string
+	// CachePath is where analyses are kept between runs. Defaults to
+	// .rubichan/wiki-cache.json under Dir.
+	CachePath string
+
 	// ProgressFunc, when non-nil, receives progress updates for each pipeline stage.
 	// When nil, progress is written to stderr instead.
 	ProgressFunc func(stage string, current, total int)
@@ -50,6 +61,34 @@ func (r *osSourceReader) ReadFile(path string) ([]byte, error) {
 func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*WikiResult, error) {
 	start := time.Now()
 
+	// Full runs start from an empty cache and save it for the next
+	// incremental run; incremental runs load what the last run saved.
+	cachePath := cfg.CachePath
+	if cachePath == "" {
+		cachePath = filepath.Join(cfg.Dir, ".rubichan", "wiki-cache.json")
+	}
+	cache := NewAnalysisCache(cachePath)
+	var changed map[string]bool
+	if cfg.Since != "" {
+		cfg.progress("changes", 0, 0, fmt.Sprintf("wiki: finding changes since %s...", cfg.Since))
+		var err error
+		changed, err = ChangedFiles(ctx, cfg.Dir, cfg.Since)
+		if err != nil {
+			if isContextCancellation(err) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("changes: %w", err)
+		}
+		cache, err = LoadAnalysisCache(cachePath)
+		if err != nil {
+			return nil, fmt.Errorf("cache: %w", err)
+		}
+	}
+	stageLLM := llm
+	if llm != nil {
+		stageLLM = cache.Completer(llm)
+	}
+
 	// Stage 1: Scan
 	cfg.progress("scanning", 0, 0, fmt.Sprintf("wiki: scanning %s...", cfg.Dir))
 	files, err := Scan(ctx, cfg.Dir, p)
@@ -59,6 +98,18 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 		}
 		return nil, fmt.Errorf("scan: %w", err)
 	}
+	// Pages from earlier runs are untracked until committed; they are output,
+	// not source, so they are neither documented nor counted as changes.
+	if out := outputPrefix(cfg.Dir, cfg.OutputDir); out != "" {
+		files = slices.DeleteFunc(files, func(f ScannedFile) bool {
+			return strings.HasPrefix(f.Path, out)
+		})
+		for path := range changed {
+			if strings.HasPrefix(path, out) {
+				delete(changed, path)
+			}
+		}
+	}
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
@@ -72,9 +123,21 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 		return nil, err
 	}
 
+	// Stage 2b: Build the machine-readable API spec and load the previous
+	// run's, so breaking changes can be flagged.
+	reader := &osSourceReader{baseDir: cfg.Dir}
+	rendererCfg := RendererConfig{Format: cfg.Format, OutputDir: cfg.OutputDir}
+	apiSpec := BuildAPISpec(apiPatterns, files, reader)
+	previousAPI, err := LoadAPISpec(rendererCfg)
+	if err != nil {
+		log.Printf("wiki: warning: ignoring previous API spec: %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Stage 3: Chunk
 	cfg.progress("chunking", 0, len(files), fmt.Sprintf("wiki: chunking %d files...", len(files)))
-	reader := &osSourceReader{baseDir: cfg.Dir}
 	chunks, err := ChunkFiles(files, reader, DefaultChunkerConfig())
 	if err != nil {
 		return nil, fmt.Errorf("chunk: %w", err)
@@ -89,7 +152,7 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 	if concurrency <= 0 {
 		concurrency = 5
 	}
-	analyzerCfg := AnalyzerConfig{Concurrency: concurrency}
+	analyzerCfg := AnalyzerConfig{Concurrency: concurrency, Cache: cache, Changed: changed}
 	analysis, err := AnalyzeBase(ctx, chunks, llm, analyzerCfg)
 	if err != nil {
 		if isContextCancellation(err) {
@@ -100,14 +163,18 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
+	cachedChunks, analyzedChunks := cache.ChunkStats()
+	if cfg.Since != "" {
+		cfg.progress("analyzed", analyzedChunks, len(chunks), fmt.Sprintf("wiki: analyzed %d chunks, reused %d from the cache", analyzedChunks, cachedChunks))
+	}
 
 	// Stage 3b: Specialized analyzers
 	cfg.progress("specialized-analysis", 0, 0, "wiki: running specialized analyzers...")
 	specializedAnalyzers := []SpecializedAnalyzer{
-		NewSuggestionAnalyzer(llm),
-		NewAPIAnalyzer(llm),
-		NewSecurityAnalyzer(llm),
-		NewDependencyAnalyzer(llm, cfg.Dir),
+		NewSuggestionAnalyzer(stageLLM),
+		NewAPIAnalyzer(stageLLM),
+		NewSecurityAnalyzer(stageLLM),
+		NewDependencyAnalyzer(stageLLM, cfg.Dir),
 	}
 	analyzerInput := AnalyzerInput{
 		Chunks:         chunks,
@@ -115,6 +182,8 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 		ModuleAnalyses: analysis.Modules,
 		Architecture:   analysis.Architecture,
 		APIPatterns:    apiPatterns,
+		APISpec:        apiSpec,
+		PreviousAPI:    previousAPI,
 	}
 	extraDocs, extraDiagrams, err := RunSpecializedAnalyzers(ctx, specializedAnalyzers, analyzerInput)
 	if err != nil {
@@ -133,7 +202,7 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 	if diagramFmt == "" {
 		diagramFmt = "mermaid"
 	}
-	diagrams, err := GenerateDiagrams(ctx, files, analysis, llm, DiagramConfig{Format: diagramFmt})
+	diagrams, err := GenerateDiagrams(ctx, files, analysis, stageLLM, DiagramConfig{Format: diagramFmt})
 	if err != nil {
 		if isContextCancellation(err) {
 			return nil, err
@@ -158,7 +227,7 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 
 	// Stage 6: Change history — read existing docs, diff, append changelog entries.
 	cfg.progress("changelog", 0, len(documents), "wiki: applying change history...")
-	existing := readExistingDocs(cfg.OutputDir)
+	existing := readExistingDocs(docSourceDir(rendererCfg))
 	documents, err = ApplyChangelog(ctx, existing, documents, llm)
 	if err != nil {
 		return nil, fmt.Errorf("changelog: %w", err)
@@ -169,13 +238,21 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 
 	// Stage 7: Render
 	cfg.progress("rendering", 0, len(documents), fmt.Sprintf("wiki: rendering %d documents to %s...", len(documents), cfg.OutputDir))
-	if err := Render(documents, RendererConfig{Format: cfg.Format, OutputDir: cfg.OutputDir}); err != nil {
+	if err := Render(documents, rendererCfg); err != nil {
+		return nil, fmt.Errorf("render: %w", err)
+	}
+	artifacts, err := WriteAPISpec(apiSpec, rendererCfg)
+	if err != nil {
 		return nil, fmt.Errorf("render: %w", err)
 	}
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
+	if err := cache.Save(); err != nil {
+		log.Printf("wiki: warning: %v", err)
+	}
+
 	cfg.progress("done", 0, 0, "wiki: done.")
 
 	format := cfg.Format
@@ -183,13 +260,19 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 		format = "raw-md"
 	}
 	result := &WikiResult{
-		OutputDir:     cfg.OutputDir,
-		Format:        format,
-		Documents:     len(documents),
-		Diagrams:      len(diagrams),
-		DurationMs:    time.Since(start).Milliseconds(),
-		APISurfaces:   uniqueAPIKinds(apiPatterns),
-		SecurityDepth: securityDocsProduced(documents),
+		OutputDir:          cfg.OutputDir,
+		Format:             format,
+		Documents:          len(documents),
+		Diagrams:           len(diagrams),
+		DurationMs:         time.Since(start).Milliseconds(),
+		APISurfaces:        uniqueAPIKinds(apiPatterns),
+		SecurityDepth:      securityDocsProduced(documents),
+		Since:              cfg.Since,
+		ChangedFiles:       len(changed),
+		AnalyzedChunks:     analyzedChunks,
+		CachedChunks:       cachedChunks,
+		Artifacts:          artifacts,
+		BreakingAPIChanges: BreakingChanges(DiffAPISpecs(previousAPI, apiSpec)),
 	}
 	// Count new vs updated vs unchanged based on existing docs.
 	for _, doc := range documents {
@@ -205,14 +288,33 @@ func Run(ctx context.Context, cfg Config, llm LLMCompleter, p *parser.Parser) (*
 	return result, nil
 }
 
-// readExistingDocs reads all .md files from the output directory into a
-// path→content map for change history comparison.
-func readExistingDocs(outputDir string) map[string]string {
+// outputPrefix returns outputDir relative to dir with a trailing slash, or ""
+// when the output is written outside dir.
+func outputPrefix(dir, outputDir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	absOut, err := filepath.Abs(outputDir)
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(absDir, absOut)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel) + "/"
+}
+
+// readExistingDocs reads all .md files under dir, the markdown a previous
+// run rendered (see docSourceDir), into a path→content map for change
+// history comparison.
+func readExistingDocs(dir string) map[string]string {
 	docs := make(map[string]string)
-	if outputDir == "" {
+	if dir == "" {
 		return docs
 	}
-	_ = filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			log.Printf("wiki: warning reading %s: %v", path, err)
 			return nil
@@ -220,7 +322,7 @@ func readExistingDocs(outputDir string) map[string]string {
 		if info.IsDir() || filepath.Ext(path) != ".md" {
 			return nil
 		}
-		rel, err := filepath.Rel(outputDir, path)
+		rel, err := filepath.Rel(dir, path)
 		if err != nil {
 			return nil
 		}
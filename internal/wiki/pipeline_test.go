@@ -4,7 +4,9 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/julianshen/rubichan/internal/parser"
 	"github.com/stretchr/testify/assert"
@@ -297,3 +299,169 @@ func ToUpper(s string) string {
 	sugContent := readTestFile(t, filepath.Join(outDir, "suggestions", "improvements.md"))
 	assert.Contains(t, sugContent, "Add tests")
 }
+
+func TestRunHTMLKeepsChangeHistoryAcrossRuns(t *testing.T) {
+	stubMermaidAsset(t, fstest.MapFS{
+		"assets/mermaid.min.js":  {Data: []byte("vendored mermaid")},
+		"assets/mermaid.LICENSE": {Data: []byte("MIT")},
+	})
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	gitAdd(t, dir, ".")
+	outDir := t.TempDir()
+
+	cfg := Config{Dir: dir, OutputDir: outDir, Format: "html", Concurrency: 1}
+	p := parser.NewParser()
+	first := &mockLLMCompleter{responses: map[string]string{
+		`module "root"`: "Summary: Entry point\nKeyTypes: none\nPatterns: none\nConcerns: none",
+	}}
+	_, err := Run(context.Background(), cfg, first, p)
+	require.NoError(t, err)
+	assertFileExists(t, filepath.Join(outDir, "index.html"))
+	assertFileContains(t, filepath.Join(outDir, "_source", "_index.md"), "Initial generation")
+
+	second := &mockLLMCompleter{responses: map[string]string{
+		`module "root"`:          "Summary: Starts the server\nKeyTypes: none\nPatterns: none\nConcerns: none",
+		"summarize what changed": "Rewrote the module summary.",
+	}}
+	_, err = Run(context.Background(), cfg, second, p)
+	require.NoError(t, err)
+
+	page := readTestFile(t, filepath.Join(outDir, "modules", "root.html"))
+	assert.Contains(t, page, "Starts the server")
+	assert.Contains(t, page, "Rewrote the module summary.", "the change is recorded")
+	assert.Contains(t, page, "Initial generation", "earlier history is kept")
+	assert.Equal(t, 1, strings.Count(readTestFile(t, filepath.Join(outDir, "_source", "modules", "root.md")), "Initial generation"),
+		"the page is not treated as new again")
+}
+
+func TestRunIncrementalReanalyzesOnlyChangedChunks(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(dir, "util", "util.go"), "package util\n\nfunc Upper() {}\n")
+	gitAdd(t, dir, ".")
+	runGit(t, dir, "tag", "v1")
+	outDir := t.TempDir()
+
+	newLLM := func() *mockLLMCompleter {
+		return &mockLLMCompleter{responses: map[string]string{
+			`module "root"`: "Summary: Entry point\nKeyTypes: none\nPatterns: none\nConcerns: none",
+			`module "util"`: "Summary: Helpers\nKeyTypes: none\nPatterns: none\nConcerns: none",
+		}}
+	}
+	cfg := Config{Dir: dir, OutputDir: outDir, Format: "raw-md", Concurrency: 1}
+	p := parser.NewParser()
+
+	full := newLLM()
+	result, err := Run(context.Background(), cfg, full, p)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.AnalyzedChunks)
+	assert.Zero(t, result.CachedChunks)
+	assertFileExists(t, filepath.Join(dir, ".rubichan", "wiki-cache.json"))
+	overview := readTestFile(t, filepath.Join(outDir, "architecture", "overview.md"))
+
+	// Nothing changed: every stage is answered from the cache.
+	cfg.Since = "v1"
+	idle := newLLM()
+	result, err = Run(context.Background(), cfg, idle, p)
+	require.NoError(t, err)
+	assert.Empty(t, idle.calls)
+	assert.Equal(t, 2, result.CachedChunks)
+	assert.Equal(t, overview, readTestFile(t, filepath.Join(outDir, "architecture", "overview.md")))
+
+	// One module changed: only its chunk goes back to the LLM.
+	writeFile(t, filepath.Join(dir, "util", "util.go"), "package util\n\nfunc Upper() {}\n\nfunc Lower() {}\n")
+	gitAdd(t, dir, ".")
+	incremental := newLLM()
+	result, err = Run(context.Background(), cfg, incremental, p)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.ChangedFiles)
+	assert.Equal(t, 1, result.AnalyzedChunks)
+	assert.Equal(t, 1, result.CachedChunks)
+	var moduleCalls []string
+	for _, call := range incremental.calls {
+		if strings.HasPrefix(call, "Analyze the following source code module") {
+			moduleCalls = append(moduleCalls, call)
+		}
+	}
+	require.Len(t, moduleCalls, 1)
+	assert.Contains(t, moduleCalls[0], `module "util"`)
+	assert.Equal(t, overview, readTestFile(t, filepath.Join(outDir, "architecture", "overview.md")),
+		"documents the change does not affect are left as they were")
+}
+
+func TestRunIncrementalAnalyzesUntrackedFiles(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	gitAdd(t, dir, ".")
+	runGit(t, dir, "tag", "v1")
+
+	llm := &mockLLMCompleter{responses: map[string]string{
+		`module "root"`: "Summary: Entry point\nKeyTypes: none\nPatterns: none\nConcerns: none",
+		`module "util"`: "Summary: Helpers\nKeyTypes: none\nPatterns: none\nConcerns: none",
+	}}
+	// The wiki is written inside the repository, so its pages are untracked
+	// files too; they must not count as source changes.
+	cfg := Config{Dir: dir, OutputDir: filepath.Join(dir, "docs", "wiki"), Format: "raw-md", Concurrency: 1}
+	p := parser.NewParser()
+	_, err := Run(context.Background(), cfg, llm, p)
+	require.NoError(t, err)
+
+	writeFile(t, filepath.Join(dir, "util", "util.go"), "package util\n\nfunc Upper() {}\n")
+	cfg.Since = "v1"
+	llm.calls = nil
+	result, err := Run(context.Background(), cfg, llm, p)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.ChangedFiles, "only the new source file changed")
+	assert.Equal(t, 1, result.AnalyzedChunks)
+	assert.Equal(t, 1, result.CachedChunks)
+	var analyzed []string
+	for _, call := range llm.calls {
+		if strings.HasPrefix(call, "Analyze the following source code module") {
+			analyzed = append(analyzed, call)
+		}
+	}
+	require.Len(t, analyzed, 1)
+	assert.Contains(t, analyzed[0], `module "util"`)
+}
+
+func TestRunWritesAPISpecAndFlagsBreakingChanges(t *testing.T) {
+	srcDir := t.TempDir()
+	initGitRepo(t, srcDir)
+	server := func(routes string) string {
+		return `package main
+
+import "net/http"
+
+func routes(mux *http.ServeMux) {
+` + routes + `}
+
+func listUsers(w http.ResponseWriter, r *http.Request) {}
+
+func deleteUser(w http.ResponseWriter, r *http.Request) {}
+`
+	}
+	writeFile(t, filepath.Join(srcDir, "main.go"), server("\tmux.HandleFunc(\"GET /users\", listUsers)\n\tmux.HandleFunc(\"DELETE /users/{id}\", deleteUser)\n"))
+	gitAdd(t, srcDir, "main.go")
+
+	outDir := t.TempDir()
+	cfg := Config{Dir: srcDir, OutputDir: outDir, Format: "raw-md", Concurrency: 1}
+	llm := &mockLLMCompleter{}
+
+	result, err := Run(context.Background(), cfg, llm, parser.NewParser())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api/openapi.json"}, result.Artifacts)
+	assert.Empty(t, result.BreakingAPIChanges)
+	spec := readTestFile(t, filepath.Join(outDir, "api", "openapi.json"))
+	assert.Contains(t, spec, `"/users/{id}"`)
+
+	writeFile(t, filepath.Join(srcDir, "main.go"), server("\tmux.HandleFunc(\"GET /users\", listUsers)\n"))
+	result, err = Run(context.Background(), cfg, llm, parser.NewParser())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"removed `DELETE /users/{id}`"}, result.BreakingAPIChanges)
+	index := readTestFile(t, filepath.Join(outDir, "api", "_index.md"))
+	assert.Contains(t, index, "Breaking API changes: removed `DELETE /users/{id}`")
+}
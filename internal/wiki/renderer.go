@@ -8,8 +8,9 @@ import (
 
 // RendererConfig controls how the site renderer writes output files.
 type RendererConfig struct {
-	Format    string // "raw-md", "hugo", or "docusaurus"
-	OutputDir string // root output directory
+	Format     string // "raw-md", "hugo", "docusaurus", or "html"
+	OutputDir  string // root output directory
+	MermaidURL string // Mermaid script for the html format; the vendored copy if empty
 }
 
 // DefaultRendererConfig returns a RendererConfig with sensible defaults.
@@ -29,11 +30,22 @@ func Render(documents []Document, cfg RendererConfig) error {
 		return renderHugo(documents, cfg)
 	case "docusaurus":
 		return renderDocusaurus(documents, cfg)
+	case "html":
+		return renderHTML(documents, cfg)
 	default:
 		return fmt.Errorf("unsupported render format: %s", cfg.Format)
 	}
 }
 
+// docSourceDir is the directory holding the markdown of the documents a
+// previous Render wrote with cfg, keyed by Document.Path.
+func docSourceDir(cfg RendererConfig) string {
+	if cfg.Format == "html" {
+		return filepath.Join(cfg.OutputDir, htmlSourceDir)
+	}
+	return cfg.OutputDir
+}
+
 // renderRawMarkdown writes each document as-is under OutputDir.
 func renderRawMarkdown(documents []Document, cfg RendererConfig) error {
 	for _, doc := range documents {
@@ -0,0 +1,555 @@
+package wiki
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+//go:generate curl -fsSL -o assets/mermaid.min.js https://cdn.jsdelivr.net/npm/mermaid@11.4.1/dist/mermaid.min.js
+
+// embeddedHTMLAssets holds the vendored scripts the HTML site ships with.
+//
+//go:embed assets
+var embeddedHTMLAssets embed.FS
+
+// htmlAssets is read for vendored scripts; tests replace it.
+var htmlAssets fs.FS = embeddedHTMLAssets
+
+// mermaidAsset is the vendored Mermaid build, copied into the site's assets
+// directory with its license so diagrams render offline.
+// RendererConfig.MermaidURL overrides it; without either, diagrams show as
+// their Mermaid source.
+const (
+	mermaidAsset   = "mermaid.min.js"
+	mermaidLicense = "mermaid.LICENSE"
+)
+
+// htmlSourceDir holds the markdown each page was rendered from, under
+// OutputDir, so later runs can compare against it.
+const htmlSourceDir = "_source"
+
+// htmlSiteTitle is the name shown in the HTML site's header and titles.
+const htmlSiteTitle = "Project Wiki"
+
+// htmlSearchTextLimit caps the text of a page kept in the search index.
+const htmlSearchTextLimit = 8000
+
+// htmlPage is a document converted for the static site.
+type htmlPage struct {
+	title   string
+	url     string // site-relative, e.g. "modules/index.html"
+	body    string
+	text    string // plain text for the search index
+	mermaid bool
+}
+
+// renderHTML writes documents as a standalone static site under OutputDir:
+// one page per document with a navigation sidebar, syntax highlighting
+// rendered ahead of time, Mermaid diagrams and a client-side search index.
+// All links are relative, so the site works from any path or from disk. The
+// markdown of each page is kept under htmlSourceDir for the next run.
+func renderHTML(documents []Document, cfg RendererConfig) error {
+	highlight := &htmlCodeRenderer{
+		formatter: chromahtml.New(chromahtml.WithClasses(true)),
+		style:     styles.Get("github"),
+	}
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(renderer.WithNodeRenderers(util.Prioritized(highlight, 100))),
+	)
+
+	pages := make([]htmlPage, 0, len(documents))
+	for _, doc := range documents {
+		page, err := convertHTMLPage(md, highlight, doc)
+		if err != nil {
+			return err
+		}
+		pages = append(pages, page)
+	}
+
+	var css bytes.Buffer
+	css.WriteString(htmlSiteCSS)
+	if err := highlight.formatter.WriteCSS(&css, highlight.style); err != nil {
+		return fmt.Errorf("writing highlight styles: %w", err)
+	}
+	index, err := htmlSearchIndex(pages)
+	if err != nil {
+		return err
+	}
+	assets := map[string]string{
+		"style.css":       css.String(),
+		"search.js":       htmlSearchJS,
+		"search-index.js": index,
+	}
+
+	mermaidURL := cfg.MermaidURL
+	if mermaidURL == "" && usesMermaid(pages) {
+		script, err := fs.ReadFile(htmlAssets, "assets/"+mermaidAsset)
+		switch {
+		case err == nil:
+			license, err := fs.ReadFile(htmlAssets, "assets/"+mermaidLicense)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", mermaidLicense, err)
+			}
+			assets[mermaidAsset] = string(script)
+			assets[mermaidLicense] = string(license)
+			mermaidURL = "assets/" + mermaidAsset
+		case errors.Is(err, fs.ErrNotExist):
+			log.Printf("wiki: warning: %s is not vendored (run go generate ./internal/wiki); diagrams will show as source", mermaidAsset)
+		default:
+			return fmt.Errorf("reading %s: %w", mermaidAsset, err)
+		}
+	}
+
+	nav := buildHTMLNav(pages)
+	for _, page := range pages {
+		content, err := renderHTMLLayout(page, nav, mermaidURL)
+		if err != nil {
+			return err
+		}
+		if err := writeDoc(filepath.Join(cfg.OutputDir, filepath.FromSlash(page.url)), content); err != nil {
+			return err
+		}
+	}
+	for name, content := range assets {
+		if err := writeDoc(filepath.Join(cfg.OutputDir, "assets", name), content); err != nil {
+			return err
+		}
+	}
+	for _, doc := range documents {
+		if err := writeDoc(filepath.Join(cfg.OutputDir, htmlSourceDir, filepath.FromSlash(doc.Path)), doc.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func usesMermaid(pages []htmlPage) bool {
+	for _, p := range pages {
+		if p.mermaid {
+			return true
+		}
+	}
+	return false
+}
+
+// convertHTMLPage converts a document's Markdown to HTML, pointing links to
+// other documents at their pages.
+func convertHTMLPage(md goldmark.Markdown, highlight *htmlCodeRenderer, doc Document) (htmlPage, error) {
+	source := []byte(doc.Content)
+	ctx := parser.NewContext(parser.WithIDs(htmlHeadingIDs{anchors: headingAnchors{}}))
+	root := md.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+
+	page := htmlPage{title: doc.Title, url: htmlPagePath(doc.Path)}
+	rewriteHTMLLinks(root, doc.Path, page.url)
+
+	highlight.mermaid = false
+	var body bytes.Buffer
+	if err := md.Renderer().Render(&body, source, root); err != nil {
+		return htmlPage{}, fmt.Errorf("rendering %s: %w", doc.Path, err)
+	}
+	page.body = body.String()
+	page.text = plainText(root, source)
+	page.mermaid = highlight.mermaid
+	if page.title == "" {
+		page.title = strings.TrimSuffix(path.Base(page.url), ".html")
+	}
+	return page, nil
+}
+
+// htmlPagePath maps a document path to its page: "a/b.md" becomes
+// "a/b.html", and a directory's _index.md or index.md its index.html.
+func htmlPagePath(docPath string) string {
+	p := strings.TrimSuffix(path.Clean(filepath.ToSlash(docPath)), ".md")
+	switch path.Base(p) {
+	case "_index", "index":
+		return path.Join(path.Dir(p), "index.html")
+	}
+	return p + ".html"
+}
+
+// rewriteHTMLLinks points relative links to Markdown documents at the pages
+// they are rendered to.
+func rewriteHTMLLinks(root ast.Node, docPath, pageURL string) {
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		link, ok := n.(*ast.Link)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		dest := string(link.Destination)
+		target, fragment, _ := strings.Cut(dest, "#")
+		if !strings.HasSuffix(target, ".md") || strings.Contains(target, ":") || strings.HasPrefix(target, "/") {
+			return ast.WalkContinue, nil
+		}
+		page := htmlPagePath(path.Join(path.Dir(filepath.ToSlash(docPath)), target))
+		rel := relativeURL(pageURL, page)
+		if fragment != "" {
+			rel += "#" + fragment
+		}
+		link.Destination = []byte(rel)
+		return ast.WalkContinue, nil
+	})
+}
+
+// relativeURL returns the URL of the site page to, relative to the page from.
+func relativeURL(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// plainText collects the text of a parsed page for search, leaving out code
+// blocks.
+func plainText(root ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if n.Type() == ast.TypeBlock && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			b.Write(n.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	s := strings.Join(strings.Fields(b.String()), " ")
+	if len(s) > htmlSearchTextLimit {
+		s = s[:htmlSearchTextLimit]
+		for !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+	}
+	return s
+}
+
+// htmlHeadingIDs gives headings the anchors headingAnchors derives, so links
+// built by the assembler resolve on the rendered pages.
+type htmlHeadingIDs struct {
+	anchors headingAnchors
+}
+
+func (h htmlHeadingIDs) Generate(value []byte, _ ast.NodeKind) []byte {
+	return []byte(h.anchors.next(string(value)))
+}
+
+func (h htmlHeadingIDs) Put(value []byte) {
+	h.anchors[string(value)] = true
+}
+
+// htmlCodeRenderer renders fenced code blocks: Mermaid diagrams as elements
+// the Mermaid script draws, and code highlighted with chroma.
+type htmlCodeRenderer struct {
+	formatter *chromahtml.Formatter
+	style     *chroma.Style
+	mermaid   bool // a Mermaid diagram was rendered on the current page
+}
+
+func (r *htmlCodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+func (r *htmlCodeRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+	var code bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		code.Write(seg.Value(source))
+	}
+	lang := strings.ToLower(string(n.Language(source)))
+
+	if lang == "mermaid" {
+		r.mermaid = true
+		_, _ = w.WriteString(`<pre class="mermaid">`)
+		template.HTMLEscape(w, code.Bytes())
+		_, _ = w.WriteString("</pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+
+	if lexer := lexers.Get(lang); lang != "" && lexer != nil {
+		iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code.String())
+		if err == nil {
+			if err := r.formatter.Format(w, r.style, iterator); err != nil {
+				return ast.WalkStop, fmt.Errorf("highlighting %s code: %w", lang, err)
+			}
+			return ast.WalkSkipChildren, nil
+		}
+	}
+	_, _ = w.WriteString("<pre><code>")
+	template.HTMLEscape(w, code.Bytes())
+	_, _ = w.WriteString("</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// htmlNavSection is a group of pages in the sidebar, one per directory.
+type htmlNavSection struct {
+	dir   string
+	title string
+	pages []htmlPage
+}
+
+// buildHTMLNav groups pages by directory in document order, each
+// directory's index page first.
+func buildHTMLNav(pages []htmlPage) []htmlNavSection {
+	var sections []htmlNavSection
+	byDir := make(map[string]int)
+	for _, page := range pages {
+		dir := path.Dir(page.url)
+		i, ok := byDir[dir]
+		if !ok {
+			i = len(sections)
+			byDir[dir] = i
+			sections = append(sections, htmlNavSection{dir: dir, title: navTitle(dir)})
+		}
+		s := &sections[i]
+		if path.Base(page.url) == "index.html" {
+			if dir != "." {
+				s.title = page.title
+			}
+			s.pages = append([]htmlPage{page}, s.pages...)
+		} else {
+			s.pages = append(s.pages, page)
+		}
+	}
+	return sections
+}
+
+// navTitle names a directory without an index page: "skill-contributed"
+// becomes "Skill contributed".
+func navTitle(dir string) string {
+	if dir == "." {
+		return ""
+	}
+	name := strings.ReplaceAll(dir, "-", " ")
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+type htmlNavLink struct {
+	Title   string
+	Href    string
+	Current bool
+}
+
+type htmlNavGroup struct {
+	Title string
+	Links []htmlNavLink
+}
+
+type htmlLayoutData struct {
+	SiteTitle  string
+	Title      string
+	Root       string
+	Nav        []htmlNavGroup
+	Body       template.HTML
+	Mermaid    bool
+	MermaidURL string
+}
+
+// renderHTMLLayout wraps a page's body in the site layout.
+func renderHTMLLayout(page htmlPage, nav []htmlNavSection, mermaidURL string) (string, error) {
+	root := strings.Repeat("../", strings.Count(page.url, "/"))
+	data := htmlLayoutData{
+		SiteTitle: htmlSiteTitle,
+		Title:     page.title,
+		Root:      root,
+		// The body is goldmark output, which escapes text and drops raw HTML.
+		Body:       template.HTML(page.body),
+		Mermaid:    page.mermaid && mermaidURL != "",
+		MermaidURL: mermaidURL,
+	}
+	if !strings.Contains(mermaidURL, "://") && !strings.HasPrefix(mermaidURL, "/") {
+		data.MermaidURL = root + mermaidURL
+	}
+	for _, s := range nav {
+		group := htmlNavGroup{Title: s.title}
+		for _, p := range s.pages {
+			group.Links = append(group.Links, htmlNavLink{
+				Title:   p.title,
+				Href:    relativeURL(page.url, p.url),
+				Current: p.url == page.url,
+			})
+		}
+		data.Nav = append(data.Nav, group)
+	}
+
+	var b strings.Builder
+	if err := htmlLayout.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering page %s: %w", page.url, err)
+	}
+	return b.String(), nil
+}
+
+// htmlSearchIndex builds the search index script. The index is a script
+// rather than JSON so that search also works when pages are opened from disk,
+// where fetch is not available.
+func htmlSearchIndex(pages []htmlPage) (string, error) {
+	type entry struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		Text  string `json:"text"`
+	}
+	entries := make([]entry, 0, len(pages))
+	for _, p := range pages {
+		entries = append(entries, entry{Title: p.title, URL: p.url, Text: p.text})
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return "", fmt.Errorf("encoding search index: %w", err)
+	}
+	return "window.WIKI_SEARCH_INDEX = " + string(data) + ";\n", nil
+}
+
+var htmlLayout = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · {{.SiteTitle}}</title>
+<link rel="stylesheet" href="{{.Root}}assets/style.css">
+</head>
+<body data-root="{{.Root}}">
+<nav class="sidebar">
+<a class="site-title" href="{{.Root}}index.html">{{.SiteTitle}}</a>
+<input id="wiki-search" type="search" placeholder="Search" aria-label="Search the wiki" autocomplete="off">
+<ul id="wiki-search-results" class="search-results"></ul>
+{{range .Nav}}<div class="nav-section">
+{{if .Title}}<p class="nav-title">{{.Title}}</p>
+{{end}}<ul>
+{{range .Links}}<li><a href="{{.Href}}"{{if .Current}} class="current" aria-current="page"{{end}}>{{.Title}}</a></li>
+{{end}}</ul>
+</div>
+{{end}}</nav>
+<main class="content">
+{{.Body}}
+</main>
+<script src="{{.Root}}assets/search-index.js"></script>
+<script src="{{.Root}}assets/search.js"></script>
+{{if .Mermaid}}<script src="{{.MermaidURL}}"></script>
+<script>if (window.mermaid) { mermaid.initialize({startOnLoad: true}); }</script>
+{{end}}</body>
+</html>
+`))
+
+const htmlSiteCSS = `*, *::before, *::after { box-sizing: border-box; }
+body { margin: 0; display: flex; font: 16px/1.6 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; }
+a { color: #0969da; text-decoration: none; }
+a:hover { text-decoration: underline; }
+.sidebar { width: 280px; flex-shrink: 0; height: 100vh; position: sticky; top: 0; overflow-y: auto; padding: 1rem; border-right: 1px solid #d0d7de; background: #f6f8fa; font-size: 14px; }
+.site-title { display: block; font-weight: 600; font-size: 18px; color: #1f2328; margin-bottom: .75rem; }
+#wiki-search { width: 100%; padding: .4rem .5rem; border: 1px solid #d0d7de; border-radius: 6px; font: inherit; }
+.search-results { list-style: none; padding: 0; margin: .5rem 0; }
+.search-results li { padding: .3rem 0; border-bottom: 1px solid #d0d7de; }
+.search-results span { display: block; color: #59636e; font-size: 12px; }
+.nav-section ul { list-style: none; padding: 0; margin: 0 0 1rem; }
+.nav-section li a { display: block; padding: .15rem .5rem; border-radius: 4px; color: #1f2328; overflow-wrap: anywhere; }
+.nav-section li a.current { background: #ddf4ff; font-weight: 600; }
+.nav-title { margin: 0 0 .25rem; font-weight: 600; text-transform: uppercase; font-size: 12px; color: #59636e; }
+.content { flex: 1; min-width: 0; max-width: 960px; padding: 2rem 3rem; }
+.content h1, .content h2 { border-bottom: 1px solid #d0d7de; padding-bottom: .3rem; }
+.content table { border-collapse: collapse; }
+.content th, .content td { border: 1px solid #d0d7de; padding: .3rem .75rem; }
+.content code { font: 85% ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; background: #eff1f3; padding: .1em .3em; border-radius: 4px; }
+.content pre { padding: 1rem; overflow-x: auto; background: #f6f8fa; border-radius: 6px; }
+.content pre code { background: none; padding: 0; }
+pre.mermaid { background: none; text-align: center; }
+@media (max-width: 800px) {
+  body { display: block; }
+  .sidebar { width: auto; height: auto; position: static; border-right: none; border-bottom: 1px solid #d0d7de; }
+  .content { padding: 1rem; }
+}
+`
+
+const htmlSearchJS = `(function () {
+  var input = document.getElementById("wiki-search");
+  var results = document.getElementById("wiki-search-results");
+  if (!input || !results) { return; }
+  var root = document.body.getAttribute("data-root") || "";
+  var index = window.WIKI_SEARCH_INDEX || [];
+
+  function snippet(text, term) {
+    var at = text.toLowerCase().indexOf(term);
+    if (at < 0) { return text.slice(0, 120); }
+    var start = Math.max(0, at - 50);
+    return (start > 0 ? "…" : "") + text.slice(start, at + term.length + 70) + "…";
+  }
+
+  function search(query) {
+    results.textContent = "";
+    var terms = query.toLowerCase().split(/\s+/).filter(Boolean);
+    if (!terms.length) { return; }
+    var hits = [];
+    index.forEach(function (page) {
+      var title = page.title.toLowerCase();
+      var text = page.text.toLowerCase();
+      var score = 0;
+      for (var i = 0; i < terms.length; i++) {
+        var inTitle = title.indexOf(terms[i]) >= 0;
+        if (!inTitle && text.indexOf(terms[i]) < 0) { return; }
+        score += inTitle ? 10 : 1;
+      }
+      hits.push({ page: page, score: score });
+    });
+    hits.sort(function (a, b) { return b.score - a.score; });
+    if (!hits.length) {
+      var none = document.createElement("li");
+      none.textContent = "No results";
+      results.appendChild(none);
+      return;
+    }
+    hits.slice(0, 20).forEach(function (hit) {
+      var li = document.createElement("li");
+      var a = document.createElement("a");
+      a.href = root + hit.page.url;
+      a.textContent = hit.page.title;
+      li.appendChild(a);
+      var span = document.createElement("span");
+      span.textContent = snippet(hit.page.text, terms[0]);
+      li.appendChild(span);
+      results.appendChild(li);
+    });
+  }
+
+  input.addEventListener("input", function () { search(input.value); });
+  input.addEventListener("keydown", function (e) {
+    if (e.key === "Escape") { input.value = ""; search(""); }
+  });
+})();
+`
@@ -1,9 +1,14 @@
 package wiki
 
 import (
+	"encoding/json"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -120,6 +125,136 @@ func TestRenderDocusaurus(t *testing.T) {
 	assertFileContains(t, configPath, "mermaid: true")
 }
 
+func TestRenderHTML(t *testing.T) {
+	stubMermaidAsset(t, fstest.MapFS{
+		"assets/mermaid.min.js":  {Data: []byte("vendored mermaid")},
+		"assets/mermaid.LICENSE": {Data: []byte("MIT")},
+	})
+	outDir := t.TempDir()
+
+	docs := []Document{
+		{Path: "_index.md", Title: "Project Overview", Content: "# Project Overview\n\nSee [the store](modules/store.md#summary) and [security](security/overview.md).\n"},
+		{Path: "modules/_index.md", Title: "Modules", Content: "# Modules\n\n- [store](store.md)\n"},
+		{Path: "modules/store.md", Title: "store", Content: "# store\n\n## Summary\n\nKeeps <b>data</b>.\n\n```go\nfunc Open() error { return nil }\n```\n"},
+		{Path: "architecture/overview.md", Title: "Architecture", Content: "# Architecture\n\n```mermaid\ngraph TD\n  A-->B\n```\n"},
+		{Path: "security/overview.md", Title: "Security", Content: "# Security\n\n## Findings\n\n### Weak hash\n\n### Weak hash\n"},
+	}
+
+	err := Render(docs, RendererConfig{Format: "html", OutputDir: outDir})
+	require.NoError(t, err)
+
+	index := filepath.Join(outDir, "index.html")
+	assertFileContains(t, index, `<a href="modules/store.html#summary">the store</a>`)
+	assertFileContains(t, index, `<a href="security/overview.html">security</a>`)
+	assertFileContains(t, index, `href="assets/style.css"`)
+
+	store := filepath.Join(outDir, "modules", "store.html")
+	assertFileContains(t, store, `href="../assets/style.css"`)
+	assertFileContains(t, store, `<h2 id="summary">Summary</h2>`)
+	assertFileContains(t, store, `<pre class="chroma">`)
+	assertFileContains(t, store, `<span class="kd">func</span>`)
+	assertFileContains(t, store, `<a href="store.html" class="current" aria-current="page">store</a>`)
+	assertFileContains(t, store, `<a href="index.html">Modules</a>`)
+	assertFileContains(t, store, `<a href="../architecture/overview.html">Architecture</a>`)
+	data, err := os.ReadFile(store)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "<b>data</b>", "raw HTML in documents is not passed through")
+	assert.NotContains(t, string(data), "mermaid.min.js", "pages without diagrams do not load Mermaid")
+
+	modules := filepath.Join(outDir, "modules", "index.html")
+	assertFileContains(t, modules, `<a href="store.html">store</a>`)
+
+	arch := filepath.Join(outDir, "architecture", "overview.html")
+	assertFileContains(t, arch, "<pre class=\"mermaid\">graph TD\n  A--&gt;B\n</pre>")
+	assertFileContains(t, arch, `<script src="../assets/mermaid.min.js"></script>`)
+	assertFileContains(t, filepath.Join(outDir, "assets", "mermaid.min.js"), "vendored mermaid")
+	assertFileContains(t, filepath.Join(outDir, "assets", "mermaid.LICENSE"), "MIT")
+
+	sec := filepath.Join(outDir, "security", "overview.html")
+	assertFileContains(t, sec, `<h3 id="weak-hash">`)
+	assertFileContains(t, sec, `<h3 id="weak-hash-1">`)
+
+	assertFileContains(t, filepath.Join(outDir, "assets", "style.css"), ".chroma")
+	assertFileExists(t, filepath.Join(outDir, "assets", "search.js"))
+
+	indexJS, err := os.ReadFile(filepath.Join(outDir, "assets", "search-index.js"))
+	require.NoError(t, err)
+	jsonPart := strings.TrimSuffix(strings.TrimPrefix(string(indexJS), "window.WIKI_SEARCH_INDEX = "), ";\n")
+	var entries []struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		Text  string `json:"text"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(jsonPart), &entries))
+	require.Len(t, entries, len(docs))
+	assert.Equal(t, "store", entries[2].Title)
+	assert.Equal(t, "modules/store.html", entries[2].URL)
+	assert.Equal(t, "store Summary Keeps data.", entries[2].Text, "code blocks and markup are left out of the index")
+}
+
+func TestRenderHTMLMermaidURLOverride(t *testing.T) {
+	stubMermaidAsset(t, fstest.MapFS{
+		"assets/mermaid.min.js":  {Data: []byte("vendored mermaid")},
+		"assets/mermaid.LICENSE": {Data: []byte("MIT")},
+	})
+	outDir := t.TempDir()
+
+	docs := []Document{
+		{Path: "architecture/overview.md", Title: "Architecture", Content: "```mermaid\ngraph TD\n```\n"},
+	}
+	err := Render(docs, RendererConfig{Format: "html", OutputDir: outDir, MermaidURL: "https://cdn.example.com/mermaid.js"})
+	require.NoError(t, err)
+
+	assertFileContains(t, filepath.Join(outDir, "architecture", "overview.html"), `<script src="https://cdn.example.com/mermaid.js"></script>`)
+	assert.NoFileExists(t, filepath.Join(outDir, "assets", "mermaid.min.js"), "an override is not copied")
+}
+
+func TestRenderHTMLWithoutVendoredMermaid(t *testing.T) {
+	stubMermaidAsset(t, fstest.MapFS{})
+	outDir := t.TempDir()
+
+	docs := []Document{
+		{Path: "architecture/overview.md", Title: "Architecture", Content: "```mermaid\ngraph TD\n```\n"},
+	}
+	require.NoError(t, Render(docs, RendererConfig{Format: "html", OutputDir: outDir}))
+
+	data, err := os.ReadFile(filepath.Join(outDir, "architecture", "overview.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<pre class="mermaid">`)
+	assert.NotContains(t, string(data), "<script src=\"../assets/mermaid", "no script to load")
+}
+
+// TestMermaidAssetIsVendored checks the embedded assets directory. The
+// license always ships; the script is optional, since without it diagrams
+// show as source, but when present it must be the Mermaid build.
+func TestMermaidAssetIsVendored(t *testing.T) {
+	license, err := fs.ReadFile(embeddedHTMLAssets, "assets/"+mermaidLicense)
+	require.NoError(t, err)
+	assert.Contains(t, string(license), "MIT License")
+
+	script, err := fs.ReadFile(embeddedHTMLAssets, "assets/"+mermaidAsset)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skipf("assets/%s is not vendored; run go generate ./internal/wiki", mermaidAsset)
+	}
+	require.NoError(t, err)
+	assert.Contains(t, string(script), "mermaid")
+}
+
+// stubMermaidAsset replaces the vendored scripts for the duration of a test.
+func stubMermaidAsset(t *testing.T, fsys fs.FS) {
+	t.Helper()
+	orig := htmlAssets
+	htmlAssets = fsys
+	t.Cleanup(func() { htmlAssets = orig })
+}
+
+func TestHTMLPagePath(t *testing.T) {
+	assert.Equal(t, "index.html", htmlPagePath("_index.md"))
+	assert.Equal(t, "index.html", htmlPagePath("index.md"))
+	assert.Equal(t, "api/index.html", htmlPagePath("api/_index.md"))
+	assert.Equal(t, "security/threat-model.html", htmlPagePath("security/threat-model.md"))
+}
+
 func TestRenderUnsupportedFormat(t *testing.T) {
 	outDir := t.TempDir()
 
@@ -39,6 +39,7 @@ var skipDirs = map[string]bool{
 	"build":        true,
 	"dist":         true,
 	"__pycache__":  true,
+	".rubichan":    true,
 }
 
 // Scan discovers source files in dir and extracts function definitions and
@@ -99,10 +100,12 @@ func listFiles(ctx context.Context, dir string) ([]string, error) {
 	return walkFiles(dir)
 }
 
-// gitLsFiles runs "git ls-files" in dir and returns the output lines.
-// Returns an error if dir is not inside a git repository.
+// gitLsFiles runs "git ls-files" in dir and returns the output lines:
+// tracked files plus untracked ones that are not ignored, so files not yet
+// committed are documented too. Returns an error if dir is not inside a git
+// repository.
 func gitLsFiles(ctx context.Context, dir string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "ls-files")
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "--cached", "--others", "--exclude-standard")
 	cmd.Dir = dir
 	out, err := cmd.Output()
 	if err != nil {
@@ -0,0 +1,21 @@
+package wiki
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// PreviewHandler serves the HTML site rendered under dir for local preview.
+// Responses are not cached, so a regenerated wiki shows up on reload.
+func PreviewHandler(dir string) (http.Handler, error) {
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); err != nil {
+		return nil, fmt.Errorf("no HTML wiki in %s (generate one with the html format): %w", dir, err)
+	}
+	files := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		files.ServeHTTP(w, r)
+	}), nil
+}
@@ -0,0 +1,41 @@
+package wiki
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewHandlerServesSite(t *testing.T) {
+	dir := t.TempDir()
+	docs := []Document{
+		{Path: "_index.md", Title: "Home", Content: "# Home\n"},
+		{Path: "modules/store.md", Title: "store", Content: "# store\n"},
+	}
+	require.NoError(t, Render(docs, RendererConfig{Format: "html", OutputDir: dir}))
+
+	h, err := PreviewHandler(dir)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<h1 id=\"home\">Home</h1>")
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/modules/store.html", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<h1 id=\"store\">store</h1>")
+}
+
+func TestPreviewHandlerRequiresHTMLSite(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Render([]Document{{Path: "_index.md", Content: "# Home\n"}}, RendererConfig{Format: "raw-md", OutputDir: dir}))
+
+	_, err := PreviewHandler(dir)
+	assert.ErrorContains(t, err, "no HTML wiki")
+}
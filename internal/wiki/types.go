@@ -33,11 +33,11 @@ type AnalysisResult struct {
 
 // ModuleAnalysis summarizes a single module from the LLM.
 type ModuleAnalysis struct {
-	Module   string
-	Summary  string
-	KeyTypes string
-	Patterns string
-	Concerns string
+	Module   string `json:"module"`
+	Summary  string `json:"summary"`
+	KeyTypes string `json:"key_types"`
+	Patterns string `json:"patterns"`
+	Concerns string `json:"concerns"`
 }
 
 // Diagram holds a generated Mermaid diagram.
@@ -52,6 +52,9 @@ type Document struct {
 	Path    string
 	Title   string
 	Content string
+	// ChangeSummary, when set, is recorded in the change history if the
+	// document changed, instead of a summary written by the LLM.
+	ChangeSummary string
 }
 
 // WikiResult summarises the outcome of a successful wiki generation run.
@@ -66,6 +69,12 @@ type WikiResult struct {
 	DurationMs         int64    `json:"duration_ms"`
 	APISurfaces        []string `json:"api_surfaces,omitempty"`
 	SecurityDepth      []string `json:"security_depth,omitempty"`
+	Since              string   `json:"since,omitempty"`
+	ChangedFiles       int      `json:"changed_files,omitempty"`
+	AnalyzedChunks     int      `json:"analyzed_chunks"`
+	CachedChunks       int      `json:"cached_chunks"`
+	Artifacts          []string `json:"artifacts,omitempty"`
+	BreakingAPIChanges []string `json:"breaking_api_changes,omitempty"`
 }
 
 // SkillWikiSection holds a wiki contribution from a skill.
@@ -100,6 +109,11 @@ type AnalyzerInput struct {
 	ModuleAnalyses []ModuleAnalysis
 	Architecture   string
 	APIPatterns    []APIPattern
+	// APISpec is the machine-readable API built from the patterns, if any.
+	APISpec *APISpec
+	// PreviousAPI is the API spec the last run wrote, if any, so API
+	// changes since then can be reported.
+	PreviousAPI *APISpec
 }
 
 // AnalyzerOutput holds documents and diagrams from a specialized analyzer.
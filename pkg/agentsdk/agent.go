@@ -66,6 +66,7 @@ type Agent struct {
 	contextStrategies []ContextStrategy
 	backgroundTasks   []BackgroundTask
 	toolMiddlewares   []Middleware
+	todos             *TodoList
 	turnMu            sync.Mutex
 }
 
@@ -314,8 +315,9 @@ func (a *Agent) executeTools(ctx context.Context, ch chan<- TurnEvent, pendingTo
 		ch <- MakeToolCallEvent(tc)
 
 		result := a.executeSingleTool(ctx, ch, tc)
-		a.conversation.AddToolResult(tc.ID, result.content, result.isError)
+		a.conversation.AddToolResultMedia(tc.ID, result.content, result.isError, result.media)
 		ch <- result.event
+		a.publishTodos(ctx, ch)
 	}
 	// The loop tests ctx.Err() before each call and never after the last one,
 	// so a cancellation landing during the final tool would otherwise be
@@ -327,6 +329,7 @@ func (a *Agent) executeTools(ctx context.Context, ch chan<- TurnEvent, pendingTo
 type toolResult struct {
 	content string
 	isError bool
+	media   []ContentBlock
 	event   TurnEvent
 }
 
@@ -334,6 +337,7 @@ func (a *Agent) executeSingleTool(ctx context.Context, ch chan<- TurnEvent, tc T
 	// Run the shared approval flow when any approval mechanism is
 	// configured. With nothing configured the SDK executes directly —
 	// approval is opt-in for embedders.
+	var decision ApprovalOutcome
 	if a.approvalChecker != nil || a.approve != nil || a.uiRequestHandler != nil {
 		flow := &ApprovalFlow{
 			Checker:   a.approvalChecker,
@@ -341,11 +345,15 @@ func (a *Agent) executeSingleTool(ctx context.Context, ch chan<- TurnEvent, tc T
 			UIHandler: a.uiRequestHandler,
 			Emit:      func(ev TurnEvent) { sendEvent(ctx, ch, ev) },
 		}
-		if out := flow.Decide(ctx, tc); !out.Approved {
-			if out.Err != nil {
-				a.logger.Error("approval failure for tool %s: %v", tc.Name, out.Err)
+		decision = flow.Decide(ctx, tc)
+		if !decision.Approved {
+			if decision.Err != nil {
+				a.logger.Error("approval failure for tool %s: %v", tc.Name, decision.Err)
 			}
-			return a.toolError(tc, out.Message)
+			return a.toolError(tc, decision.Message)
+		}
+		if decision.Input != nil {
+			tc.Input = decision.Input
 		}
 	}
 
@@ -354,10 +362,12 @@ func (a *Agent) executeSingleTool(ctx context.Context, ch chan<- TurnEvent, tc T
 	// streaming-aware execution, error wrapping. Report the name that
 	// executed, which a middleware may have rewritten.
 	out, executedName := a.dispatchTool(ctx, tc, func(ev TurnEvent) { sendEvent(ctx, ch, ev) })
+	content := decision.Annotate(out.Content)
 	return toolResult{
-		content: out.Content,
+		content: content,
 		isError: out.IsError,
-		event:   MakeToolResultEvent(tc.ID, executedName, out.Content, out.DisplayContent, out.IsError),
+		media:   out.Media,
+		event:   MakeToolResultEvent(tc.ID, executedName, content, out.DisplayContent, out.IsError),
 	}
 }
 
@@ -75,6 +75,7 @@ func (a *Agent) dispatchTool(ctx context.Context, tc ToolUseBlock, sink func(Tur
 			Content:        out.Content,
 			DisplayContent: out.DisplayContent,
 			IsError:        out.IsError,
+			Media:          out.Media,
 		}
 	}
 
@@ -88,5 +89,6 @@ func (a *Agent) dispatchTool(ctx context.Context, tc ToolUseBlock, sink func(Tur
 		Content:        res.Content,
 		DisplayContent: res.DisplayContent,
 		IsError:        res.IsError,
+		Media:          res.Media,
 	}, executedName
 }
@@ -25,6 +25,33 @@ type ApprovalOutcome struct {
 	DenyAlways bool
 	Message    string
 	Err        error
+
+	// Input, when set, replaces the tool input the model sent: the user
+	// changed the call before allowing it. Note tells the model what was
+	// changed and belongs at the end of the tool result (see Annotate).
+	Input json.RawMessage
+	Note  string
+}
+
+// Annotate appends the outcome's note, if any, to a tool result.
+func (o ApprovalOutcome) Annotate(content string) string {
+	if o.Note == "" {
+		return content
+	}
+	if content == "" {
+		return o.Note
+	}
+	return content + "\n\n" + o.Note
+}
+
+// ApprovalEdit is the optional Values payload of an approval UIResponse,
+// for adapters that let the user change a tool call rather than only
+// allow or deny it — for example by rejecting some hunks of a file edit.
+// With "allow", Input replaces the tool input and Note is appended to the
+// tool result; with "deny", Note explains the denial to the model.
+type ApprovalEdit struct {
+	Input json.RawMessage `json:"input,omitempty"`
+	Note  string          `json:"note,omitempty"`
 }
 
 // ApprovalFlow is the tool-approval decision engine shared by the SDK and
@@ -59,14 +86,16 @@ func (f *ApprovalFlow) Decide(ctx context.Context, tc ToolUseBlock) ApprovalOutc
 		return ApprovalOutcome{Message: approvalMsgUnconfigured}
 	}
 
-	approved, denyAlways, err := f.requestApproval(ctx, tc)
+	approved, denyAlways, edit, err := f.requestApproval(ctx, tc)
 	switch {
 	case err != nil:
 		return ApprovalOutcome{Message: approvalMsgError, Err: err}
 	case approved:
-		return ApprovalOutcome{Approved: true}
+		return ApprovalOutcome{Approved: true, Input: edit.Input, Note: edit.Note}
 	case denyAlways:
 		return ApprovalOutcome{DenyAlways: true, Message: approvalMsgDenyAlways}
+	case edit.Note != "":
+		return ApprovalOutcome{Message: approvalMsgDenied + ": " + edit.Note}
 	default:
 		return ApprovalOutcome{Message: approvalMsgDenied}
 	}
@@ -74,10 +103,10 @@ func (f *ApprovalFlow) Decide(ctx context.Context, tc ToolUseBlock) ApprovalOutc
 
 // requestApproval asks the user through the UI handler when available,
 // otherwise through the plain approval function.
-func (f *ApprovalFlow) requestApproval(ctx context.Context, tc ToolUseBlock) (approved, denyAlways bool, err error) {
+func (f *ApprovalFlow) requestApproval(ctx context.Context, tc ToolUseBlock) (approved, denyAlways bool, edit ApprovalEdit, err error) {
 	if f.UIHandler == nil {
 		approved, err = f.Approve(ctx, tc.Name, tc.Input)
-		return approved, false, err
+		return approved, false, edit, err
 	}
 
 	req := UIRequest{
@@ -95,27 +124,37 @@ func (f *ApprovalFlow) requestApproval(ctx context.Context, tc ToolUseBlock) (ap
 			"tool":  tc.Name,
 			"input": truncateUIInput(tc.Input),
 		},
+		Input: tc.Input,
 	}
 	f.emit(TurnEvent{Type: "ui_request", UIRequest: &req})
 	resp, err := f.UIHandler.Request(ctx, req)
 	if err != nil {
-		return false, false, err
+		return false, false, edit, err
 	}
 	if resp.RequestID != req.ID {
-		return false, false, fmt.Errorf("unexpected UI response id %q for request %q", resp.RequestID, req.ID)
+		return false, false, edit, fmt.Errorf("unexpected UI response id %q for request %q", resp.RequestID, req.ID)
 	}
 	f.emit(TurnEvent{Type: "ui_response", UIResponse: &resp})
 
+	if len(resp.Values) > 0 {
+		if err := json.Unmarshal(resp.Values, &edit); err != nil {
+			return false, false, edit, fmt.Errorf("invalid UI approval values: %w", err)
+		}
+		if len(edit.Input) > 0 && !json.Valid(edit.Input) {
+			return false, false, edit, fmt.Errorf("invalid UI approval values: edited input is not valid JSON")
+		}
+	}
+
 	switch strings.ToLower(resp.ActionID) {
 	case "allow", "allow_always", "yes":
 		// "allow_always" cache persistence is handled by the UI adapter.
-		return true, false, nil
+		return true, false, edit, nil
 	case "deny_always":
-		return false, true, nil
+		return false, true, edit, nil
 	case "deny", "no":
-		return false, false, nil
+		return false, false, edit, nil
 	default:
-		return false, false, fmt.Errorf("unsupported UI approval action %q", resp.ActionID)
+		return false, false, edit, fmt.Errorf("unsupported UI approval action %q", resp.ActionID)
 	}
 }
 
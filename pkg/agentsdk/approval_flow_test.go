@@ -238,3 +238,50 @@ func TestTruncateUIInputNeverSplitsRunes(t *testing.T) {
 	assert.True(t, strings.HasSuffix(result, "...(truncated)"))
 	assert.Equal(t, prefix+"...(truncated)", result, "partial rune must be dropped entirely")
 }
+
+func TestApprovalFlowUIEditReplacesInput(t *testing.T) {
+	var captured UIRequest
+	handler := UIRequestFunc(func(_ context.Context, req UIRequest) (UIResponse, error) {
+		captured = req
+		return UIResponse{
+			RequestID: req.ID,
+			ActionID:  "allow",
+			Values:    json.RawMessage(`{"input":{"command":"ls -a"},"note":"The user changed the command."}`),
+		}, nil
+	})
+	out := (&ApprovalFlow{UIHandler: handler}).Decide(context.Background(), approvalTC())
+	require.True(t, out.Approved)
+	assert.JSONEq(t, `{"command":"ls -a"}`, string(out.Input))
+	assert.Equal(t, "listing\n\nThe user changed the command.", out.Annotate("listing"))
+	assert.JSONEq(t, `{"command":"ls"}`, string(captured.Input), "in-process adapters get the full input")
+}
+
+func TestApprovalFlowUIDenyWithNote(t *testing.T) {
+	handler := UIRequestFunc(func(_ context.Context, req UIRequest) (UIResponse, error) {
+		return UIResponse{RequestID: req.ID, ActionID: "deny", Values: json.RawMessage(`{"note":"all hunks rejected"}`)}, nil
+	})
+	out := (&ApprovalFlow{UIHandler: handler}).Decide(context.Background(), approvalTC())
+	assert.False(t, out.Approved)
+	assert.Equal(t, "tool call denied by user: all hunks rejected", out.Message)
+}
+
+func TestApprovalFlowUIInvalidValues(t *testing.T) {
+	handler := UIRequestFunc(func(_ context.Context, req UIRequest) (UIResponse, error) {
+		return UIResponse{RequestID: req.ID, ActionID: "allow", Values: json.RawMessage(`"nope"`)}, nil
+	})
+	out := (&ApprovalFlow{UIHandler: handler}).Decide(context.Background(), approvalTC())
+	assert.False(t, out.Approved)
+	assert.Equal(t, "approval error", out.Message)
+	assert.ErrorContains(t, out.Err, "invalid UI approval values")
+}
+
+func TestUIRequestInputIsNotSerialized(t *testing.T) {
+	data, err := json.Marshal(UIRequest{ID: "r", Input: json.RawMessage(`{"secret":1}`)})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+}
+
+func TestApprovalOutcomeAnnotate(t *testing.T) {
+	assert.Equal(t, "done", ApprovalOutcome{}.Annotate("done"))
+	assert.Equal(t, "note", ApprovalOutcome{Note: "note"}.Annotate(""))
+}
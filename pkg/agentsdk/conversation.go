@@ -36,7 +36,13 @@ func (c *Conversation) AddAssistant(blocks []ContentBlock) {
 
 // AddToolResult appends a tool result message.
 func (c *Conversation) AddToolResult(toolUseID, content string, isError bool) {
-	c.messages = append(c.messages, NewToolResultMessage(toolUseID, content, isError))
+	c.AddToolResultMedia(toolUseID, content, isError, nil)
+}
+
+// AddToolResultMedia appends a tool result message with image or document
+// blocks attached.
+func (c *Conversation) AddToolResultMedia(toolUseID, content string, isError bool, media []ContentBlock) {
+	c.messages = append(c.messages, NewToolResultMessage(toolUseID, content, isError, media...))
 }
 
 // Clear removes all messages, preserving the system prompt.
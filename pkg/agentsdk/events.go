@@ -4,23 +4,48 @@ import "encoding/json"
 
 // TurnEvent represents a streaming event emitted during an agent turn.
 type TurnEvent struct {
-	Type           string             // "text_delta", "thinking_delta", "input_json_delta", "tool_call", "tool_result", "tool_progress", "ui_request", "ui_update", "ui_response", "message_start", "context_overflow", "error", "done", "subagent_done"
-	Text           string             // text content for text_delta and input_json_delta events
-	Model          string             // populated for message_start events
-	MessageID      string             // populated for message_start events
-	ToolCall       *ToolCallEvent     // populated for tool_call events
-	ToolResult     *ToolResultEvent   // populated for tool_result events
-	ToolProgress   *ToolProgressEvent // populated for tool_progress events
-	UIRequest      *UIRequest         // populated for ui_request events
-	UIUpdate       *UIUpdate          // populated for ui_update events
-	UIResponse     *UIResponse        // populated for ui_response events
-	Error          error              // populated for error events
-	InputTokens    int                // populated for done events: total input tokens used
-	OutputTokens   int                // populated for done events: total output tokens used
-	DiffSummary    string             // populated for done events: markdown-formatted cumulative file change summary
-	SubagentResult *SubagentResult    // populated for subagent_done events
-	ContextBudget  *ContextBudget     // populated for done events: per-component context usage breakdown
-	ExitReason     TurnExitReason     // populated for done events: why the turn stopped
+	Type           string               // "text_delta", "thinking_delta", "input_json_delta", "tool_call", "tool_result", "tool_progress", "ui_request", "ui_update", "ui_response", "message_start", "context_overflow", "provider_switch", "plan_update", "error", "done", "subagent_done"
+	Text           string               // text content for text_delta and input_json_delta events
+	Model          string               // populated for message_start events
+	MessageID      string               // populated for message_start events
+	ToolCall       *ToolCallEvent       // populated for tool_call events
+	ToolResult     *ToolResultEvent     // populated for tool_result events
+	ToolProgress   *ToolProgressEvent   // populated for tool_progress events
+	UIRequest      *UIRequest           // populated for ui_request events
+	UIUpdate       *UIUpdate            // populated for ui_update events
+	UIResponse     *UIResponse          // populated for ui_response events
+	Error          error                // populated for error events
+	InputTokens    int                  // populated for done events: total input tokens used
+	OutputTokens   int                  // populated for done events: total output tokens used
+	CostUSD        float64              // populated for done events: priced cost of this turn's provider calls
+	DiffSummary    string               // populated for done events: markdown-formatted cumulative file change summary
+	SubagentResult *SubagentResult      // populated for subagent_done events
+	ContextBudget  *ContextBudget       // populated for done events: per-component context usage breakdown
+	ExitReason     TurnExitReason       // populated for done events: why the turn stopped
+	ProviderSwitch *ProviderSwitchEvent // populated for provider_switch events
+	Plan           []PlanEntry          // populated for plan_update events: the whole todo list
+}
+
+// Plan entry statuses.
+const (
+	PlanEntryPending    = "pending"
+	PlanEntryInProgress = "in_progress"
+	PlanEntryCompleted  = "completed"
+)
+
+// PlanEntry is one item of the todo list the model keeps with the todo
+// tool.
+type PlanEntry struct {
+	Content string `json:"content"`
+	Status  string `json:"status"` // pending, in_progress or completed
+}
+
+// ProviderSwitchEvent reports that a failover chain routed the call to a
+// different provider than the previous one.
+type ProviderSwitchEvent struct {
+	From   string // "provider/model" that served the previous call
+	To     string // "provider/model" now serving calls
+	Reason string // triggering error, or why the chain moved back
 }
 
 // ToolCallEvent contains details about a tool being called.
@@ -68,6 +68,10 @@ const (
 
 	// ExitBudgetExceeded: token usage exceeded the configured budget threshold.
 	ExitBudgetExceeded
+
+	// ExitCostBudgetExceeded: recorded spend reached a configured dollar cap
+	// (per session or per project), so no further provider calls are made.
+	ExitCostBudgetExceeded
 )
 
 // String returns a stable lowercase identifier usable in logs and tests.
@@ -105,6 +109,8 @@ func (r TurnExitReason) String() string {
 		return "stop_hook_prevented"
 	case ExitBudgetExceeded:
 		return "budget_exceeded"
+	case ExitCostBudgetExceeded:
+		return "cost_budget_exceeded"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,177 @@
+package agentsdk
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Media source type constants for MediaSource.Type.
+const (
+	MediaSourceBase64 = "base64"
+	MediaSourceFile   = "file"
+)
+
+// MaxMediaBytes caps the decoded size of a single image or document block.
+// Provider limits sit around 20–32 MB per request; staying below the lowest
+// keeps one oversized screenshot from failing the whole turn.
+const MaxMediaBytes = 20 << 20
+
+// MediaSource is the payload of an image or document content block. Either
+// Data (base64, no data: prefix) or Path is set depending on Type. File
+// sources are resolved lazily by ResolveMediaSource so conversations can
+// reference a screenshot on disk without holding its bytes in memory.
+type MediaSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	Path      string `json:"path,omitempty"`
+}
+
+// NewImageBlock builds an image content block from raw bytes. An empty
+// mediaType is sniffed from the data.
+func NewImageBlock(mediaType string, data []byte) ContentBlock {
+	return newMediaBlock(BlockTypeImage, mediaType, data)
+}
+
+// NewDocumentBlock builds a document (PDF) content block from raw bytes.
+func NewDocumentBlock(mediaType string, data []byte) ContentBlock {
+	return newMediaBlock(BlockTypeDocument, mediaType, data)
+}
+
+func newMediaBlock(blockType, mediaType string, data []byte) ContentBlock {
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+	}
+	return ContentBlock{
+		Type: blockType,
+		Source: &MediaSource{
+			Type:      MediaSourceBase64,
+			MediaType: mediaType,
+			Data:      base64.StdEncoding.EncodeToString(data),
+		},
+	}
+}
+
+// NewMediaFileBlock builds an image or document block that references a file
+// on disk. The block type is chosen from the file extension; anything that is
+// not a known image or PDF type is rejected so callers fail before the
+// provider does. Name records the file's base name for providers that label
+// attachments.
+func NewMediaFileBlock(path string) (ContentBlock, error) {
+	mediaType := mediaTypeForExt(filepath.Ext(path))
+	blockType := ""
+	switch {
+	case strings.HasPrefix(mediaType, "image/"):
+		blockType = BlockTypeImage
+	case mediaType == "application/pdf":
+		blockType = BlockTypeDocument
+	default:
+		return ContentBlock{}, fmt.Errorf("unsupported media file %q", path)
+	}
+	return ContentBlock{
+		Type: blockType,
+		Name: filepath.Base(path),
+		Source: &MediaSource{
+			Type:      MediaSourceFile,
+			MediaType: mediaType,
+			Path:      path,
+		},
+	}, nil
+}
+
+// IsMediaBlock reports whether b carries image or document bytes.
+func IsMediaBlock(b ContentBlock) bool {
+	return b.Type == BlockTypeImage || b.Type == BlockTypeDocument
+}
+
+// ResolveMediaSource returns a base64 source for src, reading file sources
+// from disk. Base64 sources are returned unchanged. Either kind is rejected
+// when it decodes to more than MaxMediaBytes. The result always has a
+// MediaType so transformers can build data URLs without sniffing again.
+func ResolveMediaSource(src *MediaSource) (*MediaSource, error) {
+	if src == nil {
+		return nil, fmt.Errorf("media block has no source")
+	}
+	switch src.Type {
+	case MediaSourceBase64:
+		// Padding makes DecodedLen overcount by one byte per trailing "=".
+		size := base64.StdEncoding.DecodedLen(len(src.Data)) - (len(src.Data) - len(strings.TrimRight(src.Data, "=")))
+		if size > MaxMediaBytes {
+			return nil, fmt.Errorf("media data is %d bytes, exceeds limit of %d", size, MaxMediaBytes)
+		}
+		if src.MediaType != "" {
+			return src, nil
+		}
+		data, err := base64.StdEncoding.DecodeString(src.Data)
+		if err != nil {
+			return nil, fmt.Errorf("decoding media data: %w", err)
+		}
+		resolved := *src
+		resolved.MediaType = http.DetectContentType(data)
+		return &resolved, nil
+	case MediaSourceFile:
+		info, err := os.Stat(src.Path)
+		if err != nil {
+			return nil, fmt.Errorf("reading media file: %w", err)
+		}
+		if info.Size() > MaxMediaBytes {
+			return nil, fmt.Errorf("media file %s is %d bytes, exceeds limit of %d", src.Path, info.Size(), MaxMediaBytes)
+		}
+		data, err := os.ReadFile(src.Path)
+		if err != nil {
+			return nil, fmt.Errorf("reading media file: %w", err)
+		}
+		mediaType := src.MediaType
+		if mediaType == "" {
+			mediaType = mediaTypeForExt(filepath.Ext(src.Path))
+		}
+		if mediaType == "" {
+			mediaType = http.DetectContentType(data)
+		}
+		return &MediaSource{
+			Type:      MediaSourceBase64,
+			MediaType: mediaType,
+			Data:      base64.StdEncoding.EncodeToString(data),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown media source type %q", src.Type)
+	}
+}
+
+// DataURL renders a base64 source as an RFC 2397 data URL, the form
+// OpenAI-compatible APIs expect for inline images and files.
+func (s MediaSource) DataURL() string {
+	return "data:" + s.MediaType + ";base64," + s.Data
+}
+
+// MediaPlaceholder is the text substituted for a media block when the model
+// cannot accept it, so the conversation still records that something was
+// attached.
+func MediaPlaceholder(b ContentBlock) string {
+	name := ""
+	if b.Name != "" {
+		name = " " + b.Name
+	}
+	return fmt.Sprintf("[%s%s omitted: model does not accept %s input]", b.Type, name, b.Type)
+}
+
+func mediaTypeForExt(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".pdf":
+		return "application/pdf"
+	default:
+		return ""
+	}
+}
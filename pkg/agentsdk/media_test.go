@@ -0,0 +1,90 @@
+package agentsdk
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentBlockImageRoundTrip(t *testing.T) {
+	cb := NewImageBlock("image/png", []byte("png-bytes"))
+
+	data, err := json.Marshal(cb)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"image","source":{"type":"base64","media_type":"image/png","data":"cG5nLWJ5dGVz"}}`, string(data))
+
+	var got ContentBlock
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, cb, got)
+}
+
+func TestNewImageBlockSniffsMediaType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	cb := NewImageBlock("", png)
+	require.NotNil(t, cb.Source)
+	assert.Equal(t, "image/png", cb.Source.MediaType)
+}
+
+func TestNewMediaFileBlock(t *testing.T) {
+	img, err := NewMediaFileBlock("/tmp/shot.PNG")
+	require.NoError(t, err)
+	assert.Equal(t, BlockTypeImage, img.Type)
+	assert.Equal(t, "shot.PNG", img.Name)
+	assert.Equal(t, &MediaSource{Type: MediaSourceFile, MediaType: "image/png", Path: "/tmp/shot.PNG"}, img.Source)
+
+	doc, err := NewMediaFileBlock("spec.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, BlockTypeDocument, doc.Type)
+
+	_, err = NewMediaFileBlock("notes.txt")
+	assert.Error(t, err)
+}
+
+func TestResolveMediaSourceReadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shot.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("jpeg-bytes"), 0o644))
+
+	got, err := ResolveMediaSource(&MediaSource{Type: MediaSourceFile, Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, &MediaSource{Type: MediaSourceBase64, MediaType: "image/jpeg", Data: "anBlZy1ieXRlcw=="}, got)
+}
+
+func TestResolveMediaSourceErrors(t *testing.T) {
+	_, err := ResolveMediaSource(nil)
+	assert.Error(t, err)
+
+	_, err = ResolveMediaSource(&MediaSource{Type: MediaSourceFile, Path: filepath.Join(t.TempDir(), "missing.png")})
+	assert.Error(t, err)
+
+	_, err = ResolveMediaSource(&MediaSource{Type: "url"})
+	assert.Error(t, err)
+}
+
+func TestResolveMediaSourceLimitsBase64Size(t *testing.T) {
+	atLimit := base64.StdEncoding.EncodeToString(make([]byte, MaxMediaBytes))
+	_, err := ResolveMediaSource(&MediaSource{Type: MediaSourceBase64, MediaType: "image/png", Data: atLimit})
+	assert.NoError(t, err)
+
+	over := base64.StdEncoding.EncodeToString(make([]byte, MaxMediaBytes+1))
+	_, err = ResolveMediaSource(&MediaSource{Type: MediaSourceBase64, MediaType: "image/png", Data: over})
+	assert.ErrorContains(t, err, "exceeds limit")
+	_, err = ResolveMediaSource(&MediaSource{Type: MediaSourceBase64, Data: over})
+	assert.ErrorContains(t, err, "exceeds limit", "checked before the data is decoded to sniff its type")
+}
+
+func TestMediaSourceDataURL(t *testing.T) {
+	src := MediaSource{Type: MediaSourceBase64, MediaType: "image/gif", Data: "R0lG"}
+	assert.Equal(t, "data:image/gif;base64,R0lG", src.DataURL())
+}
+
+func TestMediaPlaceholder(t *testing.T) {
+	assert.Equal(t, "[image shot.png omitted: model does not accept image input]",
+		MediaPlaceholder(ContentBlock{Type: BlockTypeImage, Name: "shot.png"}))
+	assert.Equal(t, "[document omitted: model does not accept document input]",
+		MediaPlaceholder(ContentBlock{Type: BlockTypeDocument}))
+}
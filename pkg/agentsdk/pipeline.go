@@ -17,6 +17,7 @@ type Result struct {
 	Content        string
 	DisplayContent string
 	IsError        bool
+	Media          []ContentBlock // see ToolResult.Media
 }
 
 // HandlerFunc executes a tool call and returns a result.
@@ -0,0 +1,93 @@
+package agentsdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TodoList is the plan the model keeps with a todo tool. An agent built
+// with WithTodoList shows it to the model every loop iteration and emits a
+// plan_update event whenever a tool call changes it.
+//
+// Items and Set satisfy the list interface internal/tools.NewTodoTool
+// takes, so a host wires the tool with the same list it hands the agent.
+type TodoList struct {
+	mu      sync.Mutex
+	items   []PlanEntry
+	changed bool
+}
+
+// NewTodoList creates an empty TodoList.
+func NewTodoList() *TodoList {
+	return &TodoList{}
+}
+
+// Items returns a copy of the list.
+func (t *TodoList) Items() []PlanEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]PlanEntry(nil), t.items...)
+}
+
+// Set replaces the list.
+func (t *TodoList) Set(items []PlanEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.items = append([]PlanEntry(nil), items...)
+	t.changed = true
+}
+
+// takeChanged reports whether the list changed since the last call.
+func (t *TodoList) takeChanged() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	changed := t.changed
+	t.changed = false
+	return changed
+}
+
+// WithTodoList attaches a todo list to the agent. The list is rendered into
+// the system prompt, so it survives the model losing track of earlier
+// turns, and each change is published as a plan_update event. The option
+// does not register a tool: the host registers its todo tool over the same
+// list. A nil list is ignored.
+func WithTodoList(list *TodoList) Option {
+	return func(a *Agent) {
+		if list == nil {
+			return
+		}
+		a.todos = list
+		a.contextStrategies = append(a.contextStrategies, todoStrategy{list: list})
+	}
+}
+
+// publishTodos emits a plan_update event when a tool has changed the todo
+// list since the last call.
+func (a *Agent) publishTodos(ctx context.Context, ch chan<- TurnEvent) {
+	if a.todos == nil || !a.todos.takeChanged() {
+		return
+	}
+	sendEvent(ctx, ch, TurnEvent{Type: "plan_update", Plan: a.todos.Items()})
+}
+
+// todoStrategy contributes the todo list to the system prompt.
+type todoStrategy struct{ list *TodoList }
+
+func (s todoStrategy) ContributePromptSections(context.Context, PromptContext) []PromptSection {
+	items := s.list.Items()
+	if len(items) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("Your todo list for this task. Keep it current with the todo tool as you work.\n\n")
+	for i, item := range items {
+		fmt.Fprintf(&sb, "%d. [%s] %s\n", i+1, item.Status, item.Content)
+	}
+	return []PromptSection{{
+		Title:   "Todo List",
+		Content: sb.String(),
+		Reason:  "the model updates its todo list as it works",
+	}}
+}
@@ -0,0 +1,48 @@
+package agentsdk
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTodoListPublishesChangesAndPromptsWithTheList(t *testing.T) {
+	todos := NewTodoList()
+	reg := NewRegistry()
+	require.NoError(t, reg.Register(&todoSetTool{list: todos}))
+
+	p := &mockProvider{responses: [][]StreamEvent{
+		{
+			{Type: "tool_use", ToolUse: &ToolUseBlock{ID: "t1", Name: "todo"}},
+			{Type: "stop"},
+		},
+		{{Type: "text_delta", Text: "done"}, {Type: "stop"}},
+	}}
+	a := NewAgent(p, WithTools(reg), WithTodoList(todos))
+
+	events, err := a.Turn(context.Background(), "plan it")
+	require.NoError(t, err)
+	var plans [][]PlanEntry
+	for ev := range events {
+		if ev.Type == "plan_update" {
+			plans = append(plans, ev.Plan)
+		}
+	}
+
+	assert.Equal(t, [][]PlanEntry{{{Content: "step one", Status: PlanEntryInProgress}}}, plans)
+	assert.Contains(t, a.effectiveSystemPrompt(context.Background(), ""), "1. [in_progress] step one")
+}
+
+// todoSetTool stands in for the todo tool: every call sets a one-item list.
+type todoSetTool struct{ list *TodoList }
+
+func (t *todoSetTool) Name() string                 { return "todo" }
+func (t *todoSetTool) Description() string          { return "todo" }
+func (t *todoSetTool) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *todoSetTool) Execute(context.Context, json.RawMessage) (ToolResult, error) {
+	t.list.Set([]PlanEntry{{Content: "step one", Status: PlanEntryInProgress}})
+	return ToolResult{Content: "ok"}, nil
+}
@@ -83,6 +83,9 @@ type ToolResult struct {
 	Content        string // sent to LLM conversation history
 	DisplayContent string // shown to user; falls back to Content if empty
 	IsError        bool
+	// Media holds image or document blocks (see NewMediaFileBlock) that
+	// accompany Content in the model's view of the result, e.g. a screenshot.
+	Media []ContentBlock
 }
 
 // Display returns the content intended for user display. It returns
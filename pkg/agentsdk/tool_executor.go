@@ -15,13 +15,15 @@ type ToolLookup interface {
 }
 
 // ToolExecOutcome is the result of dispatching one tool call: the content
-// for the conversation, optional display-oriented content for UIs, and the
-// error flag. Execution failures are folded into an error outcome rather
-// than returned as a Go error, so a misbehaving tool never aborts the turn.
+// for the conversation, optional display-oriented content for UIs, any media
+// the tool attached, and the error flag. Execution failures are folded into
+// an error outcome rather than returned as a Go error, so a misbehaving tool
+// never aborts the turn.
 type ToolExecOutcome struct {
 	Content        string
 	DisplayContent string
 	IsError        bool
+	Media          []ContentBlock
 }
 
 // ExecuteTool dispatches a single tool call against a registry: name
@@ -71,6 +73,7 @@ func ExecuteTool(ctx context.Context, lookup ToolLookup, name string, input json
 		Content:        tr.Content,
 		DisplayContent: tr.DisplayContent,
 		IsError:        tr.IsError,
+		Media:          tr.Media,
 	}
 }
 
@@ -30,6 +30,10 @@ type ModelCapabilities struct {
 	// empty (provider default). Mapped to provider-specific parameters when
 	// the provider supports extended thinking (e.g. Anthropic budget_tokens).
 	ReasoningEffort string
+	// SupportsVision indicates the model accepts image and document content
+	// blocks. When false, transformers replace those blocks with a short
+	// text placeholder instead of sending bytes the API would reject.
+	SupportsVision bool
 }
 
 // DefaultCapabilities returns ModelCapabilities with the safe defaults:
@@ -66,6 +70,8 @@ const (
 	BlockTypeToolUse    = "tool_use"
 	BlockTypeToolResult = "tool_result"
 	BlockTypeThinking   = "thinking"
+	BlockTypeImage      = "image"
+	BlockTypeDocument   = "document"
 )
 
 // Stream event type constants.
@@ -117,6 +123,12 @@ type ContentBlock struct {
 	Input     json.RawMessage `json:"input,omitempty"`
 	ToolUseID string          `json:"tool_use_id,omitempty"`
 	IsError   bool            `json:"is_error,omitempty"`
+	// Source carries the payload of image and document blocks. Nil for
+	// every other block type.
+	Source *MediaSource `json:"source,omitempty"`
+	// Content holds image and document blocks attached to a tool_result
+	// block, sent to the model after the result's Text.
+	Content []ContentBlock `json:"content,omitempty"`
 }
 
 type contentBlockJSON struct {
@@ -127,6 +139,8 @@ type contentBlockJSON struct {
 	Input     json.RawMessage `json:"input,omitempty"`
 	ToolUseID string          `json:"tool_use_id,omitempty"`
 	IsError   bool            `json:"is_error,omitempty"`
+	Source    *MediaSource    `json:"source,omitempty"`
+	Content   []ContentBlock  `json:"content,omitempty"`
 }
 
 func (c ContentBlock) MarshalJSON() ([]byte, error) {
@@ -138,6 +152,8 @@ func (c ContentBlock) MarshalJSON() ([]byte, error) {
 		Input:     marshalSafeRawJSON(c.Input),
 		ToolUseID: c.ToolUseID,
 		IsError:   c.IsError,
+		Source:    c.Source,
+		Content:   c.Content,
 	})
 }
 
@@ -180,6 +196,7 @@ type StreamEvent struct {
 	OutputTokens        int
 	CacheCreationTokens int    // tokens written to cache on this request (billed at higher rate)
 	CacheReadTokens     int    // tokens read from cache on this request (billed at lower rate)
+	ReasoningTokens     int    // subset of OutputTokens spent on hidden reasoning, when the provider reports it
 	StopReason          string // populated on stop events: "end_turn", "max_tokens", "tool_use", "stop_sequence"
 	Model               string // populated on message_start
 	MessageID           string // populated on message_start
@@ -218,8 +235,9 @@ func NewUserMessage(text string) Message {
 // block. Every tool_use must be followed by one of these — see
 // SealOrphanedToolUses for what happens when one is missing.
 //
-// Canonical constructor, for the same reason as NewUserMessage.
-func NewToolResultMessage(toolUseID, content string, isError bool) Message {
+// Canonical constructor, for the same reason as NewUserMessage. media is
+// attached to the result block's Content.
+func NewToolResultMessage(toolUseID, content string, isError bool, media ...ContentBlock) Message {
 	return Message{
 		Role: "user",
 		Content: []ContentBlock{
@@ -228,6 +246,7 @@ func NewToolResultMessage(toolUseID, content string, isError bool) Message {
 				ToolUseID: toolUseID,
 				Text:      content,
 				IsError:   isError,
+				Content:   media,
 			},
 		},
 	}
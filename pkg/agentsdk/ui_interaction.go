@@ -40,6 +40,11 @@ type UIRequest struct {
 	Actions        []UIAction        // available actions for the user
 	TimeoutSeconds int               // optional timeout hint (0 = adapter default)
 	Metadata       map[string]string // optional transport-safe key/value hints
+
+	// Input is the full tool input of an approval request, for in-process
+	// adapters that render it (e.g. as a diff). Metadata["input"] carries
+	// a truncated copy for transports, which never see this field.
+	Input json.RawMessage `json:"-"`
 }
 
 // UIUpdate carries an incremental update for a previously emitted UI request.
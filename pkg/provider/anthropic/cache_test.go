@@ -4,8 +4,7 @@ import (
 	"encoding/json"
 	"testing"
 
-	"github.com/julianshen/rubichan/internal/provider"
-	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/pkg/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -216,7 +215,7 @@ func TestToolDefinitionCachingSingleTool(t *testing.T) {
 }
 
 func TestTransformerWithSchemaCache(t *testing.T) {
-	cache := tools.NewToolSchemaCache()
+	cache := provider.NewToolSchemaCache()
 	tr := &Transformer{SchemaCache: cache}
 
 	req := provider.CompletionRequest{
@@ -240,7 +239,7 @@ func TestTransformerWithSchemaCache(t *testing.T) {
 }
 
 func TestTransformerSchemaCacheStale(t *testing.T) {
-	cache := tools.NewToolSchemaCache()
+	cache := provider.NewToolSchemaCache()
 	tr := &Transformer{SchemaCache: cache}
 
 	tool := provider.ToolDef{Name: "file", Description: "Read", InputSchema: json.RawMessage(`{}`)}
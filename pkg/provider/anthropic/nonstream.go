@@ -9,8 +9,8 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
-	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
 )
 
 // NonStream sends req with stream=false and converts the full JSON response
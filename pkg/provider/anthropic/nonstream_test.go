@@ -11,8 +11,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
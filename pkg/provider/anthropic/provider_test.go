@@ -8,16 +8,17 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/julianshen/rubichan/internal/config"
-	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/internal/testutil"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -1131,38 +1132,6 @@ func TestStream_SetsRequestIDHeader(t *testing.T) {
 
 func floatPtr(f float64) *float64 { return &f }
 
-func TestProviderDef_BaseURLAndAuth(t *testing.T) {
-	t.Setenv("ANTHROPIC_API_KEY", "test-anthropic-key")
-
-	def := providerDef()
-	cfg := config.DefaultConfig()
-
-	assert.Equal(t, "https://api.anthropic.com", def.BaseURL(cfg))
-
-	apiKey, headers, err := def.Auth(cfg)
-	require.NoError(t, err)
-	assert.Equal(t, "test-anthropic-key", apiKey)
-	assert.Nil(t, headers)
-}
-
-func TestProviderDef_AuthMissingKey(t *testing.T) {
-	t.Setenv("ANTHROPIC_API_KEY", "")
-
-	def := providerDef()
-	cfg := config.DefaultConfig()
-
-	_, _, err := def.Auth(cfg)
-	require.Error(t, err)
-}
-
-func TestProviderDef_DefaultModel(t *testing.T) {
-	def := providerDef()
-
-	model, err := def.DefaultModel(context.Background(), config.DefaultConfig())
-	require.NoError(t, err)
-	assert.Equal(t, "claude-sonnet-4-5", model)
-}
-
 func TestConvertSSEEvent_MessageDelta_StopReason(t *testing.T) {
 	p := New("http://localhost", "test-key")
 	state := newStreamState()
@@ -1232,3 +1201,113 @@ func TestHandleMessageStart_CacheTokens(t *testing.T) {
 	assert.Equal(t, 2000, evt.CacheCreationTokens)
 	assert.Equal(t, 48000, evt.CacheReadTokens)
 }
+
+func TestTransformerSerializesImageBlocks(t *testing.T) {
+	tr := &Transformer{}
+	req := provider.CompletionRequest{
+		Model:        "claude-sonnet-4-5",
+		MaxTokens:    1024,
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages: []provider.Message{{
+			Role: "user",
+			Content: []provider.ContentBlock{
+				{Type: "text", Text: "describe"},
+				agentsdk.NewImageBlock("image/png", []byte("png-bytes")),
+				agentsdk.NewDocumentBlock("application/pdf", []byte("%PDF-1.7")),
+			},
+		}},
+	}
+
+	body, err := tr.ToProviderJSON(req)
+	require.NoError(t, err)
+
+	var parsed struct {
+		Messages []struct {
+			Content []map[string]any `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	require.Len(t, parsed.Messages, 1)
+	blocks := parsed.Messages[0].Content
+	require.Len(t, blocks, 3)
+
+	assert.Equal(t, "image", blocks[1]["type"])
+	assert.Equal(t, map[string]any{
+		"type":       "base64",
+		"media_type": "image/png",
+		"data":       "cG5nLWJ5dGVz",
+	}, blocks[1]["source"])
+	assert.Equal(t, "document", blocks[2]["type"])
+	src, ok := blocks[2]["source"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "application/pdf", src["media_type"])
+}
+
+func TestTransformerInlinesImageFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shot.png")
+	require.NoError(t, os.WriteFile(path, []byte("png-bytes"), 0o644))
+	block, err := agentsdk.NewMediaFileBlock(path)
+	require.NoError(t, err)
+
+	tr := &Transformer{}
+	body, err := tr.ToProviderJSON(provider.CompletionRequest{
+		Model:        "claude-sonnet-4-5",
+		MaxTokens:    1024,
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages:     []provider.Message{{Role: "user", Content: []provider.ContentBlock{block}}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"data":"cG5nLWJ5dGVz"`)
+	assert.NotContains(t, string(body), path, "local paths must not leak into the request")
+	assert.NotContains(t, string(body), `"name"`, "attachment name is not an Anthropic media field")
+}
+
+func TestTransformerDowngradesImagesWithoutVision(t *testing.T) {
+	tr := &Transformer{}
+	body, err := tr.ToProviderJSON(provider.CompletionRequest{
+		Model:     "claude-sonnet-4-5",
+		MaxTokens: 1024,
+		Messages: []provider.Message{{
+			Role:    "user",
+			Content: []provider.ContentBlock{agentsdk.NewImageBlock("image/png", []byte("png-bytes"))},
+		}},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), `"source"`)
+	assert.Contains(t, string(body), "image omitted")
+}
+
+func TestTransformerSerializesToolResultMedia(t *testing.T) {
+	tr := &Transformer{}
+	body, err := tr.ToProviderJSON(provider.CompletionRequest{
+		Model:        "claude-sonnet-4-5",
+		MaxTokens:    1024,
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages: []provider.Message{
+			provider.NewUserMessage("take a screenshot"),
+			{Role: "assistant", Content: []provider.ContentBlock{
+				{Type: "tool_use", ID: "t1", Name: "browser_screenshot", Input: json.RawMessage(`{}`)},
+			}},
+			provider.NewToolResultMessage("t1", "saved screenshot", false,
+				agentsdk.NewImageBlock("image/png", []byte("png-bytes"))),
+		},
+	})
+	require.NoError(t, err)
+
+	var parsed struct {
+		Messages []struct {
+			Content []struct {
+				Type    string           `json:"type"`
+				Content []map[string]any `json:"content"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	require.Len(t, parsed.Messages, 3)
+	result := parsed.Messages[2].Content[0]
+	assert.Equal(t, "tool_result", result.Type)
+	require.Len(t, result.Content, 2)
+	assert.Equal(t, map[string]any{"type": "text", "text": "saved screenshot"}, result.Content[0])
+	assert.Equal(t, "image", result.Content[1]["type"])
+	assert.Equal(t, "cG5nLWJ5dGVz", result.Content[1]["source"].(map[string]any)["data"])
+}
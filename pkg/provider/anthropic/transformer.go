@@ -3,9 +3,9 @@ package anthropic
 import (
 	"encoding/json"
 
-	"github.com/julianshen/rubichan/internal/provider"
-	"github.com/julianshen/rubichan/internal/provider/normalize"
-	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
+	"github.com/julianshen/rubichan/pkg/provider/normalize"
 )
 
 // API wire-format types for Anthropic v1 messages endpoint.
@@ -51,13 +51,23 @@ type apiContentBlock struct {
 	Name      string          `json:"name,omitempty"`
 	Input     json.RawMessage `json:"input,omitempty"`
 	ToolUseID string          `json:"tool_use_id,omitempty"`
-	Content   string          `json:"content,omitempty"`
+	Content   any             `json:"content,omitempty"` // string, or []apiContentBlock when media is attached
 	IsError   bool            `json:"is_error,omitempty"`
+	Source    *apiMediaSource `json:"source,omitempty"`
+}
+
+// apiMediaSource is the Anthropic "source" object for image and document
+// blocks. Only the base64 form is emitted; file sources are inlined by
+// normalize.ResolveMedia before conversion.
+type apiMediaSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
 }
 
 // Transformer implements provider.MessageTransformer for the Anthropic API.
 type Transformer struct {
-	SchemaCache *tools.ToolSchemaCache
+	SchemaCache *provider.ToolSchemaCache
 }
 
 // ToProviderJSON converts a CompletionRequest into the Anthropic v1 messages
@@ -81,11 +91,16 @@ func (t *Transformer) ToProviderJSON(req provider.CompletionRequest) ([]byte, er
 		apiReq.Temperature = &temp
 	}
 
-	// Normalize messages: remove empty blocks and scrub tool IDs.
+	// Normalize messages: remove empty blocks, scrub tool IDs, and inline
+	// (or downgrade, for non-vision models) image and document blocks.
 	messages := normalize.ScrubToolIDs(
 		normalize.RemoveEmptyMessages(req.Messages),
 		normalize.ScrubToolIDChars,
 	)
+	messages = normalize.ResolveMedia(messages, normalize.MediaSupport{
+		Images:    req.Capabilities.SupportsVision,
+		Documents: req.Capabilities.SupportsVision,
+	})
 
 	// Convert messages, remapping fields for the Anthropic API.
 	for _, msg := range messages {
@@ -162,7 +177,9 @@ func buildCachedSystemBlocks(system string, breakpoints []int) []apiSystemBlock
 
 // convertContentBlocks maps provider.ContentBlock to Anthropic-specific
 // apiContentBlock. For tool_result blocks, the text is placed in the "content"
-// field (which is what the Anthropic API expects) instead of "text".
+// field (which is what the Anthropic API expects) instead of "text"; when the
+// result carries media, "content" becomes a text block followed by the media
+// blocks.
 func convertContentBlocks(blocks []provider.ContentBlock) []apiContentBlock {
 	var out []apiContentBlock
 	for _, b := range blocks {
@@ -176,7 +193,18 @@ func convertContentBlocks(blocks []provider.ContentBlock) []apiContentBlock {
 		}
 		switch b.Type {
 		case "tool_result":
-			cb.Content = b.Text
+			cb.Content = toolResultContent(b)
+		case agentsdk.BlockTypeImage, agentsdk.BlockTypeDocument:
+			// Name holds the attachment filename locally; the API has no
+			// such field on media blocks and rejects unknown ones.
+			cb.Name = ""
+			if b.Source != nil {
+				cb.Source = &apiMediaSource{
+					Type:      agentsdk.MediaSourceBase64,
+					MediaType: b.Source.MediaType,
+					Data:      b.Source.Data,
+				}
+			}
 		default:
 			cb.Text = b.Text
 		}
@@ -184,3 +212,20 @@ func convertContentBlocks(blocks []provider.ContentBlock) []apiContentBlock {
 	}
 	return out
 }
+
+// toolResultContent returns the "content" value for a tool_result block: the
+// plain text, or a block array when media is attached. Empty text is left
+// nil so the field is omitted, as before media support.
+func toolResultContent(b provider.ContentBlock) any {
+	if len(b.Content) == 0 {
+		if b.Text == "" {
+			return nil
+		}
+		return b.Text
+	}
+	var blocks []provider.ContentBlock
+	if b.Text != "" {
+		blocks = append(blocks, provider.ContentBlock{Type: agentsdk.BlockTypeText, Text: b.Text})
+	}
+	return convertContentBlocks(append(blocks, b.Content...))
+}
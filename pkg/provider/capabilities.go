@@ -35,6 +35,9 @@ func DetectCapabilities(providerName, modelID string) ModelCapabilities {
 	if caps.MaxToolCount < 0 {
 		caps.MaxToolCount = 0
 	}
+	if providerName != "anthropic" {
+		caps.SupportsVision = isVisionModel(modelID)
+	}
 	return caps
 }
 
@@ -45,6 +48,8 @@ func DetectCapabilities(providerName, modelID string) ModelCapabilities {
 func detectAnthropicCapabilities(modelID string) ModelCapabilities {
 	caps := agentsdk.DefaultCapabilities()
 	lower := strings.ToLower(modelID)
+	// Every Claude model from the 3 family on accepts image and PDF input.
+	caps.SupportsVision = !strings.Contains(lower, "claude-2") && !strings.Contains(lower, "claude-instant")
 	if strings.Contains(lower, "haiku") {
 		caps.NeedsToolDiscoveryHint = true
 		caps.MaxToolCount = 12
@@ -167,6 +172,31 @@ func matchesAnyKeyword(lower string, keywords []string) bool {
 	return false
 }
 
+// visionKeywords identify non-Anthropic model families that accept image
+// input. Matched against the lowercase model ID, so "-vl" covers qwen2.5-vl
+// and kimi-vl alike.
+var visionKeywords = []string{
+	"gpt-4o", "gpt-4.1", "gpt-4-turbo", "gpt-5",
+	"claude", "gemini", "gemma3", "gemma-3", "llama4", "llama-4",
+	"-vl", "vision", "llava", "pixtral", "moondream", "minicpm-v",
+	"glm-4v", "glm-4.5v", "glm-4.6v",
+}
+
+// visionReasoningRe matches the OpenAI o-series models that accept images.
+// Their IDs are too short for substring matching: "o3" would also match the
+// text-only o3-mini. Matched against the whole ID after any "vendor/"
+// prefix, with an optional dated snapshot suffix.
+var visionReasoningRe = regexp.MustCompile(`(?:^|/)(?:o1|o1-pro|o3|o3-pro|o4-mini)(?:-\d{4}-\d{2}-\d{2})?$`)
+
+// isVisionModel reports whether modelID names a model known to accept image
+// content blocks. Unknown models default to false: sending an image to a
+// text-only model fails the whole request, while a placeholder only loses
+// the attachment.
+func isVisionModel(modelID string) bool {
+	lower := strings.ToLower(modelID)
+	return visionReasoningRe.MatchString(lower) || matchesAnyKeyword(lower, visionKeywords)
+}
+
 // smallModelRe matches size indicators that identify models with <=14B
 // parameters. Numeric indicators (e.g. "7b", "13b") are matched only when
 // preceded by a non-digit boundary so that "72b" or "22b" do not falsely
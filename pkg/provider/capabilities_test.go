@@ -504,3 +504,41 @@ func TestDetectCapabilitiesEmptyProvider(t *testing.T) {
 		t.Error("empty provider with unknown model should get NeedsToolDiscoveryHint=true")
 	}
 }
+
+func TestDetectCapabilitiesVision(t *testing.T) {
+	tests := []struct {
+		provider string
+		model    string
+		want     bool
+	}{
+		{"anthropic", "claude-sonnet-4-5", true},
+		{"anthropic", "claude-3-haiku-20240307", true},
+		{"anthropic", "claude-2.1", false},
+		{"openrouter", "openai/gpt-4o", true},
+		{"openai", "o3", true},
+		{"openai", "o3-2025-04-16", true},
+		{"openai", "o3-mini", false},
+		{"openai", "o3-mini-2025-01-31", false},
+		{"openai", "o1-mini", false},
+		{"openai", "o4-mini", true},
+		{"openrouter", "openai/o4-mini", true},
+		{"openrouter", "openai/o3-mini-high", false},
+		{"ollama", "llama3-o3:8b", false},
+		{"openrouter", "google/gemini-2.5-pro", true},
+		{"openrouter", "qwen/qwen2.5-vl-72b-instruct", true},
+		{"openrouter", "deepseek/deepseek-chat", false},
+		{"zai", "glm-4.5v", true},
+		{"zai", "glm-5", false},
+		{"ollama", "llava:13b", true},
+		{"ollama", "llama3.2-vision:11b", true},
+		{"ollama", "qwen2.5-coder:14b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.model, func(t *testing.T) {
+			got := DetectCapabilities(tt.provider, tt.model).SupportsVision
+			if got != tt.want {
+				t.Errorf("SupportsVision = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
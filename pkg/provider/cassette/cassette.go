@@ -0,0 +1,353 @@
+// Package cassette records LLM provider traffic to a file and replays it,
+// so an agent session — tool execution included — can be re-run offline
+// and deterministically.
+//
+// A Recorder wraps a real agentsdk.LLMProvider and appends every
+// CompletionRequest together with the full StreamEvent sequence it produced
+// to a cassette. A Replayer serves those sequences back, matching each
+// incoming request against the recorded ones by normalized content rather
+// than by position, so subagents and parallel calls replay correctly.
+//
+//	rec, err := cassette.NewRecorder(p, "testdata/fix-bug.json")
+//	agent := agentsdk.NewAgent(rec, ...)
+//
+//	rp, err := cassette.Load("testdata/fix-bug.json")
+//	agent := agentsdk.NewAgent(rp, ...)
+//
+// A request that matches nothing recorded fails the call with a
+// *MismatchError, which is what a regression test wants to see when a
+// prompt, compaction or tool schema change alters what the agent sends.
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// Version is the cassette file format version.
+const Version = 1
+
+// Cassette is the on-disk form of a recording.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one provider call: the normalized request it was made
+// with and what the provider answered.
+type Interaction struct {
+	Key     string  `json:"key"`
+	Request Request `json:"request"`
+	Events  []Event `json:"events,omitempty"`
+	Error   string  `json:"error,omitempty"` // Stream itself failed
+}
+
+// Request is the normalized form of a CompletionRequest used for matching.
+// Model, token limits, temperature, cache hints and message metadata are
+// left out: they vary between runs or machines without changing what the
+// model is asked.
+type Request struct {
+	System   string    `json:"system,omitempty"`
+	Tools    []Tool    `json:"tools,omitempty"`
+	Messages []Message `json:"messages"`
+}
+
+// Tool is the normalized form of an agentsdk.ToolDef: its name and a
+// digest of its input schema, so a schema change fails the match.
+type Tool struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema,omitempty"`
+}
+
+func (t Tool) String() string {
+	if t.Schema == "" {
+		return t.Name
+	}
+	return t.Name + " (" + t.Schema + ")"
+}
+
+// Message is the normalized form of an agentsdk.Message.
+type Message struct {
+	Role    string  `json:"role"`
+	Content []Block `json:"content"`
+}
+
+// Block is the normalized form of an agentsdk.ContentBlock. Media payloads
+// are reduced to a digest so cassettes stay small.
+type Block struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+	Media     string          `json:"media,omitempty"`
+	Content   []Block         `json:"content,omitempty"`
+}
+
+// Event is the serializable form of an agentsdk.StreamEvent. Errors are
+// kept as their message; replay turns them back into plain errors.
+type Event struct {
+	Type                string                 `json:"type"`
+	Text                string                 `json:"text,omitempty"`
+	ToolUse             *agentsdk.ToolUseBlock `json:"tool_use,omitempty"`
+	Error               string                 `json:"error,omitempty"`
+	InputTokens         int                    `json:"input_tokens,omitempty"`
+	OutputTokens        int                    `json:"output_tokens,omitempty"`
+	CacheCreationTokens int                    `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int                    `json:"cache_read_tokens,omitempty"`
+	ReasoningTokens     int                    `json:"reasoning_tokens,omitempty"`
+	StopReason          string                 `json:"stop_reason,omitempty"`
+	Model               string                 `json:"model,omitempty"`
+	MessageID           string                 `json:"message_id,omitempty"`
+}
+
+func newEvent(evt agentsdk.StreamEvent) Event {
+	e := Event{
+		Type:                evt.Type,
+		Text:                evt.Text,
+		ToolUse:             evt.ToolUse,
+		InputTokens:         evt.InputTokens,
+		OutputTokens:        evt.OutputTokens,
+		CacheCreationTokens: evt.CacheCreationTokens,
+		CacheReadTokens:     evt.CacheReadTokens,
+		ReasoningTokens:     evt.ReasoningTokens,
+		StopReason:          evt.StopReason,
+		Model:               evt.Model,
+		MessageID:           evt.MessageID,
+	}
+	if evt.Error != nil {
+		e.Error = evt.Error.Error()
+	}
+	return e
+}
+
+// StreamEvent converts e back to the event the provider emitted.
+func (e Event) StreamEvent() agentsdk.StreamEvent {
+	evt := agentsdk.StreamEvent{
+		Type:                e.Type,
+		Text:                e.Text,
+		ToolUse:             e.ToolUse,
+		InputTokens:         e.InputTokens,
+		OutputTokens:        e.OutputTokens,
+		CacheCreationTokens: e.CacheCreationTokens,
+		CacheReadTokens:     e.CacheReadTokens,
+		ReasoningTokens:     e.ReasoningTokens,
+		StopReason:          e.StopReason,
+		Model:               e.Model,
+		MessageID:           e.MessageID,
+	}
+	if e.Error != "" {
+		evt.Error = errors.New(e.Error)
+	}
+	// ToolUseBlock.Input is not omitempty, so a tool_use whose input
+	// arrives in later deltas round-trips as null.
+	if e.ToolUse != nil && string(e.ToolUse.Input) == "null" {
+		tu := *e.ToolUse
+		tu.Input = nil
+		evt.ToolUse = &tu
+	}
+	return evt
+}
+
+// Normalizer rewrites the text of a request before it is matched, e.g. to
+// replace a per-run temp directory with a stable placeholder.
+type Normalizer func(string) string
+
+// Option configures a Recorder or Replayer.
+type Option func(*options)
+
+type options struct {
+	normalize []Normalizer
+}
+
+// WithNormalizer adds a text rewrite applied to the system prompt, message
+// text and tool inputs before matching. Recorder and Replayer must be
+// given the same normalizers.
+func WithNormalizer(fn Normalizer) Option {
+	return func(o *options) { o.normalize = append(o.normalize, fn) }
+}
+
+// WithReplacement replaces every occurrence of old with placeholder, for
+// values such as the working directory that differ between the recording
+// and the replay.
+func WithReplacement(old, placeholder string) Option {
+	return WithNormalizer(func(s string) string {
+		if old == "" {
+			return s
+		}
+		return strings.ReplaceAll(s, old, placeholder)
+	})
+}
+
+func buildOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) text(s string) string {
+	for _, fn := range o.normalize {
+		s = fn(s)
+	}
+	return s
+}
+
+// NormalizeRequest reduces req to the content that is matched on replay.
+func NormalizeRequest(req agentsdk.CompletionRequest, opts ...Option) Request {
+	return buildOptions(opts).request(req)
+}
+
+func (o options) request(req agentsdk.CompletionRequest) Request {
+	r := Request{
+		System:   o.text(collapseSpace(req.System)),
+		Messages: make([]Message, 0, len(req.Messages)),
+	}
+	for _, t := range req.Tools {
+		r.Tools = append(r.Tools, Tool{Name: t.Name, Schema: o.schemaDigest(t.InputSchema)})
+	}
+	sort.Slice(r.Tools, func(i, j int) bool { return r.Tools[i].Name < r.Tools[j].Name })
+	for _, m := range req.Messages {
+		r.Messages = append(r.Messages, Message{Role: m.Role, Content: o.blocks(m.Content)})
+	}
+	return r
+}
+
+func (o options) blocks(in []agentsdk.ContentBlock) []Block {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]Block, 0, len(in))
+	for _, b := range in {
+		nb := Block{
+			Type:      b.Type,
+			Text:      o.text(b.Text),
+			ID:        b.ID,
+			Name:      b.Name,
+			ToolUseID: b.ToolUseID,
+			IsError:   b.IsError,
+			Content:   o.blocks(b.Content),
+		}
+		if len(b.Input) > 0 {
+			nb.Input = o.input(b.Input)
+		}
+		if b.Source != nil {
+			sum := sha256.Sum256([]byte(b.Source.Data + b.Source.Path))
+			nb.Media = b.Source.MediaType + " sha256:" + hex.EncodeToString(sum[:8])
+		}
+		out = append(out, nb)
+	}
+	return out
+}
+
+// input compacts a tool input so formatting differences do not matter,
+// then applies the normalizers to it as text.
+func (o options) input(raw json.RawMessage) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return json.RawMessage(o.text(string(raw)))
+	}
+	return json.RawMessage(o.text(buf.String()))
+}
+
+// schemaDigest hashes a tool's input schema after canonicalizing it, so key
+// order and formatting do not matter, and applying the normalizers.
+func (o options) schemaDigest(raw json.RawMessage) string {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return ""
+	}
+	canonical := string(raw)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			canonical = string(b)
+		}
+	}
+	sum := sha256.Sum256([]byte(o.text(canonical)))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Key returns the match key of a normalized request.
+func (r Request) Key() string {
+	b, _ := json.Marshal(r)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Read loads a cassette file.
+func Read(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("cassette %s: unsupported version %d (want %d)", path, c.Version, Version)
+	}
+	return &c, nil
+}
+
+// Write saves c to path, replacing it atomically so a crash mid-write
+// never leaves a truncated cassette behind.
+func (c *Cassette) Write(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+	data = append(data, '\n')
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating cassette directory: %w", err)
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cassette-*")
+	if err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+// streamOf replays events on a buffered, closed channel.
+func streamOf(events []Event) <-chan agentsdk.StreamEvent {
+	ch := make(chan agentsdk.StreamEvent, len(events))
+	for _, e := range events {
+		ch <- e.StreamEvent()
+	}
+	close(ch)
+	return ch
+}
@@ -0,0 +1,290 @@
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider answers each call with the next canned event sequence.
+type scriptedProvider struct {
+	mu      sync.Mutex
+	replies [][]agentsdk.StreamEvent
+	err     error
+	calls   int
+}
+
+func (p *scriptedProvider) Stream(_ context.Context, _ agentsdk.CompletionRequest) (<-chan agentsdk.StreamEvent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	events := p.replies[0]
+	p.replies = p.replies[1:]
+	ch := make(chan agentsdk.StreamEvent, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	return ch, nil
+}
+
+func collect(t *testing.T, ch <-chan agentsdk.StreamEvent) []agentsdk.StreamEvent {
+	t.Helper()
+	var out []agentsdk.StreamEvent
+	for e := range ch {
+		out = append(out, e)
+	}
+	return out
+}
+
+func request(system string, texts ...string) agentsdk.CompletionRequest {
+	req := agentsdk.CompletionRequest{
+		Model:  "m",
+		System: system,
+		Tools:  []agentsdk.ToolDef{{Name: "shell"}, {Name: "file"}},
+	}
+	for _, text := range texts {
+		req.Messages = append(req.Messages, agentsdk.NewUserMessage(text))
+	}
+	return req
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	inner := &scriptedProvider{replies: [][]agentsdk.StreamEvent{
+		{
+			{Type: "message_start", Model: "claude", MessageID: "msg_1"},
+			{Type: "tool_use", ToolUse: &agentsdk.ToolUseBlock{ID: "toolu_1", Name: "shell"}},
+			{Type: "text_delta", Text: `{"command":"ls"}`},
+			{Type: "stop", StopReason: "tool_use", InputTokens: 10, OutputTokens: 5},
+		},
+		{
+			{Type: "text_delta", Text: "done"},
+			{Type: "error", Error: errors.New("overloaded")},
+		},
+	}}
+	rec, err := NewRecorder(inner, path)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ch, err := rec.Stream(ctx, request("sys", "list files"))
+	require.NoError(t, err)
+	first := collect(t, ch)
+	ch, err = rec.Stream(ctx, request("sys", "list files", "and then?"))
+	require.NoError(t, err)
+	second := collect(t, ch)
+	require.Eventually(t, func() bool { return rec.Len() == 2 }, time.Second, time.Millisecond)
+	require.NoError(t, rec.Err())
+
+	rp, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rp.Remaining())
+
+	// Matching is by content, so the second call replays first.
+	ch, err = rp.Stream(ctx, request("sys", "list files", "and then?"))
+	require.NoError(t, err)
+	replayed := collect(t, ch)
+	require.Len(t, replayed, 2)
+	assert.Equal(t, second[0], replayed[0])
+	require.Error(t, replayed[1].Error)
+	assert.Equal(t, "overloaded", replayed[1].Error.Error())
+
+	// The model, token limit and whitespace are not part of the match.
+	req := request("sys  ", "list files")
+	req.Model = "other"
+	req.MaxTokens = 99
+	ch, err = rp.Stream(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first, collect(t, ch))
+	assert.Equal(t, 0, rp.Remaining())
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestReplayRepeatsIdenticalRequestsInOrder(t *testing.T) {
+	c := &Cassette{Version: Version}
+	for _, text := range []string{"one", "two"} {
+		req := NormalizeRequest(request("sys", "same"))
+		c.Interactions = append(c.Interactions, Interaction{
+			Key: req.Key(), Request: req, Events: []Event{{Type: "text_delta", Text: text}},
+		})
+	}
+	rp := NewReplayer(c)
+	for _, want := range []string{"one", "two"} {
+		ch, err := rp.Stream(context.Background(), request("sys", "same"))
+		require.NoError(t, err)
+		assert.Equal(t, want, collect(t, ch)[0].Text)
+	}
+
+	_, err := rp.Stream(context.Background(), request("sys", "same"))
+	var mismatch *MismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Nil(t, mismatch.Expected)
+}
+
+func TestReplayMismatchNamesTheDifference(t *testing.T) {
+	req := NormalizeRequest(request("sys", "hello"))
+	rp := NewReplayer(&Cassette{Version: Version, Interactions: []Interaction{{Key: req.Key(), Request: req}}})
+
+	_, err := rp.Stream(context.Background(), request("sys", "goodbye"))
+	var mismatch *MismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Contains(t, err.Error(), "message 0 differs")
+	assert.Contains(t, err.Error(), "goodbye")
+
+	changed := request("sys", "hello")
+	changed.Tools = append(changed.Tools, agentsdk.ToolDef{Name: "todo"})
+	_, err = rp.Stream(context.Background(), changed)
+	assert.ErrorContains(t, err, "tools differ")
+	assert.Equal(t, 1, rp.Remaining(), "a mismatch consumes nothing")
+}
+
+func TestToolSchemaChangesAreMismatches(t *testing.T) {
+	withSchema := func(schema string) agentsdk.CompletionRequest {
+		req := request("sys", "hello")
+		req.Tools[0].InputSchema = json.RawMessage(schema)
+		return req
+	}
+	recorded := NormalizeRequest(withSchema(`{"type": "object", "properties": {"command": {"type": "string"}}}`))
+	assert.Equal(t, recorded.Key(), NormalizeRequest(withSchema(`{"properties":{"command":{"type":"string"}},"type":"object"}`)).Key(),
+		"key order and whitespace are ignored")
+
+	rp := NewReplayer(&Cassette{Version: Version, Interactions: []Interaction{{Key: recorded.Key(), Request: recorded}}})
+	_, err := rp.Stream(context.Background(), withSchema(`{"type":"object","properties":{"cmd":{"type":"string"}}}`))
+	var mismatch *MismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.ErrorContains(t, err, "tools differ")
+}
+
+func TestReplacementNormalizesPerRunPaths(t *testing.T) {
+	recorded := NormalizeRequest(request("cwd is /tmp/run-1", "read /tmp/run-1/go.mod"), WithReplacement("/tmp/run-1", "<workdir>"))
+	assert.Equal(t, "cwd is <workdir>", recorded.System)
+
+	rp := NewReplayer(&Cassette{Version: Version, Interactions: []Interaction{{
+		Key: recorded.Key(), Request: recorded, Events: []Event{{Type: "stop"}},
+	}}}, WithReplacement("/tmp/run-2", "<workdir>"))
+	_, err := rp.Stream(context.Background(), request("cwd is /tmp/run-2", "read /tmp/run-2/go.mod"))
+	require.NoError(t, err)
+}
+
+func TestToolInputFormattingIsIgnored(t *testing.T) {
+	msg := func(input string) agentsdk.CompletionRequest {
+		return agentsdk.CompletionRequest{Messages: []agentsdk.Message{{
+			Role:    "assistant",
+			Content: []agentsdk.ContentBlock{{Type: "tool_use", ID: "t1", Name: "shell", Input: json.RawMessage(input)}},
+		}}}
+	}
+	assert.Equal(t, NormalizeRequest(msg(`{"command": "ls"}`)).Key(), NormalizeRequest(msg(`{"command":"ls"}`)).Key())
+}
+
+func TestRecorderRecordsStreamErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "c.json")
+	rec, err := NewRecorder(&scriptedProvider{err: errors.New("401 unauthorized")}, path)
+	require.NoError(t, err)
+
+	_, err = rec.Stream(context.Background(), request("sys", "hi"))
+	require.EqualError(t, err, "401 unauthorized")
+
+	rp, err := Load(path)
+	require.NoError(t, err)
+	_, err = rp.Stream(context.Background(), request("sys", "hi"))
+	assert.ErrorContains(t, err, "401 unauthorized")
+}
+
+func TestRecorderDrainsAfterCancellation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.json")
+	rec, err := NewRecorder(&scriptedProvider{replies: [][]agentsdk.StreamEvent{{
+		{Type: "text_delta", Text: "a"},
+		{Type: "text_delta", Text: "b"},
+		{Type: "stop"},
+	}}}, path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = rec.Stream(ctx, request("sys", "hi"))
+	require.NoError(t, err)
+
+	// The consumer never reads; the stream must still be recorded in full.
+	require.Eventually(t, func() bool { return rec.Len() == 1 }, time.Second, 5*time.Millisecond)
+	c, err := Read(path)
+	require.NoError(t, err)
+	require.Len(t, c.Interactions, 1)
+	assert.Len(t, c.Interactions[0].Events, 3)
+}
+
+func TestReadRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.json")
+	require.NoError(t, (&Cassette{Version: 99}).Write(path))
+	_, err := Read(path)
+	assert.ErrorContains(t, err, "unsupported version 99")
+}
+
+type countingTool struct{ calls int }
+
+func (c *countingTool) Name() string                 { return "count" }
+func (c *countingTool) Description() string          { return "Counts its calls." }
+func (c *countingTool) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (c *countingTool) Execute(context.Context, json.RawMessage) (agentsdk.ToolResult, error) {
+	c.calls++
+	return agentsdk.ToolResult{Content: "counted"}, nil
+}
+
+func runSession(t *testing.T, p agentsdk.LLMProvider) (string, int) {
+	t.Helper()
+	tool := &countingTool{}
+	reg := agentsdk.NewRegistry()
+	require.NoError(t, reg.Register(tool))
+	a := agentsdk.NewAgent(p,
+		agentsdk.WithTools(reg),
+		agentsdk.WithApproval(func(context.Context, string, json.RawMessage) (bool, error) { return true, nil }),
+	)
+	ch, err := a.Turn(context.Background(), "count once")
+	require.NoError(t, err)
+	var text string
+	for evt := range ch {
+		if evt.Type == "text_delta" {
+			text += evt.Text
+		}
+		require.NotEqual(t, "error", evt.Type, "turn error: %v", evt.Error)
+	}
+	return text, tool.calls
+}
+
+func TestAgentSessionReplaysWithToolExecution(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	inner := &scriptedProvider{replies: [][]agentsdk.StreamEvent{
+		{
+			{Type: "tool_use", ToolUse: &agentsdk.ToolUseBlock{ID: "toolu_1", Name: "count"}},
+			{Type: "text_delta", Text: "{}"},
+			{Type: "stop", StopReason: "tool_use"},
+		},
+		{
+			{Type: "text_delta", Text: "Counted once."},
+			{Type: "stop", StopReason: "end_turn"},
+		},
+	}}
+	rec, err := NewRecorder(inner, path)
+	require.NoError(t, err)
+	text, calls := runSession(t, rec)
+	require.Equal(t, "Counted once.", text)
+	require.Equal(t, 1, calls)
+	require.Eventually(t, func() bool { return rec.Len() == 2 }, time.Second, time.Millisecond)
+
+	rp, err := Load(path)
+	require.NoError(t, err)
+	text, calls = runSession(t, rp)
+	assert.Equal(t, "Counted once.", text)
+	assert.Equal(t, 1, calls, "the tool runs again on replay")
+	assert.Equal(t, 0, rp.Remaining())
+}
@@ -0,0 +1,94 @@
+package cassette
+
+import (
+	"context"
+	"sync"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// Recorder is an agentsdk.LLMProvider that forwards every call to another
+// provider and appends the request and the events it produced to a
+// cassette file. The file is rewritten after each completed call, so an
+// interrupted session still leaves a usable cassette.
+type Recorder struct {
+	inner agentsdk.LLMProvider
+	path  string
+	opts  options
+
+	mu       sync.Mutex
+	cassette Cassette
+	err      error
+}
+
+// NewRecorder creates a Recorder that wraps inner and writes to path,
+// replacing any cassette already there.
+func NewRecorder(inner agentsdk.LLMProvider, path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		inner:    inner,
+		path:     path,
+		opts:     buildOptions(opts),
+		cassette: Cassette{Version: Version, Interactions: []Interaction{}},
+	}
+	// Write the empty cassette now so an unwritable path fails at startup
+	// rather than after the first paid call.
+	if err := r.cassette.Write(path); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Stream forwards req to the wrapped provider and records what it returns.
+func (r *Recorder) Stream(ctx context.Context, req agentsdk.CompletionRequest) (<-chan agentsdk.StreamEvent, error) {
+	normalized := r.opts.request(req)
+	in, err := r.inner.Stream(ctx, req)
+	if err != nil {
+		r.record(Interaction{Key: normalized.Key(), Request: normalized, Error: err.Error()})
+		return nil, err
+	}
+
+	out := make(chan agentsdk.StreamEvent)
+	go func() {
+		defer close(out)
+		var events []Event
+		forward := true
+		for evt := range in {
+			events = append(events, newEvent(evt))
+			if !forward {
+				continue
+			}
+			// A consumer that stops reading after cancellation must not
+			// strand the rest of the stream: keep draining so it is recorded.
+			select {
+			case out <- evt:
+			case <-ctx.Done():
+				forward = false
+			}
+		}
+		r.record(Interaction{Key: normalized.Key(), Request: normalized, Events: events})
+	}()
+	return out, nil
+}
+
+func (r *Recorder) record(it Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, it)
+	if err := r.cassette.Write(r.path); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns the first error hit while writing the cassette, if any.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Len returns the number of calls recorded so far.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cassette.Interactions)
+}
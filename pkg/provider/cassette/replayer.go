@@ -0,0 +1,138 @@
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// Replayer is an agentsdk.LLMProvider that answers from a cassette. Each
+// request is matched by normalized content against the interactions not
+// yet replayed; identical requests are answered in recording order.
+type Replayer struct {
+	opts options
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Load creates a Replayer from the cassette at path.
+func Load(path string, opts ...Option) (*Replayer, error) {
+	c, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(c, opts...), nil
+}
+
+// NewReplayer creates a Replayer from an in-memory cassette.
+func NewReplayer(c *Cassette, opts ...Option) *Replayer {
+	return &Replayer{
+		opts:         buildOptions(opts),
+		interactions: c.Interactions,
+		used:         make([]bool, len(c.Interactions)),
+	}
+}
+
+// Stream replays the recorded answer to req, or fails with a
+// *MismatchError when nothing recorded matches it.
+func (r *Replayer) Stream(ctx context.Context, req agentsdk.CompletionRequest) (<-chan agentsdk.StreamEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	normalized := r.opts.request(req)
+	key := normalized.Key()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next := -1
+	for i, it := range r.interactions {
+		if r.used[i] {
+			continue
+		}
+		if next < 0 {
+			next = i
+		}
+		// Keys are recomputed rather than trusted from the file so a
+		// hand-edited request still matches what it now says.
+		if it.Key == key || it.Request.Key() == key {
+			r.used[i] = true
+			if it.Error != "" {
+				return nil, fmt.Errorf("replayed: %s", it.Error)
+			}
+			return streamOf(it.Events), nil
+		}
+	}
+	mismatch := &MismatchError{Request: normalized}
+	if next >= 0 {
+		mismatch.Expected = &r.interactions[next].Request
+	}
+	return nil, mismatch
+}
+
+// Remaining returns how many recorded interactions have not been replayed.
+// A regression test can assert it is zero to catch a session that now
+// makes fewer calls than it used to.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, u := range r.used {
+		if !u {
+			n++
+		}
+	}
+	return n
+}
+
+// MismatchError reports a request that matches no unreplayed interaction.
+type MismatchError struct {
+	Request  Request
+	Expected *Request // the next unreplayed interaction, nil if none are left
+}
+
+func (e *MismatchError) Error() string {
+	if e.Expected == nil {
+		return "cassette: no recorded interaction left for request"
+	}
+	return "cassette: request does not match the recording: " + describeDiff(*e.Expected, e.Request)
+}
+
+// describeDiff names the first place where got departs from want.
+func describeDiff(want, got Request) string {
+	if want.System != got.System {
+		return "system prompt differs"
+	}
+	if toolList(want.Tools) != toolList(got.Tools) {
+		return fmt.Sprintf("tools differ: recorded [%s], got [%s]", toolList(want.Tools), toolList(got.Tools))
+	}
+	for i := 0; i < len(want.Messages) && i < len(got.Messages); i++ {
+		w, _ := json.Marshal(want.Messages[i])
+		g, _ := json.Marshal(got.Messages[i])
+		if string(w) != string(g) {
+			return fmt.Sprintf("message %d differs: recorded %s, got %s", i, truncate(string(w)), truncate(string(g)))
+		}
+	}
+	return fmt.Sprintf("recorded %d message(s), got %d", len(want.Messages), len(got.Messages))
+}
+
+func truncate(s string) string {
+	const max = 200
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "…"
+}
+
+func toolList(tools []Tool) string {
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		names[i] = t.String()
+	}
+	return strings.Join(names, ", ")
+}
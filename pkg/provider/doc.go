@@ -0,0 +1,19 @@
+// Package provider holds the HTTP plumbing shared by Rubichan's LLM
+// provider implementations: structured provider errors, bounded retry
+// (DoWithRetry), stream watchdogs, debug logging, and model capability
+// detection.
+//
+// The implementations themselves live in subpackages and satisfy
+// agentsdk.LLMProvider, so a program outside this module can stream from a
+// real model without copying any of them:
+//
+//	p := anthropic.New("https://api.anthropic.com", os.Getenv("ANTHROPIC_API_KEY"))
+//	agent := agentsdk.NewAgent(p, agentsdk.WithModel("claude-sonnet-4-5"))
+//
+// Subpackages: anthropic, openai (and any OpenAI-compatible endpoint),
+// ollama, zai, plus normalize and ssecompat, the request normalization and
+// SSE adapters they share.
+//
+// Configuration files, API key resolution, and provider selection by name
+// stay in the CLI; this package takes explicit base URLs and keys.
+package provider
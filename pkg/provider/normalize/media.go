@@ -0,0 +1,79 @@
+package normalize
+
+import (
+	"fmt"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// MediaSupport describes which media block types a provider can serialize
+// for the target model.
+type MediaSupport struct {
+	Images    bool
+	Documents bool
+}
+
+// ResolveMedia prepares image and document blocks for serialization. File
+// sources are read and inlined as base64; blocks the model cannot accept, or
+// whose file cannot be read, are replaced by a text block so the model still
+// sees that an attachment existed. Media attached to tool_result blocks is
+// resolved the same way. Returns the original slice unmodified when no
+// message carries media.
+func ResolveMedia(msgs []agentsdk.Message, support MediaSupport) []agentsdk.Message {
+	if !hasMedia(msgs) {
+		return msgs
+	}
+	out := make([]agentsdk.Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = agentsdk.Message{Role: m.Role, Content: resolveBlocks(m.Content, support), Metadata: m.Metadata}
+	}
+	return out
+}
+
+func resolveBlocks(in []agentsdk.ContentBlock, support MediaSupport) []agentsdk.ContentBlock {
+	blocks := make([]agentsdk.ContentBlock, 0, len(in))
+	for _, b := range in {
+		switch {
+		case agentsdk.IsMediaBlock(b):
+			b = resolveMediaBlock(b, support)
+		case len(b.Content) > 0:
+			b.Content = resolveBlocks(b.Content, support)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+func resolveMediaBlock(b agentsdk.ContentBlock, support MediaSupport) agentsdk.ContentBlock {
+	allowed := support.Images
+	if b.Type == agentsdk.BlockTypeDocument {
+		allowed = support.Documents
+	}
+	if !allowed {
+		return agentsdk.ContentBlock{Type: agentsdk.BlockTypeText, Text: agentsdk.MediaPlaceholder(b)}
+	}
+	src, err := agentsdk.ResolveMediaSource(b.Source)
+	if err != nil {
+		return agentsdk.ContentBlock{Type: agentsdk.BlockTypeText, Text: fmt.Sprintf("[%s unavailable: %v]", b.Type, err)}
+	}
+	b.Source = src
+	return b
+}
+
+func hasMedia(msgs []agentsdk.Message) bool {
+	for _, m := range msgs {
+		if blocksHaveMedia(m.Content) {
+			return true
+		}
+	}
+	return false
+}
+
+func blocksHaveMedia(blocks []agentsdk.ContentBlock) bool {
+	for _, b := range blocks {
+		if agentsdk.IsMediaBlock(b) || blocksHaveMedia(b.Content) {
+			return true
+		}
+	}
+	return false
+}
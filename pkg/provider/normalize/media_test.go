@@ -0,0 +1,77 @@
+package normalize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveMedia_NoMediaReturnsInput(t *testing.T) {
+	msgs := []agentsdk.Message{msg("user", textBlock("hello"))}
+	out := ResolveMedia(msgs, MediaSupport{})
+	assert.Equal(t, msgs, out)
+}
+
+func TestResolveMedia_InlinesFileSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shot.png")
+	require.NoError(t, os.WriteFile(path, []byte("png-bytes"), 0o644))
+	img, err := agentsdk.NewMediaFileBlock(path)
+	require.NoError(t, err)
+
+	msgs := []agentsdk.Message{msg("user", textBlock("look"), img)}
+	out := ResolveMedia(msgs, MediaSupport{Images: true})
+
+	require.Len(t, out[0].Content, 2)
+	got := out[0].Content[1]
+	assert.Equal(t, agentsdk.BlockTypeImage, got.Type)
+	require.NotNil(t, got.Source)
+	assert.Equal(t, agentsdk.MediaSourceBase64, got.Source.Type)
+	assert.Equal(t, "cG5nLWJ5dGVz", got.Source.Data)
+	// The input conversation keeps its file reference.
+	assert.Equal(t, agentsdk.MediaSourceFile, msgs[0].Content[1].Source.Type)
+}
+
+func TestResolveMedia_UnsupportedBecomesPlaceholder(t *testing.T) {
+	msgs := []agentsdk.Message{msg("user",
+		agentsdk.NewImageBlock("image/png", []byte("x")),
+		agentsdk.NewDocumentBlock("application/pdf", []byte("y")),
+	)}
+	out := ResolveMedia(msgs, MediaSupport{Images: true})
+
+	assert.Equal(t, agentsdk.BlockTypeImage, out[0].Content[0].Type)
+	assert.Equal(t, agentsdk.BlockTypeText, out[0].Content[1].Type)
+	assert.Contains(t, out[0].Content[1].Text, "document omitted")
+}
+
+func TestResolveMedia_UnreadableFileBecomesPlaceholder(t *testing.T) {
+	img, err := agentsdk.NewMediaFileBlock(filepath.Join(t.TempDir(), "gone.png"))
+	require.NoError(t, err)
+
+	out := ResolveMedia([]agentsdk.Message{msg("user", img)}, MediaSupport{Images: true})
+	assert.Equal(t, agentsdk.BlockTypeText, out[0].Content[0].Type)
+	assert.Contains(t, out[0].Content[0].Text, "image unavailable")
+}
+
+func TestResolveMedia_ToolResultMedia(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shot.png")
+	require.NoError(t, os.WriteFile(path, []byte("png-bytes"), 0o644))
+	img, err := agentsdk.NewMediaFileBlock(path)
+	require.NoError(t, err)
+	msgs := []agentsdk.Message{agentsdk.NewToolResultMessage("t1", "saved", false, img)}
+
+	out := ResolveMedia(msgs, MediaSupport{Images: true})
+	nested := out[0].Content[0].Content
+	require.Len(t, nested, 1)
+	assert.Equal(t, "cG5nLWJ5dGVz", nested[0].Source.Data)
+	assert.Equal(t, agentsdk.MediaSourceFile, msgs[0].Content[0].Content[0].Source.Type)
+
+	out = ResolveMedia(msgs, MediaSupport{})
+	nested = out[0].Content[0].Content
+	require.Len(t, nested, 1)
+	assert.Equal(t, agentsdk.BlockTypeText, nested[0].Type)
+	assert.Contains(t, nested[0].Text, "omitted")
+}
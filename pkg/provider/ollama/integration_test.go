@@ -6,7 +6,7 @@ import (
 	"context"
 	"testing"
 
-	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/pkg/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -11,70 +11,11 @@ import (
 	"strings"
 	"sync/atomic"
 
-	"github.com/julianshen/rubichan/internal/config"
-	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
+	"github.com/julianshen/rubichan/pkg/provider/normalize"
 )
 
-func init() {
-	provider.Default.Register(providerDef())
-}
-
-// providerDef describes this provider's construction, auth, default model,
-// and model listing for provider.Default. Exposed as a function so tests
-// can exercise it directly, isolated from the shared provider.Default
-// registry.
-func providerDef() provider.ProviderDef {
-	return provider.ProviderDef{
-		ID: "ollama",
-		Constructor: func(baseURL, _ string, _ map[string]string) provider.LLMProvider {
-			return New(baseURL)
-		},
-		BaseURL: func(cfg *config.Config) string {
-			return resolveBaseURL(cfg)
-		},
-		Auth: func(cfg *config.Config) (string, map[string]string, error) {
-			return "", nil, nil // local server, no credentials
-		},
-		DefaultModel: resolveDefaultModel,
-		ListModels:   listModels,
-	}
-}
-
-func resolveBaseURL(cfg *config.Config) string {
-	if cfg.Provider.Ollama.BaseURL != "" {
-		return cfg.Provider.Ollama.BaseURL
-	}
-	return DefaultBaseURL
-}
-
-// resolveDefaultModel queries Ollama for available models and resolves
-// which model to use. With a single model it auto-selects; with multiple,
-// it returns the first (a future TUI picker would use listModels/ListModels
-// directly instead of this auto-select).
-func resolveDefaultModel(ctx context.Context, cfg *config.Config) (string, error) {
-	models, err := listModels(ctx, cfg)
-	if err != nil {
-		return "", err
-	}
-	if len(models) == 0 {
-		return "", fmt.Errorf("no models found; run 'rubichan ollama pull <model>' first")
-	}
-	return models[0].ID, nil
-}
-
-func listModels(ctx context.Context, cfg *config.Config) ([]provider.Model, error) {
-	client := NewClient(resolveBaseURL(cfg))
-	infos, err := client.ListModels(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("listing Ollama models: %w", err)
-	}
-	models := make([]provider.Model, len(infos))
-	for i, info := range infos {
-		models[i] = provider.Model{ID: info.Name, Name: info.Name}
-	}
-	return models, nil
-}
-
 // Provider implements the LLMProvider interface for Ollama (local LLM server).
 type Provider struct {
 	baseURL     string
@@ -140,6 +81,7 @@ type apiOptions struct {
 type apiMessage struct {
 	Role       string        `json:"role"`
 	Content    string        `json:"content"`
+	Images     []string      `json:"images,omitempty"` // raw base64, no data: prefix
 	ToolCalls  []apiToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string        `json:"tool_call_id,omitempty"`
 }
@@ -169,6 +111,9 @@ type streamChunk struct {
 	Model   string       `json:"model"`
 	Message chunkMessage `json:"message"`
 	Done    bool         `json:"done"`
+	// Token counts, reported on the final (done) chunk only.
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
 }
 
 type chunkMessage struct {
@@ -258,8 +203,12 @@ func (p *Provider) buildRequestBody(req provider.CompletionRequest) ([]byte, err
 		})
 	}
 
-	// Convert messages
-	for _, msg := range req.Messages {
+	// Convert messages. Ollama has no document input, so PDFs always
+	// degrade to a placeholder; images pass through for vision models.
+	messages := normalize.ResolveMedia(req.Messages, normalize.MediaSupport{
+		Images: req.Capabilities.SupportsVision,
+	})
+	for _, msg := range messages {
 		apiReq.Messages = append(apiReq.Messages, p.convertMessages(msg)...)
 	}
 
@@ -331,33 +280,51 @@ func (p *Provider) convertAssistantMessage(msg provider.Message) apiMessage {
 }
 
 // convertUserMessages handles user messages that may contain tool_result blocks.
+// Image blocks, including those attached to tool results, ride on the
+// trailing user message's images field, since "tool" messages cannot carry
+// them.
 func (p *Provider) convertUserMessages(msg provider.Message) []apiMessage {
 	var toolResults []apiMessage
 	var texts []string
+	var images []string
 
 	for _, block := range msg.Content {
 		switch block.Type {
 		case "tool_result":
+			text := block.Text
+			for _, nested := range block.Content {
+				switch {
+				case nested.Type == agentsdk.BlockTypeText && nested.Text != "":
+					text += "\n" + nested.Text
+				case nested.Type == agentsdk.BlockTypeImage && nested.Source != nil && nested.Source.Data != "":
+					images = append(images, nested.Source.Data)
+				}
+			}
 			toolResults = append(toolResults, apiMessage{
 				Role:       "tool",
-				Content:    block.Text,
+				Content:    text,
 				ToolCallID: block.ToolUseID,
 			})
 		case "text":
 			if block.Text != "" {
 				texts = append(texts, block.Text)
 			}
+		case agentsdk.BlockTypeImage:
+			if block.Source != nil && block.Source.Data != "" {
+				images = append(images, block.Source.Data)
+			}
 		}
 	}
 
 	if len(toolResults) > 0 {
 		// Preserve any text blocks alongside tool results.
-		if len(texts) > 0 {
+		if len(texts) > 0 || len(images) > 0 {
 			msgs := make([]apiMessage, 0, len(toolResults)+1)
 			msgs = append(msgs, toolResults...)
 			msgs = append(msgs, apiMessage{
 				Role:    "user",
 				Content: strings.Join(texts, ""),
+				Images:  images,
 			})
 			return msgs
 		}
@@ -367,6 +334,7 @@ func (p *Provider) convertUserMessages(msg provider.Message) []apiMessage {
 	return []apiMessage{{
 		Role:    "user",
 		Content: strings.Join(texts, ""),
+		Images:  images,
 	}}
 }
 
@@ -456,7 +424,11 @@ func (p *Provider) processStream(ctx context.Context, body io.ReadCloser, ch cha
 		if chunk.Done {
 			gotDone = true
 			select {
-			case ch <- provider.StreamEvent{Type: "stop"}:
+			case ch <- provider.StreamEvent{
+				Type:         "stop",
+				InputTokens:  chunk.PromptEvalCount,
+				OutputTokens: chunk.EvalCount,
+			}:
 			case <-ctx.Done():
 			}
 			break
@@ -11,9 +11,9 @@ import (
 	"testing"
 	"time"
 
-	"github.com/julianshen/rubichan/internal/config"
-	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/internal/testutil"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -906,94 +906,105 @@ func TestConvertUserMessagesStripsEmptyText(t *testing.T) {
 	assert.Equal(t, "helloworld", msgs[0].Content)
 }
 
-func TestResolveDefaultModel_SingleModel(t *testing.T) {
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte(`{"models": [{"name": "llama3.2:latest", "size": 4294967296}]}`))
-	}))
-	defer srv.Close()
+func TestBuildRequestBodyWithImages(t *testing.T) {
+	p := New("http://localhost:11434")
 
-	cfg := config.DefaultConfig()
-	cfg.Provider.Ollama.BaseURL = srv.URL
+	req := provider.CompletionRequest{
+		Model:        "llava:13b",
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages: []provider.Message{{
+			Role: "user",
+			Content: []provider.ContentBlock{
+				{Type: "text", Text: "what is this?"},
+				agentsdk.NewImageBlock("image/png", []byte("png-bytes")),
+				agentsdk.NewDocumentBlock("application/pdf", []byte("%PDF-1.7")),
+			},
+		}},
+	}
 
-	model, err := resolveDefaultModel(context.Background(), cfg)
+	body, err := p.buildRequestBody(req)
 	require.NoError(t, err)
-	assert.Equal(t, "llama3.2:latest", model)
-}
-
-func TestResolveDefaultModel_NoModels(t *testing.T) {
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte(`{"models": []}`))
-	}))
-	defer srv.Close()
 
-	cfg := config.DefaultConfig()
-	cfg.Provider.Ollama.BaseURL = srv.URL
-
-	_, err := resolveDefaultModel(context.Background(), cfg)
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "no models found")
+	var parsed apiRequest
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	require.Len(t, parsed.Messages, 1)
+	assert.Equal(t, []string{"cG5nLWJ5dGVz"}, parsed.Messages[0].Images)
+	// Ollama has no document input; the PDF degrades to a placeholder.
+	assert.Contains(t, parsed.Messages[0].Content, "what is this?")
+	assert.Contains(t, parsed.Messages[0].Content, "document omitted")
 }
 
-func TestResolveDefaultModel_MultipleModels(t *testing.T) {
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte(`{"models": [
-			{"name": "llama3.2:latest", "size": 4294967296},
-			{"name": "codellama:7b", "size": 3758096384}
-		]}`))
-	}))
-	defer srv.Close()
+func TestBuildRequestBodyImagesWithoutVision(t *testing.T) {
+	p := New("http://localhost:11434")
 
-	cfg := config.DefaultConfig()
-	cfg.Provider.Ollama.BaseURL = srv.URL
+	req := provider.CompletionRequest{
+		Model: "llama3",
+		Messages: []provider.Message{{
+			Role:    "user",
+			Content: []provider.ContentBlock{agentsdk.NewImageBlock("image/png", []byte("png-bytes"))},
+		}},
+	}
 
-	model, err := resolveDefaultModel(context.Background(), cfg)
+	body, err := p.buildRequestBody(req)
 	require.NoError(t, err)
-	assert.Equal(t, "llama3.2:latest", model) // returns first model
-}
-
-func TestResolveDefaultModel_ConnectionError(t *testing.T) {
-	cfg := config.DefaultConfig()
-	cfg.Provider.Ollama.BaseURL = "http://localhost:1"
 
-	_, err := resolveDefaultModel(context.Background(), cfg)
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "listing Ollama models")
+	var parsed apiRequest
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	require.Len(t, parsed.Messages, 1)
+	assert.Empty(t, parsed.Messages[0].Images)
+	assert.Contains(t, parsed.Messages[0].Content, "image omitted")
 }
 
-func TestListModels(t *testing.T) {
-	srv := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte(`{"models": [{"name": "llama3.2:latest", "size": 1}]}`))
-	}))
-	defer srv.Close()
+func TestBuildRequestBodyToolResultImages(t *testing.T) {
+	p := New("http://localhost:11434")
 
-	cfg := config.DefaultConfig()
-	cfg.Provider.Ollama.BaseURL = srv.URL
+	req := provider.CompletionRequest{
+		Model:        "llava:13b",
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages: []provider.Message{
+			provider.NewToolResultMessage("call_1", "screenshot saved", false,
+				agentsdk.NewImageBlock("image/png", []byte("png-bytes"))),
+		},
+	}
 
-	models, err := listModels(context.Background(), cfg)
+	body, err := p.buildRequestBody(req)
 	require.NoError(t, err)
-	assert.Equal(t, []provider.Model{{ID: "llama3.2:latest", Name: "llama3.2:latest"}}, models)
-}
-
-func TestProviderDef_BaseURLDefault(t *testing.T) {
-	def := providerDef()
-	cfg := config.DefaultConfig()
 
-	assert.Equal(t, DefaultBaseURL, def.BaseURL(cfg))
+	var parsed apiRequest
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	require.Len(t, parsed.Messages, 2)
+	assert.Equal(t, "tool", parsed.Messages[0].Role)
+	assert.Equal(t, "screenshot saved", parsed.Messages[0].Content)
+	assert.Equal(t, "user", parsed.Messages[1].Role)
+	assert.Equal(t, []string{"cG5nLWJ5dGVz"}, parsed.Messages[1].Images)
 }
 
-func TestProviderDef_BaseURLOverride(t *testing.T) {
-	def := providerDef()
-	cfg := config.DefaultConfig()
-	cfg.Provider.Ollama.BaseURL = "http://custom-ollama:1234"
-
-	assert.Equal(t, "http://custom-ollama:1234", def.BaseURL(cfg))
-}
+func TestStreamReportsTokenCountsOnStop(t *testing.T) {
+	server := testutil.NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"hi"},"done":false}` + "\n"))
+		_, _ = w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":42,"eval_count":7}` + "\n"))
+	}))
+	defer server.Close()
 
-func TestProviderDef_AuthIsKeyless(t *testing.T) {
-	def := providerDef()
+	p := New(server.URL)
+	p.SetHTTPClient(&http.Client{})
 
-	apiKey, headers, err := def.Auth(config.DefaultConfig())
+	ch, err := p.Stream(context.Background(), provider.CompletionRequest{
+		Model:    "llama3",
+		Messages: []provider.Message{provider.NewUserMessage("hi")},
+	})
 	require.NoError(t, err)
-	assert.Empty(t, apiKey)
-	assert.Nil(t, headers)
+
+	var stop *provider.StreamEvent
+	for ev := range ch {
+		if ev.Type == "stop" {
+			ev := ev
+			stop = &ev
+		}
+	}
+	require.NotNil(t, stop)
+	assert.Equal(t, 42, stop.InputTokens)
+	assert.Equal(t, 7, stop.OutputTokens)
 }
@@ -0,0 +1,102 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/julianshen/rubichan/pkg/provider"
+	"github.com/julianshen/rubichan/pkg/provider/ssecompat"
+)
+
+// Provider implements the LLMProvider interface for OpenAI-compatible APIs.
+type Provider struct {
+	baseURL      string
+	apiKey       string
+	extraHeaders map[string]string
+	client       *http.Client
+	transformer  Transformer
+	debugLogger  provider.DebugLogger
+}
+
+// SetDebugLogger enables debug logging for API requests and responses.
+func (p *Provider) SetDebugLogger(logger provider.DebugLogger) {
+	p.debugLogger = logger
+}
+
+// New creates a new OpenAI-compatible provider.
+func New(baseURL, apiKey string, extraHeaders map[string]string) *Provider {
+	if extraHeaders == nil {
+		extraHeaders = make(map[string]string)
+	}
+	return &Provider{
+		baseURL:      baseURL,
+		apiKey:       apiKey,
+		extraHeaders: extraHeaders,
+		client:       provider.NewHTTPClient(),
+	}
+}
+
+// SetStreamUsage controls whether requests ask for a final usage chunk
+// (stream_options.include_usage). It is on by default; turn it off for
+// OpenAI-compatible servers that reject the field.
+func (p *Provider) SetStreamUsage(enabled bool) {
+	p.transformer.Quirks.NoStreamUsage = !enabled
+}
+
+// StreamUsage reports whether requests ask for a final usage chunk.
+func (p *Provider) StreamUsage() bool {
+	return !p.transformer.Quirks.NoStreamUsage
+}
+
+// SetHTTPClient replaces the default HTTP client. This is intended for
+// testing with custom transports (e.g. in-memory mem:// servers).
+func (p *Provider) SetHTTPClient(c *http.Client) {
+	p.client = c
+}
+
+// Stream sends a completion request to the OpenAI-compatible API and returns a
+// channel of StreamEvents.
+func (p *Provider) Stream(ctx context.Context, req provider.CompletionRequest) (<-chan provider.StreamEvent, error) {
+	body, err := p.transformer.ToProviderJSON(req)
+	if err != nil {
+		return nil, fmt.Errorf("building request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	for k, v := range p.extraHeaders {
+		httpReq.Header.Set(k, v)
+	}
+
+	provider.LogRequest(p.debugLogger, httpReq, body)
+
+	resp, err := provider.DoWithRetry(ctx, p.client, httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		provider.LogResponse(p.debugLogger, resp.StatusCode, resp.Header, respBody)
+		return nil, provider.ClassifyAPIErrorWithResponse(resp.StatusCode, respBody, httpReq, "openai", resp.Header)
+	}
+
+	if p.debugLogger != nil {
+		p.debugLogger("[DEBUG] <<< HTTP Response: %d %s (streaming)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	ch := make(chan provider.StreamEvent)
+	go ssecompat.ProcessSSE(ctx, resp.Body, ch, "openai")
+
+	return ch, nil
+}
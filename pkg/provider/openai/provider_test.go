@@ -10,44 +10,13 @@ import (
 	"testing"
 	"time"
 
-	"github.com/julianshen/rubichan/internal/config"
-	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/internal/testutil"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestProviderDef_BaseURLAndAuth(t *testing.T) {
-	def := providerDef()
-	cfg := config.DefaultConfig()
-	cfg.Provider.Default = "openrouter"
-	cfg.Provider.OpenAI = []config.OpenAICompatibleConfig{
-		{Name: "openrouter", BaseURL: "https://openrouter.ai/api/v1", APIKeySource: "config", APIKey: "test-key", ExtraHeaders: map[string]string{"X-Test": "1"}},
-	}
-
-	assert.Equal(t, "https://openrouter.ai/api/v1", def.BaseURL(cfg))
-
-	apiKey, headers, err := def.Auth(cfg)
-	require.NoError(t, err)
-	assert.Equal(t, "test-key", apiKey)
-	assert.Equal(t, map[string]string{"X-Test": "1"}, headers)
-}
-
-func TestProviderDef_AuthUnknownProvider(t *testing.T) {
-	def := providerDef()
-	cfg := config.DefaultConfig()
-	cfg.Provider.Default = "does-not-exist"
-
-	_, _, err := def.Auth(cfg)
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), `"does-not-exist"`)
-}
-
-func TestProviderDef_DefaultModelIsNil(t *testing.T) {
-	def := providerDef()
-	assert.Nil(t, def.DefaultModel)
-}
-
 func TestStreamTextResponse(t *testing.T) {
 	sseBody := `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4","choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}
 
@@ -876,3 +845,191 @@ func TestTransformer_Quirks_InsertAssistant(t *testing.T) {
 	}
 	assert.Contains(t, roles, "assistant", "should insert filler assistant message")
 }
+
+// --- Media tests ---
+
+func TestTransformer_ImageBlockBecomesContentParts(t *testing.T) {
+	tr := &Transformer{}
+	req := provider.CompletionRequest{
+		Model:        "gpt-4o",
+		MaxTokens:    1024,
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages: []provider.Message{{
+			Role: "user",
+			Content: []provider.ContentBlock{
+				{Type: "text", Text: "what is this?"},
+				agentsdk.NewImageBlock("image/png", []byte("png-bytes")),
+			},
+		}},
+	}
+
+	body, err := tr.ToProviderJSON(req)
+	require.NoError(t, err)
+
+	var parsed struct {
+		Messages []struct {
+			Content []apiContentPart `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	require.Len(t, parsed.Messages, 1)
+	parts := parsed.Messages[0].Content
+	require.Len(t, parts, 2)
+	assert.Equal(t, "text", parts[0].Type)
+	assert.Equal(t, "what is this?", parts[0].Text)
+	assert.Equal(t, "image_url", parts[1].Type)
+	require.NotNil(t, parts[1].ImageURL)
+	assert.Equal(t, "data:image/png;base64,cG5nLWJ5dGVz", parts[1].ImageURL.URL)
+}
+
+func TestTransformer_DocumentBlockBecomesFilePart(t *testing.T) {
+	tr := &Transformer{}
+	doc := agentsdk.NewDocumentBlock("application/pdf", []byte("%PDF-1.7"))
+	doc.Name = "spec.pdf"
+	req := provider.CompletionRequest{
+		Model:        "gpt-4o",
+		MaxTokens:    1024,
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages: []provider.Message{{
+			Role:    "user",
+			Content: []provider.ContentBlock{doc},
+		}},
+	}
+
+	body, err := tr.ToProviderJSON(req)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"type":"file"`)
+	assert.Contains(t, string(body), `"filename":"spec.pdf"`)
+	assert.Contains(t, string(body), `data:application/pdf;base64,`)
+}
+
+func TestTransformer_ImageWithoutVisionBecomesPlaceholder(t *testing.T) {
+	tr := &Transformer{}
+	req := provider.CompletionRequest{
+		Model:     "gpt-3.5-turbo",
+		MaxTokens: 1024,
+		Messages: []provider.Message{{
+			Role:    "user",
+			Content: []provider.ContentBlock{agentsdk.NewImageBlock("image/png", []byte("png-bytes"))},
+		}},
+	}
+
+	body, err := tr.ToProviderJSON(req)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "image_url")
+	assert.Contains(t, string(body), "image omitted")
+}
+
+func TestTransformer_Quirks_NoDocuments(t *testing.T) {
+	tr := &Transformer{Quirks: Quirks{NoDocuments: true}}
+	req := provider.CompletionRequest{
+		Model:        "glm-4.5v",
+		MaxTokens:    1024,
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages: []provider.Message{{
+			Role: "user",
+			Content: []provider.ContentBlock{
+				agentsdk.NewImageBlock("image/png", []byte("png-bytes")),
+				agentsdk.NewDocumentBlock("application/pdf", []byte("%PDF-1.7")),
+			},
+		}},
+	}
+
+	body, err := tr.ToProviderJSON(req)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "image_url")
+	assert.NotContains(t, string(body), `"type":"file"`)
+	assert.Contains(t, string(body), "document omitted")
+}
+
+func TestTransformer_ImageAlongsideToolResults(t *testing.T) {
+	tr := &Transformer{}
+	req := provider.CompletionRequest{
+		Model:        "gpt-4o",
+		MaxTokens:    1024,
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages: []provider.Message{{
+			Role: "user",
+			Content: []provider.ContentBlock{
+				{Type: "tool_result", ToolUseID: "call_1", Text: "screenshot saved"},
+				agentsdk.NewImageBlock("image/png", []byte("png-bytes")),
+			},
+		}},
+	}
+
+	body, err := tr.ToProviderJSON(req)
+	require.NoError(t, err)
+
+	var parsed struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	require.Len(t, parsed.Messages, 2)
+	assert.Equal(t, "tool", parsed.Messages[0].Role)
+	assert.Equal(t, "user", parsed.Messages[1].Role)
+	assert.Contains(t, string(parsed.Messages[1].Content), "image_url")
+}
+
+func TestTransformer_ToolResultMedia(t *testing.T) {
+	tr := &Transformer{}
+	msgs := []provider.Message{
+		provider.NewToolResultMessage("call_1", "screenshot saved", false,
+			agentsdk.NewImageBlock("image/png", []byte("png-bytes"))),
+	}
+
+	body, err := tr.ToProviderJSON(provider.CompletionRequest{
+		Model:        "gpt-4o",
+		Capabilities: provider.ModelCapabilities{SupportsVision: true},
+		Messages:     msgs,
+	})
+	require.NoError(t, err)
+	var parsed struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	require.Len(t, parsed.Messages, 2)
+	assert.Equal(t, "tool", parsed.Messages[0].Role)
+	assert.JSONEq(t, `"screenshot saved"`, string(parsed.Messages[0].Content))
+	assert.Equal(t, "user", parsed.Messages[1].Role)
+	assert.Contains(t, string(parsed.Messages[1].Content), "data:image/png;base64,cG5nLWJ5dGVz")
+
+	// Without vision the image folds into the tool message as a placeholder.
+	body, err = tr.ToProviderJSON(provider.CompletionRequest{Model: "gpt-3.5-turbo", Messages: msgs})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	require.Len(t, parsed.Messages, 1)
+	assert.Contains(t, string(parsed.Messages[0].Content), "image omitted")
+}
+
+func TestToProviderJSONRequestsStreamUsage(t *testing.T) {
+	var tr Transformer
+	body, err := tr.ToProviderJSON(provider.CompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{provider.NewUserMessage("hi")},
+	})
+	require.NoError(t, err)
+
+	var parsed struct {
+		StreamOptions struct {
+			IncludeUsage bool `json:"include_usage"`
+		} `json:"stream_options"`
+	}
+	require.NoError(t, json.Unmarshal(body, &parsed))
+	assert.True(t, parsed.StreamOptions.IncludeUsage)
+}
+
+func TestToProviderJSONNoStreamUsageQuirk(t *testing.T) {
+	tr := Transformer{Quirks: Quirks{NoStreamUsage: true}}
+	body, err := tr.ToProviderJSON(provider.CompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{provider.NewUserMessage("hi")},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "stream_options")
+}
@@ -5,8 +5,9 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/julianshen/rubichan/internal/provider"
-	"github.com/julianshen/rubichan/internal/provider/normalize"
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
+	"github.com/julianshen/rubichan/pkg/provider/normalize"
 )
 
 // API wire-format types for OpenAI Chat Completions endpoint.
@@ -18,6 +19,12 @@ type apiRequest struct {
 	MaxTokens   int          `json:"max_tokens"`
 	Temperature *float64     `json:"temperature,omitempty"`
 	Stream      bool         `json:"stream"`
+	// StreamOptions asks for a final usage chunk so calls can be priced.
+	StreamOptions *apiStreamOptions `json:"stream_options,omitempty"`
+}
+
+type apiStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type apiMessage struct {
@@ -27,6 +34,25 @@ type apiMessage struct {
 	ToolCallID string        `json:"tool_call_id,omitempty"`
 }
 
+// apiContentPart is one element of a multi-part user message. Only used when
+// the message carries media; text-only messages keep the plain string form
+// that every OpenAI-compatible server accepts.
+type apiContentPart struct {
+	Type     string       `json:"type"`
+	Text     string       `json:"text,omitempty"`
+	ImageURL *apiImageURL `json:"image_url,omitempty"`
+	File     *apiFile     `json:"file,omitempty"`
+}
+
+type apiImageURL struct {
+	URL string `json:"url"`
+}
+
+type apiFile struct {
+	Filename string `json:"filename"`
+	FileData string `json:"file_data"`
+}
+
 type apiTool struct {
 	Type     string      `json:"type"`
 	Function apiFunction `json:"function"`
@@ -59,6 +85,13 @@ type Quirks struct {
 	// InsertAssistantAfterTool inserts a filler assistant message between
 	// tool results and user messages for providers that require it.
 	InsertAssistantAfterTool bool
+	// NoDocuments downgrades document blocks to a text placeholder for
+	// providers that accept image parts but not inline file parts.
+	NoDocuments bool
+	// NoStreamUsage omits stream_options.include_usage for backends that
+	// reject unknown request fields. Such calls are priced only if the
+	// backend reports usage unasked.
+	NoStreamUsage bool
 }
 
 // Transformer implements provider.MessageTransformer for OpenAI-compatible APIs.
@@ -74,6 +107,9 @@ func (t *Transformer) ToProviderJSON(req provider.CompletionRequest) ([]byte, er
 		MaxTokens: req.MaxTokens,
 		Stream:    true,
 	}
+	if !t.Quirks.NoStreamUsage {
+		apiReq.StreamOptions = &apiStreamOptions{IncludeUsage: true}
+	}
 
 	if req.Temperature != nil {
 		temp := *req.Temperature
@@ -102,6 +138,10 @@ func (t *Transformer) ToProviderJSON(req provider.CompletionRequest) ([]byte, er
 	if t.Quirks.InsertAssistantAfterTool {
 		messages = normalize.InsertAssistantBetweenToolAndUser(messages)
 	}
+	messages = normalize.ResolveMedia(messages, normalize.MediaSupport{
+		Images:    req.Capabilities.SupportsVision,
+		Documents: req.Capabilities.SupportsVision && !t.Quirks.NoDocuments,
+	})
 
 	// Convert messages.
 	for _, msg := range messages {
@@ -187,31 +227,44 @@ func convertAssistantMessage(msg provider.Message) apiMessage {
 
 // convertUserMessages handles user messages that may contain multiple tool_result
 // blocks. Each tool_result becomes a separate "tool" message in the OpenAI format.
+// Image and document blocks, including those attached to tool results, turn
+// the trailing user message into a multi-part content array, since "tool"
+// messages cannot carry media.
 func convertUserMessages(msg provider.Message) []apiMessage {
 	var toolResults []apiMessage
 	var texts []string
+	var media []apiContentPart
 
 	for _, block := range msg.Content {
 		switch block.Type {
 		case "tool_result":
 			toolResults = append(toolResults, apiMessage{
 				Role:       "tool",
-				Content:    block.Text,
+				Content:    toolResultText(block),
 				ToolCallID: block.ToolUseID,
 			})
+			for _, nested := range block.Content {
+				if part, ok := convertMediaPart(nested); ok {
+					media = append(media, part)
+				}
+			}
 		case "text":
 			if block.Text != "" {
 				texts = append(texts, block.Text)
 			}
+		case agentsdk.BlockTypeImage, agentsdk.BlockTypeDocument:
+			if part, ok := convertMediaPart(block); ok {
+				media = append(media, part)
+			}
 		}
 	}
 
 	if len(toolResults) > 0 {
 		// Preserve any text blocks alongside tool results.
-		if len(texts) > 0 {
+		if len(texts) > 0 || len(media) > 0 {
 			toolResults = append(toolResults, apiMessage{
 				Role:    "user",
-				Content: strings.Join(texts, ""),
+				Content: userContent(texts, media),
 			})
 		}
 		return toolResults
@@ -219,6 +272,54 @@ func convertUserMessages(msg provider.Message) []apiMessage {
 
 	return []apiMessage{{
 		Role:    "user",
-		Content: strings.Join(texts, ""),
+		Content: userContent(texts, media),
 	}}
 }
+
+// userContent returns the plain joined string when there is no media, and a
+// text-first part array otherwise.
+func userContent(texts []string, media []apiContentPart) any {
+	joined := strings.Join(texts, "")
+	if len(media) == 0 {
+		return joined
+	}
+	parts := make([]apiContentPart, 0, len(media)+1)
+	if joined != "" {
+		parts = append(parts, apiContentPart{Type: "text", Text: joined})
+	}
+	return append(parts, media...)
+}
+
+// toolResultText returns a tool result's text followed by the text blocks
+// ResolveMedia left in place of media the model cannot accept.
+func toolResultText(block provider.ContentBlock) string {
+	text := block.Text
+	for _, nested := range block.Content {
+		if nested.Type == agentsdk.BlockTypeText && nested.Text != "" {
+			text += "\n" + nested.Text
+		}
+	}
+	return text
+}
+
+// convertMediaPart maps a resolved image or document block to its
+// Chat Completions content part.
+func convertMediaPart(block provider.ContentBlock) (apiContentPart, bool) {
+	if block.Source == nil || block.Source.Data == "" {
+		return apiContentPart{}, false
+	}
+	if block.Type == agentsdk.BlockTypeDocument {
+		filename := block.Name
+		if filename == "" {
+			filename = "document.pdf"
+		}
+		return apiContentPart{
+			Type: "file",
+			File: &apiFile{Filename: filename, FileData: block.Source.DataURL()},
+		}, true
+	}
+	return apiContentPart{
+		Type:     "image_url",
+		ImageURL: &apiImageURL{URL: block.Source.DataURL()},
+	}, true
+}
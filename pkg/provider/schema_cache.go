@@ -1,4 +1,4 @@
-package tools
+package provider
 
 import (
 	"crypto/sha256"
@@ -10,8 +10,8 @@ import (
 	"io"
 	"strings"
 
-	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
 )
 
 // chatChunk represents a single SSE chunk from an OpenAI-compatible API.
@@ -19,6 +19,36 @@ type chatChunk struct {
 	ID      string        `json:"id"`
 	Model   string        `json:"model"`
 	Choices []chunkChoice `json:"choices"`
+	Usage   *chunkUsage   `json:"usage"`
+}
+
+// chunkUsage is the usage object sent in the final chunk when the request
+// sets stream_options.include_usage. prompt_tokens includes cached tokens.
+type chunkUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+// stopEvent builds the terminal stop event, carrying usage when the server
+// reported it. Cached tokens are split out of InputTokens so usage matches
+// the Anthropic convention the rest of the agent prices against.
+func stopEvent(usage *chunkUsage) provider.StreamEvent {
+	ev := provider.StreamEvent{Type: "stop"}
+	if usage == nil {
+		return ev
+	}
+	cached := usage.PromptTokensDetails.CachedTokens
+	ev.InputTokens = usage.PromptTokens - cached
+	ev.CacheReadTokens = cached
+	ev.OutputTokens = usage.CompletionTokens
+	ev.ReasoningTokens = usage.CompletionTokensDetails.ReasoningTokens
+	return ev
 }
 
 type chunkChoice struct {
@@ -126,6 +156,7 @@ func ProcessSSE(ctx context.Context, body io.ReadCloser, ch chan<- provider.Stre
 	defer watched.Close()
 
 	var toolAcc ToolCallAccumulator
+	var usage *chunkUsage
 	sentMessageStart := false
 
 	scanner := bufio.NewScanner(watched)
@@ -152,7 +183,7 @@ func ProcessSSE(ctx context.Context, body io.ReadCloser, ch chan<- provider.Stre
 		if data == "[DONE]" {
 			toolAcc.Flush(ctx, ch)
 			select {
-			case ch <- provider.StreamEvent{Type: "stop"}:
+			case ch <- stopEvent(usage):
 			case <-ctx.Done():
 			}
 			return
@@ -181,6 +212,10 @@ func ProcessSSE(ctx context.Context, body io.ReadCloser, ch chan<- provider.Stre
 			}
 		}
 
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+
 		if len(chunk.Choices) == 0 {
 			continue
 		}
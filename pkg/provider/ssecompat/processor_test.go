@@ -7,8 +7,8 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/julianshen/rubichan/internal/provider"
 	"github.com/julianshen/rubichan/pkg/agentsdk"
+	"github.com/julianshen/rubichan/pkg/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -213,3 +213,38 @@ func TestProcessSSE_ScannerError(t *testing.T) {
 	assert.Equal(t, "openai-compat", pe.Provider)
 	assert.True(t, pe.IsRetryable())
 }
+
+func TestProcessSSE_UsageOnStop(t *testing.T) {
+	body := sseBody(
+		`data: {"id":"chatcmpl-1","model":"o3","choices":[{"delta":{"content":"hi"}}]}`,
+		`data: {"id":"chatcmpl-1","model":"o3","choices":[],"usage":{"prompt_tokens":1200,"completion_tokens":300,"prompt_tokens_details":{"cached_tokens":1000},"completion_tokens_details":{"reasoning_tokens":250}}}`,
+		`data: [DONE]`,
+	)
+
+	ch := make(chan provider.StreamEvent, 16)
+	ProcessSSE(context.Background(), body, ch, "test")
+	events := collect(ch)
+
+	last := events[len(events)-1]
+	require.Equal(t, "stop", last.Type)
+	assert.Equal(t, 200, last.InputTokens, "cached tokens are split out of prompt_tokens")
+	assert.Equal(t, 1000, last.CacheReadTokens)
+	assert.Equal(t, 300, last.OutputTokens)
+	assert.Equal(t, 250, last.ReasoningTokens)
+}
+
+func TestProcessSSE_NoUsageLeavesStopEmpty(t *testing.T) {
+	body := sseBody(
+		`data: {"id":"chatcmpl-1","model":"gpt-4o","choices":[{"delta":{"content":"hi"}}]}`,
+		`data: [DONE]`,
+	)
+
+	ch := make(chan provider.StreamEvent, 16)
+	ProcessSSE(context.Background(), body, ch, "test")
+	events := collect(ch)
+
+	last := events[len(events)-1]
+	require.Equal(t, "stop", last.Type)
+	assert.Zero(t, last.InputTokens)
+	assert.Zero(t, last.OutputTokens)
+}
@@ -5,7 +5,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/julianshen/rubichan/internal/provider"
+	"github.com/julianshen/rubichan/pkg/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
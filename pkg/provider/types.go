@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// Type aliases so provider implementations can spell the wire types as
+// provider.Message etc. Canonical definitions live in pkg/agentsdk/.
+
+type LLMProvider = agentsdk.LLMProvider
+type CompletionRequest = agentsdk.CompletionRequest
+type Message = agentsdk.Message
+type ContentBlock = agentsdk.ContentBlock
+type ToolDef = agentsdk.ToolDef
+type ToolUseBlock = agentsdk.ToolUseBlock
+type StreamEvent = agentsdk.StreamEvent
+
+// NewUserMessage creates a new user message with a single text content block.
+func NewUserMessage(text string) Message {
+	return agentsdk.NewUserMessage(text)
+}
+
+// NewToolResultMessage creates a new tool result message; see
+// agentsdk.NewToolResultMessage.
+func NewToolResultMessage(toolUseID, content string, isError bool, media ...ContentBlock) Message {
+	return agentsdk.NewToolResultMessage(toolUseID, content, isError, media...)
+}
@@ -7,53 +7,11 @@ import (
 	"io"
 	"net/http"
 
-	"github.com/julianshen/rubichan/internal/config"
-	"github.com/julianshen/rubichan/internal/provider"
-	"github.com/julianshen/rubichan/internal/provider/openai"
-	"github.com/julianshen/rubichan/internal/provider/ssecompat"
+	"github.com/julianshen/rubichan/pkg/provider"
+	"github.com/julianshen/rubichan/pkg/provider/openai"
+	"github.com/julianshen/rubichan/pkg/provider/ssecompat"
 )
 
-func init() {
-	provider.Default.Register(providerDef())
-}
-
-// providerDef describes this provider's construction, auth, and default
-// model for provider.Default. Exposed as a function so tests can exercise
-// it directly, isolated from the shared provider.Default registry.
-func providerDef() provider.ProviderDef {
-	return provider.ProviderDef{
-		ID: "zai",
-		Constructor: func(baseURL, apiKey string, extraHeaders map[string]string) provider.LLMProvider {
-			// New defaults a blank model to glm-5; repeating the literal here
-			// would make the same default live in three places.
-			return New(baseURL, apiKey, "", extraHeaders)
-		},
-		BaseURL: func(cfg *config.Config) string {
-			if cfg.Provider.Zai.BaseURL != "" {
-				return cfg.Provider.Zai.BaseURL
-			}
-			return "https://api.z.ai/api/coding/paas/v4"
-		},
-		Auth: func(cfg *config.Config) (string, map[string]string, error) {
-			apiKey, err := config.ResolveAPIKey(
-				cfg.Provider.Zai.APIKeySource,
-				cfg.Provider.Zai.APIKey,
-				"Z_AI_API_KEY",
-			)
-			if err != nil {
-				return "", nil, fmt.Errorf("resolving Z.ai API key: %w", err)
-			}
-			return apiKey, nil, nil
-		},
-		DefaultModel: func(_ context.Context, cfg *config.Config) (string, error) {
-			if cfg.Provider.Zai.Model != "" {
-				return cfg.Provider.Zai.Model, nil
-			}
-			return "glm-5", nil
-		},
-	}
-}
-
 // Provider implements the LLMProvider interface for Z.ai API.
 type Provider struct {
 	baseURL      string
@@ -84,6 +42,9 @@ func New(baseURL, apiKey, model string, extraHeaders map[string]string) *Provide
 		model:        model,
 		extraHeaders: extraHeaders,
 		client:       provider.NewHTTPClient(),
+		// GLM vision models take image_url parts but not inline file
+		// data, so PDFs degrade to a placeholder.
+		transformer: openai.Transformer{Quirks: openai.Quirks{NoDocuments: true, NoStreamUsage: true}},
 	}
 }
 
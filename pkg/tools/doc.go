@@ -0,0 +1,11 @@
+// Package tools provides the core tools of the rubichan agent — file,
+// search, shell and background processes — as agentsdk.Tool
+// implementations that can be registered with any agentsdk.Registry.
+// Git tools live in pkg/tools/git and the shell sandbox in
+// pkg/tools/sandbox.
+//
+//	reg := agentsdk.NewRegistry()
+//	reg.Register(tools.NewFileTool(root))
+//	reg.Register(tools.NewSearchTool(root))
+//	reg.Register(tools.NewShellTool(root, 2*time.Minute))
+package tools
@@ -8,7 +8,7 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/pkg/tools"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -11,7 +11,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/julianshen/rubichan/internal/tools"
+	"github.com/julianshen/rubichan/pkg/tools"
 )
 
 const commandTimeout = 30 * time.Second
@@ -10,8 +10,8 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/julianshen/rubichan/internal/tools"
-	"github.com/julianshen/rubichan/internal/tools/sandbox"
+	"github.com/julianshen/rubichan/pkg/tools"
+	"github.com/julianshen/rubichan/pkg/tools/sandbox"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,8 +14,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/julianshen/rubichan/internal/config"
-	"github.com/julianshen/rubichan/internal/tools/sandbox"
+	"github.com/julianshen/rubichan/pkg/tools/sandbox"
 )
 
 // maxShellTimeout is the maximum allowed per-command timeout (10 minutes).
@@ -95,7 +94,7 @@ type ShellTool struct {
 	diffTracker    *DiffTracker
 	sandbox        ShellSandbox
 	processManager *ProcessManager
-	sandboxCfg     config.SandboxConfig
+	sandboxCfg     sandbox.Config
 	domainProxy    *sandbox.DomainProxy // nil when not configured
 }
 
@@ -121,7 +120,7 @@ func (s *ShellTool) SetSandbox(sb ShellSandbox) {
 }
 
 // SetSandboxConfig attaches sandbox configuration and an optional domain proxy.
-func (s *ShellTool) SetSandboxConfig(cfg config.SandboxConfig, proxy *sandbox.DomainProxy) {
+func (s *ShellTool) SetSandboxConfig(cfg sandbox.Config, proxy *sandbox.DomainProxy) {
 	s.sandboxCfg = cfg
 	s.domainProxy = proxy
 
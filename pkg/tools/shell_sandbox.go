@@ -12,7 +12,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/pkg/tools/sandbox"
 )
 
 // ShellSandbox wraps shell executions in an OS-specific sandbox backend.
@@ -64,7 +64,7 @@ func DefaultShellSandboxPolicy(workDir string) ShellSandboxPolicy {
 
 // BuildSandboxPolicy creates a ShellSandboxPolicy from defaults plus config overrides.
 // Config paths are appended to defaults, not replacing them.
-func BuildSandboxPolicy(workDir string, cfg config.SandboxConfig) ShellSandboxPolicy {
+func BuildSandboxPolicy(workDir string, cfg sandbox.Config) ShellSandboxPolicy {
 	policy := DefaultShellSandboxPolicy(workDir)
 	policy.ProxyPort = cfg.Network.ProxyPort
 	policy.WritablePaths = append(policy.WritablePaths, normalizeSandboxPaths(cfg.Filesystem.AllowWrite)...)
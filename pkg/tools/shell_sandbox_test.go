@@ -11,7 +11,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/julianshen/rubichan/internal/config"
+	"github.com/julianshen/rubichan/pkg/tools/sandbox"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -242,12 +242,12 @@ func TestSandboxCommandEnvNoProxy(t *testing.T) {
 }
 
 func TestBuildSandboxPolicyMergesConfig(t *testing.T) {
-	cfg := config.SandboxConfig{
-		Filesystem: config.SandboxFilesystemConfig{
+	cfg := sandbox.Config{
+		Filesystem: sandbox.FilesystemConfig{
 			AllowWrite: []string{"/opt/custom"},
 			DenyRead:   []string{"/etc/secrets"},
 		},
-		Network: config.SandboxNetworkConfig{ProxyPort: 9999},
+		Network: sandbox.NetworkConfig{ProxyPort: 9999},
 	}
 	policy := BuildSandboxPolicy("/project", cfg)
 
@@ -258,7 +258,7 @@ func TestBuildSandboxPolicyMergesConfig(t *testing.T) {
 }
 
 func TestBuildSandboxPolicyDefaults(t *testing.T) {
-	policy := BuildSandboxPolicy("/project", config.SandboxConfig{})
+	policy := BuildSandboxPolicy("/project", sandbox.Config{})
 	assert.Contains(t, policy.WritablePaths, filepath.Clean("/project"))
 	assert.Equal(t, 0, policy.ProxyPort)
 	assert.Empty(t, policy.DeniedPaths)
@@ -289,7 +289,7 @@ func TestShellToolExcludedCommandBypassesSandbox(t *testing.T) {
 	st := NewShellTool(t.TempDir(), 30*time.Second)
 	recorder := &recordingSandbox{}
 	st.SetSandbox(recorder)
-	st.SetSandboxConfig(config.SandboxConfig{
+	st.SetSandboxConfig(sandbox.Config{
 		ExcludedCommands: []string{"echo"},
 	}, nil)
 
@@ -304,7 +304,7 @@ func TestShellToolHardLockdownBlocksFallback(t *testing.T) {
 	st := NewShellTool(t.TempDir(), 30*time.Second)
 	st.SetSandbox(&recordingSandbox{err: errors.New("sandbox unavailable")})
 	f := false
-	st.SetSandboxConfig(config.SandboxConfig{
+	st.SetSandboxConfig(sandbox.Config{
 		AllowUnsandboxedCommands: &f,
 	}, nil)
 
@@ -319,7 +319,7 @@ func TestShellToolExcludedSudoCommand(t *testing.T) {
 	st := NewShellTool(t.TempDir(), 30*time.Second)
 	recorder := &recordingSandbox{}
 	st.SetSandbox(recorder)
-	st.SetSandboxConfig(config.SandboxConfig{
+	st.SetSandboxConfig(sandbox.Config{
 		ExcludedCommands: []string{"echo"},
 	}, nil)
 
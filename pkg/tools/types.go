@@ -0,0 +1,22 @@
+package tools
+
+import (
+	"github.com/julianshen/rubichan/pkg/agentsdk"
+)
+
+// Type aliases so tool implementations can spell the SDK types as
+// tools.ToolResult etc. Canonical definitions live in pkg/agentsdk/.
+
+type Tool = agentsdk.Tool
+type ToolResult = agentsdk.ToolResult
+type ToolEvent = agentsdk.ToolEvent
+type ToolEventEmitter = agentsdk.ToolEventEmitter
+type ConcurrencySafeTool = agentsdk.ConcurrencySafeTool
+type ResultCapped = agentsdk.ResultCapped
+type StreamingTool = agentsdk.StreamingTool
+
+const (
+	EventBegin = agentsdk.EventBegin
+	EventDelta = agentsdk.EventDelta
+	EventEnd   = agentsdk.EventEnd
+)